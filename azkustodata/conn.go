@@ -14,10 +14,13 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 	"unicode"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/internal/response"
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
 	truestedEndpoints "github.com/Azure/azure-kusto-go/azkustodata/trusted_endpoints"
 	"github.com/google/uuid"
 )
@@ -82,13 +85,13 @@ type connOptions struct {
 	queryOptions *queryOptions
 }
 
-func (c *Conn) rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (io.ReadCloser, error) {
-	_, _, _, body, e := c.doRequest(ctx, int(callType), db, query, *options.requestProperties)
+func (c *Conn) rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (http.Header, io.ReadCloser, error) {
+	_, _, headers, body, e := c.doRequest(ctx, int(callType), db, query, *options.requestProperties)
 	if e != nil {
-		return nil, e
+		return nil, nil, e
 	}
 
-	return body, nil
+	return headers, body, nil
 }
 
 const (
@@ -99,7 +102,7 @@ const (
 func (c *Conn) doRequest(ctx context.Context, execType int, db string, query Statement, properties requestProperties) (errors.Op, http.Header, http.Header,
 	io.ReadCloser, error) {
 	var op errors.Op
-	err := c.validateEndpoint()
+	err := c.validateEndpoint(ctx)
 	if err != nil {
 		op = errors.OpQuery
 		return 0, nil, nil, nil, errors.E(op, errors.KInternal, fmt.Errorf("could not validate endpoint: %w", err))
@@ -173,10 +176,15 @@ func (c *Conn) doRequestImpl(
 		}
 	}
 
+	clientRequestID := headers.Get(ClientRequestIdHeader)
+	tracing.Inject(ctx, headers, clientRequestID)
+	log := logger.FromContext(ctx)
+
 	if c.auth.TokenProvider != nil && c.auth.TokenProvider.AuthorizationRequired() {
 		c.auth.TokenProvider.SetHttp(c.client)
 		token, tokenType, tkerr := c.auth.TokenProvider.AcquireToken(ctx)
 		if tkerr != nil {
+			log.Debug("could not acquire a token for a call to the service", "op", op.String(), "clientRequestID", clientRequestID, "error", tkerr)
 			return nil, nil, errors.ES(op, errors.KInternal, "Error while getting token : %s", tkerr)
 		}
 		headers.Add("Authorization", fmt.Sprintf("%s %s", tokenType, token))
@@ -189,8 +197,11 @@ func (c *Conn) doRequestImpl(
 		Body:   buff,
 	}
 
+	log.Debug("calling the service", "op", op.String(), "url", endpoint.String(), "clientRequestID", clientRequestID)
+	start := time.Now()
 	resp, err := c.client.Do(req.WithContext(ctx))
 	if err != nil {
+		log.Debug("a call to the service failed", "op", op.String(), "clientRequestID", clientRequestID, "error", err)
 		// TODO(jdoak): We need a http error unwrap function that pulls out an *errors.Error.
 		return nil, nil, errors.E(op, errors.KHTTPError, fmt.Errorf("%v, %w", errorContext, err))
 	}
@@ -200,13 +211,20 @@ func (c *Conn) doRequestImpl(
 		return nil, nil, err
 	}
 
+	log.Debug("the service responded", "op", op.String(), "clientRequestID", clientRequestID, "status", resp.StatusCode,
+		"activityID", resp.Header.Get(ActivityIdHeader), "duration", time.Since(start))
 	if resp.StatusCode != http.StatusOK {
-		return nil, nil, errors.HTTP(op, resp.Status, resp.StatusCode, body, fmt.Sprintf("error from Kusto endpoint, %v", errorContext))
+		log.Debug("the service returned an error", "op", op.String(), "clientRequestID", clientRequestID, "status", resp.StatusCode,
+			"activityID", resp.Header.Get(ActivityIdHeader))
+		httpErr := errors.HTTP(op, resp.Status, resp.StatusCode, body, fmt.Sprintf("error from Kusto endpoint, %v", errorContext))
+		httpErr.ClientRequestID = clientRequestID
+		httpErr.ActivityID = resp.Header.Get(ActivityIdHeader)
+		return nil, nil, httpErr
 	}
 	return resp.Header, body, nil
 }
 
-func (c *Conn) validateEndpoint() error {
+func (c *Conn) validateEndpoint(ctx context.Context) error {
 	if !c.endpointValidated.Load() {
 		var err error
 		if cloud, err := GetMetadata(c.endpoint, c.client); err == nil {
@@ -214,6 +232,9 @@ func (c *Conn) validateEndpoint() error {
 			if err == nil {
 				c.endpointValidated.Store(true)
 			}
+		} else {
+			// The call goes on, and the endpoint is validated again with the next one.
+			logger.FromContext(ctx).Warn("could not fetch the metadata of the cluster to validate its endpoint", "endpoint", c.endpoint, "error", err)
 		}
 
 		return err
@@ -223,6 +244,7 @@ func (c *Conn) validateEndpoint() error {
 }
 
 const ClientRequestIdHeader = "x-ms-client-request-id"
+const ActivityIdHeader = "x-ms-activity-id"
 const ApplicationHeader = "x-ms-app"
 const UserHeader = "x-ms-user"
 const ClientVersionHeader = "x-ms-client-version"
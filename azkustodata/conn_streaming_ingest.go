@@ -68,6 +68,10 @@ func (c *Conn) StreamIngest(ctx context.Context, db, table string, payload io.Re
 	}
 
 	if err != nil {
+		// Errors from the service are kept as is, so callers can look at their status code and whether they are permanent.
+		if httpErr, ok := err.(*errors.HttpError); ok {
+			return httpErr
+		}
 		return errors.ES(errors.OpIngestStream, errors.KHTTPError, "streaming ingestion failed: endpoint(%s): %s", streamUrl.String(), err)
 	}
 
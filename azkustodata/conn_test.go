@@ -221,6 +221,7 @@ func TestTimeout(t *testing.T) {
 		callType              int
 		queryOptions          []QueryOption
 		expectedServerTimeout time.Duration
+		wantErr               bool
 	}{
 		{
 			name:                  "TestDefaultQuery",
@@ -254,6 +255,39 @@ func TestTimeout(t *testing.T) {
 			callType:              queryCall,
 			expectedServerTimeout: 250 * time.Second,
 		},
+		{
+			name:                  "ContextWithGrace",
+			ctx:                   newContextWithTimeout(15 * time.Second),
+			queryOptions:          []QueryOption{ServerTimeoutGrace(5 * time.Second)},
+			callType:              queryCall,
+			expectedServerTimeout: 10 * time.Second,
+		},
+		{
+			name:         "ContextWithinGrace",
+			ctx:          newContextWithTimeout(5 * time.Second),
+			queryOptions: []QueryOption{ServerTimeoutGrace(5 * time.Second)},
+			callType:     queryCall,
+			wantErr:      true,
+		},
+		{
+			name:     "ContextPassed",
+			ctx:      newContextWithTimeout(-time.Second),
+			callType: queryCall,
+			wantErr:  true,
+		},
+		{
+			name:                  "ContextClamped",
+			ctx:                   newContextWithTimeout(2 * time.Hour),
+			callType:              mgmtCall,
+			expectedServerTimeout: maxServerTimeout,
+		},
+		{
+			name:                  "OptionClamped",
+			ctx:                   context.Background(),
+			queryOptions:          []QueryOption{ServerTimeout(3 * time.Hour)},
+			callType:              queryCall,
+			expectedServerTimeout: maxServerTimeout,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt // Capture
@@ -261,9 +295,35 @@ func TestTimeout(t *testing.T) {
 			t.Parallel()
 
 			opts, err := setQueryOptions(tt.ctx, errors.OpUnknown, kql.New("test"), tt.callType, tt.queryOptions...)
+			if tt.wantErr {
+				require.Error(t, err)
+				kustoErr, ok := errors.GetKustoError(err)
+				require.True(t, ok)
+				assert.Equal(t, errors.KTimeout, kustoErr.Kind)
+				return
+			}
 			require.NoError(t, err)
 
 			require.Equal(t, value.TimespanString(tt.expectedServerTimeout), opts.requestProperties.Options[ServerTimeoutValue])
 		})
 	}
 }
+
+func TestServerTimeoutClamped(t *testing.T) {
+	t.Parallel()
+
+	var requested, clamped time.Duration
+	opts, err := setQueryOptions(context.Background(), errors.OpQuery, kql.New("test"), queryCall,
+		ServerTimeout(90*time.Minute),
+		OnServerTimeoutClamped(func(r, c time.Duration) {
+			requested, clamped = r, c
+		}))
+	require.NoError(t, err)
+
+	assert.Equal(t, value.TimespanString(time.Hour), opts.requestProperties.Options[ServerTimeoutValue])
+	assert.Equal(t, 90*time.Minute, requested)
+	assert.Equal(t, time.Hour, clamped)
+
+	_, err = setQueryOptions(context.Background(), errors.OpQuery, kql.New("test"), queryCall, ServerTimeoutGrace(-time.Second))
+	assert.Error(t, err)
+}
@@ -71,6 +71,10 @@ type Error struct {
 	Kind Kind
 	// Err is the error message. This may be of any error type and may also wrap errors.
 	Err error
+	// ClientRequestID is the x-ms-client-request-id of the call to the service the error came from, if any.
+	ClientRequestID string
+	// ActivityID is the x-ms-activity-id the service responded with to the call the error came from, if it responded.
+	ActivityID string
 
 	// restErrMsg holds the body of an error messsage that was from a REST endpoint.
 	restErrMsg []byte
@@ -176,7 +180,13 @@ func (e *Error) Error() string {
 
 // Retry determines if the error is transient and the action can be retried or not.
 // Some errors that can be retried, such as a timeout, may never succeed, so avoid infinite retries.
+// The outermost *Error decides first, and an *HttpError it wraps only decides if it doesn't.
 func Retry(err error) bool {
+	var httpErr *HttpError
+	if h, ok := err.(*HttpError); ok {
+		return Retry(&h.KustoError)
+	}
+
 	var e *Error
 	if errors.As(err, &e) {
 		// e.permanent can be set multiple ways. If it is true, you can never retry.
@@ -200,8 +210,14 @@ func Retry(err error) bool {
 		if e.inner != nil {
 			return Retry(e.inner)
 		}
+		if errors.As(e.Err, &httpErr) {
+			return Retry(&httpErr.KustoError)
+		}
 		return true
 	}
+	if errors.As(err, &httpErr) {
+		return Retry(&httpErr.KustoError)
+	}
 	return false
 }
 
@@ -319,6 +335,7 @@ func (e *HttpError) Unwrap() error {
 	return e.KustoError.Unwrap()
 }
 
+// GetKustoError returns the *Error of err, which is err itself, the error of an *HttpError, or the first of them that err wraps.
 func GetKustoError(err error) (*Error, bool) {
 	if err, ok := err.(*Error); ok {
 		return err, true
@@ -326,6 +343,14 @@ func GetKustoError(err error) (*Error, bool) {
 	if err, ok := err.(*HttpError); ok {
 		return &err.KustoError, true
 	}
+	var httpErr *HttpError
+	var kustoErr *Error
+	switch {
+	case errors.As(err, &httpErr):
+		return &httpErr.KustoError, true
+	case errors.As(err, &kustoErr):
+		return kustoErr, true
+	}
 	return nil, false
 }
 
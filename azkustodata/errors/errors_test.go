@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 	"testing"
 	"time"
 
@@ -73,6 +74,39 @@ func TestRetry(t *testing.T) {
 			err:  fmt.Errorf("blah"),
 			want: false,
 		},
+		{
+			desc: "http error",
+			err:  HTTP(OpIngestStream, "429 Too Many Requests", 429, io.NopCloser(strings.NewReader(`{"error": {"@permanent": false}}`)), "throttled"),
+			want: true,
+		},
+		{
+			desc: "permanent http error",
+			err:  HTTP(OpIngestStream, "400 Bad Request", 400, io.NopCloser(strings.NewReader(`{"error": {"@permanent": true}}`)), "bad request"),
+			want: false,
+		},
+		{
+			desc: "permanent wrapper of a retryable http error",
+			err: E(OpIngestStream, KHTTPError,
+				HTTP(OpIngestStream, "429 Too Many Requests", 429, io.NopCloser(strings.NewReader(`{"error": {"@permanent": false}}`)), "throttled")).SetNoRetry(),
+			want: false,
+		},
+		{
+			desc: "wrapper of a retryable http error",
+			err: E(OpIngestStream, KHTTPError,
+				HTTP(OpIngestStream, "429 Too Many Requests", 429, io.NopCloser(strings.NewReader(`{"error": {"@permanent": false}}`)), "throttled")),
+			want: true,
+		},
+		{
+			desc: "wrapper of a permanent http error",
+			err: E(OpIngestStream, KHTTPError,
+				HTTP(OpIngestStream, "400 Bad Request", 400, io.NopCloser(strings.NewReader(`{"error": {"@permanent": true}}`)), "bad request")),
+			want: false,
+		},
+		{
+			desc: "http error wrapped by a standard error",
+			err:  fmt.Errorf("streaming: %w", HTTP(OpIngestStream, "429 Too Many Requests", 429, io.NopCloser(strings.NewReader(`{"error": {"@permanent": false}}`)), "throttled")),
+			want: true,
+		},
 		{
 			desc: "permanent was set",
 			err:  &Error{permanent: true},
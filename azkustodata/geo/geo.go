@@ -0,0 +1,257 @@
+// Package geo converts the values of Kusto's geospatial functions into structured Go types.
+//
+// Kusto represents shapes as GeoJSON in dynamic columns (for example, the output of geo_point_to_geojson or the input of
+// geo_point_in_polygon), and S2 cells as string tokens (the output of geo_point_to_s2cell).
+// This package parses those representations, as well as well-known-text (WKT), into Point, LineString, Polygon and
+// MultiPolygon values.
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Geometry is implemented by all the shapes in this package.
+type Geometry interface {
+	// Type returns the GeoJSON type of the shape.
+	Type() string
+	// WKT returns the well-known-text representation of the shape.
+	WKT() string
+	isGeometry()
+}
+
+// Point is a location on Earth, in degrees. Like GeoJSON, the longitude comes first.
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// LineString is a sequence of points.
+type LineString []Point
+
+// Polygon is made of linear rings, where the first ring is the shell and the others are holes.
+// Every ring is closed - its first and last points are equal.
+type Polygon [][]Point
+
+// MultiPolygon is a collection of polygons.
+type MultiPolygon []Polygon
+
+func (Point) Type() string        { return "Point" }
+func (LineString) Type() string   { return "LineString" }
+func (Polygon) Type() string      { return "Polygon" }
+func (MultiPolygon) Type() string { return "MultiPolygon" }
+
+func (Point) isGeometry()        {}
+func (LineString) isGeometry()   {}
+func (Polygon) isGeometry()      {}
+func (MultiPolygon) isGeometry() {}
+
+// Validate checks that the point's coordinates are in range.
+func (p Point) Validate() error {
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("longitude %v is out of range [-180, 180]", p.Lon)
+	}
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v is out of range [-90, 90]", p.Lat)
+	}
+	return nil
+}
+
+// geoJSON is the wire representation of a GeoJSON geometry.
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ParseGeoJSON parses a GeoJSON Point, LineString, Polygon or MultiPolygon.
+func ParseGeoJSON(b []byte) (Geometry, error) {
+	var g geoJSON
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	switch g.Type {
+	case "Point":
+		var c []float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON Point coordinates: %w", err)
+		}
+		return toPoint(c)
+	case "LineString":
+		var c [][]float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON LineString coordinates: %w", err)
+		}
+		return toPoints(c)
+	case "Polygon":
+		var c [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON Polygon coordinates: %w", err)
+		}
+		return toPolygon(c)
+	case "MultiPolygon":
+		var c [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON MultiPolygon coordinates: %w", err)
+		}
+		mp := make(MultiPolygon, 0, len(c))
+		for _, pc := range c {
+			p, err := toPolygon(pc)
+			if err != nil {
+				return nil, err
+			}
+			mp = append(mp, p)
+		}
+		return mp, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %q", g.Type)
+	}
+}
+
+// FromDynamic parses a GeoJSON shape stored in a dynamic column.
+func FromDynamic(d *value.Dynamic) (Geometry, error) {
+	if d == nil || d.Value == nil {
+		return nil, fmt.Errorf("dynamic value is null")
+	}
+	return ParseGeoJSON(d.Value)
+}
+
+func toPoint(c []float64) (Point, error) {
+	if len(c) < 2 {
+		return Point{}, fmt.Errorf("a position must have at least 2 coordinates, got %d", len(c))
+	}
+	p := Point{Lon: c[0], Lat: c[1]}
+	return p, p.Validate()
+}
+
+func toPoints(c [][]float64) (LineString, error) {
+	ls := make(LineString, 0, len(c))
+	for _, pc := range c {
+		p, err := toPoint(pc)
+		if err != nil {
+			return nil, err
+		}
+		ls = append(ls, p)
+	}
+	return ls, nil
+}
+
+func toPolygon(c [][][]float64) (Polygon, error) {
+	poly := make(Polygon, 0, len(c))
+	for _, rc := range c {
+		ring, err := toPoints(rc)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateRing(ring); err != nil {
+			return nil, err
+		}
+		poly = append(poly, ring)
+	}
+	return poly, nil
+}
+
+func validateRing(ring []Point) error {
+	if len(ring) < 4 {
+		return fmt.Errorf("a linear ring must have at least 4 points, got %d", len(ring))
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return fmt.Errorf("a linear ring must be closed")
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, producing GeoJSON.
+func (p Point) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(p.Type(), position(p))
+}
+
+// MarshalJSON implements json.Marshaler, producing GeoJSON.
+func (ls LineString) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(ls.Type(), positions(ls))
+}
+
+// MarshalJSON implements json.Marshaler, producing GeoJSON.
+func (p Polygon) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(p.Type(), polygonPositions(p))
+}
+
+// MarshalJSON implements json.Marshaler, producing GeoJSON.
+func (mp MultiPolygon) MarshalJSON() ([]byte, error) {
+	c := make([][][][]float64, 0, len(mp))
+	for _, p := range mp {
+		c = append(c, polygonPositions(p))
+	}
+	return marshalGeoJSON(mp.Type(), c)
+}
+
+func marshalGeoJSON(t string, coordinates interface{}) ([]byte, error) {
+	c, err := json.Marshal(coordinates)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geoJSON{Type: t, Coordinates: c})
+}
+
+func position(p Point) []float64 {
+	return []float64{p.Lon, p.Lat}
+}
+
+func positions(ps []Point) [][]float64 {
+	c := make([][]float64, 0, len(ps))
+	for _, p := range ps {
+		c = append(c, position(p))
+	}
+	return c
+}
+
+func polygonPositions(p Polygon) [][][]float64 {
+	c := make([][][]float64, 0, len(p))
+	for _, ring := range p {
+		c = append(c, positions(ring))
+	}
+	return c
+}
+
+// S2Cell is an S2 cell token, as returned by geo_point_to_s2cell.
+type S2Cell string
+
+// ParseS2Cell validates an S2 cell token.
+func ParseS2Cell(token string) (S2Cell, error) {
+	c := S2Cell(token)
+	if _, err := c.ID(); err != nil {
+		return "", err
+	}
+	return c, nil
+}
+
+// ID returns the 64-bit S2 cell id the token represents.
+func (c S2Cell) ID() (uint64, error) {
+	if len(c) == 0 || len(c) > 16 {
+		return 0, fmt.Errorf("invalid S2 cell token %q", string(c))
+	}
+	id, err := strconv.ParseUint(string(c), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid S2 cell token %q: %w", string(c), err)
+	}
+	id <<= 4 * uint(16-len(c))
+	// A valid id has its lowest set bit at an even position, and a face number between 0 and 5 in its top 3 bits.
+	if id == 0 || bits.TrailingZeros64(id)%2 != 0 || id>>61 > 5 {
+		return 0, fmt.Errorf("invalid S2 cell token %q", string(c))
+	}
+	return id, nil
+}
+
+// Level returns the level of the cell, from 0 (a cube face) to 30 (the smallest cells).
+func (c S2Cell) Level() (int, error) {
+	id, err := c.ID()
+	if err != nil {
+		return 0, err
+	}
+	return 30 - bits.TrailingZeros64(id)/2, nil
+}
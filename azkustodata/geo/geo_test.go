@@ -0,0 +1,128 @@
+package geo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var square = Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}}
+
+func TestParseGeoJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		in   string
+		want Geometry
+		err  bool
+	}{
+		{desc: "point", in: `{"type":"Point","coordinates":[-122.13, 47.64]}`, want: Point{Lon: -122.13, Lat: 47.64}},
+		{desc: "line", in: `{"type":"LineString","coordinates":[[0,0],[1,1]]}`, want: LineString{{0, 0}, {1, 1}}},
+		{desc: "polygon", in: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`, want: square},
+		{desc: "multipolygon", in: `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,1],[0,0]]]]}`, want: MultiPolygon{square}},
+		{desc: "out of range", in: `{"type":"Point","coordinates":[200, 0]}`, err: true},
+		{desc: "open ring", in: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, err: true},
+		{desc: "unsupported", in: `{"type":"GeometryCollection","coordinates":[]}`, err: true},
+		{desc: "invalid", in: `[`, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseGeoJSON([]byte(test.in))
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+
+			// Round trip through GeoJSON.
+			b, err := json.Marshal(got)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.in, string(b))
+		})
+	}
+}
+
+func TestParseWKT(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		in   string
+		want Geometry
+		err  bool
+	}{
+		{desc: "point", in: "POINT (-122.13 47.64)", want: Point{Lon: -122.13, Lat: 47.64}},
+		{desc: "line", in: "LINESTRING (0 0, 1 1)", want: LineString{{0, 0}, {1, 1}}},
+		{desc: "polygon", in: "POLYGON ((0 0, 1 0, 1 1, 0 1, 0 0))", want: square},
+		{desc: "multipolygon", in: "MULTIPOLYGON (((0 0, 1 0, 1 1, 0 1, 0 0)))", want: MultiPolygon{square}},
+		{desc: "lowercase and spacing", in: " point( 1  2 ) ", want: Point{Lon: 1, Lat: 2}},
+		{desc: "bad number", in: "POINT (a 1)", err: true},
+		{desc: "trailing data", in: "POINT (1 1) x", err: true},
+		{desc: "unterminated", in: "POINT (1 1", err: true},
+		{desc: "unsupported", in: "CIRCLE (1 1)", err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseWKT(test.in)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+
+			again, err := ParseWKT(got.WKT())
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func TestS2Cell(t *testing.T) {
+	t.Parallel()
+
+	c, err := ParseS2Cell("89c259")
+	require.NoError(t, err)
+	id, err := c.ID()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x89c2590000000000), id)
+	level, err := c.Level()
+	require.NoError(t, err)
+	assert.Equal(t, 10, level)
+
+	for _, bad := range []string{"", "0", "zz", "89c2", "c", "89c2590000000000ff"} {
+		_, err := ParseS2Cell(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestShapeScanKusto(t *testing.T) {
+	t.Parallel()
+
+	var s Shape
+	require.NoError(t, s.ScanKusto(value.NewDynamic([]byte(`{"type":"Point","coordinates":[1,2]}`))))
+	p, ok := s.Point()
+	assert.True(t, ok)
+	assert.Equal(t, Point{Lon: 1, Lat: 2}, p)
+
+	require.NoError(t, s.ScanKusto(value.NewString("POLYGON ((0 0, 1 0, 1 1, 0 1, 0 0))")))
+	poly, ok := s.Polygon()
+	assert.True(t, ok)
+	assert.Equal(t, square, poly)
+
+	require.NoError(t, s.ScanKusto(value.NewNullDynamic()))
+	assert.Nil(t, s.Geometry)
+
+	assert.Error(t, s.ScanKusto(value.NewLong(1)))
+}
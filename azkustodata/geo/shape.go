@@ -0,0 +1,54 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Shape holds a geometry decoded from a Kusto value, and can be used as a struct field when decoding rows.
+// It accepts dynamic columns holding GeoJSON, and string columns holding either GeoJSON or WKT.
+// Geometry is nil if the value is null or empty.
+type Shape struct {
+	Geometry Geometry
+}
+
+// ScanKusto implements value.Scanner.
+func (s *Shape) ScanKusto(k value.Kusto) error {
+	s.Geometry = nil
+	if value.IsNull(k) {
+		return nil
+	}
+
+	var err error
+	switch v := k.(type) {
+	case *value.Dynamic:
+		s.Geometry, err = FromDynamic(v)
+	case *value.String:
+		text := strings.TrimSpace(v.Value)
+		switch {
+		case text == "":
+			return nil
+		case strings.HasPrefix(text, "{"):
+			s.Geometry, err = ParseGeoJSON([]byte(text))
+		default:
+			s.Geometry, err = ParseWKT(text)
+		}
+	default:
+		return fmt.Errorf("column of type %s can't hold a geometry", k.GetType())
+	}
+	return err
+}
+
+// Point returns the geometry as a point, if it is one.
+func (s Shape) Point() (Point, bool) {
+	p, ok := s.Geometry.(Point)
+	return p, ok
+}
+
+// Polygon returns the geometry as a polygon, if it is one.
+func (s Shape) Polygon() (Polygon, bool) {
+	p, ok := s.Geometry.(Polygon)
+	return p, ok
+}
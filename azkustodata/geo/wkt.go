@@ -0,0 +1,194 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WKT returns the well-known-text representation of the point.
+func (p Point) WKT() string {
+	return "POINT (" + wktPosition(p) + ")"
+}
+
+// WKT returns the well-known-text representation of the line.
+func (ls LineString) WKT() string {
+	return "LINESTRING " + wktPositions(ls)
+}
+
+// WKT returns the well-known-text representation of the polygon.
+func (p Polygon) WKT() string {
+	return "POLYGON " + wktPolygon(p)
+}
+
+// WKT returns the well-known-text representation of the polygons.
+func (mp MultiPolygon) WKT() string {
+	parts := make([]string, 0, len(mp))
+	for _, p := range mp {
+		parts = append(parts, wktPolygon(p))
+	}
+	return "MULTIPOLYGON (" + strings.Join(parts, ", ") + ")"
+}
+
+func wktPosition(p Point) string {
+	return strconv.FormatFloat(p.Lon, 'f', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+}
+
+func wktPositions(ps []Point) string {
+	parts := make([]string, 0, len(ps))
+	for _, p := range ps {
+		parts = append(parts, wktPosition(p))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func wktPolygon(p Polygon) string {
+	parts := make([]string, 0, len(p))
+	for _, ring := range p {
+		parts = append(parts, wktPositions(ring))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// ParseWKT parses the well-known-text representation of a POINT, LINESTRING, POLYGON or MULTIPOLYGON.
+func ParseWKT(s string) (Geometry, error) {
+	s = strings.TrimSpace(s)
+	open := strings.IndexByte(s, '(')
+	if open == -1 {
+		return nil, fmt.Errorf("invalid WKT %q: missing coordinates", s)
+	}
+	kind := strings.ToUpper(strings.TrimSpace(s[:open]))
+	body := s[open:]
+
+	l := &wktLexer{s: body}
+	switch kind {
+	case "POINT":
+		ps, err := l.positions()
+		if err != nil {
+			return nil, err
+		}
+		if len(ps) != 1 {
+			return nil, fmt.Errorf("invalid WKT point: expected a single position, got %d", len(ps))
+		}
+		return ps[0], l.end()
+	case "LINESTRING":
+		ps, err := l.positions()
+		if err != nil {
+			return nil, err
+		}
+		return LineString(ps), l.end()
+	case "POLYGON":
+		p, err := l.polygon()
+		if err != nil {
+			return nil, err
+		}
+		return p, l.end()
+	case "MULTIPOLYGON":
+		var mp MultiPolygon
+		err := l.list(func() error {
+			p, err := l.polygon()
+			mp = append(mp, p)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return mp, l.end()
+	default:
+		return nil, fmt.Errorf("unsupported WKT type %q", kind)
+	}
+}
+
+// wktLexer reads the parenthesized coordinate lists of WKT.
+type wktLexer struct {
+	s   string
+	pos int
+}
+
+func (l *wktLexer) skipSpaces() {
+	for l.pos < len(l.s) && (l.s[l.pos] == ' ' || l.s[l.pos] == '\t' || l.s[l.pos] == '\n' || l.s[l.pos] == '\r') {
+		l.pos++
+	}
+}
+
+func (l *wktLexer) expect(c byte) error {
+	l.skipSpaces()
+	if l.pos >= len(l.s) || l.s[l.pos] != c {
+		return fmt.Errorf("invalid WKT: expected '%c' at offset %d", c, l.pos)
+	}
+	l.pos++
+	return nil
+}
+
+// list reads "(item, item, ...)", calling item for each element.
+func (l *wktLexer) list(item func() error) error {
+	if err := l.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		l.skipSpaces()
+		if l.pos < len(l.s) && l.s[l.pos] == ',' {
+			l.pos++
+			continue
+		}
+		return l.expect(')')
+	}
+}
+
+func (l *wktLexer) positions() ([]Point, error) {
+	var ps []Point
+	err := l.list(func() error {
+		l.skipSpaces()
+		end := strings.IndexAny(l.s[l.pos:], ",)")
+		if end == -1 {
+			return fmt.Errorf("invalid WKT: unterminated position at offset %d", l.pos)
+		}
+		fields := strings.Fields(l.s[l.pos : l.pos+end])
+		if len(fields) < 2 {
+			return fmt.Errorf("invalid WKT: a position must have at least 2 coordinates at offset %d", l.pos)
+		}
+		c := make([]float64, 0, len(fields))
+		for _, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return fmt.Errorf("invalid WKT coordinate %q: %w", f, err)
+			}
+			c = append(c, v)
+		}
+		p, err := toPoint(c)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, p)
+		l.pos += end
+		return nil
+	})
+	return ps, err
+}
+
+func (l *wktLexer) polygon() (Polygon, error) {
+	var p Polygon
+	err := l.list(func() error {
+		ring, err := l.positions()
+		if err != nil {
+			return err
+		}
+		if err := validateRing(ring); err != nil {
+			return err
+		}
+		p = append(p, ring)
+		return nil
+	})
+	return p, err
+}
+
+func (l *wktLexer) end() error {
+	l.skipSpaces()
+	if l.pos != len(l.s) {
+		return fmt.Errorf("invalid WKT: unexpected %q at offset %d", l.s[l.pos:], l.pos)
+	}
+	return nil
+}
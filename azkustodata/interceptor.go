@@ -0,0 +1,146 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// Redacted replaces the secrets in the calls that interceptors see.
+const Redacted = "REDACTED"
+
+// Interceptor hooks into the HTTP calls of the client, to log them, add headers to them or capture traces, without replacing
+// the transport of the client. Each hook is optional, and the hooks of several interceptors are called in the order the
+// interceptors were added with WithInterceptor.
+//
+// The hooks see the calls with their secrets redacted: the Authorization header, and the signatures of the SAS tokens in the
+// URLs and the headers, such as those of the blobs the ingestion clients upload to, are replaced with Redacted. The bodies of
+// the calls aren't passed to the hooks.
+type Interceptor struct {
+	// OnRequest is called before each request is sent. The headers it sets on req are sent with the request, except for the
+	// Authorization header, which the client sets; its other changes are ignored.
+	OnRequest func(req *http.Request)
+	// OnResponse is called with each response, or with the error of the transport if the request failed, and the time
+	// since the request was sent. Its changes to resp are ignored.
+	OnResponse func(req *http.Request, resp *http.Response, err error, elapsed time.Duration)
+	// OnRetry is called by the ingestion clients before they retry a call that failed, such as a streaming ingestion that was
+	// throttled, with the number of the retry, starting at 1, and the error of the call it retries.
+	OnRetry func(ctx context.Context, op errors.Op, retry int, err error)
+}
+
+// WithInterceptor adds an interceptor to the HTTP calls of the client, which is called after the interceptors added before it.
+func WithInterceptor(interceptor Interceptor) Option {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptor)
+	}
+}
+
+// intercept returns a copy of client whose transport calls the interceptors, or client itself if there are none.
+func intercept(client *http.Client, interceptors []Interceptor) *http.Client {
+	if len(interceptors) == 0 {
+		return client
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	intercepted := *client
+	intercepted.Transport = &interceptingTransport{next: next, interceptors: interceptors}
+	return &intercepted
+}
+
+// interceptingTransport calls the interceptors around the round trips of next.
+type interceptingTransport struct {
+	next         http.RoundTripper
+	interceptors []Interceptor
+}
+
+func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	view := redactRequest(req)
+	for _, i := range t.interceptors {
+		if i.OnRequest != nil {
+			i.OnRequest(view)
+		}
+	}
+
+	out := req.Clone(req.Context())
+	applyHeaderChanges(out.Header, redactHeader(req.Header), view.Header)
+	if auth, ok := req.Header["Authorization"]; ok {
+		out.Header["Authorization"] = auth
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(out)
+	elapsed := time.Since(start)
+
+	var respView *http.Response
+	if resp != nil {
+		copied := *resp
+		copied.Header = redactHeader(resp.Header)
+		copied.Body = http.NoBody
+		copied.Request = view
+		respView = &copied
+	}
+	for _, i := range t.interceptors {
+		if i.OnResponse != nil {
+			i.OnResponse(view, respView, err, elapsed)
+		}
+	}
+
+	return resp, err
+}
+
+// sasSignature matches the signatures of SAS tokens.
+var sasSignature = regexp.MustCompile(`(?i)(\bsig=)[^&\s"']+`)
+
+// redactSAS replaces the signatures of the SAS tokens in s with Redacted.
+func redactSAS(s string) string {
+	return sasSignature.ReplaceAllString(s, "${1}"+Redacted)
+}
+
+// redactRequest returns a copy of req without its body, whose URL and headers are redacted.
+func redactRequest(req *http.Request) *http.Request {
+	view := req.Clone(req.Context())
+	view.Body = http.NoBody
+	view.GetBody = nil
+	view.Header = redactHeader(req.Header)
+	if view.URL != nil {
+		view.URL.RawQuery = redactSAS(view.URL.RawQuery)
+	}
+	return view
+}
+
+// redactHeader returns a copy of h whose Authorization header and SAS tokens are redacted.
+func redactHeader(h http.Header) http.Header {
+	redacted := h.Clone()
+	for key, values := range redacted {
+		if http.CanonicalHeaderKey(key) == "Authorization" {
+			values = []string{Redacted}
+		} else {
+			for i, v := range values {
+				values[i] = redactSAS(v)
+			}
+		}
+		redacted[key] = values
+	}
+	return redacted
+}
+
+// applyHeaderChanges applies to sent the changes from the redacted headers before to the headers after, leaving the values
+// that weren't changed, which may hold secrets, as they are.
+func applyHeaderChanges(sent, before, after http.Header) {
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			delete(sent, key)
+		}
+	}
+	for key, values := range after {
+		if !slices.Equal(before[key], values) {
+			sent[key] = values
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("x-ms-copy-source", "https://account.blob.core.windows.net/c/b?sv=2020&sig=secret")
+		_, _ = w.Write([]byte(testShowTablesResponse))
+	}))
+	defer server.Close()
+
+	var calls []string
+	var seen, seenResponse http.Header
+	var seenURL string
+	client := intercept(&http.Client{}, []Interceptor{
+		{
+			OnRequest: func(req *http.Request) {
+				calls = append(calls, "request 1")
+				seen = req.Header.Clone()
+				seenURL = req.URL.String()
+				req.Header.Set("x-custom", "value")
+				req.Header.Del("x-removed")
+				req.Header.Set("Authorization", "overwritten")
+			},
+			OnResponse: func(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+				calls = append(calls, "response 1")
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				seenResponse = resp.Header.Clone()
+			},
+		},
+		{
+			OnRequest: func(req *http.Request) {
+				calls = append(calls, "request 2")
+				assert.Equal(t, "value", req.Header.Get("x-custom"))
+			},
+			OnResponse: func(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+				calls = append(calls, "response 2")
+			},
+		},
+	})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/c/b?sv=2020&sig=secret", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("x-ms-copy-source", "https://account.blob.core.windows.net/c/b?sig=secret")
+	req.Header.Set("x-removed", "value")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, []string{"request 1", "request 2", "response 1", "response 2"}, calls)
+
+	// The hooks only see the redacted secrets.
+	assert.Equal(t, Redacted, seen.Get("Authorization"))
+	assert.Equal(t, "https://account.blob.core.windows.net/c/b?sig="+Redacted, seen.Get("x-ms-copy-source"))
+	assert.Equal(t, server.URL+"/c/b?sv=2020&sig="+Redacted, seenURL)
+	assert.Equal(t, "https://account.blob.core.windows.net/c/b?sv=2020&sig="+Redacted, seenResponse.Get("x-ms-copy-source"))
+
+	// The service gets the secrets and the changes of the hooks.
+	assert.Equal(t, "Bearer token", received.Get("Authorization"))
+	assert.Equal(t, "https://account.blob.core.windows.net/c/b?sig=secret", received.Get("x-ms-copy-source"))
+	assert.Equal(t, "value", received.Get("x-custom"))
+	assert.Empty(t, received.Get("x-removed"))
+	// The request of the caller isn't changed.
+	assert.Equal(t, "value", req.Header.Get("x-removed"))
+	assert.Empty(t, req.Header.Get("x-custom"))
+}
+
+func TestInterceptorOfClient(t *testing.T) {
+	t.Parallel()
+
+	var custom string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/rest/mgmt" {
+			custom = r.Header.Get("x-custom")
+		}
+		_, _ = w.Write([]byte(testShowTablesResponse))
+	}))
+	defer server.Close()
+
+	var requests []string
+	var failed error
+	client, err := New(NewConnectionStringBuilder(server.URL), WithInterceptor(Interceptor{
+		OnRequest: func(req *http.Request) {
+			requests = append(requests, req.URL.Path)
+			req.Header.Set("x-custom", req.Header.Get(ClientRequestIdHeader))
+		},
+		OnResponse: func(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+			failed = err
+		},
+	}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("id"))
+	require.NoError(t, err)
+	assert.Contains(t, requests, "/v1/rest/mgmt")
+	assert.Equal(t, "id", custom)
+	assert.NoError(t, failed)
+}
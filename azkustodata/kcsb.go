@@ -41,6 +41,7 @@ type ConnectionStringBuilder struct {
 	ApplicationForTracing            string
 	UserForTracing                   string
 	TokenCredential                  azcore.TokenCredential
+	Logger                           Logger
 }
 
 const (
@@ -413,6 +414,15 @@ func (kcsb *ConnectionStringBuilder) WithTokenCredential(tokenCredential azcore.
 	return kcsb
 }
 
+// WithLogger sets the logger the clients built from the connection string report their internal events to, such as the calls
+// to the service, the failures to acquire a token and the frames of a response that couldn't be delivered. *slog.Logger
+// implements Logger. Defaults to slog.Default(). The logger isn't part of the connection string, and isn't reset by the
+// methods that set the authentication.
+func (kcsb *ConnectionStringBuilder) WithLogger(logger Logger) *ConnectionStringBuilder {
+	kcsb.Logger = logger
+	return kcsb
+}
+
 // Method to be used for generating TokenCredential
 func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error) {
 	tkp := &TokenProvider{}
@@ -129,6 +129,12 @@ func TestBuilder(t *testing.T) {
 				AddColumn("b\na\nz").AddLiteral(" == ").
 				AddFunction("f_u_n\u1234c").AddLiteral("()"),
 			`database("f\"\"o").["b\\a\\r"] | where ["b\na\nz"] == ["f_u_n\u1234c"]()`},
+		{
+			"Test add qualified tables",
+			New("union ").
+				AddQualifiedTable("Other DB", "My Table").AddLiteral(", ").
+				AddQualifiedTable("", "Local"),
+			`union database("Other DB").["My Table"], Local`},
 		{
 			"Test Empty String",
 			New(`myTable | where col = `).AddString(""),
@@ -10,6 +10,15 @@ func (b *Builder) AddTable(table string) *Builder {
 	return b.addBase(stringConstant(NormalizeName(table)))
 }
 
+// AddQualifiedTable adds a reference to the table of another database, such as `database("db").["My Table"]`, so a query run in
+// one database can read the tables of the others. If database is empty, the table is added as with AddTable.
+func (b *Builder) AddQualifiedTable(database string, table string) *Builder {
+	if database == "" {
+		return b.AddTable(table)
+	}
+	return b.AddDatabase(database).AddLiteral(".").AddTable(table)
+}
+
 func (b *Builder) AddKeyword(keyword string) *Builder {
 	if RequiresQuoting(keyword) {
 		panic("Invalid keyword. Cannot add a keyword that requires escaping.")
@@ -1,6 +1,7 @@
 package kql
 
 import (
+	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -17,6 +18,24 @@ func NewParameters() *Parameters {
 	return &Parameters{parameters: make(map[string]value.Kusto)}
 }
 
+// NewParametersFromStruct returns parameters built from the exported fields of a struct, or a pointer to a struct.
+// Fields are named and typed like value.RecordFromStruct does, so the `kusto` tags used to decode rows also name the parameters.
+func NewParametersFromStruct(v interface{}) (*Parameters, error) {
+	record, err := value.RecordFromStruct(v)
+	if err != nil {
+		return nil, err
+	}
+
+	q := NewParameters()
+	for _, f := range record {
+		if RequiresQuoting(f.Name) {
+			return nil, fmt.Errorf("parameter name %q does not adhere to KQL entity name conventions", f.Name)
+		}
+		q.AddValue(f.Name, f.Value)
+	}
+	return q, nil
+}
+
 func (q *Parameters) Count() int {
 	return len(q.parameters)
 }
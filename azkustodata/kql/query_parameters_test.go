@@ -101,3 +101,31 @@ func TestQueryParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestNewParametersFromStruct(t *testing.T) {
+	type params struct {
+		Name  string        `kusto:"name"`
+		Count int64         `kusto:"count"`
+		Span  time.Duration `kusto:"span"`
+		Limit *int32
+		Skip  string `kusto:"-"`
+	}
+
+	qp, err := NewParametersFromStruct(params{Name: "a", Count: 3, Span: time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, "declare query_parameters(Limit:int, count:long, name:string, span:timespan);", qp.ToDeclarationString())
+	require.Equal(t, map[string]string{
+		"name":  `"a"`,
+		"count": "long(3)",
+		"span":  "timespan(01:00:00.0000000)",
+		"Limit": "int(null)",
+	}, qp.ToParameterCollection())
+
+	_, err = NewParametersFromStruct(struct {
+		Bad string `kusto:"not valid"`
+	}{})
+	require.Error(t, err)
+
+	_, err = NewParametersFromStruct("not a struct")
+	require.Error(t, err)
+}
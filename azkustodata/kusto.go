@@ -2,14 +2,25 @@ package azkustodata
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	goErrors "errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
 	"github.com/Azure/azure-kusto-go/azkustodata/query"
 	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
 	queryv2 "github.com/Azure/azure-kusto-go/azkustodata/query/v2"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
+	"github.com/Azure/azure-kusto-go/azkustodata/utils"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 )
@@ -19,7 +30,7 @@ type Statement = *kql.Builder
 // queryer provides for getting a stream of Kusto frames. Exists to allow fake Kusto streams in tests.
 type queryer interface {
 	io.Closer
-	rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (io.ReadCloser, error)
+	rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (http.Header, io.ReadCloser, error)
 }
 
 // Authorization provides the TokenProvider needed to acquire the auth token.
@@ -32,6 +43,8 @@ const (
 	defaultMgmtTimeout  = time.Hour
 	defaultQueryTimeout = 4 * time.Minute
 	clientServerDelta   = 30 * time.Second
+	// maxServerTimeout is the maximum server timeout Kusto accepts.
+	maxServerTimeout = time.Hour
 )
 
 // Client is a client to a Kusto instance.
@@ -41,6 +54,17 @@ type Client struct {
 	auth          Authorization
 	http          *http.Client
 	clientDetails *ClientDetails
+	queryGroup    utils.Group[string, query.Dataset]
+	readOnlyMgmt  bool
+	// tracer records the spans of the calls, or is nil.
+	tracer trace.Tracer
+	// logger is the logger of the connection string builder, or nil.
+	logger Logger
+	// interceptors are called around the HTTP calls of the client, in order.
+	interceptors []Interceptor
+	// onSlow is called with the calls that take longer than slowThreshold, or is nil.
+	slowThreshold time.Duration
+	onSlow        func(op SlowOperation)
 }
 
 // Option is an optional argument type for New().
@@ -57,7 +81,7 @@ func New(kcsb *ConnectionStringBuilder, options ...Option) (*Client, error) {
 	}
 	endpoint := kcsb.DataSource
 
-	client := &Client{auth: *auth, endpoint: endpoint, clientDetails: NewClientDetails(kcsb.ApplicationForTracing, kcsb.UserForTracing)}
+	client := &Client{auth: *auth, endpoint: endpoint, clientDetails: NewClientDetails(kcsb.ApplicationForTracing, kcsb.UserForTracing), logger: kcsb.Logger}
 	for _, o := range options {
 		o(client)
 	}
@@ -69,6 +93,7 @@ func New(kcsb *ConnectionStringBuilder, options ...Option) (*Client, error) {
 			},
 		}
 	}
+	client.http = intercept(client.http, client.interceptors)
 
 	conn, err := NewConn(endpoint, *auth, client.http, client.clientDetails)
 	if err != nil {
@@ -85,6 +110,25 @@ func WithHttpClient(client *http.Client) Option {
 	}
 }
 
+// WithQueryDeduplication makes concurrent calls to Query() with an identical database, query text and options share a single call to the service.
+// The decoded dataset is shared between all the callers. The call runs with the values and the deadline of the context of the
+// first caller, from which its server timeout is derived, but without its cancellation: it is canceled once every caller left.
+// Each caller waits until its own context is done at most.
+func WithQueryDeduplication() Option {
+	return func(c *Client) {
+		c.queryGroup = utils.NewGroup[string, query.Dataset]()
+	}
+}
+
+// WithReadOnlyManagement makes Mgmt() reject every command that isn't a `.show` command, before sending it, so tooling that should
+// only read diagnostics can't change the cluster, even if it builds a command incorrectly.
+// Queries and ingestion aren't affected; the permissions of the principal of the client still apply.
+func WithReadOnlyManagement() Option {
+	return func(c *Client) {
+		c.readOnlyMgmt = true
+	}
+}
+
 // QueryOption is an option type for a call to Query().
 type QueryOption func(q *queryOptions) error
 
@@ -105,7 +149,22 @@ const (
 	mgmtCall  = 2
 )
 
-func (c *Client) Mgmt(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (v1.Dataset, error) {
+func (c *Client) Mgmt(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (ds v1.Dataset, err error) {
+	if c.readOnlyMgmt && !isShowCommand(kqlQuery.String()) {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "the client only allows .show commands, see WithReadOnlyManagement").SetNoRetry()
+	}
+
+	start := time.Now()
+	ctx = logger.NewContext(ctx, c.logger)
+	ctx, span := c.startSpan(ctx, errors.OpMgmt, db)
+	defer func() {
+		if ds != nil {
+			span.SetAttributes(tracing.AttrRows.Int(countRows(ds.Tables())))
+		}
+		tracing.End(span, err)
+		c.reportSlow(start, errors.OpMgmt, db, kqlQuery, requestIDOf(ds, err), err)
+	}()
+
 	ctx, cancel := contextSetup(ctx)
 
 	opQuery := errors.OpMgmt
@@ -115,36 +174,66 @@ func (c *Client) Mgmt(ctx context.Context, db string, kqlQuery Statement, option
 		return nil, err
 	}
 
+	ids := query.RequestIDs{ClientRequestID: opts.requestProperties.ClientRequestID}
+	defer func() { err = withRequestIDs(opQuery, err, ids) }()
+
 	conn, err := c.getConn(callType(call), connOptions{queryOptions: opts})
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := conn.rawQuery(ctx, callType(call), db, kqlQuery, opts)
+	headers, res, err := conn.rawQuery(ctx, callType(call), db, kqlQuery, opts)
 
 	if err != nil {
 		cancel()
 		return nil, err
 	}
 
-	return v1.NewDatasetFromReader(ctx, opQuery, res)
+	ids.ActivityID = headers.Get(ActivityIdHeader)
+	return v1.NewDatasetFromReader(query.WithRequestIDs(ctx, ids), opQuery, tracing.NewBody(res, span, false))
 }
 
 func (c *Client) Query(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (query.Dataset, error) {
-	ds, err := c.IterativeQuery(ctx, db, kqlQuery, options...)
+	if c.queryGroup != nil {
+		key, err := queryKey(ctx, db, kqlQuery, options)
+		if err != nil {
+			return nil, err
+		}
+		ds, err, _ := c.queryGroup.Do(ctx, key, func(ctx context.Context) (query.Dataset, error) {
+			return c.query(ctx, db, kqlQuery, options...)
+		})
+		if err != nil && ctx.Err() != nil && goErrors.Is(err, ctx.Err()) {
+			return nil, errors.E(errors.OpQuery, errors.KTimeout, err)
+		}
+		return ds, err
+	}
+
+	return c.query(ctx, db, kqlQuery, options...)
+}
+
+func (c *Client) query(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (ds query.Dataset, err error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, errors.OpQuery, db)
+	defer func() {
+		if ds != nil {
+			span.SetAttributes(tracing.AttrRows.Int(countRows(ds.Tables())))
+		}
+		tracing.End(span, err)
+		c.reportSlow(start, errors.OpQuery, db, kqlQuery, requestIDOf(ds, err), err)
+	}()
+
+	iterative, err := c.IterativeQuery(context.WithValue(ctx, ownedSpanKey{}, true), db, kqlQuery, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	return ds.ToDataset()
+	return iterative.ToDataset()
 }
 
 func (c *Client) IterativeQuery(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (query.IterativeDataset, error) {
-	options = append(options, V2NewlinesBetweenFrames())
-	options = append(options, V2FragmentPrimaryTables())
-	options = append(options, ResultsErrorReportingPlacement(ResultsErrorReportingPlacementEndOfTable))
-
-	opts, res, err := c.rawV2(ctx, db, kqlQuery, options)
+	// The dataset is decoded with the context of the call, which carries the logger.
+	ctx = logger.NewContext(ctx, c.logger)
+	opts, ids, res, err := c.rawV2(ctx, db, kqlQuery, iterativeOptions(options))
 	if err != nil {
 		return nil, err
 	}
@@ -164,41 +253,105 @@ func (c *Client) IterativeQuery(ctx context.Context, db string, kqlQuery Stateme
 		fragmentCapacity = opts.v2TableCapacity
 	}
 
-	return queryv2.NewIterativeDataset(ctx, res, frameCapacity, rowCapacity, fragmentCapacity)
+	return queryv2.NewIterativeDataset(query.WithRequestIDs(ctx, ids), res, frameCapacity, rowCapacity, fragmentCapacity)
+}
+
+// IterativeRawQuery runs a query and returns the frames of the response as they arrive, without decoding them.
+// This is useful for proxying results through intermediary services, as the frames can be forwarded as-is.
+func (c *Client) IterativeRawQuery(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (<-chan queryv2.RawFrameResult, error) {
+	opts, _, res, err := c.rawV2(ctx, db, kqlQuery, iterativeOptions(options))
+	if err != nil {
+		return nil, err
+	}
+
+	frameCapacity := queryv2.DefaultIoCapacity
+	if opts.v2IoCapacity != -1 {
+		frameCapacity = opts.v2IoCapacity
+	}
+
+	return queryv2.NewRawFrames(ctx, res, frameCapacity)
+}
+
+// QueryToWriter runs a query and streams the raw response body into w, without decoding it.
+// The response is in the same format IterativeQuery consumes, so it can be decoded later with queryv2.NewIterativeDataset.
+// It returns the number of bytes written.
+func (c *Client) QueryToWriter(ctx context.Context, db string, kqlQuery Statement, w io.Writer, options ...QueryOption) (int64, error) {
+	_, _, res, err := c.rawV2(ctx, db, kqlQuery, iterativeOptions(options))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Close()
+
+	n, err := io.Copy(w, res)
+	if err != nil {
+		return n, errors.E(errors.OpQuery, errors.KIO, err)
+	}
+
+	return n, nil
+}
+
+// iterativeOptions adds the options that are required to read a response frame-by-frame.
+func iterativeOptions(options []QueryOption) []QueryOption {
+	options = append(options, V2NewlinesBetweenFrames())
+	options = append(options, V2FragmentPrimaryTables())
+	options = append(options, ResultsErrorReportingPlacement(ResultsErrorReportingPlacementEndOfTable))
+	return options
 }
 
 func (c *Client) RawV2(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (io.ReadCloser, error) {
 
-	_, res, err := c.rawV2(ctx, db, kqlQuery, options)
+	_, _, res, err := c.rawV2(ctx, db, kqlQuery, options)
 
 	return res, err
 
 }
 
-func (c *Client) rawV2(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (*queryOptions, io.ReadCloser, error) {
+// rawV2 runs the query, in a span that ends once the response is read, unless the caller owns the span of ctx.
+// It returns the IDs of the call with the response, and records them in the error if it fails.
+func (c *Client) rawV2(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (opts *queryOptions, ids query.RequestIDs, res io.ReadCloser, err error) {
+	ctx = logger.NewContext(ctx, c.logger)
+	owned := ctx.Value(ownedSpanKey{}) != nil
+	var span trace.Span
+	if owned {
+		span = trace.SpanFromContext(ctx)
+	} else {
+		start := time.Now()
+		ctx, span = c.startSpan(ctx, errors.OpQuery, db)
+		defer func() {
+			if err != nil {
+				tracing.End(span, err)
+			}
+			c.reportSlow(start, errors.OpQuery, db, kqlQuery, ids.ClientRequestID, err)
+		}()
+	}
+
 	ctx, cancel := contextSetup(ctx)
 	opQuery := errors.OpQuery
-	opts, err := setQueryOptions(ctx, opQuery, kqlQuery, queryCall, options...)
+	opts, err = setQueryOptions(ctx, opQuery, kqlQuery, queryCall, options...)
 	if err != nil {
-		return nil, nil, err
+		return nil, ids, nil, err
 	}
 
+	ids.ClientRequestID = opts.requestProperties.ClientRequestID
+	defer func() { err = withRequestIDs(opQuery, err, ids) }()
+
 	conn, err := c.getConn(queryCall, connOptions{queryOptions: opts})
 	if err != nil {
-		return nil, nil, err
+		return nil, ids, nil, err
 	}
 
-	res, err := conn.rawQuery(ctx, queryCall, db, kqlQuery, opts)
+	headers, res, err := conn.rawQuery(ctx, queryCall, db, kqlQuery, opts)
 
 	if err != nil {
 		cancel()
-		return nil, nil, err
+		return nil, ids, nil, err
 	}
-	return opts, res, nil
+	ids.ActivityID = headers.Get(ActivityIdHeader)
+	return opts, ids, tracing.NewBody(res, span, !owned), nil
 }
 
 func (c *Client) QueryToJson(ctx context.Context, db string, query Statement, options ...QueryOption) (string, error) {
-	_, res, err := c.rawV2(ctx, db, query, options)
+	_, _, res, err := c.rawV2(ctx, db, query, options)
 	if err != nil {
 		return "", err
 	}
@@ -211,8 +364,39 @@ func (c *Client) QueryToJson(ctx context.Context, db string, query Statement, op
 	return string(all), nil
 }
 
-func setQueryOptions(ctx context.Context, op errors.Op, query Statement, queryType int, options ...QueryOption) (*queryOptions, error) {
-	opt := &queryOptions{
+// queryKey identifies a query by its database, text and options, so identical queries can be deduplicated.
+// The server timeout that is derived from the context deadline is not part of the key.
+func queryKey(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (string, error) {
+	opt := newQueryOptions()
+	for _, o := range withContextOptions(ctx, options) {
+		if err := o(opt); err != nil {
+			return "", errors.ES(errors.OpQuery, errors.KClientArgs, "QueryValues in the the Stmt were incorrect: %s", err).SetNoRetry()
+		}
+	}
+
+	props, err := json.Marshal(opt.requestProperties)
+	if err != nil {
+		return "", errors.E(errors.OpQuery, errors.KInternal, err)
+	}
+
+	h := sha256.New()
+	for _, part := range []string{
+		db,
+		kqlQuery.String(),
+		opt.requestProperties.QueryParameters.ToDeclarationString(),
+		opt.requestProperties.ClientRequestID,
+		opt.requestProperties.Application,
+		opt.requestProperties.User,
+		string(props),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func newQueryOptions() *queryOptions {
+	return &queryOptions{
 		requestProperties: &requestProperties{
 			Options: map[string]interface{}{},
 		},
@@ -220,14 +404,53 @@ func setQueryOptions(ctx context.Context, op errors.Op, query Statement, queryTy
 		v2RowCapacity:   -1,
 		v2TableCapacity: -1,
 	}
+}
 
-	for _, o := range options {
+type contextOptionsKey struct{}
+
+// WithOptions returns a copy of ctx that carries default QueryOptions.
+// They are applied to every query and management command made with the returned context, before the options passed to the call itself,
+// so middleware can attach settings (such as Application or RequestReadonly) that apply to all the calls downstream.
+// Calling WithOptions on a context that already carries options adds to them.
+func WithOptions(ctx context.Context, options ...QueryOption) context.Context {
+	existing := optionsFromContext(ctx)
+	combined := make([]QueryOption, 0, len(existing)+len(options))
+	combined = append(combined, existing...)
+	combined = append(combined, options...)
+	return context.WithValue(ctx, contextOptionsKey{}, combined)
+}
+
+// optionsFromContext returns the options that were attached to ctx with WithOptions.
+func optionsFromContext(ctx context.Context) []QueryOption {
+	if options, ok := ctx.Value(contextOptionsKey{}).([]QueryOption); ok {
+		return options
+	}
+	return nil
+}
+
+// withContextOptions prepends the options that were attached to ctx, so the options passed to the call take precedence.
+func withContextOptions(ctx context.Context, options []QueryOption) []QueryOption {
+	fromCtx := optionsFromContext(ctx)
+	if len(fromCtx) == 0 {
+		return options
+	}
+	combined := make([]QueryOption, 0, len(fromCtx)+len(options))
+	combined = append(combined, fromCtx...)
+	return append(combined, options...)
+}
+
+func setQueryOptions(ctx context.Context, op errors.Op, query Statement, queryType int, options ...QueryOption) (*queryOptions, error) {
+	opt := newQueryOptions()
+
+	for _, o := range withContextOptions(ctx, options) {
 		if err := o(opt); err != nil {
 			return nil, errors.ES(op, errors.KClientArgs, "QueryValues in the the Stmt were incorrect: %s", err).SetNoRetry()
 		}
 	}
 
-	CalculateTimeout(ctx, opt, queryType)
+	if err := CalculateTimeout(ctx, op, opt, queryType); err != nil {
+		return nil, err
+	}
 
 	if query.SupportsInlineParameters() {
 		if opt.requestProperties.QueryParameters.Count() != 0 {
@@ -240,24 +463,60 @@ func setQueryOptions(ctx context.Context, op errors.Op, query Statement, queryTy
 
 		opt.requestProperties.Parameters = params
 	}
+
+	// The ID is generated here rather than with the headers, so that it can be returned with the result of the call.
+	if opt.requestProperties.ClientRequestID == "" {
+		opt.requestProperties.ClientRequestID = "KGC.execute;" + uuid.New().String()
+	}
 	return opt, nil
 }
 
+// withRequestIDs records the IDs of a call to the service in the error it failed with, wrapping it in an *errors.Error
+// if it isn't one. IDs the error already holds, such as those of an HTTP error, are kept.
+func withRequestIDs(op errors.Op, err error, ids query.RequestIDs) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := errors.GetKustoError(err)
+	if !ok {
+		e = errors.E(op, errors.KOther, err)
+		err = e
+	}
+	if e.ClientRequestID == "" {
+		e.ClientRequestID = ids.ClientRequestID
+	}
+	if e.ActivityID == "" {
+		e.ActivityID = ids.ActivityID
+	}
+	return err
+}
+
 var nower = time.Now
 
-func CalculateTimeout(ctx context.Context, opt *queryOptions, queryType int) {
+// CalculateTimeout sets the server timeout of the call. It fails if the context deadline, less the server timeout grace, leaves
+// no time for the server to run the call.
+func CalculateTimeout(ctx context.Context, op errors.Op, opt *queryOptions, queryType int) error {
 	// If the user has specified a timeout, use that.
 	if val, ok := opt.requestProperties.Options[NoRequestTimeoutValue]; ok && val.(bool) {
-		return
+		return nil
 	}
 	if _, ok := opt.requestProperties.Options[ServerTimeoutValue]; ok {
-		return
+		if opt.serverTimeout > maxServerTimeout {
+			opt.requestProperties.Options[ServerTimeoutValue] = value.TimespanString(opt.clampServerTimeout(ctx, opt.serverTimeout))
+		}
+		return nil
 	}
 
 	// Otherwise use the context deadline, if it exists. If it doesn't, use the default timeout.
 	if deadline, ok := ctx.Deadline(); ok {
-		opt.requestProperties.Options[ServerTimeoutValue] = value.TimespanString(deadline.Sub(nower()))
-		return
+		remaining := deadline.Sub(nower())
+		timeout := remaining - opt.serverTimeoutGrace
+		if timeout <= 0 {
+			return errors.ES(op, errors.KTimeout, "the context deadline is in %s, which leaves no server timeout after the server timeout grace of %s",
+				remaining, opt.serverTimeoutGrace).SetNoRetry()
+		}
+		opt.requestProperties.Options[ServerTimeoutValue] = value.TimespanString(opt.clampServerTimeout(ctx, timeout))
+		return nil
 	}
 
 	var timeout time.Duration
@@ -268,6 +527,20 @@ func CalculateTimeout(ctx context.Context, opt *queryOptions, queryType int) {
 		timeout = defaultMgmtTimeout
 	}
 	opt.requestProperties.Options[ServerTimeoutValue] = value.TimespanString(timeout)
+	return nil
+}
+
+// clampServerTimeout limits the timeout to the maximum Kusto accepts, and notifies the caller if it had to, with a warning to
+// the logger of ctx and the callback of OnServerTimeoutClamped.
+func (q *queryOptions) clampServerTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	if timeout <= maxServerTimeout {
+		return timeout
+	}
+	logger.FromContext(ctx).Warn("the server timeout was clamped to the maximum Kusto accepts", "requested", timeout, "clamped", maxServerTimeout)
+	if q.onServerTimeoutClamped != nil {
+		q.onServerTimeoutClamped(timeout, maxServerTimeout)
+	}
+	return maxServerTimeout
 }
 
 func (c *Client) getConn(callType callType, options connOptions) (queryer, error) {
@@ -282,6 +555,28 @@ func (c *Client) getConn(callType callType, options connOptions) (queryer, error
 	}
 }
 
+// isShowCommand reports whether the command is a `.show` command, ignoring the white space and comments before it.
+func isShowCommand(command string) bool {
+	for {
+		command = strings.TrimLeftFunc(command, unicode.IsSpace)
+		if !strings.HasPrefix(command, "//") {
+			break
+		}
+		end := strings.IndexByte(command, '\n')
+		if end < 0 {
+			return false
+		}
+		command = command[end+1:]
+	}
+
+	const show = ".show"
+	if len(command) < len(show) || !strings.EqualFold(command[:len(show)], show) {
+		return false
+	}
+	rest := command[len(show):]
+	return rest == "" || unicode.IsSpace(rune(rest[0]))
+}
+
 func contextSetup(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithCancel(ctx)
 }
@@ -0,0 +1,267 @@
+package azkustodata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	queryv2 "github.com/Azure/azure-kusto-go/azkustodata/query/v2"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testV2Response = `[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"DataTable","TableId":0,"TableKind":"QueryProperties","TableName":"@ExtendedProperties","Columns":[{"ColumnName":"TableId","ColumnType":"int"},{"ColumnName":"Key","ColumnType":"string"},{"ColumnName":"Value","ColumnType":"dynamic"}],"Rows":[]}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"Name","ColumnType":"string"},{"ColumnName":"Count","ColumnType":"long"}]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[["a",1],["b",2]]}
+,{"FrameType":"TableCompletion","TableId":1,"RowCount":2}
+,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]
+`
+
+// fakeQueryer is a queryer that returns a canned response, and records the calls made to it.
+type fakeQueryer struct {
+	mu    sync.Mutex
+	body  func(callType callType, query string) string
+	calls []fakeCall
+}
+
+type fakeCall struct {
+	callType callType
+	db       string
+	query    string
+	options  *queryOptions
+	// deadline is the deadline of the context of the call, if any.
+	deadline time.Time
+}
+
+func (f *fakeQueryer) rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (http.Header, io.ReadCloser, error) {
+	deadline, _ := ctx.Deadline()
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{callType: callType, db: db, query: query.String(), options: options, deadline: deadline})
+	f.mu.Unlock()
+	return http.Header{}, io.NopCloser(strings.NewReader(f.body(callType, query.String()))), nil
+}
+
+func (f *fakeQueryer) Close() error {
+	return nil
+}
+
+func (f *fakeQueryer) lastCall() fakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[len(f.calls)-1]
+}
+
+func newFakeClient(body string) (*Client, *fakeQueryer) {
+	f := &fakeQueryer{body: func(callType, string) string { return body }}
+	return &Client{conn: f, endpoint: "https://test.kusto.windows.net", clientDetails: NewClientDetails("", "")}, f
+}
+
+func TestQueryToWriter(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testV2Response)
+
+	buf := &bytes.Buffer{}
+	n, err := client.QueryToWriter(context.Background(), "db", kql.New("T"), buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(testV2Response)), n)
+	assert.Equal(t, testV2Response, buf.String())
+
+	opts := f.lastCall().options.requestProperties.Options
+	assert.Equal(t, true, opts[V2NewlinesBetweenFramesValue])
+	assert.Equal(t, true, opts[V2FragmentPrimaryTablesValue])
+
+	// The written response can be decoded later.
+	ds, err := queryv2.NewIterativeDataset(context.Background(), io.NopCloser(buf), queryv2.DefaultIoCapacity, queryv2.DefaultRowCapacity, queryv2.DefaultTableCapacity)
+	require.NoError(t, err)
+	full, err := ds.ToDataset()
+	require.NoError(t, err)
+	require.Len(t, full.Tables(), 1)
+	assert.Len(t, full.Tables()[0].Rows(), 2)
+}
+
+func TestIterativeRawQuery(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newFakeClient(testV2Response)
+
+	frames, err := client.IterativeRawQuery(context.Background(), "db", kql.New("T"))
+	require.NoError(t, err)
+
+	count := 0
+	for f := range frames {
+		require.NoError(t, f.Err)
+		count++
+	}
+	assert.Equal(t, 6, count)
+}
+
+func TestQueryDeduplication(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testV2Response)
+	WithQueryDeduplication()(client)
+
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	f.body = func(callType, string) string {
+		entered <- struct{}{}
+		<-release
+		return testV2Response
+	}
+
+	const callers = 5
+	results := make(chan error, callers)
+	run := func() {
+		ds, err := client.Query(context.Background(), "db", kql.New("T"), QueryNow(time.Unix(0, 0)))
+		if err == nil && len(ds.Tables()) != 1 {
+			err = fmt.Errorf("unexpected amount of tables %d", len(ds.Tables()))
+		}
+		results <- err
+	}
+
+	go run()
+	<-entered
+	for i := 1; i < callers; i++ {
+		go run()
+	}
+
+	// Give the duplicate callers a chance to join the in-flight call.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		require.NoError(t, <-results)
+	}
+	f.mu.Lock()
+	assert.Len(t, f.calls, 1)
+	f.mu.Unlock()
+}
+
+func TestQueryDeduplicationDeadline(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testV2Response)
+	WithQueryDeduplication()(client)
+
+	deadline := time.Now().Add(2 * time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	_, err := client.Query(ctx, "db", kql.New("T"))
+	require.NoError(t, err)
+
+	// The server timeout is derived from the deadline of the caller, rather than being the default one.
+	call := f.lastCall()
+	assert.True(t, deadline.Equal(call.deadline))
+	assert.NotEqual(t, value.TimespanString(defaultQueryTimeout), call.options.requestProperties.Options[ServerTimeoutValue])
+}
+
+func TestQueryKey(t *testing.T) {
+	t.Parallel()
+
+	base, err := queryKey(context.Background(), "db", kql.New("T"), nil)
+	require.NoError(t, err)
+
+	same, err := queryKey(context.Background(), "db", kql.New("T"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, base, same)
+
+	otherDb, err := queryKey(context.Background(), "db2", kql.New("T"), nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, base, otherDb)
+
+	otherQuery, err := queryKey(context.Background(), "db", kql.New("T | take 1"), nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, base, otherQuery)
+
+	otherOptions, err := queryKey(context.Background(), "db", kql.New("T"), []QueryOption{NoTruncation()})
+	require.NoError(t, err)
+	assert.NotEqual(t, base, otherOptions)
+}
+
+func TestReadOnlyManagement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		command string
+		allowed bool
+	}{
+		{desc: "show", command: ".show tables", allowed: true},
+		{desc: "show with pipe", command: ".show tables | where TableName has 'x'", allowed: true},
+		{desc: "leading space and comments", command: "\n  // diagnostics\n// only\n.show diagnostics", allowed: true},
+		{desc: "upper case", command: ".SHOW cluster", allowed: true},
+		{desc: "show alone", command: ".show", allowed: true},
+		{desc: "drop", command: ".drop table T"},
+		{desc: "prefix of another command", command: ".showx tables"},
+		{desc: "script", command: ".execute database script <|\n.show tables\n\n.drop table T"},
+		{desc: "set statement", command: "set notruncation;\n.show tables"},
+		{desc: "comment only", command: "// .show tables"},
+		{desc: "empty", command: ""},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client, f := newFakeClient(testShowTablesResponse)
+			WithReadOnlyManagement()(client)
+
+			_, err := client.Mgmt(context.Background(), "db", kql.New("").AddUnsafe(test.command))
+			if !test.allowed {
+				assert.ErrorContains(t, err, "only allows .show commands")
+				assert.Empty(t, f.calls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, f.calls, 1)
+		})
+	}
+
+	// Without the option, every command is sent.
+	client, f := newFakeClient(testShowTablesResponse)
+	_, err := client.Mgmt(context.Background(), "db", kql.New(".drop table T"))
+	require.NoError(t, err)
+	assert.Len(t, f.calls, 1)
+}
+
+func TestContextOptions(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testV2Response)
+
+	ctx := WithOptions(context.Background(), Application("tenant-app"), RequestReadonly())
+	ctx = WithOptions(ctx, NoTruncation())
+
+	_, err := client.Query(ctx, "db", kql.New("T"), Application("call-app"))
+	require.NoError(t, err)
+
+	props := f.lastCall().options.requestProperties
+	assert.Equal(t, "call-app", props.Application)
+	assert.Equal(t, true, props.Options[RequestReadonlyValue])
+	assert.Equal(t, true, props.Options[NoTruncationValue])
+
+	_, err = client.Query(context.Background(), "db", kql.New("T"))
+	require.NoError(t, err)
+
+	props = f.lastCall().options.requestProperties
+	assert.Equal(t, "", props.Application)
+	assert.NotContains(t, props.Options, RequestReadonlyValue)
+
+	withCtx, err := queryKey(ctx, "db", kql.New("T"), nil)
+	require.NoError(t, err)
+	without, err := queryKey(context.Background(), "db", kql.New("T"), nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, withCtx, without)
+}
@@ -0,0 +1,216 @@
+package kustosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// conn implements driver.Conn. Kusto is stateless, so a conn is only a handle to the client.
+type conn struct {
+	client     client
+	db         string
+	options    []azkustodata.QueryOption
+	ownsClient bool
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+)
+
+// Prepare implements driver.Conn.
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *conn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	return c.Prepare(query)
+}
+
+// Close implements driver.Conn.
+func (c *conn) Close() error {
+	if c.ownsClient {
+		return c.client.Close()
+	}
+	return nil
+}
+
+// Begin implements driver.Conn. Kusto doesn't support transactions.
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "kusto does not support transactions").SetNoRetry()
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	stmt, opts, err := c.statement(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if isMgmt(query) {
+		ds, err := c.client.Mgmt(ctx, c.db, stmt, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newMgmtRows(ds)
+	}
+
+	ds, err := c.client.IterativeQuery(ctx, c.db, stmt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newQueryRows(ds)
+}
+
+// ExecContext implements driver.ExecerContext. The results of the statement are discarded.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := drain(rows); err != nil {
+		return nil, err
+	}
+	return driver.ResultNoRows, nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, allowing the Kusto specific argument types.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case time.Duration, uuid.UUID, decimal.Decimal, int, int32:
+		return nil
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = v
+	return nil
+}
+
+// statement builds the statement and the query parameters for the arguments.
+func (c *conn) statement(query string, args []driver.NamedValue) (azkustodata.Statement, []azkustodata.QueryOption, error) {
+	// The query text is the caller's statement, as with any database/sql driver - the arguments are what is kept out of it.
+	stmt := kql.New("").AddUnsafe(query)
+	opts := c.options
+	if len(args) == 0 {
+		return stmt, opts, nil
+	}
+	if isMgmt(query) {
+		return nil, nil, errors.ES(errors.OpMgmt, errors.KClientArgs,
+			"management commands don't support arguments - pass them to queries only").SetNoRetry()
+	}
+
+	params := kql.NewParameters()
+	for _, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("p%d", arg.Ordinal)
+		}
+		if err := addParameter(params, name, arg.Value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	opts = append(opts[:len(opts):len(opts)], azkustodata.QueryParameters(params))
+	return stmt, opts, nil
+}
+
+// addParameter adds a value to the parameters, inferring its Kusto type.
+func addParameter(params *kql.Parameters, name string, v driver.Value) error {
+	switch t := v.(type) {
+	case nil:
+		return errors.ES(errors.OpQuery, errors.KClientArgs, "parameter %s is nil - Kusto parameters must have a type", name).SetNoRetry()
+	case bool:
+		params.AddBool(name, t)
+	case int:
+		params.AddLong(name, int64(t))
+	case int32:
+		params.AddInt(name, t)
+	case int64:
+		params.AddLong(name, t)
+	case float64:
+		params.AddReal(name, t)
+	case string:
+		params.AddString(name, t)
+	case []byte:
+		params.AddSerializedDynamic(name, t)
+	case time.Time:
+		params.AddDateTime(name, t)
+	case time.Duration:
+		params.AddTimespan(name, t)
+	case uuid.UUID:
+		params.AddGUID(name, t)
+	case decimal.Decimal:
+		params.AddDecimal(name, t)
+	default:
+		return errors.ES(errors.OpQuery, errors.KClientArgs, "parameter %s has unsupported type %T", name, v).SetNoRetry()
+	}
+	return nil
+}
+
+func isMgmt(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), ".")
+}
+
+// stmt implements driver.Stmt. Kusto has no server-side prepared statements, so it only holds the query text.
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+var (
+	_ driver.StmtQueryContext = (*stmt)(nil)
+	_ driver.StmtExecContext  = (*stmt)(nil)
+)
+
+// Close implements driver.Stmt.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput implements driver.Stmt. The amount of arguments is not checked by the driver.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec implements driver.Stmt.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), toNamed(args))
+}
+
+// Query implements driver.Stmt.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), toNamed(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func toNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, a := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: a}
+	}
+	return named
+}
@@ -0,0 +1,122 @@
+/*
+Package kustosql provides a database/sql driver for Kusto, built on the azkustodata client.
+
+The driver is registered under the name "kusto". The data source name is a Kusto connection string, and the database
+is taken from its "Initial Catalog" property:
+
+	db, err := sql.Open("kusto", "Data Source=https://help.kusto.windows.net;Initial Catalog=Samples;Fed=True")
+
+Statements that start with a dot (such as ".show tables") are sent as management commands, everything else is sent as a query.
+
+Arguments are passed to the service as query parameters, so they are never concatenated into the query text.
+Named arguments (sql.Named) keep their name, and positional arguments are named p1, p2, and so on:
+
+	rows, err := db.QueryContext(ctx, "StormEvents | where State == p1 | take 10", "TEXAS")
+
+Management commands don't support query parameters, so a management command with arguments fails with a KClientArgs error.
+
+Columns are scanned as the following Go types: bool, int64 (int and long), float64 (real), string (decimal, string, guid and timespan),
+[]byte (dynamic) and time.Time (datetime). Transactions are not supported.
+*/
+package kustosql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// DriverName is the name the driver is registered under in database/sql.
+const DriverName = "kusto"
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// client is the subset of *azkustodata.Client the driver uses. Exists to allow fake clients in tests.
+type client interface {
+	IterativeQuery(ctx context.Context, db string, kqlQuery azkustodata.Statement, options ...azkustodata.QueryOption) (query.IterativeDataset, error)
+	Mgmt(ctx context.Context, db string, kqlQuery azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error)
+	Close() error
+}
+
+// Driver implements driver.Driver and driver.DriverContext.
+type Driver struct{}
+
+// Open returns a new connection that owns its own client. Prefer sql.Open, which uses OpenConnector to share a single client.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	connector := c.(*Connector)
+	return &conn{client: connector.client, db: connector.db, ownsClient: true}, nil
+}
+
+// OpenConnector parses the connection string and creates the client shared by all the connections of the connector.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	kcsb, err := parseConnectionString(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if kcsb.InitialCatalog == "" {
+		return nil, errors.ES(errors.OpServConn, errors.KClientArgs, "the connection string must specify a database with Initial Catalog").SetNoRetry()
+	}
+
+	c, err := azkustodata.New(kcsb)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Connector{client: c, db: kcsb.InitialCatalog, driver: d}, nil
+}
+
+// parseConnectionString parses the connection string of a data source name. azkustodata.NewConnectionStringBuilder panics on an
+// invalid connection string, which database/sql expects to be an error instead.
+func parseConnectionString(dsn string) (kcsb *azkustodata.ConnectionStringBuilder, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			kcsb = nil
+			err = errors.ES(errors.OpServConn, errors.KClientArgs, "the data source name isn't a valid connection string: %v", r).SetNoRetry()
+		}
+	}()
+	return azkustodata.NewConnectionStringBuilder(dsn), nil
+}
+
+// Connector implements driver.Connector over an existing client.
+type Connector struct {
+	client  client
+	db      string
+	options []azkustodata.QueryOption
+	driver  *Driver
+}
+
+// NewConnector returns a connector that runs statements against db with an existing client.
+// options are applied to every statement. Use it with sql.OpenDB:
+//
+//	db := sql.OpenDB(kustosql.NewConnector(client, "Samples"))
+//
+// Closing the returned sql.DB closes the client.
+func NewConnector(client *azkustodata.Client, db string, options ...azkustodata.QueryOption) *Connector {
+	return &Connector{client: client, db: db, options: options, driver: &Driver{}}
+}
+
+// Connect implements driver.Connector.
+func (c *Connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{client: c.client, db: c.db, options: c.options}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close closes the client. database/sql calls it when the sql.DB is closed.
+func (c *Connector) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,192 @@
+package kustosql
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	queryv2 "github.com/Azure/azure-kusto-go/azkustodata/query/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const v2Response = `[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"DataTable","TableId":0,"TableKind":"QueryProperties","TableName":"@ExtendedProperties","Columns":[{"ColumnName":"TableId","ColumnType":"int"},{"ColumnName":"Key","ColumnType":"string"},{"ColumnName":"Value","ColumnType":"dynamic"}],"Rows":[]}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"Name","ColumnType":"string"},{"ColumnName":"Count","ColumnType":"long"},{"ColumnName":"When","ColumnType":"datetime"},{"ColumnName":"Bag","ColumnType":"dynamic"}]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[["a",1,"2024-01-02T03:04:05Z",{"x":1}],["b",null,null,null]]}
+,{"FrameType":"TableCompletion","TableId":1,"RowCount":2}
+,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]
+`
+
+const v1Response = `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"TableName","DataType":"String","ColumnType":"string"}],"Rows":[["T1"],["T2"]]}]}`
+
+type fakeClient struct {
+	mu      sync.Mutex
+	queries []string
+	options [][]azkustodata.QueryOption
+	closed  bool
+}
+
+func (f *fakeClient) record(stmt azkustodata.Statement, options []azkustodata.QueryOption) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, stmt.String())
+	f.options = append(f.options, options)
+}
+
+func (f *fakeClient) IterativeQuery(ctx context.Context, _ string, stmt azkustodata.Statement, options ...azkustodata.QueryOption) (query.IterativeDataset, error) {
+	f.record(stmt, options)
+	return queryv2.NewIterativeDataset(ctx, io.NopCloser(strings.NewReader(v2Response)), queryv2.DefaultIoCapacity, queryv2.DefaultRowCapacity, queryv2.DefaultTableCapacity)
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, _ string, stmt azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+	f.record(stmt, options)
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(v1Response)))
+}
+
+func (f *fakeClient) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newTestDB(t *testing.T) (*sql.DB, *fakeClient) {
+	f := &fakeClient{}
+	db := sql.OpenDB(&Connector{client: f, db: "db", driver: &Driver{}})
+	t.Cleanup(func() { _ = db.Close() })
+	return db, f
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	db, _ := newTestDB(t)
+
+	rows, err := db.QueryContext(context.Background(), "T")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Name", "Count", "When", "Bag"}, cols)
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, "long", types[1].DatabaseTypeName())
+
+	var (
+		name  string
+		count sql.NullInt64
+		when  sql.NullTime
+		bag   []byte
+	)
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&name, &count, &when, &bag))
+	assert.Equal(t, "a", name)
+	assert.Equal(t, sql.NullInt64{Int64: 1, Valid: true}, count)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), when.Time)
+	assert.JSONEq(t, `{"x":1}`, string(bag))
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&name, &count, &when, &bag))
+	assert.Equal(t, "b", name)
+	assert.False(t, count.Valid)
+	assert.False(t, when.Valid)
+	assert.Nil(t, bag)
+
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.Err())
+}
+
+func TestQueryParameters(t *testing.T) {
+	t.Parallel()
+
+	db, f := newTestDB(t)
+
+	rows, err := db.QueryContext(context.Background(), "T | where Name == p1 and Count > limit", "a'b", sql.Named("limit", 3), time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Equal(t, "T | where Name == p1 and Count > limit", f.queries[0])
+	require.Len(t, f.options[0], 1)
+
+	_, err = db.QueryContext(context.Background(), "T", struct{}{})
+	assert.Error(t, err)
+}
+
+func TestMgmt(t *testing.T) {
+	t.Parallel()
+
+	db, f := newTestDB(t)
+
+	rows, err := db.QueryContext(context.Background(), ".show tables")
+	require.NoError(t, err)
+
+	var names []string
+	for rows.Next() {
+		var n string
+		require.NoError(t, rows.Scan(&n))
+		names = append(names, n)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"T1", "T2"}, names)
+
+	_, err = db.ExecContext(context.Background(), ".drop table T1")
+	require.NoError(t, err)
+
+	f.mu.Lock()
+	assert.Equal(t, ".drop table T1", f.queries[1])
+	f.mu.Unlock()
+
+	// Query parameters would be declared before the command, which the service rejects.
+	_, err = db.ExecContext(context.Background(), ".drop table T2", "arg")
+	require.Error(t, err)
+	kustoErr, ok := errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.KClientArgs, kustoErr.Kind)
+
+	f.mu.Lock()
+	assert.Len(t, f.queries, 2)
+	f.mu.Unlock()
+}
+
+func TestTransactionsNotSupported(t *testing.T) {
+	t.Parallel()
+
+	db, _ := newTestDB(t)
+	_, err := db.Begin()
+	assert.Error(t, err)
+}
+
+func TestOpenConnectorRequiresDatabase(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&Driver{}).OpenConnector("Data Source=https://test.kusto.windows.net")
+	assert.Error(t, err)
+}
+
+func TestOpenConnectorInvalidConnectionString(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range []string{"", "Data Source=https://test.kusto.windows.net;Initial Catalog=db;Unknown Key=value", "https://test.kusto.windows.net;Initial Catalog"} {
+		_, err := (&Driver{}).OpenConnector(dsn)
+		require.Error(t, err, dsn)
+		kustoErr, ok := errors.GetKustoError(err)
+		require.True(t, ok, dsn)
+		assert.Equal(t, errors.KClientArgs, kustoErr.Kind, dsn)
+	}
+
+	_, err := sql.Open(DriverName, "")
+	assert.Error(t, err)
+}
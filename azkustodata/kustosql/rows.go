@@ -0,0 +1,194 @@
+package kustosql
+
+import (
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// rows implements driver.Rows over the first primary table of a result.
+type rows struct {
+	columns []query.Column
+	next    func() (query.Row, error)
+	close   func() error
+}
+
+var (
+	_ driver.Rows                           = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+)
+
+// newQueryRows reads the rows of the first primary table of an iterative dataset as they arrive.
+func newQueryRows(ds query.IterativeDataset) (driver.Rows, error) {
+	for res := range ds.Tables() {
+		if res.Err() != nil {
+			ds.Close()
+			return nil, res.Err()
+		}
+		table := res.Table()
+		if !table.IsPrimaryResult() {
+			continue
+		}
+
+		rowsCh := table.Rows()
+		return &rows{
+			columns: table.Columns(),
+			next: func() (query.Row, error) {
+				res, ok := <-rowsCh
+				if !ok {
+					return nil, io.EOF
+				}
+				if res.Err() != nil {
+					return nil, res.Err()
+				}
+				return res.Row(), nil
+			},
+			close: ds.Close,
+		}, nil
+	}
+
+	if err := ds.Close(); err != nil {
+		return nil, err
+	}
+	return &rows{next: func() (query.Row, error) { return nil, io.EOF }, close: func() error { return nil }}, nil
+}
+
+// newMgmtRows reads the rows of the primary result of a management command.
+func newMgmtRows(ds v1.Dataset) (driver.Rows, error) {
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return &rows{next: func() (query.Row, error) { return nil, io.EOF }, close: func() error { return nil }}, nil
+	}
+
+	table := tables[0]
+	all := table.Rows()
+	i := 0
+	return &rows{
+		columns: table.Columns(),
+		next: func() (query.Row, error) {
+			if i >= len(all) {
+				return nil, io.EOF
+			}
+			i++
+			return all[i-1], nil
+		},
+		close: func() error { return nil },
+	}, nil
+}
+
+// Columns implements driver.Rows.
+func (r *rows) Columns() []string {
+	names := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// Close implements driver.Rows.
+func (r *rows) Close() error {
+	return r.close()
+}
+
+// Next implements driver.Rows.
+func (r *rows) Next(dest []driver.Value) error {
+	row, err := r.next()
+	if err != nil {
+		return err
+	}
+
+	for i, v := range row.Values() {
+		if i >= len(dest) {
+			break
+		}
+		dv, err := toDriverValue(v)
+		if err != nil {
+			return err
+		}
+		dest[i] = dv
+	}
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return string(r.columns[index].Type())
+}
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType.
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	switch r.columns[index].Type() {
+	case types.Bool:
+		return reflect.TypeOf(false)
+	case types.Int, types.Long:
+		return reflect.TypeOf(int64(0))
+	case types.Real:
+		return reflect.TypeOf(float64(0))
+	case types.Dynamic:
+		return reflect.TypeOf([]byte{})
+	case types.DateTime:
+		return reflect.TypeOf(time.Time{})
+	default:
+		return reflect.TypeOf("")
+	}
+}
+
+// ColumnTypeNullable implements driver.RowsColumnTypeNullable. Every Kusto type except string is nullable.
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.columns[index].Type() != types.String, true
+}
+
+// toDriverValue converts a Kusto value into one of the types database/sql supports.
+func toDriverValue(k value.Kusto) (driver.Value, error) {
+	if value.IsNull(k) {
+		return nil, nil
+	}
+
+	switch v := k.(type) {
+	case *value.Bool:
+		return *v.Ptr(), nil
+	case *value.Int:
+		return int64(*v.Ptr()), nil
+	case *value.Long:
+		return *v.Ptr(), nil
+	case *value.Real:
+		return *v.Ptr(), nil
+	case *value.Decimal:
+		return v.String(), nil
+	case *value.String:
+		return v.Value, nil
+	case *value.Dynamic:
+		return v.Value, nil
+	case *value.DateTime:
+		return *v.Ptr(), nil
+	case *value.Timespan:
+		return v.Marshal(), nil
+	case *value.GUID:
+		return v.String(), nil
+	default:
+		return nil, errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "unsupported value type %T", k)
+	}
+}
+
+// drain reads all the rows, so the statement runs to completion, and closes them.
+func drain(r driver.Rows) error {
+	defer r.Close()
+	dest := make([]driver.Value, len(r.Columns()))
+	for {
+		if err := r.Next(dest); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package azkustodata
+
+import "github.com/Azure/azure-kusto-go/azkustodata/logger"
+
+// Logger receives the internal events of the clients, with a message and alternating keys and values, such as the calls to the
+// service, the failures to acquire a token, and the failures of the background refreshes of the ingestion resources, which
+// aren't returned to any caller. *slog.Logger implements it. It is set with ConnectionStringBuilder.WithLogger.
+type Logger = logger.Logger
@@ -0,0 +1,46 @@
+// Package logger defines the Logger the clients report their internal events to, such as failed background refreshes of the
+// ingestion resources or frames of a response that couldn't be delivered, which aren't returned to any caller. It is shared by
+// the query and the ingestion clients, and carries the logger of a call in its context to the code that runs under it.
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger receives the events of the clients, with a message and alternating keys and values, as *slog.Logger does, which
+// implements it. Debug events are sent for each call to the service, Info events for notable successes, such as a refresh of
+// the ingestion resources after failures, Warn events for failures the client recovers from, and Error events for failures
+// that are lost, such as in a background refresh.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+var _ Logger = (*slog.Logger)(nil)
+
+// OrDefault returns l, or slog.Default() if l is nil.
+func OrDefault(l Logger) Logger {
+	if l == nil {
+		return slog.Default()
+	}
+	return l
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries l, or ctx as is if l is nil.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	if l == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger ctx carries, or slog.Default() if it carries none.
+func FromContext(ctx context.Context) Logger {
+	l, _ := ctx.Value(contextKey{}).(Logger)
+	return OrDefault(l)
+}
@@ -0,0 +1,57 @@
+package azkustodata
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(ClientRequestIdHeader) == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"General_BadRequest","message":"Syntax error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(testShowTablesResponse))
+	}))
+	defer server.Close()
+
+	logs := &bytes.Buffer{}
+	kcsb := NewConnectionStringBuilder(server.URL).WithLogger(slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	client, err := New(kcsb)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("ok"))
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="calling the service" op=OpMgmt`)
+	assert.Contains(t, logs.String(), `clientRequestID=ok status=200`)
+	assert.NotContains(t, logs.String(), "level=WARN")
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("fail"))
+	require.Error(t, err)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="the service returned an error" op=OpMgmt clientRequestID=fail status=400`)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ServerTimeout(90*time.Minute))
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), `level=WARN msg="the server timeout was clamped to the maximum Kusto accepts" requested=1h30m0s clamped=1h0m0s`)
+}
+
+func TestLoggerIsKeptByAuthentication(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.Default()
+	kcsb := NewConnectionStringBuilder("https://test.kusto.windows.net").WithLogger(logger).WithAzCli()
+	assert.Equal(t, Logger(logger), kcsb.Logger)
+}
@@ -0,0 +1,156 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// The helpers of this file describe the whole cluster. They run in the context of db, which can be any database of the cluster.
+
+// CapacityResource is a kind of operation whose concurrency is limited by the capacity policy of the cluster.
+type CapacityResource string
+
+const (
+	IngestionsCapacity                       CapacityResource = "ingestions"
+	ExtentsMergeCapacity                     CapacityResource = "extents-merge"
+	ExtentsPartitionCapacity                 CapacityResource = "extents-partition"
+	DataExportCapacity                       CapacityResource = "data-export"
+	MaterializedViewCapacity                 CapacityResource = "materialized-view"
+	TableUpdatePolicyCapacity                CapacityResource = "table-update-policy"
+	StreamingIngestionPostProcessingCapacity CapacityResource = "streaming-ingestion-post-processing"
+	StoredQueryResultsCapacity               CapacityResource = "stored-query-results"
+	PurgeStorageArtifactsCleanupCapacity     CapacityResource = "purge-storage-artifacts-cleanup"
+	PeriodicStorageArtifactsCleanupCapacity  CapacityResource = "periodic-storage-artifacts-cleanup"
+	QueryAccelerationCapacity                CapacityResource = "query-acceleration"
+	GraphSnapshotCapacity                    CapacityResource = "graph-snapshot"
+)
+
+// Capacity is the capacity of the cluster for a resource, and how much of it is in use, as returned by `.show capacity`.
+type Capacity struct {
+	Resource  CapacityResource `kusto:"Resource"`
+	Total     int64            `kusto:"Total"`
+	Consumed  int64            `kusto:"Consumed"`
+	Remaining int64            `kusto:"Remaining"`
+	// Origin is the policy that decides Total, such as "CapacityPolicy".
+	Origin string `kusto:"Origin"`
+}
+
+// Utilization returns the fraction of the capacity in use, between 0 and 1. It returns 1 if the cluster has no capacity for the
+// resource.
+func (c Capacity) Utilization() float64 {
+	if c.Total <= 0 {
+		return 1
+	}
+	return float64(c.Consumed) / float64(c.Total)
+}
+
+// ClusterNode describes a node of the cluster, as returned by `.show cluster`.
+type ClusterNode struct {
+	NodeID                  string    `kusto:"NodeId"`
+	Address                 string    `kusto:"Address"`
+	Name                    string    `kusto:"Name"`
+	StartTime               time.Time `kusto:"StartTime"`
+	IsAdmin                 bool      `kusto:"IsAdmin"`
+	MachineTotalMemory      int64     `kusto:"MachineTotalMemory"`
+	MachineAvailableMemory  int64     `kusto:"MachineAvailableMemory"`
+	ProcessorCount          int       `kusto:"ProcessorCount"`
+	HotExtentsOriginalSize  int64     `kusto:"HotExtentsOriginalSize"`
+	HotExtentsSize          int64     `kusto:"HotExtentsSize"`
+	ColdExtentsOriginalSize int64     `kusto:"ColdExtentsOriginalSize"`
+	ColdExtentsSize         int64     `kusto:"ColdExtentsSize"`
+	HotExtentsCount         int64     `kusto:"HotExtentsCount"`
+	ColdExtentsCount        int64     `kusto:"ColdExtentsCount"`
+	// EnvironmentDescription is the JSON of the environment of the node, such as its SKU and region.
+	EnvironmentDescription string `kusto:"EnvironmentDescription"`
+	ProductVersion         string `kusto:"ProductVersion"`
+}
+
+// Diagnostics is the health of the cluster, as returned by `.show diagnostics`. The load and success rate factors are
+// percentages, between 0 and 100.
+type Diagnostics struct {
+	IsHealthy                          bool      `kusto:"IsHealthy"`
+	NotHealthyReason                   string    `kusto:"NotHealthyReason"`
+	IsAttentionRequired                bool      `kusto:"IsAttentionRequired"`
+	AttentionRequiredReason            string    `kusto:"AttentionRequiredReason"`
+	IsScaleOutRequired                 bool      `kusto:"IsScaleOutRequired"`
+	MachinesTotal                      int       `kusto:"MachinesTotal"`
+	MachinesOffline                    int       `kusto:"MachinesOffline"`
+	NodeLastRestartedOn                time.Time `kusto:"NodeLastRestartedOn"`
+	AdminLastElectedOn                 time.Time `kusto:"AdminLastElectedOn"`
+	MemoryLoadFactor                   float64   `kusto:"MemoryLoadFactor"`
+	ExtentsTotal                       int64     `kusto:"ExtentsTotal"`
+	DiskColdAllocationPercentage       int       `kusto:"DiskColdAllocationPercentage"`
+	InstancesTargetBasedOnDataCapacity int       `kusto:"InstancesTargetBasedOnDataCapacity"`
+	TotalOriginalDataSize              int64     `kusto:"TotalOriginalDataSize"`
+	TotalExtentSize                    int64     `kusto:"TotalExtentSize"`
+	IngestionsLoadFactor               float64   `kusto:"IngestionsLoadFactor"`
+	IngestionsInProgress               int64     `kusto:"IngestionsInProgress"`
+	IngestionsSuccessRate              float64   `kusto:"IngestionsSuccessRate"`
+	MergesInProgress                   int64     `kusto:"MergesInProgress"`
+	MergesSuccessRate                  float64   `kusto:"MergesSuccessRate"`
+	BuildVersion                       string    `kusto:"BuildVersion"`
+	BuildTime                          time.Time `kusto:"BuildTime"`
+	ClusterDataCapacityFactor          float64   `kusto:"ClusterDataCapacityFactor"`
+	IsDataWarmingRequired              bool      `kusto:"IsDataWarmingRequired"`
+	DataWarmingLastRunOn               time.Time `kusto:"DataWarmingLastRunOn"`
+	RebuildsInProgress                 int64     `kusto:"RebuildsInProgress"`
+	StoredQueryResultsCount            int64     `kusto:"StoredQueryResultsCount"`
+	MaterializedViewsInProgress        int64     `kusto:"MaterializedViewsInProgress"`
+	DataPartitioningLoadFactor         float64   `kusto:"DataPartitioningLoadFactor"`
+	ExportsLoadFactor                  float64   `kusto:"ExportsLoadFactor"`
+	ExportsInProgress                  int64     `kusto:"ExportsInProgress"`
+	ExportsSuccessRate                 float64   `kusto:"ExportsSuccessRate"`
+	ClusterCPULoadFactor               float64   `kusto:"ClusterCpuLoadFactor"`
+}
+
+// ShowCapacity returns the capacity of the cluster for each resource.
+func ShowCapacity(ctx context.Context, client Client, db string) ([]Capacity, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show capacity"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[Capacity](ds)
+}
+
+// ShowResourceCapacity returns the capacity of the cluster for resource.
+func ShowResourceCapacity(ctx context.Context, client Client, db string, resource CapacityResource) (Capacity, error) {
+	capacities, err := ShowCapacity(ctx, client, db)
+	if err != nil {
+		return Capacity{}, err
+	}
+	for _, c := range capacities {
+		if c.Resource == resource {
+			return c, nil
+		}
+	}
+	return Capacity{}, errors.ES(errors.OpMgmt, errors.KOther, "the cluster has no capacity for resource %q", resource)
+}
+
+// ShowCluster returns the nodes of the cluster.
+func ShowCluster(ctx context.Context, client Client, db string) ([]ClusterNode, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show cluster"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[ClusterNode](ds)
+}
+
+// ShowDiagnostics returns the health of the cluster.
+func ShowDiagnostics(ctx context.Context, client Client, db string) (Diagnostics, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show diagnostics"))
+	if err != nil {
+		return Diagnostics{}, err
+	}
+	rows, err := v1.ToStructs[Diagnostics](ds)
+	if err != nil {
+		return Diagnostics{}, err
+	}
+	if len(rows) == 0 {
+		return Diagnostics{}, errors.ES(errors.OpMgmt, errors.KInternal, "the diagnostics of the cluster are empty")
+	}
+	return rows[0], nil
+}
@@ -0,0 +1,114 @@
+package management
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showCapacityResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Resource","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Total","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"Consumed","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"Remaining","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"Origin","DataType":"String","ColumnType":"string"}],
+"Rows":[["ingestions",576,144,432,"CapacityPolicy"],["data-export",0,0,0,"CapacityPolicy"]]}]}`
+
+const showClusterResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"NodeId","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Address","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Name","DataType":"String","ColumnType":"string"},
+{"ColumnName":"StartTime","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"IsAdmin","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"MachineTotalMemory","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"MachineAvailableMemory","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"ProcessorCount","DataType":"Int32","ColumnType":"int"},
+{"ColumnName":"ProductVersion","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ClockDescription","DataType":"String","ColumnType":"string"}],
+"Rows":[["KENGINE000000","net.tcp://10.0.0.4:23107/","KENGINE000000","2024-01-02T00:00:00Z",true,68719476736,34359738368,8,"1.0.0","UTC"]]}]}`
+
+const showDiagnosticsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"IsHealthy","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"NotHealthyReason","DataType":"String","ColumnType":"string"},
+{"ColumnName":"IsScaleOutRequired","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"MachinesTotal","DataType":"Int32","ColumnType":"int"},
+{"ColumnName":"MachinesOffline","DataType":"Int32","ColumnType":"int"},
+{"ColumnName":"IngestionsLoadFactor","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"IngestionsInProgress","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"ExportsLoadFactor","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"ClusterCpuLoadFactor","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"BuildVersion","DataType":"String","ColumnType":"string"}],
+"Rows":[[false,"[KENGINE000001] is offline",true,2,1,25.0,144,0.5,80.5,"1.0.0"]]}]}`
+
+func TestCapacity(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(showCapacityResponse)
+
+	capacities, err := ShowCapacity(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []Capacity{
+		{Resource: IngestionsCapacity, Total: 576, Consumed: 144, Remaining: 432, Origin: "CapacityPolicy"},
+		{Resource: DataExportCapacity, Origin: "CapacityPolicy"},
+	}, capacities)
+	assert.Equal(t, 0.25, capacities[0].Utilization())
+	assert.Equal(t, 1.0, capacities[1].Utilization())
+
+	ingestions, err := ShowResourceCapacity(ctx, client, "db", IngestionsCapacity)
+	require.NoError(t, err)
+	assert.Equal(t, int64(432), ingestions.Remaining)
+
+	_, err = ShowResourceCapacity(ctx, client, "db", GraphSnapshotCapacity)
+	assert.Error(t, err)
+}
+
+func TestClusterDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(emptyResponse)
+	client.response = func(cmd string) string {
+		if strings.HasSuffix(cmd, "diagnostics") {
+			return showDiagnosticsResponse
+		}
+		return showClusterResponse
+	}
+
+	nodes, err := ShowCluster(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []ClusterNode{{
+		NodeID:                 "KENGINE000000",
+		Address:                "net.tcp://10.0.0.4:23107/",
+		Name:                   "KENGINE000000",
+		StartTime:              time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		IsAdmin:                true,
+		MachineTotalMemory:     68719476736,
+		MachineAvailableMemory: 34359738368,
+		ProcessorCount:         8,
+		ProductVersion:         "1.0.0",
+	}}, nodes)
+
+	diagnostics, err := ShowDiagnostics(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, Diagnostics{
+		NotHealthyReason:     "[KENGINE000001] is offline",
+		IsScaleOutRequired:   true,
+		MachinesTotal:        2,
+		MachinesOffline:      1,
+		IngestionsLoadFactor: 25,
+		IngestionsInProgress: 144,
+		ExportsLoadFactor:    0.5,
+		ClusterCPULoadFactor: 80.5,
+		BuildVersion:         "1.0.0",
+	}, diagnostics)
+
+	assert.Equal(t, []string{".show cluster", ".show diagnostics"}, client.commands)
+
+	_, err = ShowDiagnostics(ctx, newFakeClient(emptyResponse), "db")
+	assert.Error(t, err)
+}
@@ -0,0 +1,260 @@
+package management
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// MinimumExportInterval is the shortest interval between the runs of a continuous export Kusto accepts.
+const MinimumExportInterval = time.Minute
+
+// continuousExportOptions holds the properties of a continuous export set by ContinuousExportOption.
+type continuousExportOptions struct {
+	over            []string
+	forcedLatency   time.Duration
+	sizeLimit       int64
+	notDistributed  bool
+	managedIdentity string
+	disabled        bool
+}
+
+// ContinuousExportOption is an optional argument to CreateOrAlterContinuousExport.
+type ContinuousExportOption func(o *continuousExportOptions)
+
+// CEOver lists the tables whose new records are exported exactly once. The other tables of the query, such as dimension tables,
+// are read entirely in each run.
+func CEOver(tables ...string) ContinuousExportOption {
+	return func(o *continuousExportOptions) {
+		o.over = tables
+	}
+}
+
+// CEForcedLatency only exports the records ingested at least d ago, which lets late records of a join arrive.
+func CEForcedLatency(d time.Duration) ContinuousExportOption {
+	return func(o *continuousExportOptions) {
+		o.forcedLatency = d
+	}
+}
+
+// CESizeLimit sets the size, in bytes, of the uncompressed files written to storage.
+func CESizeLimit(bytes int64) ContinuousExportOption {
+	return func(o *continuousExportOptions) {
+		o.sizeLimit = bytes
+	}
+}
+
+// CENotDistributed exports from a single node, which writes fewer and larger files.
+func CENotDistributed() ContinuousExportOption {
+	return func(o *continuousExportOptions) {
+		o.notDistributed = true
+	}
+}
+
+// CEManagedIdentity runs the export as the managed identity, "system" or the object ID of a user assigned identity.
+// It is required if the query references tables with a row level security policy.
+func CEManagedIdentity(identity string) ContinuousExportOption {
+	return func(o *continuousExportOptions) {
+		o.managedIdentity = identity
+	}
+}
+
+// CEDisabled creates the continuous export disabled. It starts exporting once EnableContinuousExport is called.
+func CEDisabled() ContinuousExportOption {
+	return func(o *continuousExportOptions) {
+		o.disabled = true
+	}
+}
+
+// ContinuousExport describes a continuous export, as returned by `.show continuous-export`.
+type ContinuousExport struct {
+	Name                string        `kusto:"Name"`
+	ExternalTable       string        `kusto:"ExternalTableName"`
+	Query               string        `kusto:"Query"`
+	ForcedLatency       time.Duration `kusto:"ForcedLatency"`
+	IntervalBetweenRuns time.Duration `kusto:"IntervalBetweenRuns"`
+	CursorScopedTables  []string      `kusto:"CursorScopedTables"`
+	// ExportProperties is the JSON of the properties of the export, such as its size limit.
+	ExportProperties string `kusto:"ExportProperties"`
+	// ExportedTo is the time up to which the records were exported.
+	ExportedTo    time.Time `kusto:"ExportedTo"`
+	LastRunTime   time.Time `kusto:"LastRunTime"`
+	StartCursor   string    `kusto:"StartCursor"`
+	IsDisabled    bool      `kusto:"IsDisabled"`
+	LastRunResult string    `kusto:"LastRunResult"`
+	IsRunning     bool      `kusto:"IsRunning"`
+}
+
+// ExportedArtifact is a file written by a continuous export, as returned by `.show continuous-export exported-artifacts`.
+type ExportedArtifact struct {
+	Timestamp     time.Time `kusto:"Timestamp"`
+	ExternalTable string    `kusto:"ExternalTableName"`
+	Path          string    `kusto:"Path"`
+	NumRecords    int64     `kusto:"NumRecords"`
+	SizeInBytes   int64     `kusto:"SizeInBytes"`
+}
+
+// ExportFailure is a failed run of a continuous export, as returned by `.show continuous-export failures`.
+type ExportFailure struct {
+	Timestamp      time.Time `kusto:"Timestamp"`
+	OperationID    string    `kusto:"OperationId"`
+	Name           string    `kusto:"Name"`
+	LastSuccessRun time.Time `kusto:"LastSuccessRun"`
+	// FailureKind is "Failure" or "PartialFailure".
+	FailureKind string `kusto:"FailureKind"`
+	Details     string `kusto:"Details"`
+}
+
+// CreateOrAlterContinuousExport creates the continuous export name, which runs query every interval and writes its results for
+// the records ingested since the previous run to externalTable. If the export already exists, it is replaced, and continues from
+// where it stopped.
+func CreateOrAlterContinuousExport(ctx context.Context, client Client, db string, name string, externalTable string, interval time.Duration, query azkustodata.Statement, options ...ContinuousExportOption) error {
+	stmt, err := CreateOrAlterContinuousExportStatement(name, externalTable, interval, query, options...)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// DropContinuousExport drops the continuous export name. The files it wrote are kept.
+func DropContinuousExport(ctx context.Context, client Client, db string, name string) error {
+	return runContinuousExportCommand(ctx, client, db, kql.New(".drop continuous-export "), name)
+}
+
+// EnableContinuousExport resumes the continuous export name. It exports the records ingested while it was disabled.
+func EnableContinuousExport(ctx context.Context, client Client, db string, name string) error {
+	return runContinuousExportCommand(ctx, client, db, kql.New(".enable continuous-export "), name)
+}
+
+// DisableContinuousExport pauses the continuous export name.
+func DisableContinuousExport(ctx context.Context, client Client, db string, name string) error {
+	return runContinuousExportCommand(ctx, client, db, kql.New(".disable continuous-export "), name)
+}
+
+// ShowContinuousExports returns the continuous exports of the database.
+func ShowContinuousExports(ctx context.Context, client Client, db string) ([]ContinuousExport, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show continuous-exports"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[ContinuousExport](ds)
+}
+
+// ShowContinuousExport returns the continuous export name, including how far it exported and the result of its last run.
+func ShowContinuousExport(ctx context.Context, client Client, db string, name string) (ContinuousExport, error) {
+	stmt, err := continuousExportStatement(kql.New(".show continuous-export "), name)
+	if err != nil {
+		return ContinuousExport{}, err
+	}
+	return showOne[ContinuousExport](ctx, client, db, stmt, "continuous export", name)
+}
+
+// ShowExportedArtifacts returns the files written by the continuous export name.
+func ShowExportedArtifacts(ctx context.Context, client Client, db string, name string) ([]ExportedArtifact, error) {
+	stmt, err := continuousExportStatement(kql.New(".show continuous-export "), name)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt.AddLiteral(" exported-artifacts"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[ExportedArtifact](ds)
+}
+
+// ShowExportFailures returns the failed runs of the continuous export name.
+func ShowExportFailures(ctx context.Context, client Client, db string, name string) ([]ExportFailure, error) {
+	stmt, err := continuousExportStatement(kql.New(".show continuous-export "), name)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt.AddLiteral(" failures"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[ExportFailure](ds)
+}
+
+// CreateOrAlterContinuousExportStatement builds the `.create-or-alter continuous-export` command used by
+// CreateOrAlterContinuousExport.
+func CreateOrAlterContinuousExportStatement(name string, externalTable string, interval time.Duration, query azkustodata.Statement, options ...ContinuousExportOption) (azkustodata.Statement, error) {
+	opts := continuousExportOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	stmt, err := continuousExportStatement(kql.New(".create-or-alter continuous-export "), name)
+	if err != nil {
+		return nil, err
+	}
+	if externalTable == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "external table name must not be empty").SetNoRetry()
+	}
+	if interval < MinimumExportInterval {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "interval between runs must be at least %s, got %s", MinimumExportInterval, interval).SetNoRetry()
+	}
+	if opts.forcedLatency < 0 || opts.sizeLimit < 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "forced latency and size limit must not be negative").SetNoRetry()
+	}
+	if query == nil || query.String() == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "continuous export query must not be empty").SetNoRetry()
+	}
+
+	if len(opts.over) > 0 {
+		stmt.AddLiteral("\nover (")
+		for i, t := range opts.over {
+			if t == "" {
+				return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "over table[%d] is empty", i).SetNoRetry()
+			}
+			if i > 0 {
+				stmt.AddLiteral(", ")
+			}
+			stmt.AddTable(t)
+		}
+		stmt.AddLiteral(")")
+	}
+	stmt.AddLiteral("\nto table ").AddTable(externalTable)
+
+	props := &propertyList{stmt: stmt, trailing: true}
+	props.next().AddLiteral("intervalBetweenRuns=").AddTimespan(interval)
+	if opts.forcedLatency > 0 {
+		props.next().AddLiteral("forcedLatency=").AddTimespan(opts.forcedLatency)
+	}
+	if opts.sizeLimit > 0 {
+		// The limit is formatted from an int64, so it is safe to write as is.
+		props.next().AddLiteral("sizeLimit=").AddUnsafe(strconv.FormatInt(opts.sizeLimit, 10))
+	}
+	if opts.notDistributed {
+		props.next().AddLiteral("distributed=false")
+	}
+	if opts.managedIdentity != "" {
+		props.next().AddLiteral("managedIdentity=").AddString(opts.managedIdentity)
+	}
+	if opts.disabled {
+		props.next().AddLiteral("isDisabled=true")
+	}
+	props.close()
+
+	return stmt.AddLiteral("\n<| ").AddUnsafe(query.String()), nil
+}
+
+func runContinuousExportCommand(ctx context.Context, client Client, db string, stmt *kql.Builder, name string) error {
+	stmt, err := continuousExportStatement(stmt, name)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// continuousExportStatement completes stmt with the escaped continuous export name.
+func continuousExportStatement(stmt *kql.Builder, name string) (*kql.Builder, error) {
+	if name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "continuous export name must not be empty").SetNoRetry()
+	}
+	return stmt.AddTable(name), nil
+}
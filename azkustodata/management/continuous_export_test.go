@@ -0,0 +1,181 @@
+package management
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showContinuousExportResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Name","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ExternalTableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Query","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ForcedLatency","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"IntervalBetweenRuns","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"CursorScopedTables","DataType":"Object","ColumnType":"dynamic"},
+{"ColumnName":"ExportProperties","DataType":"Object","ColumnType":"dynamic"},
+{"ColumnName":"ExportedTo","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"LastRunTime","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"StartCursor","DataType":"String","ColumnType":"string"},
+{"ColumnName":"IsDisabled","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"LastRunResult","DataType":"String","ColumnType":"string"},
+{"ColumnName":"IsRunning","DataType":"Boolean","ColumnType":"bool"}],
+"Rows":[["ToLake","Archive","Events","00:10:00","01:00:00",["[db].[Events]"],{"SizeLimit":104857600},"2024-01-02T00:00:00Z","2024-01-02T00:10:00Z","000001",false,"Completed",false]]}]}`
+
+const showExportedArtifactsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Timestamp","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"ExternalTableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Path","DataType":"String","ColumnType":"string"},
+{"ColumnName":"NumRecords","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"SizeInBytes","DataType":"Int64","ColumnType":"long"}],
+"Rows":[["2024-01-02T00:10:00Z","Archive","https://acct.blob.core.windows.net/archive/1.parquet",1000,2048]]}]}`
+
+const showExportFailuresResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Timestamp","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"OperationId","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Name","DataType":"String","ColumnType":"string"},
+{"ColumnName":"LastSuccessRun","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"FailureKind","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Details","DataType":"String","ColumnType":"string"}],
+"Rows":[["2024-01-02T01:00:00Z","0cd1e4c9-5c2a-4a33-8a33-1e3fe1e3f6d4","ToLake","2024-01-02T00:10:00Z","Failure","storage unavailable"]]}]}`
+
+func TestContinuousExportStatement(t *testing.T) {
+	t.Parallel()
+
+	query := kql.New("Events | join kind=leftouter Tenants on TenantId")
+
+	tests := []struct {
+		desc     string
+		name     string
+		table    string
+		interval time.Duration
+		options  []ContinuousExportOption
+		want     string
+		err      bool
+	}{
+		{
+			desc:     "plain",
+			name:     "ToLake",
+			table:    "Archive",
+			interval: time.Hour,
+			want: ".create-or-alter continuous-export ToLake\nto table Archive\nwith (intervalBetweenRuns=timespan(01:00:00.0000000))\n" +
+				"<| Events | join kind=leftouter Tenants on TenantId",
+		},
+		{
+			desc:     "all options",
+			name:     "To Lake",
+			table:    "My Archive",
+			interval: 10 * time.Minute,
+			options: []ContinuousExportOption{
+				CEOver("Events"),
+				CEForcedLatency(5 * time.Minute),
+				CESizeLimit(104857600),
+				CENotDistributed(),
+				CEManagedIdentity("system"),
+				CEDisabled(),
+			},
+			want: ".create-or-alter continuous-export [\"To Lake\"]\nover (Events)\nto table [\"My Archive\"]\n" +
+				"with (intervalBetweenRuns=timespan(00:10:00.0000000), forcedLatency=timespan(00:05:00.0000000), sizeLimit=104857600, " +
+				"distributed=false, managedIdentity=\"system\", isDisabled=true)\n<| Events | join kind=leftouter Tenants on TenantId",
+		},
+		{desc: "no name", table: "Archive", interval: time.Hour, err: true},
+		{desc: "no table", name: "ToLake", interval: time.Hour, err: true},
+		{desc: "short interval", name: "ToLake", table: "Archive", interval: time.Second, err: true},
+		{desc: "negative size", name: "ToLake", table: "Archive", interval: time.Hour, options: []ContinuousExportOption{CESizeLimit(-1)}, err: true},
+		{desc: "empty over", name: "ToLake", table: "Archive", interval: time.Hour, options: []ContinuousExportOption{CEOver("")}, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := CreateOrAlterContinuousExportStatement(test.name, test.table, test.interval, query, test.options...)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+
+	_, err := CreateOrAlterContinuousExportStatement("ToLake", "Archive", time.Hour, kql.New(""))
+	assert.Error(t, err)
+}
+
+func TestContinuousExports(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(emptyResponse)
+	client.response = func(cmd string) string {
+		switch {
+		case strings.HasSuffix(cmd, "exported-artifacts"):
+			return showExportedArtifactsResponse
+		case strings.HasSuffix(cmd, "failures"):
+			return showExportFailuresResponse
+		case strings.HasPrefix(cmd, ".show"):
+			return showContinuousExportResponse
+		}
+		return emptyResponse
+	}
+
+	require.NoError(t, CreateOrAlterContinuousExport(ctx, client, "db", "ToLake", "Archive", time.Hour, kql.New("Events")))
+	require.NoError(t, DisableContinuousExport(ctx, client, "db", "ToLake"))
+	require.NoError(t, EnableContinuousExport(ctx, client, "db", "ToLake"))
+	require.NoError(t, DropContinuousExport(ctx, client, "db", "Old Export"))
+	assert.Error(t, DropContinuousExport(ctx, client, "db", ""))
+
+	export, err := ShowContinuousExport(ctx, client, "db", "ToLake")
+	require.NoError(t, err)
+	assert.Equal(t, ContinuousExport{
+		Name:                "ToLake",
+		ExternalTable:       "Archive",
+		Query:               "Events",
+		ForcedLatency:       10 * time.Minute,
+		IntervalBetweenRuns: time.Hour,
+		CursorScopedTables:  []string{"[db].[Events]"},
+		ExportProperties:    `{"SizeLimit":104857600}`,
+		ExportedTo:          time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		LastRunTime:         time.Date(2024, 1, 2, 0, 10, 0, 0, time.UTC),
+		StartCursor:         "000001",
+		LastRunResult:       "Completed",
+	}, export)
+
+	exports, err := ShowContinuousExports(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Len(t, exports, 1)
+
+	artifacts, err := ShowExportedArtifacts(ctx, client, "db", "ToLake")
+	require.NoError(t, err)
+	assert.Equal(t, []ExportedArtifact{{
+		Timestamp:     time.Date(2024, 1, 2, 0, 10, 0, 0, time.UTC),
+		ExternalTable: "Archive",
+		Path:          "https://acct.blob.core.windows.net/archive/1.parquet",
+		NumRecords:    1000,
+		SizeInBytes:   2048,
+	}}, artifacts)
+
+	failures, err := ShowExportFailures(ctx, client, "db", "ToLake")
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "storage unavailable", failures[0].Details)
+	assert.Equal(t, "Failure", failures[0].FailureKind)
+
+	assert.Equal(t, []string{
+		".create-or-alter continuous-export ToLake\nto table Archive\nwith (intervalBetweenRuns=timespan(01:00:00.0000000))\n<| Events",
+		".disable continuous-export ToLake",
+		".enable continuous-export ToLake",
+		`.drop continuous-export ["Old Export"]`,
+		".show continuous-export ToLake",
+		".show continuous-exports",
+		".show continuous-export ToLake exported-artifacts",
+		".show continuous-export ToLake failures",
+	}, client.commands)
+}
@@ -0,0 +1,182 @@
+package management
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/google/uuid"
+)
+
+// Extent describes a data shard of a table, as returned by `.show extents`. The sizes are in bytes.
+type Extent struct {
+	ID             string    `kusto:"ExtentId"`
+	Database       string    `kusto:"DatabaseName"`
+	Table          string    `kusto:"TableName"`
+	MinCreatedOn   time.Time `kusto:"MinCreatedOn"`
+	MaxCreatedOn   time.Time `kusto:"MaxCreatedOn"`
+	OriginalSize   float64   `kusto:"OriginalSize"`
+	ExtentSize     float64   `kusto:"ExtentSize"`
+	CompressedSize float64   `kusto:"CompressedSize"`
+	IndexSize      float64   `kusto:"IndexSize"`
+	RowCount       int64     `kusto:"RowCount"`
+	// Tags holds the tags of the extent, separated by white space. See TagList.
+	Tags string `kusto:"Tags"`
+}
+
+// TagList returns the tags of the extent, such as "drop-by:2024-01-02".
+func (e Extent) TagList() []string {
+	return strings.Fields(e.Tags)
+}
+
+// DroppedExtent is an extent dropped by DropExtentsByTags or DropExtentsOlderThan.
+type DroppedExtent struct {
+	ID        string    `kusto:"ExtentId"`
+	Table     string    `kusto:"TableName"`
+	CreatedOn time.Time `kusto:"CreatedOn"`
+}
+
+// ShowExtents returns the extents of the table, or of the database db if table is empty. If tags are given, only the extents that
+// have all of them are returned.
+func ShowExtents(ctx context.Context, client Client, db string, table string, tags ...string) ([]Extent, error) {
+	stmt, err := ShowExtentsStatement(db, table, tags...)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[Extent](ds)
+}
+
+// MergeExtents merges the extents of the table with the given IDs into fewer extents, and waits for the merge to end, like
+// WaitForCompletion. Merges usually run on their own, following the merge policy of the table; this is for rare cases, such as
+// extents left small by many ingestions of a backfill.
+func MergeExtents(ctx context.Context, client Client, db string, table string, extentIDs []string, options ...WaitOption) (Operation, error) {
+	stmt, err := MergeExtentsStatement(table, extentIDs)
+	if err != nil {
+		return Operation{}, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return Operation{}, err
+	}
+	rows, err := v1.ToStructs[operationRow](ds)
+	if err != nil {
+		return Operation{}, err
+	}
+	if len(rows) == 0 {
+		return Operation{}, errors.ES(errors.OpMgmt, errors.KInternal, "the merge of the extents of table %s returned no operation", table)
+	}
+	return WaitForCompletion(ctx, client, db, rows[0].OperationID, options...)
+}
+
+// DropExtentsByTags drops the extents of the table that have all the tags, such as the "drop-by:" tags given at ingestion, and
+// returns them. At least one tag must be given.
+func DropExtentsByTags(ctx context.Context, client Client, db string, table string, tags ...string) ([]DroppedExtent, error) {
+	if len(tags) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "at least one tag must be given to drop extents by tags").SetNoRetry()
+	}
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	show, err := ShowExtentsStatement(db, table, tags...)
+	if err != nil {
+		return nil, err
+	}
+	return dropExtents(ctx, client, db, kql.New(".drop extents <| ").AddUnsafe(show.String()))
+}
+
+// DropExtentsOlderThan drops the extents of the table, or of all the tables of the database db if table is empty, that were
+// created more than age ago, and returns them. age must be a whole number of hours.
+func DropExtentsOlderThan(ctx context.Context, client Client, db string, table string, age time.Duration) ([]DroppedExtent, error) {
+	stmt, err := DropExtentsOlderThanStatement(table, age)
+	if err != nil {
+		return nil, err
+	}
+	return dropExtents(ctx, client, db, stmt)
+}
+
+// ShowExtentsStatement builds the `.show extents` command used by ShowExtents.
+func ShowExtentsStatement(db string, table string, tags ...string) (azkustodata.Statement, error) {
+	var stmt *kql.Builder
+	switch {
+	case table != "":
+		stmt = kql.New(".show table ").AddTable(table).AddLiteral(" extents")
+	case db != "":
+		stmt = kql.New(".show database ").AddTable(db).AddLiteral(" extents")
+	default:
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "database or table name must not be empty").SetNoRetry()
+	}
+
+	for i, tag := range tags {
+		if tag == "" {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "tag[%d] is empty", i).SetNoRetry()
+		}
+		if i == 0 {
+			stmt.AddLiteral(" where tags has ")
+		} else {
+			stmt.AddLiteral(" and tags has ")
+		}
+		stmt.AddString(tag)
+	}
+	return stmt, nil
+}
+
+// MergeExtentsStatement builds the `.merge async` command used by MergeExtents.
+func MergeExtentsStatement(table string, extentIDs []string) (azkustodata.Statement, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	if len(extentIDs) < 2 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "at least two extents must be given to merge them").SetNoRetry()
+	}
+
+	stmt := kql.New(".merge async ").AddTable(table).AddLiteral(" (")
+	for i, id := range extentIDs {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "extent ID %q is not a GUID", id).SetNoRetry()
+		}
+		if i > 0 {
+			stmt.AddLiteral(", ")
+		}
+		// The ID was parsed as a GUID, so it is safe to write as is.
+		stmt.AddUnsafe(parsed.String())
+	}
+	return stmt.AddLiteral(")"), nil
+}
+
+// DropExtentsOlderThanStatement builds the `.drop extents older` command used by DropExtentsOlderThan.
+func DropExtentsOlderThanStatement(table string, age time.Duration) (azkustodata.Statement, error) {
+	const day = 24 * time.Hour
+	if age < time.Hour || age%time.Hour != 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "age must be a positive whole number of hours, got %s", age).SetNoRetry()
+	}
+
+	// The counts are formatted from an int64, so they are safe to write as is.
+	stmt := kql.New(".drop extents older ")
+	if age%day == 0 {
+		stmt.AddUnsafe(strconv.FormatInt(int64(age/day), 10)).AddLiteral(" days from ")
+	} else {
+		stmt.AddUnsafe(strconv.FormatInt(int64(age/time.Hour), 10)).AddLiteral(" hours from ")
+	}
+	if table == "" {
+		return stmt.AddLiteral("all tables"), nil
+	}
+	return stmt.AddTable(table), nil
+}
+
+func dropExtents(ctx context.Context, client Client, db string, stmt azkustodata.Statement) ([]DroppedExtent, error) {
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[DroppedExtent](ds)
+}
@@ -0,0 +1,163 @@
+package management
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showExtentsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"ExtentId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"MaxCreatedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"OriginalSize","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"ExtentSize","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"CompressedSize","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"IndexSize","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"Blocks","DataType":"Int32","ColumnType":"int"},
+{"ColumnName":"RowCount","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"MinCreatedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Tags","DataType":"String","ColumnType":"string"}],
+"Rows":[["6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d","db","Logs","2024-01-02T00:00:00Z",4096,1024,768,256,1,1000,"2024-01-01T00:00:00Z","drop-by:2024-01\r\ningest-by:batch1"]]}]}`
+
+const droppedExtentsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"ExtentId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"CreatedOn","DataType":"DateTime","ColumnType":"datetime"}],
+"Rows":[["6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d","Logs","2024-01-01T00:00:00Z"]]}]}`
+
+func TestExtentStatements(t *testing.T) {
+	t.Parallel()
+
+	ids := []string{"6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d", "7D2F2D5B-2C60-4D2F-8E4B-3A7C8F9B0D1E"}
+
+	tests := []struct {
+		desc  string
+		build func() (azkustodata.Statement, error)
+		want  string
+		err   bool
+	}{
+		{
+			desc: "show table extents by tags",
+			build: func() (azkustodata.Statement, error) {
+				return ShowExtentsStatement("db", "My Logs", "drop-by:2024-01", `o"brien`)
+			},
+			want: `.show table ["My Logs"] extents where tags has "drop-by:2024-01" and tags has "o\"brien"`,
+		},
+		{
+			desc:  "show database extents",
+			build: func() (azkustodata.Statement, error) { return ShowExtentsStatement("db", "") },
+			want:  ".show database db extents",
+		},
+		{
+			desc:  "merge",
+			build: func() (azkustodata.Statement, error) { return MergeExtentsStatement("Logs", ids) },
+			want:  ".merge async Logs (6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d, 7d2f2d5b-2c60-4d2f-8e4b-3a7c8f9b0d1e)",
+		},
+		{
+			desc:  "drop older than days",
+			build: func() (azkustodata.Statement, error) { return DropExtentsOlderThanStatement("Logs", 30*24*time.Hour) },
+			want:  ".drop extents older 30 days from Logs",
+		},
+		{
+			desc:  "drop older than hours from all tables",
+			build: func() (azkustodata.Statement, error) { return DropExtentsOlderThanStatement("", 36*time.Hour) },
+			want:  ".drop extents older 36 hours from all tables",
+		},
+		{desc: "show without names", build: func() (azkustodata.Statement, error) { return ShowExtentsStatement("", "") }, err: true},
+		{desc: "empty tag", build: func() (azkustodata.Statement, error) { return ShowExtentsStatement("db", "Logs", "") }, err: true},
+		{desc: "merge one extent", build: func() (azkustodata.Statement, error) { return MergeExtentsStatement("Logs", ids[:1]) }, err: true},
+		{
+			desc: "merge bad ID",
+			build: func() (azkustodata.Statement, error) {
+				return MergeExtentsStatement("Logs", []string{ids[0], "1) | x"})
+			},
+			err: true,
+		},
+		{desc: "partial hour", build: func() (azkustodata.Statement, error) { return DropExtentsOlderThanStatement("Logs", 90*time.Minute) }, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := test.build()
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+}
+
+func TestExtents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(emptyResponse)
+	client.response = func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, ".show operations"):
+			return showOperationsResponse(operationRowJSON("Completed", "2024-01-01T00:01:30Z", "", false))
+		case strings.HasPrefix(cmd, ".show"):
+			return showExtentsResponse
+		case strings.HasPrefix(cmd, ".merge"):
+			return operationResponse
+		}
+		return droppedExtentsResponse
+	}
+
+	extents, err := ShowExtents(ctx, client, "db", "Logs", "drop-by:2024-01")
+	require.NoError(t, err)
+	assert.Equal(t, []Extent{{
+		ID:             "6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d",
+		Database:       "db",
+		Table:          "Logs",
+		MinCreatedOn:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		MaxCreatedOn:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		OriginalSize:   4096,
+		ExtentSize:     1024,
+		CompressedSize: 768,
+		IndexSize:      256,
+		RowCount:       1000,
+		Tags:           "drop-by:2024-01\r\ningest-by:batch1",
+	}}, extents)
+	assert.Equal(t, []string{"drop-by:2024-01", "ingest-by:batch1"}, extents[0].TagList())
+
+	op, err := MergeExtents(ctx, client, "db", "Logs", []string{extents[0].ID, "7d2f2d5b-2c60-4d2f-8e4b-3a7c8f9b0d1e"}, WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, OperationCompleted, op.State)
+
+	dropped, err := DropExtentsByTags(ctx, client, "db", "Logs", "drop-by:2024-01")
+	require.NoError(t, err)
+	assert.Equal(t, []DroppedExtent{{
+		ID:        "6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d",
+		Table:     "Logs",
+		CreatedOn: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}, dropped)
+
+	_, err = DropExtentsOlderThan(ctx, client, "db", "", 7*24*time.Hour)
+	require.NoError(t, err)
+
+	_, err = DropExtentsByTags(ctx, client, "db", "Logs")
+	assert.Error(t, err)
+	_, err = DropExtentsByTags(ctx, client, "db", "", "drop-by:2024-01")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		`.show table Logs extents where tags has "drop-by:2024-01"`,
+		".merge async Logs (6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d, 7d2f2d5b-2c60-4d2f-8e4b-3a7c8f9b0d1e)",
+		".show operations " + operationID,
+		`.drop extents <| .show table Logs extents where tags has "drop-by:2024-01"`,
+		".drop extents older 7 days from all tables",
+	}, client.commands)
+}
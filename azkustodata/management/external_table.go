@@ -0,0 +1,408 @@
+package management
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+)
+
+// DataFormat is the format of the files of an external table.
+type DataFormat string
+
+const (
+	CSVFormat        DataFormat = "csv"
+	TSVFormat        DataFormat = "tsv"
+	JSONFormat       DataFormat = "json"
+	MultiJSONFormat  DataFormat = "multijson"
+	ParquetFormat    DataFormat = "parquet"
+	AvroFormat       DataFormat = "avro"
+	ApacheAvroFormat DataFormat = "apacheavro"
+	ORCFormat        DataFormat = "orc"
+	TXTFormat        DataFormat = "txt"
+	W3CLogFileFormat DataFormat = "w3clogfile"
+)
+
+var dataFormats = map[DataFormat]bool{
+	CSVFormat: true, TSVFormat: true, JSONFormat: true, MultiJSONFormat: true, ParquetFormat: true, AvroFormat: true,
+	ApacheAvroFormat: true, ORCFormat: true, TXTFormat: true, W3CLogFileFormat: true,
+}
+
+// Partition is a partition of an external table, built by StringPartition, HashPartition, DateTimePartition or VirtualPartition.
+type Partition struct {
+	name   string
+	typ    types.Column
+	column string
+	// modulus and bin are the arguments of hash() and bin(), if the partition uses them.
+	modulus int
+	bin     time.Duration
+}
+
+// StringPartition partitions the external table by the value of the string column.
+func StringPartition(name string, column string) Partition {
+	return Partition{name: name, typ: types.String, column: column}
+}
+
+// HashPartition partitions the external table by hash(column, modulus).
+func HashPartition(name string, column string, modulus int) Partition {
+	return Partition{name: name, typ: types.Long, column: column, modulus: modulus}
+}
+
+// DateTimePartition partitions the external table by the datetime column, rounded down to a multiple of bin. If bin is 0, the value
+// isn't rounded.
+func DateTimePartition(name string, column string, bin time.Duration) Partition {
+	return Partition{name: name, typ: types.DateTime, column: column, bin: bin}
+}
+
+// VirtualPartition is a partition that isn't stored in the files, but read from their path. It can be queried like a column.
+func VirtualPartition(name string, typ types.Column) Partition {
+	return Partition{name: name, typ: typ}
+}
+
+// PathElement is an element of the path format of an external table, built by PathText, PathPartition or PathDateTime.
+type PathElement struct {
+	text      string
+	partition string
+	pattern   string
+}
+
+// PathText is a constant part of the path, such as "/" or "year=".
+func PathText(text string) PathElement {
+	return PathElement{text: text}
+}
+
+// PathPartition is the value of the partition.
+func PathPartition(partition string) PathElement {
+	return PathElement{partition: partition}
+}
+
+// PathDateTime is the value of the datetime partition, formatted with pattern, such as "yyyy/MM/dd".
+func PathDateTime(pattern string, partition string) PathElement {
+	return PathElement{partition: partition, pattern: pattern}
+}
+
+// externalTableOptions holds the properties of an external table set by ExternalTableOption.
+type externalTableOptions struct {
+	partitions     []Partition
+	pathFormat     []PathElement
+	folder         string
+	docString      string
+	compressed     bool
+	includeHeaders bool
+	fileExtension  string
+	namePrefix     string
+}
+
+// ExternalTableOption is an optional argument to CreateExternalTable and CreateOrAlterExternalTable.
+type ExternalTableOption func(o *externalTableOptions)
+
+// ETPartitions partitions the external table.
+func ETPartitions(partitions ...Partition) ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.partitions = partitions
+	}
+}
+
+// ETPathFormat sets the format of the paths of the partitions, such as
+// `ETPathFormat(PathText("year="), PathDateTime("yyyy", "Date"), PathText("/"), PathPartition("Tenant"))`.
+// Without it, the partitions are separated by "/", in their order.
+func ETPathFormat(elements ...PathElement) ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.pathFormat = elements
+	}
+}
+
+// ETFolder sets the folder of the external table.
+func ETFolder(folder string) ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.folder = folder
+	}
+}
+
+// ETDocString sets the docstring of the external table.
+func ETDocString(docString string) ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.docString = docString
+	}
+}
+
+// ETCompressed declares that the files are compressed with gzip.
+func ETCompressed() ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.compressed = true
+	}
+}
+
+// ETIncludeHeaders declares that the CSV or TSV files have a header line, which is skipped.
+func ETIncludeHeaders() ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.includeHeaders = true
+	}
+}
+
+// ETFileExtension only reads the files with the given extension, such as ".parquet".
+func ETFileExtension(extension string) ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.fileExtension = extension
+	}
+}
+
+// ETNamePrefix only reads the files whose name starts with prefix.
+func ETNamePrefix(prefix string) ExternalTableOption {
+	return func(o *externalTableOptions) {
+		o.namePrefix = prefix
+	}
+}
+
+// ExternalTable describes an external table, as returned by `.show external table`.
+type ExternalTable struct {
+	Name      string `kusto:"TableName"`
+	TableType string `kusto:"TableType"`
+	Folder    string `kusto:"Folder"`
+	DocString string `kusto:"DocString"`
+	// Properties is the JSON of the properties of the table, such as its data format.
+	Properties string `kusto:"Properties"`
+	// ConnectionStrings are the connection strings of the table, with their secrets hidden.
+	ConnectionStrings []string `kusto:"ConnectionStrings"`
+	// Partitions is the JSON of the partitions of the table.
+	Partitions string `kusto:"Partitions"`
+	PathFormat string `kusto:"PathFormat"`
+}
+
+// ExternalTableArtifact is a file of an external table, as returned by `.show external table artifacts`.
+type ExternalTableArtifact struct {
+	URI string `kusto:"Uri"`
+	// Partition holds the values of the partitions of the file.
+	Partition map[string]interface{} `kusto:"Partition"`
+	Size      int64                  `kusto:"Size"`
+}
+
+// CreateExternalTable creates the external table name over the storage locations of connectionStrings, with the given columns and
+// data format. It fails if the table already exists.
+//
+// The connection strings must include a way to authenticate, such as ";impersonate", ";managed_identity=system" or a SAS token.
+// They are written as obfuscated string literals, so they aren't kept in the logs of Kusto.
+func CreateExternalTable(ctx context.Context, client Client, db string, name string, cols query.Columns, format DataFormat, connectionStrings []string, options ...ExternalTableOption) error {
+	stmt, err := CreateExternalTableStatement(name, cols, format, connectionStrings, options...)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// CreateOrAlterExternalTable creates the external table like CreateExternalTable, or replaces its definition if it already exists.
+func CreateOrAlterExternalTable(ctx context.Context, client Client, db string, name string, cols query.Columns, format DataFormat, connectionStrings []string, options ...ExternalTableOption) error {
+	stmt, err := CreateOrAlterExternalTableStatement(name, cols, format, connectionStrings, options...)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// DropExternalTable drops the external table name. The files it refers to are kept.
+func DropExternalTable(ctx context.Context, client Client, db string, name string) error {
+	stmt, err := externalTableStatement(kql.New(".drop external table "), name)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// ShowExternalTables returns the external tables of the database.
+func ShowExternalTables(ctx context.Context, client Client, db string) ([]ExternalTable, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show external tables"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[ExternalTable](ds)
+}
+
+// ShowExternalTable returns the external table name.
+func ShowExternalTable(ctx context.Context, client Client, db string, name string) (ExternalTable, error) {
+	stmt, err := externalTableStatement(kql.New(".show external table "), name)
+	if err != nil {
+		return ExternalTable{}, err
+	}
+	return showOne[ExternalTable](ctx, client, db, stmt, "external table", name)
+}
+
+// ShowExternalTableArtifacts returns the files the external table name reads, up to limit of them. If limit is 0, Kusto's default
+// limit applies.
+func ShowExternalTableArtifacts(ctx context.Context, client Client, db string, name string, limit int) ([]ExternalTableArtifact, error) {
+	if limit < 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "limit must not be negative, got %d", limit).SetNoRetry()
+	}
+	stmt, err := externalTableStatement(kql.New(".show external table "), name)
+	if err != nil {
+		return nil, err
+	}
+	stmt.AddLiteral(" artifacts")
+	if limit > 0 {
+		// The limit is formatted from an int, so it is safe to write as is.
+		stmt.AddLiteral(" limit ").AddUnsafe(strconv.Itoa(limit))
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[ExternalTableArtifact](ds)
+}
+
+// CreateExternalTableStatement builds the `.create external table` command used by CreateExternalTable.
+func CreateExternalTableStatement(name string, cols query.Columns, format DataFormat, connectionStrings []string, options ...ExternalTableOption) (azkustodata.Statement, error) {
+	return externalTableWithDefinition(kql.New(".create external table "), name, cols, format, connectionStrings, options)
+}
+
+// CreateOrAlterExternalTableStatement builds the `.create-or-alter external table` command used by CreateOrAlterExternalTable.
+func CreateOrAlterExternalTableStatement(name string, cols query.Columns, format DataFormat, connectionStrings []string, options ...ExternalTableOption) (azkustodata.Statement, error) {
+	return externalTableWithDefinition(kql.New(".create-or-alter external table "), name, cols, format, connectionStrings, options)
+}
+
+// externalTableWithDefinition completes stmt with the name and the definition of the external table.
+func externalTableWithDefinition(stmt *kql.Builder, name string, cols query.Columns, format DataFormat, connectionStrings []string, options []ExternalTableOption) (*kql.Builder, error) {
+	opts := externalTableOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	stmt, err := externalTableStatement(stmt, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "external table %s must have at least one column", name).SetNoRetry()
+	}
+	if !dataFormats[format] {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown data format %q", format).SetNoRetry()
+	}
+	if len(connectionStrings) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "external table %s must have at least one connection string", name).SetNoRetry()
+	}
+
+	stmt.AddLiteral(" ")
+	if err := addColumnList(stmt, cols); err != nil {
+		return nil, err
+	}
+	stmt.AddLiteral("\nkind=storage")
+
+	if err := addPartitions(stmt, opts.partitions, opts.pathFormat); err != nil {
+		return nil, err
+	}
+
+	stmt.AddLiteral("\ndataformat=").AddKeyword(string(format)).AddLiteral("\n(\n")
+	for i, cs := range connectionStrings {
+		if cs == "" {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "connection string[%d] is empty", i).SetNoRetry()
+		}
+		if i > 0 {
+			stmt.AddLiteral(",\n")
+		}
+		stmt.AddLiteral("h").AddString(cs)
+	}
+	stmt.AddLiteral("\n)")
+
+	props := &propertyList{stmt: stmt, trailing: true}
+	if opts.folder != "" {
+		props.next().AddLiteral("folder=").AddString(opts.folder)
+	}
+	if opts.docString != "" {
+		props.next().AddLiteral("docstring=").AddString(opts.docString)
+	}
+	if opts.compressed {
+		props.next().AddLiteral("compressed=true")
+	}
+	if opts.includeHeaders {
+		props.next().AddLiteral("includeHeaders=").AddString("All")
+	}
+	if opts.fileExtension != "" {
+		props.next().AddLiteral("fileExtension=").AddString(opts.fileExtension)
+	}
+	if opts.namePrefix != "" {
+		props.next().AddLiteral("namePrefix=").AddString(opts.namePrefix)
+	}
+	props.close()
+
+	return stmt, nil
+}
+
+// addPartitions writes the `partition by` and `pathformat` clauses, if there are partitions.
+func addPartitions(stmt *kql.Builder, partitions []Partition, pathFormat []PathElement) error {
+	if len(partitions) == 0 {
+		if len(pathFormat) > 0 {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "a path format requires partitions").SetNoRetry()
+		}
+		return nil
+	}
+
+	names := make(map[string]types.Column, len(partitions))
+	stmt.AddLiteral("\npartition by (")
+	for i, p := range partitions {
+		if p.name == "" {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "partition[%d] has no name", i).SetNoRetry()
+		}
+		if p.typ != types.String && p.typ != types.Long && p.typ != types.DateTime {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "partition %s has type %q, but must be a string, long or datetime", p.name, p.typ).SetNoRetry()
+		}
+		if p.modulus < 0 || p.bin < 0 {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "partition %s has a negative hash modulus or bin", p.name).SetNoRetry()
+		}
+		names[p.name] = p.typ
+
+		if i > 0 {
+			stmt.AddLiteral(", ")
+		}
+		stmt.AddColumn(p.name).AddLiteral(":").AddKeyword(string(p.typ))
+		switch {
+		case p.column == "":
+		case p.modulus > 0:
+			stmt.AddLiteral(" = hash(").AddColumn(p.column).AddLiteral(", ").AddLong(int64(p.modulus)).AddLiteral(")")
+		case p.bin > 0:
+			stmt.AddLiteral(" = bin(").AddColumn(p.column).AddLiteral(", ").AddTimespan(p.bin).AddLiteral(")")
+		default:
+			stmt.AddLiteral(" = ").AddColumn(p.column)
+		}
+	}
+	stmt.AddLiteral(")")
+
+	if len(pathFormat) == 0 {
+		return nil
+	}
+	stmt.AddLiteral("\npathformat=(")
+	for i, e := range pathFormat {
+		if i > 0 {
+			stmt.AddLiteral(" ")
+		}
+		if e.partition == "" {
+			stmt.AddString(e.text)
+			continue
+		}
+		typ, ok := names[e.partition]
+		if !ok {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "path format refers to unknown partition %s", e.partition).SetNoRetry()
+		}
+		if e.pattern == "" {
+			stmt.AddColumn(e.partition)
+			continue
+		}
+		if typ != types.DateTime {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "path format has a datetime pattern for partition %s of type %s", e.partition, typ).SetNoRetry()
+		}
+		stmt.AddLiteral("datetime_pattern(").AddString(e.pattern).AddLiteral(", ").AddColumn(e.partition).AddLiteral(")")
+	}
+	stmt.AddLiteral(")")
+	return nil
+}
+
+// externalTableStatement completes stmt with the escaped external table name.
+func externalTableStatement(stmt *kql.Builder, name string) (*kql.Builder, error) {
+	if name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "external table name must not be empty").SetNoRetry()
+	}
+	return stmt.AddTable(name), nil
+}
@@ -0,0 +1,199 @@
+package management
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showExternalTableResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"TableType","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Folder","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DocString","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Properties","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ConnectionStrings","DataType":"Object","ColumnType":"dynamic"},
+{"ColumnName":"Partitions","DataType":"Object","ColumnType":"dynamic"},
+{"ColumnName":"PathFormat","DataType":"String","ColumnType":"string"}],
+"Rows":[["Archive","Blob","Lake","","{\"Format\":\"Parquet\"}",["https://acct.blob.core.windows.net/archive;******"],[{"ColumnName":"Id","Mod":10,"Name":"Part","Ordinal":0}],""]]}]}`
+
+const showArtifactsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Uri","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Partition","DataType":"Object","ColumnType":"dynamic"},
+{"ColumnName":"Size","DataType":"Int64","ColumnType":"long"}],
+"Rows":[["https://acct.blob.core.windows.net/archive/2024/01/01/a.parquet",{"Date":"2024-01-01T00:00:00Z"},1024]]}]}`
+
+func TestExternalTableStatements(t *testing.T) {
+	t.Parallel()
+
+	cols := query.Columns{
+		query.NewColumn(0, "Timestamp", types.DateTime),
+		query.NewColumn(1, "Tenant Id", types.String),
+		query.NewColumn(2, "Value", types.Real),
+	}
+	conn := []string{`https://acct.blob.core.windows.net/archive;managed_identity=system`}
+
+	tests := []struct {
+		desc    string
+		alter   bool
+		name    string
+		format  DataFormat
+		conn    []string
+		options []ExternalTableOption
+		want    string
+		err     bool
+	}{
+		{
+			desc:   "plain",
+			name:   "Archive",
+			format: ParquetFormat,
+			conn:   conn,
+			want: ".create external table Archive (Timestamp:datetime, [\"Tenant Id\"]:string, Value:real)\nkind=storage\ndataformat=parquet\n(\n" +
+				"h\"https://acct.blob.core.windows.net/archive;managed_identity=system\"\n)",
+		},
+		{
+			desc:   "partitioned",
+			alter:  true,
+			name:   "Archive",
+			format: CSVFormat,
+			conn:   []string{conn[0], `https://acct2.blob.core.windows.net/archive?sv=2024&sig=a"b`},
+			options: []ExternalTableOption{
+				ETPartitions(
+					DateTimePartition("Date", "Timestamp", 24*time.Hour),
+					StringPartition("Tenant", "Tenant Id"),
+					HashPartition("Shard", "Tenant Id", 10),
+					VirtualPartition("Region", types.String),
+				),
+				ETPathFormat(PathText("date="), PathDateTime("yyyy/MM/dd", "Date"), PathText("/"), PathPartition("Tenant"), PathText("/"), PathPartition("Region")),
+				ETFolder("Lake"),
+				ETDocString("archived events"),
+				ETCompressed(),
+				ETIncludeHeaders(),
+				ETFileExtension(".csv.gz"),
+				ETNamePrefix("part-"),
+			},
+			want: ".create-or-alter external table Archive (Timestamp:datetime, [\"Tenant Id\"]:string, Value:real)\nkind=storage\n" +
+				"partition by (Date:datetime = bin(Timestamp, timespan(1.00:00:00.0000000)), Tenant:string = [\"Tenant Id\"], Shard:long = hash([\"Tenant Id\"], long(10)), Region:string)\n" +
+				"pathformat=(\"date=\" datetime_pattern(\"yyyy/MM/dd\", Date) \"/\" Tenant \"/\" Region)\n" +
+				"dataformat=csv\n(\nh\"https://acct.blob.core.windows.net/archive;managed_identity=system\",\nh\"https://acct2.blob.core.windows.net/archive?sv=2024&sig=a\\\"b\"\n)\n" +
+				"with (folder=\"Lake\", docstring=\"archived events\", compressed=true, includeHeaders=\"All\", fileExtension=\".csv.gz\", namePrefix=\"part-\")",
+		},
+		{desc: "no name", format: ParquetFormat, conn: conn, err: true},
+		{desc: "unknown format", name: "Archive", format: "xlsx", conn: conn, err: true},
+		{desc: "no connection strings", name: "Archive", format: ParquetFormat, err: true},
+		{desc: "empty connection string", name: "Archive", format: ParquetFormat, conn: []string{""}, err: true},
+		{
+			desc:    "path format without partitions",
+			name:    "Archive",
+			format:  ParquetFormat,
+			conn:    conn,
+			options: []ExternalTableOption{ETPathFormat(PathText("a"))},
+			err:     true,
+		},
+		{
+			desc:    "unknown path partition",
+			name:    "Archive",
+			format:  ParquetFormat,
+			conn:    conn,
+			options: []ExternalTableOption{ETPartitions(StringPartition("Tenant", "Tenant Id")), ETPathFormat(PathPartition("Date"))},
+			err:     true,
+		},
+		{
+			desc:    "datetime pattern of a string",
+			name:    "Archive",
+			format:  ParquetFormat,
+			conn:    conn,
+			options: []ExternalTableOption{ETPartitions(StringPartition("Tenant", "Tenant Id")), ETPathFormat(PathDateTime("yyyy", "Tenant"))},
+			err:     true,
+		},
+		{
+			desc:    "partition type",
+			name:    "Archive",
+			format:  ParquetFormat,
+			conn:    conn,
+			options: []ExternalTableOption{ETPartitions(VirtualPartition("Flag", types.Bool))},
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			create := CreateExternalTableStatement
+			if test.alter {
+				create = CreateOrAlterExternalTableStatement
+			}
+			stmt, err := create(test.name, cols, test.format, test.conn, test.options...)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+}
+
+func TestExternalTables(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(emptyResponse)
+	client.response = func(cmd string) string {
+		switch {
+		case strings.HasSuffix(cmd, "limit 100"):
+			return showArtifactsResponse
+		case strings.HasPrefix(cmd, ".show"):
+			return showExternalTableResponse
+		}
+		return emptyResponse
+	}
+	cols := query.Columns{query.NewColumn(0, "Id", types.String)}
+
+	require.NoError(t, CreateExternalTable(ctx, client, "db", "Archive", cols, ParquetFormat, []string{"https://acct/archive;impersonate"}))
+	require.NoError(t, CreateOrAlterExternalTable(ctx, client, "db", "Archive", cols, ParquetFormat, []string{"https://acct/archive;impersonate"}))
+	require.NoError(t, DropExternalTable(ctx, client, "db", "Old Archive"))
+
+	table, err := ShowExternalTable(ctx, client, "db", "Archive")
+	require.NoError(t, err)
+	assert.Equal(t, ExternalTable{
+		Name:              "Archive",
+		TableType:         "Blob",
+		Folder:            "Lake",
+		Properties:        `{"Format":"Parquet"}`,
+		ConnectionStrings: []string{"https://acct.blob.core.windows.net/archive;******"},
+		Partitions:        `[{"ColumnName":"Id","Mod":10,"Name":"Part","Ordinal":0}]`,
+	}, table)
+
+	tables, err := ShowExternalTables(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Len(t, tables, 1)
+
+	artifacts, err := ShowExternalTableArtifacts(ctx, client, "db", "Archive", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []ExternalTableArtifact{{
+		URI:       "https://acct.blob.core.windows.net/archive/2024/01/01/a.parquet",
+		Partition: map[string]interface{}{"Date": "2024-01-01T00:00:00Z"},
+		Size:      1024,
+	}}, artifacts)
+
+	_, err = ShowExternalTableArtifacts(ctx, client, "db", "Archive", -1)
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		".create external table Archive (Id:string)\nkind=storage\ndataformat=parquet\n(\nh\"https://acct/archive;impersonate\"\n)",
+		".create-or-alter external table Archive (Id:string)\nkind=storage\ndataformat=parquet\n(\nh\"https://acct/archive;impersonate\"\n)",
+		`.drop external table ["Old Archive"]`,
+		".show external table Archive",
+		".show external tables",
+		".show external table Archive artifacts limit 100",
+	}, client.commands)
+}
@@ -0,0 +1,179 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// The helpers of this file manage the databases a cluster follows from a leader cluster. They must be run against the follower
+// cluster, with the name of the followed database.
+
+// ModificationKind decides how the overrides of a follower database combine with the settings of the leader database.
+type ModificationKind string
+
+const (
+	// ModificationNone keeps the settings of the leader database, and ignores the overrides.
+	ModificationNone ModificationKind = "none"
+	// ModificationUnion applies the overrides on top of the settings of the leader database.
+	ModificationUnion ModificationKind = "union"
+	// ModificationReplace replaces the settings of the leader database with the overrides.
+	ModificationReplace ModificationKind = "replace"
+)
+
+// FollowerDatabase describes a follower database, as returned by `.show follower database`.
+type FollowerDatabase struct {
+	Name                      string `kusto:"DatabaseName"`
+	LeaderClusterMetadataPath string `kusto:"LeaderClusterMetadataPath"`
+	// CachingPolicyOverride is the JSON of the caching policy override of the database, if it has one.
+	CachingPolicyOverride string `kusto:"CachingPolicyOverride"`
+	// AuthorizedPrincipalsOverride is the JSON of the principals added to the database.
+	AuthorizedPrincipalsOverride         string           `kusto:"AuthorizedPrincipalsOverride"`
+	AuthorizedPrincipalsModificationKind ModificationKind `kusto:"AuthorizedPrincipalsModificationKind"`
+	IsAutoPrefetchEnabled                bool             `kusto:"IsAutoPrefetchEnabled"`
+	// TableMetadataOverrides is the JSON of the overrides of the tables, such as their caching policies.
+	TableMetadataOverrides          string           `kusto:"TableMetadataOverrides"`
+	CachingPoliciesModificationKind ModificationKind `kusto:"CachingPoliciesModificationKind"`
+}
+
+// ShowFollowerDatabases returns the databases the cluster follows.
+func ShowFollowerDatabases(ctx context.Context, client Client, db string) ([]FollowerDatabase, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show follower databases"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[FollowerDatabase](ds)
+}
+
+// ShowFollowerDatabase returns the follower database db.
+func ShowFollowerDatabase(ctx context.Context, client Client, db string) (FollowerDatabase, error) {
+	stmt, err := followerStatement(kql.New(".show "), db)
+	if err != nil {
+		return FollowerDatabase{}, err
+	}
+	return showOne[FollowerDatabase](ctx, client, db, stmt, "follower database", db)
+}
+
+// AlterFollowerCachingPolicy overrides the caching policy of the follower database db, or of its table if table isn't empty, so
+// hot days of data are cached. Whether it applies depends on the caching policies modification kind of the database.
+func AlterFollowerCachingPolicy(ctx context.Context, client Client, db string, table string, hot time.Duration) error {
+	if hot < 0 {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "hot span must not be negative, got %s", hot).SetNoRetry()
+	}
+	stmt, err := followerPolicyStatement(kql.New(".alter "), db, table)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt.AddLiteral(" policy caching hot = ").AddTimespan(hot))
+}
+
+// DeleteFollowerCachingPolicy removes the caching policy override of the follower database db, or of its table if table isn't
+// empty.
+func DeleteFollowerCachingPolicy(ctx context.Context, client Client, db string, table string) error {
+	stmt, err := followerPolicyStatement(kql.New(".delete "), db, table)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt.AddLiteral(" policy caching"))
+}
+
+// AddFollowerPrincipals grants the role on the follower database db to the principals. IngestorsRole can't be granted, since
+// follower databases are read-only.
+func AddFollowerPrincipals(ctx context.Context, client Client, db string, role Role, principals []Principal, notes string) error {
+	stmt, err := followerPrincipalsStatement(kql.New(".add follower "), db, role, principals)
+	if err != nil {
+		return err
+	}
+	if notes != "" {
+		stmt.AddLiteral(" ").AddString(notes)
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// DropFollowerPrincipals revokes the role on the follower database db from the principals.
+func DropFollowerPrincipals(ctx context.Context, client Client, db string, role Role, principals []Principal) error {
+	stmt, err := followerPrincipalsStatement(kql.New(".drop follower "), db, role, principals)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// AlterFollowerPrincipalsModificationKind sets how the principals added to the follower database db combine with the principals
+// of the leader database.
+func AlterFollowerPrincipalsModificationKind(ctx context.Context, client Client, db string, kind ModificationKind) error {
+	stmt, err := followerStatement(kql.New(".alter "), db)
+	if err != nil {
+		return err
+	}
+	if err := validateModificationKind(kind); err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt.AddLiteral(" principals-modification-kind = ").AddKeyword(string(kind)))
+}
+
+// AlterFollowerCachingPoliciesModificationKind sets how the caching policy overrides of the follower database db combine with
+// the caching policies of the leader database.
+func AlterFollowerCachingPoliciesModificationKind(ctx context.Context, client Client, db string, kind ModificationKind) error {
+	stmt, err := followerStatement(kql.New(".alter "), db)
+	if err != nil {
+		return err
+	}
+	if err := validateModificationKind(kind); err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt.AddLiteral(" caching-policies-modification-kind = ").AddKeyword(string(kind)))
+}
+
+// AlterFollowerPrefetchExtents sets whether the follower database db caches the new data of the leader database before
+// querying it.
+func AlterFollowerPrefetchExtents(ctx context.Context, client Client, db string, prefetch bool) error {
+	stmt, err := followerStatement(kql.New(".alter "), db)
+	if err != nil {
+		return err
+	}
+	if prefetch {
+		return run(ctx, client, db, stmt.AddLiteral(" prefetch-extents = true"))
+	}
+	return run(ctx, client, db, stmt.AddLiteral(" prefetch-extents = false"))
+}
+
+func validateModificationKind(kind ModificationKind) error {
+	switch kind {
+	case ModificationNone, ModificationUnion, ModificationReplace:
+		return nil
+	}
+	return errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown modification kind %q", kind).SetNoRetry()
+}
+
+// followerPrincipalsStatement completes stmt, which holds the verb of the command and "follower", with the database, the role and
+// the principals.
+func followerPrincipalsStatement(stmt *kql.Builder, db string, role Role, principals []Principal) (*kql.Builder, error) {
+	if role == IngestorsRole {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "role %q can't be granted on a follower database", role).SetNoRetry()
+	}
+	return principalsStatement(stmt, DatabaseScope(db), role, principals)
+}
+
+// followerPolicyStatement completes stmt with the follower database, and the table if it isn't empty.
+func followerPolicyStatement(stmt *kql.Builder, db string, table string) (*kql.Builder, error) {
+	stmt, err := followerStatement(stmt, db)
+	if err != nil {
+		return nil, err
+	}
+	if table != "" {
+		stmt.AddLiteral(" table ").AddTable(table)
+	}
+	return stmt, nil
+}
+
+// followerStatement completes stmt with `follower database db`.
+func followerStatement(stmt *kql.Builder, db string) (*kql.Builder, error) {
+	if db == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "database name must not be empty").SetNoRetry()
+	}
+	return stmt.AddLiteral("follower database ").AddTable(db), nil
+}
@@ -0,0 +1,75 @@
+package management
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showFollowerDatabaseResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"LeaderClusterMetadataPath","DataType":"String","ColumnType":"string"},
+{"ColumnName":"CachingPolicyOverride","DataType":"String","ColumnType":"string"},
+{"ColumnName":"AuthorizedPrincipalsOverride","DataType":"String","ColumnType":"string"},
+{"ColumnName":"AuthorizedPrincipalsModificationKind","DataType":"String","ColumnType":"string"},
+{"ColumnName":"IsAutoPrefetchEnabled","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"TableMetadataOverrides","DataType":"String","ColumnType":"string"},
+{"ColumnName":"CachingPoliciesModificationKind","DataType":"String","ColumnType":"string"}],
+"Rows":[["db","https://leader.blob.core.windows.net/metadata","{\"DataHotSpan\":\"7.00:00:00\"}","[]","union",true,"{}","replace"]]}]}`
+
+func TestFollowerDatabases(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(showFollowerDatabaseResponse)
+	app := AppPrincipal("5a4b", "contoso.com")
+
+	db, err := ShowFollowerDatabase(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, FollowerDatabase{
+		Name:                                 "db",
+		LeaderClusterMetadataPath:            "https://leader.blob.core.windows.net/metadata",
+		CachingPolicyOverride:                `{"DataHotSpan":"7.00:00:00"}`,
+		AuthorizedPrincipalsOverride:         "[]",
+		AuthorizedPrincipalsModificationKind: ModificationUnion,
+		IsAutoPrefetchEnabled:                true,
+		TableMetadataOverrides:               "{}",
+		CachingPoliciesModificationKind:      ModificationReplace,
+	}, db)
+
+	dbs, err := ShowFollowerDatabases(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Len(t, dbs, 1)
+
+	require.NoError(t, AlterFollowerCachingPolicy(ctx, client, "db", "", 7*24*time.Hour))
+	require.NoError(t, AlterFollowerCachingPolicy(ctx, client, "db", "My T", time.Hour))
+	require.NoError(t, DeleteFollowerCachingPolicy(ctx, client, "db", ""))
+	require.NoError(t, DeleteFollowerCachingPolicy(ctx, client, "db", "T"))
+	require.NoError(t, AddFollowerPrincipals(ctx, client, "db", ViewersRole, []Principal{app}, "readers"))
+	require.NoError(t, DropFollowerPrincipals(ctx, client, "db", ViewersRole, []Principal{app}))
+	require.NoError(t, AlterFollowerPrincipalsModificationKind(ctx, client, "db", ModificationReplace))
+	require.NoError(t, AlterFollowerCachingPoliciesModificationKind(ctx, client, "db", ModificationNone))
+	require.NoError(t, AlterFollowerPrefetchExtents(ctx, client, "db", false))
+
+	assert.Error(t, AlterFollowerCachingPolicy(ctx, client, "db", "", -time.Hour))
+	assert.Error(t, AddFollowerPrincipals(ctx, client, "db", IngestorsRole, []Principal{app}, ""))
+	assert.Error(t, AlterFollowerPrincipalsModificationKind(ctx, client, "db", "merge"))
+	assert.Error(t, AlterFollowerPrefetchExtents(ctx, client, "", true))
+
+	assert.Equal(t, []string{
+		".show follower database db",
+		".show follower databases",
+		".alter follower database db policy caching hot = timespan(7.00:00:00.0000000)",
+		`.alter follower database db table ["My T"] policy caching hot = timespan(01:00:00.0000000)`,
+		".delete follower database db policy caching",
+		".delete follower database db table T policy caching",
+		`.add follower database db viewers ("aadapp=5a4b;contoso.com") "readers"`,
+		`.drop follower database db viewers ("aadapp=5a4b;contoso.com")`,
+		".alter follower database db principals-modification-kind = replace",
+		".alter follower database db caching-policies-modification-kind = none",
+		".alter follower database db prefetch-extents = false",
+	}, client.commands)
+}
@@ -0,0 +1,185 @@
+/*
+Package functions manages the stored functions of a Kusto database, for deploying them from Go code:
+
+	params := []functions.Parameter{{Name: "since", Type: types.Timespan, Default: value.NewTimespan(time.Hour)}}
+	body := kql.New("Events | where Timestamp > ago(since)")
+	err := functions.CreateOrAlter(ctx, client, "db", "RecentEvents", params, body, "Events of the last period", "Events")
+
+Names, types, default values, docstrings and folders are escaped as needed.
+*/
+package functions
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Parameter is a parameter of a function.
+type Parameter struct {
+	// Name is the name of the parameter.
+	Name string
+	// Type is the type of a scalar parameter.
+	Type types.Column
+	// Default is the optional default value of a scalar parameter. Parameters with a default value must come last.
+	Default value.Kusto
+	// Tabular makes the parameter a table, and Type and Default are ignored.
+	Tabular bool
+	// Columns are the columns a tabular parameter must have. If empty, any table is accepted.
+	Columns []Parameter
+}
+
+// Function describes a stored function, as returned by `.show functions`.
+type Function struct {
+	Name string `kusto:"Name"`
+	// Parameters are the parameters of the function as written in its declaration, such as "(since:timespan)".
+	Parameters string `kusto:"Parameters"`
+	Body       string `kusto:"Body"`
+	Folder     string `kusto:"Folder"`
+	DocString  string `kusto:"DocString"`
+}
+
+// CreateOrAlter creates the function, or replaces it if it already exists. docString and folder are optional.
+func CreateOrAlter(ctx context.Context, client management.Client, db string, name string, params []Parameter, body azkustodata.Statement, docString string, folder string) error {
+	stmt, err := CreateOrAlterStatement(name, params, body, docString, folder)
+	if err != nil {
+		return err
+	}
+	_, err = client.Mgmt(ctx, db, stmt)
+	return err
+}
+
+// Drop drops the function.
+func Drop(ctx context.Context, client management.Client, db string, name string) error {
+	stmt, err := functionStatement(kql.New(".drop function "), name)
+	if err != nil {
+		return err
+	}
+	_, err = client.Mgmt(ctx, db, stmt)
+	return err
+}
+
+// List returns the functions of the database.
+func List(ctx context.Context, client management.Client, db string) ([]Function, error) {
+	return show(ctx, client, db, kql.New(".show functions"))
+}
+
+// Show returns the function name.
+func Show(ctx context.Context, client management.Client, db string, name string) (Function, error) {
+	stmt, err := functionStatement(kql.New(".show function "), name)
+	if err != nil {
+		return Function{}, err
+	}
+	fns, err := show(ctx, client, db, stmt)
+	if err != nil {
+		return Function{}, err
+	}
+	if len(fns) == 0 {
+		return Function{}, errors.ES(errors.OpMgmt, errors.KOther, "function %q was not found in database %q", name, db)
+	}
+	return fns[0], nil
+}
+
+// CreateOrAlterStatement builds the `.create-or-alter function` command used by CreateOrAlter.
+func CreateOrAlterStatement(name string, params []Parameter, body azkustodata.Statement, docString string, folder string) (azkustodata.Statement, error) {
+	if body == nil || body.String() == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "function body must not be empty").SetNoRetry()
+	}
+
+	stmt := kql.New(".create-or-alter function ")
+	if docString != "" || folder != "" {
+		stmt.AddLiteral("with (")
+		if docString != "" {
+			stmt.AddLiteral("docstring=").AddString(docString)
+		}
+		if folder != "" {
+			if docString != "" {
+				stmt.AddLiteral(", ")
+			}
+			stmt.AddLiteral("folder=").AddString(folder)
+		}
+		stmt.AddLiteral(") ")
+	}
+
+	stmt, err := functionStatement(stmt, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := addParameters(stmt, params, true); err != nil {
+		return nil, err
+	}
+	return stmt.AddLiteral("\n{\n").AddUnsafe(body.String()).AddLiteral("\n}"), nil
+}
+
+// addParameters writes the parenthesized list of params. Defaults and tabular parameters are only allowed at the top level.
+func addParameters(stmt *kql.Builder, params []Parameter, topLevel bool) error {
+	stmt.AddLiteral("(")
+	seenDefault := false
+	for i, p := range params {
+		if i > 0 {
+			stmt.AddLiteral(", ")
+		}
+		if p.Name == "" {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "parameter[%d] has no name", i).SetNoRetry()
+		}
+		stmt.AddColumn(p.Name).AddLiteral(":")
+
+		if p.Tabular {
+			if !topLevel {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s of a tabular parameter can't be tabular", p.Name).SetNoRetry()
+			}
+			if len(p.Columns) == 0 {
+				stmt.AddLiteral("(*)")
+			} else if err := addParameters(stmt, p.Columns, false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if value.Default(p.Type) == nil {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "parameter %s has an unknown type %q", p.Name, p.Type).SetNoRetry()
+		}
+		stmt.AddKeyword(string(p.Type))
+
+		if p.Default != nil {
+			if !topLevel {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s of a tabular parameter can't have a default value", p.Name).SetNoRetry()
+			}
+			if p.Default.GetType() != p.Type {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "parameter %s of type %s has a default value of type %s", p.Name, p.Type, p.Default.GetType()).SetNoRetry()
+			}
+			stmt.AddLiteral("=").AddValue(p.Default)
+			seenDefault = true
+		} else if seenDefault {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "parameter %s has no default value, but follows a parameter that has one", p.Name).SetNoRetry()
+		}
+	}
+	stmt.AddLiteral(")")
+	return nil
+}
+
+// functionStatement completes stmt with the escaped function name.
+func functionStatement(stmt *kql.Builder, name string) (*kql.Builder, error) {
+	if name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "function name must not be empty").SetNoRetry()
+	}
+	return stmt.AddFunction(name), nil
+}
+
+func show(ctx context.Context, client management.Client, db string, stmt azkustodata.Statement) ([]Function, error) {
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command returned no tables")
+	}
+	return query.ToStructs[Function](tables[0])
+}
@@ -0,0 +1,167 @@
+package functions
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showFunctionsHeader = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Name","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Parameters","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Body","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Folder","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DocString","DataType":"String","ColumnType":"string"}],
+"Rows":[`
+
+// fakeClient answers every command with a `.show functions` result holding rows, and records the commands.
+type fakeClient struct {
+	rows     string
+	commands []string
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, _ string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	f.commands = append(f.commands, kqlQuery.String())
+	body := showFunctionsHeader + f.rows + `]}]}`
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(body)))
+}
+
+func TestCreateOrAlterStatement(t *testing.T) {
+	t.Parallel()
+
+	body := kql.New("Events | where Timestamp > ago(since) | where Name == \"}\"")
+
+	tests := []struct {
+		desc      string
+		name      string
+		params    []Parameter
+		docString string
+		folder    string
+		want      string
+		err       bool
+	}{
+		{
+			desc: "no parameters",
+			name: "Recent",
+			want: ".create-or-alter function Recent()\n{\nEvents | where Timestamp > ago(since) | where Name == \"}\"\n}",
+		},
+		{
+			desc: "scalar parameters",
+			name: "My Function",
+			params: []Parameter{
+				{Name: "name", Type: types.String},
+				{Name: "since", Type: types.Timespan, Default: value.NewTimespan(time.Hour)},
+				{Name: "limit", Type: types.Long, Default: value.NewLong(10)},
+			},
+			docString: `Events "recently"`,
+			folder:    `Events\Recent`,
+			want: `.create-or-alter function with (docstring="Events \"recently\"", folder="Events\\Recent") ["My Function"]` +
+				`(name:string, since:timespan=timespan(01:00:00.0000000), limit:long=long(10))` +
+				"\n{\nEvents | where Timestamp > ago(since) | where Name == \"}\"\n}",
+		},
+		{
+			desc: "tabular parameters",
+			name: "Recent",
+			params: []Parameter{
+				{Name: "T", Tabular: true},
+				{Name: "Dim", Tabular: true, Columns: []Parameter{{Name: "Id", Type: types.GUID}, {Name: "Display Name", Type: types.String}}},
+			},
+			folder: "Events",
+			want:   `.create-or-alter function with (folder="Events") Recent(T:(*), Dim:(Id:guid, ["Display Name"]:string))` + "\n{\nEvents | where Timestamp > ago(since) | where Name == \"}\"\n}",
+		},
+		{desc: "no name", err: true},
+		{desc: "no parameter name", name: "F", params: []Parameter{{Type: types.String}}, err: true},
+		{desc: "unknown type", name: "F", params: []Parameter{{Name: "p", Type: "text"}}, err: true},
+		{desc: "wrong default type", name: "F", params: []Parameter{{Name: "p", Type: types.Int, Default: value.NewLong(1)}}, err: true},
+		{
+			desc:   "default before required",
+			name:   "F",
+			params: []Parameter{{Name: "p", Type: types.Long, Default: value.NewLong(1)}, {Name: "q", Type: types.Long}},
+			err:    true,
+		},
+		{
+			desc:   "nested tabular",
+			name:   "F",
+			params: []Parameter{{Name: "T", Tabular: true, Columns: []Parameter{{Name: "U", Tabular: true}}}},
+			err:    true,
+		},
+		{
+			desc:   "column default",
+			name:   "F",
+			params: []Parameter{{Name: "T", Tabular: true, Columns: []Parameter{{Name: "c", Type: types.Long, Default: value.NewLong(1)}}}},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := CreateOrAlterStatement(test.name, test.params, body, test.docString, test.folder)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+
+	_, err := CreateOrAlterStatement("F", nil, kql.New(""), "", "")
+	assert.Error(t, err)
+}
+
+func TestFunctions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{rows: `["Recent","(since:timespan=timespan(01:00:00))","{\nEvents | where Timestamp > ago(since)\n}","Events","Recent events"],
+["Count","()","{ Events | count }","",""]`}
+
+	params := []Parameter{{Name: "since", Type: types.Timespan, Default: value.NewTimespan(time.Hour)}}
+	require.NoError(t, CreateOrAlter(ctx, client, "db", "Recent", params, kql.New("Events | where Timestamp > ago(since)"), "Recent events", "Events"))
+	require.NoError(t, Drop(ctx, client, "db", "Old Function"))
+
+	fns, err := List(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []Function{
+		{
+			Name:       "Recent",
+			Parameters: "(since:timespan=timespan(01:00:00))",
+			Body:       "{\nEvents | where Timestamp > ago(since)\n}",
+			Folder:     "Events",
+			DocString:  "Recent events",
+		},
+		{Name: "Count", Parameters: "()", Body: "{ Events | count }"},
+	}, fns)
+
+	fn, err := Show(ctx, client, "db", "Recent")
+	require.NoError(t, err)
+	assert.Equal(t, fns[0], fn)
+
+	assert.Equal(t, []string{
+		".create-or-alter function with (docstring=\"Recent events\", folder=\"Events\") Recent(since:timespan=timespan(01:00:00.0000000))\n{\nEvents | where Timestamp > ago(since)\n}",
+		`.drop function ["Old Function"]`,
+		".show functions",
+		".show function Recent",
+	}, client.commands)
+
+	client.rows = ""
+	_, err = Show(ctx, client, "db", "Missing")
+	assert.ErrorContains(t, err, `function "Missing" was not found in database "db"`)
+
+	assert.Error(t, Drop(ctx, client, "db", ""))
+	assert.Len(t, client.commands, 5)
+}
@@ -0,0 +1,111 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// JournalEntry is a change to the metadata of a database, such as the creation of a table or the alteration of a policy, as
+// returned by `.show journal`.
+type JournalEntry struct {
+	// Event is the kind of the change, such as "CREATE-TABLE" or "ADD-COLUMN".
+	Event               string    `kusto:"Event"`
+	EventTimestamp      time.Time `kusto:"EventTimestamp"`
+	Database            string    `kusto:"Database"`
+	EntityName          string    `kusto:"EntityName"`
+	UpdatedEntityName   string    `kusto:"UpdatedEntityName"`
+	EntityVersion       string    `kusto:"EntityVersion"`
+	EntityContainerName string    `kusto:"EntityContainerName"`
+	OriginalEntityState string    `kusto:"OriginalEntityState"`
+	UpdatedEntityState  string    `kusto:"UpdatedEntityState"`
+	ChangeCommand       string    `kusto:"ChangeCommand"`
+	Principal           string    `kusto:"Principal"`
+}
+
+// CommandInfo describes a command or query run on the cluster, as returned by `.show commands-and-queries`.
+type CommandInfo struct {
+	ClientActivityID string `kusto:"ClientActivityId"`
+	// CommandType is the kind of the command, such as "Query" or "TableSetOrAppend".
+	CommandType    string        `kusto:"CommandType"`
+	Text           string        `kusto:"Text"`
+	Database       string        `kusto:"Database"`
+	StartedOn      time.Time     `kusto:"StartedOn"`
+	LastUpdatedOn  time.Time     `kusto:"LastUpdatedOn"`
+	Duration       time.Duration `kusto:"Duration"`
+	State          string        `kusto:"State"`
+	RootActivityID string        `kusto:"RootActivityId"`
+	User           string        `kusto:"User"`
+	FailureReason  string        `kusto:"FailureReason"`
+	Application    string        `kusto:"Application"`
+	Principal      string        `kusto:"Principal"`
+	WorkloadGroup  string        `kusto:"WorkloadGroup"`
+	// TotalCPU and MemoryPeak, in bytes, are taken from the resource utilization of the command.
+	TotalCPU   time.Duration `kusto:"TotalCpu"`
+	MemoryPeak int64         `kusto:"MemoryPeak"`
+}
+
+// ShowJournal returns the changes to the metadata of the database db made between from and to. A zero from or to leaves that
+// end of the range open.
+func ShowJournal(ctx context.Context, client Client, db string, from time.Time, to time.Time) ([]JournalEntry, error) {
+	stmt, err := ShowJournalStatement(db, from, to)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[JournalEntry](ds)
+}
+
+// ShowCommandsAndQueries returns the commands and queries started between from and to that the principal of the client can see,
+// in all the databases of the cluster. A zero from or to leaves that end of the range open. The cluster keeps this history for
+// 30 days.
+func ShowCommandsAndQueries(ctx context.Context, client Client, db string, from time.Time, to time.Time) ([]CommandInfo, error) {
+	stmt, err := ShowCommandsAndQueriesStatement(from, to)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[CommandInfo](ds)
+}
+
+// ShowJournalStatement builds the `.show database journal` command used by ShowJournal.
+func ShowJournalStatement(db string, from time.Time, to time.Time) (azkustodata.Statement, error) {
+	if db == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "database name must not be empty").SetNoRetry()
+	}
+	return addTimeRange(kql.New(".show database ").AddTable(db).AddLiteral(" journal"), "EventTimestamp", from, to)
+}
+
+// ShowCommandsAndQueriesStatement builds the `.show commands-and-queries` command used by ShowCommandsAndQueries.
+func ShowCommandsAndQueriesStatement(from time.Time, to time.Time) (azkustodata.Statement, error) {
+	stmt, err := addTimeRange(kql.New(".show commands-and-queries"), "StartedOn", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.AddLiteral(" | extend TotalCpu = totimespan(ResourceUtilization.TotalCpu), MemoryPeak = tolong(ResourceUtilization.MemoryPeak)"), nil
+}
+
+// addTimeRange filters the rows of stmt to those whose column is in [from, to). A zero from or to isn't filtered on.
+func addTimeRange(stmt *kql.Builder, column string, from time.Time, to time.Time) (*kql.Builder, error) {
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "the end of the time range (%s) must be after its start (%s)", to, from).SetNoRetry()
+	}
+
+	if !from.IsZero() {
+		stmt.AddLiteral(" | where ").AddColumn(column).AddLiteral(" >= ").AddDateTime(from)
+	}
+	if !to.IsZero() {
+		stmt.AddLiteral(" | where ").AddColumn(column).AddLiteral(" < ").AddDateTime(to)
+	}
+	return stmt, nil
+}
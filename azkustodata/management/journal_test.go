@@ -0,0 +1,116 @@
+package management
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const journalResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Event","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EventTimestamp","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Database","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"UpdatedEntityName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityVersion","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityContainerName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"OriginalEntityState","DataType":"String","ColumnType":"string"},
+{"ColumnName":"UpdatedEntityState","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ChangeCommand","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Principal","DataType":"String","ColumnType":"string"}],
+"Rows":[["ADD-COLUMN","2024-01-02T03:04:05Z","db","Logs","Logs","v7.0","db","Name: Logs","Name: Logs, Level: string",".alter-merge table Logs (Level:string)","aadapp=5a4b"]]}]}`
+
+const commandsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"ClientActivityId","DataType":"String","ColumnType":"string"},
+{"ColumnName":"CommandType","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Text","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Database","DataType":"String","ColumnType":"string"},
+{"ColumnName":"StartedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"LastUpdatedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Duration","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"State","DataType":"String","ColumnType":"string"},
+{"ColumnName":"RootActivityId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"User","DataType":"String","ColumnType":"string"},
+{"ColumnName":"FailureReason","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Application","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Principal","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ClientRequestProperties","DataType":"Object","ColumnType":"dynamic"},
+{"ColumnName":"ResourceUtilization","DataType":"Object","ColumnType":"dynamic"},
+{"ColumnName":"WorkloadGroup","DataType":"String","ColumnType":"string"},
+{"ColumnName":"TotalCpu","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"MemoryPeak","DataType":"Int64","ColumnType":"long"}],
+"Rows":[["KD2RunQuery;1","Query","Logs | count","db","2024-01-02T03:04:05Z","2024-01-02T03:04:07Z","00:00:02","Completed",
+"9f5a3e2c-7d3b-4bfa-9a2e-3c1b2d4e5f60","user@contoso.com","","Kusto.Explorer","aaduser=1a2b",{},{"TotalCpu":"00:00:01.5000000","MemoryPeak":1024},"default","00:00:01.5000000",1024]]}]}`
+
+func TestJournalStatements(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	stmt, err := ShowJournalStatement("My DB", from, to)
+	require.NoError(t, err)
+	assert.Equal(t, `.show database ["My DB"] journal | where EventTimestamp >= datetime(2024-01-01T00:00:00Z) | where EventTimestamp < datetime(2024-01-02T00:00:00Z)`, stmt.String())
+
+	stmt, err = ShowJournalStatement("db", time.Time{}, to)
+	require.NoError(t, err)
+	assert.Equal(t, `.show database db journal | where EventTimestamp < datetime(2024-01-02T00:00:00Z)`, stmt.String())
+
+	stmt, err = ShowCommandsAndQueriesStatement(from, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, `.show commands-and-queries | where StartedOn >= datetime(2024-01-01T00:00:00Z)`+
+		` | extend TotalCpu = totimespan(ResourceUtilization.TotalCpu), MemoryPeak = tolong(ResourceUtilization.MemoryPeak)`, stmt.String())
+
+	_, err = ShowJournalStatement("", from, to)
+	assert.Error(t, err)
+	_, err = ShowJournalStatement("db", to, from)
+	assert.Error(t, err)
+	_, err = ShowCommandsAndQueriesStatement(from, from)
+	assert.Error(t, err)
+}
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	entries, err := ShowJournal(ctx, newFakeClient(journalResponse), "db", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []JournalEntry{{
+		Event:               "ADD-COLUMN",
+		EventTimestamp:      ts,
+		Database:            "db",
+		EntityName:          "Logs",
+		UpdatedEntityName:   "Logs",
+		EntityVersion:       "v7.0",
+		EntityContainerName: "db",
+		OriginalEntityState: "Name: Logs",
+		UpdatedEntityState:  "Name: Logs, Level: string",
+		ChangeCommand:       ".alter-merge table Logs (Level:string)",
+		Principal:           "aadapp=5a4b",
+	}}, entries)
+
+	commands, err := ShowCommandsAndQueries(ctx, newFakeClient(commandsResponse), "db", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []CommandInfo{{
+		ClientActivityID: "KD2RunQuery;1",
+		CommandType:      "Query",
+		Text:             "Logs | count",
+		Database:         "db",
+		StartedOn:        ts,
+		LastUpdatedOn:    ts.Add(2 * time.Second),
+		Duration:         2 * time.Second,
+		State:            "Completed",
+		RootActivityID:   "9f5a3e2c-7d3b-4bfa-9a2e-3c1b2d4e5f60",
+		User:             "user@contoso.com",
+		Application:      "Kusto.Explorer",
+		Principal:        "aaduser=1a2b",
+		WorkloadGroup:    "default",
+		TotalCPU:         1500 * time.Millisecond,
+		MemoryPeak:       1024,
+	}}, commands)
+}
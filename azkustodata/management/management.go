@@ -0,0 +1,58 @@
+/*
+Package management provides typed helpers for common Kusto management commands, such as creating tables and ingestion mappings.
+
+The helpers build the commands with the kql package, so names and literals are escaped as needed, and run them with the
+Mgmt method of an *azkustodata.Client:
+
+	err := management.CreateTableFromStruct[Event](ctx, client, "db", "Events", management.WithMapping(management.JSONMapping, "EventsMapping"))
+
+Every helper takes the database it runs in, so a single client manages all the databases of the cluster. Queries given to the
+helpers, such as the predicate of SoftDelete, can read the tables of other databases with kql's AddQualifiedTable:
+
+	predicate := kql.New("where UserId in (").AddQualifiedTable("Users", "DeletedUsers").AddLiteral(" | project UserId)")
+	_, err := management.SoftDelete(ctx, client, "Logs", "Events", predicate)
+
+The commands are also available as statements, for callers that want to run them themselves or inspect them.
+*/
+package management
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// Client runs management commands. It is implemented by *azkustodata.Client.
+type Client interface {
+	Mgmt(ctx context.Context, db string, kqlQuery azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error)
+}
+
+// run runs each statement in order, stopping at the first error.
+func run(ctx context.Context, client Client, db string, stmts ...azkustodata.Statement) error {
+	for _, stmt := range stmts {
+		if _, err := client.Mgmt(ctx, db, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// showOne runs stmt and decodes the first row of its result into a T. kind and name describe the entity, for the error returned
+// if the result is empty.
+func showOne[T any](ctx context.Context, client Client, db string, stmt azkustodata.Statement, kind string, name string) (T, error) {
+	var zero T
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return zero, err
+	}
+	rows, err := v1.ToStructs[T](ds)
+	if err != nil {
+		return zero, err
+	}
+	if len(rows) == 0 {
+		return zero, errors.ES(errors.OpMgmt, errors.KOther, "%s %q was not found in database %q", kind, name, db)
+	}
+	return rows[0], nil
+}
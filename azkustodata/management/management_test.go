@@ -0,0 +1,34 @@
+package management
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+const emptyResponse = `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Result","DataType":"String","ColumnType":"string"}],"Rows":[]}]}`
+
+// fakeClient records the commands it runs, and answers them with a canned v1 response.
+type fakeClient struct {
+	mu       sync.Mutex
+	response func(cmd string) string
+	dbs      []string
+	commands []string
+}
+
+func newFakeClient(response string) *fakeClient {
+	return &fakeClient{response: func(string) string { return response }}
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, db string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	f.mu.Lock()
+	f.dbs = append(f.dbs, db)
+	f.commands = append(f.commands, kqlQuery.String())
+	f.mu.Unlock()
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(f.response(kqlQuery.String()))))
+}
@@ -0,0 +1,518 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+)
+
+// MappingKind is the kind of an ingestion mapping. It must match the format of the ingested data.
+type MappingKind string
+
+const (
+	// CSVMapping maps the columns of CSV data, and of the other delimited formats, by ordinal.
+	CSVMapping MappingKind = "csv"
+	// JSONMapping maps the properties of JSON data by path.
+	JSONMapping MappingKind = "json"
+	// AvroMapping maps the fields of Avro data by path or field name.
+	AvroMapping MappingKind = "avro"
+	// ApacheAvroMapping maps the fields of Avro data, decoded with the Apache Avro library, by path.
+	ApacheAvroMapping MappingKind = "apacheavro"
+	// ParquetMapping maps the fields of Parquet data by path.
+	ParquetMapping MappingKind = "parquet"
+	// ORCMapping maps the fields of ORC data by path.
+	ORCMapping MappingKind = "orc"
+	// W3CLogFileMapping maps the fields of W3C log files by field name.
+	W3CLogFileMapping MappingKind = "w3clogfile"
+)
+
+var mappingKinds = map[MappingKind]bool{
+	CSVMapping:        true,
+	JSONMapping:       true,
+	AvroMapping:       true,
+	ApacheAvroMapping: true,
+	ParquetMapping:    true,
+	ORCMapping:        true,
+	W3CLogFileMapping: true,
+}
+
+// Names of the properties of a ColumnMapping.
+const (
+	PropertyOrdinal    = "Ordinal"
+	PropertyPath       = "Path"
+	PropertyField      = "Field"
+	PropertyConstValue = "ConstValue"
+	PropertyTransform  = "Transform"
+)
+
+// Transforms of the values of a column mapping, set in its Transform property.
+const (
+	// TransformDateTimeFromUnixSeconds converts a number of seconds since the Unix epoch to a datetime.
+	TransformDateTimeFromUnixSeconds = "DateTimeFromUnixSeconds"
+	// TransformDateTimeFromUnixMilliseconds converts a number of milliseconds since the Unix epoch to a datetime.
+	TransformDateTimeFromUnixMilliseconds = "DateTimeFromUnixMilliseconds"
+	// TransformDateTimeFromUnixMicroseconds converts a number of microseconds since the Unix epoch to a datetime.
+	TransformDateTimeFromUnixMicroseconds = "DateTimeFromUnixMicroseconds"
+	// TransformDateTimeFromUnixNanoseconds converts a number of nanoseconds since the Unix epoch to a datetime.
+	TransformDateTimeFromUnixNanoseconds = "DateTimeFromUnixNanoseconds"
+	// TransformPropertyBagArrayToDictionary converts an array of {"Key": k, "Value": v} objects to a dictionary.
+	TransformPropertyBagArrayToDictionary = "PropertyBagArrayToDictionary"
+	// TransformSourceLocation sets the column to the URI of the ingested blob, and needs no path.
+	TransformSourceLocation = "SourceLocation"
+	// TransformSourceLineNumber sets the column to the line number of the record in the ingested blob, and needs no path.
+	TransformSourceLineNumber = "SourceLineNumber"
+)
+
+// transformTypes holds the Kusto type of the columns a transform produces.
+var transformTypes = map[string]types.Column{
+	TransformDateTimeFromUnixSeconds:      types.DateTime,
+	TransformDateTimeFromUnixMilliseconds: types.DateTime,
+	TransformDateTimeFromUnixMicroseconds: types.DateTime,
+	TransformDateTimeFromUnixNanoseconds:  types.DateTime,
+	TransformPropertyBagArrayToDictionary: types.Dynamic,
+	TransformSourceLocation:               types.String,
+	TransformSourceLineNumber:             types.Long,
+}
+
+// ColumnMapping maps a value of the ingested data to a column of the table.
+// It is marshaled as an element of the mapping, as expected by Kusto.
+type ColumnMapping struct {
+	// Column is the name of the column in the table.
+	Column string `json:"Column"`
+	// DataType is the type of the column, used if the column doesn't exist yet. It is optional.
+	DataType types.Column `json:"DataType,omitempty"`
+	// Properties holds the properties that depend on the kind of the mapping, such as Path or Ordinal.
+	Properties map[string]string `json:"Properties,omitempty"`
+}
+
+// CSVColumn maps the value at ordinal, starting from 0, to column.
+func CSVColumn(column string, ordinal int) ColumnMapping {
+	return ColumnMapping{Column: column, Properties: map[string]string{PropertyOrdinal: strconv.Itoa(ordinal)}}
+}
+
+// JSONColumn maps the value at the JSON path, such as "$.name", to column.
+// It can be used for the other kinds of mappings that map by path, such as ParquetMapping.
+func JSONColumn(column string, path string) ColumnMapping {
+	return ColumnMapping{Column: column, Properties: map[string]string{PropertyPath: path}}
+}
+
+// ConstColumn sets column to a constant value for every ingested row.
+func ConstColumn(column string, constValue string) ColumnMapping {
+	return ColumnMapping{Column: column, Properties: map[string]string{PropertyConstValue: constValue}}
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Kusto returns the properties of a mapping as strings or as numbers, which are both kept as strings.
+func (c *ColumnMapping) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Column     string
+		DataType   types.Column
+		Properties map[string]interface{}
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	c.Column = raw.Column
+	c.DataType = raw.DataType
+	if t := types.NormalizeColumn(string(raw.DataType)); t != "" {
+		c.DataType = t
+	}
+	c.Properties = nil
+	if len(raw.Properties) > 0 {
+		c.Properties = make(map[string]string, len(raw.Properties))
+		for k, v := range raw.Properties {
+			if s, ok := v.(string); ok {
+				c.Properties[k] = s
+			} else {
+				c.Properties[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return nil
+}
+
+// Mapping is an ingestion mapping: the list of its column mappings.
+type Mapping []ColumnMapping
+
+// Validate checks that the mapping can be used as a mapping of the given kind: every element must have a column, columns can't repeat,
+// CSV elements need an ordinal or a constant value, and the other kinds need a path, a field or a constant value.
+func (m Mapping) Validate(kind MappingKind) error {
+	if !mappingKinds[kind] {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown mapping kind %q", kind).SetNoRetry()
+	}
+	if len(m) == 0 {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "mapping must have at least one column").SetNoRetry()
+	}
+
+	seen := make(map[string]bool, len(m))
+	for i, c := range m {
+		if c.Column == "" {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "mapping[%d] has no column", i).SetNoRetry()
+		}
+		if seen[c.Column] {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s is mapped more than once", c.Column).SetNoRetry()
+		}
+		seen[c.Column] = true
+
+		if c.DataType != "" && types.NormalizeColumn(string(c.DataType)) == "" {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s has an unknown type %q", c.Column, c.DataType).SetNoRetry()
+		}
+		if _, ok := c.Properties[PropertyConstValue]; ok {
+			continue
+		}
+		if t := c.Properties[PropertyTransform]; t == TransformSourceLocation || t == TransformSourceLineNumber {
+			continue
+		}
+
+		if kind == CSVMapping {
+			ordinal, ok := c.Properties[PropertyOrdinal]
+			if !ok {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s needs an ordinal in a csv mapping", c.Column).SetNoRetry()
+			}
+			if n, err := strconv.Atoi(ordinal); err != nil || n < 0 {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s has an invalid ordinal %q", c.Column, ordinal).SetNoRetry()
+			}
+			continue
+		}
+
+		if c.Properties[PropertyPath] == "" && c.Properties[PropertyField] == "" {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s needs a path or a field in a %s mapping", c.Column, kind).SetNoRetry()
+		}
+	}
+	return nil
+}
+
+// MappingFromStruct returns a mapping of the given kind for data produced from T, with the columns CreateTableFromStruct creates.
+// For CSVMapping, the fields are mapped by their order in the struct. For JSONMapping, they are mapped by the name encoding/json uses for them,
+// taken from their `json` tag or their field name. Fields that encoding/json skips are not mapped.
+//
+// The `kustomapping` tag of a field sets the path of its JSON mapping, such as `kustomapping:"path=$.meta.ts"` for a column read from a nested
+// object, and the transform of its value, such as `kustomapping:"transform=DateTimeFromUnixMilliseconds"`, with the Transform constants. A
+// transform also sets the type of the column to the type it produces, such as a datetime for an int64 of Unix milliseconds. A field with the
+// SourceLocation or SourceLineNumber transform is mapped even if encoding/json skips it.
+func MappingFromStruct[T any](kind MappingKind) (Mapping, error) {
+	fields, err := structFields(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := make(Mapping, 0, len(fields))
+	ordinal := 0
+	for _, f := range fields {
+		fromSource := f.transform == TransformSourceLocation || f.transform == TransformSourceLineNumber
+		var m ColumnMapping
+		switch kind {
+		case CSVMapping:
+			if fromSource {
+				m = ColumnMapping{Column: f.column, Properties: map[string]string{}}
+				break
+			}
+			m = CSVColumn(f.column, ordinal)
+			ordinal++
+		case JSONMapping:
+			switch {
+			case fromSource:
+				m = ColumnMapping{Column: f.column, Properties: map[string]string{}}
+			case f.jsonName == "-" && f.path == "":
+				continue
+			case f.path != "":
+				m = JSONColumn(f.column, f.path)
+			default:
+				m = JSONColumn(f.column, "$."+f.jsonName)
+			}
+		default:
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "cannot build a %q mapping from a struct", kind).SetNoRetry()
+		}
+		if f.transform != "" {
+			m.Properties[PropertyTransform] = f.transform
+		}
+		m.DataType = f.kustoType
+		mapping = append(mapping, m)
+	}
+	return mapping, nil
+}
+
+// parseMappingTag sets the path and transform of the field from its `kustomapping` tag.
+func (f *structField) parseMappingTag(field reflect.StructField) error {
+	tag := strings.TrimSpace(field.Tag.Get("kustomapping"))
+	if tag == "" {
+		return nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || val == "" {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "field %s has an invalid kustomapping tag %q: expected key=value", field.Name, part).SetNoRetry()
+		}
+		switch key {
+		case "path":
+			if !strings.HasPrefix(val, "$") {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "field %s has a path %q that doesn't start with $", field.Name, val).SetNoRetry()
+			}
+			f.path = val
+		case "transform":
+			t, ok := transformTypes[val]
+			if !ok {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "field %s has an unknown transform %q", field.Name, val).SetNoRetry()
+			}
+			f.transform = val
+			f.kustoType = t
+		default:
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "field %s has an unknown kustomapping key %q", field.Name, key).SetNoRetry()
+		}
+	}
+	return nil
+}
+
+// MappingFromColumns returns a mapping of the given kind for data with the columns, as MappingFromStruct does for a struct. For
+// CSVMapping, the columns are mapped by their order. For JSONMapping, they are mapped by their name, at the top level of each
+// object.
+func MappingFromColumns(cols query.Columns, kind MappingKind) (Mapping, error) {
+	mapping := make(Mapping, 0, len(cols))
+	for i, c := range cols {
+		var m ColumnMapping
+		switch kind {
+		case CSVMapping:
+			m = CSVColumn(c.Name(), i)
+		case JSONMapping:
+			m = JSONColumn(c.Name(), "$."+c.Name())
+		default:
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "cannot build a %q mapping from columns", kind).SetNoRetry()
+		}
+		m.DataType = c.Type()
+		mapping = append(mapping, m)
+	}
+	return mapping, nil
+}
+
+// DiffMappings returns the differences between the mappings want and got, such as a mapping built by MappingFromStruct and the
+// mapping of a table, or nil if they map the same columns the same way. The types of the columns are only compared when both
+// mappings set them.
+func DiffMappings(want Mapping, got Mapping) []string {
+	gotByColumn := make(map[string]ColumnMapping, len(got))
+	for _, c := range got {
+		gotByColumn[c.Column] = c
+	}
+
+	var diffs []string
+	for _, w := range want {
+		g, ok := gotByColumn[w.Column]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("column %s is not mapped", w.Column))
+			continue
+		}
+		delete(gotByColumn, w.Column)
+
+		if w.DataType != "" && g.DataType != "" && types.NormalizeColumn(string(w.DataType)) != types.NormalizeColumn(string(g.DataType)) {
+			diffs = append(diffs, fmt.Sprintf("column %s has type %s, expected %s", w.Column, g.DataType, w.DataType))
+		}
+		for _, p := range []string{PropertyOrdinal, PropertyPath, PropertyField, PropertyConstValue, PropertyTransform} {
+			if w.Properties[p] != g.Properties[p] {
+				diffs = append(diffs, fmt.Sprintf("column %s has %s %q, expected %q", w.Column, p, g.Properties[p], w.Properties[p]))
+			}
+		}
+	}
+	for _, g := range got {
+		if _, ok := gotByColumn[g.Column]; ok {
+			diffs = append(diffs, fmt.Sprintf("column %s is mapped, but not expected", g.Column))
+		}
+	}
+	return diffs
+}
+
+// VerifyMapping checks that the ingestion mapping of the table with the kind and name is the mapping want, such as a mapping
+// built by MappingFromStruct for the records a producer writes, so that the producer and the mapping are kept in sync. It
+// returns a KOther error that lists the differences, as DiffMappings does, if they differ.
+func VerifyMapping(ctx context.Context, client Client, db string, table string, kind MappingKind, name string, want Mapping) error {
+	info, err := ShowMapping(ctx, client, db, table, kind, name)
+	if err != nil {
+		return err
+	}
+	if diffs := DiffMappings(want, info.Mapping); len(diffs) > 0 {
+		return errors.ES(errors.OpMgmt, errors.KOther, "%s mapping %q of table %q differs: %s", kind, name, table, strings.Join(diffs, "; ")).SetNoRetry()
+	}
+	return nil
+}
+
+// MappingInfo describes an ingestion mapping, as returned by `.show table T ingestion mappings`. Its Kind is lower case, like the MappingKind constants.
+type MappingInfo struct {
+	Name          string
+	Kind          MappingKind
+	Mapping       Mapping
+	LastUpdatedOn time.Time
+	Database      string
+	Table         string
+}
+
+// mappingRow is a row of `.show table T ingestion mappings`.
+type mappingRow struct {
+	Name          string    `kusto:"Name"`
+	Kind          string    `kusto:"Kind"`
+	Mapping       string    `kusto:"Mapping"`
+	LastUpdatedOn time.Time `kusto:"LastUpdatedOn"`
+	Database      string    `kusto:"Database"`
+	Table         string    `kusto:"Table"`
+}
+
+// CreateMapping creates the ingestion mapping of the table. It fails if a mapping of the same kind and name already exists.
+func CreateMapping(ctx context.Context, client Client, db string, table string, kind MappingKind, name string, mapping Mapping) error {
+	stmt, err := CreateMappingStatement(table, kind, name, mapping)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// AlterMapping replaces the ingestion mapping of the table. It fails if the mapping doesn't exist.
+func AlterMapping(ctx context.Context, client Client, db string, table string, kind MappingKind, name string, mapping Mapping) error {
+	stmt, err := AlterMappingStatement(table, kind, name, mapping)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// CreateOrAlterMapping creates the ingestion mapping of the table, or replaces it if it already exists.
+// Running it again with the same mapping has no effect, so it is safe to call every time an application starts.
+func CreateOrAlterMapping(ctx context.Context, client Client, db string, table string, kind MappingKind, name string, mapping Mapping) error {
+	stmt, err := CreateOrAlterMappingStatement(table, kind, name, mapping)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// DropMapping drops the ingestion mapping of the table.
+func DropMapping(ctx context.Context, client Client, db string, table string, kind MappingKind, name string) error {
+	stmt, err := DropMappingStatement(table, kind, name)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// ShowMappings returns the ingestion mappings of the table. If kind is empty, the mappings of every kind are returned.
+func ShowMappings(ctx context.Context, client Client, db string, table string, kind MappingKind) ([]MappingInfo, error) {
+	stmt, err := ShowMappingsStatement(table, kind)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := v1.ToStructs[mappingRow](ds)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]MappingInfo, len(rows))
+	for i, r := range rows {
+		var m Mapping
+		if err := json.Unmarshal([]byte(r.Mapping), &m); err != nil {
+			return nil, errors.ES(errors.OpMgmt, errors.KFailedToParse, "mapping %s could not be parsed: %s", r.Name, err)
+		}
+		infos[i] = MappingInfo{
+			Name:          r.Name,
+			Kind:          MappingKind(strings.ToLower(r.Kind)),
+			Mapping:       m,
+			LastUpdatedOn: r.LastUpdatedOn,
+			Database:      r.Database,
+			Table:         r.Table,
+		}
+	}
+	return infos, nil
+}
+
+// ShowMapping returns the ingestion mapping of the table with the given kind and name.
+func ShowMapping(ctx context.Context, client Client, db string, table string, kind MappingKind, name string) (MappingInfo, error) {
+	if kind == "" {
+		return MappingInfo{}, errors.ES(errors.OpMgmt, errors.KClientArgs, "mapping kind must not be empty").SetNoRetry()
+	}
+	mappings, err := ShowMappings(ctx, client, db, table, kind)
+	if err != nil {
+		return MappingInfo{}, err
+	}
+	for _, m := range mappings {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return MappingInfo{}, errors.ES(errors.OpMgmt, errors.KOther, "%s mapping %q was not found on table %q", kind, name, table)
+}
+
+// CreateMappingStatement builds a `.create table ingestion mapping` command.
+func CreateMappingStatement(table string, kind MappingKind, name string, mapping Mapping) (azkustodata.Statement, error) {
+	return mappingWithBody(kql.New(".create table "), table, kind, name, mapping)
+}
+
+// AlterMappingStatement builds an `.alter table ingestion mapping` command.
+func AlterMappingStatement(table string, kind MappingKind, name string, mapping Mapping) (azkustodata.Statement, error) {
+	return mappingWithBody(kql.New(".alter table "), table, kind, name, mapping)
+}
+
+// CreateOrAlterMappingStatement builds a `.create-or-alter table ingestion mapping` command, which creates the mapping or replaces it.
+func CreateOrAlterMappingStatement(table string, kind MappingKind, name string, mapping Mapping) (azkustodata.Statement, error) {
+	return mappingWithBody(kql.New(".create-or-alter table "), table, kind, name, mapping)
+}
+
+// DropMappingStatement builds a `.drop table ingestion mapping` command.
+func DropMappingStatement(table string, kind MappingKind, name string) (azkustodata.Statement, error) {
+	return mappingStatement(kql.New(".drop table "), table, kind, name)
+}
+
+// ShowMappingsStatement builds a `.show table ingestion mappings` command. If kind is empty, the mappings of every kind are shown.
+func ShowMappingsStatement(table string, kind MappingKind) (azkustodata.Statement, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	stmt := kql.New(".show table ").AddTable(table).AddLiteral(" ingestion ")
+	if kind != "" {
+		if !mappingKinds[kind] {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown mapping kind %q", kind).SetNoRetry()
+		}
+		stmt.AddKeyword(string(kind)).AddLiteral(" ")
+	}
+	return stmt.AddLiteral("mappings"), nil
+}
+
+// mappingStatement completes stmt, which holds the verb of a command, with `table T ingestion kind mapping "name"`.
+func mappingStatement(stmt *kql.Builder, table string, kind MappingKind, name string) (*kql.Builder, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	if name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "mapping name must not be empty").SetNoRetry()
+	}
+	if !mappingKinds[kind] {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown mapping kind %q", kind).SetNoRetry()
+	}
+	return stmt.AddTable(table).AddLiteral(" ingestion ").AddKeyword(string(kind)).AddLiteral(" mapping ").AddString(name), nil
+}
+
+// mappingWithBody is mappingStatement followed by the validated mapping, as a string literal.
+func mappingWithBody(stmt *kql.Builder, table string, kind MappingKind, name string, mapping Mapping) (*kql.Builder, error) {
+	stmt, err := mappingStatement(stmt, table, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := mapping.Validate(kind); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(mapping)
+	if err != nil {
+		return nil, errors.E(errors.OpMgmt, errors.KClientArgs, err).SetNoRetry()
+	}
+	return stmt.AddLiteral(" ").AddString(string(b)), nil
+}
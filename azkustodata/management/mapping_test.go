@@ -0,0 +1,226 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showMappingsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Name","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Kind","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Mapping","DataType":"String","ColumnType":"string"},
+{"ColumnName":"LastUpdatedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Database","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Table","DataType":"String","ColumnType":"string"}],
+"Rows":[
+["EventsJson","Json","[{\"column\":\"Name\",\"datatype\":\"string\",\"Properties\":{\"Path\":\"$.name\"}}]","2024-01-02T03:04:05Z","db","Events"],
+["EventsCsv","Csv","[{\"column\":\"Name\",\"datatype\":\"\",\"Properties\":{\"Ordinal\":0}}]","2024-01-02T03:04:05Z","db","Events"]]}]}`
+
+func TestMappingValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		kind    MappingKind
+		mapping Mapping
+		err     bool
+	}{
+		{desc: "csv", kind: CSVMapping, mapping: Mapping{CSVColumn("A", 0), CSVColumn("B", 3), ConstColumn("C", "x")}},
+		{desc: "json", kind: JSONMapping, mapping: Mapping{JSONColumn("A", "$.a"), {Column: "B", Properties: map[string]string{PropertyField: "b"}}}},
+		{desc: "path for parquet", kind: ParquetMapping, mapping: Mapping{JSONColumn("A", "$.a")}},
+		{desc: "unknown kind", kind: "xml", mapping: Mapping{JSONColumn("A", "$.a")}, err: true},
+		{desc: "empty", kind: CSVMapping, err: true},
+		{desc: "no column", kind: CSVMapping, mapping: Mapping{CSVColumn("", 0)}, err: true},
+		{desc: "duplicate column", kind: CSVMapping, mapping: Mapping{CSVColumn("A", 0), CSVColumn("A", 1)}, err: true},
+		{desc: "path in csv", kind: CSVMapping, mapping: Mapping{JSONColumn("A", "$.a")}, err: true},
+		{desc: "negative ordinal", kind: CSVMapping, mapping: Mapping{CSVColumn("A", -1)}, err: true},
+		{desc: "ordinal in json", kind: JSONMapping, mapping: Mapping{CSVColumn("A", 0)}, err: true},
+		{desc: "unknown type", kind: JSONMapping, mapping: Mapping{{Column: "A", DataType: "blob", Properties: map[string]string{PropertyPath: "$.a"}}}, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.mapping.Validate(test.kind)
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMappingStatements(t *testing.T) {
+	t.Parallel()
+
+	mapping := Mapping{JSONColumn("A", "$.a")}
+	body := `"[{\"Column\":\"A\",\"Properties\":{\"Path\":\"$.a\"}}]"`
+
+	stmt, err := CreateMappingStatement("T", JSONMapping, "m", mapping)
+	require.NoError(t, err)
+	assert.Equal(t, `.create table T ingestion json mapping "m" `+body, stmt.String())
+
+	stmt, err = AlterMappingStatement("My T", JSONMapping, "m", mapping)
+	require.NoError(t, err)
+	assert.Equal(t, `.alter table ["My T"] ingestion json mapping "m" `+body, stmt.String())
+
+	stmt, err = CreateOrAlterMappingStatement("T", JSONMapping, `m"`, mapping)
+	require.NoError(t, err)
+	assert.Equal(t, `.create-or-alter table T ingestion json mapping "m\"" `+body, stmt.String())
+
+	stmt, err = DropMappingStatement("T", CSVMapping, "m")
+	require.NoError(t, err)
+	assert.Equal(t, `.drop table T ingestion csv mapping "m"`, stmt.String())
+
+	stmt, err = ShowMappingsStatement("T", "")
+	require.NoError(t, err)
+	assert.Equal(t, `.show table T ingestion mappings`, stmt.String())
+
+	stmt, err = ShowMappingsStatement("T", ParquetMapping)
+	require.NoError(t, err)
+	assert.Equal(t, `.show table T ingestion parquet mappings`, stmt.String())
+
+	_, err = CreateMappingStatement("T", CSVMapping, "m", mapping)
+	assert.Error(t, err)
+	_, err = CreateMappingStatement("", JSONMapping, "m", mapping)
+	assert.Error(t, err)
+	_, err = DropMappingStatement("T", JSONMapping, "")
+	assert.Error(t, err)
+	_, err = ShowMappingsStatement("T", "bad kind")
+	assert.Error(t, err)
+}
+
+func TestMappingCRUD(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(emptyResponse)
+	mapping := Mapping{CSVColumn("A", 0)}
+
+	require.NoError(t, CreateMapping(ctx, client, "db", "T", CSVMapping, "m", mapping))
+	require.NoError(t, AlterMapping(ctx, client, "db", "T", CSVMapping, "m", mapping))
+	require.NoError(t, CreateOrAlterMapping(ctx, client, "db", "T", CSVMapping, "m", mapping))
+	require.NoError(t, DropMapping(ctx, client, "db", "T", CSVMapping, "m"))
+	assert.Error(t, CreateMapping(ctx, client, "db", "T", JSONMapping, "m", mapping))
+
+	require.Len(t, client.commands, 4)
+	assert.Equal(t, `.drop table T ingestion csv mapping "m"`, client.commands[3])
+}
+
+func TestShowMappings(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(showMappingsResponse)
+
+	mappings, err := ShowMappings(ctx, client, "db", "Events", "")
+	require.NoError(t, err)
+	require.Len(t, mappings, 2)
+	assert.Equal(t, MappingInfo{
+		Name:          "EventsJson",
+		Kind:          JSONMapping,
+		Mapping:       Mapping{{Column: "Name", DataType: types.String, Properties: map[string]string{PropertyPath: "$.name"}}},
+		LastUpdatedOn: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Database:      "db",
+		Table:         "Events",
+	}, mappings[0])
+	assert.Equal(t, Mapping{CSVColumn("Name", 0)}, mappings[1].Mapping)
+	assert.NoError(t, mappings[1].Mapping.Validate(CSVMapping))
+
+	m, err := ShowMapping(ctx, client, "db", "Events", CSVMapping, "EventsCsv")
+	require.NoError(t, err)
+	assert.Equal(t, "EventsCsv", m.Name)
+	assert.Equal(t, `.show table Events ingestion csv mappings`, client.commands[len(client.commands)-1])
+
+	_, err = ShowMapping(ctx, client, "db", "Events", CSVMapping, "Missing")
+	assert.Error(t, err)
+}
+
+func TestColumnMappingJSON(t *testing.T) {
+	t.Parallel()
+
+	var m Mapping
+	require.NoError(t, json.Unmarshal([]byte(`[{"Column":"A","DataType":"int64","Properties":{"Ordinal":"2","Transform":"SourceLocation"}}]`), &m))
+	assert.Equal(t, Mapping{{Column: "A", DataType: types.Long, Properties: map[string]string{PropertyOrdinal: "2", PropertyTransform: "SourceLocation"}}}, m)
+
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"Column":"A","DataType":"long","Properties":{"Ordinal":"2","Transform":"SourceLocation"}}]`, string(b))
+}
+
+func TestMappingFromStructTags(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		Name      string `json:"name"`
+		Timestamp int64  `json:"ts" kustomapping:"path=$.meta.ts,transform=DateTimeFromUnixMilliseconds"`
+		Source    string `json:"-" kustomapping:"transform=SourceLocation"`
+		Count     int64  `json:"count"`
+	}
+
+	mapping, err := MappingFromStruct[event](JSONMapping)
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{
+		{Column: "Name", DataType: types.String, Properties: map[string]string{PropertyPath: "$.name"}},
+		{Column: "Timestamp", DataType: types.DateTime, Properties: map[string]string{PropertyPath: "$.meta.ts", PropertyTransform: TransformDateTimeFromUnixMilliseconds}},
+		{Column: "Source", DataType: types.String, Properties: map[string]string{PropertyTransform: TransformSourceLocation}},
+		{Column: "Count", DataType: types.Long, Properties: map[string]string{PropertyPath: "$.count"}},
+	}, mapping)
+	assert.NoError(t, mapping.Validate(JSONMapping))
+
+	csv, err := MappingFromStruct[event](CSVMapping)
+	require.NoError(t, err)
+	assert.Equal(t, "2", csv[3].Properties[PropertyOrdinal])
+	assert.NoError(t, csv.Validate(CSVMapping))
+
+	// The columns created from the struct have the type of the transforms.
+	cols, err := TableColumnsFromStruct[event]()
+	require.NoError(t, err)
+	assert.Equal(t, types.DateTime, cols[1].Type())
+
+	type badTransform struct {
+		A int64 `kustomapping:"transform=Unknown"`
+	}
+	_, err = MappingFromStruct[badTransform](JSONMapping)
+	assert.ErrorContains(t, err, "unknown transform")
+
+	type badPath struct {
+		A int64 `kustomapping:"path=a.b"`
+	}
+	_, err = MappingFromStruct[badPath](JSONMapping)
+	assert.ErrorContains(t, err, "doesn't start with $")
+}
+
+func TestVerifyMapping(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		Name string `json:"name"`
+	}
+	want, err := MappingFromStruct[event](JSONMapping)
+	require.NoError(t, err)
+
+	client := newFakeClient(showMappingsResponse)
+	require.NoError(t, VerifyMapping(context.Background(), client, "db", "Events", JSONMapping, "EventsJson", want))
+
+	type changed struct {
+		Name  string `json:"fullName"`
+		Count int64  `json:"count"`
+	}
+	want, err = MappingFromStruct[changed](JSONMapping)
+	require.NoError(t, err)
+	err = VerifyMapping(context.Background(), client, "db", "Events", JSONMapping, "EventsJson", want)
+	assert.ErrorContains(t, err, `column Name has Path "$.name", expected "$.fullName"`)
+	assert.ErrorContains(t, err, "column Count is not mapped")
+
+	assert.Equal(t, []string{"column Extra is mapped, but not expected"}, DiffMappings(Mapping{CSVColumn("A", 0)}, Mapping{CSVColumn("A", 0), CSVColumn("Extra", 1)}))
+}
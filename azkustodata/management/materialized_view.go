@@ -0,0 +1,351 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// materializedViewOptions holds the properties of a materialized view set by MaterializedViewOption.
+type materializedViewOptions struct {
+	async                     bool
+	ifNotExists               bool
+	backfill                  bool
+	effectiveDateTime         time.Time
+	updateExtentsCreationTime bool
+	lookback                  time.Duration
+	autoUpdateSchema          bool
+	dimensionTables           []string
+	folder                    string
+	docString                 string
+}
+
+// MaterializedViewOption is an optional argument to CreateMaterializedView and AlterMaterializedView.
+type MaterializedViewOption func(o *materializedViewOptions)
+
+// MVAsync runs the creation as an asynchronous operation, whose ID is returned by CreateMaterializedView.
+// It is recommended with MVBackfill, since backfilling a large source table can take hours.
+func MVAsync() MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.async = true
+	}
+}
+
+// MVIfNotExists doesn't fail the creation if the view already exists.
+func MVIfNotExists() MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.ifNotExists = true
+	}
+}
+
+// MVBackfill materializes the records already in the source table, instead of only the records ingested from now on.
+// Only valid on creation.
+func MVBackfill() MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.backfill = true
+	}
+}
+
+// MVEffectiveDateTime limits the backfill to records ingested after t. It implies MVBackfill. Only valid on creation.
+func MVEffectiveDateTime(t time.Time) MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.backfill = true
+		o.effectiveDateTime = t
+	}
+}
+
+// MVUpdateExtentsCreationTime sets the creation time of the backfilled extents to the datetime group-by key, so retention and caching
+// policies apply as if the records were ingested at that time. It implies MVBackfill. Only valid on creation.
+func MVUpdateExtentsCreationTime() MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.backfill = true
+		o.updateExtentsCreationTime = true
+	}
+}
+
+// MVLookback limits the period in which duplicates are expected, for views using take_any, arg_max or arg_min.
+func MVLookback(d time.Duration) MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.lookback = d
+	}
+}
+
+// MVAutoUpdateSchema updates the view automatically when the source table changes. Only valid on creation.
+func MVAutoUpdateSchema() MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.autoUpdateSchema = true
+	}
+}
+
+// MVDimensionTables declares the dimension tables the view joins with.
+func MVDimensionTables(tables ...string) MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.dimensionTables = tables
+	}
+}
+
+// MVFolder sets the folder of the view.
+func MVFolder(folder string) MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.folder = folder
+	}
+}
+
+// MVDocString sets the docstring of the view.
+func MVDocString(docString string) MaterializedViewOption {
+	return func(o *materializedViewOptions) {
+		o.docString = docString
+	}
+}
+
+// MaterializedView describes a materialized view, as returned by `.show materialized-view`.
+// IsHealthy, IsEnabled, MaterializedTo, LastRun and LastRunResult tell whether the view keeps up with its source table.
+type MaterializedView struct {
+	Name              string        `kusto:"Name"`
+	SourceTable       string        `kusto:"SourceTable"`
+	Query             string        `kusto:"Query"`
+	MaterializedTo    time.Time     `kusto:"MaterializedTo"`
+	LastRun           time.Time     `kusto:"LastRun"`
+	LastRunResult     string        `kusto:"LastRunResult"`
+	IsHealthy         bool          `kusto:"IsHealthy"`
+	IsEnabled         bool          `kusto:"IsEnabled"`
+	Folder            string        `kusto:"Folder"`
+	DocString         string        `kusto:"DocString"`
+	AutoUpdateSchema  bool          `kusto:"AutoUpdateSchema"`
+	EffectiveDateTime time.Time     `kusto:"EffectiveDateTime"`
+	Lookback          time.Duration `kusto:"Lookback"`
+}
+
+// MaterializedViewDetails holds the storage details of a materialized view, as returned by `.show materialized-view ... details`.
+type MaterializedViewDetails struct {
+	Name                   string    `kusto:"MaterializedViewName"`
+	Database               string    `kusto:"DatabaseName"`
+	Folder                 string    `kusto:"Folder"`
+	DocString              string    `kusto:"DocString"`
+	TotalExtents           int64     `kusto:"TotalExtents"`
+	TotalExtentSize        float64   `kusto:"TotalExtentSize"`
+	TotalOriginalSize      float64   `kusto:"TotalOriginalSize"`
+	TotalRowCount          int64     `kusto:"TotalRowCount"`
+	HotExtents             int64     `kusto:"HotExtents"`
+	HotExtentSize          float64   `kusto:"HotExtentSize"`
+	HotOriginalSize        float64   `kusto:"HotOriginalSize"`
+	HotRowCount            int64     `kusto:"HotRowCount"`
+	MinExtentsCreationTime time.Time `kusto:"MinExtentsCreationTime"`
+	MaxExtentsCreationTime time.Time `kusto:"MaxExtentsCreationTime"`
+}
+
+// operationRow is the result of an asynchronous command.
+type operationRow struct {
+	OperationID string `kusto:"OperationId"`
+}
+
+// CreateMaterializedView creates the materialized view name over sourceTable, with the given aggregation query.
+// If MVAsync is given, the ID of the operation is returned, which can be awaited with WaitForCompletion.
+func CreateMaterializedView(ctx context.Context, client Client, db string, name string, sourceTable string, query azkustodata.Statement, options ...MaterializedViewOption) (string, error) {
+	opts := newMaterializedViewOptions(options)
+	stmt, err := createMaterializedViewStatement(name, sourceTable, query, opts)
+	if err != nil {
+		return "", err
+	}
+
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil || !opts.async {
+		return "", err
+	}
+	rows, err := v1.ToStructs[operationRow](ds)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", errors.ES(errors.OpMgmt, errors.KInternal, "the asynchronous creation of materialized view %s returned no operation", name)
+	}
+	return rows[0].OperationID, nil
+}
+
+// AlterMaterializedView replaces the query of the materialized view name. The query must keep the same group-by keys and aggregations,
+// but can change their expressions. MVLookback, MVDimensionTables, MVFolder and MVDocString can be given.
+func AlterMaterializedView(ctx context.Context, client Client, db string, name string, sourceTable string, query azkustodata.Statement, options ...MaterializedViewOption) error {
+	stmt, err := AlterMaterializedViewStatement(name, sourceTable, query, options...)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// DropMaterializedView drops the materialized view name.
+func DropMaterializedView(ctx context.Context, client Client, db string, name string) error {
+	stmt, err := materializedViewStatement(kql.New(".drop materialized-view "), name)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// EnableMaterializedView resumes the materialization of the view name.
+func EnableMaterializedView(ctx context.Context, client Client, db string, name string) error {
+	stmt, err := materializedViewStatement(kql.New(".enable materialized-view "), name)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// DisableMaterializedView stops the materialization of the view name. Queries over the view still return the materialized part.
+func DisableMaterializedView(ctx context.Context, client Client, db string, name string) error {
+	stmt, err := materializedViewStatement(kql.New(".disable materialized-view "), name)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// ShowMaterializedViews returns the materialized views of the database.
+func ShowMaterializedViews(ctx context.Context, client Client, db string) ([]MaterializedView, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show materialized-views"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[MaterializedView](ds)
+}
+
+// ShowMaterializedView returns the materialized view name, including whether it is healthy and how far it is materialized.
+func ShowMaterializedView(ctx context.Context, client Client, db string, name string) (MaterializedView, error) {
+	stmt, err := materializedViewStatement(kql.New(".show materialized-view "), name)
+	if err != nil {
+		return MaterializedView{}, err
+	}
+	return showOne[MaterializedView](ctx, client, db, stmt, "materialized view", name)
+}
+
+// ShowMaterializedViewDetails returns the storage details of the materialized view name.
+func ShowMaterializedViewDetails(ctx context.Context, client Client, db string, name string) (MaterializedViewDetails, error) {
+	stmt, err := materializedViewStatement(kql.New(".show materialized-view "), name)
+	if err != nil {
+		return MaterializedViewDetails{}, err
+	}
+	return showOne[MaterializedViewDetails](ctx, client, db, stmt.AddLiteral(" details"), "materialized view", name)
+}
+
+// CreateMaterializedViewStatement builds the `.create materialized-view` command used by CreateMaterializedView.
+func CreateMaterializedViewStatement(name string, sourceTable string, query azkustodata.Statement, options ...MaterializedViewOption) (azkustodata.Statement, error) {
+	return createMaterializedViewStatement(name, sourceTable, query, newMaterializedViewOptions(options))
+}
+
+// AlterMaterializedViewStatement builds the `.alter materialized-view` command used by AlterMaterializedView.
+func AlterMaterializedViewStatement(name string, sourceTable string, query azkustodata.Statement, options ...MaterializedViewOption) (azkustodata.Statement, error) {
+	opts := newMaterializedViewOptions(options)
+	if opts.async || opts.ifNotExists || opts.backfill || opts.autoUpdateSchema {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "backfill, async, if-not-exists and auto update schema options are only valid when creating a materialized view").SetNoRetry()
+	}
+	return viewWithQuery(kql.New(".alter materialized-view "), name, sourceTable, query, opts)
+}
+
+func newMaterializedViewOptions(options []MaterializedViewOption) materializedViewOptions {
+	opts := materializedViewOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	return opts
+}
+
+func createMaterializedViewStatement(name string, sourceTable string, query azkustodata.Statement, opts materializedViewOptions) (azkustodata.Statement, error) {
+	stmt := kql.New(".create ")
+	if opts.async {
+		stmt.AddLiteral("async ")
+	}
+	if opts.ifNotExists {
+		stmt.AddLiteral("ifnotexists ")
+	}
+	return viewWithQuery(stmt.AddLiteral("materialized-view "), name, sourceTable, query, opts)
+}
+
+// viewWithQuery completes stmt with `with (properties) name on table sourceTable { query }`.
+func viewWithQuery(stmt *kql.Builder, name string, sourceTable string, query azkustodata.Statement, opts materializedViewOptions) (*kql.Builder, error) {
+	if name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "materialized view name must not be empty").SetNoRetry()
+	}
+	if sourceTable == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "source table must not be empty").SetNoRetry()
+	}
+	if query == nil || query.String() == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "materialized view query must not be empty").SetNoRetry()
+	}
+	if opts.lookback < 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "lookback must not be negative, got %s", opts.lookback).SetNoRetry()
+	}
+
+	props := &propertyList{stmt: stmt}
+	if opts.backfill {
+		props.next().AddLiteral("backfill=true")
+	}
+	if !opts.effectiveDateTime.IsZero() {
+		props.next().AddLiteral("effectiveDateTime=").AddDateTime(opts.effectiveDateTime)
+	}
+	if opts.updateExtentsCreationTime {
+		props.next().AddLiteral("updateExtentsCreationTime=true")
+	}
+	if opts.lookback > 0 {
+		props.next().AddLiteral("lookback=").AddTimespan(opts.lookback)
+	}
+	if opts.autoUpdateSchema {
+		props.next().AddLiteral("autoUpdateSchema=true")
+	}
+	if len(opts.dimensionTables) > 0 {
+		props.next().AddLiteral("dimensionTables=").AddDynamic(opts.dimensionTables)
+	}
+	if opts.folder != "" {
+		props.next().AddLiteral("folder=").AddString(opts.folder)
+	}
+	if opts.docString != "" {
+		props.next().AddLiteral("docString=").AddString(opts.docString)
+	}
+	props.close()
+
+	return stmt.AddTable(name).AddLiteral(" on table ").AddTable(sourceTable).
+		AddLiteral("\n{\n").AddUnsafe(query.String()).AddLiteral("\n}"), nil
+}
+
+// materializedViewStatement completes stmt with the escaped view name.
+func materializedViewStatement(stmt *kql.Builder, name string) (*kql.Builder, error) {
+	if name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "materialized view name must not be empty").SetNoRetry()
+	}
+	return stmt.AddTable(name), nil
+}
+
+// propertyList writes a `with (a=1, b=2) ` clause, if any property is added. If trailing is set, the clause ends the command instead, and
+// is written on a line of its own, without the space after it.
+type propertyList struct {
+	stmt     *kql.Builder
+	trailing bool
+	count    int
+}
+
+// next starts a property, and returns the statement to write it to.
+func (p *propertyList) next() *kql.Builder {
+	switch {
+	case p.count > 0:
+		p.stmt.AddLiteral(", ")
+	case p.trailing:
+		p.stmt.AddLiteral("\nwith (")
+	default:
+		p.stmt.AddLiteral("with (")
+	}
+	p.count++
+	return p.stmt
+}
+
+// close ends the clause.
+func (p *propertyList) close() {
+	switch {
+	case p.count == 0:
+	case p.trailing:
+		p.stmt.AddLiteral(")")
+	default:
+		p.stmt.AddLiteral(") ")
+	}
+}
@@ -0,0 +1,186 @@
+package management
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showMaterializedViewResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Name","DataType":"String","ColumnType":"string"},
+{"ColumnName":"SourceTable","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Query","DataType":"String","ColumnType":"string"},
+{"ColumnName":"MaterializedTo","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"LastRun","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"LastRunResult","DataType":"String","ColumnType":"string"},
+{"ColumnName":"IsHealthy","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"IsEnabled","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"Folder","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DocString","DataType":"String","ColumnType":"string"},
+{"ColumnName":"AutoUpdateSchema","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"EffectiveDateTime","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Lookback","DataType":"TimeSpan","ColumnType":"timespan"}],
+"Rows":[["LastEvent","Events","Events | summarize arg_max(Timestamp, *) by Id","2024-01-02T03:00:00Z","2024-01-02T03:01:00Z","Completed",true,true,"Views","",false,null,"6.00:00:00"]]}]}`
+
+const showMaterializedViewDetailsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"MaterializedViewName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Folder","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DocString","DataType":"String","ColumnType":"string"},
+{"ColumnName":"TotalExtents","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"TotalExtentSize","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"TotalOriginalSize","DataType":"Double","ColumnType":"real"},
+{"ColumnName":"TotalRowCount","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"MinExtentsCreationTime","DataType":"DateTime","ColumnType":"datetime"}],
+"Rows":[["LastEvent","db","Views","",12,1024.5,4096,1000,"2024-01-01T00:00:00Z"]]}]}`
+
+const operationResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"OperationId","DataType":"Guid","ColumnType":"guid"}],
+"Rows":[["0cd1e4c9-5c2a-4a33-8a33-1e3fe1e3f6d4"]]}]}`
+
+func TestMaterializedViewStatements(t *testing.T) {
+	t.Parallel()
+
+	query := kql.New("Events | summarize count() by Id")
+	effective := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc    string
+		alter   bool
+		name    string
+		options []MaterializedViewOption
+		want    string
+		err     bool
+	}{
+		{
+			desc: "plain",
+			name: "Counts",
+			want: ".create materialized-view Counts on table Events\n{\nEvents | summarize count() by Id\n}",
+		},
+		{
+			desc:    "backfill",
+			name:    "My Counts",
+			options: []MaterializedViewOption{MVAsync(), MVIfNotExists(), MVEffectiveDateTime(effective), MVUpdateExtentsCreationTime(), MVFolder("Views")},
+			want: ".create async ifnotexists materialized-view with (backfill=true, effectiveDateTime=datetime(2024-01-01T00:00:00Z), updateExtentsCreationTime=true, folder=\"Views\") " +
+				"[\"My Counts\"] on table Events\n{\nEvents | summarize count() by Id\n}",
+		},
+		{
+			desc:    "lookback and dimensions",
+			name:    "Counts",
+			options: []MaterializedViewOption{MVBackfill(), MVLookback(6 * time.Hour), MVDimensionTables("Dim"), MVAutoUpdateSchema(), MVDocString("doc")},
+			want: ".create materialized-view with (backfill=true, lookback=timespan(06:00:00.0000000), autoUpdateSchema=true, dimensionTables=dynamic([\"Dim\"]), docString=\"doc\") " +
+				"Counts on table Events\n{\nEvents | summarize count() by Id\n}",
+		},
+		{
+			desc:    "alter",
+			alter:   true,
+			name:    "Counts",
+			options: []MaterializedViewOption{MVLookback(time.Hour)},
+			want:    ".alter materialized-view with (lookback=timespan(01:00:00.0000000)) Counts on table Events\n{\nEvents | summarize count() by Id\n}",
+		},
+		{desc: "alter with backfill", alter: true, name: "Counts", options: []MaterializedViewOption{MVBackfill()}, err: true},
+		{desc: "no name", err: true},
+		{desc: "negative lookback", name: "Counts", options: []MaterializedViewOption{MVLookback(-time.Hour)}, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			create := CreateMaterializedViewStatement
+			if test.alter {
+				create = AlterMaterializedViewStatement
+			}
+			stmt, err := create(test.name, "Events", query, test.options...)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+
+	_, err := CreateMaterializedViewStatement("Counts", "Events", kql.New(""))
+	assert.Error(t, err)
+}
+
+func TestMaterializedViewLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(emptyResponse)
+	client.response = func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, ".create async"):
+			return operationResponse
+		case strings.HasSuffix(cmd, " details"):
+			return showMaterializedViewDetailsResponse
+		case strings.HasPrefix(cmd, ".show"):
+			return showMaterializedViewResponse
+		}
+		return emptyResponse
+	}
+	query := kql.New("Events | summarize arg_max(Timestamp, *) by Id")
+
+	id, err := CreateMaterializedView(ctx, client, "db", "LastEvent", "Events", query)
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	id, err = CreateMaterializedView(ctx, client, "db", "LastEvent", "Events", query, MVAsync(), MVBackfill())
+	require.NoError(t, err)
+	assert.Equal(t, "0cd1e4c9-5c2a-4a33-8a33-1e3fe1e3f6d4", id)
+
+	require.NoError(t, AlterMaterializedView(ctx, client, "db", "LastEvent", "Events", query))
+	require.NoError(t, DisableMaterializedView(ctx, client, "db", "LastEvent"))
+	require.NoError(t, EnableMaterializedView(ctx, client, "db", "LastEvent"))
+	require.NoError(t, DropMaterializedView(ctx, client, "db", "LastEvent"))
+
+	view, err := ShowMaterializedView(ctx, client, "db", "LastEvent")
+	require.NoError(t, err)
+	assert.Equal(t, MaterializedView{
+		Name:           "LastEvent",
+		SourceTable:    "Events",
+		Query:          "Events | summarize arg_max(Timestamp, *) by Id",
+		MaterializedTo: time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC),
+		LastRun:        time.Date(2024, 1, 2, 3, 1, 0, 0, time.UTC),
+		LastRunResult:  "Completed",
+		IsHealthy:      true,
+		IsEnabled:      true,
+		Folder:         "Views",
+		Lookback:       6 * 24 * time.Hour,
+	}, view)
+
+	views, err := ShowMaterializedViews(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Len(t, views, 1)
+
+	details, err := ShowMaterializedViewDetails(ctx, client, "db", "LastEvent")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), details.TotalExtents)
+	assert.Equal(t, 1024.5, details.TotalExtentSize)
+	assert.Equal(t, int64(1000), details.TotalRowCount)
+	assert.Equal(t, "db", details.Database)
+
+	assert.Equal(t, []string{
+		".create materialized-view LastEvent on table Events\n{\nEvents | summarize arg_max(Timestamp, *) by Id\n}",
+		".create async materialized-view with (backfill=true) LastEvent on table Events\n{\nEvents | summarize arg_max(Timestamp, *) by Id\n}",
+		".alter materialized-view LastEvent on table Events\n{\nEvents | summarize arg_max(Timestamp, *) by Id\n}",
+		".disable materialized-view LastEvent",
+		".enable materialized-view LastEvent",
+		".drop materialized-view LastEvent",
+		".show materialized-view LastEvent",
+		".show materialized-views",
+		".show materialized-view LastEvent details",
+	}, client.commands)
+
+	client.response = func(string) string { return emptyResponse }
+	_, err = ShowMaterializedView(ctx, client, "db", "Missing")
+	assert.ErrorContains(t, err, `materialized view "Missing" was not found in database "db"`)
+}
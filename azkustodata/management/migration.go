@@ -0,0 +1,274 @@
+package management
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// SchemaChangeKind is the kind of a SchemaChange.
+type SchemaChangeKind string
+
+const (
+	// ColumnAdded is a column of the desired schema that the table doesn't have.
+	ColumnAdded SchemaChangeKind = "add"
+	// ColumnTypeChanged is a column whose type differs between the table and the desired schema.
+	ColumnTypeChanged SchemaChangeKind = "alter-type"
+	// ColumnRemoved is a column of the table that the desired schema doesn't have.
+	ColumnRemoved SchemaChangeKind = "drop"
+)
+
+// SchemaChange is a difference between the schema of a table and a desired schema.
+type SchemaChange struct {
+	Kind   SchemaChangeKind
+	Column string
+	// From is the type of the column in the table. It is empty for ColumnAdded.
+	From types.Column
+	// To is the type of the column in the desired schema. It is empty for ColumnRemoved.
+	To types.Column
+}
+
+// Destructive reports whether the change loses data: dropping a column deletes its values, and changing its type makes the
+// values already ingested in the column null.
+func (c SchemaChange) Destructive() bool {
+	return c.Kind != ColumnAdded
+}
+
+// MigrationPlan holds the changes that converge a table to a desired schema, and the commands that apply them, in order.
+type MigrationPlan struct {
+	Table string
+	// Create is set if the table doesn't exist. Statements then holds a single `.create-merge table` command.
+	Create     bool
+	Changes    []SchemaChange
+	Statements []azkustodata.Statement
+}
+
+// Destructive reports whether any change of the plan loses data.
+func (p MigrationPlan) Destructive() bool {
+	for _, c := range p.Changes {
+		if c.Destructive() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty reports whether the table already has the desired schema.
+func (p MigrationPlan) IsEmpty() bool {
+	return len(p.Statements) == 0
+}
+
+// migrationOptions holds the options of Migrate.
+type migrationOptions struct {
+	dryRun      bool
+	destructive bool
+	dropColumns bool
+}
+
+// MigrationOption is an optional argument to PlanMigration and Migrate.
+type MigrationOption func(o *migrationOptions)
+
+// MigrateDryRun makes Migrate return the plan without running it.
+func MigrateDryRun() MigrationOption {
+	return func(o *migrationOptions) {
+		o.dryRun = true
+	}
+}
+
+// MigrateDropColumns also drops the columns of the table that the desired schema doesn't have. By default they are kept.
+func MigrateDropColumns() MigrationOption {
+	return func(o *migrationOptions) {
+		o.dropColumns = true
+	}
+}
+
+// MigrateDestructive lets Migrate run plans that lose data. Without it, Migrate returns an error for such plans, before running
+// any command.
+func MigrateDestructive() MigrationOption {
+	return func(o *migrationOptions) {
+		o.destructive = true
+	}
+}
+
+// DiffSchema compares the columns of a table with the desired columns, by name. The changes are ordered as the commands that
+// apply them: added columns, in the order of desired, then type changes, then removed columns, in the order of live.
+func DiffSchema(live query.Columns, desired query.Columns) []SchemaChange {
+	liveTypes := make(map[string]types.Column, len(live))
+	for _, c := range live {
+		liveTypes[c.Name()] = c.Type()
+	}
+	desiredTypes := make(map[string]types.Column, len(desired))
+	for _, c := range desired {
+		desiredTypes[c.Name()] = c.Type()
+	}
+
+	var added, altered, removed []SchemaChange
+	for _, c := range desired {
+		from, ok := liveTypes[c.Name()]
+		switch {
+		case !ok:
+			added = append(added, SchemaChange{Kind: ColumnAdded, Column: c.Name(), To: c.Type()})
+		case from != c.Type():
+			altered = append(altered, SchemaChange{Kind: ColumnTypeChanged, Column: c.Name(), From: from, To: c.Type()})
+		}
+	}
+	for _, c := range live {
+		if _, ok := desiredTypes[c.Name()]; !ok {
+			removed = append(removed, SchemaChange{Kind: ColumnRemoved, Column: c.Name(), From: c.Type()})
+		}
+	}
+
+	return append(append(added, altered...), removed...)
+}
+
+// PlanMigration compares the table with the desired columns, and returns the commands that converge it. Columns the desired schema
+// doesn't have are only dropped with MigrateDropColumns. Nothing is changed.
+func PlanMigration(ctx context.Context, client Client, db string, table string, desired query.Columns, options ...MigrationOption) (MigrationPlan, error) {
+	opts := migrationOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	if len(desired) == 0 {
+		return MigrationPlan{}, errors.ES(errors.OpMgmt, errors.KClientArgs, "table %s must have at least one column", table).SetNoRetry()
+	}
+	exists, err := tableExists(ctx, client, db, table)
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+	if !exists {
+		create, err := CreateMergeTableStatement(table, desired, "", "")
+		if err != nil {
+			return MigrationPlan{}, err
+		}
+		changes := DiffSchema(nil, desired)
+		return MigrationPlan{Table: table, Create: true, Changes: changes, Statements: []azkustodata.Statement{create}}, nil
+	}
+
+	info, err := ShowTableMetadata(ctx, client, db, table)
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+	changes := DiffSchema(info.Columns, desired)
+	if !opts.dropColumns {
+		kept := changes[:0]
+		for _, c := range changes {
+			if c.Kind != ColumnRemoved {
+				kept = append(kept, c)
+			}
+		}
+		changes = kept
+	}
+
+	stmts, err := MigrationStatements(table, changes)
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+	return MigrationPlan{Table: table, Changes: changes, Statements: stmts}, nil
+}
+
+// PlanMigrationFromStruct is PlanMigration with the columns of T, as created by CreateTableFromStruct.
+func PlanMigrationFromStruct[T any](ctx context.Context, client Client, db string, table string, options ...MigrationOption) (MigrationPlan, error) {
+	cols, err := TableColumnsFromStruct[T]()
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+	return PlanMigration(ctx, client, db, table, cols, options...)
+}
+
+// Migrate converges the table to the desired columns, and returns the plan it ran. With MigrateDryRun, the plan is only returned.
+func Migrate(ctx context.Context, client Client, db string, table string, desired query.Columns, options ...MigrationOption) (MigrationPlan, error) {
+	opts := migrationOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	plan, err := PlanMigration(ctx, client, db, table, desired, options...)
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+	if opts.dryRun {
+		return plan, nil
+	}
+	if plan.Destructive() && !opts.destructive {
+		return plan, errors.ES(errors.OpMgmt, errors.KClientArgs, "migrating table %s loses data, which requires MigrateDestructive", table).SetNoRetry()
+	}
+	return plan, run(ctx, client, db, plan.Statements...)
+}
+
+// MigrationStatements builds the commands that apply the changes to the table, in order: a single `.alter-merge table` for the
+// added columns, an `.alter column` for each type change, and a single `.drop table columns` for the removed columns.
+func MigrationStatements(table string, changes []SchemaChange) ([]azkustodata.Statement, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+
+	var added query.Columns
+	var altered, removed []SchemaChange
+	for _, c := range changes {
+		if c.Column == "" {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "column name must not be empty").SetNoRetry()
+		}
+		switch c.Kind {
+		case ColumnAdded:
+			added = append(added, query.NewColumn(len(added), c.Column, c.To))
+		case ColumnTypeChanged:
+			altered = append(altered, c)
+		case ColumnRemoved:
+			removed = append(removed, c)
+		default:
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown schema change kind %q", c.Kind).SetNoRetry()
+		}
+	}
+
+	var stmts []azkustodata.Statement
+	if len(added) > 0 {
+		stmt := kql.New(".alter-merge table ").AddTable(table).AddLiteral(" ")
+		if err := addColumnList(stmt, added); err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	for _, c := range altered {
+		if value.Default(c.To) == nil {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s has an unknown type %q", c.Column, c.To).SetNoRetry()
+		}
+		stmts = append(stmts, kql.New(".alter column ").AddTable(table).AddLiteral(".").AddColumn(c.Column).
+			AddLiteral(" type=").AddKeyword(string(c.To)))
+	}
+	if len(removed) > 0 {
+		stmt := kql.New(".drop table ").AddTable(table).AddLiteral(" columns (")
+		for i, c := range removed {
+			if i > 0 {
+				stmt.AddLiteral(", ")
+			}
+			stmt.AddColumn(c.Column)
+		}
+		stmts = append(stmts, stmt.AddLiteral(")"))
+	}
+	return stmts, nil
+}
+
+// tableExists reports whether the database db has the table.
+func tableExists(ctx context.Context, client Client, db string, table string) (bool, error) {
+	if table == "" {
+		return false, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	ds, err := client.Mgmt(ctx, db, kql.New(".show tables | where TableName == ").AddString(table))
+	if err != nil {
+		return false, err
+	}
+	rows, err := v1.ToStructs[struct {
+		Name string `kusto:"TableName"`
+	}](ds)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
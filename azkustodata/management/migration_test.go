@@ -0,0 +1,175 @@
+package management
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showTablesResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"}],
+"Rows":[["Logs","db"]]}]}`
+
+type migrationLog struct {
+	Timestamp string `kusto:"Timestamp"`
+	Message   string `kusto:"Message"`
+	Level     int64  `kusto:"Level"`
+}
+
+func TestDiffSchema(t *testing.T) {
+	t.Parallel()
+
+	live := query.Columns{
+		query.NewColumn(0, "Timestamp", types.DateTime),
+		query.NewColumn(1, "Message", types.String),
+		query.NewColumn(2, "Old", types.Int),
+	}
+
+	tests := []struct {
+		desc    string
+		live    query.Columns
+		desired query.Columns
+		want    []SchemaChange
+	}{
+		{desc: "same", live: live, desired: live[:3]},
+		{
+			desc:    "new table",
+			desired: query.Columns{query.NewColumn(0, "Timestamp", types.DateTime)},
+			want:    []SchemaChange{{Kind: ColumnAdded, Column: "Timestamp", To: types.DateTime}},
+		},
+		{
+			desc: "all changes",
+			live: live,
+			desired: query.Columns{
+				query.NewColumn(0, "Timestamp", types.String),
+				query.NewColumn(1, "Level", types.Long),
+				query.NewColumn(2, "Message", types.String),
+			},
+			want: []SchemaChange{
+				{Kind: ColumnAdded, Column: "Level", To: types.Long},
+				{Kind: ColumnTypeChanged, Column: "Timestamp", From: types.DateTime, To: types.String},
+				{Kind: ColumnRemoved, Column: "Old", From: types.Int},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, DiffSchema(test.live, test.desired))
+		})
+	}
+}
+
+func TestMigrationStatements(t *testing.T) {
+	t.Parallel()
+
+	stmts, err := MigrationStatements("My Logs", []SchemaChange{
+		{Kind: ColumnAdded, Column: "Level", To: types.Long},
+		{Kind: ColumnAdded, Column: "Host Name", To: types.String},
+		{Kind: ColumnTypeChanged, Column: "Timestamp", From: types.DateTime, To: types.String},
+		{Kind: ColumnRemoved, Column: "Old", From: types.Int},
+		{Kind: ColumnRemoved, Column: "Older", From: types.Int},
+	})
+	require.NoError(t, err)
+
+	got := make([]string, len(stmts))
+	for i, s := range stmts {
+		got[i] = s.String()
+	}
+	assert.Equal(t, []string{
+		`.alter-merge table ["My Logs"] (Level:long, ["Host Name"]:string)`,
+		`.alter column ["My Logs"].Timestamp type=string`,
+		`.drop table ["My Logs"] columns (Old, Older)`,
+	}, got)
+
+	stmts, err = MigrationStatements("Logs", nil)
+	require.NoError(t, err)
+	assert.Empty(t, stmts)
+
+	_, err = MigrationStatements("", nil)
+	assert.Error(t, err)
+	_, err = MigrationStatements("Logs", []SchemaChange{{Kind: "rename", Column: "a"}})
+	assert.Error(t, err)
+	_, err = MigrationStatements("Logs", []SchemaChange{{Kind: ColumnTypeChanged, Column: "a", To: "blob"}})
+	assert.Error(t, err)
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	newClient := func(exists bool) *fakeClient {
+		client := newFakeClient(emptyResponse)
+		client.response = func(cmd string) string {
+			switch {
+			case strings.HasPrefix(cmd, ".show tables"):
+				if exists {
+					return showTablesResponse
+				}
+				return emptyResponse
+			case strings.HasPrefix(cmd, ".show table"):
+				return showTableSchemaResponse
+			}
+			return emptyResponse
+		}
+		return client
+	}
+
+	// The table has Timestamp:datetime and Message:string, and the struct changes Timestamp to a string and adds Level.
+	client := newClient(true)
+	plan, err := PlanMigrationFromStruct[migrationLog](ctx, client, "db", "Logs")
+	require.NoError(t, err)
+	assert.False(t, plan.Create)
+	assert.True(t, plan.Destructive())
+	assert.Equal(t, []SchemaChange{
+		{Kind: ColumnAdded, Column: "Level", To: types.Long},
+		{Kind: ColumnTypeChanged, Column: "Timestamp", From: types.DateTime, To: types.String},
+	}, plan.Changes)
+	require.Len(t, plan.Statements, 2)
+
+	cols, err := TableColumnsFromStruct[migrationLog]()
+	require.NoError(t, err)
+
+	client = newClient(true)
+	_, err = Migrate(ctx, client, "db", "Logs", cols)
+	assert.Error(t, err)
+
+	client = newClient(true)
+	_, err = Migrate(ctx, client, "db", "Logs", cols, MigrateDryRun())
+	require.NoError(t, err)
+	assert.Equal(t, []string{`.show tables | where TableName == "Logs"`, ".show table Logs schema as json"}, client.commands)
+
+	client = newClient(true)
+	_, err = Migrate(ctx, client, "db", "Logs", cols[1:2], MigrateDropColumns(), MigrateDestructive())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`.show tables | where TableName == "Logs"`,
+		".show table Logs schema as json",
+		".drop table Logs columns (Timestamp)",
+	}, client.commands)
+
+	client = newClient(true)
+	plan, err = Migrate(ctx, client, "db", "Logs", cols[1:2])
+	require.NoError(t, err)
+	assert.True(t, plan.IsEmpty())
+
+	client = newClient(false)
+	plan, err = Migrate(ctx, client, "db", "Logs", cols)
+	require.NoError(t, err)
+	assert.True(t, plan.Create)
+	assert.Equal(t, ".create-merge table Logs (Timestamp:string, Message:string, Level:long)", client.commands[1])
+
+	_, err = Migrate(ctx, client, "db", "", cols)
+	assert.Error(t, err)
+	_, err = Migrate(ctx, client, "db", "Logs", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,199 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/google/uuid"
+)
+
+// OperationState is the state of an asynchronous operation.
+type OperationState string
+
+const (
+	OperationInProgress         OperationState = "InProgress"
+	OperationScheduled          OperationState = "Scheduled"
+	OperationThrottled          OperationState = "Throttled"
+	OperationCompleted          OperationState = "Completed"
+	OperationPartiallySucceeded OperationState = "PartiallySucceeded"
+	OperationFailed             OperationState = "Failed"
+	OperationBadInput           OperationState = "BadInput"
+	OperationAbandoned          OperationState = "Abandoned"
+	OperationCanceled           OperationState = "Canceled"
+	OperationSkipped            OperationState = "Skipped"
+)
+
+// IsFinal reports whether the operation has ended, and its state won't change anymore.
+func (s OperationState) IsFinal() bool {
+	switch s {
+	case OperationInProgress, OperationScheduled, OperationThrottled, "":
+		return false
+	}
+	return true
+}
+
+// IsSuccess reports whether the operation has completed successfully.
+func (s OperationState) IsSuccess() bool {
+	return s == OperationCompleted
+}
+
+// Operation describes an operation, as returned by `.show operations`.
+type Operation struct {
+	ID            string         `kusto:"OperationId"`
+	Operation     string         `kusto:"Operation"`
+	NodeID        string         `kusto:"NodeId"`
+	StartedOn     time.Time      `kusto:"StartedOn"`
+	LastUpdatedOn time.Time      `kusto:"LastUpdatedOn"`
+	Duration      time.Duration  `kusto:"Duration"`
+	State         OperationState `kusto:"State"`
+	// Status holds the details of the state, such as the error of a failed operation.
+	Status         string `kusto:"Status"`
+	RootActivityID string `kusto:"RootActivityId"`
+	// ShouldRetry reports whether a failed operation can be run again.
+	ShouldRetry bool   `kusto:"ShouldRetry"`
+	Database    string `kusto:"Database"`
+	Principal   string `kusto:"Principal"`
+	User        string `kusto:"User"`
+}
+
+// Err returns nil if the operation completed successfully, or an error describing its state otherwise.
+// Callers that run failed operations again should check ShouldRetry first.
+func (o Operation) Err() error {
+	if o.State.IsSuccess() {
+		return nil
+	}
+	if !o.State.IsFinal() {
+		return errors.ES(errors.OpMgmt, errors.KOther, "operation %s (%s) is still in state %s", o.ID, o.Operation, o.State)
+	}
+	return errors.ES(errors.OpMgmt, errors.KOther, "operation %s (%s) ended in state %s: %s", o.ID, o.Operation, o.State, o.Status)
+}
+
+type waitOptions struct {
+	interval    time.Duration
+	maxInterval time.Duration
+}
+
+// WaitOption is an option for WaitForCompletion and the other functions that wait for an operation.
+type WaitOption func(o *waitOptions)
+
+// WithPollInterval sets the interval before the first poll of the operation. It doubles after every poll, up to the maximum
+// set by WithMaxPollInterval. It defaults to DefaultPollInterval.
+func WithPollInterval(d time.Duration) WaitOption {
+	return func(o *waitOptions) {
+		o.interval = d
+	}
+}
+
+// WithMaxPollInterval sets the maximum interval between the polls of the operation. It defaults to DefaultMaxPollInterval.
+func WithMaxPollInterval(d time.Duration) WaitOption {
+	return func(o *waitOptions) {
+		o.maxInterval = d
+	}
+}
+
+var (
+	DefaultPollInterval    = time.Second
+	DefaultMaxPollInterval = 30 * time.Second
+)
+
+// ShowOperation returns the operation with the given ID.
+func ShowOperation(ctx context.Context, client Client, db string, operationID string) (Operation, error) {
+	stmt, err := ShowOperationStatement(operationID)
+	if err != nil {
+		return Operation{}, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return Operation{}, err
+	}
+	ops, err := v1.ToStructs[Operation](ds)
+	if err != nil {
+		return Operation{}, err
+	}
+	if len(ops) == 0 {
+		return Operation{}, errors.ES(errors.OpMgmt, errors.KOther, "operation %s was not found", operationID)
+	}
+	// The operation can have a row per update of its state; the latest one is its current state.
+	latest := ops[0]
+	for _, op := range ops[1:] {
+		if op.LastUpdatedOn.After(latest.LastUpdatedOn) {
+			latest = op
+		}
+	}
+	return latest, nil
+}
+
+// ShowOperations returns the operations that ran recently on the cluster.
+func ShowOperations(ctx context.Context, client Client, db string) ([]Operation, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show operations"))
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[Operation](ds)
+}
+
+// WaitForCompletion polls the operation with the given ID until it ends, with an increasing interval between the polls, and returns
+// its final state. If the operation didn't complete successfully, the error of Operation.Err is returned with it.
+// It stops polling when ctx is done.
+func WaitForCompletion(ctx context.Context, client Client, db string, operationID string, options ...WaitOption) (Operation, error) {
+	if _, err := ShowOperationStatement(operationID); err != nil {
+		return Operation{}, err
+	}
+	var op Operation
+	err := poll(ctx, options, func() (bool, error) {
+		var err error
+		op, err = ShowOperation(ctx, client, db, operationID)
+		return err == nil && op.State.IsFinal(), err
+	})
+	if err != nil {
+		return Operation{}, err
+	}
+	return op, op.Err()
+}
+
+// poll calls check with an increasing interval until it returns true or an error, or until ctx is done.
+func poll(ctx context.Context, options []WaitOption, check func() (bool, error)) error {
+	opts := waitOptions{interval: DefaultPollInterval, maxInterval: DefaultMaxPollInterval}
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.interval <= 0 || opts.maxInterval < opts.interval {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "invalid poll intervals %s and %s", opts.interval, opts.maxInterval).SetNoRetry()
+	}
+
+	timer := time.NewTimer(opts.interval)
+	defer timer.Stop()
+	interval := opts.interval
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.E(errors.OpMgmt, errors.KTimeout, ctx.Err())
+		case <-timer.C:
+		}
+
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+
+		interval *= 2
+		if interval > opts.maxInterval {
+			interval = opts.maxInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+// ShowOperationStatement builds a `.show operations <id>` command.
+func ShowOperationStatement(operationID string) (azkustodata.Statement, error) {
+	id, err := uuid.Parse(operationID)
+	if err != nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "operation ID %q is not a GUID", operationID).SetNoRetry()
+	}
+	// The ID was parsed as a GUID, so it is safe to write as is.
+	return kql.New(".show operations ").AddUnsafe(id.String()), nil
+}
@@ -0,0 +1,147 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const operationID = "0cd1e4c9-5c2a-4a33-8a33-1e3fe1e3f6d4"
+
+func showOperationsResponse(rows ...string) string {
+	body := `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"OperationId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"Operation","DataType":"String","ColumnType":"string"},
+{"ColumnName":"NodeId","DataType":"String","ColumnType":"string"},
+{"ColumnName":"StartedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"LastUpdatedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Duration","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"State","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Status","DataType":"String","ColumnType":"string"},
+{"ColumnName":"RootActivityId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"ShouldRetry","DataType":"Boolean","ColumnType":"bool"},
+{"ColumnName":"Database","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Principal","DataType":"String","ColumnType":"string"},
+{"ColumnName":"User","DataType":"String","ColumnType":"string"},
+{"ColumnName":"AdminEpochStartTime","DataType":"DateTime","ColumnType":"datetime"}],"Rows":[`
+	for i, r := range rows {
+		if i > 0 {
+			body += ","
+		}
+		body += r
+	}
+	return body + `]}]}`
+}
+
+func operationRowJSON(state string, updated string, status string, shouldRetry bool) string {
+	return fmt.Sprintf(`["%s","MaterializedViewCreateOrAlter","","2024-01-01T00:00:00Z","%s","00:01:30","%s","%s","9f5a3e2c-7d3b-4bfa-9a2e-3c1b2d4e5f60",%t,"db","aadapp=5a4b","",null]`,
+		operationID, updated, state, status, shouldRetry)
+}
+
+func TestShowOperation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(showOperationsResponse(
+		operationRowJSON("InProgress", "2024-01-01T00:00:30Z", "", false),
+		operationRowJSON("Completed", "2024-01-01T00:01:30Z", "", false),
+		operationRowJSON("InProgress", "2024-01-01T00:01:00Z", "", false),
+	))
+
+	op, err := ShowOperation(ctx, client, "db", operationID)
+	require.NoError(t, err)
+	assert.Equal(t, Operation{
+		ID:             operationID,
+		Operation:      "MaterializedViewCreateOrAlter",
+		StartedOn:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		LastUpdatedOn:  time.Date(2024, 1, 1, 0, 1, 30, 0, time.UTC),
+		Duration:       90 * time.Second,
+		State:          OperationCompleted,
+		RootActivityID: "9f5a3e2c-7d3b-4bfa-9a2e-3c1b2d4e5f60",
+		Database:       "db",
+		Principal:      "aadapp=5a4b",
+	}, op)
+	assert.NoError(t, op.Err())
+
+	ops, err := ShowOperations(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Len(t, ops, 3)
+
+	assert.Equal(t, []string{".show operations " + operationID, ".show operations"}, client.commands)
+
+	_, err = ShowOperation(ctx, client, "db", "not-a-guid")
+	assert.Error(t, err)
+
+	client.response = func(string) string { return showOperationsResponse() }
+	_, err = ShowOperation(ctx, client, "db", operationID)
+	assert.ErrorContains(t, err, "was not found")
+}
+
+func TestWaitForCompletion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		states []string
+		want   OperationState
+		polls  int
+		err    string
+	}{
+		{desc: "completed", states: []string{"InProgress", "Scheduled", "Completed"}, want: OperationCompleted, polls: 3},
+		{desc: "failed", states: []string{"InProgress", "Failed"}, want: OperationFailed, polls: 2, err: "ended in state Failed: disk full"},
+		{desc: "canceled", states: []string{"InProgress"}, polls: -1, err: "context deadline exceeded"},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			polls := 0
+			client := newFakeClient("")
+			client.response = func(string) string {
+				state := test.states[len(test.states)-1]
+				if polls < len(test.states) {
+					state = test.states[polls]
+				}
+				polls++
+				return showOperationsResponse(operationRowJSON(state, "2024-01-01T00:01:30Z", "disk full", true))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			op, err := WaitForCompletion(ctx, client, "db", operationID, WithPollInterval(time.Millisecond), WithMaxPollInterval(4*time.Millisecond))
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.want, op.State)
+			if test.polls >= 0 {
+				assert.Equal(t, test.polls, polls)
+			}
+			if test.want == OperationFailed {
+				assert.True(t, op.ShouldRetry)
+			}
+		})
+	}
+}
+
+func TestWaitForCompletionArgs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(emptyResponse)
+
+	_, err := WaitForCompletion(ctx, client, "db", "not-a-guid")
+	assert.Error(t, err)
+	_, err = WaitForCompletion(ctx, client, "db", operationID, WithPollInterval(time.Minute), WithMaxPollInterval(time.Second))
+	assert.Error(t, err)
+	assert.False(t, errors.Retry(err))
+	assert.Empty(t, client.commands)
+}
@@ -0,0 +1,59 @@
+package policies
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+)
+
+// AutoDeletePolicy drops a table once it expires, such as a table created for the duration of a job.
+type AutoDeletePolicy struct {
+	ExpiryDate time.Time
+	// DeleteIfNotEmpty drops the table even if it still has records. Otherwise, only an empty table is dropped.
+	DeleteIfNotEmpty bool
+}
+
+// ShowAutoDelete returns the auto delete policy of the table, or nil if it isn't set.
+func ShowAutoDelete(ctx context.Context, client management.Client, db string, table string) (*AutoDeletePolicy, error) {
+	var p AutoDeletePolicy
+	ok, err := showPolicy(ctx, client, db, Table(table), AutoDeleteKind, &p)
+	if !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AlterAutoDelete sets the auto delete policy of the table.
+func AlterAutoDelete(ctx context.Context, client management.Client, db string, table string, p AutoDeletePolicy) error {
+	stmt, err := AlterAutoDeleteStatement(table, p)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// AutoDeleteAfter sets the auto delete policy of the table, so it is dropped, with its records, once ttl has passed.
+func AutoDeleteAfter(ctx context.Context, client management.Client, db string, table string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "time to live must be positive, got %s", ttl).SetNoRetry()
+	}
+	return AlterAutoDelete(ctx, client, db, table, AutoDeletePolicy{ExpiryDate: time.Now().Add(ttl).UTC(), DeleteIfNotEmpty: true})
+}
+
+// DeleteAutoDelete deletes the auto delete policy of the table, so it is kept.
+func DeleteAutoDelete(ctx context.Context, client management.Client, db string, table string) error {
+	stmt, err := entityStatement(kql.New(".delete "), Table(table))
+	if err != nil {
+		return err
+	}
+	return alterPolicy(ctx, client, db, stmt.AddLiteral(" policy auto_delete"), nil)
+}
+
+// AlterAutoDeleteStatement builds the `.alter table policy auto_delete` command.
+func AlterAutoDeleteStatement(table string, p AutoDeletePolicy) (azkustodata.Statement, error) {
+	if p.ExpiryDate.IsZero() {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "expiry date must be set").SetNoRetry()
+	}
+	return alterPolicyStatement(Table(table), AutoDeleteKind, p)
+}
@@ -0,0 +1,81 @@
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+)
+
+// MinimumBatchingTimeSpan is the shortest batching time Kusto accepts.
+const MinimumBatchingTimeSpan = 10 * time.Second
+
+// IngestionBatchingPolicy decides when queued ingestions are sealed into a batch. A batch is sealed as soon as one of the limits is reached.
+// A limit that is 0 uses the default of Kusto.
+type IngestionBatchingPolicy struct {
+	// MaximumBatchingTimeSpan is how long data is batched before it is ingested.
+	MaximumBatchingTimeSpan time.Duration
+	// MaximumNumberOfItems is the number of blobs in a batch.
+	MaximumNumberOfItems int
+	// MaximumRawDataSizeMB is the size of the uncompressed data in a batch.
+	MaximumRawDataSizeMB int
+}
+
+type ingestionBatchingJSON struct {
+	MaximumBatchingTimeSpan timespan `json:",omitempty"`
+	MaximumNumberOfItems    int      `json:",omitempty"`
+	MaximumRawDataSizeMB    int      `json:",omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, with the format of the policy in Kusto.
+func (p IngestionBatchingPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ingestionBatchingJSON{
+		MaximumBatchingTimeSpan: timespan(p.MaximumBatchingTimeSpan),
+		MaximumNumberOfItems:    p.MaximumNumberOfItems,
+		MaximumRawDataSizeMB:    p.MaximumRawDataSizeMB,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, with the format of the policy in Kusto.
+func (p *IngestionBatchingPolicy) UnmarshalJSON(b []byte) error {
+	var j ingestionBatchingJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*p = IngestionBatchingPolicy{
+		MaximumBatchingTimeSpan: time.Duration(j.MaximumBatchingTimeSpan),
+		MaximumNumberOfItems:    j.MaximumNumberOfItems,
+		MaximumRawDataSizeMB:    j.MaximumRawDataSizeMB,
+	}
+	return nil
+}
+
+// ShowIngestionBatching returns the ingestion batching policy of the entity, or nil if it isn't set.
+func ShowIngestionBatching(ctx context.Context, client management.Client, db string, e Entity) (*IngestionBatchingPolicy, error) {
+	var p IngestionBatchingPolicy
+	ok, err := showPolicy(ctx, client, db, e, IngestionBatchingKind, &p)
+	if !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AlterIngestionBatching sets the ingestion batching policy of the entity.
+func AlterIngestionBatching(ctx context.Context, client management.Client, db string, e Entity, p IngestionBatchingPolicy) error {
+	stmt, err := AlterIngestionBatchingStatement(e, p)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// AlterIngestionBatchingStatement builds the `.alter <entity> policy ingestionbatching` command.
+func AlterIngestionBatchingStatement(e Entity, p IngestionBatchingPolicy) (azkustodata.Statement, error) {
+	if p.MaximumBatchingTimeSpan != 0 && p.MaximumBatchingTimeSpan < MinimumBatchingTimeSpan {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "batching time span must be at least %s, got %s", MinimumBatchingTimeSpan, p.MaximumBatchingTimeSpan).SetNoRetry()
+	}
+	if p.MaximumNumberOfItems < 0 || p.MaximumRawDataSizeMB < 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "batching limits must not be negative").SetNoRetry()
+	}
+	return alterPolicyStatement(e, IngestionBatchingKind, p)
+}
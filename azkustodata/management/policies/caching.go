@@ -0,0 +1,76 @@
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+)
+
+// CachingPolicy decides how long data is kept in the hot cache, where it is queried faster.
+type CachingPolicy struct {
+	// DataHotSpan is how long the data is cached, measured from its ingestion.
+	DataHotSpan time.Duration
+	// IndexHotSpan is how long the indexes of the data are cached. It is usually equal to DataHotSpan.
+	IndexHotSpan time.Duration
+}
+
+type cachingJSON struct {
+	DataHotSpan  timespan
+	IndexHotSpan timespan
+}
+
+// MarshalJSON implements json.Marshaler, with the format of the policy in Kusto.
+func (p CachingPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cachingJSON{DataHotSpan: timespan(p.DataHotSpan), IndexHotSpan: timespan(p.IndexHotSpan)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, with the format of the policy in Kusto.
+func (p *CachingPolicy) UnmarshalJSON(b []byte) error {
+	var j cachingJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*p = CachingPolicy{DataHotSpan: time.Duration(j.DataHotSpan), IndexHotSpan: time.Duration(j.IndexHotSpan)}
+	return nil
+}
+
+// ShowCaching returns the caching policy of the entity, or nil if it isn't set.
+func ShowCaching(ctx context.Context, client management.Client, db string, e Entity) (*CachingPolicy, error) {
+	var p CachingPolicy
+	ok, err := showPolicy(ctx, client, db, e, CachingKind, &p)
+	if !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AlterCaching sets the caching policy of the entity.
+func AlterCaching(ctx context.Context, client management.Client, db string, e Entity, p CachingPolicy) error {
+	stmt, err := AlterCachingStatement(e, p)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// AlterCachingStatement builds the `.alter <entity> policy caching` command. If IndexHotSpan is 0, DataHotSpan is used for both.
+func AlterCachingStatement(e Entity, p CachingPolicy) (azkustodata.Statement, error) {
+	if p.IndexHotSpan == 0 {
+		p.IndexHotSpan = p.DataHotSpan
+	}
+	if p.DataHotSpan < 0 || p.IndexHotSpan < 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "hot spans must not be negative, got %s and %s", p.DataHotSpan, p.IndexHotSpan).SetNoRetry()
+	}
+
+	stmt, err := entityStatement(kql.New(".alter "), e)
+	if err != nil {
+		return nil, err
+	}
+	stmt.AddLiteral(" policy caching ")
+	if p.DataHotSpan == p.IndexHotSpan {
+		return stmt.AddLiteral("hot = ").AddTimespan(p.DataHotSpan), nil
+	}
+	return stmt.AddLiteral("hotdata = ").AddTimespan(p.DataHotSpan).AddLiteral(", hotindex = ").AddTimespan(p.IndexHotSpan), nil
+}
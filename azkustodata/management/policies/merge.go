@@ -0,0 +1,156 @@
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+)
+
+// LookbackKind decides which extents the merge policy considers, by their creation time.
+type LookbackKind string
+
+const (
+	// LookbackDefault considers the extents created in the last DefaultLookbackPeriod.
+	LookbackDefault LookbackKind = "Default"
+	// LookbackAll considers all the extents.
+	LookbackAll LookbackKind = "All"
+	// LookbackHotCache considers the extents in the hot cache, per the caching policy.
+	LookbackHotCache LookbackKind = "HotCache"
+	// LookbackCustom considers the extents created in the last Lookback.CustomPeriod.
+	LookbackCustom LookbackKind = "Custom"
+)
+
+// DefaultLookbackPeriod is the period of the Default lookback.
+const DefaultLookbackPeriod = 14 * 24 * time.Hour
+
+// Lookback decides which extents the merge policy considers. Extents created before it, such as those of backfilled data with
+// an old creation time, aren't merged.
+type Lookback struct {
+	// Kind is the kind of the lookback. Kusto uses LookbackDefault if it is empty.
+	Kind LookbackKind
+	// CustomPeriod is the period of the Custom kind.
+	CustomPeriod time.Duration
+}
+
+// Period returns how far back from now the lookback goes, given the hot span of the caching policy for the HotCache kind. It
+// returns 0 if there is no limit, for the All kind, or the HotCache kind without a hot span.
+func (l Lookback) Period(hotSpan time.Duration) time.Duration {
+	switch l.Kind {
+	case LookbackAll:
+		return 0
+	case LookbackHotCache:
+		return hotSpan
+	case LookbackCustom:
+		return l.CustomPeriod
+	}
+	return DefaultLookbackPeriod
+}
+
+// MergePolicy decides how the extents of a table are merged into larger ones. To change some of its settings only, alter the
+// policy returned by ShowMerge, as the zero values of the others, such as AllowMerge, aren't Kusto's defaults.
+type MergePolicy struct {
+	// RowCountUpperBoundForMerge is the largest number of rows of a merged extent.
+	RowCountUpperBoundForMerge int64
+	// OriginalSizeMBUpperBoundForMerge is the largest size of the data of a merged extent, before compression, in MB.
+	OriginalSizeMBUpperBoundForMerge int64
+	// MaxExtentsToMerge is the largest number of extents merged at once.
+	MaxExtentsToMerge int
+	// MaxRangeInHours is the largest difference between the creation times of the extents merged together.
+	MaxRangeInHours int
+	// AllowRebuild allows extents to be rebuilt, and AllowMerge to be merged.
+	AllowRebuild bool
+	AllowMerge   bool
+	// Lookback decides which extents are considered.
+	Lookback Lookback
+}
+
+type lookbackJSON struct {
+	Kind         LookbackKind `json:",omitempty"`
+	CustomPeriod *timespan
+}
+
+type mergeJSON struct {
+	RowCountUpperBoundForMerge       int64 `json:",omitempty"`
+	OriginalSizeMBUpperBoundForMerge int64 `json:",omitempty"`
+	MaxExtentsToMerge                int   `json:",omitempty"`
+	MaxRangeInHours                  int   `json:",omitempty"`
+	AllowRebuild                     bool
+	AllowMerge                       bool
+	Lookback                         lookbackJSON
+}
+
+// MarshalJSON implements json.Marshaler, with the format of the policy in Kusto.
+func (p MergePolicy) MarshalJSON() ([]byte, error) {
+	j := mergeJSON{
+		RowCountUpperBoundForMerge:       p.RowCountUpperBoundForMerge,
+		OriginalSizeMBUpperBoundForMerge: p.OriginalSizeMBUpperBoundForMerge,
+		MaxExtentsToMerge:                p.MaxExtentsToMerge,
+		MaxRangeInHours:                  p.MaxRangeInHours,
+		AllowRebuild:                     p.AllowRebuild,
+		AllowMerge:                       p.AllowMerge,
+		Lookback:                         lookbackJSON{Kind: p.Lookback.Kind},
+	}
+	if p.Lookback.Kind == LookbackCustom {
+		period := timespan(p.Lookback.CustomPeriod)
+		j.Lookback.CustomPeriod = &period
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, with the format of the policy in Kusto.
+func (p *MergePolicy) UnmarshalJSON(b []byte) error {
+	var j mergeJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*p = MergePolicy{
+		RowCountUpperBoundForMerge:       j.RowCountUpperBoundForMerge,
+		OriginalSizeMBUpperBoundForMerge: j.OriginalSizeMBUpperBoundForMerge,
+		MaxExtentsToMerge:                j.MaxExtentsToMerge,
+		MaxRangeInHours:                  j.MaxRangeInHours,
+		AllowRebuild:                     j.AllowRebuild,
+		AllowMerge:                       j.AllowMerge,
+		Lookback:                         Lookback{Kind: j.Lookback.Kind},
+	}
+	if j.Lookback.CustomPeriod != nil {
+		p.Lookback.CustomPeriod = time.Duration(*j.Lookback.CustomPeriod)
+	}
+	return nil
+}
+
+// ShowMerge returns the merge policy of the entity, or nil if it isn't set.
+func ShowMerge(ctx context.Context, client management.Client, db string, e Entity) (*MergePolicy, error) {
+	var p MergePolicy
+	ok, err := showPolicy(ctx, client, db, e, MergeKind, &p)
+	if !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AlterMerge sets the merge policy of the entity.
+func AlterMerge(ctx context.Context, client management.Client, db string, e Entity, p MergePolicy) error {
+	stmt, err := AlterMergeStatement(e, p)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// AlterMergeStatement builds the `.alter <entity> policy merge` command.
+func AlterMergeStatement(e Entity, p MergePolicy) (azkustodata.Statement, error) {
+	if p.RowCountUpperBoundForMerge < 0 || p.OriginalSizeMBUpperBoundForMerge < 0 || p.MaxExtentsToMerge < 0 || p.MaxRangeInHours < 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "the bounds of the merge policy must not be negative").SetNoRetry()
+	}
+	switch p.Lookback.Kind {
+	case "", LookbackDefault, LookbackAll, LookbackHotCache:
+	case LookbackCustom:
+		if p.Lookback.CustomPeriod <= 0 {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "the period of a custom lookback must be positive, got %s", p.Lookback.CustomPeriod).SetNoRetry()
+		}
+	default:
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown lookback kind %q", p.Lookback.Kind).SetNoRetry()
+	}
+	return alterPolicyStatement(e, MergeKind, p)
+}
@@ -0,0 +1,209 @@
+/*
+Package policies provides typed getters and setters for the policies of Kusto tables and databases.
+
+The policies are read with `.show <entity> policy <kind>`, whose JSON is parsed into a struct, and written with the matching
+`.alter` command:
+
+	retention, err := policies.ShowRetention(ctx, client, "db", policies.Table("Events"))
+	...
+	err = policies.AlterRetention(ctx, client, "db", policies.Table("Events"), policies.RetentionPolicy{SoftDeletePeriod: 30 * 24 * time.Hour})
+
+The Show functions return nil if the policy isn't set on the entity, in which case the policy of its parent applies.
+*/
+package policies
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// EntityType is the type of entity a policy is set on.
+type EntityType string
+
+const (
+	// TableEntity is a table.
+	TableEntity EntityType = "table"
+	// DatabaseEntity is a database.
+	DatabaseEntity EntityType = "database"
+)
+
+// Entity is the table or database a policy is set on.
+type Entity struct {
+	Type EntityType
+	Name string
+}
+
+// Table returns the table entity with the given name.
+func Table(name string) Entity {
+	return Entity{Type: TableEntity, Name: name}
+}
+
+// Database returns the database entity with the given name.
+func Database(name string) Entity {
+	return Entity{Type: DatabaseEntity, Name: name}
+}
+
+// PolicyKind is the kind of a policy, as written in the policy commands.
+type PolicyKind string
+
+const (
+	// RetentionKind is the retention policy, see RetentionPolicy.
+	RetentionKind PolicyKind = "retention"
+	// CachingKind is the caching policy, see CachingPolicy.
+	CachingKind PolicyKind = "caching"
+	// IngestionBatchingKind is the ingestion batching policy, see IngestionBatchingPolicy.
+	IngestionBatchingKind PolicyKind = "ingestionbatching"
+	// RowLevelSecurityKind is the row level security policy of a table, see RowLevelSecurityPolicy.
+	RowLevelSecurityKind PolicyKind = "row_level_security"
+	// StreamingIngestionKind is the streaming ingestion policy, see StreamingIngestionPolicy.
+	StreamingIngestionKind PolicyKind = "streamingingestion"
+	// UpdateKind is the update policy of a table, see UpdatePolicy.
+	UpdateKind PolicyKind = "update"
+	// AutoDeleteKind is the auto delete policy of a table, see AutoDeletePolicy.
+	AutoDeleteKind PolicyKind = "auto_delete"
+	// MergeKind is the merge policy, see MergePolicy.
+	MergeKind PolicyKind = "merge"
+)
+
+// ShowPolicyStatement builds a `.show <entity> policy <kind>` command.
+func ShowPolicyStatement(e Entity, kind PolicyKind) (azkustodata.Statement, error) {
+	stmt, err := entityStatement(kql.New(".show "), e)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateKind(kind); err != nil {
+		return nil, err
+	}
+	return stmt.AddLiteral(" policy ").AddKeyword(string(kind)), nil
+}
+
+// alterPolicyStatement builds an `.alter <entity> policy <kind>` command with the policy as a JSON string literal.
+func alterPolicyStatement(e Entity, kind PolicyKind, policy interface{}) (azkustodata.Statement, error) {
+	stmt, err := entityStatement(kql.New(".alter "), e)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateKind(kind); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return nil, errors.E(errors.OpMgmt, errors.KClientArgs, err).SetNoRetry()
+	}
+	return stmt.AddLiteral(" policy ").AddKeyword(string(kind)).AddLiteral(" ").AddString(string(b)), nil
+}
+
+// entityStatement completes stmt with the type and the escaped name of e.
+func entityStatement(stmt *kql.Builder, e Entity) (*kql.Builder, error) {
+	if e.Type != TableEntity && e.Type != DatabaseEntity {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown entity type %q", e.Type).SetNoRetry()
+	}
+	if e.Name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "%s name must not be empty", e.Type).SetNoRetry()
+	}
+	return stmt.AddKeyword(string(e.Type)).AddLiteral(" ").AddTable(e.Name), nil
+}
+
+func validateKind(kind PolicyKind) error {
+	switch kind {
+	case RetentionKind, CachingKind, IngestionBatchingKind, RowLevelSecurityKind, StreamingIngestionKind, UpdateKind, AutoDeleteKind, MergeKind:
+		return nil
+	}
+	return errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown policy kind %q", kind).SetNoRetry()
+}
+
+// policyRow is a row of `.show <entity> policy <kind>`.
+type policyRow struct {
+	PolicyName string `kusto:"PolicyName"`
+	EntityName string `kusto:"EntityName"`
+	Policy     string `kusto:"Policy"`
+}
+
+// showPolicy runs `.show <entity> policy <kind>` and unmarshals the policy into out. It returns false if the policy isn't set.
+func showPolicy(ctx context.Context, client management.Client, db string, e Entity, kind PolicyKind, out interface{}) (bool, error) {
+	stmt, err := ShowPolicyStatement(e, kind)
+	if err != nil {
+		return false, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return false, err
+	}
+
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return false, errors.ES(errors.OpMgmt, errors.KInternal, "the command returned no tables")
+	}
+	rows, err := query.ToStructs[policyRow](tables[0])
+	if err != nil {
+		return false, err
+	}
+	if len(rows) == 0 {
+		return false, nil
+	}
+
+	policy := bytes.TrimSpace([]byte(rows[0].Policy))
+	if len(policy) == 0 || bytes.Equal(policy, []byte("null")) {
+		return false, nil
+	}
+	if err := json.Unmarshal(policy, out); err != nil {
+		return false, errors.ES(errors.OpMgmt, errors.KFailedToParse, "%s policy of %s %s could not be parsed: %s", kind, e.Type, e.Name, err)
+	}
+	return true, nil
+}
+
+// alterPolicy runs stmt, if it was built without an error.
+func alterPolicy(ctx context.Context, client management.Client, db string, stmt azkustodata.Statement, err error) error {
+	if err != nil {
+		return err
+	}
+	_, err = client.Mgmt(ctx, db, stmt)
+	return err
+}
+
+// timespan is a time.Duration in the JSON of a policy. It is written in the Kusto format, and read from that format or from
+// an object holding it in a Value property, which Kusto uses for some policies.
+type timespan time.Duration
+
+func (t timespan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.TimespanString(time.Duration(t)))
+}
+
+func (t *timespan) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*t = 0
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var wrapped struct {
+			Value *string
+		}
+		if err := json.Unmarshal(b, &wrapped); err != nil {
+			return err
+		}
+		if wrapped.Value == nil {
+			*t = 0
+			return nil
+		}
+		s = *wrapped.Value
+	}
+
+	d, err := value.ParseTimespan(s)
+	if err != nil {
+		return err
+	}
+	*t = timespan(d)
+	return nil
+}
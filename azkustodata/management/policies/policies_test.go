@@ -0,0 +1,333 @@
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient answers every command with a `.show policy` result holding policy, or the policy of byCommand for the commands
+// it has, and records the commands. The commands of raw are answered with their response as is.
+type fakeClient struct {
+	policy    string
+	byCommand map[string]string
+	raw       map[string]string
+	commands  []string
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, _ string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	f.commands = append(f.commands, kqlQuery.String())
+	if body, ok := f.raw[kqlQuery.String()]; ok {
+		return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(body)))
+	}
+	p, ok := f.byCommand[kqlQuery.String()]
+	if !ok {
+		p = f.policy
+	}
+	policy, _ := json.Marshal(p)
+	body := `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"PolicyName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Policy","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ChildEntities","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityType","DataType":"String","ColumnType":"string"}],
+"Rows":[["Policy","[db].[T]",` + string(policy) + `,"","Table"]]}]}`
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(body)))
+}
+
+func TestShowPolicyStatement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		entity Entity
+		kind   PolicyKind
+		want   string
+		err    bool
+	}{
+		{desc: "table", entity: Table("T"), kind: RetentionKind, want: ".show table T policy retention"},
+		{desc: "database", entity: Database("my-db"), kind: CachingKind, want: `.show database ["my-db"] policy caching`},
+		{desc: "batching", entity: Table("T"), kind: IngestionBatchingKind, want: ".show table T policy ingestionbatching"},
+		{desc: "row level security", entity: Table("T"), kind: RowLevelSecurityKind, want: ".show table T policy row_level_security"},
+		{desc: "streaming ingestion", entity: Database("db"), kind: StreamingIngestionKind, want: ".show database db policy streamingingestion"},
+		{desc: "unknown kind", entity: Table("T"), kind: "sharding", err: true},
+		{desc: "unknown entity", entity: Entity{Type: "cluster", Name: "c"}, kind: RetentionKind, err: true},
+		{desc: "no name", entity: Table(""), kind: RetentionKind, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := ShowPolicyStatement(test.entity, test.kind)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+}
+
+func TestRetention(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{policy: `{"SoftDeletePeriod":"365.00:00:00","Recoverability":"Disabled"}`}
+
+	p, err := ShowRetention(ctx, client, "db", Table("T"))
+	require.NoError(t, err)
+	assert.Equal(t, &RetentionPolicy{SoftDeletePeriod: 365 * 24 * time.Hour, Recoverability: RecoverabilityDisabled}, p)
+	assert.Equal(t, ".show table T policy retention", client.commands[0])
+
+	require.NoError(t, AlterRetention(ctx, client, "db", Database("db"), RetentionPolicy{SoftDeletePeriod: 36 * time.Hour}))
+	assert.Equal(t, `.alter database db policy retention "{\"SoftDeletePeriod\":\"1.12:00:00\"}"`, client.commands[1])
+
+	assert.Error(t, AlterRetention(ctx, client, "db", Table("T"), RetentionPolicy{}))
+	assert.Error(t, AlterRetention(ctx, client, "db", Table("T"), RetentionPolicy{SoftDeletePeriod: time.Hour, Recoverability: "Maybe"}))
+	assert.Len(t, client.commands, 2)
+
+	client.policy = "null"
+	p, err = ShowRetention(ctx, client, "db", Table("T"))
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	client.policy = "not json"
+	_, err = ShowRetention(ctx, client, "db", Table("T"))
+	assert.Error(t, err)
+}
+
+func TestCaching(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{policy: `{"DataHotSpan":{"Value":"7.00:00:00"},"IndexHotSpan":"14.00:00:00"}`}
+
+	p, err := ShowCaching(ctx, client, "db", Table("T"))
+	require.NoError(t, err)
+	assert.Equal(t, &CachingPolicy{DataHotSpan: 7 * 24 * time.Hour, IndexHotSpan: 14 * 24 * time.Hour}, p)
+
+	require.NoError(t, AlterCaching(ctx, client, "db", Table("T"), CachingPolicy{DataHotSpan: 24 * time.Hour}))
+	assert.Equal(t, ".alter table T policy caching hot = timespan(1.00:00:00.0000000)", client.commands[1])
+
+	require.NoError(t, AlterCaching(ctx, client, "db", Table("T"), *p))
+	assert.Equal(t, ".alter table T policy caching hotdata = timespan(7.00:00:00.0000000), hotindex = timespan(14.00:00:00.0000000)", client.commands[2])
+
+	assert.Error(t, AlterCaching(ctx, client, "db", Table("T"), CachingPolicy{DataHotSpan: -time.Hour}))
+}
+
+func TestIngestionBatching(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{policy: `{"MaximumBatchingTimeSpan":"00:05:00","MaximumNumberOfItems":500,"MaximumRawDataSizeMB":1024}`}
+
+	p, err := ShowIngestionBatching(ctx, client, "db", Table("T"))
+	require.NoError(t, err)
+	assert.Equal(t, &IngestionBatchingPolicy{MaximumBatchingTimeSpan: 5 * time.Minute, MaximumNumberOfItems: 500, MaximumRawDataSizeMB: 1024}, p)
+
+	require.NoError(t, AlterIngestionBatching(ctx, client, "db", Table("T"), IngestionBatchingPolicy{MaximumBatchingTimeSpan: 30 * time.Second, MaximumNumberOfItems: 20}))
+	assert.Equal(t, `.alter table T policy ingestionbatching "{\"MaximumBatchingTimeSpan\":\"00:00:30\",\"MaximumNumberOfItems\":20}"`, client.commands[1])
+
+	assert.Error(t, AlterIngestionBatching(ctx, client, "db", Table("T"), IngestionBatchingPolicy{MaximumBatchingTimeSpan: time.Second}))
+	assert.Error(t, AlterIngestionBatching(ctx, client, "db", Table("T"), IngestionBatchingPolicy{MaximumRawDataSizeMB: -1}))
+}
+
+func TestRowLevelSecurity(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{policy: `{"IsEnabled":true,"Query":"TenantRows(\"contoso\")"}`}
+
+	p, err := ShowRowLevelSecurity(ctx, client, "db", "T")
+	require.NoError(t, err)
+	assert.Equal(t, &RowLevelSecurityPolicy{IsEnabled: true, Query: `TenantRows("contoso")`}, p)
+
+	query := RowLevelSecurityQuery("Tenant Rows", value.NewString(`o"brien`), value.NewLong(2))
+	assert.Equal(t, `["Tenant Rows"]("o\"brien", long(2))`, query.String())
+
+	require.NoError(t, EnableRowLevelSecurity(ctx, client, "db", "My T", query))
+	assert.Equal(t, `.alter table ["My T"] policy row_level_security enable "[\"Tenant Rows\"](\"o\\\"brien\", long(2))"`, client.commands[1])
+
+	require.NoError(t, DisableRowLevelSecurity(ctx, client, "db", "T", kql.New("T | where Tenant == current_principal()")))
+	assert.Equal(t, `.alter table T policy row_level_security disable "T | where Tenant == current_principal()"`, client.commands[2])
+
+	assert.Error(t, EnableRowLevelSecurity(ctx, client, "db", "T", kql.New("")))
+	assert.Error(t, EnableRowLevelSecurity(ctx, client, "db", "", query))
+	assert.Len(t, client.commands, 3)
+}
+
+func TestStreamingIngestion(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{policy: `{"IsEnabled":true,"HintAllocatedRate":2.5}`}
+
+	p, err := ShowStreamingIngestion(ctx, client, "db", Table("T"))
+	require.NoError(t, err)
+	rate := 2.5
+	assert.Equal(t, &StreamingIngestionPolicy{IsEnabled: true, HintAllocatedRate: &rate}, p)
+
+	require.NoError(t, EnableStreamingIngestion(ctx, client, "db", Table("My T")))
+	require.NoError(t, DisableStreamingIngestion(ctx, client, "db", Database("db")))
+	assert.Error(t, EnableStreamingIngestion(ctx, client, "db", Table("")))
+	assert.Equal(t, []string{
+		".show table T policy streamingingestion",
+		`.alter table ["My T"] policy streamingingestion enable`,
+		".alter database db policy streamingingestion disable",
+	}, client.commands)
+
+	tests := []struct {
+		desc     string
+		table    string
+		database string
+		want     bool
+	}{
+		{desc: "table enabled", table: `{"IsEnabled":true}`, database: `{"IsEnabled":false}`, want: true},
+		{desc: "table disabled", table: `{"IsEnabled":false}`, database: `{"IsEnabled":true}`, want: false},
+		{desc: "database enabled", table: "null", database: `{"IsEnabled":true}`, want: true},
+		{desc: "no policy", table: "null", database: "", want: false},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := &fakeClient{byCommand: map[string]string{
+				".show table T policy streamingingestion":     test.table,
+				".show database db policy streamingingestion": test.database,
+			}}
+			enabled, err := IsStreamingIngestionEnabled(ctx, client, "db", "T")
+			require.NoError(t, err)
+			assert.Equal(t, test.want, enabled)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{
+		policy: `[{"IsEnabled":true,"Source":"Raw","Query":"RawToLogs()","IsTransactional":true,"PropagateIngestionProperties":false,"ManagedIdentity":null}]`,
+		raw: map[string]string{".show functions": `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Name","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Parameters","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Body","DataType":"String","ColumnType":"string"}],
+"Rows":[["RawToLogs","()","{ Raw }"],["Raw To Events","()","{ Raw }"]]}]}`},
+	}
+
+	p, err := ShowUpdate(ctx, client, "db", "Logs")
+	require.NoError(t, err)
+	assert.Equal(t, []UpdatePolicy{{IsEnabled: true, Source: "Raw", Query: "RawToLogs()", IsTransactional: true}}, p)
+
+	require.NoError(t, AlterUpdate(ctx, client, "db", "Logs", []UpdatePolicy{
+		{IsEnabled: true, Source: "Raw", Query: "RawToLogs()", IsTransactional: true},
+		{IsEnabled: true, Source: "Raw", Query: `["Raw To Events"]()`, PropagateIngestionProperties: true, ManagedIdentity: "system"},
+		{IsEnabled: false, Source: "Raw", Query: "Raw | where Level == 2"},
+	}))
+	require.NoError(t, DeleteUpdate(ctx, client, "db", "My Logs"))
+	assert.Equal(t, []string{
+		".show table Logs policy update",
+		".show functions",
+		`.alter table Logs policy update "[{\"IsEnabled\":true,\"Source\":\"Raw\",\"Query\":\"RawToLogs()\",` +
+			`\"IsTransactional\":true,\"PropagateIngestionProperties\":false},` +
+			`{\"IsEnabled\":true,\"Source\":\"Raw\",\"Query\":\"[\\\"Raw To Events\\\"]()\",` +
+			`\"IsTransactional\":false,\"PropagateIngestionProperties\":true,\"ManagedIdentity\":\"system\"},` +
+			`{\"IsEnabled\":false,\"Source\":\"Raw\",\"Query\":\"Raw | where Level == 2\",` +
+			`\"IsTransactional\":false,\"PropagateIngestionProperties\":false}]"`,
+		`.delete table ["My Logs"] policy update`,
+	}, client.commands)
+
+	client.commands = nil
+	assert.Error(t, AlterUpdate(ctx, client, "db", "Logs", []UpdatePolicy{{Source: "Raw", Query: "MissingFunction(1)"}}))
+	assert.Error(t, AlterUpdate(ctx, client, "db", "Logs", []UpdatePolicy{{Query: "RawToLogs()"}}))
+	assert.Error(t, AlterUpdate(ctx, client, "db", "Logs", []UpdatePolicy{{Source: "Raw", Query: " "}}))
+	assert.Equal(t, []string{".show functions"}, client.commands)
+
+	stmt, err := AlterUpdateStatement("Logs", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `.alter table Logs policy update "[]"`, stmt.String())
+
+	client = &fakeClient{policy: "[]"}
+	p, err = ShowUpdate(ctx, client, "db", "Logs")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestAutoDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{policy: `{"ExpiryDate":"2024-01-02T00:00:00.0000000Z","DeleteIfNotEmpty":true}`}
+	expiry := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	p, err := ShowAutoDelete(ctx, client, "db", "Job1")
+	require.NoError(t, err)
+	assert.Equal(t, &AutoDeletePolicy{ExpiryDate: expiry, DeleteIfNotEmpty: true}, p)
+
+	require.NoError(t, AlterAutoDelete(ctx, client, "db", "Job 1", AutoDeletePolicy{ExpiryDate: expiry}))
+	require.NoError(t, DeleteAutoDelete(ctx, client, "db", "Job1"))
+	assert.Equal(t, []string{
+		".show table Job1 policy auto_delete",
+		`.alter table ["Job 1"] policy auto_delete "{\"ExpiryDate\":\"2024-01-02T00:00:00Z\",\"DeleteIfNotEmpty\":false}"`,
+		".delete table Job1 policy auto_delete",
+	}, client.commands)
+
+	require.NoError(t, AutoDeleteAfter(ctx, client, "db", "Job1", time.Hour))
+	assert.Contains(t, client.commands[3], `\"DeleteIfNotEmpty\":true}`)
+
+	assert.Error(t, AutoDeleteAfter(ctx, client, "db", "Job1", 0))
+	assert.Error(t, AlterAutoDelete(ctx, client, "db", "Job1", AutoDeletePolicy{}))
+	assert.Error(t, AlterAutoDelete(ctx, client, "db", "", AutoDeletePolicy{ExpiryDate: expiry}))
+	assert.Len(t, client.commands, 4)
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{policy: `{"RowCountUpperBoundForMerge":16000000,"OriginalSizeMBUpperBoundForMerge":30000,"MaxExtentsToMerge":100,` +
+		`"MaxRangeInHours":24,"AllowRebuild":true,"AllowMerge":true,"Lookback":{"Kind":"Custom","CustomPeriod":"30.00:00:00"}}`}
+
+	p, err := ShowMerge(ctx, client, "db", Table("T"))
+	require.NoError(t, err)
+	assert.Equal(t, &MergePolicy{
+		RowCountUpperBoundForMerge:       16000000,
+		OriginalSizeMBUpperBoundForMerge: 30000,
+		MaxExtentsToMerge:                100,
+		MaxRangeInHours:                  24,
+		AllowRebuild:                     true,
+		AllowMerge:                       true,
+		Lookback:                         Lookback{Kind: LookbackCustom, CustomPeriod: 30 * 24 * time.Hour},
+	}, p)
+	assert.Equal(t, 30*24*time.Hour, p.Lookback.Period(0))
+	assert.Equal(t, DefaultLookbackPeriod, Lookback{}.Period(time.Hour))
+	assert.Equal(t, time.Hour, Lookback{Kind: LookbackHotCache}.Period(time.Hour))
+	assert.Zero(t, Lookback{Kind: LookbackAll}.Period(time.Hour))
+
+	require.NoError(t, AlterMerge(ctx, client, "db", Table("T"), MergePolicy{AllowMerge: true, Lookback: Lookback{Kind: LookbackAll}}))
+	assert.Equal(t, `.alter table T policy merge "{\"AllowRebuild\":false,\"AllowMerge\":true,\"Lookback\":{\"Kind\":\"All\",\"CustomPeriod\":null}}"`, client.commands[1])
+
+	require.NoError(t, AlterMerge(ctx, client, "db", Table("T"), *p))
+	assert.Contains(t, client.commands[2], `\"Lookback\":{\"Kind\":\"Custom\",\"CustomPeriod\":\"30.00:00:00\"}`)
+
+	assert.Error(t, AlterMerge(ctx, client, "db", Table("T"), MergePolicy{Lookback: Lookback{Kind: LookbackCustom}}))
+	assert.Error(t, AlterMerge(ctx, client, "db", Table("T"), MergePolicy{Lookback: Lookback{Kind: "Recent"}}))
+	assert.Error(t, AlterMerge(ctx, client, "db", Table("T"), MergePolicy{MaxExtentsToMerge: -1}))
+	assert.Len(t, client.commands, 3)
+}
@@ -0,0 +1,76 @@
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+)
+
+// Recoverability decides whether data can be recovered after it was deleted.
+type Recoverability string
+
+const (
+	RecoverabilityEnabled  Recoverability = "Enabled"
+	RecoverabilityDisabled Recoverability = "Disabled"
+)
+
+// RetentionPolicy decides how long data is kept.
+type RetentionPolicy struct {
+	// SoftDeletePeriod is how long the data is available for queries, measured from its ingestion.
+	SoftDeletePeriod time.Duration
+	// Recoverability decides whether the data can be recovered after it was deleted. Kusto enables it if it is empty.
+	Recoverability Recoverability
+}
+
+type retentionJSON struct {
+	SoftDeletePeriod timespan
+	Recoverability   Recoverability `json:",omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, with the format of the policy in Kusto.
+func (p RetentionPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(retentionJSON{SoftDeletePeriod: timespan(p.SoftDeletePeriod), Recoverability: p.Recoverability})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, with the format of the policy in Kusto.
+func (p *RetentionPolicy) UnmarshalJSON(b []byte) error {
+	var j retentionJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*p = RetentionPolicy{SoftDeletePeriod: time.Duration(j.SoftDeletePeriod), Recoverability: j.Recoverability}
+	return nil
+}
+
+// ShowRetention returns the retention policy of the entity, or nil if it isn't set.
+func ShowRetention(ctx context.Context, client management.Client, db string, e Entity) (*RetentionPolicy, error) {
+	var p RetentionPolicy
+	ok, err := showPolicy(ctx, client, db, e, RetentionKind, &p)
+	if !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AlterRetention sets the retention policy of the entity.
+func AlterRetention(ctx context.Context, client management.Client, db string, e Entity, p RetentionPolicy) error {
+	stmt, err := AlterRetentionStatement(e, p)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// AlterRetentionStatement builds the `.alter <entity> policy retention` command.
+func AlterRetentionStatement(e Entity, p RetentionPolicy) (azkustodata.Statement, error) {
+	if p.SoftDeletePeriod <= 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "soft delete period must be positive, got %s", p.SoftDeletePeriod).SetNoRetry()
+	}
+	switch p.Recoverability {
+	case "", RecoverabilityEnabled, RecoverabilityDisabled:
+	default:
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown recoverability %q", p.Recoverability).SetNoRetry()
+	}
+	return alterPolicyStatement(e, RetentionKind, p)
+}
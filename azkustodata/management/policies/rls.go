@@ -0,0 +1,76 @@
+package policies
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// RowLevelSecurityPolicy restricts the rows of a table that a principal can query. While it is enabled, the queries of the
+// table read the result of Query instead.
+type RowLevelSecurityPolicy struct {
+	IsEnabled bool
+	// Query is the query that replaces the table, usually the invocation of a function filtering it, such as
+	// "TenantRows()".
+	Query string
+}
+
+// ShowRowLevelSecurity returns the row level security policy of the table, or nil if it isn't set.
+func ShowRowLevelSecurity(ctx context.Context, client management.Client, db string, table string) (*RowLevelSecurityPolicy, error) {
+	var p RowLevelSecurityPolicy
+	ok, err := showPolicy(ctx, client, db, Table(table), RowLevelSecurityKind, &p)
+	if !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// EnableRowLevelSecurity sets the row level security policy of the table to query, and enables it.
+func EnableRowLevelSecurity(ctx context.Context, client management.Client, db string, table string, query azkustodata.Statement) error {
+	stmt, err := AlterRowLevelSecurityStatement(table, true, query)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// DisableRowLevelSecurity sets the row level security policy of the table to query, and disables it.
+func DisableRowLevelSecurity(ctx context.Context, client management.Client, db string, table string, query azkustodata.Statement) error {
+	stmt, err := AlterRowLevelSecurityStatement(table, false, query)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// RowLevelSecurityQuery builds the query of a row level security policy that invokes the function with the given
+// arguments, which are quoted as needed:
+//
+//	query := policies.RowLevelSecurityQuery("TenantRows", value.NewString("contoso"))
+func RowLevelSecurityQuery(function string, args ...value.Kusto) azkustodata.Statement {
+	query := kql.New("").AddFunction(function).AddLiteral("(")
+	for i, arg := range args {
+		if i > 0 {
+			query.AddLiteral(", ")
+		}
+		query.AddValue(arg)
+	}
+	return query.AddLiteral(")")
+}
+
+// AlterRowLevelSecurityStatement builds the `.alter table policy row_level_security` command. query is passed as a string
+// literal, so it is escaped once more.
+func AlterRowLevelSecurityStatement(table string, enable bool, query azkustodata.Statement) (azkustodata.Statement, error) {
+	if query == nil || query.String() == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "row level security query must not be empty").SetNoRetry()
+	}
+	stmt, err := entityStatement(kql.New(".alter "), Table(table))
+	if err != nil {
+		return nil, err
+	}
+	stmt.AddLiteral(" policy row_level_security ")
+	if enable {
+		stmt.AddLiteral("enable ")
+	} else {
+		stmt.AddLiteral("disable ")
+	}
+	return stmt.AddString(query.String()), nil
+}
@@ -0,0 +1,68 @@
+package policies
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+)
+
+// StreamingIngestionPolicy decides whether data can be ingested with streaming ingestion, which also requires streaming
+// ingestion to be enabled on the cluster.
+type StreamingIngestionPolicy struct {
+	IsEnabled bool
+	// HintAllocatedRate is the expected ingestion rate of the table, in GB per hour, if it was set.
+	HintAllocatedRate *float64 `json:",omitempty"`
+}
+
+// ShowStreamingIngestion returns the streaming ingestion policy of the entity, or nil if it isn't set.
+func ShowStreamingIngestion(ctx context.Context, client management.Client, db string, e Entity) (*StreamingIngestionPolicy, error) {
+	var p StreamingIngestionPolicy
+	ok, err := showPolicy(ctx, client, db, e, StreamingIngestionKind, &p)
+	if !ok {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// EnableStreamingIngestion enables streaming ingestion on the entity.
+func EnableStreamingIngestion(ctx context.Context, client management.Client, db string, e Entity) error {
+	stmt, err := AlterStreamingIngestionStatement(e, true)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// DisableStreamingIngestion disables streaming ingestion on the entity.
+func DisableStreamingIngestion(ctx context.Context, client management.Client, db string, e Entity) error {
+	stmt, err := AlterStreamingIngestionStatement(e, false)
+	return alterPolicy(ctx, client, db, stmt, err)
+}
+
+// IsStreamingIngestionEnabled reports whether data can be streamed into the table, which is the case if the policy of the table
+// enables it, or if the table has no policy and the policy of the database db enables it.
+// It doesn't check that streaming ingestion is enabled on the cluster.
+func IsStreamingIngestionEnabled(ctx context.Context, client management.Client, db string, table string) (bool, error) {
+	p, err := ShowStreamingIngestion(ctx, client, db, Table(table))
+	if err != nil {
+		return false, err
+	}
+	if p == nil {
+		p, err = ShowStreamingIngestion(ctx, client, db, Database(db))
+		if err != nil || p == nil {
+			return false, err
+		}
+	}
+	return p.IsEnabled, nil
+}
+
+// AlterStreamingIngestionStatement builds the `.alter <entity> policy streamingingestion enable|disable` command.
+func AlterStreamingIngestionStatement(e Entity, enable bool) (azkustodata.Statement, error) {
+	stmt, err := entityStatement(kql.New(".alter "), e)
+	if err != nil {
+		return nil, err
+	}
+	if enable {
+		return stmt.AddLiteral(" policy streamingingestion enable"), nil
+	}
+	return stmt.AddLiteral(" policy streamingingestion disable"), nil
+}
@@ -0,0 +1,111 @@
+package policies
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/management/functions"
+)
+
+// UpdatePolicy runs Query on the data ingested into the Source table, and appends its result to the table the policy is set
+// on. A table can have several update policies, one per source.
+type UpdatePolicy struct {
+	IsEnabled bool
+	Source    string
+	// Query transforms the data of Source, usually with the invocation of a function, such as "RawToLogs()".
+	Query string
+	// IsTransactional fails the ingestion into Source if the update fails, so neither table gets the data.
+	IsTransactional bool
+	// PropagateIngestionProperties applies the properties of the ingestion into Source, such as its tags, to the data of the
+	// update.
+	PropagateIngestionProperties bool
+	// ManagedIdentity runs Query as the managed identity, "system" or the object ID of a user assigned identity. It is required if
+	// Query references tables with a row level security policy.
+	ManagedIdentity string `json:",omitempty"`
+}
+
+// invocationPattern matches a query that starts with the invocation of a function, and captures its name as written by
+// kql.NormalizeName.
+var invocationPattern = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*|\["(?:[^"\\]|\\.)*"\])\s*\(`)
+
+// ShowUpdate returns the update policies of the table, or nil if it has none.
+func ShowUpdate(ctx context.Context, client management.Client, db string, table string) ([]UpdatePolicy, error) {
+	var p []UpdatePolicy
+	ok, err := showPolicy(ctx, client, db, Table(table), UpdateKind, &p)
+	if !ok || len(p) == 0 {
+		return nil, err
+	}
+	return p, nil
+}
+
+// AlterUpdate replaces the update policies of the table. Before altering them, it checks that the source tables are set, and that
+// the functions invoked by the queries exist in the database db, since Kusto only reports a missing function when data is
+// ingested.
+func AlterUpdate(ctx context.Context, client management.Client, db string, table string, policies []UpdatePolicy) error {
+	stmt, err := AlterUpdateStatement(table, policies)
+	if err != nil {
+		return err
+	}
+	if err := validateUpdateFunctions(ctx, client, db, policies); err != nil {
+		return err
+	}
+	return alterPolicy(ctx, client, db, stmt, nil)
+}
+
+// DeleteUpdate deletes the update policies of the table.
+func DeleteUpdate(ctx context.Context, client management.Client, db string, table string) error {
+	stmt, err := entityStatement(kql.New(".delete "), Table(table))
+	if err != nil {
+		return err
+	}
+	return alterPolicy(ctx, client, db, stmt.AddLiteral(" policy update"), nil)
+}
+
+// AlterUpdateStatement builds the `.alter table policy update` command used by AlterUpdate. It doesn't check the functions.
+func AlterUpdateStatement(table string, policies []UpdatePolicy) (azkustodata.Statement, error) {
+	for i, p := range policies {
+		if p.Source == "" {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "update policy[%d] has no source table", i).SetNoRetry()
+		}
+		if strings.TrimSpace(p.Query) == "" {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "update policy[%d] has no query", i).SetNoRetry()
+		}
+	}
+	if policies == nil {
+		policies = []UpdatePolicy{}
+	}
+	return alterPolicyStatement(Table(table), UpdateKind, policies)
+}
+
+// validateUpdateFunctions checks that the functions invoked by the queries of the policies exist in the database db.
+func validateUpdateFunctions(ctx context.Context, client management.Client, db string, policies []UpdatePolicy) error {
+	var invoked []string
+	for _, p := range policies {
+		if m := invocationPattern.FindStringSubmatch(p.Query); m != nil {
+			invoked = append(invoked, m[1])
+		}
+	}
+	if len(invoked) == 0 {
+		return nil
+	}
+
+	fns, err := functions.List(ctx, client, db)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(fns))
+	for _, f := range fns {
+		names[kql.NormalizeName(f.Name)] = true
+	}
+	for _, name := range invoked {
+		if !names[name] {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "function %s of the update policy was not found in database %q", name, db).SetNoRetry()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package management
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// PrincipalType is the type of a Microsoft Entra ID (AAD) principal, as written in its fully qualified name.
+type PrincipalType string
+
+const (
+	// AADUser is a user, identified by their UPN or object ID.
+	AADUser PrincipalType = "aaduser"
+	// AADGroup is a security group, identified by its object ID or name.
+	AADGroup PrincipalType = "aadgroup"
+	// AADApp is an application, identified by its application (client) ID.
+	AADApp PrincipalType = "aadapp"
+)
+
+// Principal is a principal that can be granted a role.
+type Principal struct {
+	Type PrincipalType
+	// ID identifies the principal, see the PrincipalType constants.
+	ID string
+	// Tenant is the ID or the domain of the tenant of the principal. If empty, the tenant of the cluster is used.
+	Tenant string
+}
+
+// UserPrincipal returns the user principal with the given UPN or object ID.
+func UserPrincipal(id string, tenant string) Principal {
+	return Principal{Type: AADUser, ID: id, Tenant: tenant}
+}
+
+// GroupPrincipal returns the group principal with the given object ID or name.
+func GroupPrincipal(id string, tenant string) Principal {
+	return Principal{Type: AADGroup, ID: id, Tenant: tenant}
+}
+
+// AppPrincipal returns the application principal with the given application ID.
+func AppPrincipal(appID string, tenant string) Principal {
+	return Principal{Type: AADApp, ID: appID, Tenant: tenant}
+}
+
+// ParsePrincipal parses the fully qualified name of a principal, such as "aadapp=<app id>;<tenant>".
+func ParsePrincipal(fqn string) (Principal, error) {
+	typ, rest, ok := strings.Cut(fqn, "=")
+	if !ok || rest == "" {
+		return Principal{}, errors.ES(errors.OpMgmt, errors.KClientArgs, "principal %q is not a fully qualified name", fqn).SetNoRetry()
+	}
+	id, tenant, _ := strings.Cut(rest, ";")
+	p := Principal{Type: PrincipalType(strings.ToLower(typ)), ID: id, Tenant: tenant}
+	if err := p.validate(); err != nil {
+		return Principal{}, err
+	}
+	return p, nil
+}
+
+// String returns the fully qualified name of the principal, which is used in the principal commands.
+func (p Principal) String() string {
+	if p.Tenant == "" {
+		return string(p.Type) + "=" + p.ID
+	}
+	return string(p.Type) + "=" + p.ID + ";" + p.Tenant
+}
+
+func (p Principal) validate() error {
+	switch p.Type {
+	case AADUser, AADGroup, AADApp:
+	default:
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown principal type %q", p.Type).SetNoRetry()
+	}
+	if p.ID == "" || strings.ContainsAny(p.ID, ";=") || strings.Contains(p.Tenant, ";") {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "principal %q is not valid", p.String()).SetNoRetry()
+	}
+	return nil
+}
+
+// Role is a security role of a database or a table.
+type Role string
+
+const (
+	// AdminsRole can do anything in the database or the table.
+	AdminsRole Role = "admins"
+	// UsersRole can read the database and create tables and functions in it.
+	UsersRole Role = "users"
+	// ViewersRole can read the database.
+	ViewersRole Role = "viewers"
+	// UnrestrictedViewersRole can read the database, bypassing its row level security and restricted view access policies.
+	UnrestrictedViewersRole Role = "unrestrictedviewers"
+	// IngestorsRole can ingest data into the database or the table.
+	IngestorsRole Role = "ingestors"
+	// MonitorsRole can see the metadata and the operations of the database.
+	MonitorsRole Role = "monitors"
+)
+
+var (
+	databaseRoles = map[Role]bool{AdminsRole: true, UsersRole: true, ViewersRole: true, UnrestrictedViewersRole: true, IngestorsRole: true, MonitorsRole: true}
+	tableRoles    = map[Role]bool{AdminsRole: true, IngestorsRole: true}
+)
+
+// PrincipalScope is the database or the table whose principals are managed.
+type PrincipalScope struct {
+	table bool
+	name  string
+}
+
+// DatabaseScope returns the scope of the database with the given name.
+func DatabaseScope(name string) PrincipalScope {
+	return PrincipalScope{name: name}
+}
+
+// TableScope returns the scope of the table with the given name.
+func TableScope(name string) PrincipalScope {
+	return PrincipalScope{table: true, name: name}
+}
+
+// PrincipalInfo describes a principal and its role, as returned by `.show database principals`.
+type PrincipalInfo struct {
+	// Role is the role as displayed by Kusto, such as "Database db Admin".
+	Role string `kusto:"Role"`
+	// Type is the type as displayed by Kusto, such as "AAD Application".
+	Type        string `kusto:"PrincipalType"`
+	DisplayName string `kusto:"PrincipalDisplayName"`
+	ObjectID    string `kusto:"PrincipalObjectId"`
+	// FQN is the fully qualified name of the principal, which ParsePrincipal parses.
+	FQN   string `kusto:"PrincipalFQN"`
+	Notes string `kusto:"Notes"`
+}
+
+// AddPrincipals grants the role on the scope to the principals. notes is optional, and is shown with the principals.
+func AddPrincipals(ctx context.Context, client Client, db string, scope PrincipalScope, role Role, principals []Principal, notes string) error {
+	stmt, err := AddPrincipalsStatement(scope, role, principals, notes)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// DropPrincipals revokes the role on the scope from the principals.
+func DropPrincipals(ctx context.Context, client Client, db string, scope PrincipalScope, role Role, principals []Principal) error {
+	stmt, err := DropPrincipalsStatement(scope, role, principals)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// ShowPrincipals returns the principals of the scope, with their roles.
+func ShowPrincipals(ctx context.Context, client Client, db string, scope PrincipalScope) ([]PrincipalInfo, error) {
+	stmt, err := ShowPrincipalsStatement(scope)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[PrincipalInfo](ds)
+}
+
+// AddPrincipalsStatement builds an `.add database|table <role>` command.
+func AddPrincipalsStatement(scope PrincipalScope, role Role, principals []Principal, notes string) (azkustodata.Statement, error) {
+	stmt, err := principalsStatement(kql.New(".add "), scope, role, principals)
+	if err != nil {
+		return nil, err
+	}
+	if notes != "" {
+		stmt.AddLiteral(" ").AddString(notes)
+	}
+	return stmt, nil
+}
+
+// DropPrincipalsStatement builds a `.drop database|table <role>` command.
+func DropPrincipalsStatement(scope PrincipalScope, role Role, principals []Principal) (azkustodata.Statement, error) {
+	return principalsStatement(kql.New(".drop "), scope, role, principals)
+}
+
+// ShowPrincipalsStatement builds a `.show database|table principals` command.
+func ShowPrincipalsStatement(scope PrincipalScope) (azkustodata.Statement, error) {
+	stmt, err := scopeStatement(kql.New(".show "), scope)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.AddLiteral(" principals"), nil
+}
+
+// principalsStatement completes stmt, which holds the verb of a command, with the scope, the role and the list of principals.
+func principalsStatement(stmt *kql.Builder, scope PrincipalScope, role Role, principals []Principal) (*kql.Builder, error) {
+	stmt, err := scopeStatement(stmt, scope)
+	if err != nil {
+		return nil, err
+	}
+	if scope.table && !tableRoles[role] || !scope.table && !databaseRoles[role] {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "role %q can't be granted on a %s", role, scope.kind()).SetNoRetry()
+	}
+	if len(principals) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "no principals given").SetNoRetry()
+	}
+
+	stmt.AddLiteral(" ").AddKeyword(string(role)).AddLiteral(" (")
+	for i, p := range principals {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			stmt.AddLiteral(", ")
+		}
+		stmt.AddString(p.String())
+	}
+	return stmt.AddLiteral(")"), nil
+}
+
+// scopeStatement completes stmt with the kind and the escaped name of the scope.
+func scopeStatement(stmt *kql.Builder, scope PrincipalScope) (*kql.Builder, error) {
+	if scope.name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "%s name must not be empty", scope.kind()).SetNoRetry()
+	}
+	return stmt.AddKeyword(scope.kind()).AddLiteral(" ").AddTable(scope.name), nil
+}
+
+func (s PrincipalScope) kind() string {
+	if s.table {
+		return "table"
+	}
+	return "database"
+}
@@ -0,0 +1,152 @@
+package management
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showPrincipalsResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"Role","DataType":"String","ColumnType":"string"},
+{"ColumnName":"PrincipalType","DataType":"String","ColumnType":"string"},
+{"ColumnName":"PrincipalDisplayName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"PrincipalObjectId","DataType":"String","ColumnType":"string"},
+{"ColumnName":"PrincipalFQN","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Notes","DataType":"String","ColumnType":"string"}],
+"Rows":[["Database db Viewer","AAD Application","ingest-app","5a4b","aadapp=5a4b;contoso.com","reporting"]]}]}`
+
+func TestParsePrincipal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fqn  string
+		want Principal
+		err  bool
+	}{
+		{fqn: "aadapp=5a4b;contoso.com", want: AppPrincipal("5a4b", "contoso.com")},
+		{fqn: "AADUser=jane@contoso.com", want: UserPrincipal("jane@contoso.com", "")},
+		{fqn: "aadgroup=admins;72f9", want: GroupPrincipal("admins", "72f9")},
+		{fqn: "msauser=jane@outlook.com", err: true},
+		{fqn: "aaduser=", err: true},
+		{fqn: "jane@contoso.com", err: true},
+		{fqn: "aaduser=jane;contoso;com", err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.fqn, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParsePrincipal(test.fqn)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestPrincipalsStatements(t *testing.T) {
+	t.Parallel()
+
+	app := AppPrincipal("5a4b", "contoso.com")
+	user := UserPrincipal(`jane"doe@contoso.com`, "")
+
+	tests := []struct {
+		desc       string
+		drop       bool
+		scope      PrincipalScope
+		role       Role
+		principals []Principal
+		notes      string
+		want       string
+		err        bool
+	}{
+		{
+			desc:       "database",
+			scope:      DatabaseScope("db"),
+			role:       ViewersRole,
+			principals: []Principal{app, user},
+			notes:      "reporting",
+			want:       `.add database db viewers ("aadapp=5a4b;contoso.com", "aaduser=jane\"doe@contoso.com") "reporting"`,
+		},
+		{
+			desc:       "table",
+			scope:      TableScope("My T"),
+			role:       IngestorsRole,
+			principals: []Principal{app},
+			want:       `.add table ["My T"] ingestors ("aadapp=5a4b;contoso.com")`,
+		},
+		{
+			desc:       "drop",
+			drop:       true,
+			scope:      DatabaseScope("my-db"),
+			role:       UnrestrictedViewersRole,
+			principals: []Principal{app},
+			want:       `.drop database ["my-db"] unrestrictedviewers ("aadapp=5a4b;contoso.com")`,
+		},
+		{desc: "table role", scope: TableScope("T"), role: ViewersRole, principals: []Principal{app}, err: true},
+		{desc: "unknown role", scope: DatabaseScope("db"), role: "owners", principals: []Principal{app}, err: true},
+		{desc: "no principals", scope: DatabaseScope("db"), role: AdminsRole, err: true},
+		{desc: "no name", scope: DatabaseScope(""), role: AdminsRole, principals: []Principal{app}, err: true},
+		{desc: "bad principal", scope: DatabaseScope("db"), role: AdminsRole, principals: []Principal{{Type: "msauser", ID: "a"}}, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var stmt azkustodata.Statement
+			var err error
+			if test.drop {
+				stmt, err = DropPrincipalsStatement(test.scope, test.role, test.principals)
+			} else {
+				stmt, err = AddPrincipalsStatement(test.scope, test.role, test.principals, test.notes)
+			}
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+}
+
+func TestPrincipals(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(showPrincipalsResponse)
+	app := AppPrincipal("5a4b", "contoso.com")
+
+	require.NoError(t, AddPrincipals(ctx, client, "db", DatabaseScope("db"), ViewersRole, []Principal{app}, "reporting"))
+	require.NoError(t, DropPrincipals(ctx, client, "db", TableScope("T"), AdminsRole, []Principal{app}))
+
+	principals, err := ShowPrincipals(ctx, client, "db", DatabaseScope("db"))
+	require.NoError(t, err)
+	assert.Equal(t, []PrincipalInfo{{
+		Role:        "Database db Viewer",
+		Type:        "AAD Application",
+		DisplayName: "ingest-app",
+		ObjectID:    "5a4b",
+		FQN:         "aadapp=5a4b;contoso.com",
+		Notes:       "reporting",
+	}}, principals)
+
+	p, err := ParsePrincipal(principals[0].FQN)
+	require.NoError(t, err)
+	assert.Equal(t, app, p)
+
+	assert.Equal(t, []string{
+		`.add database db viewers ("aadapp=5a4b;contoso.com") "reporting"`,
+		`.drop table T admins ("aadapp=5a4b;contoso.com")`,
+		".show database db principals",
+	}, client.commands)
+}
@@ -0,0 +1,137 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/google/uuid"
+)
+
+// PurgePlan is the result of the first step of a purge, returned by PlanPurge.
+type PurgePlan struct {
+	NumRecordsToPurge           int64         `kusto:"NumRecordsToPurge"`
+	EstimatedPurgeExecutionTime time.Duration `kusto:"EstimatedPurgeExecutionTime"`
+	// VerificationToken confirms the purge in its second step, see Purge.
+	VerificationToken string `kusto:"VerificationToken"`
+}
+
+// PurgeOperation describes a purge, as returned by `.show purges`.
+type PurgeOperation struct {
+	ID            string        `kusto:"OperationId"`
+	Database      string        `kusto:"DatabaseName"`
+	Table         string        `kusto:"TableName"`
+	ScheduledTime time.Time     `kusto:"ScheduledTime"`
+	Duration      time.Duration `kusto:"Duration"`
+	LastUpdatedOn time.Time     `kusto:"LastUpdatedOn"`
+	// EngineOperationID is the ID of the operation that purges the data in the engine, see ShowOperation.
+	EngineOperationID string         `kusto:"EngineOperationId"`
+	State             OperationState `kusto:"State"`
+	StateDetails      string         `kusto:"StateDetails"`
+	EngineStartTime   time.Time      `kusto:"EngineStartTime"`
+	EngineDuration    time.Duration  `kusto:"EngineDuration"`
+	Retries           int32          `kusto:"Retries"`
+	ClientRequestID   string         `kusto:"ClientRequestId"`
+	Principal         string         `kusto:"Principal"`
+}
+
+// Err returns nil if the purge completed successfully, or an error describing its state otherwise.
+func (p PurgeOperation) Err() error {
+	if p.State.IsSuccess() {
+		return nil
+	}
+	if !p.State.IsFinal() {
+		return errors.ES(errors.OpMgmt, errors.KOther, "purge %s of table %s is still in state %s", p.ID, p.Table, p.State)
+	}
+	return errors.ES(errors.OpMgmt, errors.KOther, "purge %s of table %s ended in state %s: %s", p.ID, p.Table, p.State, p.StateDetails)
+}
+
+// PlanPurge runs the first step of the purge of the records of the table matching predicate, such as
+// `kql.New("where UserId == ").AddString(id)`. It deletes nothing, and returns the number of records that would be purged, with
+// the token that confirms the purge in Purge.
+//
+// Purges are run by the data management service, so client must be connected to the ingestion endpoint of the cluster
+// ("https://ingest-<cluster>"). Purged data can't be recovered.
+func PlanPurge(ctx context.Context, client Client, db string, table string, predicate azkustodata.Statement) (PurgePlan, error) {
+	stmt, err := PurgeStatement(db, table, predicate, "")
+	if err != nil {
+		return PurgePlan{}, err
+	}
+	return showOne[PurgePlan](ctx, client, db, stmt, "purge plan of table", table)
+}
+
+// Purge runs the second step of the purge planned by PlanPurge, which must be called with the same arguments, and returns the
+// ID of the purge operation. The data is deleted asynchronously; use WaitForPurge to wait until it is.
+func Purge(ctx context.Context, client Client, db string, table string, predicate azkustodata.Statement, plan PurgePlan) (string, error) {
+	if plan.VerificationToken == "" {
+		return "", errors.ES(errors.OpMgmt, errors.KClientArgs, "the purge plan has no verification token").SetNoRetry()
+	}
+	stmt, err := PurgeStatement(db, table, predicate, plan.VerificationToken)
+	if err != nil {
+		return "", err
+	}
+	op, err := showOne[PurgeOperation](ctx, client, db, stmt, "purge operation of table", table)
+	if err != nil {
+		return "", err
+	}
+	return op.ID, nil
+}
+
+// ShowPurge returns the purge operation with the given ID. client must be connected to the ingestion endpoint, see PlanPurge.
+func ShowPurge(ctx context.Context, client Client, db string, operationID string) (PurgeOperation, error) {
+	stmt, err := ShowPurgeStatement(operationID)
+	if err != nil {
+		return PurgeOperation{}, err
+	}
+	return showOne[PurgeOperation](ctx, client, db, stmt, "purge operation", operationID)
+}
+
+// WaitForPurge polls the purge operation with the given ID until it ends, like WaitForCompletion, and returns its final state.
+// If the purge didn't complete successfully, the error of PurgeOperation.Err is returned with it.
+func WaitForPurge(ctx context.Context, client Client, db string, operationID string, options ...WaitOption) (PurgeOperation, error) {
+	if _, err := ShowPurgeStatement(operationID); err != nil {
+		return PurgeOperation{}, err
+	}
+	var op PurgeOperation
+	err := poll(ctx, options, func() (bool, error) {
+		var err error
+		op, err = ShowPurge(ctx, client, db, operationID)
+		return err == nil && op.State.IsFinal(), err
+	})
+	if err != nil {
+		return PurgeOperation{}, err
+	}
+	return op, op.Err()
+}
+
+// PurgeStatement builds a `.purge table records` command. Without a verificationToken, it is the first step of the purge, which
+// only returns its plan.
+func PurgeStatement(db string, table string, predicate azkustodata.Statement, verificationToken string) (azkustodata.Statement, error) {
+	if db == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "database name must not be empty").SetNoRetry()
+	}
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	if predicate == nil || predicate.String() == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "purge predicate must not be empty").SetNoRetry()
+	}
+
+	stmt := kql.New(".purge table ").AddTable(table).AddLiteral(" records in database ").AddTable(db)
+	if verificationToken != "" {
+		stmt.AddLiteral(" with (verificationtoken=h").AddString(verificationToken).AddLiteral(")")
+	}
+	return stmt.AddLiteral(" <| ").AddUnsafe(predicate.String()), nil
+}
+
+// ShowPurgeStatement builds a `.show purges <id>` command.
+func ShowPurgeStatement(operationID string) (azkustodata.Statement, error) {
+	id, err := uuid.Parse(operationID)
+	if err != nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "operation ID %q is not a GUID", operationID).SetNoRetry()
+	}
+	// The ID was parsed as a GUID, so it is safe to write as is.
+	return kql.New(".show purges ").AddUnsafe(id.String()), nil
+}
@@ -0,0 +1,148 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const purgePlanResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"NumRecordsToPurge","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"EstimatedPurgeExecutionTime","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"VerificationToken","DataType":"String","ColumnType":"string"}],
+"Rows":[[1596,"00:00:02","e43c7184ed22f4f23c7a9d7b124d196be2e570096987e5baadf65057fa65736b"]]}]}`
+
+func purgeResponse(state string) string {
+	return fmt.Sprintf(`{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"OperationId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ScheduledTime","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Duration","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"LastUpdatedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"EngineOperationId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"State","DataType":"String","ColumnType":"string"},
+{"ColumnName":"StateDetails","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EngineStartTime","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"EngineDuration","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"Retries","DataType":"Int32","ColumnType":"int"},
+{"ColumnName":"ClientRequestId","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Principal","DataType":"String","ColumnType":"string"}],
+"Rows":[["%s","db","Users","2024-01-01T00:00:00Z","00:05:00","2024-01-01T00:05:00Z",null,"%s","","2024-01-01T00:01:00Z",null,0,"KE.RunCommand;1","aadapp=5a4b"]]}]}`, operationID, state)
+}
+
+func TestPurgeStatement(t *testing.T) {
+	t.Parallel()
+
+	predicate := kql.New("where UserId == ").AddString(`o"brien`)
+
+	tests := []struct {
+		desc      string
+		db        string
+		table     string
+		predicate *kql.Builder
+		token     string
+		want      string
+		err       bool
+	}{
+		{
+			desc:      "plan",
+			db:        "db",
+			table:     "Users",
+			predicate: predicate,
+			want:      `.purge table Users records in database db <| where UserId == "o\"brien"`,
+		},
+		{
+			desc:      "confirm",
+			db:        "my-db",
+			table:     "My Users",
+			predicate: predicate,
+			token:     "e43c",
+			want:      `.purge table ["My Users"] records in database ["my-db"] with (verificationtoken=h"e43c") <| where UserId == "o\"brien"`,
+		},
+		{desc: "no database", table: "Users", predicate: predicate, err: true},
+		{desc: "no table", db: "db", predicate: predicate, err: true},
+		{desc: "no predicate", db: "db", table: "Users", predicate: kql.New(""), err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := PurgeStatement(test.db, test.table, test.predicate, test.token)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	polls := 0
+	client := newFakeClient("")
+	client.response = func(cmd string) string {
+		switch {
+		case strings.Contains(cmd, "verificationtoken"):
+			return purgeResponse("Scheduled")
+		case strings.HasPrefix(cmd, ".purge"):
+			return purgePlanResponse
+		}
+		polls++
+		if polls < 3 {
+			return purgeResponse("InProgress")
+		}
+		return purgeResponse("Completed")
+	}
+	predicate := kql.New("where UserId == ").AddString("u1")
+
+	plan, err := PlanPurge(ctx, client, "db", "Users", predicate)
+	require.NoError(t, err)
+	assert.Equal(t, PurgePlan{
+		NumRecordsToPurge:           1596,
+		EstimatedPurgeExecutionTime: 2 * time.Second,
+		VerificationToken:           "e43c7184ed22f4f23c7a9d7b124d196be2e570096987e5baadf65057fa65736b",
+	}, plan)
+
+	_, err = Purge(ctx, client, "db", "Users", predicate, PurgePlan{})
+	assert.Error(t, err)
+
+	id, err := Purge(ctx, client, "db", "Users", predicate, plan)
+	require.NoError(t, err)
+	assert.Equal(t, operationID, id)
+
+	op, err := WaitForPurge(ctx, client, "db", id, WithPollInterval(time.Millisecond), WithMaxPollInterval(2*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, OperationCompleted, op.State)
+	assert.Equal(t, "Users", op.Table)
+	assert.Equal(t, 5*time.Minute, op.Duration)
+	assert.Equal(t, 3, polls)
+
+	assert.Equal(t, []string{
+		`.purge table Users records in database db <| where UserId == "u1"`,
+		`.purge table Users records in database db with (verificationtoken=h"e43c7184ed22f4f23c7a9d7b124d196be2e570096987e5baadf65057fa65736b") <| where UserId == "u1"`,
+		".show purges " + operationID,
+		".show purges " + operationID,
+		".show purges " + operationID,
+	}, client.commands)
+
+	client.response = func(string) string { return purgeResponse("BadInput") }
+	op, err = WaitForPurge(ctx, client, "db", id, WithPollInterval(time.Millisecond))
+	assert.ErrorContains(t, err, "ended in state BadInput")
+	assert.Equal(t, OperationBadInput, op.State)
+
+	_, err = ShowPurge(ctx, client, "db", "not-a-guid")
+	assert.Error(t, err)
+}
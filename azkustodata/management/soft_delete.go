@@ -0,0 +1,62 @@
+package management
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// SoftDeletedExtent is an extent whose records were deleted by SoftDelete, or would be by PlanSoftDelete.
+type SoftDeletedExtent struct {
+	Table               string `kusto:"TableName"`
+	Database            string `kusto:"DatabaseName"`
+	ExtentID            string `kusto:"ExtentId"`
+	OriginalRecordCount int64  `kusto:"OriginalRecordCount"`
+	DeletedRecordCount  int64  `kusto:"DeletedRecordCount"`
+}
+
+// PlanSoftDelete returns the extents of the table with records matching predicate, such as
+// `kql.New("where JobId == ").AddString(id)`, and how many of their records SoftDelete would delete. It deletes nothing.
+func PlanSoftDelete(ctx context.Context, client Client, db string, table string, predicate azkustodata.Statement) ([]SoftDeletedExtent, error) {
+	return softDelete(ctx, client, db, table, predicate, true)
+}
+
+// SoftDelete deletes the records of the table matching predicate, such as `kql.New("where JobId == ").AddString(id)`, and returns
+// the extents they were deleted from. Unlike Purge, the records are only hidden from queries, and removed from storage when their
+// extents are merged or expire. It is meant for cleanups and corrections, rather than for compliance.
+func SoftDelete(ctx context.Context, client Client, db string, table string, predicate azkustodata.Statement) ([]SoftDeletedExtent, error) {
+	return softDelete(ctx, client, db, table, predicate, false)
+}
+
+// SoftDeleteStatement builds the `.delete table records` command used by SoftDelete. With whatIf, it is the command used by
+// PlanSoftDelete.
+func SoftDeleteStatement(table string, predicate azkustodata.Statement, whatIf bool) (azkustodata.Statement, error) {
+	stmt, err := tableStatement(kql.New(".delete table "), table)
+	if err != nil {
+		return nil, err
+	}
+	if predicate == nil || predicate.String() == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "soft delete predicate must not be empty").SetNoRetry()
+	}
+
+	stmt.AddLiteral(" records")
+	if whatIf {
+		stmt.AddLiteral(" with (whatif=true)")
+	}
+	return stmt.AddLiteral(" <| ").AddTable(table).AddLiteral(" | ").AddUnsafe(predicate.String()), nil
+}
+
+func softDelete(ctx context.Context, client Client, db string, table string, predicate azkustodata.Statement, whatIf bool) ([]SoftDeletedExtent, error) {
+	stmt, err := SoftDeleteStatement(table, predicate, whatIf)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[SoftDeletedExtent](ds)
+}
@@ -0,0 +1,68 @@
+package management
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const softDeleteResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ExtentId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"OriginalRecordCount","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"DeletedRecordCount","DataType":"Int64","ColumnType":"long"}],
+"Rows":[["Logs","db","6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d",1000,10]]}]}`
+
+func TestSoftDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(softDeleteResponse)
+	predicate := kql.New("where JobId == ").AddString("job-1")
+
+	plan, err := PlanSoftDelete(ctx, client, "db", "Logs", predicate)
+	require.NoError(t, err)
+	assert.Equal(t, []SoftDeletedExtent{{
+		Table:               "Logs",
+		Database:            "db",
+		ExtentID:            "6c1e1c4a-1b5f-4c1e-9d3a-2f6b7e8a9c0d",
+		OriginalRecordCount: 1000,
+		DeletedRecordCount:  10,
+	}}, plan)
+
+	_, err = SoftDelete(ctx, client, "db", "My Logs", predicate)
+	require.NoError(t, err)
+
+	_, err = SoftDelete(ctx, client, "db", "", predicate)
+	assert.Error(t, err)
+	_, err = SoftDelete(ctx, client, "db", "Logs", kql.New(""))
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		`.delete table Logs records with (whatif=true) <| Logs | where JobId == "job-1"`,
+		`.delete table ["My Logs"] records <| ["My Logs"] | where JobId == "job-1"`,
+	}, client.commands)
+}
+
+func TestCrossDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(softDeleteResponse)
+	predicate := kql.New("where UserId in (").AddQualifiedTable("Users DB", "Deleted Users").AddLiteral(" | project UserId)")
+
+	for _, db := range []string{"Logs EU", "Logs US"} {
+		_, err := SoftDelete(ctx, client, db, "Logs", predicate)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"Logs EU", "Logs US"}, client.dbs)
+	assert.Equal(t, []string{
+		`.delete table Logs records <| Logs | where UserId in (database("Users DB").["Deleted Users"] | project UserId)`,
+		`.delete table Logs records <| Logs | where UserId in (database("Users DB").["Deleted Users"] | project UserId)`,
+	}, client.commands)
+}
@@ -0,0 +1,223 @@
+package management
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// tableOptions holds the options of CreateTableFromStruct and CreateTable.
+type tableOptions struct {
+	folder      string
+	docString   string
+	mappings    []mappingRequest
+	ifNotExists bool
+}
+
+type mappingRequest struct {
+	kind MappingKind
+	name string
+}
+
+// TableOption is an optional argument to CreateTableFromStruct and CreateTable.
+type TableOption func(o *tableOptions)
+
+// WithFolder sets the folder of the table.
+func WithFolder(folder string) TableOption {
+	return func(o *tableOptions) {
+		o.folder = folder
+	}
+}
+
+// WithDocString sets the docstring of the table.
+func WithDocString(docString string) TableOption {
+	return func(o *tableOptions) {
+		o.docString = docString
+	}
+}
+
+// WithMapping also creates, or alters, an ingestion mapping of the given kind and name for the struct. See MappingFromStruct.
+// It can be given more than once, to create mappings of different kinds.
+func WithMapping(kind MappingKind, name string) TableOption {
+	return func(o *tableOptions) {
+		o.mappings = append(o.mappings, mappingRequest{kind: kind, name: name})
+	}
+}
+
+// TableIfNotExists leaves the table as it is if the database already has it: the table, and its mappings, are only created if it
+// doesn't exist. Without it, the columns the table lacks are added to it, and the mappings are altered.
+func TableIfNotExists() TableOption {
+	return func(o *tableOptions) {
+		o.ifNotExists = true
+	}
+}
+
+// CreateTableFromStruct creates the table in the database db with a column for each exported field of T, using `.create-merge table`.
+// Columns that already exist are kept, so it can be called every time an application starts.
+//
+// T must be a struct. The fields are mapped to columns with the same `kusto` tags as Row.ToStruct: the tag holds the column name,
+// a field without a tag uses its field name, and fields tagged with "-" are skipped. The type of each column is inferred by value.ColumnTypeOf.
+func CreateTableFromStruct[T any](ctx context.Context, client Client, db string, table string, options ...TableOption) error {
+	cols, err := TableColumnsFromStruct[T]()
+	if err != nil {
+		return err
+	}
+	return createTable(ctx, client, db, table, cols, MappingFromStruct[T], options)
+}
+
+// CreateTable creates the table in the database db with the columns, using `.create-merge table`, as CreateTableFromStruct does
+// for the fields of a struct. The mappings given with WithMapping are built with MappingFromColumns.
+func CreateTable(ctx context.Context, client Client, db string, table string, cols query.Columns, options ...TableOption) error {
+	return createTable(ctx, client, db, table, cols, func(kind MappingKind) (Mapping, error) {
+		return MappingFromColumns(cols, kind)
+	}, options)
+}
+
+// createTable creates the table with the columns, and the mappings of the options, built by mappingOf.
+func createTable(ctx context.Context, client Client, db string, table string, cols query.Columns, mappingOf func(kind MappingKind) (Mapping, error), options []TableOption) error {
+	opts := tableOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	create, err := CreateMergeTableStatement(table, cols, opts.folder, opts.docString)
+	if err != nil {
+		return err
+	}
+
+	stmts := []azkustodata.Statement{create}
+	for _, m := range opts.mappings {
+		mapping, err := mappingOf(m.kind)
+		if err != nil {
+			return err
+		}
+		stmt, err := CreateOrAlterMappingStatement(table, m.kind, m.name, mapping)
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	if opts.ifNotExists {
+		exists, err := tableExists(ctx, client, db, table)
+		if err != nil || exists {
+			return err
+		}
+	}
+	return run(ctx, client, db, stmts...)
+}
+
+// TableColumnsFromStruct returns the columns CreateTableFromStruct creates for T.
+func TableColumnsFromStruct[T any]() (query.Columns, error) {
+	fields, err := structFields(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make(query.Columns, len(fields))
+	for i, f := range fields {
+		cols[i] = query.NewColumn(i, f.column, f.kustoType)
+	}
+	return cols, nil
+}
+
+// CreateMergeTableStatement builds a `.create-merge table` command for the columns. folder and docString are optional.
+func CreateMergeTableStatement(table string, cols query.Columns, folder string, docString string) (azkustodata.Statement, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	if len(cols) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table %s must have at least one column", table).SetNoRetry()
+	}
+
+	stmt := kql.New(".create-merge table ").AddTable(table).AddLiteral(" ")
+	if err := addColumnList(stmt, cols); err != nil {
+		return nil, err
+	}
+
+	if folder != "" || docString != "" {
+		stmt.AddLiteral(" with (")
+		if folder != "" {
+			stmt.AddLiteral("folder=").AddString(folder)
+		}
+		if docString != "" {
+			if folder != "" {
+				stmt.AddLiteral(", ")
+			}
+			stmt.AddLiteral("docstring=").AddString(docString)
+		}
+		stmt.AddLiteral(")")
+	}
+	return stmt, nil
+}
+
+// addColumnList writes the `(name:type, ...)` schema of cols.
+func addColumnList(stmt *kql.Builder, cols query.Columns) error {
+	stmt.AddLiteral("(")
+	for i, c := range cols {
+		if i > 0 {
+			stmt.AddLiteral(", ")
+		}
+		if value.Default(c.Type()) == nil {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "column %s has an unknown type %q", c.Name(), c.Type()).SetNoRetry()
+		}
+		stmt.AddColumn(c.Name()).AddLiteral(":").AddKeyword(string(c.Type()))
+	}
+	stmt.AddLiteral(")")
+	return nil
+}
+
+// structField is an exported field of a struct that maps to a column.
+type structField struct {
+	column    string
+	jsonName  string
+	kustoType types.Column
+	// path and transform are set by the `kustomapping` tag of the field.
+	path      string
+	transform string
+}
+
+// structFields returns the fields of the struct type t that map to columns, in order.
+func structFields(t reflect.Type) ([]structField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "expected a struct, got %s", t).SetNoRetry()
+	}
+
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag := strings.TrimSpace(field.Tag.Get("kusto")); tag != "" {
+			name = tag
+		}
+		if name == "-" {
+			continue
+		}
+
+		kustoType, ok := value.ColumnTypeOf(field.Type)
+		if !ok {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "cannot infer the Kusto type of field %s of type %s", field.Name, field.Type).SetNoRetry()
+		}
+
+		jsonName := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" {
+			jsonName = tag
+		}
+		f := structField{column: name, jsonName: jsonName, kustoType: kustoType}
+		if err := f.parseMappingTag(field); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
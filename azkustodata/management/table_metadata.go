@@ -0,0 +1,106 @@
+package management
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// AlterTableDocString sets the docstring of the table. An empty docString clears it.
+func AlterTableDocString(ctx context.Context, client Client, db string, table string, docString string) error {
+	stmt, err := tableStatement(kql.New(".alter table "), table)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt.AddLiteral(" docstring ").AddString(docString))
+}
+
+// AlterTableFolder moves the table to folder. An empty folder moves it to the root.
+func AlterTableFolder(ctx context.Context, client Client, db string, table string, folder string) error {
+	stmt, err := tableStatement(kql.New(".alter table "), table)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt.AddLiteral(" folder ").AddString(folder))
+}
+
+// AlterColumnDocStrings sets the docstrings of the columns of the table, by column name. The docstrings of the other columns are
+// kept.
+func AlterColumnDocStrings(ctx context.Context, client Client, db string, table string, docStrings map[string]string) error {
+	stmt, err := AlterColumnDocStringsStatement(table, docStrings)
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, db, stmt)
+}
+
+// ShowTableMetadata returns the folder and docstring of the table, and its columns with their docstrings.
+func ShowTableMetadata(ctx context.Context, client Client, db string, table string) (azkustodata.TableSchemaInfo, error) {
+	stmt, err := azkustodata.TableSchemaStatement(table)
+	if err != nil {
+		return azkustodata.TableSchemaInfo{}, err
+	}
+	row, err := showOne[tableSchemaRow](ctx, client, db, stmt, "table", table)
+	if err != nil {
+		return azkustodata.TableSchemaInfo{}, err
+	}
+	cols, err := query.ColumnsFromSchemaJSON(row.Schema, row.Folder)
+	if err != nil {
+		return azkustodata.TableSchemaInfo{}, err
+	}
+	return azkustodata.TableSchemaInfo{
+		TableInfo: azkustodata.TableInfo{Name: row.Name, Database: row.Database, Folder: row.Folder, DocString: row.DocString},
+		Columns:   cols,
+	}, nil
+}
+
+// AlterColumnDocStringsStatement builds the `.alter-merge table column-docstrings` command used by AlterColumnDocStrings. The
+// columns are sorted by name, so the command is the same for the same docstrings.
+func AlterColumnDocStringsStatement(table string, docStrings map[string]string) (azkustodata.Statement, error) {
+	stmt, err := tableStatement(kql.New(".alter-merge table "), table)
+	if err != nil {
+		return nil, err
+	}
+	if len(docStrings) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "at least one column docstring must be given").SetNoRetry()
+	}
+
+	names := make([]string, 0, len(docStrings))
+	for name := range docStrings {
+		if name == "" {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "column name must not be empty").SetNoRetry()
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stmt.AddLiteral(" column-docstrings (")
+	for i, name := range names {
+		if i > 0 {
+			stmt.AddLiteral(", ")
+		}
+		stmt.AddColumn(name).AddLiteral(":").AddString(docStrings[name])
+	}
+	return stmt.AddLiteral(")"), nil
+}
+
+// tableSchemaRow is a row of `.show table T schema as json`.
+type tableSchemaRow struct {
+	Name      string `kusto:"TableName"`
+	Database  string `kusto:"DatabaseName"`
+	Folder    string `kusto:"Folder"`
+	DocString string `kusto:"DocString"`
+	Schema    string `kusto:"Schema"`
+}
+
+// tableStatement completes stmt with the escaped table name.
+func tableStatement(stmt *kql.Builder, table string) (*kql.Builder, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	return stmt.AddTable(table), nil
+}
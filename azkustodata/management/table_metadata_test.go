@@ -0,0 +1,93 @@
+package management
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const showTableSchemaResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Schema","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Folder","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DocString","DataType":"String","ColumnType":"string"}],
+"Rows":[["Logs","{\"Name\":\"Logs\",\"OrderedColumns\":[{\"Name\":\"Timestamp\",\"Type\":\"System.DateTime\",\"CslType\":\"datetime\"},{\"Name\":\"Message\",\"Type\":\"System.String\",\"CslType\":\"string\",\"DocString\":\"The message\"}]}","db","Raw","Raw logs"]]}]}`
+
+func TestAlterColumnDocStringsStatement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		table      string
+		docStrings map[string]string
+		want       string
+		err        bool
+	}{
+		{
+			desc:       "sorted",
+			table:      "Logs",
+			docStrings: map[string]string{"Timestamp": "When it happened", "Message": "The \"message\""},
+			want:       `.alter-merge table Logs column-docstrings (Message:"The \"message\"", Timestamp:"When it happened")`,
+		},
+		{
+			desc:       "escaped",
+			table:      "My Logs",
+			docStrings: map[string]string{"My Column": ""},
+			want:       `.alter-merge table ["My Logs"] column-docstrings (["My Column"]:"")`,
+		},
+		{desc: "no table", docStrings: map[string]string{"a": "b"}, err: true},
+		{desc: "no columns", table: "Logs", err: true},
+		{desc: "empty column", table: "Logs", docStrings: map[string]string{"": "b"}, err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := AlterColumnDocStringsStatement(test.table, test.docStrings)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+}
+
+func TestTableMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newFakeClient(showTableSchemaResponse)
+
+	require.NoError(t, AlterTableDocString(ctx, client, "db", "Logs", "Raw logs"))
+	require.NoError(t, AlterTableFolder(ctx, client, "db", "My Logs", `Raw\Logs`))
+	require.NoError(t, AlterColumnDocStrings(ctx, client, "db", "Logs", map[string]string{"Message": "The message"}))
+	assert.Error(t, AlterTableFolder(ctx, client, "db", "", "Raw"))
+
+	info, err := ShowTableMetadata(ctx, client, "db", "Logs")
+	require.NoError(t, err)
+	assert.Equal(t, "Logs", info.Name)
+	assert.Equal(t, "Raw", info.Folder)
+	assert.Equal(t, "Raw logs", info.DocString)
+	require.Len(t, info.Columns, 2)
+	assert.Equal(t, types.DateTime, info.Columns[0].Type())
+	assert.Equal(t, "Message", info.Columns[1].Name())
+	assert.Equal(t, "The message", info.Columns[1].DocString())
+
+	_, err = ShowTableMetadata(ctx, newFakeClient(emptyResponse), "db", "Missing")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		`.alter table Logs docstring "Raw logs"`,
+		`.alter table ["My Logs"] folder "Raw\\Logs"`,
+		`.alter-merge table Logs column-docstrings (Message:"The message")`,
+		".show table Logs schema as json",
+	}, client.commands)
+}
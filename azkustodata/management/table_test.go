@@ -0,0 +1,144 @@
+package management
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type event struct {
+	Timestamp time.Time              `json:"ts"`
+	Name      string                 `kusto:"EventName" json:"name"`
+	Count     *int64                 `json:"count,omitempty"`
+	Props     map[string]interface{} `kusto:"Properties" json:"props"`
+	Took      value.Timespan         `json:"-"`
+	Internal  string                 `kusto:"-"`
+	hidden    int
+}
+
+func TestTableColumnsFromStruct(t *testing.T) {
+	t.Parallel()
+
+	cols, err := TableColumnsFromStruct[event]()
+	require.NoError(t, err)
+	require.Len(t, cols, 5)
+
+	names := make([]string, len(cols))
+	kinds := make([]types.Column, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name()
+		kinds[i] = c.Type()
+	}
+	assert.Equal(t, []string{"Timestamp", "EventName", "Count", "Properties", "Took"}, names)
+	assert.Equal(t, []types.Column{types.DateTime, types.String, types.Long, types.Dynamic, types.Timespan}, kinds)
+
+	_, err = TableColumnsFromStruct[int]()
+	assert.Error(t, err)
+	_, err = TableColumnsFromStruct[struct{ C chan int }]()
+	assert.Error(t, err)
+}
+
+func TestCreateMergeTableStatement(t *testing.T) {
+	t.Parallel()
+
+	cols, err := TableColumnsFromStruct[struct {
+		A     string
+		Other int32 `kusto:"my col"`
+	}]()
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc      string
+		table     string
+		folder    string
+		docString string
+		want      string
+		err       bool
+	}{
+		{desc: "plain", table: "T", want: `.create-merge table T (A:string, ["my col"]:int)`},
+		{desc: "folder", table: "T", folder: "Raw", want: `.create-merge table T (A:string, ["my col"]:int) with (folder="Raw")`},
+		{
+			desc: "folder and docstring", table: "My T", folder: "Raw", docString: `Say "hi"`,
+			want: `.create-merge table ["My T"] (A:string, ["my col"]:int) with (folder="Raw", docstring="Say \"hi\"")`,
+		},
+		{desc: "no table", err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := CreateMergeTableStatement(test.table, cols, test.folder, test.docString)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+
+	_, err = CreateMergeTableStatement("T", nil, "", "")
+	assert.Error(t, err)
+}
+
+func TestCreateTableFromStruct(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeClient(emptyResponse)
+	err := CreateTableFromStruct[event](context.Background(), client, "db", "Events",
+		WithFolder("App"), WithMapping(JSONMapping, "EventsJson"), WithMapping(CSVMapping, "EventsCsv"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"db", "db", "db"}, client.dbs)
+	require.Len(t, client.commands, 3)
+	assert.Equal(t, `.create-merge table Events (Timestamp:datetime, EventName:string, Count:long, Properties:dynamic, Took:timespan) with (folder="App")`, client.commands[0])
+	assert.Equal(t, `.create-or-alter table Events ingestion json mapping "EventsJson" "[`+
+		`{\"Column\":\"Timestamp\",\"DataType\":\"datetime\",\"Properties\":{\"Path\":\"$.ts\"}},`+
+		`{\"Column\":\"EventName\",\"DataType\":\"string\",\"Properties\":{\"Path\":\"$.name\"}},`+
+		`{\"Column\":\"Count\",\"DataType\":\"long\",\"Properties\":{\"Path\":\"$.count\"}},`+
+		`{\"Column\":\"Properties\",\"DataType\":\"dynamic\",\"Properties\":{\"Path\":\"$.props\"}}]"`, client.commands[1])
+	assert.Contains(t, client.commands[2], `.create-or-alter table Events ingestion csv mapping "EventsCsv" "[{\"Column\":\"Timestamp\",\"DataType\":\"datetime\",\"Properties\":{\"Ordinal\":\"0\"}}`)
+	assert.Contains(t, client.commands[2], `{\"Column\":\"Took\",\"DataType\":\"timespan\",\"Properties\":{\"Ordinal\":\"4\"}}]"`)
+
+	err = CreateTableFromStruct[event](context.Background(), client, "db", "Events", WithMapping("parquet", "P"))
+	assert.Error(t, err)
+	assert.Len(t, client.commands, 3)
+}
+
+func TestCreateTable(t *testing.T) {
+	t.Parallel()
+
+	cols := query.Columns{query.NewColumn(0, "Timestamp", types.DateTime), query.NewColumn(1, "Message", types.String)}
+
+	client := newFakeClient(emptyResponse)
+	require.NoError(t, CreateTable(context.Background(), client, "db", "Logs", cols, WithMapping(JSONMapping, "LogsJson"), TableIfNotExists()))
+	assert.Equal(t, []string{
+		`.show tables | where TableName == "Logs"`,
+		`.create-merge table Logs (Timestamp:datetime, Message:string)`,
+		`.create-or-alter table Logs ingestion json mapping "LogsJson" "[` +
+			`{\"Column\":\"Timestamp\",\"DataType\":\"datetime\",\"Properties\":{\"Path\":\"$.Timestamp\"}},` +
+			`{\"Column\":\"Message\",\"DataType\":\"string\",\"Properties\":{\"Path\":\"$.Message\"}}]"`,
+	}, client.commands)
+
+	// An existing table is left as it is.
+	client = newFakeClient(showTablesResponse)
+	require.NoError(t, CreateTable(context.Background(), client, "db", "Logs", cols, WithMapping(CSVMapping, "LogsCsv"), TableIfNotExists()))
+	assert.Equal(t, []string{`.show tables | where TableName == "Logs"`}, client.commands)
+
+	mapping, err := MappingFromColumns(cols, CSVMapping)
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{
+		{Column: "Timestamp", DataType: types.DateTime, Properties: map[string]string{PropertyOrdinal: "0"}},
+		{Column: "Message", DataType: types.String, Properties: map[string]string{PropertyOrdinal: "1"}},
+	}, mapping)
+	_, err = MappingFromColumns(cols, "avro")
+	assert.Error(t, err)
+}
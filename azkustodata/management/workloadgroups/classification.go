@@ -0,0 +1,84 @@
+package workloadgroups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// ClassificationPolicy is the request classification policy of the cluster. While it is enabled, ClassificationFunction is
+// called for every request, and returns the name of its workload group from the properties of the request in request_properties,
+// such as request_properties.current_principal. An unknown or empty name classifies the request in the default group.
+type ClassificationPolicy struct {
+	IsEnabled              bool
+	ClassificationFunction string `json:",omitempty"`
+}
+
+// ShowClassification returns the request classification policy of the cluster, or nil if it isn't set.
+func ShowClassification(ctx context.Context, client management.Client, db string) (*ClassificationPolicy, error) {
+	ds, err := client.Mgmt(ctx, db, kql.New(".show cluster policy request_classification"))
+	if err != nil {
+		return nil, err
+	}
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command returned no tables")
+	}
+	rows, err := query.ToStructs[struct {
+		Policy string `kusto:"Policy"`
+	}](tables[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	policy := bytes.TrimSpace([]byte(rows[0].Policy))
+	if len(policy) == 0 || bytes.Equal(policy, []byte("null")) {
+		return nil, nil
+	}
+	var p ClassificationPolicy
+	if err := json.Unmarshal(policy, &p); err != nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KFailedToParse, "request classification policy could not be parsed: %s", err)
+	}
+	return &p, nil
+}
+
+// AlterClassification sets the request classification policy of the cluster, with function as its classification function,
+// such as:
+//
+//	kql.New(`case(current_principal_is_member_of("aadgroup=tenants@contoso.com"), "Tenants", "default")`)
+func AlterClassification(ctx context.Context, client management.Client, db string, enabled bool, function azkustodata.Statement) error {
+	stmt, err := AlterClassificationStatement(enabled, function)
+	if err != nil {
+		return err
+	}
+	_, err = client.Mgmt(ctx, db, stmt)
+	return err
+}
+
+// DeleteClassification deletes the request classification policy of the cluster, so every request is in the default group.
+func DeleteClassification(ctx context.Context, client management.Client, db string) error {
+	_, err := client.Mgmt(ctx, db, kql.New(".delete cluster policy request_classification"))
+	return err
+}
+
+// AlterClassificationStatement builds the `.alter cluster policy request_classification` command used by AlterClassification.
+func AlterClassificationStatement(enabled bool, function azkustodata.Statement) (azkustodata.Statement, error) {
+	if function == nil || function.String() == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "classification function must not be empty").SetNoRetry()
+	}
+	b, err := json.Marshal(ClassificationPolicy{IsEnabled: enabled})
+	if err != nil {
+		return nil, errors.E(errors.OpMgmt, errors.KClientArgs, err).SetNoRetry()
+	}
+	return kql.New(".alter cluster policy request_classification ").AddString(string(b)).
+		AddLiteral(" <|\n").AddUnsafe(function.String()), nil
+}
@@ -0,0 +1,333 @@
+/*
+Package workloadgroups manages the workload groups of a Kusto cluster, and the request classification policy that assigns requests
+to them, so quotas can be enforced per tenant or per application:
+
+	group := workloadgroups.WorkloadGroup{
+		RequestRateLimitPolicies: []workloadgroups.RequestRateLimitPolicy{
+			workloadgroups.ConcurrentRequestsLimit(workloadgroups.PrincipalScope, 10),
+		},
+	}
+	err := workloadgroups.CreateOrAlter(ctx, client, "db", "Tenants", group)
+
+The commands apply to the whole cluster. They run in the context of db, which can be any database of the cluster.
+*/
+package workloadgroups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Default is the workload group of the requests that aren't classified into another group.
+const Default = "default"
+
+// Timespan is a time.Duration that is written in the Kusto format in the JSON of a workload group.
+type Timespan time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (t Timespan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.TimespanString(time.Duration(t)))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Timespan) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	d, err := value.ParseTimespan(s)
+	if err != nil {
+		return err
+	}
+	*t = Timespan(d)
+	return nil
+}
+
+// Limit is a limit of a RequestLimitsPolicy. If IsRelaxable is set, requests can relax the limit with their properties.
+type Limit[T any] struct {
+	IsRelaxable bool
+	Value       T
+}
+
+// DataScope decides which data a query reads.
+type DataScope string
+
+const (
+	// AllData reads all the data.
+	AllData DataScope = "All"
+	// HotCacheData reads the data in the hot cache.
+	HotCacheData DataScope = "HotCache"
+	// DefaultData reads the data of the default scope of the query.
+	DefaultData DataScope = "Default"
+)
+
+// RequestLimitsPolicy limits the resources a request of the group can use. A nil limit uses the default of Kusto.
+type RequestLimitsPolicy struct {
+	DataScope                           *Limit[DataScope] `json:",omitempty"`
+	MaxMemoryPerQueryPerNode            *Limit[int64]     `json:",omitempty"`
+	MaxMemoryPerIterator                *Limit[int64]     `json:",omitempty"`
+	MaxFanoutThreadsPercentage          *Limit[int]       `json:",omitempty"`
+	MaxFanoutNodesPercentage            *Limit[int]       `json:",omitempty"`
+	MaxResultRecords                    *Limit[int64]     `json:",omitempty"`
+	MaxResultBytes                      *Limit[int64]     `json:",omitempty"`
+	MaxExecutionTime                    *Limit[Timespan]  `json:",omitempty"`
+	QueryResultsProgressiveUpdatePeriod *Limit[Timespan]  `json:",omitempty"`
+}
+
+// RateLimitScope is the scope a RequestRateLimitPolicy counts requests in.
+type RateLimitScope string
+
+const (
+	// WorkloadGroupScope counts the requests of the whole group.
+	WorkloadGroupScope RateLimitScope = "WorkloadGroup"
+	// PrincipalScope counts the requests of each principal of the group separately.
+	PrincipalScope RateLimitScope = "Principal"
+)
+
+// RateLimitKind is the kind of a RequestRateLimitPolicy.
+type RateLimitKind string
+
+const (
+	ConcurrentRequestsKind  RateLimitKind = "ConcurrentRequests"
+	ResourceUtilizationKind RateLimitKind = "ResourceUtilization"
+)
+
+// ResourceKind is the resource a ResourceUtilizationKind limit counts.
+type ResourceKind string
+
+const (
+	RequestCountResource    ResourceKind = "RequestCount"
+	TotalCPUSecondsResource ResourceKind = "TotalCpuSeconds"
+)
+
+// RateLimitProperties are the properties of a RequestRateLimitPolicy. MaxConcurrentRequests is set for ConcurrentRequestsKind,
+// and the other properties for ResourceUtilizationKind.
+type RateLimitProperties struct {
+	MaxConcurrentRequests int          `json:",omitempty"`
+	ResourceKind          ResourceKind `json:",omitempty"`
+	MaxUtilization        float64      `json:",omitempty"`
+	TimeWindow            Timespan     `json:",omitempty"`
+}
+
+// RequestRateLimitPolicy limits the rate of the requests of the group. Requests over the limit are rejected.
+type RequestRateLimitPolicy struct {
+	IsEnabled  bool
+	Scope      RateLimitScope
+	LimitKind  RateLimitKind
+	Properties RateLimitProperties
+}
+
+// ConcurrentRequestsLimit returns an enabled policy that limits the number of requests that run at the same time.
+func ConcurrentRequestsLimit(scope RateLimitScope, max int) RequestRateLimitPolicy {
+	return RequestRateLimitPolicy{
+		IsEnabled:  true,
+		Scope:      scope,
+		LimitKind:  ConcurrentRequestsKind,
+		Properties: RateLimitProperties{MaxConcurrentRequests: max},
+	}
+}
+
+// ResourceUtilizationLimit returns an enabled policy that limits the use of the resource over a sliding window of time.
+func ResourceUtilizationLimit(scope RateLimitScope, resource ResourceKind, max float64, window time.Duration) RequestRateLimitPolicy {
+	return RequestRateLimitPolicy{
+		IsEnabled:  true,
+		Scope:      scope,
+		LimitKind:  ResourceUtilizationKind,
+		Properties: RateLimitProperties{ResourceKind: resource, MaxUtilization: max, TimeWindow: Timespan(window)},
+	}
+}
+
+// EnforcementLevel decides where rate limits are enforced.
+type EnforcementLevel string
+
+const (
+	ClusterLevel   EnforcementLevel = "Cluster"
+	QueryHeadLevel EnforcementLevel = "QueryHead"
+	DatabaseLevel  EnforcementLevel = "Database"
+)
+
+// RequestRateLimitsEnforcementPolicy decides where the rate limits of queries and commands are enforced.
+type RequestRateLimitsEnforcementPolicy struct {
+	// QueriesEnforcementLevel is ClusterLevel or QueryHeadLevel.
+	QueriesEnforcementLevel EnforcementLevel `json:",omitempty"`
+	// CommandsEnforcementLevel is ClusterLevel or DatabaseLevel.
+	CommandsEnforcementLevel EnforcementLevel `json:",omitempty"`
+}
+
+// RequestQueuingPolicy decides whether requests over the concurrency limits are queued instead of rejected.
+type RequestQueuingPolicy struct {
+	IsEnabled bool
+}
+
+// WorkloadGroup holds the policies of a workload group. A nil policy isn't changed by AlterMerge, and uses the default of Kusto
+// with CreateOrAlter.
+type WorkloadGroup struct {
+	RequestLimitsPolicy                *RequestLimitsPolicy                `json:",omitempty"`
+	RequestRateLimitPolicies           []RequestRateLimitPolicy            `json:",omitempty"`
+	RequestRateLimitsEnforcementPolicy *RequestRateLimitsEnforcementPolicy `json:",omitempty"`
+	RequestQueuingPolicy               *RequestQueuingPolicy               `json:",omitempty"`
+}
+
+// Info is a workload group and its name, as returned by `.show workload_groups`.
+type Info struct {
+	Name  string
+	Group WorkloadGroup
+}
+
+// CreateOrAlter creates the workload group name, or replaces its policies if it already exists.
+func CreateOrAlter(ctx context.Context, client management.Client, db string, name string, group WorkloadGroup) error {
+	stmt, err := CreateOrAlterStatement(name, group)
+	if err != nil {
+		return err
+	}
+	_, err = client.Mgmt(ctx, db, stmt)
+	return err
+}
+
+// AlterMerge changes the policies of the workload group name that are set in group, and keeps the others.
+func AlterMerge(ctx context.Context, client management.Client, db string, name string, group WorkloadGroup) error {
+	stmt, err := groupWithBody(kql.New(".alter-merge workload_group "), name, group)
+	if err != nil {
+		return err
+	}
+	_, err = client.Mgmt(ctx, db, stmt)
+	return err
+}
+
+// Drop drops the workload group name. The default workload group can't be dropped.
+func Drop(ctx context.Context, client management.Client, db string, name string) error {
+	if name == Default {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "the default workload group can't be dropped").SetNoRetry()
+	}
+	stmt, err := groupStatement(kql.New(".drop workload_group "), name)
+	if err != nil {
+		return err
+	}
+	_, err = client.Mgmt(ctx, db, stmt)
+	return err
+}
+
+// List returns the workload groups of the cluster.
+func List(ctx context.Context, client management.Client, db string) ([]Info, error) {
+	return show(ctx, client, db, kql.New(".show workload_groups"))
+}
+
+// Show returns the workload group name.
+func Show(ctx context.Context, client management.Client, db string, name string) (Info, error) {
+	stmt, err := groupStatement(kql.New(".show workload_group "), name)
+	if err != nil {
+		return Info{}, err
+	}
+	groups, err := show(ctx, client, db, stmt)
+	if err != nil {
+		return Info{}, err
+	}
+	if len(groups) == 0 {
+		return Info{}, errors.ES(errors.OpMgmt, errors.KOther, "workload group %q was not found", name)
+	}
+	return groups[0], nil
+}
+
+// CreateOrAlterStatement builds the `.create-or-alter workload_group` command used by CreateOrAlter.
+func CreateOrAlterStatement(name string, group WorkloadGroup) (azkustodata.Statement, error) {
+	return groupWithBody(kql.New(".create-or-alter workload_group "), name, group)
+}
+
+// groupRow is a row of `.show workload_groups`.
+type groupRow struct {
+	Name  string `kusto:"WorkloadGroupName"`
+	Group string `kusto:"WorkloadGroup"`
+}
+
+func show(ctx context.Context, client management.Client, db string, stmt azkustodata.Statement) ([]Info, error) {
+	ds, err := client.Mgmt(ctx, db, stmt)
+	if err != nil {
+		return nil, err
+	}
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command returned no tables")
+	}
+	rows, err := query.ToStructs[groupRow](tables[0])
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]Info, len(rows))
+	for i, r := range rows {
+		groups[i].Name = r.Name
+		if len(bytes.TrimSpace([]byte(r.Group))) == 0 {
+			continue
+		}
+		if err := json.Unmarshal([]byte(r.Group), &groups[i].Group); err != nil {
+			return nil, errors.ES(errors.OpMgmt, errors.KFailedToParse, "workload group %s could not be parsed: %s", r.Name, err)
+		}
+	}
+	return groups, nil
+}
+
+// groupWithBody completes stmt with the escaped name of the group and its policies, as a JSON string literal.
+func groupWithBody(stmt *kql.Builder, name string, group WorkloadGroup) (*kql.Builder, error) {
+	stmt, err := groupStatement(stmt, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := group.validate(); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(group)
+	if err != nil {
+		return nil, errors.E(errors.OpMgmt, errors.KClientArgs, err).SetNoRetry()
+	}
+	return stmt.AddLiteral(" ").AddString(string(b)), nil
+}
+
+// groupStatement completes stmt with the escaped name of the group.
+func groupStatement(stmt *kql.Builder, name string) (*kql.Builder, error) {
+	if name == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "workload group name must not be empty").SetNoRetry()
+	}
+	return stmt.AddTable(name), nil
+}
+
+func (g WorkloadGroup) validate() error {
+	for i, p := range g.RequestRateLimitPolicies {
+		if p.Scope != WorkloadGroupScope && p.Scope != PrincipalScope {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "rate limit policy[%d] has an unknown scope %q", i, p.Scope).SetNoRetry()
+		}
+		switch p.LimitKind {
+		case ConcurrentRequestsKind:
+			if p.Properties.MaxConcurrentRequests <= 0 {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "rate limit policy[%d] must allow at least one concurrent request", i).SetNoRetry()
+			}
+		case ResourceUtilizationKind:
+			if p.Properties.ResourceKind != RequestCountResource && p.Properties.ResourceKind != TotalCPUSecondsResource {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "rate limit policy[%d] has an unknown resource kind %q", i, p.Properties.ResourceKind).SetNoRetry()
+			}
+			if p.Properties.MaxUtilization <= 0 || p.Properties.TimeWindow <= 0 {
+				return errors.ES(errors.OpMgmt, errors.KClientArgs, "rate limit policy[%d] must have a positive maximum utilization and time window", i).SetNoRetry()
+			}
+		default:
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "rate limit policy[%d] has an unknown limit kind %q", i, p.LimitKind).SetNoRetry()
+		}
+	}
+
+	if e := g.RequestRateLimitsEnforcementPolicy; e != nil {
+		if e.QueriesEnforcementLevel != "" && e.QueriesEnforcementLevel != ClusterLevel && e.QueriesEnforcementLevel != QueryHeadLevel {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "queries can't be enforced at level %q", e.QueriesEnforcementLevel).SetNoRetry()
+		}
+		if e.CommandsEnforcementLevel != "" && e.CommandsEnforcementLevel != ClusterLevel && e.CommandsEnforcementLevel != DatabaseLevel {
+			return errors.ES(errors.OpMgmt, errors.KClientArgs, "commands can't be enforced at level %q", e.CommandsEnforcementLevel).SetNoRetry()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package workloadgroups
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const emptyResponse = `{"Tables":[{"TableName":"Table_0","Columns":[],"Rows":[]}]}`
+
+// fakeClient answers the `.show workload_group` commands with a row for every group of groups, the `.show cluster policy`
+// command with classification, and the other commands with an empty result. It records the commands.
+type fakeClient struct {
+	groups         map[string]string
+	classification string
+	commands       []string
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, _ string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	cmd := kqlQuery.String()
+	f.commands = append(f.commands, cmd)
+
+	body := emptyResponse
+	switch {
+	case strings.HasPrefix(cmd, ".show workload_group"):
+		var rows []string
+		for name, group := range f.groups {
+			if cmd != ".show workload_groups" && cmd != ".show workload_group "+name {
+				continue
+			}
+			n, _ := json.Marshal(name)
+			g, _ := json.Marshal(group)
+			rows = append(rows, "["+string(n)+","+string(g)+"]")
+		}
+		body = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"WorkloadGroupName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"WorkloadGroup","DataType":"String","ColumnType":"string"}],
+"Rows":[` + strings.Join(rows, ",") + `]}]}`
+	case strings.HasPrefix(cmd, ".show cluster policy"):
+		p, _ := json.Marshal(f.classification)
+		body = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"PolicyName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Policy","DataType":"String","ColumnType":"string"}],
+"Rows":[["RequestClassificationPolicy","",` + string(p) + `]]}]}`
+	}
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(body)))
+}
+
+func TestCreateOrAlterStatement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		name  string
+		group WorkloadGroup
+		want  string
+		err   bool
+	}{
+		{
+			desc: "empty",
+			name: "Tenants",
+			want: `.create-or-alter workload_group Tenants "{}"`,
+		},
+		{
+			desc: "all policies",
+			name: "My Tenants",
+			group: WorkloadGroup{
+				RequestLimitsPolicy: &RequestLimitsPolicy{
+					DataScope:        &Limit[DataScope]{Value: HotCacheData},
+					MaxResultRecords: &Limit[int64]{IsRelaxable: true, Value: 1000},
+					MaxExecutionTime: &Limit[Timespan]{Value: Timespan(5 * time.Minute)},
+				},
+				RequestRateLimitPolicies: []RequestRateLimitPolicy{
+					ConcurrentRequestsLimit(PrincipalScope, 10),
+					ResourceUtilizationLimit(WorkloadGroupScope, TotalCPUSecondsResource, 1000, time.Hour),
+				},
+				RequestRateLimitsEnforcementPolicy: &RequestRateLimitsEnforcementPolicy{QueriesEnforcementLevel: QueryHeadLevel},
+				RequestQueuingPolicy:               &RequestQueuingPolicy{IsEnabled: true},
+			},
+			want: `.create-or-alter workload_group ["My Tenants"] "{\"RequestLimitsPolicy\":{` +
+				`\"DataScope\":{\"IsRelaxable\":false,\"Value\":\"HotCache\"},` +
+				`\"MaxResultRecords\":{\"IsRelaxable\":true,\"Value\":1000},` +
+				`\"MaxExecutionTime\":{\"IsRelaxable\":false,\"Value\":\"00:05:00\"}},` +
+				`\"RequestRateLimitPolicies\":[` +
+				`{\"IsEnabled\":true,\"Scope\":\"Principal\",\"LimitKind\":\"ConcurrentRequests\",\"Properties\":{\"MaxConcurrentRequests\":10}},` +
+				`{\"IsEnabled\":true,\"Scope\":\"WorkloadGroup\",\"LimitKind\":\"ResourceUtilization\",` +
+				`\"Properties\":{\"ResourceKind\":\"TotalCpuSeconds\",\"MaxUtilization\":1000,\"TimeWindow\":\"01:00:00\"}}],` +
+				`\"RequestRateLimitsEnforcementPolicy\":{\"QueriesEnforcementLevel\":\"QueryHead\"},` +
+				`\"RequestQueuingPolicy\":{\"IsEnabled\":true}}"`,
+		},
+		{desc: "no name", err: true},
+		{
+			desc:  "no concurrent requests",
+			name:  "Tenants",
+			group: WorkloadGroup{RequestRateLimitPolicies: []RequestRateLimitPolicy{ConcurrentRequestsLimit(PrincipalScope, 0)}},
+			err:   true,
+		},
+		{
+			desc:  "unknown scope",
+			name:  "Tenants",
+			group: WorkloadGroup{RequestRateLimitPolicies: []RequestRateLimitPolicy{ConcurrentRequestsLimit("Database", 1)}},
+			err:   true,
+		},
+		{
+			desc:  "no time window",
+			name:  "Tenants",
+			group: WorkloadGroup{RequestRateLimitPolicies: []RequestRateLimitPolicy{ResourceUtilizationLimit(PrincipalScope, RequestCountResource, 10, 0)}},
+			err:   true,
+		},
+		{
+			desc:  "unknown limit kind",
+			name:  "Tenants",
+			group: WorkloadGroup{RequestRateLimitPolicies: []RequestRateLimitPolicy{{Scope: PrincipalScope, LimitKind: "Memory"}}},
+			err:   true,
+		},
+		{
+			desc:  "commands at query head",
+			name:  "Tenants",
+			group: WorkloadGroup{RequestRateLimitsEnforcementPolicy: &RequestRateLimitsEnforcementPolicy{CommandsEnforcementLevel: QueryHeadLevel}},
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, err := CreateOrAlterStatement(test.name, test.group)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, stmt.String())
+		})
+	}
+}
+
+func TestWorkloadGroups(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{groups: map[string]string{
+		"Tenants": `{"RequestLimitsPolicy":{"MaxMemoryPerQueryPerNode":{"IsRelaxable":true,"Value":8589934592},` +
+			`"MaxExecutionTime":{"IsRelaxable":false,"Value":"00:10:00"}},` +
+			`"RequestRateLimitPolicies":[{"IsEnabled":true,"Scope":"Principal","LimitKind":"ConcurrentRequests","Properties":{"MaxConcurrentRequests":5}}]}`,
+	}}
+
+	require.NoError(t, CreateOrAlter(ctx, client, "db", "Tenants", WorkloadGroup{RequestQueuingPolicy: &RequestQueuingPolicy{}}))
+	require.NoError(t, AlterMerge(ctx, client, "db", "Tenants", WorkloadGroup{RequestQueuingPolicy: &RequestQueuingPolicy{IsEnabled: true}}))
+	require.NoError(t, Drop(ctx, client, "db", "Old Tenants"))
+	assert.Error(t, Drop(ctx, client, "db", Default))
+
+	group, err := Show(ctx, client, "db", "Tenants")
+	require.NoError(t, err)
+	assert.Equal(t, Info{
+		Name: "Tenants",
+		Group: WorkloadGroup{
+			RequestLimitsPolicy: &RequestLimitsPolicy{
+				MaxMemoryPerQueryPerNode: &Limit[int64]{IsRelaxable: true, Value: 8589934592},
+				MaxExecutionTime:         &Limit[Timespan]{Value: Timespan(10 * time.Minute)},
+			},
+			RequestRateLimitPolicies: []RequestRateLimitPolicy{ConcurrentRequestsLimit(PrincipalScope, 5)},
+		},
+	}, group)
+
+	_, err = Show(ctx, client, "db", "Missing")
+	assert.Error(t, err)
+
+	groups, err := List(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Len(t, groups, 1)
+
+	assert.Equal(t, []string{
+		`.create-or-alter workload_group Tenants "{\"RequestQueuingPolicy\":{\"IsEnabled\":false}}"`,
+		`.alter-merge workload_group Tenants "{\"RequestQueuingPolicy\":{\"IsEnabled\":true}}"`,
+		`.drop workload_group ["Old Tenants"]`,
+		".show workload_group Tenants",
+		".show workload_group Missing",
+		".show workload_groups",
+	}, client.commands)
+}
+
+func TestClassification(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := &fakeClient{classification: `{"IsEnabled":true,"ClassificationFunction":"iff(request_properties.current_database == \"Tenants\", \"Tenants\", \"default\")"}`}
+
+	policy, err := ShowClassification(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, &ClassificationPolicy{
+		IsEnabled:              true,
+		ClassificationFunction: `iff(request_properties.current_database == "Tenants", "Tenants", "default")`,
+	}, policy)
+
+	function := kql.New(`iff(request_properties.current_database == "Tenants", "Tenants", "default")`)
+	require.NoError(t, AlterClassification(ctx, client, "db", true, function))
+	assert.Error(t, AlterClassification(ctx, client, "db", true, kql.New("")))
+	require.NoError(t, DeleteClassification(ctx, client, "db"))
+
+	assert.Equal(t, []string{
+		".show cluster policy request_classification",
+		".alter cluster policy request_classification \"{\\\"IsEnabled\\\":true}\" <|\n" +
+			`iff(request_properties.current_database == "Tenants", "Tenants", "default")`,
+		".delete cluster policy request_classification",
+	}, client.commands)
+
+	client = &fakeClient{}
+	policy, err = ShowClassification(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+}
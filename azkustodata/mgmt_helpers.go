@@ -0,0 +1,266 @@
+package azkustodata
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// TableInfo describes a table, as returned by `.show tables`.
+type TableInfo struct {
+	Name      string `kusto:"TableName"`
+	Database  string `kusto:"DatabaseName"`
+	Folder    string `kusto:"Folder"`
+	DocString string `kusto:"DocString"`
+}
+
+// TableSchemaInfo describes a table and its columns, as returned by `.show table T schema as json`.
+// Each column exposes its CslType, DocString and Folder.
+type TableSchemaInfo struct {
+	TableInfo
+	Columns query.Columns
+}
+
+// tableSchemaRow is a row of `.show table T schema as json`.
+type tableSchemaRow struct {
+	Name      string `kusto:"TableName"`
+	Database  string `kusto:"DatabaseName"`
+	Folder    string `kusto:"Folder"`
+	DocString string `kusto:"DocString"`
+	Schema    string `kusto:"Schema"`
+}
+
+// ListTables returns the tables of the database db.
+func (c *Client) ListTables(ctx context.Context, db string, options ...QueryOption) ([]TableInfo, error) {
+	ds, err := c.Mgmt(ctx, db, kql.New(".show tables"), options...)
+	if err != nil {
+		return nil, err
+	}
+	return v1.ToStructs[TableInfo](ds)
+}
+
+// TableSchema returns the schema of the table in the database db.
+// The table name is escaped as needed.
+func (c *Client) TableSchema(ctx context.Context, db string, table string, options ...QueryOption) (TableSchemaInfo, error) {
+	stmt, err := TableSchemaStatement(table)
+	if err != nil {
+		return TableSchemaInfo{}, err
+	}
+	ds, err := c.Mgmt(ctx, db, stmt, options...)
+	if err != nil {
+		return TableSchemaInfo{}, err
+	}
+
+	rows, err := v1.ToStructs[tableSchemaRow](ds)
+	if err != nil {
+		return TableSchemaInfo{}, err
+	}
+	if len(rows) == 0 {
+		return TableSchemaInfo{}, errors.ES(errors.OpMgmt, errors.KOther, "table %q was not found in database %q", table, db)
+	}
+
+	row := rows[0]
+	cols, err := query.ColumnsFromSchemaJSON(row.Schema, row.Folder)
+	if err != nil {
+		return TableSchemaInfo{}, err
+	}
+	return TableSchemaInfo{
+		TableInfo: TableInfo{Name: row.Name, Database: row.Database, Folder: row.Folder, DocString: row.DocString},
+		Columns:   cols,
+	}, nil
+}
+
+// TableSchemaStatement builds the statement used by TableSchema.
+func TableSchemaStatement(table string) (Statement, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	return kql.New(".show table ").AddTable(table).AddLiteral(" schema as json"), nil
+}
+
+// ScriptCommandState is the state of a command of a database script.
+type ScriptCommandState string
+
+const (
+	ScriptCommandCompleted ScriptCommandState = "Completed"
+	ScriptCommandFailed    ScriptCommandState = "Failed"
+	// ScriptCommandSkipped is a command that didn't run, since a previous command failed.
+	ScriptCommandSkipped ScriptCommandState = "Skipped"
+)
+
+// ScriptCommandResult is the result of a command of a database script, as returned by `.execute database script`.
+type ScriptCommandResult struct {
+	OperationID string             `kusto:"OperationId"`
+	CommandType string             `kusto:"CommandType"`
+	CommandText string             `kusto:"CommandText"`
+	Result      ScriptCommandState `kusto:"Result"`
+	// Reason is the error of a failed command.
+	Reason string `kusto:"Reason"`
+}
+
+// DatabaseScriptResult holds the results of the commands of a database script, in the order of the script.
+type DatabaseScriptResult struct {
+	Commands []ScriptCommandResult
+}
+
+// Succeeded returns the commands that completed.
+func (r DatabaseScriptResult) Succeeded() []ScriptCommandResult {
+	return r.filter(ScriptCommandCompleted)
+}
+
+// Failed returns the commands that failed.
+func (r DatabaseScriptResult) Failed() []ScriptCommandResult {
+	return r.filter(ScriptCommandFailed)
+}
+
+// Err returns nil if every command completed, or an error describing the first command that didn't.
+func (r DatabaseScriptResult) Err() error {
+	for _, c := range r.Commands {
+		if c.Result != ScriptCommandCompleted {
+			return errors.ES(errors.OpMgmt, errors.KOther, "command %q of the database script is %s: %s", c.CommandText, c.Result, c.Reason)
+		}
+	}
+	return nil
+}
+
+func (r DatabaseScriptResult) filter(state ScriptCommandState) []ScriptCommandResult {
+	var out []ScriptCommandResult
+	for _, c := range r.Commands {
+		if c.Result == state {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// ExecuteDatabaseScript runs the management commands of script in the database db, in order, as a single request. The commands
+// are separated by empty lines, see DatabaseScript.
+//
+// By default, the script stops at the first command that fails, and the next ones are skipped. If continueOnErrors is set, every
+// command runs. In both cases the commands that completed are kept, and the result of every command is returned; check
+// DatabaseScriptResult.Err or Failed to know whether all of them completed.
+func (c *Client) ExecuteDatabaseScript(ctx context.Context, db string, script Statement, continueOnErrors bool, options ...QueryOption) (DatabaseScriptResult, error) {
+	stmt, err := ExecuteDatabaseScriptStatement(script, continueOnErrors)
+	if err != nil {
+		return DatabaseScriptResult{}, err
+	}
+	ds, err := c.Mgmt(ctx, db, stmt, options...)
+	if err != nil {
+		return DatabaseScriptResult{}, err
+	}
+	rows, err := v1.ToStructs[ScriptCommandResult](ds)
+	if err != nil {
+		return DatabaseScriptResult{}, err
+	}
+	return DatabaseScriptResult{Commands: rows}, nil
+}
+
+// DatabaseScript joins management commands into a script for ExecuteDatabaseScript. Each command is separated from the next by
+// an empty line, so commands can span several lines, but must not contain empty lines.
+func DatabaseScript(commands ...Statement) Statement {
+	script := kql.New("")
+	for i, cmd := range commands {
+		if i > 0 {
+			script.AddLiteral("\n\n")
+		}
+		script.AddUnsafe(cmd.String())
+	}
+	return script
+}
+
+// ExecuteDatabaseScriptStatement builds the `.execute database script` command used by ExecuteDatabaseScript.
+func ExecuteDatabaseScriptStatement(script Statement, continueOnErrors bool) (Statement, error) {
+	if script == nil || strings.TrimSpace(script.String()) == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "database script must not be empty").SetNoRetry()
+	}
+	stmt := kql.New(".execute database script")
+	if continueOnErrors {
+		stmt.AddLiteral(" with (ContinueOnErrors=true)")
+	}
+	return stmt.AddLiteral(" <|\n").AddUnsafe(script.String()), nil
+}
+
+// QueryState is the state of a query, as returned by `.show queries`.
+type QueryState string
+
+const (
+	QueryInProgress QueryState = "InProgress"
+	QueryCompleted  QueryState = "Completed"
+	QueryFailed     QueryState = "Failed"
+)
+
+// ScannedExtentsStatistics describes the data a query scanned, compared to the data of the tables it ran on.
+type ScannedExtentsStatistics struct {
+	// MinDataScannedTime and MaxDataScannedTime bound the ingestion times of the data scanned.
+	MinDataScannedTime  time.Time
+	MaxDataScannedTime  time.Time
+	ScannedExtentsCount int64
+	TotalExtentsCount   int64
+	ScannedRowsCount    int64
+	TotalRowsCount      int64
+}
+
+// QueryDiagnostics describes a query that ran on the cluster, as returned by `.show queries`.
+type QueryDiagnostics struct {
+	ClientRequestID string        `kusto:"ClientActivityId"`
+	Text            string        `kusto:"Text"`
+	Database        string        `kusto:"Database"`
+	StartedOn       time.Time     `kusto:"StartedOn"`
+	LastUpdatedOn   time.Time     `kusto:"LastUpdatedOn"`
+	Duration        time.Duration `kusto:"Duration"`
+	State           QueryState    `kusto:"State"`
+	// FailureReason is the error of a failed query.
+	FailureReason  string        `kusto:"FailureReason"`
+	RootActivityID string        `kusto:"RootActivityId"`
+	TotalCPU       time.Duration `kusto:"TotalCpu"`
+	// MemoryPeak is the peak memory the query used on a node, in bytes.
+	MemoryPeak     int64                    `kusto:"MemoryPeak"`
+	ScannedExtents ScannedExtentsStatistics `kusto:"ScannedExtentsStatistics"`
+	Application    string                   `kusto:"Application"`
+	User           string                   `kusto:"User"`
+	Principal      string                   `kusto:"Principal"`
+	WorkloadGroup  string                   `kusto:"WorkloadGroup"`
+}
+
+// GetQueryDiagnostics returns the diagnostics of the query sent with the given client request ID, such as the one returned by
+// the ClientRequestID method of its dataset or by its error, from `.show queries`. The query must have run recently, and the
+// principal of the client can only see its own queries, unless it is a database admin. If the query was sent more than once
+// with the ID, its latest run is returned.
+func (c *Client) GetQueryDiagnostics(ctx context.Context, clientRequestID string, options ...QueryOption) (QueryDiagnostics, error) {
+	stmt, err := QueryDiagnosticsStatement(clientRequestID)
+	if err != nil {
+		return QueryDiagnostics{}, err
+	}
+	ds, err := c.Mgmt(ctx, "", stmt, options...)
+	if err != nil {
+		return QueryDiagnostics{}, err
+	}
+	rows, err := v1.ToStructs[QueryDiagnostics](ds)
+	if err != nil {
+		return QueryDiagnostics{}, err
+	}
+	if len(rows) == 0 {
+		return QueryDiagnostics{}, errors.ES(errors.OpMgmt, errors.KOther, "no query with the client request ID %q was found", clientRequestID)
+	}
+
+	latest := rows[0]
+	for _, row := range rows[1:] {
+		if row.StartedOn.After(latest.StartedOn) {
+			latest = row
+		}
+	}
+	return latest, nil
+}
+
+// QueryDiagnosticsStatement builds the `.show queries` command used by GetQueryDiagnostics.
+func QueryDiagnosticsStatement(clientRequestID string) (Statement, error) {
+	if clientRequestID == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "client request ID must not be empty").SetNoRetry()
+	}
+	return kql.New(".show queries | where ClientActivityId == ").AddString(clientRequestID), nil
+}
@@ -0,0 +1,219 @@
+package azkustodata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testShowTablesResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Folder","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DocString","DataType":"String","ColumnType":"string"}],
+"Rows":[["Logs","db","Raw","Raw logs"],["Events","db","",""]]}]}`
+
+const testShowTableSchemaResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Schema","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DatabaseName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Folder","DataType":"String","ColumnType":"string"},
+{"ColumnName":"DocString","DataType":"String","ColumnType":"string"}],
+"Rows":[["Logs","{\"Name\":\"Logs\",\"OrderedColumns\":[{\"Name\":\"Timestamp\",\"Type\":\"System.DateTime\",\"CslType\":\"datetime\"},{\"Name\":\"Message\",\"Type\":\"System.String\",\"CslType\":\"string\",\"DocString\":\"The message\"}]}","db","Raw","Raw logs"]]}]}`
+
+const testEmptyMgmtResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Schema","DataType":"String","ColumnType":"string"}],
+"Rows":[]}]}`
+
+func TestTableSchemaStatement(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "Simple", table: "Logs", expected: ".show table Logs schema as json"},
+		{name: "Escaped", table: "My Table", expected: ".show table [\"My Table\"] schema as json"},
+		{name: "Empty", table: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stmt, err := TableSchemaStatement(tt.table)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stmt.String())
+		})
+	}
+}
+
+func TestListTables(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testShowTablesResponse)
+
+	tables, err := client.ListTables(context.Background(), "db")
+	require.NoError(t, err)
+	assert.Equal(t, []TableInfo{
+		{Name: "Logs", Database: "db", Folder: "Raw", DocString: "Raw logs"},
+		{Name: "Events", Database: "db"},
+	}, tables)
+	assert.Equal(t, ".show tables", f.lastCall().query)
+	assert.Equal(t, callType(mgmtCall), f.lastCall().callType)
+}
+
+func TestTableSchema(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testShowTableSchemaResponse)
+
+	schema, err := client.TableSchema(context.Background(), "db", "Logs")
+	require.NoError(t, err)
+	assert.Equal(t, ".show table Logs schema as json", f.lastCall().query)
+	assert.Equal(t, TableInfo{Name: "Logs", Database: "db", Folder: "Raw", DocString: "Raw logs"}, schema.TableInfo)
+
+	require.Len(t, schema.Columns, 2)
+	assert.Equal(t, "Timestamp", schema.Columns[0].Name())
+	assert.Equal(t, types.DateTime, schema.Columns[0].Type())
+	assert.Equal(t, "string", schema.Columns[1].CslType())
+	assert.Equal(t, "The message", schema.Columns[1].DocString())
+	assert.Equal(t, "Raw", schema.Columns[1].Folder())
+
+	client, _ = newFakeClient(testEmptyMgmtResponse)
+	_, err = client.TableSchema(context.Background(), "db", "Missing")
+	assert.ErrorContains(t, err, `table "Missing" was not found in database "db"`)
+}
+
+const testExecuteScriptResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"OperationId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"CommandType","DataType":"String","ColumnType":"string"},
+{"ColumnName":"CommandText","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Result","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Reason","DataType":"String","ColumnType":"string"}],
+"Rows":[["0cd1e4c9-5c2a-4a33-8a33-1e3fe1e3f6d4","TableCreateMerge",".create-merge table Logs (Message:string)","Completed",""],
+["9f5a3e2c-7d3b-4bfa-9a2e-3c1b2d4e5f60","TableAlterFolder",".alter table Missing folder \"Raw\"","Failed","Table 'Missing' could not be found"],
+["00000000-0000-0000-0000-000000000000","FunctionCreateOrAlter",".create-or-alter function F() { Logs }","Skipped",""]]}]}`
+
+func TestExecuteDatabaseScriptStatement(t *testing.T) {
+	t.Parallel()
+
+	script := DatabaseScript(
+		kql.New(".create-merge table Logs (Message:string)"),
+		kql.New(".alter table Logs folder ").AddString("Raw"),
+	)
+	assert.Equal(t, ".create-merge table Logs (Message:string)\n\n.alter table Logs folder \"Raw\"", script.String())
+
+	stmt, err := ExecuteDatabaseScriptStatement(script, false)
+	require.NoError(t, err)
+	assert.Equal(t, ".execute database script <|\n.create-merge table Logs (Message:string)\n\n.alter table Logs folder \"Raw\"", stmt.String())
+
+	stmt, err = ExecuteDatabaseScriptStatement(kql.New(".drop table T"), true)
+	require.NoError(t, err)
+	assert.Equal(t, ".execute database script with (ContinueOnErrors=true) <|\n.drop table T", stmt.String())
+
+	_, err = ExecuteDatabaseScriptStatement(DatabaseScript(), false)
+	assert.Error(t, err)
+}
+
+func TestExecuteDatabaseScript(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testExecuteScriptResponse)
+
+	result, err := client.ExecuteDatabaseScript(context.Background(), "db", kql.New(".create-merge table Logs (Message:string)"), true)
+	require.NoError(t, err)
+	assert.Equal(t, ".execute database script with (ContinueOnErrors=true) <|\n.create-merge table Logs (Message:string)", f.lastCall().query)
+	assert.Equal(t, callType(mgmtCall), f.lastCall().callType)
+
+	require.Len(t, result.Commands, 3)
+	assert.Equal(t, []ScriptCommandResult{{
+		OperationID: "0cd1e4c9-5c2a-4a33-8a33-1e3fe1e3f6d4",
+		CommandType: "TableCreateMerge",
+		CommandText: ".create-merge table Logs (Message:string)",
+		Result:      ScriptCommandCompleted,
+	}}, result.Succeeded())
+	require.Len(t, result.Failed(), 1)
+	assert.Equal(t, "Table 'Missing' could not be found", result.Failed()[0].Reason)
+	assert.ErrorContains(t, result.Err(), "Failed: Table 'Missing' could not be found")
+
+	assert.NoError(t, DatabaseScriptResult{Commands: result.Succeeded()}.Err())
+}
+
+const testShowQueriesResponse = `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"ClientActivityId","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Text","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Database","DataType":"String","ColumnType":"string"},
+{"ColumnName":"StartedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"LastUpdatedOn","DataType":"DateTime","ColumnType":"datetime"},
+{"ColumnName":"Duration","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"State","DataType":"String","ColumnType":"string"},
+{"ColumnName":"RootActivityId","DataType":"Guid","ColumnType":"guid"},
+{"ColumnName":"User","DataType":"String","ColumnType":"string"},
+{"ColumnName":"FailureReason","DataType":"String","ColumnType":"string"},
+{"ColumnName":"TotalCpu","DataType":"TimeSpan","ColumnType":"timespan"},
+{"ColumnName":"MemoryPeak","DataType":"Int64","ColumnType":"long"},
+{"ColumnName":"ScannedExtentsStatistics","DataType":"Object","ColumnType":"dynamic"}],
+"Rows":[
+["KGC.execute;1","T | count","db","2024-05-01T10:00:00Z","2024-05-01T10:00:01Z","00:00:01.5000000","Failed","cd7ecd5e-6a2b-4f2c-9a2f-37a7a5c7c1a4","user@contoso.com","Query timed out","00:00:00.2500000",1024,{"MinDataScannedTime":null,"MaxDataScannedTime":null,"ScannedExtentsCount":0,"TotalExtentsCount":0,"ScannedRowsCount":0,"TotalRowsCount":0}],
+["KGC.execute;1","T | count","db","2024-05-01T11:00:00Z","2024-05-01T11:00:02Z","00:00:02","Completed","8f1c5c53-3a43-4a4c-8d3b-5b8c0a1c2b3d","user@contoso.com","","00:00:00.5000000",2048,{"MinDataScannedTime":"2024-04-30T00:00:00Z","MaxDataScannedTime":"2024-05-01T00:00:00Z","ScannedExtentsCount":3,"TotalExtentsCount":10,"ScannedRowsCount":300,"TotalRowsCount":1000}]
+]}]}`
+
+func TestQueryDiagnosticsStatement(t *testing.T) {
+	t.Parallel()
+
+	stmt, err := QueryDiagnosticsStatement(`KGC.execute;"id"`)
+	require.NoError(t, err)
+	assert.Equal(t, `.show queries | where ClientActivityId == "KGC.execute;\"id\""`, stmt.String())
+
+	_, err = QueryDiagnosticsStatement("")
+	assert.Error(t, err)
+}
+
+func TestGetQueryDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testShowQueriesResponse)
+
+	diagnostics, err := client.GetQueryDiagnostics(context.Background(), "KGC.execute;1")
+	require.NoError(t, err)
+	assert.Equal(t, `.show queries | where ClientActivityId == "KGC.execute;1"`, f.lastCall().query)
+	assert.Equal(t, callType(mgmtCall), f.lastCall().callType)
+
+	// The latest run of the query is returned.
+	assert.Equal(t, QueryDiagnostics{
+		ClientRequestID: "KGC.execute;1",
+		Text:            "T | count",
+		Database:        "db",
+		StartedOn:       time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		LastUpdatedOn:   time.Date(2024, 5, 1, 11, 0, 2, 0, time.UTC),
+		Duration:        2 * time.Second,
+		State:           QueryCompleted,
+		RootActivityID:  "8f1c5c53-3a43-4a4c-8d3b-5b8c0a1c2b3d",
+		User:            "user@contoso.com",
+		TotalCPU:        500 * time.Millisecond,
+		MemoryPeak:      2048,
+		ScannedExtents: ScannedExtentsStatistics{
+			MinDataScannedTime:  time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+			MaxDataScannedTime:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			ScannedExtentsCount: 3,
+			TotalExtentsCount:   10,
+			ScannedRowsCount:    300,
+			TotalRowsCount:      1000,
+		},
+	}, diagnostics)
+
+	client, _ = newFakeClient(testEmptyMgmtResponse)
+	_, err = client.GetQueryDiagnostics(context.Background(), "KGC.execute;2")
+	assert.ErrorContains(t, err, `no query with the client request ID "KGC.execute;2" was found`)
+}
@@ -4,12 +4,27 @@ import "github.com/Azure/azure-kusto-go/azkustodata/types"
 
 // Column represents a column in a table.
 type Column interface {
-	// Ordinal returns the column's index in the table.
+	// Index returns the column's ordinal in the table.
 	Index() int
 	// Name returns the column's name.
 	Name() string
 	// Type returns the column's kusto data type.
 	Type() types.Column
+	// CslType returns the name of the column's type in the Kusto query language, such as "long" or "datetime".
+	CslType() string
+	// DocString returns the column's docstring, when the column was described by a table schema (see ColumnsFromSchemaJSON).
+	DocString() string
+	// Folder returns the folder of the column's table, when the column was described by a table schema (see ColumnsFromSchemaJSON).
+	Folder() string
 }
 
 type Columns []Column
+
+// ByName returns the columns keyed by their names.
+func (c Columns) ByName() map[string]Column {
+	m := make(map[string]Column, len(c))
+	for _, col := range c {
+		m[col.Name()] = col
+	}
+	return m
+}
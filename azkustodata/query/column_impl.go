@@ -1,6 +1,9 @@
 package query
 
 import (
+	"encoding/json"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 )
 
@@ -9,6 +12,8 @@ type column struct {
 	index     int
 	name      string
 	kustoType types.Column
+	docString string
+	folder    string
 }
 
 func (c column) Index() int {
@@ -23,6 +28,18 @@ func (c column) Type() types.Column {
 	return c.kustoType
 }
 
+func (c column) CslType() string {
+	return string(c.kustoType)
+}
+
+func (c column) DocString() string {
+	return c.docString
+}
+
+func (c column) Folder() string {
+	return c.folder
+}
+
 func NewColumn(ordinal int, name string, kustoType types.Column) Column {
 	return &column{
 		index:     ordinal,
@@ -30,3 +47,55 @@ func NewColumn(ordinal int, name string, kustoType types.Column) Column {
 		kustoType: kustoType,
 	}
 }
+
+// ColumnMetadata holds the optional properties of a column that are only known from its table's schema.
+type ColumnMetadata struct {
+	DocString string
+	Folder    string
+}
+
+// NewColumnWithMetadata returns a column with a docstring and a folder.
+func NewColumnWithMetadata(ordinal int, name string, kustoType types.Column, metadata ColumnMetadata) Column {
+	return &column{
+		index:     ordinal,
+		name:      name,
+		kustoType: kustoType,
+		docString: metadata.DocString,
+		folder:    metadata.Folder,
+	}
+}
+
+// tableSchema is the Schema column returned by `.show table T schema as json`.
+type tableSchema struct {
+	Name           string
+	Folder         string
+	DocString      string
+	OrderedColumns []struct {
+		Name      string
+		CslType   string
+		DocString string
+	}
+}
+
+// ColumnsFromSchemaJSON parses the Schema column returned by `.show table T schema as json` into columns with their docstrings.
+// The folder of every column is the folder of the table, which is taken from folder if it is not empty, since the Folder
+// column of the command's result is more reliable than the schema.
+func ColumnsFromSchemaJSON(schema string, folder string) (Columns, error) {
+	var s tableSchema
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return nil, errors.E(errors.OpTableAccess, errors.KFailedToParse, err)
+	}
+	if folder == "" {
+		folder = s.Folder
+	}
+
+	cols := make(Columns, len(s.OrderedColumns))
+	for i, c := range s.OrderedColumns {
+		t := types.NormalizeColumn(c.CslType)
+		if t == "" {
+			return nil, errors.ES(errors.OpTableAccess, errors.KClientArgs, "column[%d] is of type %q, which is not valid", i, c.CslType)
+		}
+		cols[i] = NewColumnWithMetadata(i, c.Name, t, ColumnMetadata{DocString: c.DocString, Folder: folder})
+	}
+	return cols, nil
+}
@@ -0,0 +1,54 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnsFromSchemaJSON(t *testing.T) {
+	t.Parallel()
+
+	schema := `{"Name":"T","OrderedColumns":[` +
+		`{"Name":"Id","Type":"System.Int64","CslType":"long","DocString":"The identifier"},` +
+		`{"Name":"When","Type":"System.DateTime","CslType":"datetime"}],"Folder":"from-schema"}`
+
+	cols, err := ColumnsFromSchemaJSON(schema, "Sales")
+	require.NoError(t, err)
+	require.Len(t, cols, 2)
+
+	assert.Equal(t, 1, cols[1].Index())
+	assert.Equal(t, "Id", cols[0].Name())
+	assert.Equal(t, types.Long, cols[0].Type())
+	assert.Equal(t, "long", cols[0].CslType())
+	assert.Equal(t, "The identifier", cols[0].DocString())
+	assert.Equal(t, "", cols[1].DocString())
+	assert.Equal(t, "Sales", cols[1].Folder())
+
+	cols, err = ColumnsFromSchemaJSON(schema, "")
+	require.NoError(t, err)
+	assert.Equal(t, "from-schema", cols[0].Folder())
+
+	_, err = ColumnsFromSchemaJSON(`{"OrderedColumns":[{"Name":"a","CslType":"blob"}]}`, "")
+	assert.Error(t, err)
+	_, err = ColumnsFromSchemaJSON(`nope`, "")
+	assert.Error(t, err)
+}
+
+func TestColumnsByName(t *testing.T) {
+	t.Parallel()
+
+	cols := Columns{NewColumn(0, "A", types.String), NewColumn(1, "B", types.Real)}
+	byName := cols.ByName()
+	assert.Len(t, byName, 2)
+	assert.Equal(t, types.Real, byName["B"].Type())
+
+	ds := NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	table := NewBaseTable(ds, 0, "1", "T", "PrimaryResult", cols)
+	assert.Equal(t, byName, table.ColumnsByName())
+	assert.Equal(t, "real", table.ColumnsByName()["B"].CslType())
+}
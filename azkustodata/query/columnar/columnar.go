@@ -0,0 +1,386 @@
+/*
+Package columnar converts query results into column-oriented record batches.
+
+Every column is a contiguous slice of values with a validity bitmap (least-significant bit first, a set bit marks a non-null
+value), and variable-length columns are stored as a single data buffer with int32 offsets. This lets wide numeric tables be handed
+to analytics code column by column, without going through the values of each row.
+
+The package has its own types, and isn't compatible with Apache Arrow or any other columnar library. The columns of the Kusto
+types are:
+
+	bool     -> PrimitiveArray[bool]
+	int      -> PrimitiveArray[int32]
+	long     -> PrimitiveArray[int64]
+	real     -> PrimitiveArray[float64]
+	datetime -> PrimitiveArray[int64] of nanoseconds since the Unix epoch, in UTC (converting a datetime before 1677-09-21 or
+	            after 2262-04-11 fails)
+	timespan -> PrimitiveArray[int64] of nanoseconds
+	decimal  -> BinaryArray of the text of the decimals, as Kusto decimals have 34 significant digits
+	string   -> BinaryArray
+	guid     -> PrimitiveArray[[16]byte]
+	dynamic  -> BinaryArray of the JSON text
+*/
+package columnar
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Field describes a column of a record batch.
+type Field struct {
+	Name string
+	Type types.Column
+}
+
+// Array is a column of a record batch.
+type Array interface {
+	// Len returns the amount of values in the array.
+	Len() int
+	// IsNull reports whether the value at index i is null.
+	IsNull(i int) bool
+	// NullCount returns the amount of null values in the array.
+	NullCount() int
+	// Validity returns the validity bitmap of the array.
+	Validity() Bitmap
+
+	appendValue(k value.Kusto) error
+}
+
+// Bitmap is a validity bitmap, with bits ordered least-significant first.
+type Bitmap []byte
+
+// Get returns the bit at index i.
+func (b Bitmap) Get(i int) bool {
+	return b[i/8]&(1<<(i%8)) != 0
+}
+
+func (b *Bitmap) append(n int, set bool) {
+	if n%8 == 0 {
+		*b = append(*b, 0)
+	}
+	if set {
+		(*b)[n/8] |= 1 << (n % 8)
+	}
+}
+
+// validity tracks the nulls of an array.
+type validity struct {
+	bitmap Bitmap
+	length int
+	nulls  int
+}
+
+func (v *validity) Len() int          { return v.length }
+func (v *validity) IsNull(i int) bool { return !v.bitmap.Get(i) }
+func (v *validity) NullCount() int    { return v.nulls }
+func (v *validity) Validity() Bitmap  { return v.bitmap }
+func (v *validity) appendValid(ok bool) {
+	v.bitmap.append(v.length, ok)
+	v.length++
+	if !ok {
+		v.nulls++
+	}
+}
+
+// PrimitiveArray holds fixed-size values. Null slots hold the zero value.
+type PrimitiveArray[T any] struct {
+	validity
+	Values []T
+	get    func(k value.Kusto) (T, bool, error)
+}
+
+// Value returns the value at index i. It is the zero value if the value is null.
+func (a *PrimitiveArray[T]) Value(i int) T {
+	return a.Values[i]
+}
+
+func (a *PrimitiveArray[T]) appendValue(k value.Kusto) error {
+	v, ok, err := a.get(k)
+	if err != nil {
+		return err
+	}
+	a.Values = append(a.Values, v)
+	a.appendValid(ok)
+	return nil
+}
+
+// BinaryArray holds variable-length values in a single buffer. The value at index i is Data[Offsets[i]:Offsets[i+1]].
+type BinaryArray struct {
+	validity
+	Offsets []int32
+	Data    []byte
+	get     func(k value.Kusto) ([]byte, bool)
+}
+
+// Value returns the bytes of the value at index i. They are empty if the value is null.
+func (a *BinaryArray) Value(i int) []byte {
+	return a.Data[a.Offsets[i]:a.Offsets[i+1]]
+}
+
+// String returns the value at index i as a string.
+func (a *BinaryArray) String(i int) string {
+	return string(a.Value(i))
+}
+
+func (a *BinaryArray) appendValue(k value.Kusto) error {
+	b, ok := a.get(k)
+	if len(a.Data)+len(b) > int(^uint32(0)>>1) {
+		return errors.ES(errors.OpTableAccess, errors.KLimitsExceeded, "column data exceeds the maximum size of a record batch column")
+	}
+	a.Data = append(a.Data, b...)
+	a.Offsets = append(a.Offsets, int32(len(a.Data)))
+	a.appendValid(ok)
+	return nil
+}
+
+// RecordBatch is a set of equal-length columns.
+type RecordBatch struct {
+	Schema  []Field
+	Columns []Array
+	NumRows int
+}
+
+// Column returns the column with the given name, or nil if there is no such column.
+func (r *RecordBatch) Column(name string) Array {
+	for i, f := range r.Schema {
+		if f.Name == name {
+			return r.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Builder accumulates rows into a record batch.
+type Builder struct {
+	schema  []Field
+	columns []Array
+	rows    int
+}
+
+// NewBuilder returns a builder for the given columns.
+func NewBuilder(cols []query.Column) (*Builder, error) {
+	b := &Builder{}
+	b.reset(cols)
+	for i, c := range b.columns {
+		if c == nil {
+			return nil, errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "column %s has unsupported type %s", cols[i].Name(), cols[i].Type())
+		}
+	}
+	return b, nil
+}
+
+func (b *Builder) reset(cols []query.Column) {
+	if cols != nil {
+		b.schema = make([]Field, len(cols))
+		for i, c := range cols {
+			b.schema[i] = Field{Name: c.Name(), Type: c.Type()}
+		}
+	}
+	b.columns = make([]Array, len(b.schema))
+	for i, f := range b.schema {
+		b.columns[i] = newArray(f.Type)
+	}
+	b.rows = 0
+}
+
+// Append adds a row to the batch.
+func (b *Builder) Append(row query.Row) error {
+	values := row.Values()
+	if len(values) != len(b.columns) {
+		return errors.ES(errors.OpTableAccess, errors.KInternal, "row %d has %d values, expected %d", row.Index(), len(values), len(b.columns))
+	}
+	for i, v := range values {
+		if err := b.columns[i].appendValue(v); err != nil {
+			return err
+		}
+	}
+	b.rows++
+	return nil
+}
+
+// Len returns the amount of rows appended since the last call to NewRecordBatch.
+func (b *Builder) Len() int {
+	return b.rows
+}
+
+// NewRecordBatch returns the accumulated rows as a record batch, and resets the builder.
+func (b *Builder) NewRecordBatch() *RecordBatch {
+	r := &RecordBatch{Schema: b.schema, Columns: b.columns, NumRows: b.rows}
+	b.reset(nil)
+	return r
+}
+
+// FromTable converts a table into a single record batch.
+func FromTable(t query.Table) (*RecordBatch, error) {
+	b, err := NewBuilder(t.Columns())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range t.Rows() {
+		if err := b.Append(r); err != nil {
+			return nil, err
+		}
+	}
+	return b.NewRecordBatch(), nil
+}
+
+// RecordBatchResult is a record batch, or the error that occurred while building it.
+type RecordBatchResult struct {
+	Batch *RecordBatch
+	Err   error
+}
+
+// FromIterativeTable converts the rows of a table into record batches of up to batchSize rows, as they arrive.
+// The channel is closed after the last batch, after an error, or once ctx is done, which stops the conversion when the batches
+// aren't read anymore; close the dataset of the table to release it then.
+func FromIterativeTable(ctx context.Context, t query.IterativeTable, batchSize int) (<-chan RecordBatchResult, error) {
+	if batchSize <= 0 {
+		return nil, errors.ES(errors.OpTableAccess, errors.KClientArgs, "batch size must be positive, got %d", batchSize)
+	}
+	b, err := NewBuilder(t.Columns())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan RecordBatchResult, 1)
+	go func() {
+		defer close(out)
+		send := func(res RecordBatchResult) bool {
+			select {
+			case out <- res:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			var res query.RowResult
+			var ok bool
+			select {
+			case res, ok = <-t.Rows():
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				break
+			}
+			if res.Err() != nil {
+				send(RecordBatchResult{Err: res.Err()})
+				return
+			}
+			if err := b.Append(res.Row()); err != nil {
+				send(RecordBatchResult{Err: err})
+				return
+			}
+			if b.Len() == batchSize && !send(RecordBatchResult{Batch: b.NewRecordBatch()}) {
+				return
+			}
+		}
+		if b.Len() > 0 {
+			send(RecordBatchResult{Batch: b.NewRecordBatch()})
+		}
+	}()
+	return out, nil
+}
+
+func newArray(t types.Column) Array {
+	switch t {
+	case types.Bool:
+		return &PrimitiveArray[bool]{get: infallible(pointerGetter[bool, *value.Bool])}
+	case types.Int:
+		return &PrimitiveArray[int32]{get: infallible(pointerGetter[int32, *value.Int])}
+	case types.Long:
+		return &PrimitiveArray[int64]{get: infallible(pointerGetter[int64, *value.Long])}
+	case types.Real:
+		return &PrimitiveArray[float64]{get: infallible(pointerGetter[float64, *value.Real])}
+	case types.DateTime:
+		return &PrimitiveArray[int64]{get: func(k value.Kusto) (int64, bool, error) {
+			t, ok := pointerGetter[time.Time, *value.DateTime](k)
+			if !ok {
+				return 0, false, nil
+			}
+			if t.Before(minTimestamp) || t.After(maxTimestamp) {
+				return 0, false, errors.ES(errors.OpTableAccess, errors.KLimitsExceeded,
+					"datetime %s is out of the range of nanosecond timestamps, %s to %s", t.Format(time.RFC3339Nano),
+					minTimestamp.Format(time.RFC3339Nano), maxTimestamp.Format(time.RFC3339Nano))
+			}
+			return t.UnixNano(), true, nil
+		}}
+	case types.Timespan:
+		return &PrimitiveArray[int64]{get: infallible(func(k value.Kusto) (int64, bool) {
+			d, ok := pointerGetter[time.Duration, *value.Timespan](k)
+			return int64(d), ok
+		})}
+	case types.GUID:
+		return &PrimitiveArray[[16]byte]{get: infallible(func(k value.Kusto) ([16]byte, bool) {
+			g, ok := k.(*value.GUID)
+			if !ok || g.Ptr() == nil {
+				return [16]byte{}, false
+			}
+			return *g.Ptr(), true
+		})}
+	case types.String, types.Decimal:
+		return &BinaryArray{Offsets: []int32{0}, get: func(k value.Kusto) ([]byte, bool) {
+			if value.IsNull(k) {
+				return nil, false
+			}
+			return []byte(k.String()), true
+		}}
+	case types.Dynamic:
+		return &BinaryArray{Offsets: []int32{0}, get: func(k value.Kusto) ([]byte, bool) {
+			d, ok := k.(*value.Dynamic)
+			if !ok || d.Value == nil {
+				return nil, false
+			}
+			return d.Value, true
+		}}
+	default:
+		return nil
+	}
+}
+
+// minTimestamp and maxTimestamp bound the datetimes that nanoseconds since the Unix epoch can hold in an int64. Kusto datetimes
+// range from the year 1 to 9999.
+var (
+	minTimestamp = time.Unix(0, math.MinInt64).UTC()
+	maxTimestamp = time.Unix(0, math.MaxInt64).UTC()
+)
+
+// infallible adapts a getter of values that can't fail to convert to the getter of a PrimitiveArray.
+func infallible[T any](get func(k value.Kusto) (T, bool)) func(k value.Kusto) (T, bool, error) {
+	return func(k value.Kusto) (T, bool, error) {
+		v, ok := get(k)
+		return v, ok, nil
+	}
+}
+
+// pointerGetter returns the value held by a Kusto value of type K, and whether it is non-null.
+func pointerGetter[T any, K interface{ Ptr() *T }](k value.Kusto) (T, bool) {
+	var zero T
+	p, ok := k.(K)
+	if !ok || p.Ptr() == nil {
+		return zero, false
+	}
+	return *p.Ptr(), true
+}
+
+// FromDataset converts every table of a dataset into a record batch.
+func FromDataset(ds query.Dataset) ([]*RecordBatch, error) {
+	tables := ds.Tables()
+	batches := make([]*RecordBatch, 0, len(tables))
+	for _, t := range tables {
+		b, err := FromTable(t)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, nil
+}
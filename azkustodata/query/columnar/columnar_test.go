@@ -0,0 +1,169 @@
+package columnar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTable(t *testing.T) query.Table {
+	cols := []query.Column{
+		query.NewColumn(0, "b", types.Bool),
+		query.NewColumn(1, "l", types.Long),
+		query.NewColumn(2, "r", types.Real),
+		query.NewColumn(3, "s", types.String),
+		query.NewColumn(4, "dt", types.DateTime),
+		query.NewColumn(5, "ts", types.Timespan),
+		query.NewColumn(6, "g", types.GUID),
+		query.NewColumn(7, "d", types.Dynamic),
+		query.NewColumn(8, "dec", types.Decimal),
+		query.NewColumn(9, "i", types.Int),
+	}
+	ds := query.NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	base := query.NewBaseTable(ds, 0, "1", "T", "PrimaryResult", cols)
+	g := uuid.MustParse("123e27de-1e4e-49d9-b579-fe0b331d3642")
+	rows := []query.Row{
+		query.NewRow(base, 0, value.Values{value.NewBool(true), value.NewLong(1), value.NewReal(1.5), value.NewString("ab"),
+			value.NewDateTime(time.Unix(0, 100).UTC()), value.NewTimespan(time.Second), value.NewGUID(g), value.NewDynamic([]byte(`{"a":1}`)),
+			value.DecimalFromString("1.25"), value.NewInt(7)}),
+		query.NewRow(base, 1, value.Values{value.NewNullBool(), value.NewNullLong(), value.NewNullReal(), value.NewString("c"),
+			value.NewNullDateTime(), value.NewNullTimespan(), value.NewNullGUID(), value.NewNullDynamic(), value.NewNullDecimal(), value.NewNullInt()}),
+	}
+	return query.NewTable(base, rows)
+}
+
+func TestFromTable(t *testing.T) {
+	t.Parallel()
+
+	rb, err := FromTable(testTable(t))
+	require.NoError(t, err)
+	require.Equal(t, 2, rb.NumRows)
+	require.Len(t, rb.Columns, 10)
+
+	b := rb.Column("b").(*PrimitiveArray[bool])
+	assert.True(t, b.Value(0))
+	assert.True(t, b.IsNull(1))
+	assert.Equal(t, 1, b.NullCount())
+
+	l := rb.Column("l").(*PrimitiveArray[int64])
+	assert.Equal(t, []int64{1, 0}, l.Values)
+	assert.Equal(t, Bitmap{0b01}, l.Validity())
+
+	s := rb.Column("s").(*BinaryArray)
+	assert.Equal(t, []int32{0, 2, 3}, s.Offsets)
+	assert.Equal(t, "ab", s.String(0))
+	assert.Equal(t, "c", s.String(1))
+	assert.Equal(t, 0, s.NullCount())
+
+	dt := rb.Column("dt").(*PrimitiveArray[int64])
+	assert.Equal(t, int64(100), dt.Value(0))
+
+	ts := rb.Column("ts").(*PrimitiveArray[int64])
+	assert.Equal(t, int64(time.Second), ts.Value(0))
+
+	g := rb.Column("g").(*PrimitiveArray[[16]byte])
+	assert.Equal(t, [16]byte(uuid.MustParse("123e27de-1e4e-49d9-b579-fe0b331d3642")), g.Value(0))
+
+	d := rb.Column("d").(*BinaryArray)
+	assert.Equal(t, `{"a":1}`, d.String(0))
+	assert.True(t, d.IsNull(1))
+	assert.Empty(t, d.Value(1))
+
+	dec := rb.Column("dec").(*BinaryArray)
+	assert.Equal(t, "1.25", dec.String(0))
+
+	i := rb.Column("i").(*PrimitiveArray[int32])
+	assert.Equal(t, int32(7), i.Value(0))
+
+	assert.Nil(t, rb.Column("missing"))
+}
+
+func TestBuilderBatches(t *testing.T) {
+	t.Parallel()
+
+	table := testTable(t)
+	b, err := NewBuilder(table.Columns())
+	require.NoError(t, err)
+
+	for i := 0; i < 9; i++ {
+		require.NoError(t, b.Append(table.Rows()[i%2]))
+	}
+	first := b.NewRecordBatch()
+	assert.Equal(t, 9, first.NumRows)
+	assert.Equal(t, 9, first.Columns[0].Len())
+	assert.Equal(t, Bitmap{0b01010101, 0b1}, first.Columns[0].Validity())
+
+	assert.Equal(t, 0, b.Len())
+	require.NoError(t, b.Append(table.Rows()[0]))
+	second := b.NewRecordBatch()
+	assert.Equal(t, 1, second.NumRows)
+	assert.Equal(t, 9, first.NumRows)
+}
+
+func TestDateTimeOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	cols := []query.Column{query.NewColumn(0, "dt", types.DateTime)}
+	ds := query.NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	base := query.NewBaseTable(ds, 0, "1", "T", "PrimaryResult", cols)
+
+	b, err := NewBuilder(cols)
+	require.NoError(t, err)
+	require.NoError(t, b.Append(query.NewRow(base, 0, value.Values{value.NewDateTime(time.Date(2262, 1, 1, 0, 0, 0, 0, time.UTC))})))
+
+	for _, dt := range []time.Time{time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)} {
+		err = b.Append(query.NewRow(base, 1, value.Values{value.NewDateTime(dt)}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "out of the range of nanosecond timestamps")
+	}
+}
+
+type fakeIterativeTable struct {
+	query.BaseTable
+	rows chan query.RowResult
+}
+
+func (f fakeIterativeTable) Rows() <-chan query.RowResult { return f.rows }
+
+func (f fakeIterativeTable) ToTable() (query.Table, error) { return nil, nil }
+
+func TestFromIterativeTableCanceled(t *testing.T) {
+	t.Parallel()
+
+	table := testTable(t)
+	rows := make(chan query.RowResult, 10)
+	for i := 0; i < 10; i++ {
+		rows <- query.RowResultSuccess(table.Rows()[0])
+	}
+	it := fakeIterativeTable{BaseTable: table, rows: rows}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out, err := FromIterativeTable(ctx, it, 1)
+	require.NoError(t, err)
+
+	res := <-out
+	require.NoError(t, res.Err)
+	assert.Equal(t, 1, res.Batch.NumRows)
+
+	// The batches aren't read anymore: the conversion stops once ctx is canceled, instead of blocking on sending them.
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range out {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel wasn't closed after ctx was canceled")
+	}
+}
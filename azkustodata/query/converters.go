@@ -0,0 +1,86 @@
+package query
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// converterKey identifies a converter by the Kusto type of the column and the Go type of the destination field.
+type converterKey struct {
+	colType   types.Column
+	fieldType reflect.Type
+}
+
+// converterFunc stores the converted value in v, which is of the type the converter was registered for.
+type converterFunc func(k value.Kusto, v reflect.Value) error
+
+var converters = map[converterKey]converterFunc{}
+var convertersLock = sync.RWMutex{}
+
+// RegisterConverter registers a function that converts values of columns with the Kusto type colType into the Go type T.
+// It is used by Row.ToStruct, ToStructs and ToStructsIterative whenever a column of that type is decoded into a field of type T or *T,
+// taking precedence over the built-in conversions.
+// Registering a converter for the same types again replaces it.
+//
+// For example, to decode datetime columns into a custom civil time type:
+//
+//	query.RegisterConverter(types.DateTime, func(k value.Kusto) (CivilTime, error) {
+//		t := k.(*value.DateTime).Ptr()
+//		if t == nil {
+//			return CivilTime{}, nil
+//		}
+//		return CivilTimeOf(*t), nil
+//	})
+func RegisterConverter[T any](colType types.Column, f func(k value.Kusto) (T, error)) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	converters[converterKey{colType: colType, fieldType: reflect.TypeOf((*T)(nil)).Elem()}] = func(k value.Kusto, v reflect.Value) error {
+		out, err := f(k)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&out).Elem())
+		return nil
+	}
+}
+
+// UnregisterConverter removes the converter registered for colType and T, if any.
+func UnregisterConverter[T any](colType types.Column) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	delete(converters, converterKey{colType: colType, fieldType: reflect.TypeOf((*T)(nil)).Elem()})
+}
+
+// customConvert converts k into v using a registered converter.
+// It returns false if there is no converter registered for the types.
+func customConvert(colType types.Column, k value.Kusto, v reflect.Value) (bool, error) {
+	convertersLock.RLock()
+	defer convertersLock.RUnlock()
+	if len(converters) == 0 {
+		return false, nil
+	}
+
+	if f, ok := converters[converterKey{colType: colType, fieldType: v.Type()}]; ok {
+		return true, f(k, v)
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if f, ok := converters[converterKey{colType: colType, fieldType: v.Type().Elem()}]; ok {
+			if value.IsNull(k) {
+				v.Set(reflect.Zero(v.Type()))
+				return true, nil
+			}
+			ptr := reflect.New(v.Type().Elem())
+			if err := f(k, ptr.Elem()); err != nil {
+				return true, err
+			}
+			v.Set(ptr)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,133 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// civilDate is a user type that has no built-in conversion.
+type civilDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// upperString is only used by the tests of the converter registry, so registering a converter for it doesn't affect other tests.
+type upperString string
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter(types.DateTime, func(k value.Kusto) (civilDate, error) {
+		dt := k.(*value.DateTime).Ptr()
+		if dt == nil {
+			return civilDate{}, nil
+		}
+		return civilDate{Year: dt.Year(), Month: dt.Month(), Day: dt.Day()}, nil
+	})
+	defer UnregisterConverter[civilDate](types.DateTime)
+
+	RegisterConverter(types.String, func(k value.Kusto) (upperString, error) {
+		if k.String() == "bad" {
+			return "", fmt.Errorf("bad value")
+		}
+		return upperString("UP:" + k.String()), nil
+	})
+	defer UnregisterConverter[upperString](types.String)
+
+	cols := Columns{
+		NewColumn(0, "Date", types.DateTime),
+		NewColumn(1, "DatePtr", types.DateTime),
+		NewColumn(2, "Name", types.String),
+		NewColumn(3, "Raw", types.DateTime),
+	}
+	ts := time.Date(2024, 2, 29, 13, 0, 0, 0, time.UTC)
+
+	type rec struct {
+		Date    civilDate
+		DatePtr *civilDate
+		Name    upperString
+		Raw     time.Time
+	}
+
+	var r rec
+	row := NewRowFromParts(cols, nil, 0, value.Values{value.NewDateTime(ts), value.NewDateTime(ts), value.NewString("a"), value.NewDateTime(ts)})
+	require.NoError(t, row.ToStruct(&r))
+	assert.Equal(t, civilDate{Year: 2024, Month: time.February, Day: 29}, r.Date)
+	require.NotNil(t, r.DatePtr)
+	assert.Equal(t, r.Date, *r.DatePtr)
+	assert.Equal(t, upperString("UP:a"), r.Name)
+	assert.Equal(t, ts, r.Raw)
+
+	r = rec{DatePtr: &civilDate{}}
+	row = NewRowFromParts(cols, nil, 0, value.Values{value.NewNullDateTime(), value.NewNullDateTime(), value.NewString("a"), value.NewNullDateTime()})
+	require.NoError(t, row.ToStruct(&r))
+	assert.Nil(t, r.DatePtr)
+
+	row = NewRowFromParts(cols, nil, 0, value.Values{value.NewDateTime(ts), value.NewDateTime(ts), value.NewString("bad"), value.NewDateTime(ts)})
+	assert.ErrorContains(t, row.ToStruct(&r), "bad value")
+
+	UnregisterConverter[upperString](types.String)
+	row = NewRowFromParts(cols, nil, 0, value.Values{value.NewDateTime(ts), value.NewDateTime(ts), value.NewString("a"), value.NewDateTime(ts)})
+	require.NoError(t, row.ToStruct(&r))
+	assert.Equal(t, upperString("a"), r.Name)
+}
+
+func TestToStructNullable(t *testing.T) {
+	t.Parallel()
+
+	cols := Columns{
+		NewColumn(0, "Count", types.Long),
+		NewColumn(1, "When", types.DateTime),
+	}
+
+	type rec struct {
+		Count value.Nullable[int64]
+		When  value.Nullable[time.Time]
+	}
+
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var r rec
+	row := NewRowFromParts(cols, nil, 0, value.Values{value.NewLong(4), value.NewNullDateTime()})
+	require.NoError(t, row.ToStruct(&r))
+	assert.Equal(t, rec{Count: value.NewNullable[int64](4), When: value.Null[time.Time]()}, r)
+
+	row = NewRowFromParts(cols, nil, 0, value.Values{value.NewNullLong(), value.NewDateTime(ts)})
+	require.NoError(t, row.ToStruct(&r))
+	assert.Equal(t, rec{Count: value.Null[int64](), When: value.NewNullable(ts)}, r)
+}
+
+func TestToStructGUID(t *testing.T) {
+	t.Parallel()
+
+	cols := Columns{
+		NewColumn(0, "ID", types.GUID),
+		NewColumn(1, "Ptr", types.GUID),
+		NewColumn(2, "Null", types.GUID),
+		NewColumn(3, "Text", types.GUID),
+		NewColumn(4, "Raw", types.GUID),
+	}
+
+	type rec struct {
+		ID   uuid.UUID
+		Ptr  *uuid.UUID
+		Null uuid.NullUUID
+		Text string
+		Raw  [16]byte
+	}
+
+	id := uuid.MustParse("8f26b3a5-3c25-4b57-9a4b-06ad57d8ae7c")
+	var r rec
+	row := NewRowFromParts(cols, nil, 0, value.Values{value.NewGUID(id), value.NewGUID(id), value.NewGUID(id), value.NewGUID(id), value.NewGUID(id)})
+	require.NoError(t, row.ToStruct(&r))
+	assert.Equal(t, rec{ID: id, Ptr: &id, Null: uuid.NullUUID{UUID: id, Valid: true}, Text: id.String(), Raw: id}, r)
+
+	row = NewRowFromParts(cols, nil, 0, value.Values{value.NewNullGUID(), value.NewNullGUID(), value.NewNullGUID(), value.NewNullGUID(), value.NewNullGUID()})
+	require.NoError(t, row.ToStruct(&r))
+	assert.Equal(t, rec{}, r)
+}
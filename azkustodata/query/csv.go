@@ -0,0 +1,115 @@
+package query
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+type csvOptions struct {
+	header     bool
+	null       string
+	timeFormat string
+	comma      rune
+}
+
+// CSVOption is an optional argument to WriteCSV.
+type CSVOption func(o *csvOptions)
+
+// CSVNoHeader omits the header line with the column names.
+func CSVNoHeader() CSVOption {
+	return func(o *csvOptions) {
+		o.header = false
+	}
+}
+
+// CSVNullValue sets the text written for null values. The default is an empty field.
+func CSVNullValue(null string) CSVOption {
+	return func(o *csvOptions) {
+		o.null = null
+	}
+}
+
+// CSVTimeFormat sets the layout used to format datetime values, as accepted by time.Time.Format. The default is time.RFC3339Nano.
+func CSVTimeFormat(layout string) CSVOption {
+	return func(o *csvOptions) {
+		o.timeFormat = layout
+	}
+}
+
+// CSVComma sets the field delimiter. The default is ','.
+func CSVComma(comma rune) CSVOption {
+	return func(o *csvOptions) {
+		o.comma = comma
+	}
+}
+
+// WriteCSV writes the rows of a table, an iterative table, a dataset with a single primary table, or a slice of rows to w as CSV.
+// Rows of an iterative table are written as they arrive, so the results don't have to fit in memory.
+// Dynamic values are written as their JSON text, and timespans in the Kusto format.
+func WriteCSV(w io.Writer, data interface{}, options ...CSVOption) error {
+	opts := csvOptions{header: true, timeFormat: time.RFC3339Nano, comma: ','}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	src, err := newRowSource(data)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.comma
+
+	if opts.header {
+		header := make([]string, len(src.columns))
+		for i, c := range src.columns {
+			header[i] = c.Name()
+		}
+		if err := cw.Write(header); err != nil {
+			return errors.E(errors.OpTableAccess, errors.KIO, err)
+		}
+	}
+
+	for {
+		row, ok, err := src.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		values := row.Values()
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = formatCSV(v, &opts)
+		}
+		if err := cw.Write(record); err != nil {
+			return errors.E(errors.OpTableAccess, errors.KIO, err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return errors.E(errors.OpTableAccess, errors.KIO, err)
+	}
+	return nil
+}
+
+func formatCSV(k value.Kusto, opts *csvOptions) string {
+	if value.IsNull(k) {
+		return opts.null
+	}
+	switch v := k.(type) {
+	case *value.DateTime:
+		return v.Ptr().Format(opts.timeFormat)
+	case *value.Timespan:
+		return v.Marshal()
+	default:
+		return k.String()
+	}
+}
@@ -0,0 +1,52 @@
+package query
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		options  []CSVOption
+		expected string
+	}{
+		{
+			name: "Default",
+			expected: "Name,Count,Ratio,Ok,When,Took,Bag,Price\n" +
+				"\"a,b\",3,0.5,true,2024-01-02T03:04:05.0000006Z,01:30:00,\"{\"\"k\"\":[1,2]}\",10.5\n" +
+				",,,,,,,\n",
+		},
+		{
+			name:    "Options",
+			options: []CSVOption{CSVNoHeader(), CSVNullValue("null"), CSVTimeFormat("2006-01-02"), CSVComma(';')},
+			expected: "a,b;3;0.5;true;2024-01-02;01:30:00;\"{\"\"k\"\":[1,2]}\";10.5\n" +
+				";null;null;null;null;null;null;null\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buf := &bytes.Buffer{}
+			require.NoError(t, WriteCSV(buf, newExportTestTable(), tt.options...))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteCSVRows(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteCSV(buf, newExportTestTable().Rows()[:1], CSVNoHeader()))
+	assert.Equal(t, "\"a,b\",3,0.5,true,2024-01-02T03:04:05.0000006Z,01:30:00,\"{\"\"k\"\":[1,2]}\",10.5\n", buf.String())
+
+	assert.Error(t, WriteCSV(buf, 5))
+}
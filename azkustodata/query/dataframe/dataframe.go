@@ -0,0 +1,407 @@
+/*
+Package dataframe holds query results column-wise, for code that manipulates whole columns rather than rows.
+
+A Frame is a list of named, typed Series of equal length. Frames are built from query results with FromTable or
+FromIterativeTable, and converted back into a query.Table with Frame.Table, or into ingestion payloads with
+Frame.WriteCSV and Frame.WriteJSONLines.
+
+The package doesn't depend on a dataframe library. Frames are converted to and from the DataFrame of gota by the gotadf
+package, and to and from the matrices of gonum by the gonummat package, which keep those dependencies out of the programs
+that don't use them:
+
+	df, err := gotadf.ToDataFrame(frame)
+	m, err := gonummat.ToDense(frame, "x", "y")
+*/
+package dataframe
+
+import (
+	"context"
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Series is a named column of values of a single Kusto type.
+type Series struct {
+	// Name is the name of the column.
+	Name string
+	// Type is the Kusto type of the values.
+	Type types.Column
+
+	values value.Values
+}
+
+// NewSeries returns a series of the given values, which must all be of type t.
+func NewSeries(name string, t types.Column, values value.Values) (*Series, error) {
+	if value.Default(t) == nil {
+		return nil, errors.ES(errors.OpTableAccess, errors.KClientArgs, "series %s has an invalid type %s", name, t)
+	}
+	for i, v := range values {
+		if v == nil || v.GetType() != t {
+			return nil, errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "value %d of series %s is not of type %s", i, name, t)
+		}
+	}
+	return &Series{Name: name, Type: t, values: values}, nil
+}
+
+// Float64Series returns a real series of the given values. NaN values are stored as nulls.
+func Float64Series(name string, values []float64) *Series {
+	s := &Series{Name: name, Type: types.Real, values: make(value.Values, len(values))}
+	for i, v := range values {
+		if math.IsNaN(v) {
+			s.values[i] = value.NewNullReal()
+		} else {
+			s.values[i] = value.NewReal(v)
+		}
+	}
+	return s
+}
+
+// Len returns the amount of values in the series.
+func (s *Series) Len() int {
+	return len(s.values)
+}
+
+// Value returns the value at index i.
+func (s *Series) Value(i int) value.Kusto {
+	return s.values[i]
+}
+
+// Values returns the values of the series. The slice must not be modified.
+func (s *Series) Values() value.Values {
+	return s.values
+}
+
+// IsNull reports whether the value at index i is null.
+func (s *Series) IsNull(i int) bool {
+	return value.IsNull(s.values[i])
+}
+
+// Interfaces returns the native Go values of the series, with nil for nulls.
+func (s *Series) Interfaces() []interface{} {
+	out := make([]interface{}, len(s.values))
+	for i, v := range s.values {
+		if !value.IsNull(v) {
+			out[i] = nativeValue(v)
+		}
+	}
+	return out
+}
+
+// Strings returns the string representation of the values, with empty strings for nulls.
+func (s *Series) Strings() []string {
+	out := make([]string, len(s.values))
+	for i, v := range s.values {
+		out[i] = formatValue(v)
+	}
+	return out
+}
+
+// Float64s returns the values of a numeric series as float64, with NaN for nulls.
+// int, long, real and decimal series are supported, as well as timespans (in seconds) and datetimes (in seconds since the Unix epoch).
+func (s *Series) Float64s() ([]float64, error) {
+	out := make([]float64, len(s.values))
+	for i, v := range s.values {
+		f, ok := toFloat64(v)
+		if !ok {
+			return nil, errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "series %s of type %s is not numeric", s.Name, s.Type)
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+func (s *Series) subset(idx []int) *Series {
+	values := make(value.Values, len(idx))
+	for i, j := range idx {
+		values[i] = s.values[j]
+	}
+	return &Series{Name: s.Name, Type: s.Type, values: values}
+}
+
+func toFloat64(k value.Kusto) (float64, bool) {
+	if value.IsNull(k) {
+		switch k.GetType() {
+		case types.Int, types.Long, types.Real, types.Decimal, types.Timespan, types.DateTime:
+			return math.NaN(), true
+		default:
+			return 0, false
+		}
+	}
+	switch v := k.(type) {
+	case *value.Int:
+		return float64(*v.Ptr()), true
+	case *value.Long:
+		return float64(*v.Ptr()), true
+	case *value.Real:
+		return *v.Ptr(), true
+	case *value.Decimal:
+		f, _ := v.Ptr().Float64()
+		return f, true
+	case *value.Timespan:
+		return v.Ptr().Seconds(), true
+	case *value.DateTime:
+		// UnixNano overflows outside of 1677-2262, which Kusto datetimes span past.
+		t := v.Ptr()
+		return float64(t.Unix()) + float64(t.Nanosecond())/float64(time.Second), true
+	default:
+		return 0, false
+	}
+}
+
+func nativeValue(k value.Kusto) interface{} {
+	if d, ok := k.(*value.Dynamic); ok {
+		return string(d.Value)
+	}
+	v := reflect.ValueOf(k.GetValue())
+	if v.Kind() == reflect.Ptr {
+		return v.Elem().Interface()
+	}
+	return v.Interface()
+}
+
+func formatValue(k value.Kusto) string {
+	if value.IsNull(k) {
+		return ""
+	}
+	if t, ok := k.(*value.Timespan); ok {
+		return t.Marshal()
+	}
+	return k.String()
+}
+
+// Frame is a list of series of equal length.
+type Frame struct {
+	series []*Series
+	rows   int
+}
+
+// New returns a frame of the given series, which must have unique names and the same length.
+func New(series ...*Series) (*Frame, error) {
+	f := &Frame{}
+	for _, s := range series {
+		if f.Col(s.Name) != nil {
+			return nil, errors.ES(errors.OpTableAccess, errors.KClientArgs, "duplicate series %s", s.Name)
+		}
+		if err := f.Mutate(s); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// FromTable converts a table into a frame.
+func FromTable(t query.Table) (*Frame, error) {
+	f := newFrame(t.Columns())
+	for _, r := range t.Rows() {
+		if err := f.appendRow(r); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// FromIterativeTable reads all the rows of a table into a frame.
+func FromIterativeTable(t query.IterativeTable) (*Frame, error) {
+	f := newFrame(t.Columns())
+	for res := range t.Rows() {
+		if res.Err() != nil {
+			return nil, res.Err()
+		}
+		if err := f.appendRow(res.Row()); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func newFrame(cols query.Columns) *Frame {
+	f := &Frame{series: make([]*Series, len(cols))}
+	for i, c := range cols {
+		f.series[i] = &Series{Name: c.Name(), Type: c.Type()}
+	}
+	return f
+}
+
+func (f *Frame) appendRow(r query.Row) error {
+	values := r.Values()
+	if len(values) != len(f.series) {
+		return errors.ES(errors.OpTableAccess, errors.KInternal, "row %d has %d values, expected %d", r.Index(), len(values), len(f.series))
+	}
+	for i, v := range values {
+		f.series[i].values = append(f.series[i].values, v)
+	}
+	f.rows++
+	return nil
+}
+
+// Nrow returns the amount of rows in the frame.
+func (f *Frame) Nrow() int {
+	return f.rows
+}
+
+// Ncol returns the amount of series in the frame.
+func (f *Frame) Ncol() int {
+	return len(f.series)
+}
+
+// Names returns the names of the series, in order.
+func (f *Frame) Names() []string {
+	names := make([]string, len(f.series))
+	for i, s := range f.series {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Series returns the series at index i.
+func (f *Frame) Series(i int) *Series {
+	return f.series[i]
+}
+
+// Col returns the series with the given name, or nil if there is no such series.
+func (f *Frame) Col(name string) *Series {
+	for _, s := range f.series {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Mutate adds a series to the frame, replacing the series with the same name if there is one.
+func (f *Frame) Mutate(s *Series) error {
+	if len(f.series) > 0 && s.Len() != f.rows {
+		return errors.ES(errors.OpTableAccess, errors.KClientArgs, "series %s has %d values, expected %d", s.Name, s.Len(), f.rows)
+	}
+	f.rows = s.Len()
+	for i, existing := range f.series {
+		if existing.Name == s.Name {
+			f.series[i] = s
+			return nil
+		}
+	}
+	f.series = append(f.series, s)
+	return nil
+}
+
+// Select returns a frame with the named series, in the given order.
+func (f *Frame) Select(names ...string) (*Frame, error) {
+	out := &Frame{rows: f.rows}
+	for _, n := range names {
+		s := f.Col(n)
+		if s == nil {
+			return nil, errors.ES(errors.OpTableAccess, errors.KClientArgs, "frame has no series named %s", n)
+		}
+		out.series = append(out.series, s)
+	}
+	return out, nil
+}
+
+// Filter returns a frame with the rows for which keep returns true.
+func (f *Frame) Filter(keep func(f *Frame, row int) bool) *Frame {
+	var idx []int
+	for i := 0; i < f.rows; i++ {
+		if keep(f, i) {
+			idx = append(idx, i)
+		}
+	}
+	out := &Frame{series: make([]*Series, len(f.series)), rows: len(idx)}
+	for i, s := range f.series {
+		out.series[i] = s.subset(idx)
+	}
+	return out
+}
+
+// Records returns the frame as string records, with the series names as the first record.
+// Nulls are empty strings. This is the input of gota's dataframe.LoadRecords.
+func (f *Frame) Records() [][]string {
+	records := make([][]string, f.rows+1)
+	records[0] = f.Names()
+	for i := 1; i <= f.rows; i++ {
+		records[i] = make([]string, len(f.series))
+	}
+	for j, s := range f.series {
+		for i, v := range s.Strings() {
+			records[i+1][j] = v
+		}
+	}
+	return records
+}
+
+// Maps returns a map per row, from the series names to the native Go values, with nil for nulls.
+// This is the input of gota's dataframe.LoadMaps.
+func (f *Frame) Maps() []map[string]interface{} {
+	maps := make([]map[string]interface{}, f.rows)
+	for i := range maps {
+		maps[i] = make(map[string]interface{}, len(f.series))
+	}
+	for _, s := range f.series {
+		for i, v := range s.Interfaces() {
+			maps[i][s.Name] = v
+		}
+	}
+	return maps
+}
+
+// Matrix returns the named numeric series as a row-major matrix of float64, with NaN for nulls.
+// If no names are given, all the series are used. The result is the input of gonum's mat.NewDense.
+func (f *Frame) Matrix(names ...string) (rows, cols int, data []float64, err error) {
+	sel := f
+	if len(names) > 0 {
+		if sel, err = f.Select(names...); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	rows, cols = sel.rows, len(sel.series)
+	data = make([]float64, rows*cols)
+	for j, s := range sel.series {
+		vs, err := s.Float64s()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		for i, v := range vs {
+			data[i*cols+j] = v
+		}
+	}
+	return rows, cols, data, nil
+}
+
+// Table converts the frame back into a query.Table, so it can be used with the rest of the query package.
+func (f *Frame) Table() query.Table {
+	cols := make([]query.Column, len(f.series))
+	for i, s := range f.series {
+		cols[i] = query.NewColumn(i, s.Name, s.Type)
+	}
+
+	ds := query.NewBaseDataset(context.Background(), errors.OpTableAccess, "PrimaryResult")
+	base := query.NewBaseTable(ds, 0, "0", "PrimaryResult", "PrimaryResult", cols)
+	rows := make([]query.Row, f.rows)
+	for i := range rows {
+		values := make(value.Values, len(f.series))
+		for j, s := range f.series {
+			values[j] = s.values[i]
+		}
+		rows[i] = query.NewRow(base, i, values)
+	}
+	return query.NewTable(base, rows)
+}
+
+// WriteCSV writes the rows of the frame to w as CSV without a header, in the column order of the frame.
+// The output can be ingested as-is with the CSV data format.
+func (f *Frame) WriteCSV(w io.Writer) error {
+	return query.WriteCSV(w, f.Table(), query.CSVNoHeader())
+}
+
+// WriteJSONLines writes the rows of the frame to w as JSON Lines.
+// The output can be ingested with the JSON data format, mapping the properties by name.
+func (f *Frame) WriteJSONLines(w io.Writer) error {
+	return query.WriteJSONLines(w, f.Table())
+}
@@ -0,0 +1,150 @@
+package dataframe
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTable() query.Table {
+	cols := []query.Column{
+		query.NewColumn(0, "Name", types.String),
+		query.NewColumn(1, "Count", types.Long),
+		query.NewColumn(2, "Ratio", types.Real),
+		query.NewColumn(3, "Took", types.Timespan),
+	}
+	ds := query.NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	base := query.NewBaseTable(ds, 0, "1", "T", "PrimaryResult", cols)
+	rows := []query.Row{
+		query.NewRow(base, 0, value.Values{value.NewString("a"), value.NewLong(1), value.NewReal(0.5), value.NewTimespan(time.Minute)}),
+		query.NewRow(base, 1, value.Values{value.NewString("b"), value.NewNullLong(), value.NewReal(1.5), value.NewNullTimespan()}),
+		query.NewRow(base, 2, value.Values{value.NewString("c"), value.NewLong(3), value.NewNullReal(), value.NewTimespan(time.Second)}),
+	}
+	return query.NewTable(base, rows)
+}
+
+func TestFromTable(t *testing.T) {
+	t.Parallel()
+
+	f, err := FromTable(newTestTable())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, f.Nrow())
+	assert.Equal(t, 4, f.Ncol())
+	assert.Equal(t, []string{"Name", "Count", "Ratio", "Took"}, f.Names())
+	assert.Equal(t, types.Long, f.Col("Count").Type)
+	assert.Nil(t, f.Col("Missing"))
+
+	assert.Equal(t, []interface{}{int64(1), nil, int64(3)}, f.Col("Count").Interfaces())
+	assert.Equal(t, []string{"a", "b", "c"}, f.Col("Name").Strings())
+	assert.Equal(t, []string{"00:01:00", "", "00:00:01"}, f.Col("Took").Strings())
+	assert.True(t, f.Col("Count").IsNull(1))
+
+	counts, err := f.Col("Count").Float64s()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, counts[0])
+	assert.True(t, math.IsNaN(counts[1]))
+
+	_, err = f.Col("Name").Float64s()
+	assert.Error(t, err)
+}
+
+func TestFrameManipulation(t *testing.T) {
+	t.Parallel()
+
+	f, err := FromTable(newTestTable())
+	require.NoError(t, err)
+
+	sel, err := f.Select("Ratio", "Name")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ratio", "Name"}, sel.Names())
+
+	_, err = f.Select("Missing")
+	assert.Error(t, err)
+
+	filtered := f.Filter(func(f *Frame, row int) bool { return !f.Col("Count").IsNull(row) })
+	assert.Equal(t, 2, filtered.Nrow())
+	assert.Equal(t, []string{"a", "c"}, filtered.Col("Name").Strings())
+
+	require.NoError(t, f.Mutate(Float64Series("Ratio", []float64{1, 2, math.NaN()})))
+	assert.Equal(t, 4, f.Ncol())
+	assert.Equal(t, []interface{}{1.0, 2.0, nil}, f.Col("Ratio").Interfaces())
+
+	assert.Error(t, f.Mutate(Float64Series("Short", []float64{1})))
+
+	_, err = New(Float64Series("x", nil), Float64Series("x", nil))
+	assert.Error(t, err)
+
+	_, err = NewSeries("bad", types.Long, value.Values{value.NewString("a")})
+	assert.Error(t, err)
+}
+
+func TestFrameConversions(t *testing.T) {
+	t.Parallel()
+
+	f, err := FromTable(newTestTable())
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"Name", "Count", "Ratio", "Took"},
+		{"a", "1", "0.5", "00:01:00"},
+		{"b", "", "1.5", ""},
+		{"c", "3", "", "00:00:01"},
+	}, f.Records())
+
+	maps := f.Maps()
+	require.Len(t, maps, 3)
+	assert.Equal(t, map[string]interface{}{"Name": "b", "Count": nil, "Ratio": 1.5, "Took": nil}, maps[1])
+
+	rows, cols, data, err := f.Matrix("Count", "Ratio")
+	require.NoError(t, err)
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, 2, cols)
+	assert.Equal(t, 1.0, data[0])
+	assert.Equal(t, 0.5, data[1])
+	assert.True(t, math.IsNaN(data[2]))
+	assert.Equal(t, 3.0, data[4])
+
+	_, _, _, err = f.Matrix()
+	assert.Error(t, err)
+
+	table := f.Table()
+	require.Len(t, table.Rows(), 3)
+	assert.Equal(t, "Ratio", table.Columns()[2].Name())
+	assert.Equal(t, "c", table.Rows()[2].Values()[0].String())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, f.WriteCSV(buf))
+	assert.Equal(t, "a,1,0.5,00:01:00\nb,,1.5,\nc,3,,00:00:01\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, f.WriteJSONLines(buf))
+	assert.Contains(t, buf.String(), `{"Name":"b","Count":null,"Ratio":1.5,"Took":null}`)
+}
+
+func TestDateTimeFloat64s(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSeries("When", types.DateTime, value.Values{
+		value.NewDateTime(time.Unix(1, 500000000).UTC()),
+		value.NewDateTime(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)),
+		value.NewDateTime(time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)),
+	})
+	require.NoError(t, err)
+
+	got, err := s.Float64s()
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, got[0])
+	// Datetimes that nanoseconds since the epoch can't hold in an int64 don't overflow.
+	assert.Equal(t, float64(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC).Unix()), got[1])
+	assert.Equal(t, float64(time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC).Unix()), got[2])
+}
@@ -0,0 +1,50 @@
+/*
+Package gonummat converts frames of the dataframe package to and from the matrices of gonum (gonum.org/v1/gonum/mat).
+
+The conversions are in their own package, so that only the programs that use gonum depend on it:
+
+	frame, err := dataframe.FromTable(table)
+	m, err := gonummat.ToDense(frame, "x", "y")
+	var cov mat.SymDense
+	stat.CovarianceMatrix(&cov, m, nil)
+*/
+package gonummat
+
+import (
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query/dataframe"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ToDense returns the named series of f, or all of its series if no names are given, as a matrix with a row per row of f and
+// a column per series, in the format of Frame.Matrix: the series must be numeric, and nulls are NaN. gonum has no empty
+// matrix, so the matrix must have at least one row and one column.
+func ToDense(f *dataframe.Frame, names ...string) (*mat.Dense, error) {
+	rows, cols, data, err := f.Matrix(names...)
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 || cols == 0 {
+		return nil, errors.ES(errors.OpTableAccess, errors.KClientArgs, "gonum can't hold an empty matrix, got %d rows and %d columns", rows, cols)
+	}
+	return mat.NewDense(rows, cols, data), nil
+}
+
+// FromMatrix returns a frame with a real series per column of m, named after names, which must have a name per column. NaN
+// values become nulls.
+func FromMatrix(m mat.Matrix, names ...string) (*dataframe.Frame, error) {
+	rows, cols := m.Dims()
+	if len(names) != cols {
+		return nil, errors.ES(errors.OpTableAccess, errors.KClientArgs, "the matrix has %d columns, got %d names", cols, len(names))
+	}
+
+	columns := make([]*dataframe.Series, cols)
+	for j := range columns {
+		values := make([]float64, rows)
+		for i := range values {
+			values[i] = m.At(i, j)
+		}
+		columns[j] = dataframe.Float64Series(names[j], values)
+	}
+	return dataframe.New(columns...)
+}
@@ -0,0 +1,74 @@
+package gonummat
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query/dataframe"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestToDense(t *testing.T) {
+	t.Parallel()
+
+	name, err := dataframe.NewSeries("Name", types.String, value.Values{value.NewString("a"), value.NewString("b")})
+	require.NoError(t, err)
+	count, err := dataframe.NewSeries("Count", types.Long, value.Values{value.NewLong(1), value.NewNullLong()})
+	require.NoError(t, err)
+	f, err := dataframe.New(name, count, dataframe.Float64Series("Ratio", []float64{0.5, 1.5}))
+	require.NoError(t, err)
+
+	m, err := ToDense(f, "Ratio", "Count")
+	require.NoError(t, err)
+	rows, cols := m.Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 2, cols)
+	assert.Equal(t, []float64{0.5, 1.5}, mat.Col(nil, 0, m))
+	assert.Equal(t, 1.0, m.At(0, 1))
+	assert.True(t, math.IsNaN(m.At(1, 1)))
+
+	_, err = ToDense(f)
+	assert.Error(t, err, "the string series isn't numeric")
+
+	empty, err := dataframe.New(dataframe.Float64Series("Ratio", nil))
+	require.NoError(t, err)
+	_, err = ToDense(empty)
+	e, ok := errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.KClientArgs, e.Kind)
+}
+
+func TestFromMatrix(t *testing.T) {
+	t.Parallel()
+
+	m := mat.NewDense(2, 2, []float64{1, 2, math.NaN(), 4})
+	f, err := FromMatrix(m, "x", "y")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, f.Names())
+	assert.Equal(t, types.Real, f.Col("x").Type)
+	assert.Equal(t, []interface{}{1.0, nil}, f.Col("x").Interfaces())
+	assert.Equal(t, []interface{}{2.0, 4.0}, f.Col("y").Interfaces())
+
+	back, err := ToDense(f)
+	require.NoError(t, err)
+	assert.True(t, mat.EqualApprox(mat.NewDense(2, 2, []float64{1, 2, 0, 4}), withoutNaN(back), 0))
+
+	_, err = FromMatrix(m, "x")
+	assert.Error(t, err)
+}
+
+func withoutNaN(m *mat.Dense) *mat.Dense {
+	out := mat.DenseCopyOf(m)
+	out.Apply(func(_, _ int, v float64) float64 {
+		if math.IsNaN(v) {
+			return 0
+		}
+		return v
+	}, out)
+	return out
+}
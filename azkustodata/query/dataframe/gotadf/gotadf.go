@@ -0,0 +1,183 @@
+/*
+Package gotadf converts frames of the dataframe package to and from the DataFrame of gota (github.com/go-gota/gota).
+
+The conversions are in their own package, so that only the programs that use gota depend on it:
+
+	frame, err := dataframe.FromTable(table)
+	df, err := gotadf.ToDataFrame(frame)
+	df = df.Filter(gota.F{Colname: "Count", Comparator: series.Greater, Comparando: 1})
+	frame, err = gotadf.FromDataFrame(df)
+*/
+package gotadf
+
+import (
+	"math"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query/dataframe"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	gota "github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+)
+
+// ToDataFrame converts f to a gota DataFrame. bool series become Bool series, int and long series Int series, real and
+// decimal series Float series, and the other series String series of their values in the format of Series.Strings.
+// Nulls become NA values. gota has no empty DataFrame, so f must have at least one series.
+func ToDataFrame(f *dataframe.Frame) (gota.DataFrame, error) {
+	if f.Ncol() == 0 {
+		return gota.DataFrame{}, errors.ES(errors.OpTableAccess, errors.KClientArgs, "gota can't hold a frame without series")
+	}
+
+	columns := make([]series.Series, f.Ncol())
+	for i := range columns {
+		s, err := toSeries(f.Series(i))
+		if err != nil {
+			return gota.DataFrame{}, err
+		}
+		columns[i] = s
+	}
+
+	df := gota.New(columns...)
+	if df.Err != nil {
+		return gota.DataFrame{}, errors.E(errors.OpTableAccess, errors.KClientArgs, df.Err)
+	}
+	return df, nil
+}
+
+// toSeries converts s to a gota series, with nil elements for the nulls, which gota stores as NA.
+func toSeries(s *dataframe.Series) (series.Series, error) {
+	var t series.Type
+	switch s.Type {
+	case types.Bool:
+		t = series.Bool
+	case types.Int, types.Long:
+		t = series.Int
+	case types.Real, types.Decimal:
+		t = series.Float
+	default:
+		return series.New(nullableStrings(s), series.String, s.Name), nil
+	}
+
+	elements := make([]interface{}, s.Len())
+	for i, v := range s.Values() {
+		if value.IsNull(v) {
+			continue
+		}
+		switch v := v.(type) {
+		case *value.Bool:
+			elements[i] = *v.Ptr()
+		case *value.Int:
+			elements[i] = int(*v.Ptr())
+		case *value.Long:
+			n := *v.Ptr()
+			if n > math.MaxInt || n < math.MinInt {
+				return series.Series{}, errors.ES(errors.OpTableAccess, errors.KWrongColumnType,
+					"value %d of series %s doesn't fit in an int", i, s.Name)
+			}
+			elements[i] = int(n)
+		case *value.Real:
+			elements[i] = *v.Ptr()
+		case *value.Decimal:
+			f, _ := v.Ptr().Float64()
+			elements[i] = f
+		}
+	}
+	return series.New(elements, t, s.Name), nil
+}
+
+func nullableStrings(s *dataframe.Series) []interface{} {
+	elements := make([]interface{}, s.Len())
+	for i, v := range s.Strings() {
+		if !s.IsNull(i) {
+			elements[i] = v
+		}
+	}
+	return elements
+}
+
+// FromDataFrame converts a gota DataFrame to a frame. Bool series become bool series, Int series long series, Float series
+// real series and String series string series. NA values become nulls, and empty strings in String series, as Kusto strings
+// aren't nullable.
+func FromDataFrame(df gota.DataFrame) (*dataframe.Frame, error) {
+	if df.Err != nil {
+		return nil, errors.E(errors.OpTableAccess, errors.KClientArgs, df.Err)
+	}
+
+	columns := make([]*dataframe.Series, 0, df.Ncol())
+	for _, name := range df.Names() {
+		s, err := fromSeries(df.Col(name))
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, s)
+	}
+	return dataframe.New(columns...)
+}
+
+func fromSeries(s series.Series) (*dataframe.Series, error) {
+	values := make(value.Values, s.Len())
+	var t types.Column
+	for i := range values {
+		e := s.Elem(i)
+		switch s.Type() {
+		case series.Bool:
+			t = types.Bool
+			if e.IsNA() {
+				values[i] = value.NewNullBool()
+				continue
+			}
+			b, err := e.Bool()
+			if err != nil {
+				return nil, errors.E(errors.OpTableAccess, errors.KWrongColumnType, err)
+			}
+			values[i] = value.NewBool(b)
+		case series.Int:
+			t = types.Long
+			if e.IsNA() {
+				values[i] = value.NewNullLong()
+				continue
+			}
+			n, err := e.Int()
+			if err != nil {
+				return nil, errors.E(errors.OpTableAccess, errors.KWrongColumnType, err)
+			}
+			values[i] = value.NewLong(int64(n))
+		case series.Float:
+			t = types.Real
+			if e.IsNA() {
+				values[i] = value.NewNullReal()
+				continue
+			}
+			values[i] = value.NewReal(e.Float())
+		case series.String:
+			t = types.String
+			// Kusto strings aren't nullable.
+			if e.IsNA() {
+				values[i] = value.NewString("")
+				continue
+			}
+			values[i] = value.NewString(e.String())
+		default:
+			return nil, errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "series %s has an unsupported type %s", s.Name, s.Type())
+		}
+	}
+	if t == "" {
+		t = columnType(s.Type())
+	}
+	return dataframe.NewSeries(s.Name, t, values)
+}
+
+// columnType returns the type of the values of an empty gota series.
+func columnType(t series.Type) types.Column {
+	switch t {
+	case series.Bool:
+		return types.Bool
+	case series.Int:
+		return types.Long
+	case series.Float:
+		return types.Real
+	default:
+		return types.String
+	}
+}
@@ -0,0 +1,94 @@
+package gotadf
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query/dataframe"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/go-gota/gota/series"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSeries(t *testing.T, name string, typ types.Column, values ...value.Kusto) *dataframe.Series {
+	s, err := dataframe.NewSeries(name, typ, values)
+	require.NoError(t, err)
+	return s
+}
+
+func TestToDataFrame(t *testing.T) {
+	t.Parallel()
+
+	f, err := dataframe.New(
+		newSeries(t, "Name", types.String, value.NewString("a"), value.NewString("b")),
+		newSeries(t, "Count", types.Long, value.NewLong(1), value.NewNullLong()),
+		newSeries(t, "Small", types.Int, value.NewNullInt(), value.NewInt(2)),
+		newSeries(t, "Ratio", types.Real, value.NewReal(0.5), value.NewNullReal()),
+		newSeries(t, "Price", types.Decimal, value.NewDecimal(decimal.RequireFromString("1.25")), value.NewNullDecimal()),
+		newSeries(t, "Ok", types.Bool, value.NewBool(true), value.NewNullBool()),
+		newSeries(t, "Took", types.Timespan, value.NewTimespan(time.Minute), value.NewNullTimespan()),
+	)
+	require.NoError(t, err)
+
+	df, err := ToDataFrame(f)
+	require.NoError(t, err)
+	assert.Equal(t, 2, df.Nrow())
+	assert.Equal(t, f.Names(), df.Names())
+	assert.Equal(t, []series.Type{series.String, series.Int, series.Int, series.Float, series.Float, series.Bool, series.String}, df.Types())
+
+	assert.Equal(t, []string{"a", "b"}, df.Col("Name").Records())
+	count := df.Col("Count")
+	assert.Equal(t, []bool{false, true}, count.IsNaN())
+	n, err := count.Elem(0).Int()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []bool{true, false}, df.Col("Small").IsNaN())
+	assert.Equal(t, 0.5, df.Col("Ratio").Elem(0).Float())
+	assert.True(t, math.IsNaN(df.Col("Ratio").Elem(1).Float()))
+	assert.Equal(t, 1.25, df.Col("Price").Elem(0).Float())
+	assert.Equal(t, []bool{false, true}, df.Col("Ok").IsNaN())
+	assert.Equal(t, "00:01:00", df.Col("Took").Elem(0).String())
+	assert.Equal(t, []bool{false, true}, df.Col("Took").IsNaN())
+
+	_, err = ToDataFrame(&dataframe.Frame{})
+	e, ok := errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.KClientArgs, e.Kind)
+}
+
+func TestFromDataFrame(t *testing.T) {
+	t.Parallel()
+
+	f, err := dataframe.New(
+		newSeries(t, "Name", types.String, value.NewString("a"), value.NewString("b")),
+		newSeries(t, "Count", types.Long, value.NewLong(1), value.NewNullLong()),
+		newSeries(t, "Ratio", types.Real, value.NewNullReal(), value.NewReal(1.5)),
+		newSeries(t, "Ok", types.Bool, value.NewBool(false), value.NewBool(true)),
+	)
+	require.NoError(t, err)
+	df, err := ToDataFrame(f)
+	require.NoError(t, err)
+
+	back, err := FromDataFrame(df)
+	require.NoError(t, err)
+	assert.Equal(t, f.Names(), back.Names())
+	for i := 0; i < f.Ncol(); i++ {
+		assert.Equal(t, f.Series(i).Type, back.Series(i).Type)
+		assert.Equal(t, f.Series(i).Interfaces(), back.Series(i).Interfaces())
+	}
+
+	// Kusto strings aren't nullable.
+	df = df.Mutate(series.New([]interface{}{nil, "c"}, series.String, "Name"))
+	back, err = FromDataFrame(df)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "c"}, back.Col("Name").Strings())
+
+	df = df.Select("Missing")
+	_, err = FromDataFrame(df)
+	assert.Error(t, err)
+}
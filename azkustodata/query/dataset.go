@@ -13,6 +13,13 @@ type BaseDataset interface {
 	Op() errors.Op
 
 	PrimaryResultKind() string
+
+	// ClientRequestID returns the client request ID the call that returned the dataset was sent with, which identifies it in
+	// the `.show queries` and `.show commands` output, or "" if the dataset wasn't returned by a call to the service.
+	ClientRequestID() string
+	// ActivityID returns the ID the service assigned to the call that returned the dataset, from its x-ms-activity-id header,
+	// which support tickets ask for, or "" if the service didn't send one.
+	ActivityID() string
 }
 
 type Dataset interface {
@@ -10,6 +10,7 @@ type baseDataset struct {
 	ctx                context.Context
 	op                 errors.Op
 	primaryResultsKind string
+	ids                RequestIDs
 }
 
 func (d *baseDataset) Context() context.Context {
@@ -24,14 +25,40 @@ func (d *baseDataset) PrimaryResultKind() string {
 	return d.primaryResultsKind
 }
 
+func (d *baseDataset) ClientRequestID() string {
+	return d.ids.ClientRequestID
+}
+
+func (d *baseDataset) ActivityID() string {
+	return d.ids.ActivityID
+}
+
+// NewBaseDataset returns a BaseDataset, with the IDs of the call that ctx carries, if any; see WithRequestIDs.
 func NewBaseDataset(ctx context.Context, op errors.Op, primaryResultsKind string) BaseDataset {
+	ids, _ := ctx.Value(requestIDsKey{}).(RequestIDs)
 	return &baseDataset{
 		ctx:                ctx,
 		op:                 op,
 		primaryResultsKind: primaryResultsKind,
+		ids:                ids,
 	}
 }
 
+// RequestIDs identifies a call to the service.
+type RequestIDs struct {
+	// ClientRequestID is the x-ms-client-request-id header the call was sent with.
+	ClientRequestID string
+	// ActivityID is the x-ms-activity-id header the service responded with.
+	ActivityID string
+}
+
+type requestIDsKey struct{}
+
+// WithRequestIDs returns a context that carries the IDs of a call, which the datasets decoded with it return.
+func WithRequestIDs(ctx context.Context, ids RequestIDs) context.Context {
+	return context.WithValue(ctx, requestIDsKey{}, ids)
+}
+
 type dataset struct {
 	BaseDataset
 	tables []Table
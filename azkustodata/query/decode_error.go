@@ -0,0 +1,89 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+)
+
+// DecodeError is returned when a value can't be parsed from a response, or can't be stored in a struct field by Row.ToStruct,
+// ToStructs or ToStructsIterative. It identifies the value that failed, so a single bad row in a large result can be found.
+// Use errors.As to retrieve it.
+type DecodeError struct {
+	errors.KustoError
+	// Table is the name of the table the row belongs to. It is empty if the table isn't known, such as for rows built with NewRowFromParts.
+	Table string
+	// Row is the ordinal of the row in its table.
+	Row int
+	// Column is the name of the column of the value.
+	Column string
+	// KustoType is the type of the column.
+	KustoType types.Column
+	// GoType is the type of the struct field the value was stored in. It is nil if the value couldn't be parsed from the response.
+	GoType reflect.Type
+	// Field is the name of the struct field the value was stored in. It is empty if the value couldn't be parsed from the response.
+	Field string
+}
+
+// NewDecodeError returns a DecodeError for a value of column col, in the row with the given ordinal of table, that couldn't be parsed.
+func NewDecodeError(op errors.Op, table string, row int, col Column, err error) *DecodeError {
+	kind := errors.KFailedToParse
+	if kerr, ok := errors.GetKustoError(err); ok && kerr.Kind != errors.KOther {
+		kind = kerr.Kind
+	}
+
+	e := &DecodeError{
+		Table:     table,
+		Row:       row,
+		Column:    col.Name(),
+		KustoType: col.Type(),
+	}
+	e.KustoError = errors.KustoError{
+		Op:   op,
+		Kind: kind,
+		Err:  fmt.Errorf("%s: could not parse the %s value: %w", e.location(), col.Type(), err),
+	}
+	return e
+}
+
+// newFieldDecodeError returns a DecodeError for a value of column col that couldn't be stored in the struct field named field.
+func newFieldDecodeError(table string, row int, col Column, field string, goType reflect.Type, err error) *DecodeError {
+	e := &DecodeError{
+		Table:     table,
+		Row:       row,
+		Column:    col.Name(),
+		KustoType: col.Type(),
+		GoType:    goType,
+		Field:     field,
+	}
+	e.KustoError = errors.KustoError{
+		Op:   errors.OpTableAccess,
+		Kind: errors.KWrongColumnType,
+		Err:  fmt.Errorf("%s: could not store the %s value in struct.%s of type %s: %w", e.location(), col.Type(), field, goType, err),
+	}
+	return e
+}
+
+// location describes where the value is, such as `table "T", row 3, column "Name"`.
+func (e *DecodeError) location() string {
+	b := new(strings.Builder)
+	if e.Table != "" {
+		fmt.Fprintf(b, "table %q, ", e.Table)
+	}
+	fmt.Fprintf(b, "row %d, column %q", e.Row, e.Column)
+	return b.String()
+}
+
+func (e *DecodeError) Error() string {
+	return e.KustoError.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.KustoError.Unwrap()
+}
@@ -0,0 +1,98 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeError(t *testing.T) {
+	t.Parallel()
+
+	cols := Columns{NewColumn(0, "Name", types.String), NewColumn(1, "Count", types.Long)}
+	ds := NewBaseDataset(context.Background(), kustoErrors.OpQuery, "PrimaryResult")
+	base := NewBaseTable(ds, 0, "1", "Events", "PrimaryResult", cols)
+	values := value.Values{value.NewString("a"), value.NewLong(7)}
+
+	tests := []struct {
+		desc  string
+		row   Row
+		table string
+		msg   string
+	}{
+		{
+			desc:  "row of a table",
+			row:   NewRow(base, 41, values),
+			table: "Events",
+			msg:   `table "Events", row 41, column "Count": could not store the long value in struct.Count of type bool`,
+		},
+		{
+			desc: "row without a table",
+			row:  NewRowFromParts(cols, base.ColumnByName, 41, values),
+			msg:  `row 41, column "Count": could not store the long value in struct.Count of type bool`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var s struct {
+				Name  string
+				Count bool
+			}
+			err := test.row.ToStruct(&s)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, test.msg)
+
+			var decodeErr *DecodeError
+			require.True(t, errors.As(err, &decodeErr))
+			assert.Equal(t, test.table, decodeErr.Table)
+			assert.Equal(t, 41, decodeErr.Row)
+			assert.Equal(t, "Count", decodeErr.Column)
+			assert.Equal(t, types.Long, decodeErr.KustoType)
+			assert.Equal(t, reflect.TypeOf(false), decodeErr.GoType)
+			assert.Equal(t, "Count", decodeErr.Field)
+			assert.Equal(t, kustoErrors.KWrongColumnType, decodeErr.Kind)
+		})
+	}
+}
+
+func TestDecodeErrorToStructs(t *testing.T) {
+	t.Parallel()
+
+	cols := Columns{NewColumn(0, "Small", types.Long)}
+	rows := []Row{
+		NewRowFromParts(cols, nil, 0, value.Values{value.NewLong(1)}),
+		NewRowFromParts(cols, nil, 1, value.Values{value.NewLong(1000)}),
+	}
+
+	_, err := ToStructs[struct{ Small int8 }](rows, WithOverflowPolicy(OverflowError))
+	var decodeErr *DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 1, decodeErr.Row)
+	assert.Equal(t, reflect.TypeOf(int8(0)), decodeErr.GoType)
+	assert.ErrorContains(t, err, "overflows int8")
+}
+
+func TestNewDecodeError(t *testing.T) {
+	t.Parallel()
+
+	col := NewColumn(2, "When", types.DateTime)
+	err := NewDecodeError(kustoErrors.OpQuery, "T", 3, col, value.NewNullDateTime().Unmarshal(5))
+	assert.Equal(t, kustoErrors.KWrongColumnType, err.Kind)
+	assert.Equal(t, types.DateTime, err.KustoType)
+	assert.ErrorContains(t, err, `table "T", row 3, column "When": could not parse the datetime value`)
+
+	err = NewDecodeError(kustoErrors.OpQuery, "T", 3, col, errors.New("boom"))
+	assert.Equal(t, kustoErrors.KFailedToParse, err.Kind)
+	assert.ErrorContains(t, err, "boom")
+}
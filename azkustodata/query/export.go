@@ -0,0 +1,65 @@
+package query
+
+import (
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// rowSource iterates over the rows of a table, a dataset or a slice of rows, so exporters can stream them.
+type rowSource struct {
+	columns Columns
+	next    func() (Row, bool, error)
+}
+
+// newRowSource accepts the same data as ToStructs. Iterative tables are streamed, without materializing the rows.
+func newRowSource(data interface{}) (*rowSource, error) {
+	switch v := data.(type) {
+	case IterativeTable:
+		rows := v.Rows()
+		return &rowSource{
+			columns: v.Columns(),
+			next: func() (Row, bool, error) {
+				res, ok := <-rows
+				if !ok {
+					return nil, false, nil
+				}
+				if res.Err() != nil {
+					return nil, false, res.Err()
+				}
+				return res.Row(), true, nil
+			},
+		}, nil
+	case Table:
+		return sliceRowSource(v.Columns(), v.Rows()), nil
+	case Dataset:
+		tables := v.Tables()
+		if len(tables) == 0 {
+			return nil, errors.ES(errors.OpUnknown, errors.KInternal, "dataset does not contain any tables")
+		}
+		if !tables[0].IsPrimaryResult() {
+			return nil, errors.ES(errors.OpUnknown, errors.KInternal, "dataset contains no primary results")
+		}
+		return sliceRowSource(tables[0].Columns(), tables[0].Rows()), nil
+	case []Row:
+		var cols Columns
+		if len(v) > 0 {
+			cols = v[0].Columns()
+		}
+		return sliceRowSource(cols, v), nil
+	default:
+		return nil, errors.ES(errors.OpUnknown, errors.KInternal, "invalid data type - expected Dataset, Table, IterativeTable or []Row")
+	}
+}
+
+func sliceRowSource(cols Columns, rows []Row) *rowSource {
+	i := 0
+	return &rowSource{
+		columns: cols,
+		next: func() (Row, bool, error) {
+			if i >= len(rows) {
+				return nil, false, nil
+			}
+			i++
+			return rows[i-1], true, nil
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// newExportTestTable returns a table with a column of every type, a row of values and a row of nulls.
+func newExportTestTable() Table {
+	cols := []Column{
+		NewColumn(0, "Name", types.String),
+		NewColumn(1, "Count", types.Long),
+		NewColumn(2, "Ratio", types.Real),
+		NewColumn(3, "Ok", types.Bool),
+		NewColumn(4, "When", types.DateTime),
+		NewColumn(5, "Took", types.Timespan),
+		NewColumn(6, "Bag", types.Dynamic),
+		NewColumn(7, "Price", types.Decimal),
+	}
+	ds := NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	base := NewBaseTable(ds, 0, "1", "T", "PrimaryResult", cols)
+	rows := []Row{
+		NewRow(base, 0, value.Values{value.NewString("a,b"), value.NewLong(3), value.NewReal(0.5), value.NewBool(true),
+			value.NewDateTime(time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)), value.NewTimespan(90 * time.Minute),
+			value.NewDynamic([]byte(`{"k":[1,2]}`)), value.DecimalFromString("10.50")}),
+		NewRow(base, 1, value.Values{value.NewString(""), value.NewNullLong(), value.NewNullReal(), value.NewNullBool(),
+			value.NewNullDateTime(), value.NewNullTimespan(), value.NewNullDynamic(), value.NewNullDecimal()}),
+	}
+	return NewTable(base, rows)
+}
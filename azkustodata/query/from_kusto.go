@@ -1,7 +1,6 @@
 package query
 
 import (
-	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
 	"reflect"
 	"strings"
@@ -15,21 +14,28 @@ type fieldMap struct {
 var typeMapper = map[reflect.Type]fieldMap{}
 var typeMapperLock = sync.RWMutex{}
 
-// decodeToStruct takes a list of columns and a row to decode into "p" which will be a pointer
+// decodeToStruct takes a row to decode into "p" which will be a pointer
 // to a struct (enforce in the decoder).
-func decodeToStruct(cols []Column, row value.Values, p interface{}) error {
+func decodeToStruct(r *row, p interface{}, opts decodeOptions) error {
 	t := reflect.TypeOf(p)
 	v := reflect.ValueOf(p)
 	fields := newFields(t)
 
-	for i, col := range cols {
-		if err := fields.convert(col, row[i], v); err != nil {
-			return err
+	for i, col := range r.columns {
+		if err := fields.convert(col, r.values[i], v, opts); err != nil {
+			return newFieldDecodeError(r.table, r.ordinal, col, err.field, err.goType, err.err)
 		}
 	}
 	return nil
 }
 
+// fieldError is a failure to store a value in a struct field, before the row it belongs to is known.
+type fieldError struct {
+	field  string
+	goType reflect.Type
+	err    error
+}
+
 // newFields takes in the Columns from our row and the reflect.Type of our *struct.
 func newFields(ptr reflect.Type) fieldMap {
 	typeMapperLock.RLock()
@@ -55,7 +61,7 @@ func newFields(ptr reflect.Type) fieldMap {
 }
 
 // convert converts a KustoValue that is for Column col into "v" reflect.Value with reflect.Type "t".
-func (f fieldMap) convert(col Column, k value.Kusto, v reflect.Value) error {
+func (f fieldMap) convert(col Column, k value.Kusto, v reflect.Value, opts decodeOptions) *fieldError {
 	fieldName, ok := f.colNameToFieldName[col.Name()]
 	if !ok {
 		return nil
@@ -65,9 +71,20 @@ func (f fieldMap) convert(col Column, k value.Kusto, v reflect.Value) error {
 		return nil
 	}
 
-	err := k.Convert(v.Elem().FieldByName(fieldName))
+	field := v.Elem().FieldByName(fieldName)
+	converted, err := customConvert(col.Type(), k, field)
+	if !converted {
+		converted, err = applyOverflowPolicy(opts.overflow, k, field)
+	}
+	if !converted {
+		if scanner, ok := field.Addr().Interface().(value.Scanner); ok {
+			err = scanner.ScanKusto(k)
+		} else {
+			err = k.Convert(field)
+		}
+	}
 	if err != nil {
-		return kustoErrors.ES(kustoErrors.OpTableAccess, kustoErrors.KWrongColumnType, "column %s could not store in struct.%s: %s", col.Name(), fieldName, err.Error())
+		return &fieldError{field: fieldName, goType: field.Type(), err: err}
 	}
 
 	return nil
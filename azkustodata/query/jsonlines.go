@@ -0,0 +1,124 @@
+package query
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// WriteJSONLines writes the rows of a table, an iterative table, a dataset with a single primary table, or a slice of rows to w
+// as JSON Lines - one JSON object per row, with the column names as keys, in column order.
+// Rows of an iterative table are written as they arrive, so the results don't have to fit in memory.
+//
+// Values are written with their native JSON types: numbers for int, long, real and decimal (decimals keep all their digits),
+// booleans, RFC3339 strings for datetimes, Kusto-formatted strings for timespans, and the nested JSON of dynamic values.
+// Non-finite reals are written as the strings "NaN", "Infinity" and "-Infinity". Nulls are written as null.
+func WriteJSONLines(w io.Writer, data interface{}) error {
+	src, err := newRowSource(data)
+	if err != nil {
+		return err
+	}
+
+	keys := make([][]byte, len(src.columns))
+	for i, c := range src.columns {
+		k, err := json.Marshal(c.Name())
+		if err != nil {
+			return errors.E(errors.OpTableAccess, errors.KInternal, err)
+		}
+		keys[i] = k
+	}
+
+	bw := bufio.NewWriter(w)
+	line := make([]byte, 0, 256)
+	for {
+		row, ok, err := src.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		line = append(line[:0], '{')
+		for i, v := range row.Values() {
+			if i > 0 {
+				line = append(line, ',')
+			}
+			if i < len(keys) {
+				line = append(line, keys[i]...)
+			} else {
+				line = strconv.AppendQuote(line, strconv.Itoa(i))
+			}
+			line = append(line, ':')
+			line, err = appendJSONValue(line, v)
+			if err != nil {
+				return err
+			}
+		}
+		line = append(line, '}', '\n')
+
+		if _, err := bw.Write(line); err != nil {
+			return errors.E(errors.OpTableAccess, errors.KIO, err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return errors.E(errors.OpTableAccess, errors.KIO, err)
+	}
+	return nil
+}
+
+// appendJSONValue appends the JSON representation of k to b.
+func appendJSONValue(b []byte, k value.Kusto) ([]byte, error) {
+	if value.IsNull(k) {
+		return append(b, "null"...), nil
+	}
+
+	switch v := k.(type) {
+	case *value.Bool:
+		return strconv.AppendBool(b, *v.Ptr()), nil
+	case *value.Int:
+		return strconv.AppendInt(b, int64(*v.Ptr()), 10), nil
+	case *value.Long:
+		return strconv.AppendInt(b, *v.Ptr(), 10), nil
+	case *value.Real:
+		f := *v.Ptr()
+		switch {
+		case math.IsNaN(f):
+			return append(b, `"NaN"`...), nil
+		case math.IsInf(f, 1):
+			return append(b, `"Infinity"`...), nil
+		case math.IsInf(f, -1):
+			return append(b, `"-Infinity"`...), nil
+		}
+		return strconv.AppendFloat(b, f, 'g', -1, 64), nil
+	case *value.Decimal:
+		return append(b, v.String()...), nil
+	case *value.DateTime:
+		return strconv.AppendQuote(b, v.Ptr().Format(time.RFC3339Nano)), nil
+	case *value.Timespan:
+		return strconv.AppendQuote(b, v.Marshal()), nil
+	case *value.Dynamic:
+		if !json.Valid(v.Value) {
+			// Dynamic values are JSON, but fall back to a string rather than producing an invalid line.
+			s, err := json.Marshal(string(v.Value))
+			if err != nil {
+				return nil, errors.E(errors.OpTableAccess, errors.KInternal, err)
+			}
+			return append(b, s...), nil
+		}
+		return append(b, v.Value...), nil
+	default:
+		s, err := json.Marshal(k.String())
+		if err != nil {
+			return nil, errors.E(errors.OpTableAccess, errors.KInternal, err)
+		}
+		return append(b, s...), nil
+	}
+}
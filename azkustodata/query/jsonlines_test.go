@@ -0,0 +1,27 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJSONLines(buf, newExportTestTable()))
+
+	expected := `{"Name":"a,b","Count":3,"Ratio":0.5,"Ok":true,"When":"2024-01-02T03:04:05.0000006Z","Took":"01:30:00","Bag":{"k":[1,2]},"Price":10.5}
+{"Name":"","Count":null,"Ratio":null,"Ok":null,"When":null,"Took":null,"Bag":null,"Price":null}
+`
+	assert.Equal(t, expected, buf.String())
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+}
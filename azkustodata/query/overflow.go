@@ -0,0 +1,166 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// OverflowPolicy decides what happens when a numeric value doesn't fit the struct field it is decoded into,
+// such as a long decoded into an int32 field, or a real decoded into a float32 or an integer field.
+type OverflowPolicy int
+
+const (
+	// OverflowTruncate converts the value like a Go conversion does, silently wrapping integers and dropping fractions.
+	// This is the default.
+	OverflowTruncate OverflowPolicy = iota
+	// OverflowError fails the decoding of the row.
+	OverflowError
+	// OverflowSaturate stores the closest value the field can hold: the minimum or maximum of its type, the value without its fraction,
+	// or 0 for NaN in an integer field.
+	OverflowSaturate
+	// OverflowSkip leaves the field untouched.
+	OverflowSkip
+)
+
+type decodeOptions struct {
+	overflow OverflowPolicy
+}
+
+// DecodeOption is an optional argument to Row.ToStruct, ToStructs and ToStructsIterative.
+type DecodeOption func(o *decodeOptions)
+
+// WithOverflowPolicy sets what happens when a numeric value doesn't fit its destination field.
+func WithOverflowPolicy(p OverflowPolicy) DecodeOption {
+	return func(o *decodeOptions) {
+		o.overflow = p
+	}
+}
+
+func newDecodeOptions(options []DecodeOption) decodeOptions {
+	opts := decodeOptions{overflow: OverflowTruncate}
+	for _, o := range options {
+		o(&opts)
+	}
+	return opts
+}
+
+// applyOverflowPolicy checks whether k fits into the numeric field v. If it doesn't, the policy is applied and handled is true.
+// Otherwise, the value is left to the regular conversions.
+func applyOverflowPolicy(policy OverflowPolicy, k value.Kusto, v reflect.Value) (handled bool, err error) {
+	if policy == OverflowTruncate {
+		return false, nil
+	}
+
+	t := v.Type()
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+
+	var f float64
+	var i int64
+	isFloat := false
+	switch val := k.(type) {
+	case *value.Int:
+		if val.Ptr() == nil {
+			return false, nil
+		}
+		i = int64(*val.Ptr())
+	case *value.Long:
+		if val.Ptr() == nil {
+			return false, nil
+		}
+		i = *val.Ptr()
+	case *value.Real:
+		if val.Ptr() == nil {
+			return false, nil
+		}
+		f = *val.Ptr()
+		isFloat = true
+	default:
+		return false, nil
+	}
+
+	out := reflect.New(t).Elem()
+	fits := true
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+		lo, hi := int64(math.MinInt64)>>(64-bits), int64(math.MaxInt64)>>(64-bits)
+		if isFloat {
+			limit := math.Ldexp(1, bits-1)
+			switch {
+			case math.IsNaN(f):
+				fits, i = false, 0
+			case f >= limit:
+				fits, i = false, hi
+			case f < -limit:
+				fits, i = false, lo
+			default:
+				fits, i = f == math.Trunc(f), int64(f)
+			}
+		} else if i < lo {
+			fits, i = false, lo
+		} else if i > hi {
+			fits, i = false, hi
+		}
+		out.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		bits := t.Bits()
+		hi := uint64(math.MaxUint64) >> (64 - bits)
+		var u uint64
+		if isFloat {
+			limit := math.Ldexp(1, bits)
+			switch {
+			case math.IsNaN(f), f < 0:
+				fits, u = f == 0, 0
+			case f >= limit:
+				fits, u = false, hi
+			default:
+				fits, u = f == math.Trunc(f), uint64(f)
+			}
+		} else if i < 0 {
+			fits, u = false, 0
+		} else if uint64(i) > hi {
+			fits, u = false, hi
+		} else {
+			u = uint64(i)
+		}
+		out.SetUint(u)
+	case reflect.Float32:
+		if !isFloat || math.IsNaN(f) || math.IsInf(f, 0) {
+			return false, nil
+		}
+		if f > math.MaxFloat32 {
+			fits, f = false, math.MaxFloat32
+		} else if f < -math.MaxFloat32 {
+			fits, f = false, -math.MaxFloat32
+		}
+		out.SetFloat(f)
+	default:
+		return false, nil
+	}
+
+	if fits {
+		return false, nil
+	}
+
+	switch policy {
+	case OverflowError:
+		return true, fmt.Errorf("value %s of type %s overflows %s", k, k.GetType(), t)
+	case OverflowSkip:
+		return true, nil
+	}
+
+	if isPtr {
+		p := reflect.New(t)
+		p.Elem().Set(out)
+		v.Set(p)
+	} else {
+		v.Set(out)
+	}
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package query
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type overflowRecord struct {
+	Small  int8
+	Medium int32
+	Count  uint16
+	Ratio  float32
+	Whole  int64
+	Exact  int32
+	Nested float64
+}
+
+func TestOverflowPolicy(t *testing.T) {
+	t.Parallel()
+
+	cols := Columns{
+		NewColumn(0, "Small", types.Long),
+		NewColumn(1, "Medium", types.Long),
+		NewColumn(2, "Count", types.Int),
+		NewColumn(3, "Ratio", types.Real),
+		NewColumn(4, "Whole", types.Real),
+		NewColumn(5, "Exact", types.Long),
+		NewColumn(6, "Nested", types.Real),
+	}
+	overflowing := NewRowFromParts(cols, nil, 0, value.Values{
+		value.NewLong(300), value.NewLong(-1 << 40), value.NewInt(-5), value.NewReal(1e300), value.NewReal(math.NaN()),
+		value.NewLong(7), value.NewReal(1e300),
+	})
+	fitting := NewRowFromParts(cols, nil, 1, value.Values{
+		value.NewLong(-128), value.NewNullLong(), value.NewInt(65535), value.NewReal(0.5), value.NewReal(-3),
+		value.NewLong(7), value.NewReal(1e300),
+	})
+
+	seven := int32(7)
+	medium := int32(math.MinInt32)
+	tests := []struct {
+		desc        string
+		policy      OverflowPolicy
+		want        overflowRecord
+		err         bool
+		noOverflows bool
+	}{
+		{
+			desc:   "truncate",
+			policy: OverflowTruncate,
+			want:   overflowRecord{Small: int8(44), Count: 65531, Ratio: float32(math.Inf(1)), Whole: math.MinInt64, Exact: 7, Nested: 1e300},
+		},
+		{
+			desc:   "error",
+			policy: OverflowError,
+			err:    true,
+		},
+		{
+			desc:   "saturate",
+			policy: OverflowSaturate,
+			want:   overflowRecord{Small: math.MaxInt8, Medium: medium, Count: 0, Ratio: math.MaxFloat32, Whole: 0, Exact: 7, Nested: 1e300},
+		},
+		{
+			desc:   "skip",
+			policy: OverflowSkip,
+			want:   overflowRecord{Exact: 7, Nested: 1e300},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got overflowRecord
+			err := overflowing.ToStruct(&got, WithOverflowPolicy(test.policy))
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				if test.policy == OverflowTruncate {
+					// The result of truncating is platform dependent for some conversions, only check the stable fields.
+					assert.Equal(t, test.want.Small, got.Small)
+					assert.Equal(t, test.want.Count, got.Count)
+					assert.Equal(t, test.want.Exact, got.Exact)
+				} else {
+					assert.Equal(t, test.want, got)
+				}
+			}
+
+			// Values that fit are decoded the same way whatever the policy.
+			var fit overflowRecord
+			require.NoError(t, fitting.ToStruct(&fit, WithOverflowPolicy(test.policy)))
+			assert.Equal(t, overflowRecord{Small: -128, Count: 65535, Ratio: 0.5, Whole: -3, Exact: seven, Nested: 1e300}, fit)
+		})
+	}
+}
+
+func TestOverflowPolicyToStructs(t *testing.T) {
+	t.Parallel()
+
+	cols := Columns{NewColumn(0, "Small", types.Real)}
+	rows := []Row{
+		NewRowFromParts(cols, nil, 0, value.Values{value.NewReal(1)}),
+		NewRowFromParts(cols, nil, 1, value.Values{value.NewReal(1.5)}),
+	}
+
+	out, err := ToStructs[overflowRecord](rows, WithOverflowPolicy(OverflowError))
+	assert.Error(t, err)
+	assert.Len(t, out, 1)
+
+	out, err = ToStructs[overflowRecord](rows, WithOverflowPolicy(OverflowSaturate))
+	require.NoError(t, err)
+	assert.Equal(t, int8(1), out[1].Small)
+
+	// Pointer fields are saturated too.
+	var ptr struct{ Small *int8 }
+	row := NewRowFromParts(cols, nil, 0, value.Values{value.NewReal(-1e10)})
+	require.NoError(t, row.ToStruct(&ptr, WithOverflowPolicy(OverflowSaturate)))
+	require.NotNil(t, ptr.Small)
+	assert.Equal(t, int8(math.MinInt8), *ptr.Small)
+}
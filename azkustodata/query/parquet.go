@@ -0,0 +1,436 @@
+package query
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// DefaultParquetRowGroupSize is the default amount of rows in each row group written by WriteParquet.
+const DefaultParquetRowGroupSize = 100000
+
+// Parquet physical types.
+const (
+	parquetBoolean           = 0
+	parquetInt32             = 1
+	parquetInt64             = 2
+	parquetDouble            = 5
+	parquetByteArray         = 6
+	parquetFixedLenByteArray = 7
+)
+
+// Parquet converted types, written alongside the logical types for older readers.
+const (
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMicros = 10
+	parquetConvertedJSON            = 19
+)
+
+// Parquet encodings.
+const (
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+var parquetMagic = []byte("PAR1")
+
+type parquetOptions struct {
+	rowGroupSize   int
+	timestampNanos bool
+}
+
+// ParquetOption is an optional argument to WriteParquet.
+type ParquetOption func(o *parquetOptions)
+
+// ParquetRowGroupSize sets the amount of rows in each row group. Rows are buffered in memory until a row group is full.
+func ParquetRowGroupSize(rows int) ParquetOption {
+	return func(o *parquetOptions) {
+		o.rowGroupSize = rows
+	}
+}
+
+// ParquetTimestampNanos writes datetime columns with nanosecond precision, keeping the 100ns precision of Kusto.
+// The default is microseconds, which is supported by more readers. Nanosecond timestamps only hold datetimes from 1677-09-21 to
+// 2262-04-11: WriteParquet fails on a datetime outside of this range.
+func ParquetTimestampNanos() ParquetOption {
+	return func(o *parquetOptions) {
+		o.timestampNanos = true
+	}
+}
+
+// WriteParquet writes the rows of a table, an iterative table, a dataset with a single primary table, or a slice of rows to w
+// as an uncompressed Parquet file. Rows of an iterative table are written as they arrive, a row group at a time.
+//
+// All columns are optional (nullable), and Kusto types are mapped to Parquet types as follows:
+//
+//	bool     -> BOOLEAN
+//	int      -> INT32
+//	long     -> INT64
+//	real     -> DOUBLE
+//	datetime -> INT64, TIMESTAMP(isAdjustedToUTC=true, MICROS or NANOS)
+//	timespan -> INT64 (nanoseconds)
+//	decimal  -> BYTE_ARRAY, STRING (Kusto decimals don't have a fixed scale)
+//	string   -> BYTE_ARRAY, STRING
+//	guid     -> FIXED_LEN_BYTE_ARRAY(16), UUID
+//	dynamic  -> BYTE_ARRAY, JSON
+func WriteParquet(w io.Writer, data interface{}, options ...ParquetOption) error {
+	opts := parquetOptions{rowGroupSize: DefaultParquetRowGroupSize}
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.rowGroupSize <= 0 {
+		return errors.ES(errors.OpTableAccess, errors.KClientArgs, "row group size must be positive, got %d", opts.rowGroupSize)
+	}
+
+	src, err := newRowSource(data)
+	if err != nil {
+		return err
+	}
+
+	pw := &parquetWriter{w: bufio.NewWriter(w), opts: opts}
+	pw.columns = make([]*parquetColumn, len(src.columns))
+	for i, c := range src.columns {
+		col, err := newParquetColumn(c, opts)
+		if err != nil {
+			return err
+		}
+		pw.columns[i] = col
+	}
+
+	if err := pw.write(parquetMagic); err != nil {
+		return err
+	}
+
+	for {
+		row, ok, err := src.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		values := row.Values()
+		if len(values) != len(pw.columns) {
+			return errors.ES(errors.OpTableAccess, errors.KInternal, "row %d has %d values, expected %d", row.Index(), len(values), len(pw.columns))
+		}
+		for i, v := range values {
+			if err := pw.columns[i].add(v); err != nil {
+				return err
+			}
+		}
+		pw.rows++
+
+		if pw.rows == opts.rowGroupSize {
+			if err := pw.flushRowGroup(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if pw.rows > 0 {
+		if err := pw.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+
+	return pw.close()
+}
+
+// parquetColumnChunk is the metadata of a column chunk that was written to the file.
+type parquetColumnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type parquetRowGroup struct {
+	chunks  []parquetColumnChunk
+	size    int64
+	numRows int64
+}
+
+type parquetWriter struct {
+	w         *bufio.Writer
+	opts      parquetOptions
+	columns   []*parquetColumn
+	offset    int64
+	rows      int
+	totalRows int64
+	groups    []parquetRowGroup
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	if err != nil {
+		return errors.E(errors.OpTableAccess, errors.KIO, err)
+	}
+	return nil
+}
+
+// flushRowGroup writes the buffered rows of every column as a single data page.
+func (p *parquetWriter) flushRowGroup() error {
+	group := parquetRowGroup{numRows: int64(p.rows)}
+	for _, c := range p.columns {
+		page := c.page()
+
+		header := &thriftWriter{}
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structField(5)
+		header.i32(1, int32(c.numValues))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.endStruct()
+
+		chunk := parquetColumnChunk{offset: p.offset, numValues: int64(c.numValues)}
+		if err := p.write(header.buf); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		chunk.size = p.offset - chunk.offset
+		group.size += chunk.size
+		group.chunks = append(group.chunks, chunk)
+
+		c.reset()
+	}
+
+	p.groups = append(p.groups, group)
+	p.totalRows += int64(p.rows)
+	p.rows = 0
+	return nil
+}
+
+// close writes the file metadata and the footer.
+func (p *parquetWriter) close() error {
+	meta := &thriftWriter{}
+	meta.i32(1, 1)
+	meta.structList(2, len(p.columns)+1, func(i int) {
+		if i == 0 {
+			meta.stringField(4, "schema")
+			meta.i32(5, int32(len(p.columns)))
+			return
+		}
+		p.columns[i-1].writeSchema(meta)
+	})
+	meta.i64(3, p.totalRows)
+	meta.structList(4, len(p.groups), func(g int) {
+		group := p.groups[g]
+		meta.structList(1, len(group.chunks), func(i int) {
+			chunk := group.chunks[i]
+			col := p.columns[i]
+			meta.i64(2, chunk.offset)
+			meta.structField(3)
+			meta.i32(1, col.physical)
+			meta.i32List(2, []int32{parquetPlain, parquetRLE})
+			meta.stringList(3, []string{col.name})
+			meta.i32(4, 0) // UNCOMPRESSED
+			meta.i64(5, chunk.numValues)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.endStruct()
+		})
+		meta.i64(2, group.size)
+		meta.i64(3, group.numRows)
+	})
+	meta.stringField(6, "azure-kusto-go")
+	meta.endStruct()
+
+	if err := p.write(meta.buf); err != nil {
+		return err
+	}
+	if err := p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(meta.buf)))); err != nil {
+		return err
+	}
+	if err := p.write(parquetMagic); err != nil {
+		return err
+	}
+	if err := p.w.Flush(); err != nil {
+		return errors.E(errors.OpTableAccess, errors.KIO, err)
+	}
+	return nil
+}
+
+// parquetColumn buffers the values of a column for the current row group.
+type parquetColumn struct {
+	name       string
+	kind       types.Column
+	physical   int32
+	typeLength int32
+	opts       parquetOptions
+
+	numValues int
+	defined   []byte // definition levels as a bitmap, least-significant bit first.
+	values    []byte // PLAIN encoded non-null values.
+	bools     int    // amount of non-null booleans, which are bit-packed into values.
+}
+
+func newParquetColumn(c Column, opts parquetOptions) (*parquetColumn, error) {
+	col := &parquetColumn{name: c.Name(), kind: c.Type(), opts: opts}
+	switch c.Type() {
+	case types.Bool:
+		col.physical = parquetBoolean
+	case types.Int:
+		col.physical = parquetInt32
+	case types.Long, types.DateTime, types.Timespan:
+		col.physical = parquetInt64
+	case types.Real:
+		col.physical = parquetDouble
+	case types.String, types.Decimal, types.Dynamic:
+		col.physical = parquetByteArray
+	case types.GUID:
+		col.physical = parquetFixedLenByteArray
+		col.typeLength = 16
+	default:
+		return nil, errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "column %s has unsupported type %s", c.Name(), c.Type())
+	}
+	return col, nil
+}
+
+func (c *parquetColumn) reset() {
+	c.numValues = 0
+	c.defined = c.defined[:0]
+	c.values = c.values[:0]
+	c.bools = 0
+}
+
+func (c *parquetColumn) add(k value.Kusto) error {
+	null := value.IsNull(k)
+	if !null {
+		if err := c.addValue(k); err != nil {
+			return err
+		}
+	}
+	if c.numValues%8 == 0 {
+		c.defined = append(c.defined, 0)
+	}
+	if !null {
+		c.defined[c.numValues/8] |= 1 << (c.numValues % 8)
+	}
+	c.numValues++
+	return nil
+}
+
+// minTimestampNanos and maxTimestampNanos bound the datetimes that can be written as nanosecond timestamps, which are int64
+// nanoseconds since the Unix epoch.
+var (
+	minTimestampNanos = time.Unix(0, math.MinInt64).UTC()
+	maxTimestampNanos = time.Unix(0, math.MaxInt64).UTC()
+)
+
+func (c *parquetColumn) addValue(k value.Kusto) error {
+	le := binary.LittleEndian
+	switch v := k.(type) {
+	case *value.Bool:
+		if c.bools%8 == 0 {
+			c.values = append(c.values, 0)
+		}
+		if *v.Ptr() {
+			c.values[len(c.values)-1] |= 1 << (c.bools % 8)
+		}
+		c.bools++
+	case *value.Int:
+		c.values = le.AppendUint32(c.values, uint32(*v.Ptr()))
+	case *value.Long:
+		c.values = le.AppendUint64(c.values, uint64(*v.Ptr()))
+	case *value.Real:
+		c.values = le.AppendUint64(c.values, math.Float64bits(*v.Ptr()))
+	case *value.DateTime:
+		t := *v.Ptr()
+		var ts int64
+		if c.opts.timestampNanos {
+			if t.Before(minTimestampNanos) || t.After(maxTimestampNanos) {
+				return errors.ES(errors.OpTableAccess, errors.KLimitsExceeded,
+					"datetime %s of column %s is out of the range of nanosecond timestamps, %s to %s; write microsecond timestamps instead",
+					t.Format(time.RFC3339Nano), c.name, minTimestampNanos.Format(time.RFC3339Nano), maxTimestampNanos.Format(time.RFC3339Nano))
+			}
+			ts = t.UnixNano()
+		} else {
+			ts = t.UnixMicro()
+		}
+		c.values = le.AppendUint64(c.values, uint64(ts))
+	case *value.Timespan:
+		c.values = le.AppendUint64(c.values, uint64(*v.Ptr()))
+	case *value.GUID:
+		g := *v.Ptr()
+		c.values = append(c.values, g[:]...)
+	case *value.Dynamic:
+		c.appendByteArray(v.Value)
+	default:
+		c.appendByteArray([]byte(k.String()))
+	}
+	return nil
+}
+
+func (c *parquetColumn) appendByteArray(b []byte) {
+	c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(b)))
+	c.values = append(c.values, b...)
+}
+
+// page returns the data of a v1 data page: the definition levels, followed by the values.
+func (c *parquetColumn) page() []byte {
+	// The definition levels are encoded as a single bit-packed run of the RLE/bit-packing hybrid encoding, with a bit width of 1.
+	levels := binary.AppendUvarint(nil, uint64(len(c.defined))<<1|1)
+	levels = append(levels, c.defined...)
+
+	page := make([]byte, 0, 4+len(levels)+len(c.values))
+	page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+	page = append(page, levels...)
+	return append(page, c.values...)
+}
+
+// writeSchema writes the SchemaElement of the column.
+func (c *parquetColumn) writeSchema(t *thriftWriter) {
+	t.i32(1, c.physical)
+	if c.typeLength != 0 {
+		t.i32(2, c.typeLength)
+	}
+	t.i32(3, 1) // OPTIONAL
+	t.stringField(4, c.name)
+
+	switch c.kind {
+	case types.String, types.Decimal:
+		t.i32(6, parquetConvertedUTF8)
+		t.structField(10)
+		t.emptyStructField(1) // STRING
+		t.endStruct()
+	case types.Dynamic:
+		t.i32(6, parquetConvertedJSON)
+		t.structField(10)
+		t.emptyStructField(12) // JSON
+		t.endStruct()
+	case types.GUID:
+		t.structField(10)
+		t.emptyStructField(14) // UUID
+		t.endStruct()
+	case types.DateTime:
+		if !c.opts.timestampNanos {
+			t.i32(6, parquetConvertedTimestampMicros)
+		}
+		t.structField(10)
+		t.structField(8) // TIMESTAMP
+		t.boolField(1, true)
+		t.structField(2)
+		if c.opts.timestampNanos {
+			t.emptyStructField(3) // NANOS
+		} else {
+			t.emptyStructField(2) // MICROS
+		}
+		t.endStruct()
+		t.endStruct()
+		t.endStruct()
+	}
+}
@@ -0,0 +1,341 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodedStruct is a decoded thrift compact struct, keyed by field id.
+type decodedStruct map[int16]interface{}
+
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftBoolTrue:
+		return true
+	case thriftBoolFalse:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		v := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return v
+	case thriftList:
+		h := r.buf[r.pos]
+		r.pos++
+		size := int(h >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(h & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unsupported thrift type")
+}
+
+func (r *thriftReader) readStruct() decodedStruct {
+	s := decodedStruct{}
+	var id int16
+	for {
+		h := r.buf[r.pos]
+		r.pos++
+		if h == 0 {
+			return s
+		}
+		if delta := int16(h >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		s[id] = r.value(h & 0x0f)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteParquet(buf, newExportTestTable(), ParquetRowGroupSize(1)))
+
+	b := buf.Bytes()
+	require.Equal(t, "PAR1", string(b[:4]))
+	require.Equal(t, "PAR1", string(b[len(b)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	meta := (&thriftReader{buf: b[len(b)-8-footerLen:]}).readStruct()
+
+	assert.Equal(t, int64(2), meta[3])
+
+	schema := meta[2].([]interface{})
+	require.Len(t, schema, 9)
+	assert.Equal(t, "schema", schema[0].(decodedStruct)[4])
+	assert.Equal(t, int64(8), schema[0].(decodedStruct)[5])
+
+	expected := []struct {
+		name     string
+		physical int64
+	}{
+		{"Name", parquetByteArray},
+		{"Count", parquetInt64},
+		{"Ratio", parquetDouble},
+		{"Ok", parquetBoolean},
+		{"When", parquetInt64},
+		{"Took", parquetInt64},
+		{"Bag", parquetByteArray},
+		{"Price", parquetByteArray},
+	}
+	for i, e := range expected {
+		el := schema[i+1].(decodedStruct)
+		assert.Equal(t, e.name, el[4])
+		assert.Equal(t, e.physical, el[1])
+		assert.Equal(t, int64(1), el[3])
+	}
+	assert.Equal(t, int64(parquetConvertedTimestampMicros), schema[5].(decodedStruct)[6])
+	assert.Equal(t, int64(parquetConvertedJSON), schema[7].(decodedStruct)[6])
+
+	// Every row is in its own row group.
+	groups := meta[4].([]interface{})
+	require.Len(t, groups, 2)
+
+	chunkValues := func(group, column int) (defined bool, values []byte) {
+		chunk := groups[group].(decodedStruct)[1].([]interface{})[column].(decodedStruct)
+		md := chunk[3].(decodedStruct)
+		assert.Equal(t, int64(1), md[5])
+
+		r := &thriftReader{buf: b, pos: int(md[9].(int64))}
+		header := r.readStruct()
+		size := int(header[3].(int64))
+		page := b[r.pos : r.pos+size]
+
+		levelsLen := int(binary.LittleEndian.Uint32(page))
+		levels := page[4 : 4+levelsLen]
+		assert.Equal(t, []byte{3}, levels[:1]) // A single bit-packed group.
+		return levels[1]&1 == 1, page[4+levelsLen:]
+	}
+
+	defined, values := chunkValues(0, 0)
+	assert.True(t, defined)
+	assert.Equal(t, append([]byte{3, 0, 0, 0}, "a,b"...), values)
+
+	defined, values = chunkValues(0, 1)
+	assert.True(t, defined)
+	assert.Equal(t, uint64(3), binary.LittleEndian.Uint64(values))
+
+	defined, values = chunkValues(0, 2)
+	assert.True(t, defined)
+	assert.Equal(t, 0.5, math.Float64frombits(binary.LittleEndian.Uint64(values)))
+
+	defined, values = chunkValues(0, 3)
+	assert.True(t, defined)
+	assert.Equal(t, []byte{1}, values)
+
+	defined, values = chunkValues(0, 4)
+	assert.True(t, defined)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixMicro(), int64(binary.LittleEndian.Uint64(values)))
+
+	defined, values = chunkValues(0, 5)
+	assert.True(t, defined)
+	assert.Equal(t, int64(90*time.Minute), int64(binary.LittleEndian.Uint64(values)))
+
+	defined, values = chunkValues(0, 7)
+	assert.True(t, defined)
+	assert.Equal(t, append([]byte{4, 0, 0, 0}, "10.5"...), values)
+
+	// An empty string is not null, but the other values of the second row are.
+	defined, values = chunkValues(1, 0)
+	assert.True(t, defined)
+	assert.Equal(t, []byte{0, 0, 0, 0}, values)
+
+	for i := 1; i < 8; i++ {
+		defined, values = chunkValues(1, i)
+		assert.False(t, defined)
+		assert.Empty(t, values)
+	}
+}
+
+func TestWriteParquetOptions(t *testing.T) {
+	t.Parallel()
+
+	err := WriteParquet(&bytes.Buffer{}, newExportTestTable(), ParquetRowGroupSize(0))
+	assert.Error(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteParquet(buf, newExportTestTable(), ParquetTimestampNanos()))
+
+	b := buf.Bytes()
+	footerLen := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	meta := (&thriftReader{buf: b[len(b)-8-footerLen:]}).readStruct()
+
+	assert.Len(t, meta[4].([]interface{}), 1)
+
+	when := meta[2].([]interface{})[5].(decodedStruct)
+	assert.NotContains(t, when, int16(6))
+	unit := when[10].(decodedStruct)[8].(decodedStruct)[2].(decodedStruct)
+	assert.Contains(t, unit, int16(3))
+}
+
+func TestWriteParquetTimestampNanosOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	cols := []Column{NewColumn(0, "When", types.DateTime)}
+	base := NewBaseTable(NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult"), 0, "1", "T", "PrimaryResult", cols)
+	rows := []Row{NewRow(base, 0, value.Values{value.NewDateTime(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC))})}
+
+	err := WriteParquet(&bytes.Buffer{}, rows, ParquetTimestampNanos())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of the range of nanosecond timestamps")
+
+	// Microsecond timestamps hold every Kusto datetime.
+	assert.NoError(t, WriteParquet(&bytes.Buffer{}, rows))
+}
+
+// pyarrowReadScript prints the schema and the rows of the Parquet file given as argument, as read by pyarrow.
+const pyarrowReadScript = `
+import datetime, json, sys
+import pyarrow.parquet as pq
+
+def plain(name, v):
+    if v is None:
+        return None
+    if name == "Id":
+        return v.hex() if isinstance(v, bytes) else v.hex
+    if isinstance(v, datetime.datetime):
+        return v.isoformat()
+    if isinstance(v, bytes):
+        return v.decode()
+    return v
+
+table = pq.read_table(sys.argv[1])
+rows = []
+if sys.argv[2] == "rows":
+    rows = [{k: plain(k, v) for k, v in row.items()} for row in table.to_pylist()]
+print(json.dumps({
+    "types": {f.name: str(f.type) for f in table.schema},
+    "count": table.num_rows,
+    "rows": rows,
+}))
+`
+
+// TestWriteParquetPyArrow reads the files written by WriteParquet with pyarrow, so they are checked by a reader that wasn't
+// written with the writer. It is skipped where python3 doesn't have pyarrow.
+func TestWriteParquetPyArrow(t *testing.T) {
+	t.Parallel()
+
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 isn't installed")
+	}
+	if err := exec.Command(python, "-c", "import pyarrow.parquet").Run(); err != nil {
+		t.Skip("pyarrow isn't installed")
+	}
+
+	cols := []Column{
+		NewColumn(0, "Name", types.String),
+		NewColumn(1, "Count", types.Long),
+		NewColumn(2, "Small", types.Int),
+		NewColumn(3, "Ratio", types.Real),
+		NewColumn(4, "Ok", types.Bool),
+		NewColumn(5, "When", types.DateTime),
+		NewColumn(6, "Took", types.Timespan),
+		NewColumn(7, "Id", types.GUID),
+		NewColumn(8, "Bag", types.Dynamic),
+		NewColumn(9, "Price", types.Decimal),
+	}
+	base := NewBaseTable(NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult"), 0, "1", "T", "PrimaryResult", cols)
+	var rows []Row
+	for i := 0; i < 10; i++ {
+		rows = append(rows, NewRow(base, i, value.Values{value.NewString("a,b"), value.NewLong(int64(i)), value.NewInt(int32(-i)),
+			value.NewReal(0.5), value.NewBool(i%3 == 0), value.NewDateTime(time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)),
+			value.NewTimespan(90 * time.Minute), value.NewGUID(uuid.MustParse("123e27de-1e4e-49d9-b579-fe0b331d3642")),
+			value.NewDynamic([]byte(`{"k":[1,2]}`)), value.DecimalFromString("10.50")}))
+		rows = append(rows, NewRow(base, i, value.Values{value.NewString(""), value.NewNullLong(), value.NewNullInt(), value.NewNullReal(),
+			value.NewNullBool(), value.NewNullDateTime(), value.NewNullTimespan(), value.NewNullGUID(), value.NewNullDynamic(),
+			value.NewNullDecimal()}))
+	}
+
+	type read struct {
+		Types map[string]string
+		Count int
+		Rows  []map[string]interface{}
+	}
+	// The rows are only decoded with the default options, as pyarrow refuses to convert timestamps with nanoseconds.
+	readWithPyArrow := func(t *testing.T, decodeRows bool, options ...ParquetOption) read {
+		path := filepath.Join(t.TempDir(), "t.parquet")
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		require.NoError(t, WriteParquet(f, rows, options...))
+		require.NoError(t, f.Close())
+
+		mode := "schema"
+		if decodeRows {
+			mode = "rows"
+		}
+		out, err := exec.Command(python, "-c", pyarrowReadScript, path, mode).Output()
+		require.NoError(t, err)
+		var r read
+		require.NoError(t, json.Unmarshal(out, &r))
+		return r
+	}
+
+	r := readWithPyArrow(t, true, ParquetRowGroupSize(3))
+	assert.Equal(t, "string", r.Types["Name"])
+	assert.Equal(t, "int64", r.Types["Count"])
+	assert.Equal(t, "int32", r.Types["Small"])
+	assert.Equal(t, "double", r.Types["Ratio"])
+	assert.Equal(t, "bool", r.Types["Ok"])
+	assert.Equal(t, "timestamp[us, tz=UTC]", r.Types["When"])
+	assert.Equal(t, "int64", r.Types["Took"])
+	assert.Equal(t, "string", r.Types["Price"])
+
+	require.Len(t, r.Rows, len(rows))
+	assert.Equal(t, len(rows), r.Count)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, map[string]interface{}{
+			"Name": "a,b", "Count": float64(i), "Small": float64(-i), "Ratio": 0.5, "Ok": i%3 == 0, "When": "2024-01-02T03:04:05+00:00",
+			"Took": float64(90 * time.Minute), "Id": "123e27de1e4e49d9b579fe0b331d3642", "Bag": `{"k":[1,2]}`, "Price": "10.50",
+		}, r.Rows[2*i])
+		assert.Equal(t, map[string]interface{}{
+			"Name": "", "Count": nil, "Small": nil, "Ratio": nil, "Ok": nil, "When": nil, "Took": nil, "Id": nil, "Bag": nil, "Price": nil,
+		}, r.Rows[2*i+1])
+	}
+
+	r = readWithPyArrow(t, false, ParquetTimestampNanos())
+	assert.Equal(t, "timestamp[ns, tz=UTC]", r.Types["When"])
+	assert.Equal(t, len(rows), r.Count)
+}
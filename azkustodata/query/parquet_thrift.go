@@ -0,0 +1,132 @@
+package query
+
+import (
+	"encoding/binary"
+)
+
+// Thrift compact protocol types, used to encode the Parquet metadata structures.
+const (
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftStruct    = 12
+)
+
+// thriftWriter encodes structs with the thrift compact protocol.
+// Fields must be written in increasing id order within a struct.
+type thriftWriter struct {
+	buf    []byte
+	last   []int16
+	fieldN int16
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.buf = binary.AppendUvarint(t.buf, v)
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	delta := id - t.fieldN
+	if delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.zigzag(int64(id))
+	}
+	t.fieldN = id
+}
+
+// beginStruct starts a nested struct. The top level struct doesn't need it.
+func (t *thriftWriter) beginStruct() {
+	t.last = append(t.last, t.fieldN)
+	t.fieldN = 0
+}
+
+// endStruct writes the stop field of the current struct.
+func (t *thriftWriter) endStruct() {
+	t.buf = append(t.buf, 0)
+	if len(t.last) > 0 {
+		t.fieldN = t.last[len(t.last)-1]
+		t.last = t.last[:len(t.last)-1]
+	}
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) boolField(id int16, v bool) {
+	if v {
+		t.fieldHeader(id, thriftBoolTrue)
+	} else {
+		t.fieldHeader(id, thriftBoolFalse)
+	}
+}
+
+func (t *thriftWriter) binaryValue(v []byte) {
+	t.varint(uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
+
+func (t *thriftWriter) stringField(id int16, v string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binaryValue([]byte(v))
+}
+
+// structField starts a nested struct field. It must be closed with endStruct.
+func (t *thriftWriter) structField(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginStruct()
+}
+
+// emptyStructField writes a field holding a struct without fields, as used by the members of Parquet's unions.
+func (t *thriftWriter) emptyStructField(id int16) {
+	t.structField(id)
+	t.endStruct()
+}
+
+func (t *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elemType)
+	} else {
+		t.buf = append(t.buf, 0xf0|elemType)
+		t.varint(uint64(size))
+	}
+}
+
+func (t *thriftWriter) i32List(id int16, vs []int32) {
+	t.listHeader(id, thriftI32, len(vs))
+	for _, v := range vs {
+		t.zigzag(int64(v))
+	}
+}
+
+func (t *thriftWriter) stringList(id int16, vs []string) {
+	t.listHeader(id, thriftBinary, len(vs))
+	for _, v := range vs {
+		t.binaryValue([]byte(v))
+	}
+}
+
+// structList starts a list of structs, calling write for each element between beginStruct and endStruct.
+func (t *thriftWriter) structList(id int16, size int, write func(i int)) {
+	t.listHeader(id, thriftStruct, size)
+	for i := 0; i < size; i++ {
+		t.beginStruct()
+		write(i)
+		t.endStruct()
+	}
+}
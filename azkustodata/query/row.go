@@ -29,7 +29,7 @@ type Row interface {
 
 	// ToStruct converts the row into a struct and assigns it to the provided pointer.
 	// It returns an error if the conversion fails.
-	ToStruct(p interface{}) error
+	ToStruct(p interface{}, options ...DecodeOption) error
 
 	// String returns a string representation of the row.
 	String() string
@@ -13,6 +13,7 @@ import (
 )
 
 type row struct {
+	table        string
 	columns      Columns
 	columnByName func(string) Column
 	values       value.Values
@@ -20,11 +21,17 @@ type row struct {
 }
 
 func NewRow(t BaseTable, ordinal int, values value.Values) Row {
-	return NewRowFromParts(t.Columns(), t.ColumnByName, ordinal, values)
+	return NewRowFromPartsWithTable(t.Name(), t.Columns(), t.ColumnByName, ordinal, values)
 }
 
 func NewRowFromParts(c Columns, columnByName func(string) Column, ordinal int, values value.Values) Row {
+	return NewRowFromPartsWithTable("", c, columnByName, ordinal, values)
+}
+
+// NewRowFromPartsWithTable is like NewRowFromParts, and records the name of the table the row belongs to, which is reported by DecodeError.
+func NewRowFromPartsWithTable(table string, c Columns, columnByName func(string) Column, ordinal int, values value.Values) Row {
 	return &row{
+		table:        table,
 		columns:      c,
 		columnByName: columnByName,
 		ordinal:      ordinal,
@@ -78,7 +85,10 @@ func (r *row) ValueByName(name string) (value.Kusto, error) {
 // non-nil value if the column is not NULL. To decode NULL values of other types, use
 // one of the kusto types (Int, Long, Dynamic, ...) as the type of the destination field.
 // You can check the .Valid field of those types to see if the value was set.
-func (r *row) ToStruct(p interface{}) error {
+//
+// Numeric values that don't fit their field are truncated like a Go conversion, unless WithOverflowPolicy is given.
+// A value that can't be stored in its field returns a *DecodeError.
+func (r *row) ToStruct(p interface{}, options ...DecodeOption) error {
 	// Check if p is a pointer to a struct
 	if t := reflect.TypeOf(p); t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
 		return errors.ES(errors.OpTableAccess, errors.KClientArgs, "type %T is not a pointer to a struct", p)
@@ -87,7 +97,7 @@ func (r *row) ToStruct(p interface{}) error {
 		return errors.ES(errors.OpTableAccess, errors.KClientArgs, "row does not have the correct number of values(%d) for the number of columns(%d)", len(r.Values()), len(r.Columns()))
 	}
 
-	return decodeToStruct(r.Columns(), r.Values(), p)
+	return decodeToStruct(r, p, newDecodeOptions(options))
 }
 
 // String implements fmt.Stringer for a Row. This simply outputs a CSV version of the row.
@@ -221,7 +231,7 @@ func (r *row) GuidByName(name string) (*uuid.UUID, error) {
 
 // ToStructs converts a table, a non-iterative dataset or a slice of rows into a slice of structs.
 // If a dataset is provided, it should contain exactly one table.
-func ToStructs[T any](data interface{}) ([]T, error) {
+func ToStructs[T any](data interface{}, options ...DecodeOption) ([]T, error) {
 	var rows []Row
 	var errs error
 
@@ -257,7 +267,7 @@ func ToStructs[T any](data interface{}) ([]T, error) {
 
 	out := make([]T, len(rows))
 	for i, r := range rows {
-		if err := r.ToStruct(&out[i]); err != nil {
+		if err := r.ToStruct(&out[i], options...); err != nil {
 			out = out[:i]
 			if len(out) == 0 {
 				out = nil
@@ -274,7 +284,7 @@ type StructResult[T any] struct {
 	Err error
 }
 
-func ToStructsIterative[T any](tb IterativeTable) chan StructResult[T] {
+func ToStructsIterative[T any](tb IterativeTable, options ...DecodeOption) chan StructResult[T] {
 	out := make(chan StructResult[T])
 
 	go func() {
@@ -284,7 +294,7 @@ func ToStructsIterative[T any](tb IterativeTable) chan StructResult[T] {
 				out <- StructResult[T]{Err: rowResult.Err()}
 			} else {
 				var s T
-				if err := rowResult.Row().ToStruct(&s); err != nil {
+				if err := rowResult.Row().ToStruct(&s, options...); err != nil {
 					out <- StructResult[T]{Err: err}
 				} else {
 					out <- StructResult[T]{Out: s}
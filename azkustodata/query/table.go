@@ -9,6 +9,8 @@ type BaseTable interface {
 	Columns() []Column
 	Kind() string
 	ColumnByName(name string) Column
+	// ColumnsByName returns the columns keyed by their names. The map must not be modified.
+	ColumnsByName() map[string]Column
 	Op() errors.Op
 	IsPrimaryResult() bool
 }
@@ -23,10 +23,7 @@ func NewBaseTable(ds BaseDataset, index int64, id string, name string, kind stri
 		kind:    kind,
 		columns: columns,
 	}
-	b.columnsByName = make(map[string]Column)
-	for _, c := range columns {
-		b.columnsByName[c.Name()] = c
-	}
+	b.columnsByName = Columns(columns).ByName()
 
 	return b
 }
@@ -58,6 +55,10 @@ func (t *baseTable) ColumnByName(name string) Column {
 	return nil
 }
 
+func (t *baseTable) ColumnsByName() map[string]Column {
+	return t.columnsByName
+}
+
 func (t *baseTable) IsPrimaryResult() bool {
 	return t.Kind() == t.dataSet.PrimaryResultKind()
 }
@@ -167,3 +167,12 @@ type Dataset interface {
 	Status() []QueryStatus
 	Info() []QueryProperties
 }
+
+// ToStructs decodes the first table of the dataset, which holds the result of a management command, into a slice of T.
+func ToStructs[T any](ds Dataset) ([]T, error) {
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command returned no tables")
+	}
+	return query.ToStructs[T](tables[0])
+}
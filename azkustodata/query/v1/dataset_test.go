@@ -160,3 +160,14 @@ func TestBoolAsInt(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, nullBool)
 }
+
+func TestToStructs(t *testing.T) {
+	t.Parallel()
+
+	ds, err := NewDatasetFromReader(context.Background(), errors.OpMgmt, io.NopCloser(strings.NewReader(successFile)))
+	assert.NoError(t, err)
+
+	rows, err := ToStructs[firstTable](ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []firstTable{{A: 1}, {A: 2}, {A: 3}}, rows)
+}
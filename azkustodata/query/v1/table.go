@@ -66,12 +66,12 @@ func NewTable(d query.BaseDataset, dt *RawTable, index *TableIndexRow) (query.Ta
 			if v != nil {
 				err := parsed.Unmarshal(v)
 				if err != nil {
-					return nil, errors.ES(op, errors.KInternal, "unable to unmarshal column %s into a %s value: %s", columns[j].Name(), columns[j].Type(), err)
+					return nil, query.NewDecodeError(op, baseTable.Name(), i, columns[j], err)
 				}
 			}
 			values[j] = parsed
 		}
-		rows = append(rows, query.NewRowFromParts(baseTable.Columns(), baseTable.ColumnByName, i, values))
+		rows = append(rows, query.NewRow(baseTable, i, values))
 	}
 	return query.NewTable(baseTable, rows), nil
 }
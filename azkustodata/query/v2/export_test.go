@@ -0,0 +1,33 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSVIterative(t *testing.T) {
+	t.Parallel()
+
+	ds, err := NewIterativeDataset(context.Background(), io.NopCloser(strings.NewReader(validFrames)), DefaultIoCapacity, DefaultRowCapacity, DefaultTableCapacity)
+	require.NoError(t, err)
+	defer ds.Close()
+
+	res := <-ds.Tables()
+	require.NoError(t, res.Err())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, query.WriteCSV(buf, res.Table()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "vnum,vdec,vdate,vspan,vobj,vb,vreal,vstr,vlong,vguid", lines[0])
+	assert.Equal(t, `1,2.00000000000001,2020-03-04T14:05:01.3109965Z,01:23:45.6789000,"{""moshe"":""value""}",true,0.01,asdf,9223372036854775807,123e27de-1e4e-49d9-b579-fe0b331d3642`, lines[1])
+	assert.Equal(t, ",,,,,,,,,", lines[2])
+}
@@ -23,7 +23,7 @@ func newDecoder(r io.Reader) *json.Decoder {
 func (t *TableFragment) UnmarshalJSON(b []byte) error {
 	decoder := newDecoder(bytes.NewReader(b))
 
-	rows, err := decodeTableFragment(b, decoder, t.Columns, t.PreviousIndex)
+	rows, err := decodeTableFragment(b, decoder, t.TableName, t.Columns, t.PreviousIndex)
 	if err != nil {
 		return err
 	}
@@ -42,7 +42,7 @@ func (q *DataTable) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
-	rows, err := decodeTableFragment(b, decoder, q.Header.Columns, 0)
+	rows, err := decodeTableFragment(b, decoder, q.Header.TableName, q.Header.Columns, 0)
 	if err != nil {
 		return err
 	}
@@ -105,7 +105,7 @@ func decodeHeader(decoder *json.Decoder, t *TableHeader, frameType FrameType) er
 }
 
 // decodeTableFragment decodes the common part of a TableFragment and DataTable - the rows.
-func decodeTableFragment(b []byte, decoder *json.Decoder, columns []query.Column, previousIndex int) ([]query.Row, error) {
+func decodeTableFragment(b []byte, decoder *json.Decoder, tableName string, columns []query.Column, previousIndex int) ([]query.Row, error) {
 
 	// skip properties until we reach the Rows property (guaranteed to be the last one)
 	for {
@@ -118,7 +118,7 @@ func decodeTableFragment(b []byte, decoder *json.Decoder, columns []query.Column
 		}
 	}
 
-	rows, err := decodeRows(b, decoder, columns, previousIndex)
+	rows, err := decodeRows(b, decoder, tableName, columns, previousIndex)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +165,8 @@ func decodeColumns(decoder *json.Decoder) ([]query.Column, error) {
 // This function:
 // 1. Creates a cached map of column names to columns for faster lookup
 // 2. Decodes the rows into a slice of query.Rows
-func decodeRows(b []byte, decoder *json.Decoder, cols []query.Column, startIndex int) ([]query.Row, error) {
+// Values that can't be parsed return a *query.DecodeError.
+func decodeRows(b []byte, decoder *json.Decoder, tableName string, cols []query.Column, startIndex int) ([]query.Row, error) {
 	const RowArrayAllocSize = 10
 	var rows = make([]query.Row, 0, RowArrayAllocSize)
 
@@ -180,12 +181,12 @@ func decodeRows(b []byte, decoder *json.Decoder, cols []query.Column, startIndex
 	}
 
 	for i := startIndex; decoder.More(); i++ {
-		rowValues, err := decodeRow(b, decoder, cols)
+		rowValues, err := decodeRow(b, decoder, tableName, i, cols)
 		if err != nil {
 			return nil, err
 		}
 
-		row := query.NewRowFromParts(cols, func(name string) query.Column { return columnsByName[name] }, i, rowValues)
+		row := query.NewRowFromPartsWithTable(tableName, cols, func(name string) query.Column { return columnsByName[name] }, i, rowValues)
 		rows = append(rows, row)
 	}
 
@@ -202,6 +203,8 @@ func decodeRows(b []byte, decoder *json.Decoder, cols []query.Column, startIndex
 func decodeRow(
 	buffer []byte,
 	decoder *json.Decoder,
+	tableName string,
+	rowIndex int,
 	cols []query.Column) (value.Values, error) {
 
 	err := assertToken(decoder, json.Delim('['))
@@ -233,7 +236,7 @@ func decodeRow(
 		// Unmarshal the value
 		err = kustoValue.Unmarshal(t)
 		if err != nil {
-			return nil, err
+			return nil, query.NewDecodeError(errors.OpTableAccess, tableName, rowIndex, cols[field], err)
 		}
 
 		values = append(values, kustoValue)
@@ -23,6 +23,20 @@ func (f FrameColumn) Type() types.Column {
 	return types.Column(f.ColumnType)
 }
 
+func (f FrameColumn) CslType() string {
+	return f.ColumnType
+}
+
+// DocString is always empty, as query results don't include docstrings.
+func (f FrameColumn) DocString() string {
+	return ""
+}
+
+// Folder is always empty, as query results don't include folders.
+func (f FrameColumn) Folder() string {
+	return ""
+}
+
 type DataTable struct {
 	Header TableHeader
 	Rows   []query.Row
@@ -54,6 +68,7 @@ type TableHeader struct {
 }
 
 type TableFragment struct {
+	TableName     string
 	Columns       []query.Column
 	Rows          []query.Row
 	PreviousIndex int
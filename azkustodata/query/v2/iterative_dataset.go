@@ -7,6 +7,7 @@ import (
 	"io"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
 	"github.com/Azure/azure-kusto-go/azkustodata/query"
 )
 
@@ -79,6 +80,7 @@ func NewIterativeDataset(ctx context.Context, r io.ReadCloser, ioCapacity int, r
 // readRoutine reads the frames from the Kusto service and sends them to the buffered channel.
 // This is so we could keep up if the IO is faster than the consumption of the frames.
 func readRoutine(reader *frameReader, d *iterativeDataset) {
+	log := logger.FromContext(d.Context())
 	loop := true
 
 	for loop {
@@ -87,6 +89,7 @@ func readRoutine(reader *frameReader, d *iterativeDataset) {
 			if err != io.EOF {
 				select {
 				case <-d.Context().Done():
+					log.Debug("dropping an error reading the response, as the dataset is closed", "error", err)
 				// When we send data, we always make sure that the context isn't cancelled, so we don't block forever.
 				case d.jsonData <- err:
 				}
@@ -95,6 +98,7 @@ func readRoutine(reader *frameReader, d *iterativeDataset) {
 		} else {
 			select {
 			case <-d.Context().Done():
+				log.Debug("dropping the rest of the frames of the response, as the dataset is closed")
 				loop = false
 			case d.jsonData <- line:
 			}
@@ -104,6 +108,7 @@ func readRoutine(reader *frameReader, d *iterativeDataset) {
 	if err := reader.close(); err != nil {
 		select {
 		case <-d.Context().Done():
+			log.Debug("dropping an error closing the response, as the dataset is closed", "error", err)
 		case d.jsonData <- err:
 		}
 	}
@@ -119,6 +124,7 @@ func parseRoutine(d *iterativeDataset, cancel context.CancelFunc) {
 		select {
 		case d.results <- query.TableResultError(err):
 		case <-d.Context().Done():
+			logger.FromContext(d.Context()).Debug("dropping an error decoding the response, as the dataset is closed", "error", err)
 		}
 		cancel()
 	}
@@ -260,7 +266,7 @@ func readPrimaryTable(d *iterativeDataset, dec *json.Decoder) error {
 			return err
 		}
 		if frameType == TableFragmentFrameType {
-			fragment := TableFragment{Columns: header.Columns, PreviousIndex: i}
+			fragment := TableFragment{TableName: header.TableName, Columns: header.Columns, PreviousIndex: i}
 			err = dec.Decode(&fragment)
 			if err != nil {
 				return err
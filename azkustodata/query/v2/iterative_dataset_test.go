@@ -2,7 +2,9 @@ package v2
 
 import (
 	"context"
+	"errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -364,3 +366,22 @@ func TestStreamingDataSet_Context_Canceled(t *testing.T) {
 	assert.NoError(t, err)
 	cancel()
 }
+
+func TestStreamingDataSet_DecodeError(t *testing.T) {
+	t.Parallel()
+	s := strings.Replace(twoTables, `"Rows":[[2], [3]]`, `"Rows":[[2], ["three"]]`, 1)
+	d, err := defaultDataset(strings.NewReader(s))
+	require.NoError(t, err)
+
+	_, err = d.ToDataset()
+	require.Error(t, err)
+
+	var decodeErr *query.DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "PrimaryResult", decodeErr.Table)
+	assert.Equal(t, 2, decodeErr.Row)
+	assert.Equal(t, "A", decodeErr.Column)
+	assert.Equal(t, types.Int, decodeErr.KustoType)
+	assert.Nil(t, decodeErr.GoType)
+	assert.ErrorContains(t, err, `table "PrimaryResult", row 2, column "A"`)
+}
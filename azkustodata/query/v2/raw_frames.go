@@ -0,0 +1,73 @@
+package v2
+
+import (
+	"context"
+	"io"
+)
+
+// RawFrame is a single, undecoded frame of a v2 response.
+// It can be used to proxy the results of a query without paying for the decoding of the rows.
+type RawFrame struct {
+	// Type is the type of the frame, peeked from the raw data.
+	Type FrameType
+	// Data is the raw JSON of the frame, without the array separators of the response.
+	Data []byte
+}
+
+// RawFrameResult is a structure that holds a raw frame, or the error that occurred while reading it.
+type RawFrameResult struct {
+	Frame RawFrame
+	Err   error
+}
+
+// NewRawFrames reads a FragmentedV2 response frame-by-frame, and sends each frame on the returned channel without decoding it.
+// capacity is the amount of frames to buffer.
+// The channel is closed when the response is fully read, an error occurs, or the context is cancelled.
+// The reader is closed when reading is done.
+func NewRawFrames(ctx context.Context, r io.ReadCloser, capacity int) (<-chan RawFrameResult, error) {
+	reader, err := newFrameReader(r, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make(chan RawFrameResult, capacity)
+
+	go func() {
+		defer close(frames)
+
+		send := func(res RawFrameResult) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case frames <- res:
+				return true
+			}
+		}
+
+		for {
+			line, err := reader.advance()
+			if err != nil {
+				if err != io.EOF {
+					send(RawFrameResult{Err: err})
+				}
+				break
+			}
+
+			frameType, err := peekFrameType(line)
+			if err != nil {
+				send(RawFrameResult{Err: err})
+				break
+			}
+
+			if !send(RawFrameResult{Frame: RawFrame{Type: frameType, Data: line}}) {
+				break
+			}
+		}
+
+		if err := reader.close(); err != nil {
+			send(RawFrameResult{Err: err})
+		}
+	}()
+
+	return frames, nil
+}
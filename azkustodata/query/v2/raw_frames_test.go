@@ -0,0 +1,60 @@
+package v2
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawFrames(t *testing.T) {
+	t.Parallel()
+
+	frames, err := NewRawFrames(context.Background(), io.NopCloser(strings.NewReader(validFrames)), DefaultIoCapacity)
+	require.NoError(t, err)
+
+	expected := []FrameType{
+		DataSetHeaderFrameType,
+		DataTableFrameType,
+		TableHeaderFrameType,
+		TableFragmentFrameType,
+		TableCompletionFrameType,
+		DataTableFrameType,
+		DataSetCompletionFrameType,
+	}
+
+	var got []FrameType
+	for f := range frames {
+		require.NoError(t, f.Err)
+		require.True(t, strings.HasPrefix(string(f.Frame.Data), "{"))
+		got = append(got, f.Frame.Type)
+	}
+
+	require.Equal(t, expected, got)
+}
+
+func TestRawFramesError(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRawFrames(context.Background(), io.NopCloser(strings.NewReader(errorText)), DefaultIoCapacity)
+	require.Error(t, err)
+}
+
+func TestRawFramesInvalidFrame(t *testing.T) {
+	t.Parallel()
+
+	frames, err := NewRawFrames(context.Background(), io.NopCloser(strings.NewReader("[{\"FrameType\":\"DataSetHeader\"}\n,{}\n]")), DefaultIoCapacity)
+	require.NoError(t, err)
+
+	first := <-frames
+	require.NoError(t, first.Err)
+	require.Equal(t, DataSetHeaderFrameType, first.Frame.Type)
+
+	second := <-frames
+	require.Error(t, second.Err)
+
+	_, ok := <-frames
+	require.False(t, ok)
+}
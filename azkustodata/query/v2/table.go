@@ -41,6 +41,8 @@ func (f iterativeWrapper) ColumnByName(name string) query.Column {
 	return f.table.ColumnByName(name)
 }
 
+func (f iterativeWrapper) ColumnsByName() map[string]query.Column { return f.table.ColumnsByName() }
+
 func (f iterativeWrapper) Op() errors.Op { return f.table.Op() }
 
 func (f iterativeWrapper) IsPrimaryResult() bool { return f.table.IsPrimaryResult() }
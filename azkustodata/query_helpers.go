@@ -0,0 +1,76 @@
+package azkustodata
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// SortOrder is the order of the rows returned by Top.
+type SortOrder string
+
+const (
+	// Descending returns the largest values first. This is the default order of Kusto.
+	Descending SortOrder = "desc"
+	// Ascending returns the smallest values first.
+	Ascending SortOrder = "asc"
+)
+
+// MaxHelperRows is the most rows Sample and Top return. It is the number of records Kusto truncates results to by default;
+// larger results need a query with the NoTruncation option.
+const MaxHelperRows int64 = 500000
+
+// Sample returns up to n arbitrary rows of the given table. n must be positive and at most MaxHelperRows.
+// The table name is escaped as needed.
+func (c *Client) Sample(ctx context.Context, db string, table string, n int64, options ...QueryOption) (query.Dataset, error) {
+	stmt, err := SampleStatement(table, n)
+	if err != nil {
+		return nil, err
+	}
+	return c.Query(ctx, db, stmt, options...)
+}
+
+// Top returns the first n rows of the results of stmt, sorted by the orderBy column in descending order. n must be positive and
+// at most MaxHelperRows. The column name is escaped as needed.
+func (c *Client) Top(ctx context.Context, db string, stmt Statement, n int64, orderBy string, options ...QueryOption) (query.Dataset, error) {
+	top, err := TopStatement(stmt, n, orderBy, Descending)
+	if err != nil {
+		return nil, err
+	}
+	return c.Query(ctx, db, top, options...)
+}
+
+// SampleStatement builds the statement used by Sample.
+func SampleStatement(table string, n int64) (Statement, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "table name must not be empty").SetNoRetry()
+	}
+	if n <= 0 || n > MaxHelperRows {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "sample size must be between 1 and %d, got %d", MaxHelperRows, n).SetNoRetry()
+	}
+	return kql.New("").AddTable(table).AddLiteral(" | sample ").AddLong(n), nil
+}
+
+// TopStatement builds a statement that returns the first n rows of stmt sorted by the orderBy column.
+// stmt is not modified.
+func TopStatement(stmt Statement, n int64, orderBy string, order SortOrder) (Statement, error) {
+	if orderBy == "" {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "order by column must not be empty").SetNoRetry()
+	}
+	if n <= 0 || n > MaxHelperRows {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "top count must be between 1 and %d, got %d", MaxHelperRows, n).SetNoRetry()
+	}
+
+	top := kql.FromBuilder(stmt).AddLiteral("\n| top ").AddLong(n).AddLiteral(" by ").AddColumn(orderBy)
+	switch order {
+	case Ascending:
+		top.AddLiteral(" asc")
+	case Descending, "":
+		top.AddLiteral(" desc")
+	default:
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "unknown sort order %q", order).SetNoRetry()
+	}
+	return top, nil
+}
@@ -0,0 +1,108 @@
+package azkustodata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleStatement(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		n        int64
+		expected string
+		wantErr  bool
+	}{
+		{name: "Simple", table: "Logs", n: 10, expected: "Logs | sample long(10)"},
+		{name: "Escaped", table: "My Table", n: 1, expected: "[\"My Table\"] | sample long(1)"},
+		{name: "Injection", table: "T | drop", n: 1, expected: "[\"T | drop\"] | sample long(1)"},
+		{name: "EmptyTable", table: "", n: 1, wantErr: true},
+		{name: "NonPositive", table: "T", n: 0, wantErr: true},
+		{name: "Max", table: "T", n: MaxHelperRows, expected: "T | sample long(500000)"},
+		{name: "OverMax", table: "T", n: MaxHelperRows + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stmt, err := SampleStatement(tt.table, tt.n)
+			if tt.wantErr {
+				e, ok := errors.GetKustoError(err)
+				require.True(t, ok)
+				assert.Equal(t, errors.KClientArgs, e.Kind)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stmt.String())
+		})
+	}
+}
+
+func TestTopStatement(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		orderBy  string
+		n        int64
+		order    SortOrder
+		expected string
+		wantErr  bool
+	}{
+		{name: "Default", orderBy: "Timestamp", n: 5, expected: "T\n| top long(5) by Timestamp desc"},
+		{name: "Ascending", orderBy: "Timestamp", n: 5, order: Ascending, expected: "T\n| top long(5) by Timestamp asc"},
+		{name: "Escaped", orderBy: "my col", n: 5, order: Descending, expected: "T\n| top long(5) by [\"my col\"] desc"},
+		{name: "EmptyColumn", orderBy: "", n: 5, wantErr: true},
+		{name: "NonPositive", orderBy: "a", n: -1, wantErr: true},
+		{name: "OverMax", orderBy: "a", n: MaxHelperRows + 1, wantErr: true},
+		{name: "UnknownOrder", orderBy: "a", n: 1, order: "sideways", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stmt := kql.New("T")
+			top, err := TopStatement(stmt, tt.n, tt.orderBy, tt.order)
+			if tt.wantErr {
+				e, ok := errors.GetKustoError(err)
+				require.True(t, ok)
+				assert.Equal(t, errors.KClientArgs, e.Kind)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, top.String())
+			assert.Equal(t, "T", stmt.String())
+		})
+	}
+}
+
+func TestSampleAndTop(t *testing.T) {
+	t.Parallel()
+
+	client, f := newFakeClient(testV2Response)
+
+	ds, err := client.Sample(context.Background(), "db", "Logs", 2)
+	require.NoError(t, err)
+	assert.Len(t, ds.Tables()[0].Rows(), 2)
+	assert.Equal(t, "Logs | sample long(2)", f.lastCall().query)
+
+	_, err = client.Top(context.Background(), "db", kql.New("Logs"), 2, "Count")
+	require.NoError(t, err)
+	assert.Equal(t, "Logs\n| top long(2) by Count desc", f.lastCall().query)
+
+	// Counts over the cap are rejected before calling the service.
+	_, err = client.Sample(context.Background(), "db", "Logs", MaxHelperRows+1)
+	e, ok := errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.KClientArgs, e.Kind)
+	_, err = client.Top(context.Background(), "db", kql.New("Logs"), 0, "Count")
+	e, ok = errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.KClientArgs, e.Kind)
+	assert.Equal(t, "Logs\n| top long(2) by Count desc", f.lastCall().query)
+}
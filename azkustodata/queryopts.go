@@ -4,6 +4,7 @@ package azkustodata
 // it clogs up the main kusto.go file.
 
 import (
+	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/kql"
 	"time"
 
@@ -23,11 +24,14 @@ type requestProperties struct {
 }
 
 type queryOptions struct {
-	requestProperties *requestProperties
-	queryIngestion    bool
-	v2IoCapacity      int
-	v2RowCapacity     int
-	v2TableCapacity   int
+	requestProperties      *requestProperties
+	queryIngestion         bool
+	v2IoCapacity           int
+	v2RowCapacity          int
+	v2TableCapacity        int
+	serverTimeout          time.Duration
+	serverTimeoutGrace     time.Duration
+	onServerTimeoutClamped func(requested, clamped time.Duration)
 }
 
 const ResultsProgressiveEnabledValue = "results_progressive_enabled"
@@ -203,11 +207,33 @@ func ResultsProgressiveEnabled() QueryOption {
 // ServerTimeout overrides the default request timeout.
 func ServerTimeout(d time.Duration) QueryOption {
 	return func(q *queryOptions) error {
+		q.serverTimeout = d
 		q.requestProperties.Options[ServerTimeoutValue] = value.TimespanString(d)
 		return nil
 	}
 }
 
+// ServerTimeoutGrace sets the margin that is subtracted from the context deadline when it is used to calculate the server timeout,
+// so the server gives up (and reports why) before the client does.
+func ServerTimeoutGrace(d time.Duration) QueryOption {
+	return func(q *queryOptions) error {
+		if d < 0 {
+			return fmt.Errorf("server timeout grace must not be negative, got %s", d)
+		}
+		q.serverTimeoutGrace = d
+		return nil
+	}
+}
+
+// OnServerTimeoutClamped registers a callback that is called when the requested server timeout exceeds the maximum Kusto accepts (1 hour),
+// and was clamped to it. The client also logs a warning to its logger when it clamps the server timeout.
+func OnServerTimeoutClamped(f func(requested, clamped time.Duration)) QueryOption {
+	return func(q *queryOptions) error {
+		q.onServerTimeoutClamped = f
+		return nil
+	}
+}
+
 // CustomQueryOption exists to allow a QueryOption that is not defined in the Go SDK, as all options
 // are not defined. Please Note: you should always use the type safe options provided below when available.
 // Also note that Kusto does not error on non-existent parameter names or bad values, it simply doesn't
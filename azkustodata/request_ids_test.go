@@ -0,0 +1,69 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDs(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/rest/auth/metadata" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(ActivityIdHeader, "activity;"+r.Header.Get(ClientRequestIdHeader))
+		if r.Header.Get(ClientRequestIdHeader) == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"General_BadRequest","message":"Syntax error"}}`))
+			return
+		}
+		if r.URL.Path == "/v2/rest/query" {
+			_, _ = w.Write([]byte(testV2Response))
+			return
+		}
+		_, _ = w.Write([]byte(testShowTablesResponse))
+	}))
+	defer server.Close()
+
+	client, err := New(NewConnectionStringBuilder(server.URL))
+	require.NoError(t, err)
+	defer client.Close()
+
+	mgmt, err := client.Mgmt(context.Background(), "db", kql.New(".show tables"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(mgmt.ClientRequestID(), "KGC.execute;"))
+	assert.Equal(t, "activity;"+mgmt.ClientRequestID(), mgmt.ActivityID())
+
+	ds, err := client.Query(context.Background(), "db", kql.New("T"), ClientRequestID("query"))
+	require.NoError(t, err)
+	assert.Equal(t, "query", ds.ClientRequestID())
+	assert.Equal(t, "activity;query", ds.ActivityID())
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("fail"))
+	require.Error(t, err)
+	kustoErr, ok := errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, "fail", kustoErr.ClientRequestID)
+	assert.Equal(t, "activity;fail", kustoErr.ActivityID)
+
+	// Errors that don't come from the service still carry the client request ID.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.Query(ctx, "db", kql.New("T"), ClientRequestID("canceled"))
+	require.Error(t, err)
+	kustoErr, ok = errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, "canceled", kustoErr.ClientRequestID)
+	assert.Empty(t, kustoErr.ActivityID)
+	assert.ErrorIs(t, err, context.Canceled)
+}
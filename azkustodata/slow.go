@@ -0,0 +1,72 @@
+package azkustodata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// SlowOperation summarizes a query, a management command or an ingestion that took longer than the threshold set with
+// WithSlowOperationThreshold.
+type SlowOperation struct {
+	// Op is the kind of the operation, such as errors.OpQuery, errors.OpMgmt, errors.OpIngestStream or errors.OpFileIngest.
+	Op       errors.Op
+	Database string
+	// Table is the table of an ingestion, or "".
+	Table string
+	// TextHash is the SHA-256 of the text of a query or a command, in hex, which groups the calls with the same text without
+	// exposing it, or "" for an ingestion.
+	TextHash string
+	Duration time.Duration
+	// RequestID is the client request ID of a query, a command or a streaming ingestion, which identifies it in the
+	// `.show queries` and `.show commands` output, or the source ID of a queued ingestion.
+	RequestID string
+	// Err is the error the operation failed with, or nil.
+	Err error
+}
+
+// WithSlowOperationThreshold calls callback with the summary of each query and management command of the client that takes
+// longer than threshold, failed ones included, such as to alert on the latency of a service without tracing it. The duration of
+// Query and Mgmt covers the reading of the response; the one of IterativeQuery and of the raw queries ends with the response of
+// the service. callback is called from the goroutine of the call, and must return quickly.
+func WithSlowOperationThreshold(threshold time.Duration, callback func(op SlowOperation)) Option {
+	return func(c *Client) {
+		c.slowThreshold = threshold
+		c.onSlow = callback
+	}
+}
+
+// reportSlow calls the callback of WithSlowOperationThreshold if the call, which started at start, took longer than its
+// threshold.
+func (c *Client) reportSlow(start time.Time, op errors.Op, db string, stmt Statement, requestID string, err error) {
+	if c.onSlow == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed <= c.slowThreshold {
+		return
+	}
+	hash := sha256.Sum256([]byte(stmt.String()))
+	c.onSlow(SlowOperation{
+		Op:        op,
+		Database:  db,
+		TextHash:  hex.EncodeToString(hash[:]),
+		Duration:  elapsed,
+		RequestID: requestID,
+		Err:       err,
+	})
+}
+
+// requestIDOf returns the client request ID of the call that returned ds, or failed with err.
+func requestIDOf(ds query.BaseDataset, err error) string {
+	if ds != nil {
+		return ds.ClientRequestID()
+	}
+	if e, ok := errors.GetKustoError(err); ok {
+		return e.ClientRequestID
+	}
+	return ""
+}
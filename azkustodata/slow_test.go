@@ -0,0 +1,74 @@
+package azkustodata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowOperationThreshold(t *testing.T) {
+	t.Parallel()
+
+	hash := func(text string) string {
+		sum := sha256.Sum256([]byte(text))
+		return hex.EncodeToString(sum[:])
+	}
+
+	var slow []SlowOperation
+	client, _ := newFakeClient(testShowTablesResponse)
+	WithSlowOperationThreshold(0, func(op SlowOperation) {
+		slow = append(slow, op)
+	})(client)
+
+	_, err := client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("mgmt"))
+	require.NoError(t, err)
+	require.Len(t, slow, 1)
+	assert.Equal(t, errors.OpMgmt, slow[0].Op)
+	assert.Equal(t, "db", slow[0].Database)
+	assert.Equal(t, hash(".show tables"), slow[0].TextHash)
+	assert.Equal(t, "mgmt", slow[0].RequestID)
+	assert.Positive(t, slow[0].Duration)
+	assert.NoError(t, slow[0].Err)
+
+	client.readOnlyMgmt = false
+	client.conn = &fakeQueryer{body: func(callType, string) string { return testV2Response }}
+	_, err = client.Query(context.Background(), "db", kql.New("T"), ClientRequestID("query"))
+	require.NoError(t, err)
+	// The query is reported once, with the reading of its response.
+	require.Len(t, slow, 2)
+	assert.Equal(t, errors.OpQuery, slow[1].Op)
+	assert.Equal(t, hash("T"), slow[1].TextHash)
+	assert.Equal(t, "query", slow[1].RequestID)
+
+	_, err = client.IterativeQuery(context.Background(), "db", kql.New("T"), ClientRequestID("iterative"))
+	require.NoError(t, err)
+	require.Len(t, slow, 3)
+	assert.Equal(t, "iterative", slow[2].RequestID)
+
+	// Failed calls are reported with their error.
+	client.readOnlyMgmt = true
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".drop table T"))
+	require.Error(t, err)
+	require.Len(t, slow, 3, "calls rejected before they start aren't reported")
+
+	client.conn = &fakeQueryer{body: func(callType, string) string { return "not a response" }}
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("failed"))
+	require.Error(t, err)
+	require.Len(t, slow, 4)
+	assert.Equal(t, "failed", slow[3].RequestID)
+	assert.Equal(t, err, slow[3].Err)
+
+	fast, _ := newFakeClient(testShowTablesResponse)
+	WithSlowOperationThreshold(time.Hour, func(op SlowOperation) {
+		t.Errorf("the call was reported as slow: %+v", op)
+	})(fast)
+	_, err = fast.Mgmt(context.Background(), "db", kql.New(".show tables"))
+	require.NoError(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync/atomic"
 
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
 	"github.com/Azure/azure-kusto-go/azkustodata/utils"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -40,6 +41,7 @@ func (tkp *TokenProvider) AcquireToken(ctx context.Context) (string, string, err
 		if err != nil {
 			return "", "", err
 		}
+		logger.FromContext(ctx).Debug("acquired a token", "scopes", tkp.scopes, "expiresOn", token.ExpiresOn)
 		return token.Token, tkp.tokenScheme, nil
 	}
 
@@ -0,0 +1,38 @@
+package azkustodata
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracerProvider records an OpenTelemetry span for each query and management command of the client, with the tracers of tp,
+// such as the TracerProvider of the OpenTelemetry SDK, and sends the trace context with the calls in W3C Trace Context headers.
+// The spans carry the cluster, the database, the client request ID, the number of rows and the size of the response, and the
+// error codes of failed calls; see the tracing package for their attributes. Without it, no span is recorded.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracer = tracing.NewTracer(tp)
+	}
+}
+
+// startSpan starts the span of a call of the client to the database, or returns a span that records nothing if the client has
+// no tracer.
+func (c *Client) startSpan(ctx context.Context, op errors.Op, db string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, c.tracer, op, c.endpoint, db)
+}
+
+// ownedSpanKey marks a context whose span is ended by the caller of rawV2, which records the rows of the result.
+type ownedSpanKey struct{}
+
+// countRows returns the number of rows of the tables.
+func countRows(tables []query.Table) int {
+	n := 0
+	for _, t := range tables {
+		n += len(t.Rows())
+	}
+	return n
+}
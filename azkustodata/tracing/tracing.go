@@ -0,0 +1,177 @@
+// Package tracing records OpenTelemetry spans of the calls of the clients to the service, and propagates their trace context in
+// W3C Trace Context headers. It is shared by the query and the ingestion clients, and exports the attributes of the spans so they
+// can be looked up by their keys.
+package tracing
+
+import (
+	"context"
+	goErrors "errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/internal/version"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the name of the tracer the spans of the clients are recorded with.
+const TracerName = "github.com/Azure/azure-kusto-go"
+
+// The attributes of the spans.
+const (
+	// AttrOperation is the operation of the span: "query", "mgmt", "streaming_ingest" or "queued_ingest".
+	AttrOperation = attribute.Key("kusto.operation")
+	// AttrCluster is the host name of the cluster.
+	AttrCluster = attribute.Key("server.address")
+	// AttrDatabase is the database of the call.
+	AttrDatabase = attribute.Key("db.namespace")
+	// AttrTable is the table of an ingestion.
+	AttrTable = attribute.Key("kusto.table")
+	// AttrClientRequestID is the client request ID the call was sent with, which the service logs.
+	AttrClientRequestID = attribute.Key("kusto.client_request_id")
+	// AttrSourceID is the source ID of a queued ingestion, which the statuses the service reports carry.
+	AttrSourceID = attribute.Key("kusto.source_id")
+	// AttrRows is the number of rows a query or a management command returned.
+	AttrRows = attribute.Key("kusto.rows")
+	// AttrResponseBytes is the size of the response of the service, as it was received.
+	AttrResponseBytes = attribute.Key("kusto.response.bytes")
+	// AttrRawBytes is the size of the data of an ingestion, before the client compresses it, if it does.
+	AttrRawBytes = attribute.Key("kusto.ingest.raw_bytes")
+	// AttrSentBytes is the size of the data of an ingestion that was uploaded or streamed.
+	AttrSentBytes = attribute.Key("kusto.ingest.sent_bytes")
+	// AttrStatusCode is the HTTP status code of a call that failed with one.
+	AttrStatusCode = attribute.Key("http.response.status_code")
+	// AttrErrorKind is the kind of the error of a failed call, such as "KHTTPError".
+	AttrErrorKind = attribute.Key("error.type")
+	// AttrErrorCode is the error code the service returned for a failed call, such as "General_BadRequest".
+	AttrErrorCode = attribute.Key("kusto.error.code")
+)
+
+// NewTracer returns the tracer of the clients from tp, or a tracer that records nothing if tp is nil.
+func NewTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	return tp.Tracer(TracerName, trace.WithInstrumentationVersion(version.Kusto))
+}
+
+// Start starts the span of an operation on the database of the cluster at endpoint, with tracer, or returns a span that records
+// nothing if tracer is nil. It is ended with End.
+func Start(ctx context.Context, tracer trace.Tracer, op errors.Op, endpoint string, db string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	operation := operationOf(op)
+	attrs = append(attrs, AttrOperation.String(operation), AttrDatabase.String(db))
+	if u, err := url.Parse(endpoint); err == nil && u.Hostname() != "" {
+		attrs = append(attrs, AttrCluster.String(u.Hostname()))
+	}
+	return tracer.Start(ctx, "kusto."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// SetOperation changes the operation of a span started with Start, such as when a managed ingestion falls back from streaming to
+// queued ingestion.
+func SetOperation(span trace.Span, op errors.Op) {
+	operation := operationOf(op)
+	span.SetName("kusto." + operation)
+	span.SetAttributes(AttrOperation.String(operation))
+}
+
+// operationOf returns the operation of the spans of op.
+func operationOf(op errors.Op) string {
+	switch op {
+	case errors.OpMgmt:
+		return "mgmt"
+	case errors.OpIngestStream:
+		return "streaming_ingest"
+	case errors.OpFileIngest:
+		return "queued_ingest"
+	default:
+		return "query"
+	}
+}
+
+// End ends a span started with Start, with the error of the operation, if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(errorAttributes(err)...)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// errorAttributes returns the attributes of a span that failed with err.
+func errorAttributes(err error) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	var httpErr *errors.HttpError
+	if goErrors.As(err, &httpErr) {
+		attrs = append(attrs, AttrStatusCode.Int(httpErr.StatusCode))
+	}
+	if e, ok := errors.GetKustoError(err); ok {
+		attrs = append(attrs, AttrErrorKind.String(e.Kind.String()))
+		if rest, ok := e.UnmarshalREST()["error"].(map[string]interface{}); ok {
+			if code, ok := rest["code"].(string); ok {
+				attrs = append(attrs, AttrErrorCode.String(code))
+			}
+		}
+	}
+	return attrs
+}
+
+// Inject records the client request ID of a call in the span of ctx, and adds the trace context of ctx to the headers of the
+// call.
+func Inject(ctx context.Context, headers http.Header, clientRequestID string) {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(AttrClientRequestID.String(clientRequestID))
+	}
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(headers))
+}
+
+// Body is the body of a response, which records its size in the span of the call once it is read to the end or closed, and
+// then ends the span, if it owns it.
+type Body struct {
+	io.ReadCloser
+	span trace.Span
+	owns bool
+	n    int64
+	once sync.Once
+}
+
+// NewBody returns body, recording its size in span. If owns is set, the span is ended once the body is read or closed.
+func NewBody(body io.ReadCloser, span trace.Span, owns bool) *Body {
+	return &Body{ReadCloser: body, span: span, owns: owns}
+}
+
+// Read implements io.Reader.
+func (b *Body) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.done(nil)
+	} else if err != nil {
+		b.done(err)
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *Body) Close() error {
+	b.done(nil)
+	return b.ReadCloser.Close()
+}
+
+func (b *Body) done(err error) {
+	b.once.Do(func() {
+		b.span.SetAttributes(AttrResponseBytes.Int64(b.n))
+		if b.owns {
+			End(b.span, err)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/rest/auth/metadata" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if r.URL.Path == "/v2/rest/query" {
+			_, _ = w.Write([]byte(testV2Response))
+			return
+		}
+		if r.Header.Get("x-ms-client-request-id") == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"General_BadRequest","message":"Syntax error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(testShowTablesResponse))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	client, err := New(NewConnectionStringBuilder(server.URL), WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("ok"))
+	require.NoError(t, err)
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"), ClientRequestID("fail"))
+	require.Error(t, err)
+	_, err = client.Query(context.Background(), "db", kql.New("T"))
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	require.Len(t, traceparents, 3)
+
+	ok := spanAttributes(spans[0])
+	assert.Equal(t, "kusto.mgmt", spans[0].Name())
+	assert.Equal(t, "mgmt", ok[tracing.AttrOperation].AsString())
+	assert.Equal(t, "127.0.0.1", ok[tracing.AttrCluster].AsString())
+	assert.Equal(t, "db", ok[tracing.AttrDatabase].AsString())
+	assert.Equal(t, "ok", ok[tracing.AttrClientRequestID].AsString())
+	assert.Equal(t, int64(2), ok[tracing.AttrRows].AsInt64())
+	assert.Equal(t, int64(len(testShowTablesResponse)), ok[tracing.AttrResponseBytes].AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, traceparents[0], spans[0].SpanContext().TraceID().String())
+
+	failed := spanAttributes(spans[1])
+	assert.Equal(t, "fail", failed[tracing.AttrClientRequestID].AsString())
+	assert.Equal(t, int64(http.StatusBadRequest), failed[tracing.AttrStatusCode].AsInt64())
+	assert.Equal(t, "General_BadRequest", failed[tracing.AttrErrorCode].AsString())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Contains(t, traceparents[1], spans[1].SpanContext().TraceID().String())
+
+	query := spanAttributes(spans[2])
+	assert.Equal(t, "kusto.query", spans[2].Name())
+	assert.Equal(t, int64(2), query[tracing.AttrRows].AsInt64())
+	assert.Equal(t, int64(len(testV2Response)), query[tracing.AttrResponseBytes].AsInt64())
+	assert.Contains(t, traceparents[2], spans[2].SpanContext().TraceID().String())
+}
+
+func TestTracingDisabled(t *testing.T) {
+	t.Parallel()
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/rest/mgmt" {
+			traceparent = r.Header.Get("traceparent")
+		}
+		_, _ = w.Write([]byte(testShowTablesResponse))
+	}))
+	defer server.Close()
+
+	client, err := New(NewConnectionStringBuilder(server.URL))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show tables"))
+	require.NoError(t, err)
+	assert.Empty(t, traceparent)
+}
+
+// spanAttributes returns the attributes of the span by their keys.
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
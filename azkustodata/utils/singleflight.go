@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Group collapses concurrent calls that share the same key into a single execution, whose result is shared by all the callers.
+type Group[K comparable, Out any] interface {
+	// Do executes f, unless a call with the same key is already in flight, in which case it waits for it and returns its result.
+	// f runs with a context that carries the values and the deadline of ctx, but isn't canceled with it: it is canceled once
+	// every caller waiting for the call has left, or once f returns. The callers that join the call share its deadline. Each caller waits until the call ends or its own ctx is done, in which case it returns ctx.Err().
+	// If f panics, the callers get a *PanicError. shared reports whether the result was given to more than one caller.
+	Do(ctx context.Context, key K, f func(ctx context.Context) (Out, error)) (result Out, err error, shared bool)
+}
+
+// PanicError is returned by Group.Do to the callers of a call that panicked.
+type PanicError struct {
+	// Value is the value the call panicked with.
+	Value interface{}
+	// Stack is the stack of the goroutine of the call when it panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("the call panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+type call[Out any] struct {
+	// done is closed once the call ended.
+	done   chan struct{}
+	result Out
+	err    error
+	// dups is the number of callers that joined the call, and waiters the number of callers still waiting for it.
+	dups    int
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type group[K comparable, Out any] struct {
+	mutex sync.Mutex
+	calls map[K]*call[Out]
+}
+
+func NewGroup[K comparable, Out any]() Group[K, Out] {
+	return &group[K, Out]{
+		calls: map[K]*call[Out]{},
+	}
+}
+
+func (g *group[K, Out]) Do(ctx context.Context, key K, f func(ctx context.Context) (Out, error)) (Out, error, bool) {
+	g.mutex.Lock()
+	c, ok := g.calls[key]
+	if ok {
+		c.dups++
+		c.waiters++
+		g.mutex.Unlock()
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if deadline, ok := ctx.Deadline(); ok {
+			callCtx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline)
+		}
+		c = &call[Out]{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = c
+		g.mutex.Unlock()
+		go g.run(callCtx, key, c, f)
+	}
+
+	select {
+	case <-c.done:
+		g.mutex.Lock()
+		shared := c.dups > 0
+		g.mutex.Unlock()
+		return c.result, c.err, shared
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero Out
+		return zero, ctx.Err(), false
+	}
+}
+
+// run executes f for the call, and hands its result, or its panic, to the callers.
+func (g *group[K, Out]) run(ctx context.Context, key K, c *call[Out], f func(ctx context.Context) (Out, error)) {
+	defer close(c.done)
+	defer c.cancel()
+	defer func() {
+		g.mutex.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mutex.Unlock()
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	c.result, c.err = f(ctx)
+}
+
+// leave removes a caller that stopped waiting for the call, and cancels the call once no caller waits for it anymore. A canceled
+// call isn't joined by new callers.
+func (g *group[K, Out]) leave(key K, c *call[Out]) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.cancel()
+}
@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup[string, int]()
+	release := make(chan struct{})
+	var calls int32
+
+	const callers = 10
+	wg := sync.WaitGroup{}
+	started := sync.WaitGroup{}
+	results := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			started.Done()
+			res, err, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 42, nil
+			})
+			assert.NoError(t, err)
+			results[i] = res
+		}(i)
+	}
+
+	started.Wait()
+	close(release)
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&calls), int32(callers))
+	for _, r := range results {
+		assert.Equal(t, 42, r)
+	}
+}
+
+func TestGroupShared(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup[string, int]()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	testErr := errors.New("test")
+
+	var firstShared bool
+	done := make(chan struct{})
+	go func() {
+		_, err, shared := g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			close(entered)
+			<-release
+			return 0, testErr
+		})
+		assert.ErrorIs(t, err, testErr)
+		firstShared = shared
+		close(done)
+	}()
+
+	<-entered
+	secondDone := make(chan struct{})
+	go func() {
+		_, err, shared := g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			t.Error("the second call should not be executed")
+			return 0, nil
+		})
+		assert.ErrorIs(t, err, testErr)
+		assert.True(t, shared)
+		close(secondDone)
+	}()
+
+	// A different key is not collapsed.
+	res, err, shared := g.Do(context.Background(), "other", func(context.Context) (int, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res)
+	assert.False(t, shared)
+
+	// Wait for the second caller to register before releasing the first.
+	for {
+		g.(*group[string, int]).mutex.Lock()
+		dups := g.(*group[string, int]).calls["key"].dups
+		g.(*group[string, int]).mutex.Unlock()
+		if dups > 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	<-done
+	<-secondDone
+	assert.True(t, firstShared)
+}
+
+func TestGroupWaitersLeave(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup[string, int]()
+	entered := make(chan struct{})
+	canceled := make(chan struct{})
+	first, cancelFirst := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err, _ := g.Do(first, "key", func(ctx context.Context) (int, error) {
+			close(entered)
+			<-ctx.Done()
+			close(canceled)
+			return 0, ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	<-entered
+
+	// A caller that joins the call leaves at its own deadline.
+	short, cancelShort := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelShort()
+	_, err, _ := g.Do(short, "key", func(context.Context) (int, error) {
+		t.Error("the call should be joined")
+		return 0, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The call goes on while the first caller waits for it, and is canceled once it leaves too.
+	select {
+	case <-canceled:
+		t.Fatal("the call was canceled while a caller waited for it")
+	default:
+	}
+	cancelFirst()
+	<-done
+	<-canceled
+
+	// A new call is made once the canceled one was left.
+	res, err, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res)
+}
+
+func TestGroupFirstCallerLeaves(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup[string, int]()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	first, cancelFirst := context.WithCancel(context.Background())
+	go func() {
+		_, err, _ := g.Do(first, "key", func(ctx context.Context) (int, error) {
+			close(entered)
+			select {
+			case <-release:
+				return 42, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	<-entered
+
+	second := make(chan int)
+	go func() {
+		res, err, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			t.Error("the call should be joined")
+			return 0, nil
+		})
+		assert.NoError(t, err)
+		second <- res
+	}()
+	for {
+		g.(*group[string, int]).mutex.Lock()
+		dups := g.(*group[string, int]).calls["key"].dups
+		g.(*group[string, int]).mutex.Unlock()
+		if dups > 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+
+	// The call isn't canceled with the first caller, as the second one still waits for it.
+	cancelFirst()
+	close(release)
+	assert.Equal(t, 42, <-second)
+}
+
+func TestGroupPanic(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup[string, int]()
+	_, err, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) {
+		panic("boom")
+	})
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+
+	// The key is free again.
+	res, err, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res)
+}
+
+func TestGroupDeadline(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup[string, int]()
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	var callCtx context.Context
+	_, err, _ := g.Do(ctx, "key", func(ctx context.Context) (int, error) {
+		callCtx = ctx
+		got, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.True(t, deadline.Equal(got))
+		return 1, nil
+	})
+	require.NoError(t, err)
+	// The context of the call is released once the call returns.
+	assert.ErrorIs(t, callCtx.Err(), context.Canceled)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"reflect"
+	"strconv"
 )
 
 // Bool represents a Kusto boolean type. Bool implements Kusto.
@@ -57,3 +58,16 @@ func (bo *Bool) Unmarshal(i interface{}) error {
 func (bo *Bool) GetType() types.Column {
 	return types.Bool
 }
+
+// MarshalJSON implements json.Marshaler. A null value is marshaled as JSON null.
+func (bo *Bool) MarshalJSON() ([]byte, error) {
+	if bo.value == nil {
+		return nullJSON, nil
+	}
+	return strconv.AppendBool(nil, *bo.value), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (bo *Bool) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(bo, b)
+}
@@ -4,9 +4,15 @@ import (
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"reflect"
+	"strconv"
 	"time"
 )
 
+// unixEpochTicks is the amount of ticks between 0001-01-01T00:00:00Z, where Kusto ticks start, and the Unix epoch.
+const unixEpochTicks = 621355968000000000
+
+const ticksPerSecond = int64(time.Second / tick)
+
 // DateTime represents a Kusto datetime type.  DateTime implements Kusto.
 type DateTime struct {
 	pointerValue[time.Time]
@@ -17,6 +23,11 @@ func NewDateTime(v time.Time) *DateTime {
 	return &DateTime{newPointerValue[time.Time](&v)}
 }
 
+// DateTimeFromTicks creates a new DateTime from Kusto ticks (see Ticks).
+func DateTimeFromTicks(ticks int64) *DateTime {
+	return NewDateTime(TicksToTime(ticks))
+}
+
 // NewNullDateTime creates a new null DateTime.
 func NewNullDateTime() *DateTime {
 	return &DateTime{newPointerValue[time.Time](nil)}
@@ -68,3 +79,57 @@ func (d *DateTime) Convert(v reflect.Value) error {
 func (d *DateTime) GetType() types.Column {
 	return types.DateTime
 }
+
+// MarshalJSON implements json.Marshaler. The value is marshaled as an RFC3339 string with all its fractional digits,
+// so the 100ns precision of Kusto datetimes is kept. A null value is marshaled as JSON null.
+func (d *DateTime) MarshalJSON() ([]byte, error) {
+	if d.value == nil {
+		return nullJSON, nil
+	}
+	return strconv.AppendQuote(nil, d.value.Format(time.RFC3339Nano)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *DateTime) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(d, b)
+}
+
+// Ticks returns the value as Kusto ticks: the amount of 100ns intervals since 0001-01-01T00:00:00Z, as returned by tolong() on a datetime.
+// ok is false if the value is null.
+func (d *DateTime) Ticks() (ticks int64, ok bool) {
+	if d.value == nil {
+		return 0, false
+	}
+	return TimeToTicks(*d.value), true
+}
+
+// Equal reports whether both values are null, or hold the same instant at tick precision, regardless of their location.
+// Nanoseconds below a tick are ignored, as Kusto doesn't store them.
+func (d *DateTime) Equal(other *DateTime) bool {
+	if d.value == nil || other.value == nil {
+		return d.value == nil && other.value == nil
+	}
+	return TimeToTicks(*d.value) == TimeToTicks(*other.value)
+}
+
+// TimeToTicks converts t to Kusto ticks (see DateTime.Ticks), truncating the nanoseconds below a tick.
+// Ticks are exact, so they can be used as keys when deduplicating rows by timestamp.
+func TimeToTicks(t time.Time) int64 {
+	return (t.Unix()*ticksPerSecond + unixEpochTicks) + int64(t.Nanosecond())/int64(tick)
+}
+
+// TicksToTime converts Kusto ticks (see DateTime.Ticks) to a time in UTC.
+func TicksToTime(ticks int64) time.Time {
+	ticks -= unixEpochTicks
+	sec, rem := ticks/ticksPerSecond, ticks%ticksPerSecond
+	if rem < 0 {
+		sec--
+		rem += ticksPerSecond
+	}
+	return time.Unix(sec, rem*int64(tick)).UTC()
+}
+
+// TruncateToTick truncates t to the 100ns precision of Kusto datetimes, so it compares equal to the value Kusto returns for it.
+func TruncateToTick(t time.Time) time.Time {
+	return TicksToTime(TimeToTicks(t)).In(t.Location())
+}
@@ -1,13 +1,17 @@
 package value
 
 import (
+	"encoding"
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/shopspring/decimal"
 	"math/big"
 	"reflect"
+	"strconv"
 )
 
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
 // Decimal represents a Kusto decimal type.  Decimal implements Kusto.
 type Decimal struct {
 	pointerValue[decimal.Decimal]
@@ -43,6 +47,33 @@ func (d *Decimal) ParseFloat(base int, prec uint, mode big.RoundingMode) (f *big
 	return big.ParseFloat(d.value.String(), base, prec, mode)
 }
 
+// BigRat returns the value as an exact *big.Rat. It returns nil if the value is null.
+func (d *Decimal) BigRat() *big.Rat {
+	if d.value == nil {
+		return nil
+	}
+	return d.value.Rat()
+}
+
+// BigFloat returns the value as a *big.Float, with enough precision to hold all the digits of the decimal.
+// It returns nil if the value is null.
+func (d *Decimal) BigFloat() *big.Float {
+	if d.value == nil {
+		return nil
+	}
+	f, _, err := big.ParseFloat(d.value.String(), 10, decimalPrecision(d.value), big.ToNearestEven)
+	if err != nil {
+		// The string representation of a decimal.Decimal is always a valid float.
+		panic(err)
+	}
+	return f
+}
+
+// decimalPrecision returns the amount of bits needed to represent the digits of the decimal without loss (log2(10) < 4 bits per digit).
+func decimalPrecision(d *decimal.Decimal) uint {
+	return uint(len(d.Coefficient().String()))*4 + 64
+}
+
 // Unmarshal unmarshals i into Decimal. i must be a string representing a decimal type or nil.
 func (d *Decimal) Unmarshal(i interface{}) error {
 	if i == nil {
@@ -78,6 +109,37 @@ func (d *Decimal) Convert(v reflect.Value) error {
 		return nil
 	}
 
+	switch v.Type() {
+	case reflect.TypeOf(big.Rat{}):
+		v.Set(reflect.ValueOf(*d.BigRat()))
+		return nil
+	case reflect.TypeOf(&big.Rat{}):
+		v.Set(reflect.ValueOf(d.BigRat()))
+		return nil
+	case reflect.TypeOf(big.Float{}):
+		v.Set(reflect.ValueOf(*d.BigFloat()))
+		return nil
+	case reflect.TypeOf(&big.Float{}):
+		v.Set(reflect.ValueOf(d.BigFloat()))
+		return nil
+	}
+
+	// Other arbitrary-precision types can be supported by implementing encoding.TextUnmarshaler.
+	if v.Kind() == reflect.Pointer && v.Type().Implements(textUnmarshalerType) {
+		target := reflect.New(v.Type().Elem())
+		if err := target.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(d.value.String())); err != nil {
+			return parseError(d, d.value.String(), err)
+		}
+		v.Set(target)
+		return nil
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(d.value.String())); err != nil {
+			return parseError(d, d.value.String(), err)
+		}
+		return nil
+	}
+
 	return convertError(d, v)
 }
 
@@ -85,3 +147,17 @@ func (d *Decimal) Convert(v reflect.Value) error {
 func (d *Decimal) GetType() types.Column {
 	return types.Decimal
 }
+
+// MarshalJSON implements json.Marshaler. The decimal is marshaled as a string, so none of its digits are lost.
+// A null value is marshaled as JSON null.
+func (d *Decimal) MarshalJSON() ([]byte, error) {
+	if d.value == nil {
+		return nullJSON, nil
+	}
+	return strconv.AppendQuote(nil, d.value.String()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(d, b)
+}
@@ -0,0 +1,74 @@
+package value
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textDecimal is an arbitrary-precision type that is supported through encoding.TextUnmarshaler.
+type textDecimal struct {
+	text string
+}
+
+func (t *textDecimal) UnmarshalText(text []byte) error {
+	t.text = string(text)
+	return nil
+}
+
+func TestDecimalBig(t *testing.T) {
+	t.Parallel()
+
+	const digits = "123456789012345678901234567890.123456789"
+	d := DecimalFromString(digits)
+
+	expectedRat, ok := new(big.Rat).SetString(digits)
+	require.True(t, ok)
+	assert.Equal(t, 0, expectedRat.Cmp(d.BigRat()))
+
+	f := d.BigFloat()
+	require.NotNil(t, f)
+	assert.Equal(t, digits, f.Text('f', 9))
+
+	assert.Nil(t, NewNullDecimal().BigRat())
+	assert.Nil(t, NewNullDecimal().BigFloat())
+}
+
+func TestDecimalConvert(t *testing.T) {
+	t.Parallel()
+
+	const digits = "79228162514264337593543950335.5"
+	d := DecimalFromString(digits)
+	expectedRat, _ := new(big.Rat).SetString(digits)
+
+	s := struct {
+		Rat      big.Rat
+		RatPtr   *big.Rat
+		Float    big.Float
+		FloatPtr *big.Float
+		Text     textDecimal
+		TextPtr  *textDecimal
+	}{}
+	v := reflect.ValueOf(&s).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		require.NoError(t, d.Convert(v.Field(i)), v.Type().Field(i).Name)
+	}
+
+	assert.Equal(t, 0, expectedRat.Cmp(&s.Rat))
+	assert.Equal(t, 0, expectedRat.Cmp(s.RatPtr))
+	assert.Equal(t, digits, s.Float.Text('f', 1))
+	assert.Equal(t, digits, s.FloatPtr.Text('f', 1))
+	assert.Equal(t, digits, s.Text.text)
+	assert.Equal(t, digits, s.TextPtr.text)
+
+	null := NewNullDecimal()
+	require.NoError(t, null.Convert(v.FieldByName("RatPtr")))
+	assert.Nil(t, s.RatPtr)
+
+	var i int
+	assert.Error(t, d.Convert(reflect.ValueOf(&i).Elem()))
+}
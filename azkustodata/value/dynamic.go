@@ -40,6 +40,19 @@ func DynamicFromInterface(v interface{}) *Dynamic {
 
 func (*Dynamic) isKustoVal() {}
 
+// UnmarshalInto decodes the JSON held by the Dynamic into v, which must be a non-nil pointer to a struct, map, slice or any other type
+// that encoding/json can decode into. A null Dynamic is decoded as a JSON null.
+func (d *Dynamic) UnmarshalInto(v interface{}) error {
+	data := d.Value
+	if data == nil {
+		data = []byte("null")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return parseError(d, d.String(), err)
+	}
+	return nil
+}
+
 // Unmarshal unmarshal's i into Dynamic. i must be a string, []byte, map[string]interface{}, []interface{}, other JSON serializable value or nil.
 // If []byte or string, must be a JSON representation of a value.
 func (d *Dynamic) Unmarshal(i interface{}) error {
@@ -104,6 +117,13 @@ func (d *Dynamic) Convert(v reflect.Value) error {
 		}
 
 		valueToSet = structPtr.Elem()
+	case t.Kind() == reflect.Interface || t.Kind() == reflect.Bool || isNumericKind(t.Kind()):
+		ptr := reflect.New(t)
+		if err := json.Unmarshal(d.Value, ptr.Interface()); err != nil {
+			return fmt.Errorf("Could not unmarshal type dynamic into a %s: %s", t.Kind(), err)
+		}
+
+		valueToSet = ptr.Elem()
 	default:
 		return fmt.Errorf("Column was type Kusto.Dynamic, receiver had base Kind %s ", t.Kind())
 	}
@@ -123,3 +143,35 @@ func (d *Dynamic) Convert(v reflect.Value) error {
 func (d *Dynamic) GetType() types.Column {
 	return types.Dynamic
 }
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler. The value is marshaled as the JSON it holds, and a null value as JSON null.
+// If the value doesn't hold valid JSON, it is marshaled as a string.
+func (d *Dynamic) MarshalJSON() ([]byte, error) {
+	if d.Value == nil {
+		return nullJSON, nil
+	}
+	if !json.Valid(d.Value) {
+		return json.Marshal(string(d.Value))
+	}
+	return d.Value, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The JSON is kept as-is, and JSON null is unmarshaled as a null value.
+func (d *Dynamic) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		d.Value = nil
+		return nil
+	}
+	d.Value = append([]byte(nil), b...)
+	return nil
+}
@@ -27,12 +27,23 @@ type TestStruct struct {
 	ID   int64  `json:"id"`
 }
 
+type NestedTestStruct struct {
+	Inner TestStruct `json:"inner"`
+	Tags  []string   `json:"tags"`
+}
+
 func TestDynamicConverter(t *testing.T) {
 	t.Parallel()
 
 	wantByteArray := []byte(`hello`)
 	emptyStr := ""
 	wantStr := "hello"
+	var wantAny interface{}
+	var wantAnyResult interface{} = map[string]interface{}{"name": "A", "id": float64(1)}
+	var wantInt int64
+	wantIntResult := int64(42)
+	var wantBool bool
+	wantBoolResult := true
 
 	testCases := []DynamicConverterTestCase{
 		{
@@ -121,6 +132,33 @@ func TestDynamicConverter(t *testing.T) {
 				},
 			},
 		},
+		{
+			Desc:   "convert to nested struct",
+			Value:  *value.NewDynamic([]byte(`{"inner":{"name":"A","id":1},"tags":["x"]}`)),
+			Target: reflect.ValueOf(&NestedTestStruct{}),
+			Want: &NestedTestStruct{
+				Inner: TestStruct{Name: "A", ID: 1},
+				Tags:  []string{"x"},
+			},
+		},
+		{
+			Desc:   "convert to interface{}",
+			Value:  *value.NewDynamic([]byte(`{"name":"A","id":1}`)),
+			Target: reflect.ValueOf(&wantAny),
+			Want:   &wantAnyResult,
+		},
+		{
+			Desc:   "convert to int64",
+			Value:  *value.NewDynamic([]byte(`42`)),
+			Target: reflect.ValueOf(&wantInt),
+			Want:   &wantIntResult,
+		},
+		{
+			Desc:   "convert to bool",
+			Value:  *value.NewDynamic([]byte(`true`)),
+			Target: reflect.ValueOf(&wantBool),
+			Want:   &wantBoolResult,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -210,3 +248,24 @@ func TestDynamicConverterNegative(t *testing.T) {
 
 	}
 }
+
+func TestDynamicUnmarshalInto(t *testing.T) {
+	t.Parallel()
+
+	d := value.NewDynamic([]byte(`{"inner":{"name":"A","id":1},"tags":["x","y"]}`))
+
+	var s NestedTestStruct
+	assert.NoError(t, d.UnmarshalInto(&s))
+	assert.Equal(t, NestedTestStruct{Inner: TestStruct{Name: "A", ID: 1}, Tags: []string{"x", "y"}}, s)
+
+	var m map[string]interface{}
+	assert.NoError(t, d.UnmarshalInto(&m))
+	assert.Equal(t, []interface{}{"x", "y"}, m["tags"])
+
+	var p *NestedTestStruct
+	assert.NoError(t, value.NewNullDynamic().UnmarshalInto(&p))
+	assert.Nil(t, p)
+
+	var wrong []int
+	assert.Error(t, d.UnmarshalInto(&wrong))
+}
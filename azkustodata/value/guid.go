@@ -1,8 +1,10 @@
 package value
 
 import (
+	"database/sql/driver"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"reflect"
+	"strconv"
 
 	"github.com/google/uuid"
 )
@@ -37,8 +39,100 @@ func (g *GUID) Unmarshal(i interface{}) error {
 	return nil
 }
 
-// Convert GUID into reflect value.
+// NewGUIDFromNullUUID creates a new GUID from a uuid.NullUUID, which is null if n is not valid.
+func NewGUIDFromNullUUID(n uuid.NullUUID) *GUID {
+	if !n.Valid {
+		return NewNullGUID()
+	}
+	return NewGUID(n.UUID)
+}
+
+// GUIDFromString parses a GUID in any of the forms accepted by uuid.Parse.
+func GUIDFromString(s string) (*GUID, error) {
+	g := &GUID{}
+	if err := g.Unmarshal(s); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// UUID returns the value, and false if it is null.
+func (g *GUID) UUID() (uuid.UUID, bool) {
+	if g.value == nil {
+		return uuid.Nil, false
+	}
+	return *g.value, true
+}
+
+// NullUUID returns the value as a uuid.NullUUID, which is not valid if the value is null.
+func (g *GUID) NullUUID() uuid.NullUUID {
+	if g.value == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *g.value, Valid: true}
+}
+
+// Scan implements sql.Scanner. src can be nil, a uuid.UUID, a string or a []byte holding the text form or the 16 raw bytes.
+func (g *GUID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		g.value = nil
+	case uuid.UUID:
+		g.value = &v
+	case []byte:
+		if len(v) == 16 {
+			u, err := uuid.FromBytes(v)
+			if err != nil {
+				return parseError(g, src, err)
+			}
+			g.value = &u
+			return nil
+		}
+		return g.Unmarshal(string(v))
+	case string:
+		return g.Unmarshal(v)
+	default:
+		return convertError(g, src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer. The value is the canonical string form, or nil if the value is null.
+func (g *GUID) Value() (driver.Value, error) {
+	if g.value == nil {
+		return nil, nil
+	}
+	return g.value.String(), nil
+}
+
+// Convert GUID into reflect value. Besides uuid.UUID and *uuid.UUID, fields can be a uuid.NullUUID, a string,
+// or any type convertible from uuid.UUID, such as [16]byte.
 func (g *GUID) Convert(v reflect.Value) error {
+	switch v.Type() {
+	case nullUUIDType:
+		v.Set(reflect.ValueOf(g.NullUUID()))
+		return nil
+	case reflect.PtrTo(nullUUIDType):
+		n := g.NullUUID()
+		v.Set(reflect.ValueOf(&n))
+		return nil
+	}
+
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(g.String())
+		return nil
+	case v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.String:
+		if g.value == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().SetString(g.value.String())
+		v.Set(p)
+		return nil
+	}
+
 	return Convert[uuid.UUID](*g, &g.pointerValue, v)
 }
 
@@ -46,3 +140,16 @@ func (g *GUID) Convert(v reflect.Value) error {
 func (g *GUID) GetType() types.Column {
 	return types.GUID
 }
+
+// MarshalJSON implements json.Marshaler. A null value is marshaled as JSON null.
+func (g *GUID) MarshalJSON() ([]byte, error) {
+	if g.value == nil {
+		return nullJSON, nil
+	}
+	return strconv.AppendQuote(nil, g.value.String()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (g *GUID) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(g, b)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"math"
 	"reflect"
+	"strconv"
 )
 
 // Int represents a Kusto boolean type. Bool implements Kusto.
@@ -75,3 +76,16 @@ func (in *Int) Unmarshal(i interface{}) error {
 	in.value = &val
 	return nil
 }
+
+// MarshalJSON implements json.Marshaler. A null value is marshaled as JSON null.
+func (in *Int) MarshalJSON() ([]byte, error) {
+	if in.value == nil {
+		return nullJSON, nil
+	}
+	return strconv.AppendInt(nil, int64(*in.value), 10), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (in *Int) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(in, b)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"math"
 	"reflect"
+	"strconv"
 )
 
 // Long represents a Kusto long type, which is an int64.  Long implements Kusto.
@@ -66,3 +67,16 @@ func (l *Long) Convert(v reflect.Value) error {
 
 // GetType returns the type of the value.
 func (l *Long) GetType() types.Column { return types.Long }
+
+// MarshalJSON implements json.Marshaler. A null value is marshaled as JSON null.
+func (l *Long) MarshalJSON() ([]byte, error) {
+	if l.value == nil {
+		return nullJSON, nil
+	}
+	return strconv.AppendInt(nil, *l.value, 10), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *Long) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(l, b)
+}
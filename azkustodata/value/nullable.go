@@ -0,0 +1,126 @@
+package value
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Nullable holds a value of a Go type that may be null in Kusto.
+// It gives the same null handling to every column type, and can be used as a struct field when decoding rows:
+//
+//	type Record struct {
+//		Count value.Nullable[int64]     `kusto:"Count"`
+//		When  value.Nullable[time.Time] `kusto:"When"`
+//	}
+type Nullable[T any] struct {
+	// Value holds the value, and is the zero value of T when the value is null.
+	Value T
+	// Valid is true if the value is not null.
+	Valid bool
+}
+
+// Scanner is implemented by types that can decode themselves from a Kusto value, such as Nullable.
+// Row.ToStruct uses it for struct fields whose pointer implements it.
+type Scanner interface {
+	ScanKusto(k Kusto) error
+}
+
+// NewNullable returns a valid Nullable holding v.
+func NewNullable[T any](v T) Nullable[T] {
+	return Nullable[T]{Value: v, Valid: true}
+}
+
+// Null returns a null Nullable.
+func Null[T any]() Nullable[T] {
+	return Nullable[T]{}
+}
+
+// NullableFromPtr returns a Nullable holding *p, or a null Nullable if p is nil.
+func NullableFromPtr[T any](p *T) Nullable[T] {
+	if p == nil {
+		return Null[T]()
+	}
+	return NewNullable(*p)
+}
+
+// NullableFromKusto converts a Kusto value into a Nullable.
+func NullableFromKusto[T any](k Kusto) (Nullable[T], error) {
+	n := Nullable[T]{}
+	err := n.ScanKusto(k)
+	return n, err
+}
+
+// ValueOr returns the value, or def if the value is null.
+func (n Nullable[T]) ValueOr(def T) T {
+	if !n.Valid {
+		return def
+	}
+	return n.Value
+}
+
+// Ptr returns a pointer to a copy of the value, or nil if the value is null.
+func (n Nullable[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Value
+	return &v
+}
+
+// ScanKusto implements Scanner.
+func (n *Nullable[T]) ScanKusto(k Kusto) error {
+	if k == nil || IsNull(k) {
+		*n = Null[T]()
+		return nil
+	}
+	var v T
+	if err := k.Convert(reflect.ValueOf(&v).Elem()); err != nil {
+		return err
+	}
+	*n = NewNullable(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. A null value is marshaled as JSON null.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. JSON null is unmarshaled as a null value.
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*n = Null[T]()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*n = NewNullable(v)
+	return nil
+}
+
+// kustoValue implements kustoValuer, so ValueOf and RecordFromStruct can encode Nullable fields.
+func (n Nullable[T]) kustoValue() (Kusto, error) {
+	if !n.Valid {
+		return nullOf(reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return ValueOf(n.Value)
+}
+
+// IsNull reports whether k holds a Kusto null.
+// Kusto strings can't be null, so a String is never null.
+func IsNull(k Kusto) bool {
+	rv := reflect.ValueOf(k.GetValue())
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,108 @@
+package value
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullable(t *testing.T) {
+	t.Parallel()
+
+	valid := NewNullable[int64](5)
+	null := Null[int64]()
+
+	assert.Equal(t, int64(5), valid.ValueOr(7))
+	assert.Equal(t, int64(7), null.ValueOr(7))
+	require.NotNil(t, valid.Ptr())
+	assert.Equal(t, int64(5), *valid.Ptr())
+	assert.Nil(t, null.Ptr())
+
+	five := int64(5)
+	assert.Equal(t, valid, NullableFromPtr(&five))
+	assert.Equal(t, null, NullableFromPtr[int64](nil))
+}
+
+func TestNullableScanKusto(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		desc  string
+		k     Kusto
+		scan  func(k Kusto) (interface{}, error)
+		want  interface{}
+		isErr bool
+	}{
+		{
+			desc: "long",
+			k:    NewLong(3),
+			scan: func(k Kusto) (interface{}, error) { return NullableFromKusto[int64](k) },
+			want: NewNullable[int64](3),
+		},
+		{
+			desc: "null long",
+			k:    NewNullLong(),
+			scan: func(k Kusto) (interface{}, error) { return NullableFromKusto[int64](k) },
+			want: Null[int64](),
+		},
+		{
+			desc: "datetime",
+			k:    NewDateTime(ts),
+			scan: func(k Kusto) (interface{}, error) { return NullableFromKusto[time.Time](k) },
+			want: NewNullable(ts),
+		},
+		{
+			desc: "null dynamic",
+			k:    NewNullDynamic(),
+			scan: func(k Kusto) (interface{}, error) { return NullableFromKusto[map[string]interface{}](k) },
+			want: Null[map[string]interface{}](),
+		},
+		{
+			desc: "string is never null",
+			k:    NewString(""),
+			scan: func(k Kusto) (interface{}, error) { return NullableFromKusto[string](k) },
+			want: NewNullable(""),
+		},
+		{
+			desc:  "wrong type",
+			k:     NewString("a"),
+			scan:  func(k Kusto) (interface{}, error) { return NullableFromKusto[int64](k) },
+			isErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+			got, err := test.scan(test.k)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestNullableJSON(t *testing.T) {
+	t.Parallel()
+
+	type rec struct {
+		A Nullable[int64]  `json:"a"`
+		B Nullable[string] `json:"b"`
+	}
+
+	b, err := json.Marshal(rec{A: NewNullable[int64](1), B: Null[string]()})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":1,"b":null}`, string(b))
+
+	var r rec
+	require.NoError(t, json.Unmarshal([]byte(`{"a":null,"b":"x"}`), &r))
+	assert.Equal(t, rec{A: Null[int64](), B: NewNullable("x")}, r)
+}
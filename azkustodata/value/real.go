@@ -3,6 +3,7 @@ package value
 import (
 	"encoding/json"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"math"
 	"reflect"
 	"strconv"
 )
@@ -71,3 +72,26 @@ func (r *Real) Convert(v reflect.Value) error {
 func (r *Real) GetType() types.Column {
 	return types.Real
 }
+
+// MarshalJSON implements json.Marshaler. A null value is marshaled as JSON null.
+// Non-finite values, which JSON numbers can't hold, are marshaled as the strings "NaN", "Infinity" and "-Infinity", like Kusto does.
+func (r *Real) MarshalJSON() ([]byte, error) {
+	if r.value == nil {
+		return nullJSON, nil
+	}
+	v := *r.value
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Real) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(r, b)
+}
@@ -0,0 +1,283 @@
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	kustoType      = reflect.TypeOf((*Kusto)(nil)).Elem()
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	nullUUIDType   = reflect.TypeOf(uuid.NullUUID{})
+	decimalType    = reflect.TypeOf(decimal.Decimal{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// kustoValuer is implemented by Nullable, whose type parameter can't be inspected through an interface.
+type kustoValuer interface {
+	kustoValue() (Kusto, error)
+}
+
+// Field is a named value of a Record.
+type Field struct {
+	Name  string
+	Value Kusto
+}
+
+// Record is an ordered list of named values, such as a row to ingest or a set of query parameters.
+type Record []Field
+
+// RecordFromStruct converts the exported fields of a struct, or a pointer to a struct, into a Record.
+// It uses the same `kusto` tags as Row.ToStruct: the tag holds the column name, a field without a tag uses its field name,
+// and fields tagged with "-" are skipped. The Kusto type of each field is inferred by ValueOf.
+func RecordFromStruct(v interface{}) (Record, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "RecordFromStruct received a nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "RecordFromStruct expects a struct, got %T", v)
+	}
+
+	t := rv.Type()
+	record := make(Record, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag := strings.TrimSpace(field.Tag.Get("kusto")); tag != "" {
+			name = tag
+		}
+		if name == "-" {
+			continue
+		}
+
+		k, err := valueOf(rv.Field(i))
+		if err != nil {
+			return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "field %s: %s", field.Name, err)
+		}
+		record = append(record, Field{Name: name, Value: k})
+	}
+	return record, nil
+}
+
+// Names returns the names of the fields, in order.
+func (r Record) Names() []string {
+	names := make([]string, len(r))
+	for i, f := range r {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Values returns the values of the fields, in order.
+func (r Record) Values() Values {
+	values := make(Values, len(r))
+	for i, f := range r {
+		values[i] = f.Value
+	}
+	return values
+}
+
+// MarshalJSON implements json.Marshaler. The record is marshaled as a JSON object with the fields in order,
+// which can be ingested with the JSON format and a mapping by name.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		v, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ValueOf converts a Go value into a Kusto value, inferring its Kusto type:
+//
+//	bool                                    -> bool
+//	int8, int16, int32, uint8, uint16        -> int
+//	int, int64, uint, uint32, uint64         -> long (uint64 values must fit in an int64)
+//	float32, float64                         -> real
+//	string                                   -> string
+//	time.Time                                -> datetime
+//	time.Duration                            -> timespan
+//	uuid.UUID, uuid.NullUUID                 -> guid
+//	decimal.Decimal                          -> decimal
+//	json.RawMessage                          -> dynamic, as-is
+//	maps, slices, arrays and other structs   -> dynamic, marshaled with encoding/json
+//
+// A value.Kusto is returned as-is. A nil pointer or a null Nullable becomes a null value of the pointed type.
+func ValueOf(i interface{}) (Kusto, error) {
+	if i == nil {
+		return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "cannot infer the Kusto type of nil")
+	}
+	return valueOf(reflect.ValueOf(i))
+}
+
+func valueOf(rv reflect.Value) (Kusto, error) {
+	if rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "cannot infer the Kusto type of a nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Ptr && reflect.PtrTo(rv.Type()).Implements(kustoType) {
+		// A value type such as value.Long, whose methods have pointer receivers. Copy it, so the result doesn't alias v.
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		return p.Interface().(Kusto), nil
+	}
+	if rv.Type().Implements(kustoType) {
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return Default(reflect.New(rv.Type().Elem()).Interface().(Kusto).GetType()), nil
+		}
+		return rv.Interface().(Kusto), nil
+	}
+	if n, ok := rv.Interface().(kustoValuer); ok {
+		return n.kustoValue()
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nullOf(rv.Type().Elem())
+		}
+		return valueOf(rv.Elem())
+	}
+
+	switch rv.Type() {
+	case timeType:
+		return NewDateTime(rv.Interface().(time.Time)), nil
+	case durationType:
+		return NewTimespan(time.Duration(rv.Int())), nil
+	case uuidType:
+		return NewGUID(rv.Interface().(uuid.UUID)), nil
+	case nullUUIDType:
+		return NewGUIDFromNullUUID(rv.Interface().(uuid.NullUUID)), nil
+	case decimalType:
+		return NewDecimal(rv.Interface().(decimal.Decimal)), nil
+	case rawMessageType:
+		if rv.IsNil() {
+			return NewNullDynamic(), nil
+		}
+		return NewDynamic(append([]byte(nil), rv.Bytes()...)), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return NewBool(rv.Bool()), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return NewInt(int32(rv.Int())), nil
+	case reflect.Uint8, reflect.Uint16:
+		return NewInt(int32(rv.Uint())), nil
+	case reflect.Int, reflect.Int64:
+		return NewLong(rv.Int()), nil
+	case reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "value %d overflows a Kusto long", rv.Uint())
+		}
+		return NewLong(int64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return NewReal(rv.Float()), nil
+	case reflect.String:
+		return NewString(rv.String()), nil
+	case reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return NewNullDynamic(), nil
+		}
+		fallthrough
+	case reflect.Array, reflect.Struct, reflect.Interface:
+		b, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "could not marshal %s as dynamic: %s", rv.Type(), err)
+		}
+		return NewDynamic(b), nil
+	}
+	return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "cannot infer the Kusto type of %s", rv.Type())
+}
+
+// nullOf returns a null value of the Kusto type inferred for t.
+func nullOf(t reflect.Type) (Kusto, error) {
+	if n, ok := reflect.Zero(t).Interface().(kustoValuer); ok {
+		return n.kustoValue()
+	}
+	col, ok := ColumnTypeOf(t)
+	if !ok {
+		return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "cannot infer the Kusto type of %s", t)
+	}
+	return Default(col), nil
+}
+
+// ColumnTypeOf returns the Kusto type ValueOf infers for values of type t, or false if it can't be inferred.
+// Pointers are followed, and the value types of this package, such as Long or Nullable[int64], return their own type.
+func ColumnTypeOf(t reflect.Type) (types.Column, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(kustoType) {
+		return reflect.New(t).Interface().(Kusto).GetType(), true
+	}
+	if n, ok := reflect.Zero(t).Interface().(kustoValuer); ok {
+		k, err := n.kustoValue()
+		if err != nil {
+			return "", false
+		}
+		return k.GetType(), true
+	}
+
+	switch t {
+	case timeType:
+		return types.DateTime, true
+	case durationType:
+		return types.Timespan, true
+	case uuidType, nullUUIDType:
+		return types.GUID, true
+	case decimalType:
+		return types.Decimal, true
+	case rawMessageType:
+		return types.Dynamic, true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return types.Bool, true
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return types.Int, true
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return types.Long, true
+	case reflect.Float32, reflect.Float64:
+		return types.Real, true
+	case reflect.String:
+		return types.String, true
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Interface:
+		return types.Dynamic, true
+	}
+	return "", false
+}
@@ -0,0 +1,126 @@
+package value
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordTestStruct struct {
+	Name     string          `kusto:"name"`
+	Count    int64           `kusto:"count"`
+	Small    int16           `kusto:"small"`
+	Ratio    float32         `kusto:"ratio"`
+	Ok       bool            `kusto:"ok"`
+	When     time.Time       `kusto:"when"`
+	Took     time.Duration   `kusto:"took"`
+	ID       uuid.UUID       `kusto:"id"`
+	Price    decimal.Decimal `kusto:"price"`
+	Tags     []string        `kusto:"tags"`
+	Bag      json.RawMessage `kusto:"bag"`
+	Missing  *int            `kusto:"missing"`
+	Maybe    Nullable[bool]  `kusto:"maybe"`
+	Explicit *Long           `kusto:"explicit"`
+	Untagged uint32
+	Skipped  string `kusto:"-"`
+	private  string
+}
+
+func TestRecordFromStruct(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	id := uuid.MustParse("8f26b3a5-3c25-4b57-9a4b-06ad57d8ae7c")
+	s := &recordTestStruct{
+		Name: "a", Count: 3, Small: 2, Ratio: 0.5, Ok: true, When: when, Took: time.Minute, ID: id,
+		Price: decimal.RequireFromString("1.25"), Tags: []string{"x"}, Bag: json.RawMessage(`{"k":1}`),
+		Explicit: NewLong(9), Untagged: 7, Skipped: "no", private: "no",
+	}
+
+	r, err := RecordFromStruct(s)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"name", "count", "small", "ratio", "ok", "when", "took", "id", "price", "tags", "bag", "missing", "maybe", "explicit", "Untagged"}, r.Names())
+
+	wantTypes := []types.Column{types.String, types.Long, types.Int, types.Real, types.Bool, types.DateTime, types.Timespan, types.GUID,
+		types.Decimal, types.Dynamic, types.Dynamic, types.Long, types.Bool, types.Long, types.Long}
+	for i, v := range r.Values() {
+		assert.Equal(t, wantTypes[i], v.GetType(), r[i].Name)
+	}
+	assert.True(t, IsNull(r[11].Value))
+	assert.True(t, IsNull(r[12].Value))
+
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"a","count":3,"small":2,"ratio":0.5,"ok":true,"when":"2024-01-02T03:04:05Z","took":"00:01:00",`+
+		`"id":"8f26b3a5-3c25-4b57-9a4b-06ad57d8ae7c","price":"1.25","tags":["x"],"bag":{"k":1},"missing":null,"maybe":null,"explicit":9,"Untagged":7}`, string(b))
+
+	_, err = RecordFromStruct(3)
+	assert.Error(t, err)
+	_, err = RecordFromStruct((*recordTestStruct)(nil))
+	assert.Error(t, err)
+}
+
+func TestValueOf(t *testing.T) {
+	t.Parallel()
+
+	v, err := ValueOf(NewNullable(int32(4)))
+	require.NoError(t, err)
+	assert.Equal(t, types.Int, v.GetType())
+	assert.Equal(t, "4", v.String())
+
+	v, err = ValueOf(map[string]int{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, v.String())
+
+	v, err = ValueOf(Long{})
+	require.NoError(t, err)
+	assert.Equal(t, types.Long, v.GetType())
+
+	_, err = ValueOf(uint64(1 << 63))
+	assert.Error(t, err)
+
+	_, err = ValueOf(nil)
+	assert.Error(t, err)
+
+	_, err = ValueOf(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestColumnTypeOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		t    reflect.Type
+		want types.Column
+		ok   bool
+	}{
+		{desc: "int64", t: reflect.TypeOf(int64(0)), want: types.Long, ok: true},
+		{desc: "pointer", t: reflect.TypeOf((**int16)(nil)), want: types.Int, ok: true},
+		{desc: "time", t: reflect.TypeOf(time.Time{}), want: types.DateTime, ok: true},
+		{desc: "kusto value", t: reflect.TypeOf(Timespan{}), want: types.Timespan, ok: true},
+		{desc: "kusto pointer", t: reflect.TypeOf(&GUID{}), want: types.GUID, ok: true},
+		{desc: "nullable", t: reflect.TypeOf(Nullable[float32]{}), want: types.Real, ok: true},
+		{desc: "struct", t: reflect.TypeOf(struct{ A int }{}), want: types.Dynamic, ok: true},
+		{desc: "channel", t: reflect.TypeOf(make(chan int)), ok: false},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := ColumnTypeOf(test.t)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package value
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"reflect"
@@ -67,3 +68,13 @@ func (s *String) GetValue() interface{} {
 func (s *String) GetType() types.Column {
 	return types.String
 }
+
+// MarshalJSON implements json.Marshaler.
+func (s *String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. JSON null is unmarshaled as an empty string.
+func (s *String) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(s, b)
+}
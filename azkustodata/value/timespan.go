@@ -3,6 +3,7 @@ package value
 import (
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -75,14 +76,16 @@ func (t *Timespan) Marshal() string {
 	return sb.String()
 }
 
-// Unmarshal unmarshals i into Timespan. i must be a string representing a Values timespan or nil.
-func (t *Timespan) Unmarshal(i interface{}) error {
-	const (
-		hoursIndex   = 0
-		minutesIndex = 1
-		secondsIndex = 2
-	)
+// String implements fmt.Stringer. It returns the value in the Kusto format (see Marshal), or an empty string for a null value.
+func (t *Timespan) String() string {
+	if t.value == nil {
+		return ""
+	}
+	return t.Marshal()
+}
 
+// Unmarshal unmarshals i into Timespan. i must be a string accepted by ParseTimespan or nil.
+func (t *Timespan) Unmarshal(i interface{}) error {
 	if i == nil {
 		t.value = nil
 		return nil
@@ -93,136 +96,231 @@ func (t *Timespan) Unmarshal(i interface{}) error {
 		return convertError(t, i)
 	}
 
-	negative := false
-	if len(v) > 1 {
-		if string(v[0]) == "-" {
-			negative = true
-			v = v[1:]
-		}
+	d, err := ParseTimespan(v)
+	if err != nil {
+		return parseError(t, v, err)
 	}
 
-	sp := strings.Split(v, ":")
-	if len(sp) != 3 {
-		return parseError(v, sp, fmt.Errorf("value to unmarshal into Timespan does not seem to fit format '00:00:00', where values are decimal(%s)", v))
-	}
+	t.value = &d
+	return nil
+}
 
-	var sum time.Duration
+// timespanUnits holds the units accepted by Kusto timespan literals, such as 1.5h or 90s.
+var timespanUnits = map[string]time.Duration{
+	"d": day, "day": day, "days": day,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+	"ms": time.Millisecond, "milli": time.Millisecond, "millis": time.Millisecond,
+	"millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"microsecond": time.Microsecond, "microseconds": time.Microsecond,
+	"tick": tick, "ticks": tick,
+}
 
-	d, err := t.unmarshalDaysHours(sp[hoursIndex])
-	if err != nil {
-		return parseError(v, sp, err)
+// ParseTimespan parses a Kusto timespan. It accepts:
+//
+//   - The constant format returned by Kusto, [-][d.]hh:mm:ss[.fffffff], where the fraction has up to 9 digits.
+//     The days can be any number, so spans longer than a day are written as 2.01:00:00, rather than 49:00:00.
+//   - Timespan literals such as 2d, 1.5h, 30m, 90s, 100ms, 10microseconds or 5ticks, optionally negative.
+//   - Either of the above wrapped in time(...) or timespan(...). A plain number inside the parentheses is a number of days.
+//
+// Values outside the range of time.Duration (about 292 years) return an error.
+func ParseTimespan(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	wrapped := false
+	for _, prefix := range []string{"timespan(", "time("} {
+		if strings.HasPrefix(str, prefix) && strings.HasSuffix(str, ")") {
+			str = strings.TrimSpace(str[len(prefix) : len(str)-1])
+			wrapped = true
+			break
+		}
 	}
-	sum += d
 
-	d, err = t.unmarshalMinutes(sp[minutesIndex])
-	if err != nil {
-		return parseError(v, sp, err)
+	negative := false
+	if strings.HasPrefix(str, "-") {
+		negative = true
+		str = str[1:]
 	}
-	sum += d
 
-	d, err = t.unmarshalSeconds(sp[secondsIndex])
+	var d time.Duration
+	var err error
+	if strings.Contains(str, ":") {
+		d, err = parseTimespanConstant(str)
+	} else {
+		d, err = parseTimespanLiteral(str, wrapped)
+	}
 	if err != nil {
-		return parseError(v, sp, err)
+		return 0, fmt.Errorf("%q is not a valid timespan: %w", s, err)
 	}
 
-	sum += d
-
 	if negative {
-		sum = sum * time.Duration(-1)
+		d = -d
 	}
+	return d, nil
+}
 
-	t.value = &sum
-	return nil
+// parseTimespanConstant parses [d.]hh:mm:ss[.fffffff], without the sign.
+func parseTimespanConstant(s string) (time.Duration, error) {
+	const (
+		hoursIndex   = 0
+		minutesIndex = 1
+		secondsIndex = 2
+	)
+
+	sp := strings.Split(s, ":")
+	if len(sp) != 3 {
+		return 0, fmt.Errorf("value does not fit the format '[d.]hh:mm:ss[.fffffff]'")
+	}
+
+	days, hours, err := unmarshalDaysHours(sp[hoursIndex])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := unmarshalMinutes(sp[minutesIndex])
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := unmarshalSeconds(sp[secondsIndex])
+	if err != nil {
+		return 0, err
+	}
+
+	sum := seconds
+	for _, part := range []struct {
+		n    int64
+		unit time.Duration
+	}{{days, day}, {hours, time.Hour}, {minutes, time.Minute}} {
+		if part.n > (math.MaxInt64-int64(sum))/int64(part.unit) {
+			return 0, fmt.Errorf("value is out of range")
+		}
+		sum += time.Duration(part.n) * part.unit
+	}
+	return sum, nil
 }
 
-func (t *Timespan) unmarshalDaysHours(s string) (time.Duration, error) {
+func unmarshalDaysHours(s string) (days int64, hours int64, err error) {
 	sp := strings.Split(s, ".")
 	switch len(sp) {
 	case 1:
-		hours, err := strconv.Atoi(s)
+		hours, err := parseTimespanField(s)
 		if err != nil {
-			return 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s: %s", s, err)
+			return 0, 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s", s)
 		}
-		return time.Duration(hours) * time.Hour, nil
+		return 0, hours, nil
 	case 2:
-		days, err := strconv.Atoi(sp[0])
+		days, err := parseTimespanField(sp[0])
 		if err != nil {
-			return 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s", s)
+			return 0, 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s", s)
 		}
-		hours, err := strconv.Atoi(sp[1])
-		if err != nil {
-			return 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s", s)
+		hours, err := parseTimespanField(sp[1])
+		if err != nil || hours > 23 {
+			return 0, 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s", s)
 		}
-		return time.Duration(days)*day + time.Duration(hours)*time.Hour, nil
+		return days, hours, nil
 	}
-	return 0, fmt.Errorf("timespan's hours/days field did not have the requisite '.'s, was %s", s)
+	return 0, 0, fmt.Errorf("timespan's hours/days field did not have the requisite '.'s, was %s", s)
 }
 
-func (t *Timespan) unmarshalMinutes(s string) (time.Duration, error) {
+func unmarshalMinutes(s string) (int64, error) {
 	s = strings.Split(s, ".")[0] // We can have 01 or 01.00 or 59, but nothing comes behind the .
 
-	minutes, err := strconv.Atoi(s)
-	if err != nil {
-		return 0, fmt.Errorf("timespan's minutes field was incorrect, was %s", s)
-	}
-	if minutes < 0 || minutes > 59 {
+	minutes, err := parseTimespanField(s)
+	if err != nil || minutes > 59 {
 		return 0, fmt.Errorf("timespan's minutes field was incorrect, was %s", s)
 	}
-	return time.Duration(minutes) * time.Minute, nil
+	return minutes, nil
 }
 
 // unmarshalSeconds deals with this crazy output format. Instead of having some multiplier, the number
 // of precision characters behind the decimal indicates your multiplier. This can be between 0 and 7, but
 // really only has 3, 4 and 7. There is something called a tick, which is 100 Nanoseconds and the precision
 // at len 4 is 100 * Microsecond (don't know if that has a name).
-func (t *Timespan) unmarshalSeconds(s string) (time.Duration, error) {
+func unmarshalSeconds(s string) (time.Duration, error) {
 	// "03" = 3 * time.Second
 	// "00.099" = 99 * time.Millisecond
 	// "03.0123" == 3 * time.Second + 12300 * time.Microsecond
 	sp := strings.Split(s, ".")
-	switch len(sp) {
-	case 1:
-		seconds, err := strconv.Atoi(s)
-		if err != nil {
-			return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
-		}
+	if len(sp) > 2 {
+		return 0, fmt.Errorf("timespan's seconds field did not have the requisite '.'s, was %s", s)
+	}
+
+	seconds, err := parseTimespanField(sp[0])
+	if err != nil || seconds > 59 {
+		return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
+	}
+	if len(sp) == 1 {
 		return time.Duration(seconds) * time.Second, nil
-	case 2:
-		seconds, err := strconv.Atoi(sp[0])
-		if err != nil {
-			return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
-		}
-		n, err := strconv.Atoi(sp[1])
-		if err != nil {
-			return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
+	}
+
+	if len(sp[1]) == 0 || len(sp[1]) > 9 {
+		return 0, fmt.Errorf("timespan's seconds field did not have 1-9 numbers after the decimal, had %v", s)
+	}
+	n, err := parseTimespanField(sp[1] + strings.Repeat("0", 9-len(sp[1])))
+	if err != nil {
+		return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
+	}
+	return time.Duration(seconds)*time.Second + time.Duration(n), nil
+}
+
+// parseTimespanField parses a non-negative integer made only of digits.
+func parseTimespanField(s string) (int64, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// parseTimespanLiteral parses a number followed by a unit, such as 1.5h, without the sign.
+// If bareDays is set, a number without a unit is a number of days, as in time(2).
+func parseTimespanLiteral(s string, bareDays bool) (time.Duration, error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	num, unitName := s, ""
+	if i >= 0 {
+		num, unitName = s[:i], strings.TrimSpace(s[i:])
+	}
+
+	unit, ok := timespanUnits[strings.ToLower(unitName)]
+	if unitName == "" && bareDays {
+		unit, ok = day, true
+	}
+	if !ok {
+		return 0, fmt.Errorf("unknown timespan unit %q", unitName)
+	}
+
+	whole, frac, _ := strings.Cut(num, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("missing number")
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := parseTimespanField(whole)
+	if err != nil {
+		return 0, err
+	}
+	if w > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("value is out of range")
+	}
+	d := time.Duration(w) * unit
+
+	if frac != "" {
+		// Scale the fraction digit by digit, so no precision is lost for ticks.
+		scale := unit
+		for _, c := range frac {
+			if c < '0' || c > '9' {
+				return 0, fmt.Errorf("%q is not a number", num)
+			}
+			scale /= 10
+			if scale == 0 {
+				break
+			}
+			d += time.Duration(c-'0') * scale
 		}
-		var prec time.Duration
-		switch len(sp[1]) {
-		case 1:
-			prec = time.Duration(n) * (100 * time.Millisecond)
-		case 2:
-			prec = time.Duration(n) * (10 * time.Millisecond)
-		case 3:
-			prec = time.Duration(n) * time.Millisecond
-		case 4:
-			prec = time.Duration(n) * 100 * time.Microsecond
-		case 5:
-			prec = time.Duration(n) * 10 * time.Microsecond
-		case 6:
-			prec = time.Duration(n) * time.Microsecond
-		case 7:
-			prec = time.Duration(n) * tick
-		case 8:
-			prec = time.Duration(n) * (10 * time.Nanosecond)
-		case 9:
-			prec = time.Duration(n) * time.Nanosecond
-		default:
-			return 0, fmt.Errorf("timespan's seconds field did not have 1-9 numbers after the decimal, had %v", s)
+		if d < 0 {
+			return 0, fmt.Errorf("value is out of range")
 		}
-
-		return time.Duration(seconds)*time.Second + prec, nil
 	}
-	return 0, fmt.Errorf("timespan's seconds field did not have the requisite '.'s, was %s", s)
+	return d, nil
 }
 
 // Convert Timespan into reflect value.
@@ -258,3 +356,17 @@ func TimespanString(d time.Duration) string {
 func (t *Timespan) GetType() types.Column {
 	return types.Timespan
 }
+
+// MarshalJSON implements json.Marshaler. The value is marshaled as a Kusto timespan literal string (see Marshal).
+// A null value is marshaled as JSON null.
+func (t *Timespan) MarshalJSON() ([]byte, error) {
+	if t.value == nil {
+		return nullJSON, nil
+	}
+	return strconv.AppendQuote(nil, t.Marshal()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Timespan) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(t, b)
+}
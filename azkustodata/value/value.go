@@ -36,6 +36,8 @@ The Unmarshal() is for internal use, it should not be needed by an end user. Use
 package value
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
@@ -168,3 +170,30 @@ func Default(t types.Column) Kusto {
 
 // Values is a list of Kusto values, usually an ordered row.
 type Values []Kusto
+
+var nullJSON = []byte("null")
+
+// unmarshalJSON decodes b, which holds a value as it is represented in the Kusto v2 protocol, and unmarshals it into k.
+func unmarshalJSON(k Kusto, b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var i interface{}
+	if err := dec.Decode(&i); err != nil {
+		return parseError(k, string(b), err)
+	}
+	return k.Unmarshal(i)
+}
+
+// UnmarshalJSONAs unmarshals the JSON representation of a value of type t, as produced by the MarshalJSON method of the value types.
+// It is used to re-decode values whose type is only known from the column they belong to.
+func UnmarshalJSONAs(t types.Column, b []byte) (Kusto, error) {
+	k := Default(t)
+	if k == nil {
+		return nil, errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "unknown column type %s", t)
+	}
+	if err := k.(json.Unmarshaler).UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
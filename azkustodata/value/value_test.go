@@ -620,3 +620,216 @@ func timeMustParse(layout string, p string) time.Time {
 	}
 	return t
 }
+
+func TestJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		val  Kusto
+		want string
+	}{
+		{desc: "bool", val: NewBool(true), want: `true`},
+		{desc: "null bool", val: NewNullBool(), want: `null`},
+		{desc: "int", val: NewInt(-7), want: `-7`},
+		{desc: "long", val: NewLong(math.MaxInt64), want: `9223372036854775807`},
+		{desc: "null long", val: NewNullLong(), want: `null`},
+		{desc: "real", val: NewReal(1.25), want: `1.25`},
+		{desc: "real NaN", val: NewReal(math.NaN()), want: `"NaN"`},
+		{desc: "real -Inf", val: NewReal(math.Inf(-1)), want: `"-Infinity"`},
+		{desc: "decimal", val: DecimalFromString("123456789012345678901234567890.0123"), want: `"123456789012345678901234567890.0123"`},
+		{desc: "null decimal", val: NewNullDecimal(), want: `null`},
+		{desc: "string", val: NewString(`a"b`), want: `"a\"b"`},
+		{desc: "datetime", val: NewDateTime(time.Date(2024, 1, 2, 3, 4, 5, 1234500, time.UTC)), want: `"2024-01-02T03:04:05.0012345Z"`},
+		{desc: "null datetime", val: NewNullDateTime(), want: `null`},
+		{desc: "timespan", val: NewTimespan(-(26*time.Hour + 100*time.Nanosecond)), want: `"-1.02:00:00.0000001"`},
+		{desc: "null timespan", val: NewNullTimespan(), want: `null`},
+		{desc: "guid", val: NewGUID(uuid.MustParse("8f26b3a5-3c25-4b57-9a4b-06ad57d8ae7c")), want: `"8f26b3a5-3c25-4b57-9a4b-06ad57d8ae7c"`},
+		{desc: "dynamic", val: NewDynamic([]byte(`{"a":[1,2]}`)), want: `{"a":[1,2]}`},
+		{desc: "null dynamic", val: NewNullDynamic(), want: `null`},
+	}
+
+	for _, test := range tests {
+		test := test // Capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := json.Marshal(test.val)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, string(b))
+
+			got, err := UnmarshalJSONAs(test.val.GetType(), b)
+			assert.NoError(t, err)
+			if r, ok := test.val.(*Real); ok && math.IsNaN(*r.Ptr()) {
+				assert.True(t, math.IsNaN(*got.(*Real).Ptr()))
+				return
+			}
+			assert.Equal(t, test.val.String(), got.String())
+			assert.Equal(t, IsNull(test.val), IsNull(got))
+		})
+	}
+}
+
+func TestJSONValues(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(Values{NewLong(1), NewNullReal(), NewString("x")})
+	assert.NoError(t, err)
+	assert.Equal(t, `[1,null,"x"]`, string(b))
+
+	_, err = UnmarshalJSONAs("nope", b)
+	assert.Error(t, err)
+
+	var l Long
+	assert.Error(t, json.Unmarshal([]byte(`"x"`), &l))
+}
+
+func TestParseTimespan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{in: "00:00:00", want: 0},
+		{in: "-00:00:01", want: -time.Second},
+		{in: "1.02:03:04.5", want: day + 2*time.Hour + 3*time.Minute + 4*time.Second + 500*time.Millisecond},
+		{in: "10000.00:00:00", want: 10000 * day},
+		{in: "-3.17:25:30.0000001", want: -(3*day + 17*time.Hour + 25*time.Minute + 30*time.Second + tick)},
+		{in: "49:00:00", want: 49 * time.Hour},
+		{in: "1h", want: time.Hour},
+		{in: "90s", want: 90 * time.Second},
+		{in: "1.5h", want: 90 * time.Minute},
+		{in: "-2d", want: -2 * day},
+		{in: "100ms", want: 100 * time.Millisecond},
+		{in: "10microseconds", want: 10 * time.Microsecond},
+		{in: "3ticks", want: 3 * tick},
+		{in: "0.1tick", want: 10 * time.Nanosecond},
+		{in: "30 minutes", want: 30 * time.Minute},
+		{in: "time(2)", want: 2 * day},
+		{in: "timespan(1.00:00:00)", want: day},
+		{in: "time(-5m)", want: -5 * time.Minute},
+		{in: "2", err: true},
+		{in: "1y", err: true},
+		{in: "h", err: true},
+		{in: "1.24:00:00", err: true},
+		{in: "00:60:00", err: true},
+		{in: "00:00:60", err: true},
+		{in: "00:00:00.", err: true},
+		{in: "00:00:00.1234567890", err: true},
+		{in: "+1:00:00", err: true},
+		{in: "1000000.00:00:00", err: true},
+		{in: "1000000d", err: true},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseTimespan(test.in)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestTimespanString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", NewNullTimespan().String())
+	assert.Equal(t, "-2.01:00:00.5000000", NewTimespan(-(49*time.Hour + 500*time.Millisecond)).String())
+
+	for _, d := range []time.Duration{0, tick, -day, 10000*day + 17*time.Second, 99 * time.Hour} {
+		parsed, err := ParseTimespan(NewTimespan(d).String())
+		assert.NoError(t, err)
+		assert.Equal(t, d, parsed)
+	}
+}
+
+func TestDateTimeTicks(t *testing.T) {
+	t.Parallel()
+
+	epoch := time.Unix(0, 0).UTC()
+	assert.Equal(t, int64(621355968000000000), TimeToTicks(epoch))
+	assert.Equal(t, int64(0), TimeToTicks(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), TicksToTime(0))
+	assert.Equal(t, time.Date(9999, 12, 31, 23, 59, 59, 999999900, time.UTC), TicksToTime(3155378975999999999))
+
+	// Before the Unix epoch, ticks round towards the past.
+	before := time.Date(1969, 12, 31, 23, 59, 59, 999999950, time.UTC)
+	assert.Equal(t, int64(621355967999999999), TimeToTicks(before))
+	assert.Equal(t, time.Date(1969, 12, 31, 23, 59, 59, 999999900, time.UTC), TruncateToTick(before))
+
+	withNanos := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	truncated := TruncateToTick(withNanos)
+	assert.Equal(t, 123456700, truncated.Nanosecond())
+	assert.Equal(t, TimeToTicks(withNanos), TimeToTicks(truncated))
+
+	dt := NewDateTime(withNanos)
+	ticks, ok := dt.Ticks()
+	assert.True(t, ok)
+	assert.Equal(t, truncated, *DateTimeFromTicks(ticks).Ptr())
+
+	_, ok = NewNullDateTime().Ticks()
+	assert.False(t, ok)
+
+	// Decoding and re-encoding keeps all the ticks.
+	decoded := &DateTime{}
+	assert.NoError(t, decoded.Unmarshal("2024-05-06T07:08:09.1234567Z"))
+	assert.Equal(t, "2024-05-06T07:08:09.1234567Z", decoded.Marshal())
+	assert.True(t, decoded.Equal(NewDateTime(withNanos.In(time.FixedZone("X", 3600)))))
+	assert.False(t, decoded.Equal(NewDateTime(withNanos.Add(tick))))
+	assert.False(t, decoded.Equal(NewNullDateTime()))
+	assert.True(t, NewNullDateTime().Equal(NewNullDateTime()))
+}
+
+func TestGUIDInterop(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.MustParse("8f26b3a5-3c25-4b57-9a4b-06ad57d8ae7c")
+
+	g := NewGUID(id)
+	u, ok := g.UUID()
+	assert.True(t, ok)
+	assert.Equal(t, id, u)
+	assert.Equal(t, uuid.NullUUID{UUID: id, Valid: true}, g.NullUUID())
+
+	_, ok = NewNullGUID().UUID()
+	assert.False(t, ok)
+	assert.Equal(t, uuid.NullUUID{}, NewNullGUID().NullUUID())
+	assert.True(t, IsNull(NewGUIDFromNullUUID(uuid.NullUUID{})))
+	assert.Equal(t, g, NewGUIDFromNullUUID(g.NullUUID()))
+
+	parsed, err := GUIDFromString("{8f26b3a5-3c25-4b57-9a4b-06ad57d8ae7c}")
+	assert.NoError(t, err)
+	assert.Equal(t, g, parsed)
+	_, err = GUIDFromString("nope")
+	assert.Error(t, err)
+
+	for _, src := range []interface{}{id, id.String(), []byte(id.String()), id[:]} {
+		scanned := &GUID{}
+		assert.NoError(t, scanned.Scan(src))
+		assert.Equal(t, g, scanned)
+	}
+	scanned := NewGUID(id)
+	assert.NoError(t, scanned.Scan(nil))
+	assert.True(t, IsNull(scanned))
+	assert.Error(t, scanned.Scan(3))
+
+	v, err := g.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, id.String(), v)
+	v, err = NewNullGUID().Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	k, err := ValueOf(uuid.NullUUID{UUID: id, Valid: true})
+	assert.NoError(t, err)
+	assert.Equal(t, g, k)
+}
@@ -0,0 +1,141 @@
+package azkustoingest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// defaultBatchParallelism is the number of sources IngestBatch ingests at the same time, unless WithBatchParallelism is given.
+const defaultBatchParallelism = 8
+
+// BatchSource is a source of IngestBatch: a local file or a blob URI, as given to FromFile, and the options that apply to it
+// only.
+type BatchSource struct {
+	Path    string
+	Options []FileOption
+}
+
+// BatchResult holds the results of the ingestions of the sources given to IngestBatch.
+type BatchResult struct {
+	// Results holds the result of each source, in order. It is nil for the sources that failed to be queued.
+	Results []*Result
+	sources []BatchSource
+}
+
+// Wait waits for the ingestions of all the queued sources to end, like Result.Wait, and returns a *BatchError holding the
+// failures, or nil if they all succeeded.
+func (b *BatchResult) Wait(ctx context.Context, options ...WaitOption) error {
+	failed := map[int]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for idx, r := range b.Results {
+		if r == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, r *Result) {
+			defer wg.Done()
+			if err := <-r.Wait(ctx, options...); err != nil {
+				mu.Lock()
+				failed[idx] = err
+				mu.Unlock()
+			}
+		}(idx, r)
+	}
+	wg.Wait()
+
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Errors: failed, sources: b.sources}
+}
+
+// BatchError holds the failures of the sources of a batch ingestion. The other sources of the batch were queued.
+type BatchError struct {
+	// Errors holds the error of each failed source, by its index in the sources given to IngestBatch.
+	Errors  map[int]error
+	sources []BatchSource
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for idx := range e.Errors {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d sources failed to be ingested:", len(e.Errors), len(e.sources))
+	for _, idx := range indexes {
+		fmt.Fprintf(&b, "\n%s: %s", e.sources[idx].Path, e.Errors[idx])
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors of the failed sources, so they can be checked with errors.Is and errors.As.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// IngestBatch queues the ingestions of several files, such as the rotated logs of a folder, as FromFile does for each of them.
+// Up to 8 files are uploaded at the same time, or the number given with WithBatchParallelism. The options apply to all the
+// sources, before the options of each source.
+// A source failing doesn't stop the others: the returned BatchResult holds the results of the sources that were queued, and a
+// *BatchError holds the failures of the others.
+// This method is thread-safe.
+func (i *Ingestion) IngestBatch(ctx context.Context, sources []BatchSource, options ...FileOption) (*BatchResult, error) {
+	if len(sources) == 0 {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "at least one source must be given to ingest a batch").SetNoRetry()
+	}
+	for idx, src := range sources {
+		if src.Path == "" {
+			return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the path of source[%d] is empty", idx).SetNoRetry()
+		}
+	}
+
+	parallelism := i.batchParallelism
+	if parallelism <= 0 {
+		parallelism = defaultBatchParallelism
+	}
+
+	batch := &BatchResult{Results: make([]*Result, len(sources)), sources: sources}
+	failed := map[int]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallelism)
+	for idx, src := range sources {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, src BatchSource) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			opts := append(append([]FileOption{}, options...), src.Options...)
+			res, err := i.FromFile(ctx, src.Path, opts...)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[idx] = err
+				return
+			}
+			batch.Results[idx] = res
+		}(idx, src)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return batch, &BatchError{Errors: failed, sources: sources}
+	}
+	return batch, nil
+}
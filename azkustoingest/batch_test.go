@@ -0,0 +1,83 @@
+package azkustoingest
+
+import (
+	"context"
+	goErrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestBatch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var sources []BatchSource
+	for i := 0; i < 6; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("log%d.csv", i))
+		require.NoError(t, os.WriteFile(path, []byte("a,b\n"), 0o600))
+		sources = append(sources, BatchSource{Path: path})
+	}
+	sources[4].Options = []FileOption{Table("otherTable")}
+	failing := sources[2].Path
+
+	ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", batchParallelism: 2})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	tables := map[string]string{}
+	ingestion.fs = resources.FsMock{
+		OnLocal: func(ctx context.Context, from string, props properties.All) (string, int64, error) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			if from == failing {
+				return "", 0, fmt.Errorf("upload failed")
+			}
+			return "https://account.blob.core.windows.net/container/" + filepath.Base(from), 4, nil
+		},
+		OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+			mu.Lock()
+			defer mu.Unlock()
+			tables[filepath.Base(from)] = props.Ingestion.TableName
+			return nil
+		},
+	}
+
+	batch, err := ingestion.IngestBatch(context.Background(), sources, Database("db"))
+	var batchErr *BatchError
+	require.True(t, goErrors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 1)
+	assert.ErrorContains(t, batchErr.Errors[2], "upload failed")
+	assert.ErrorContains(t, err, "1 of 6 sources failed")
+	assert.ErrorContains(t, err, failing)
+
+	require.Len(t, batch.Results, 6)
+	assert.Nil(t, batch.Results[2])
+	assert.Equal(t, Queued, batch.Results[0].record.Status)
+	assert.Len(t, tables, 5)
+	assert.Equal(t, "otherTable", tables["log4.csv"])
+	assert.Equal(t, "defaultTable", tables["log0.csv"])
+	assert.LessOrEqual(t, maxInFlight, 2)
+
+	// Without status reporting, the queued sources have nothing to wait for.
+	assert.NoError(t, batch.Wait(context.Background()))
+
+	_, err = ingestion.IngestBatch(context.Background(), nil)
+	assert.ErrorContains(t, err, "at least one source")
+}
@@ -0,0 +1,86 @@
+package azkustoingest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// Batcher buffers the records added to it, such as the events of a telemetry producer, and ingests them in batches with a
+// queued, streaming or managed client, as IngestFromChannel does: a batch is submitted once it reaches the size or the delay of
+// its ChannelBatching. Close submits the records left and waits for them. Its methods are thread-safe.
+type Batcher[T any] struct {
+	records chan T
+	// done is closed once the batcher stopped, with err.
+	done chan struct{}
+	err  error
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewBatcher returns a Batcher that ingests records of type T with the client. The records are encoded, and the options
+// applied, as with IngestFromChannel. ctx bounds the ingestion of the batches: once it is done, the batcher stops, and the
+// records not yet submitted are dropped. Unless batching.OnBatch is set, the batcher stops at the first batch that fails to be
+// submitted, and Add and Close then return its error.
+func NewBatcher[T any](ctx context.Context, client Ingestor, batching ChannelBatching, options ...FileOption) (*Batcher[T], error) {
+	rb, err := newRecordBatcher[T](client, batching, options, MultiJSON, newRecordEncoder[T])
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Batcher[T]{records: make(chan T), done: make(chan struct{})}
+	go func() {
+		defer close(b.done)
+		b.err = rb.run(ctx, b.records)
+	}()
+	return b, nil
+}
+
+// Add adds a record to the current batch. It blocks while a full batch is submitted, until ctx is done, which bounds the
+// memory used when the records come faster than they are ingested.
+func (b *Batcher[T]) Add(ctx context.Context, record T) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return errors.ES(errors.OpFileIngest, errors.KClientArgs, "cannot add a record to a closed batcher").SetNoRetry()
+	}
+
+	select {
+	case b.records <- record:
+		return nil
+	case <-b.done:
+		if b.err != nil {
+			return b.err
+		}
+		return errors.ES(errors.OpFileIngest, errors.KClientArgs, "the batcher has stopped").SetNoRetry()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close submits the records that were added and not yet submitted, and waits for their ingestion to be queued or streamed. It
+// returns the error that stopped the batcher, if any. Records can't be added once Close is called.
+func (b *Batcher[T]) Close() error {
+	return b.Shutdown(context.Background())
+}
+
+// Shutdown is Close, with the wait for the records that were added bounded by ctx. It returns a KTimeout error if ctx is done
+// first, with the batches left still submitted in the background until the ctx of NewBatcher is done. The client of the batcher
+// is left open, to be shut down once the batcher is.
+func (b *Batcher[T]) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.records)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.err
+	case <-ctx.Done():
+		return errors.ES(errors.OpFileIngest, errors.KTimeout, "records were still being submitted when the batcher was shut down: %s", ctx.Err())
+	}
+}
@@ -0,0 +1,91 @@
+package azkustoingest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatcher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Flushes by size and drains on Close", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{}
+		b, err := NewBatcher[channelEvent](t.Context(), rec, ChannelBatching{MaxSize: 50})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, b.Add(t.Context(), channelEvent{Name: "e", Count: 1}))
+			}(i)
+		}
+		wg.Wait()
+		require.NoError(t, b.Close())
+
+		assert.Equal(t, []string{
+			`{"name":"e","count":1,"Labels":null}` + "\n" + `{"name":"e","count":1,"Labels":null}` + "\n",
+			`{"name":"e","count":1,"Labels":null}` + "\n",
+		}, rec.batches)
+
+		err = b.Add(t.Context(), channelEvent{})
+		assert.ErrorContains(t, err, "closed batcher")
+		assert.NoError(t, b.Close())
+	})
+
+	t.Run("Flushes after the delay", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{}
+		b, err := NewBatcher[channelEvent](t.Context(), rec, ChannelBatching{MaxDelay: 10 * time.Millisecond}, FileFormat(CSV))
+		require.NoError(t, err)
+		defer b.Close()
+
+		require.NoError(t, b.Add(t.Context(), channelEvent{Name: "e"}))
+		require.Eventually(t, func() bool {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			return len(rec.batches) == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []DataFormat{CSV}, rec.formats)
+	})
+
+	t.Run("A failed batch stops the batcher", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{err: errors.ES(errors.OpFileIngest, errors.KBlobstore, "upload failed")}
+		b, err := NewBatcher[channelEvent](t.Context(), rec, ChannelBatching{MaxSize: 1})
+		require.NoError(t, err)
+
+		require.NoError(t, b.Add(t.Context(), channelEvent{}))
+		require.Eventually(t, func() bool {
+			return b.Add(t.Context(), channelEvent{}) != nil
+		}, time.Second, 5*time.Millisecond)
+		assert.ErrorContains(t, b.Add(t.Context(), channelEvent{}), "upload failed")
+		assert.ErrorContains(t, b.Close(), "upload failed")
+	})
+
+	t.Run("Stops when its context is done", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		b, err := NewBatcher[channelEvent](ctx, &batchRecorder{}, ChannelBatching{})
+		require.NoError(t, err)
+		<-b.done
+		assert.ErrorIs(t, b.Add(t.Context(), channelEvent{}), context.Canceled)
+		assert.ErrorIs(t, b.Close(), context.Canceled)
+
+		_, err = NewBatcher[int](t.Context(), &batchRecorder{}, ChannelBatching{}, FileFormat(CSV))
+		assert.ErrorContains(t, err, "records must be structs")
+	})
+}
@@ -0,0 +1,291 @@
+package azkustoingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+)
+
+const (
+	// defaultChannelBatchSize is the size of the encoded records of a batch of IngestFromChannel, unless ChannelBatching.MaxSize is set.
+	defaultChannelBatchSize = 64 * 1024 * 1024
+	// defaultChannelBatchDelay is how long IngestFromChannel waits before submitting a batch that isn't full, unless
+	// ChannelBatching.MaxDelay is set.
+	defaultChannelBatchDelay = 30 * time.Second
+)
+
+// ChannelBatching sets how IngestFromChannel groups records into batches. A batch is submitted as soon as one of the limits is
+// reached.
+type ChannelBatching struct {
+	// MaxSize is the size in bytes of the encoded records of a batch. Defaults to 64MiB.
+	MaxSize int
+	// MaxDelay is how long the first record of a batch waits for the batch to fill. Defaults to 30 seconds.
+	MaxDelay time.Duration
+	// OnBatch, if set, is called after each batch is submitted, with the number of records of the batch and the result or error
+	// of its submission. A failed batch then doesn't stop IngestFromChannel.
+	OnBatch func(records int, result *Result, err error)
+}
+
+// recordEncoder appends a record to the content of a batch.
+type recordEncoder[T any] func(buf *bytes.Buffer, record T) error
+
+// IngestFromChannel ingests the records received from ch, such as the events of a service, with the client. The records are
+// encoded as MultiJSON with encoding/json, or as CSV if the options set FileFormat(CSV). CSV records must be structs, whose
+// exported fields are the columns, in order; a field with the tag `kusto:"-"` is skipped.
+// The records are grouped into batches, per batching, and each batch is submitted with FromReader as soon as it is full.
+// IngestFromChannel returns once ch is closed and the last batch is submitted, or when ctx is done. Unless
+// batching.OnBatch is set, it stops at the first batch that fails to be submitted, and returns its error.
+func IngestFromChannel[T any](ctx context.Context, client Ingestor, ch <-chan T, batching ChannelBatching, options ...FileOption) error {
+	b, err := newRecordBatcher[T](client, batching, options, MultiJSON, newRecordEncoder[T])
+	if err != nil {
+		return err
+	}
+	return b.run(ctx, ch)
+}
+
+// recordBatcher submits batches of records of type T, per a ChannelBatching.
+type recordBatcher[T any] struct {
+	client   Ingestor
+	options  []FileOption
+	encode   recordEncoder[T]
+	maxSize  int
+	maxDelay time.Duration
+	onBatch  func(records int, result *Result, err error)
+
+	// buf holds the encoded records of the current batch, and records their number.
+	buf     *bytes.Buffer
+	records int
+	// results are the results of the submitted batches.
+	results []*Result
+}
+
+// newRecordBatcher returns a recordBatcher of the records encoded by newEncoder, into the format set by the options, or
+// defaultFormat if they don't set one.
+func newRecordBatcher[T any](client Ingestor, batching ChannelBatching, options []FileOption, defaultFormat DataFormat,
+	newEncoder func(format DataFormat) (recordEncoder[T], error)) (*recordBatcher[T], error) {
+	if client == nil {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the client must not be nil").SetNoRetry()
+	}
+	if batching.MaxSize < 0 || batching.MaxDelay < 0 {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the batch size and delay must not be negative").SetNoRetry()
+	}
+	b := &recordBatcher[T]{client: client, options: options, maxSize: batching.MaxSize, maxDelay: batching.MaxDelay, onBatch: batching.OnBatch,
+		buf: &bytes.Buffer{}}
+	if b.maxSize == 0 {
+		b.maxSize = defaultChannelBatchSize
+	}
+	if b.maxDelay == 0 {
+		b.maxDelay = defaultChannelBatchDelay
+	}
+
+	format := channelFormat(options)
+	if format == DFUnknown {
+		format = defaultFormat
+		b.options = append(options[:len(options):len(options)], FileFormat(defaultFormat))
+	}
+	encode, err := newEncoder(format)
+	if err != nil {
+		return nil, err
+	}
+	b.encode = encode
+	return b, nil
+}
+
+// run submits the records received from ch in batches, until ch is closed or ctx is done.
+func (b *recordBatcher[T]) run(ctx context.Context, ch <-chan T) error {
+	timer := time.NewTimer(b.maxDelay)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			if err := b.flush(ctx); err != nil {
+				return err
+			}
+		case record, ok := <-ch:
+			if !ok {
+				return b.flush(ctx)
+			}
+			if err := b.add(ctx, record); err != nil {
+				return err
+			}
+			switch b.records {
+			case 0:
+				// The batch was full, and was submitted.
+				timer.Stop()
+			case 1:
+				timer.Reset(b.maxDelay)
+			}
+		}
+	}
+}
+
+// add encodes record into the current batch, and submits the batch once it is full.
+func (b *recordBatcher[T]) add(ctx context.Context, record T) error {
+	if err := b.encode(b.buf, record); err != nil {
+		return err
+	}
+	b.records++
+	if b.buf.Len() >= b.maxSize {
+		return b.flush(ctx)
+	}
+	return nil
+}
+
+// flush submits the current batch, if it has records. Unless onBatch is set, it returns the error of the submission.
+func (b *recordBatcher[T]) flush(ctx context.Context) error {
+	if b.records == 0 {
+		return nil
+	}
+	res, err := b.client.FromReader(ctx, bytes.NewReader(b.buf.Bytes()), b.options...)
+	n := b.records
+	b.buf = &bytes.Buffer{}
+	b.records = 0
+	if res != nil {
+		b.results = append(b.results, res)
+	}
+	if b.onBatch != nil {
+		b.onBatch(n, res, err)
+		return nil
+	}
+	return err
+}
+
+// channelFormat returns the format set by the options, or DFUnknown. Errors of the options are left to FromReader.
+func channelFormat(options []FileOption) DataFormat {
+	props := properties.All{}
+	for _, o := range options {
+		_ = o.Run(&props, o.ClientScopes(), FromReader)
+	}
+	return props.Ingestion.Additional.Format
+}
+
+// newRecordEncoder returns the encoder of the records of type T into format.
+func newRecordEncoder[T any](format DataFormat) (recordEncoder[T], error) {
+	switch format {
+	case JSON, MultiJSON:
+		return func(buf *bytes.Buffer, record T) error {
+			b, err := json.Marshal(record)
+			if err != nil {
+				return errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not encode a record as JSON: %s", err).SetNoRetry()
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+			return nil
+		}, nil
+	case CSV:
+		fields, err := csvFields(reflect.TypeOf((*T)(nil)).Elem())
+		if err != nil {
+			return nil, err
+		}
+		row := make([]string, len(fields))
+		return func(buf *bytes.Buffer, record T) error {
+			v := reflect.ValueOf(&record).Elem()
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return errors.ES(errors.OpFileIngest, errors.KClientArgs, "cannot encode a nil record as CSV").SetNoRetry()
+				}
+				v = v.Elem()
+			}
+			for i, idx := range fields {
+				s, err := csvValue(v.Field(idx))
+				if err != nil {
+					return errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not encode field %s of a record as CSV: %s",
+						v.Type().Field(idx).Name, err).SetNoRetry()
+				}
+				row[i] = s
+			}
+			w := csv.NewWriter(buf)
+			if err := w.Write(row); err != nil {
+				return errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not encode a record as CSV: %s", err).SetNoRetry()
+			}
+			w.Flush()
+			return w.Error()
+		}, nil
+	default:
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "records can only be ingested as JSON, MultiJSON or CSV, not %s", format).SetNoRetry()
+	}
+}
+
+// csvFields returns the indexes of the fields of t that are CSV columns: its exported fields not tagged `kusto:"-"`.
+func csvFields(t reflect.Type) ([]int, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "records must be structs to be ingested as CSV, got %s", t).SetNoRetry()
+	}
+
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || strings.TrimSpace(f.Tag.Get("kusto")) == "-" {
+			continue
+		}
+		fields = append(fields, i)
+	}
+	if len(fields) == 0 {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "records of type %s have no exported field to ingest as CSV", t).SetNoRetry()
+	}
+	return fields, nil
+}
+
+// csvValue formats v as Kusto parses a CSV value of the column type of v. A nil value is an empty, null, value. Values that
+// are neither scalars nor fmt.Stringer, such as maps and slices, are encoded as JSON, for dynamic columns.
+func csvValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return "", nil
+	}
+
+	switch x := v.Interface().(type) {
+	case time.Time:
+		if x.IsZero() {
+			return "", nil
+		}
+		return x.UTC().Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return value.TimespanString(x), nil
+	case json.RawMessage:
+		return string(x), nil
+	case fmt.Stringer:
+		// Such as uuid.UUID and decimal.Decimal.
+		return x.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
@@ -0,0 +1,163 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder is an Ingestor that keeps the content of the readers it is given.
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches []string
+	formats []DataFormat
+	err     error
+}
+
+func (b *batchRecorder) Close() error {
+	return nil
+}
+
+func (b *batchRecorder) FromFile(context.Context, string, ...FileOption) (*Result, error) {
+	return nil, errors.ES(errors.OpFileIngest, errors.KInternal, "unexpected FromFile")
+}
+
+func (b *batchRecorder) FromReader(_ context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, string(data))
+	b.formats = append(b.formats, channelFormat(options))
+	if b.err != nil {
+		return nil, b.err
+	}
+	return newResult(), nil
+}
+
+type channelEvent struct {
+	Name     string        `json:"name"`
+	Count    int           `json:"count"`
+	Took     time.Duration `json:"-"`
+	internal string
+	Skipped  string `kusto:"-" json:"-"`
+	Labels   map[string]string
+}
+
+func TestIngestFromChannel(t *testing.T) {
+	t.Parallel()
+
+	events := func(n int) <-chan channelEvent {
+		ch := make(chan channelEvent, n)
+		for i := 0; i < n; i++ {
+			ch <- channelEvent{Name: "e", Count: i, Took: 90 * time.Second, internal: "x", Skipped: "y"}
+		}
+		close(ch)
+		return ch
+	}
+
+	t.Run("MultiJSON by default, batched by size", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{}
+		err := IngestFromChannel(t.Context(), rec, events(3), ChannelBatching{MaxSize: 50})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			`{"name":"e","count":0,"Labels":null}` + "\n" + `{"name":"e","count":1,"Labels":null}` + "\n",
+			`{"name":"e","count":2,"Labels":null}` + "\n",
+		}, rec.batches)
+		assert.Equal(t, []DataFormat{MultiJSON, MultiJSON}, rec.formats)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan *channelEvent, 2)
+		ch <- &channelEvent{Name: "a,b", Count: 1, Took: 90 * time.Second, Labels: map[string]string{"k": "v"}}
+		ch <- &channelEvent{Name: "c", Count: 2}
+		close(ch)
+
+		rec := &batchRecorder{}
+		err := IngestFromChannel(t.Context(), rec, ch, ChannelBatching{}, FileFormat(CSV))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"\"a,b\",1,00:01:30,\"{\"\"k\"\":\"\"v\"\"}\"\nc,2,00:00:00,\n"}, rec.batches)
+		assert.Equal(t, []DataFormat{CSV}, rec.formats)
+	})
+
+	t.Run("Flushes after the delay", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan channelEvent)
+		rec := &batchRecorder{}
+		done := make(chan error, 1)
+		go func() {
+			done <- IngestFromChannel(t.Context(), rec, ch, ChannelBatching{MaxDelay: 10 * time.Millisecond})
+		}()
+
+		ch <- channelEvent{Name: "e"}
+		require.Eventually(t, func() bool {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			return len(rec.batches) == 1
+		}, time.Second, 5*time.Millisecond)
+
+		close(ch)
+		require.NoError(t, <-done)
+		assert.Len(t, rec.batches, 1)
+	})
+
+	t.Run("A failed batch stops, unless OnBatch is set", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{err: errors.ES(errors.OpFileIngest, errors.KBlobstore, "upload failed")}
+		err := IngestFromChannel(t.Context(), rec, events(3), ChannelBatching{MaxSize: 1})
+		require.ErrorContains(t, err, "upload failed")
+		assert.Len(t, rec.batches, 1)
+
+		rec = &batchRecorder{err: errors.ES(errors.OpFileIngest, errors.KBlobstore, "upload failed")}
+		var failed int
+		err = IngestFromChannel(t.Context(), rec, events(3), ChannelBatching{
+			MaxSize: 1,
+			OnBatch: func(records int, result *Result, err error) {
+				assert.Equal(t, 1, records)
+				if err != nil {
+					failed++
+				}
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, failed)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		t.Parallel()
+
+		err := IngestFromChannel(t.Context(), &batchRecorder{}, make(chan int), ChannelBatching{}, FileFormat(CSV))
+		require.ErrorContains(t, err, "records must be structs")
+
+		err = IngestFromChannel(t.Context(), &batchRecorder{}, events(1), ChannelBatching{}, FileFormat(Parquet))
+		require.ErrorContains(t, err, "can only be ingested as JSON, MultiJSON or CSV")
+
+		err = IngestFromChannel(t.Context(), nil, events(1), ChannelBatching{})
+		require.ErrorContains(t, err, "client must not be nil")
+	})
+
+	t.Run("Context done", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		err := IngestFromChannel(ctx, &batchRecorder{}, make(chan channelEvent), ChannelBatching{})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,62 @@
+package azkustoingest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+)
+
+// tableCreator creates a table of the client if it doesn't exist.
+type tableCreator func(ctx context.Context, client management.Client, db string, table string) error
+
+// WithCreateTableIfNotExists makes the client create the table with the columns, before its first ingestion into it, if the
+// database doesn't have it, which simplifies the bootstrap of new streams of data. An existing table, and its mappings, are left
+// as they are. The options are those of management.CreateTable, such as management.WithMapping to create a mapping with the
+// table, which the ingestions use if they are given IngestionMappingRef too. The client checks the table once, in each database
+// it ingests into it, and the identity of the client needs the rights to create tables in the database.
+func WithCreateTableIfNotExists(table string, cols query.Columns, options ...management.TableOption) Option {
+	return withTableCreator(table, func(ctx context.Context, client management.Client, db string, table string) error {
+		return management.CreateTable(ctx, client, db, table, cols, append(options, management.TableIfNotExists())...)
+	})
+}
+
+// WithCreateTableFromStructIfNotExists is like WithCreateTableIfNotExists, with a column for each exported field of T, as
+// management.CreateTableFromStruct creates, such as for the records of IngestFromChannel or a Batcher.
+func WithCreateTableFromStructIfNotExists[T any](table string, options ...management.TableOption) Option {
+	return withTableCreator(table, func(ctx context.Context, client management.Client, db string, table string) error {
+		if _, err := management.TableColumnsFromStruct[T](); err != nil {
+			return err
+		}
+		return management.CreateTableFromStruct[T](ctx, client, db, table, append(options, management.TableIfNotExists())...)
+	})
+}
+
+func withTableCreator(table string, create tableCreator) Option {
+	return func(s *Ingestion) {
+		if s.tableCreators == nil {
+			s.tableCreators = map[string]tableCreator{}
+		}
+		s.tableCreators[table] = create
+	}
+}
+
+// ensureTable creates the table of the ingestion with its creator in creators, if it has one and the client didn't already
+// check the table. created holds the tables the client checked, by database and table.
+func ensureTable(ctx context.Context, client management.Client, creators map[string]tableCreator, created *sync.Map, props *properties.All) error {
+	create, ok := creators[props.Ingestion.TableName]
+	if !ok {
+		return nil
+	}
+	key := [2]string{props.Ingestion.DatabaseName, props.Ingestion.TableName}
+	if _, ok := created.Load(key); ok {
+		return nil
+	}
+	if err := create(ctx, client, props.Ingestion.DatabaseName, props.Ingestion.TableName); err != nil {
+		return err
+	}
+	created.Store(key, struct{}{})
+	return nil
+}
@@ -0,0 +1,113 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tableCommands returns a client that records the management commands about tables, and answers `.show tables` with the
+// tables of existing.
+func tableCommands(existing ...string) (mockClient, *[]string) {
+	var mu sync.Mutex
+	commands := &[]string{}
+	client := newMockClient()
+	client.onMgmt = func(ctx context.Context, db string, stmt azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+		cmd := stmt.String()
+		if cmd == ".get kusto identity token" {
+			return nil, nil
+		}
+		mu.Lock()
+		*commands = append(*commands, cmd)
+		mu.Unlock()
+
+		rows := ""
+		if strings.HasPrefix(cmd, ".show tables") {
+			for _, table := range existing {
+				if strings.Contains(cmd, `"`+table+`"`) {
+					rows = `["` + table + `"]`
+				}
+			}
+		}
+		body := `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"TableName","DataType":"String","ColumnType":"string"}],"Rows":[` + rows + `]}]}`
+		return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(body)))
+	}
+	return client, commands
+}
+
+func TestCreateTableIfNotExists(t *testing.T) {
+	t.Parallel()
+
+	cols := query.Columns{query.NewColumn(0, "Name", types.String), query.NewColumn(1, "Count", types.Long)}
+
+	t.Run("Queued creates the table once", func(t *testing.T) {
+		t.Parallel()
+
+		client, commands := tableCommands()
+		ingestion, err := newFromClient(client, getOptions([]Option{WithDefaultDatabase("defaultDb"), WithDefaultTable("defaultTable"),
+			WithCreateTableIfNotExists("Events", cols, management.WithMapping(management.CSVMapping, "EventsCsv"))}))
+		require.NoError(t, err)
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				return "https://account.blob.core.windows.net/container/blob", 0, nil
+			},
+		}
+
+		for i := 0; i < 2; i++ {
+			_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,1\n"), Table("Events"), IngestionMappingRef("EventsCsv", CSV))
+			require.NoError(t, err)
+		}
+		// The other tables aren't created.
+		_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,1\n"), Table("Other"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`.show tables | where TableName == "Events"`,
+			".create-merge table Events (Name:string, Count:long)",
+			`.create-or-alter table Events ingestion csv mapping "EventsCsv" "[` +
+				`{\"Column\":\"Name\",\"DataType\":\"string\",\"Properties\":{\"Ordinal\":\"0\"}},` +
+				`{\"Column\":\"Count\",\"DataType\":\"long\",\"Properties\":{\"Ordinal\":\"1\"}}]"`,
+		}, *commands)
+	})
+
+	t.Run("Streaming leaves an existing table", func(t *testing.T) {
+		t.Parallel()
+
+		type event struct {
+			Name  string
+			Count int64
+		}
+		client, commands := tableCommands("Events")
+		o := getOptions([]Option{WithCreateTableFromStructIfNotExists[event]("Events"), WithCreateTableFromStructIfNotExists[int]("Counts")})
+		streaming := &Streaming{
+			db:            "defaultDb",
+			table:         "Events",
+			client:        client,
+			tableCreators: o.tableCreators,
+			streamConn: fakeStreamIngestor{
+				onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+					return nil
+				},
+			},
+		}
+
+		_, err := streaming.FromReader(t.Context(), strings.NewReader("{}"), FileFormat(JSON))
+		require.NoError(t, err)
+		assert.Equal(t, []string{`.show tables | where TableName == "Events"`}, *commands)
+
+		_, err = streaming.FromReader(t.Context(), strings.NewReader("{}"), FileFormat(JSON), Table("Counts"))
+		assert.ErrorContains(t, err, "expected a struct")
+	})
+}
@@ -0,0 +1,116 @@
+package azkustoingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/management/policies"
+	"github.com/google/uuid"
+)
+
+// CheckCreationTime checks the creation time given with SetCreationTime in the options against the merge policy of the table
+// the options ingest into, or of its database if the table has none. Extents created before the lookback of the policy aren't
+// merged with others, so a backfill job that ingests data older than it ends up with many small extents. It returns a
+// KClientArgs error if the creation time is in the future, or older than the lookback. The options are those of the ingestion,
+// only the creation time, the database and the table are used.
+func (i *Ingestion) CheckCreationTime(ctx context.Context, options ...FileOption) error {
+	props := i.newProp()
+	for _, option := range options {
+		if err := option.Run(&props, QueuedClient, FromFile|FromReader|FromBlob); err != nil {
+			return err
+		}
+	}
+
+	creationTime := props.Ingestion.Additional.CreationTime
+	if creationTime.IsZero() {
+		return errors.ES(errors.OpFileIngest, errors.KClientArgs, "no creation time was set with SetCreationTime").SetNoRetry()
+	}
+	if creationTime.After(time.Now()) {
+		return errors.ES(errors.OpFileIngest, errors.KClientArgs, "the creation time %s is in the future", creationTime).SetNoRetry()
+	}
+
+	db, table := props.Ingestion.DatabaseName, props.Ingestion.TableName
+	lookback := policies.Lookback{Kind: policies.LookbackDefault}
+	for _, entity := range []policies.Entity{policies.Table(table), policies.Database(db)} {
+		merge, err := policies.ShowMerge(ctx, i.client, db, entity)
+		if err != nil {
+			return err
+		}
+		if merge != nil && merge.Lookback.Kind != "" {
+			lookback = merge.Lookback
+			break
+		}
+	}
+
+	var hotSpan time.Duration
+	if lookback.Kind == policies.LookbackHotCache {
+		for _, entity := range []policies.Entity{policies.Table(table), policies.Database(db)} {
+			caching, err := policies.ShowCaching(ctx, i.client, db, entity)
+			if err != nil {
+				return err
+			}
+			if caching != nil {
+				hotSpan = caching.DataHotSpan
+				break
+			}
+		}
+	}
+
+	period := lookback.Period(hotSpan)
+	if period > 0 && time.Since(creationTime) > period {
+		return errors.ES(errors.OpFileIngest, errors.KClientArgs,
+			"the creation time %s is older than the %s lookback of the merge policy of table %s, %s: its extents won't be merged",
+			creationTime, lookback.Kind, table, period).SetNoRetry()
+	}
+	return nil
+}
+
+// DedupTag returns a tag for IngestByTags, IfNotExists or IngestOnce that is stable for the values, such as the path and the
+// date of a source, and holds no white space. It is a hash of the values, so that the same values give the same tag across
+// runs of a job.
+func DedupTag(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		// The length prefix keeps ("ab", "c") and ("a", "bc") apart.
+		h.Write([]byte(strconv.Itoa(len(v))))
+		h.Write([]byte{':'})
+		h.Write([]byte(v))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FileTag returns the DedupTag of the local file, from its name, size and modification time, so that replaying a file that
+// didn't change gives the same tag.
+func FileTag(fPath string) (string, error) {
+	info, err := os.Stat(fPath)
+	if err != nil {
+		return "", errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "problem retrieving source file %q: %s", fPath, err).SetNoRetry()
+	}
+	return DedupTag(filepath.Base(fPath), strconv.FormatInt(info.Size(), 10), info.ModTime().UTC().Format(time.RFC3339Nano)), nil
+}
+
+// sourceIDNamespace is the namespace of the UUIDs of DeterministicSourceID and ContentSourceID.
+var sourceIDNamespace = uuid.MustParse("6f1d3c9e-4b8a-4f27-9d2e-8c5a7b1e0f43")
+
+// DeterministicSourceID returns a UUID, version 5, that is stable for the values, such as the path and the date of a source, for
+// the SourceID option.
+func DeterministicSourceID(values ...string) uuid.UUID {
+	return uuid.NewSHA1(sourceIDNamespace, []byte(DedupTag(values...)))
+}
+
+// ContentSourceID returns a UUID, version 5, of the SHA-256 hash of the content of the reader, for the SourceID option. It reads
+// the reader to its end, so a reader that can't be read again, such as a pipe, must be buffered first.
+func ContentSourceID(reader io.Reader) (uuid.UUID, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return uuid.Nil, errors.ES(errors.OpFileIngest, errors.KIO, "could not read the source: %s", err)
+	}
+	return uuid.NewSHA1(sourceIDNamespace, h.Sum(nil)), nil
+}
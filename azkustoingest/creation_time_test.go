@@ -0,0 +1,198 @@
+package azkustoingest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// policyClient returns a client that answers the `.show policy` commands with the policies, by command, and no policy for the
+// others.
+func policyClient(t *testing.T, policies map[string]string) mockClient {
+	client := newMockClient()
+	client.onMgmt = func(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+		if !strings.HasPrefix(query.String(), ".show ") {
+			return nil, nil
+		}
+		policy, err := json.Marshal(policies[query.String()])
+		require.NoError(t, err)
+		body := `{"Tables":[{"TableName":"Table_0","Columns":[
+{"ColumnName":"PolicyName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityName","DataType":"String","ColumnType":"string"},
+{"ColumnName":"Policy","DataType":"String","ColumnType":"string"},
+{"ColumnName":"ChildEntities","DataType":"String","ColumnType":"string"},
+{"ColumnName":"EntityType","DataType":"String","ColumnType":"string"}],
+"Rows":[["Policy","[db]",` + string(policy) + `,"","Table"]]}]}`
+		return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(body)))
+	}
+	return client
+}
+
+func TestCheckCreationTime(t *testing.T) {
+	t.Parallel()
+
+	day := 24 * time.Hour
+	tests := []struct {
+		desc        string
+		policies    map[string]string
+		age         time.Duration
+		options     []FileOption
+		errContains string
+	}{
+		{
+			desc: "Within the default lookback",
+			age:  7 * day,
+		},
+		{
+			desc:        "Older than the default lookback",
+			age:         20 * day,
+			errContains: "older than the Default lookback",
+		},
+		{
+			desc:     "Custom lookback of the table",
+			policies: map[string]string{".show table T policy merge": `{"Lookback":{"Kind":"Custom","CustomPeriod":"60.00:00:00"}}`},
+			age:      30 * day,
+		},
+		{
+			desc: "Lookback of the database, for the table of the options",
+			policies: map[string]string{
+				".show table Other policy merge": "null",
+				".show database db policy merge": `{"Lookback":{"Kind":"Custom","CustomPeriod":"1.00:00:00"}}`,
+			},
+			age:         2 * day,
+			options:     []FileOption{Table("Other")},
+			errContains: "table Other",
+		},
+		{
+			desc:     "All the extents are merged",
+			policies: map[string]string{".show table T policy merge": `{"Lookback":{"Kind":"All"}}`},
+			age:      1000 * day,
+		},
+		{
+			desc: "Hot cache",
+			policies: map[string]string{
+				".show table T policy merge":       `{"Lookback":{"Kind":"HotCache"}}`,
+				".show database db policy caching": `{"DataHotSpan":"3.00:00:00","IndexHotSpan":"3.00:00:00"}`,
+			},
+			age:         4 * day,
+			errContains: "older than the HotCache lookback",
+		},
+		{
+			desc:        "Future",
+			age:         -day,
+			errContains: "in the future",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ingestion, err := newFromClient(policyClient(t, test.policies), &Ingestion{db: "db", table: "T"})
+			require.NoError(t, err)
+			options := append([]FileOption{SetCreationTime(time.Now().Add(-test.age))}, test.options...)
+			err = ingestion.CheckCreationTime(t.Context(), options...)
+			if test.errContains != "" {
+				assert.ErrorContains(t, err, test.errContains)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "db", table: "T"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, ingestion.CheckCreationTime(t.Context()), "no creation time")
+	assert.ErrorContains(t, ingestion.CheckCreationTime(t.Context(), SetCreationTime(time.Time{})), "must not be zero")
+}
+
+func TestDedupTag(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, DedupTag("a", "b"), DedupTag("a", "b"))
+	assert.NotEqual(t, DedupTag("ab", "c"), DedupTag("a", "bc"))
+	assert.NoError(t, validateTag(DedupTag("with space", "")))
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	require.NoError(t, os.WriteFile(path, []byte("a,1\n"), 0o600))
+	tag, err := FileTag(path)
+	require.NoError(t, err)
+	again, err := FileTag(path)
+	require.NoError(t, err)
+	assert.Equal(t, tag, again)
+
+	require.NoError(t, os.WriteFile(path, []byte("a,1\nb,2\n"), 0o600))
+	changed, err := FileTag(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, tag, changed)
+
+	_, err = FileTag(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestSourceID(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, DeterministicSourceID("a", "b"), DeterministicSourceID("a", "b"))
+	assert.NotEqual(t, DeterministicSourceID("ab"), DeterministicSourceID("a", "b"))
+	assert.Equal(t, uuid.Version(5), DeterministicSourceID("a").Version())
+
+	id, err := ContentSourceID(strings.NewReader("a,1\n"))
+	require.NoError(t, err)
+	again, err := ContentSourceID(strings.NewReader("a,1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	t.Run("Queued", func(t *testing.T) {
+		t.Parallel()
+
+		ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable"})
+		require.NoError(t, err)
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				return "https://account.blob.core.windows.net/container/blob", 0, nil
+			},
+			OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+				assert.Equal(t, id, props.Source.ID)
+				assert.Equal(t, id, props.Ingestion.ID)
+				return nil
+			},
+		}
+		result, err := ingestion.FromReader(t.Context(), strings.NewReader("a,1\n"), SourceID(id))
+		require.NoError(t, err)
+		assert.Equal(t, id, result.SourceID())
+
+		_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,1\n"), SourceID(uuid.Nil))
+		assert.ErrorContains(t, err, "must not be the nil UUID")
+	})
+
+	t.Run("Split parts have deterministic IDs", func(t *testing.T) {
+		t.Parallel()
+
+		var runs [][]uuid.UUID
+		for i := 0; i < 2; i++ {
+			ingestion, rec := splitRecorder(t)
+			_, err := ingestion.FromReader(t.Context(), strings.NewReader("a,1\nb,2\n"), SourceID(id), SplitLargeSources(4))
+			require.NoError(t, err)
+			runs = append(runs, rec.ids)
+		}
+		assert.Equal(t, runs[0], runs[1])
+		assert.Equal(t, id, runs[0][0])
+		assert.NotEqual(t, id, runs[0][1])
+	})
+}
@@ -0,0 +1,77 @@
+package azkustoingest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+// CSVRows returns a reader of the rows encoded as CSV, to ingest with FromReader, whose default format is CSV. Values are quoted
+// and escaped as needed. With skipHeader, the first row, such as the names of the columns, is left out.
+func CSVRows(rows [][]string, skipHeader bool) io.Reader {
+	if skipHeader && len(rows) > 0 {
+		rows = rows[1:]
+	}
+	return newCSVSource(func() ([]string, error) {
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	})
+}
+
+// CSVRecords returns a reader of the records of r encoded as CSV, to ingest with FromReader, whose default format is CSV. This
+// converts a source with another delimiter, comment lines or lax quoting, as configured on r, to the CSV that Kusto expects.
+// The records are read from r as the returned reader is read, so the source isn't held in memory. With skipHeader, the first
+// record is left out. An error of r, such as a *csv.ParseError, is returned by the reader, which fails the ingestion.
+func CSVRecords(r *csv.Reader, skipHeader bool) io.Reader {
+	skip := skipHeader
+	return newCSVSource(func() ([]string, error) {
+		if skip {
+			skip = false
+			if _, err := r.Read(); err != nil {
+				return nil, err
+			}
+		}
+		return r.Read()
+	})
+}
+
+// csvSource is an io.Reader of the CSV encoding of the rows returned by next, until it returns io.EOF.
+type csvSource struct {
+	next func() ([]string, error)
+	buf  bytes.Buffer
+	w    *csv.Writer
+	err  error
+}
+
+func newCSVSource(next func() ([]string, error)) *csvSource {
+	s := &csvSource{next: next}
+	s.w = csv.NewWriter(&s.buf)
+	return s
+}
+
+// Read implements io.Reader.
+func (s *csvSource) Read(p []byte) (int, error) {
+	for s.buf.Len() == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		row, err := s.next()
+		if err != nil {
+			s.err = err
+			continue
+		}
+		if err := s.w.Write(row); err != nil {
+			s.err = err
+			continue
+		}
+		s.w.Flush()
+		if err := s.w.Error(); err != nil {
+			s.err = err
+		}
+	}
+	return s.buf.Read(p)
+}
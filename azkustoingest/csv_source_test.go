@@ -0,0 +1,76 @@
+package azkustoingest
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVSources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc        string
+		source      func() io.Reader
+		want        string
+		errContains string
+	}{
+		{
+			desc: "Rows are quoted and escaped",
+			source: func() io.Reader {
+				return CSVRows([][]string{{"a", "b,c"}, {`say "hi"`, "multi\nline"}}, false)
+			},
+			want: "a,\"b,c\"\n\"say \"\"hi\"\"\",\"multi\nline\"\n",
+		},
+		{
+			desc: "Rows without the header",
+			source: func() io.Reader {
+				return CSVRows([][]string{{"Name", "Count"}, {"x", "1"}}, true)
+			},
+			want: "x,1\n",
+		},
+		{
+			desc: "No rows",
+			source: func() io.Reader {
+				return CSVRows(nil, true)
+			},
+			want: "",
+		},
+		{
+			desc: "Records of another delimiter, without the header",
+			source: func() io.Reader {
+				r := csv.NewReader(strings.NewReader("Name;Count\n# comment\nx;1\n\"y;z\";2\n\"a,b\";3\n"))
+				r.Comma = ';'
+				r.Comment = '#'
+				return CSVRecords(r, true)
+			},
+			want: "x,1\ny;z,2\n\"a,b\",3\n",
+		},
+		{
+			desc: "Invalid records",
+			source: func() io.Reader {
+				return CSVRecords(csv.NewReader(strings.NewReader("a,b\nc\n")), false)
+			},
+			errContains: "wrong number of fields",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := io.ReadAll(test.source())
+			if test.errContains != "" {
+				require.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package azkustoingest
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/status"
+	"github.com/google/uuid"
+)
+
+// DefaultListenInterval is the interval between the polls of the status queues once they are empty.
+var DefaultListenInterval = 10 * time.Second
+
+// statusQueueBatch is the number of messages received from a status queue at once, the maximum of Azure queues.
+const statusQueueBatch = 32
+
+// IngestionFailure is the failure of an ingestion, as reported by the service to its failed ingestions queue.
+type IngestionFailure struct {
+	IngestionStatus
+}
+
+// IsPermanent reports whether the ingestion failed for good, such as for malformed data, rather than for a transient reason
+// that ingesting the source again may overcome.
+func (f IngestionFailure) IsPermanent() bool {
+	return f.FailureStatus == Permanent
+}
+
+// ListenerOption is an option of a FailureListener.
+type ListenerOption func(l *FailureListener)
+
+// ListenInterval sets the interval between the polls of the status queues once they are empty. It defaults to
+// DefaultListenInterval.
+func ListenInterval(interval time.Duration) ListenerOption {
+	return func(l *FailureListener) {
+		l.interval = interval
+	}
+}
+
+// OnSuccess makes the listener read the successful ingestions queue too, and call fn with the status of each successful
+// ingestion reported to it, which the service only does for the ingestions made with ReportLevel(ReportAll).
+func OnSuccess(fn func(IngestionStatus)) ListenerOption {
+	return func(l *FailureListener) {
+		l.onSuccess = fn
+	}
+}
+
+// FailureListener delivers the failures of ingestions that the service reports to the status queues of the cluster, so that
+// failures are seen without polling the status of each ingestion. The queues are shared by all the clients that ingest into
+// the cluster, and messages are deleted once delivered, so a single listener should run per cluster; a message is delivered
+// again if the listener stops before deleting it.
+type FailureListener struct {
+	failed     []status.QueueReader
+	successful []status.QueueReader
+	interval   time.Duration
+	onSuccess  func(IngestionStatus)
+	logger     azkustodata.Logger
+
+	mu  sync.Mutex
+	err error
+}
+
+// FailureListener returns a FailureListener of the status queues of the cluster of the client.
+func (i *Ingestion) FailureListener(options ...ListenerOption) (*FailureListener, error) {
+	failed, successful, err := i.mgr.GetStatusQueues(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if len(failed) == 0 {
+		return nil, errors.ES(errors.OpFileIngest, errors.KInternal, "the ingestion resources of the cluster have no failed ingestions queue")
+	}
+
+	l := &FailureListener{interval: DefaultListenInterval, logger: i.log()}
+	for _, o := range options {
+		o(l)
+	}
+
+	queues := func(uris []*resources.URI) ([]status.QueueReader, error) {
+		readers := make([]status.QueueReader, 0, len(uris))
+		for _, u := range uris {
+			client, err := status.NewQueueClient(i.client.HttpClient(), *u)
+			if err != nil {
+				return nil, errors.E(errors.OpFileIngest, errors.KBlobstore, err)
+			}
+			readers = append(readers, client)
+		}
+		return readers, nil
+	}
+	if l.failed, err = queues(failed); err != nil {
+		return nil, err
+	}
+	if l.onSuccess != nil {
+		if l.successful, err = queues(successful); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Listen calls onFailure with each failure reported to the failed ingestions queues, and the OnSuccess function with each
+// success, until ctx is done, when it returns nil. It returns the error of the first poll of a queue that fails. The functions
+// are called from the goroutine of Listen, one at a time.
+func (l *FailureListener) Listen(ctx context.Context, onFailure func(IngestionFailure)) error {
+	for {
+		received := 0
+		for _, q := range l.failed {
+			n, err := l.drain(ctx, q, Failed, func(st IngestionStatus) { onFailure(IngestionFailure{st}) })
+			if err != nil {
+				return err
+			}
+			received += n
+		}
+		for _, q := range l.successful {
+			n, err := l.drain(ctx, q, Succeeded, l.onSuccess)
+			if err != nil {
+				return err
+			}
+			received += n
+		}
+		if received > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(l.interval):
+		}
+	}
+}
+
+// Failures returns a channel of the failures reported to the failed ingestions queues, as Listen delivers them. The channel
+// is closed once ctx is done, or a poll of a queue fails, whose error Err then returns.
+func (l *FailureListener) Failures(ctx context.Context) <-chan IngestionFailure {
+	ch := make(chan IngestionFailure)
+	go func() {
+		defer close(ch)
+		err := l.Listen(ctx, func(f IngestionFailure) {
+			select {
+			case ch <- f:
+			case <-ctx.Done():
+			}
+		})
+		l.mu.Lock()
+		l.err = err
+		l.mu.Unlock()
+	}()
+	return ch
+}
+
+// Err returns the error that closed the channel of Failures, if any.
+func (l *FailureListener) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// drain delivers the messages of one receive from the queue, deleting each once delivered, and returns their number.
+func (l *FailureListener) drain(ctx context.Context, q status.QueueReader, code StatusCode, deliver func(IngestionStatus)) (int, error) {
+	messages, err := q.Receive(ctx, statusQueueBatch)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, nil
+		}
+		return 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not read the status queue: %s", err)
+	}
+
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			return 0, nil
+		}
+		st, err := parseStatusMessage(msg.Text, code)
+		if err != nil {
+			l.logger.Warn("dropping a malformed ingestion status message", "message", string(msg.Text), "error", err)
+		} else {
+			deliver(st)
+		}
+		if err := q.Delete(ctx, msg); err != nil && ctx.Err() == nil {
+			return 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not delete a message of the status queue: %s", err)
+		}
+	}
+	return len(messages), nil
+}
+
+// parseStatusMessage parses a message of the failed or successful ingestions queue.
+func parseStatusMessage(text []byte, code StatusCode) (IngestionStatus, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(text, &data); err != nil {
+		return IngestionStatus{}, err
+	}
+
+	r := newStatusRecord()
+	r.FromMap(data)
+	r.Status = code
+	if r.ActivityID == uuid.Nil {
+		r.ActivityID = getGoogleUUIDFromInterface(data, "RootActivityId")
+	}
+	on := "FailedOn"
+	if code == Succeeded {
+		on = "SucceededOn"
+		r.ErrorCode, r.FailureStatus = "", ""
+	}
+	if t, err := getTimeFromInterface(data[on]); err == nil {
+		r.UpdatedOn = t
+	}
+	return newIngestionStatus(r), nil
+}
@@ -0,0 +1,113 @@
+package azkustoingest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/status"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueue is a status queue with the messages, that records those deleted.
+type fakeQueue struct {
+	mu       sync.Mutex
+	messages []status.QueueMessage
+	deleted  []string
+	err      error
+}
+
+func newFakeQueue(texts ...string) *fakeQueue {
+	q := &fakeQueue{}
+	for i, text := range texts {
+		q.messages = append(q.messages, status.QueueMessage{ID: fmt.Sprint(i), PopReceipt: "pop", Text: []byte(text)})
+	}
+	return q
+}
+
+func (q *fakeQueue) Receive(_ context.Context, max int32) ([]status.QueueMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return nil, q.err
+	}
+	n := min(int(max), len(q.messages))
+	batch := q.messages[:n]
+	q.messages = q.messages[n:]
+	return batch, nil
+}
+
+func (q *fakeQueue) Delete(_ context.Context, msg status.QueueMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deleted = append(q.deleted, msg.ID)
+	return nil
+}
+
+func TestFailureListener(t *testing.T) {
+	t.Parallel()
+
+	sourceID := uuid.New()
+	failure := fmt.Sprintf(`{"OperationId":"%s","Database":"db","Table":"T","FailedOn":"2024-05-01T10:00:00.1234567Z",
+"IngestionSourceId":"%s","IngestionSourcePath":"https://account.blob.core.windows.net/c/blob.csv?sig=secret",
+"Details":"Empty blob","ErrorCode":"BadRequest_EmptyBlob","FailureStatus":"Permanent","RootActivityId":"%s",
+"OriginatesFromUpdatePolicy":false}`, uuid.New(), sourceID, uuid.New())
+	success := fmt.Sprintf(`{"SucceededOn":"2024-05-01T10:00:00Z","Database":"db","Table":"T","IngestionSourceId":"%s"}`, sourceID)
+
+	failed := newFakeQueue(failure, "not json", `{"Table":"Other","FailureStatus":"Transient"}`)
+	successful := newFakeQueue(success)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	var successes []IngestionStatus
+	l := &FailureListener{
+		failed:     []status.QueueReader{failed},
+		successful: []status.QueueReader{successful},
+		interval:   time.Millisecond,
+		onSuccess: func(st IngestionStatus) {
+			successes = append(successes, st)
+			cancel()
+		},
+		logger: slog.Default(),
+	}
+
+	var failures []IngestionFailure
+	require.NoError(t, l.Listen(ctx, func(f IngestionFailure) { failures = append(failures, f) }))
+
+	require.Len(t, failures, 2)
+	f := failures[0]
+	assert.Equal(t, sourceID, f.SourceID)
+	assert.Equal(t, Failed, f.Status)
+	assert.Equal(t, "T", f.Table)
+	assert.Equal(t, "BadRequest_EmptyBlob", f.ErrorCode)
+	assert.Equal(t, "https://account.blob.core.windows.net/c/blob.csv", f.SourcePath)
+	assert.NotEqual(t, uuid.Nil, f.ActivityID)
+	assert.Equal(t, 2024, f.UpdatedOn.Year())
+	assert.True(t, f.IsPermanent())
+	assert.Error(t, f.Err())
+	assert.False(t, failures[1].IsPermanent())
+	assert.Equal(t, "Other", failures[1].Table)
+
+	// The malformed message is dropped, and all of them are deleted.
+	assert.Equal(t, []string{"0", "1", "2"}, failed.deleted)
+	require.Len(t, successes, 1)
+	assert.Equal(t, Succeeded, successes[0].Status)
+	assert.Empty(t, successes[0].ErrorCode)
+
+	// The channel of Failures is closed once a poll fails.
+	failed.messages = newFakeQueue(failure).messages
+	failed.err = nil
+	l.successful = nil
+	ch := l.Failures(t.Context())
+	assert.Equal(t, sourceID, (<-ch).SourceID)
+	failed.mu.Lock()
+	failed.err = fmt.Errorf("forbidden")
+	failed.mu.Unlock()
+	for range ch {
+	}
+	assert.ErrorContains(t, l.Err(), "forbidden")
+}
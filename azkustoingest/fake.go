@@ -0,0 +1,48 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// FakeIngestor is an Ingestor that calls its functions instead of ingesting, for the tests of code that takes an Ingestor.
+// A nil function returns NewFakeResult(Succeeded) and no error.
+type FakeIngestor struct {
+	OnFromFile   func(ctx context.Context, fPath string, options ...FileOption) (*Result, error)
+	OnFromReader func(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error)
+	OnClose      func() error
+}
+
+// FromFile implements Ingestor.FromFile.
+func (f *FakeIngestor) FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error) {
+	if f.OnFromFile == nil {
+		return NewFakeResult(Succeeded), nil
+	}
+	return f.OnFromFile(ctx, fPath, options...)
+}
+
+// FromReader implements Ingestor.FromReader.
+func (f *FakeIngestor) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+	if f.OnFromReader == nil {
+		return NewFakeResult(Succeeded), nil
+	}
+	return f.OnFromReader(ctx, reader, options...)
+}
+
+// Close implements Ingestor.Close.
+func (f *FakeIngestor) Close() error {
+	if f.OnClose == nil {
+		return nil
+	}
+	return f.OnClose()
+}
+
+// NewFakeResult returns a Result with the status, for a FakeIngestor to return. Wait and Poll return at once for a final
+// status, and Wait sends the status record if it isn't a success.
+func NewFakeResult(code StatusCode) *Result {
+	res := newResult()
+	res.record.Status = code
+	res.record.UpdatedOn = time.Now()
+	return res
+}
@@ -0,0 +1,35 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeIngestor(t *testing.T) {
+	t.Parallel()
+
+	var client Ingestor = &FakeIngestor{}
+	res, err := client.FromFile(t.Context(), "/path/to/file")
+	require.NoError(t, err)
+	assert.NoError(t, <-res.Wait(t.Context()))
+	assert.Equal(t, Succeeded, res.Status().Status)
+	assert.NoError(t, client.Close())
+
+	var got string
+	client = &FakeIngestor{
+		OnFromReader: func(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+			b, err := io.ReadAll(reader)
+			got = string(b)
+			return NewFakeResult(Failed), err
+		},
+	}
+	res, err = client.FromReader(t.Context(), strings.NewReader("a,b"))
+	require.NoError(t, err)
+	assert.Equal(t, "a,b", got)
+	assert.True(t, IsStatusRecord(<-res.Wait(t.Context())))
+}
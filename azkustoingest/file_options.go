@@ -3,10 +3,15 @@ package azkustoingest
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
@@ -95,7 +100,8 @@ func (o option) Run(p *properties.All, clientType ClientScope, sourceType Source
 	return o.run(p)
 }
 
-// Database overrides the default database name.
+// Database overrides the default database name, given with WithDefaultDatabase, for this ingestion. A single client can so
+// ingest into any number of databases and tables, sharing its connections and the cached ingestion resources of the cluster.
 func Database(name string) FileOption {
 	return option{
 		run: func(p *properties.All) error {
@@ -108,7 +114,7 @@ func Database(name string) FileOption {
 	}
 }
 
-// Table overrides the default table name.
+// Table overrides the default table name, given with WithDefaultTable, for this ingestion.
 func Table(name string) FileOption {
 	return option{
 		run: func(p *properties.All) error {
@@ -141,13 +147,17 @@ func backOff(off *backoff.ExponentialBackOff) FileOption {
 			p.ManagedStreaming.Backoff = off
 			return nil
 		},
-		clientScopes: ManagedClient,
+		clientScopes: StreamingClient | ManagedClient,
 		sourceScope:  FromFile | FromReader | FromBlob,
 		name:         "BackOff",
 	}
 }
 
 // FlushImmediately  the service batching manager will not aggregate this file, thus overriding the batching policy
+// This lowers the latency of queued ingestion for latency sensitive, low volume tables. Each ingestion then creates its own
+// extents, so using it for frequent or small ingestions loads the cluster with many small extents to merge; the client logs a
+// warning the first time it is used. The batching of a table is better tuned with its ingestion batching policy, which the
+// service applies to all the ingestions of the table, as it accepts no other batching setting per ingestion.
 func FlushImmediately() FileOption {
 	return option{
 		run: func(p *properties.All) error {
@@ -160,7 +170,9 @@ func FlushImmediately() FileOption {
 	}
 }
 
-// IgnoreFirstRecord tells Kusto to flush on write.
+// IgnoreFirstRecord tells Kusto to skip the first record of the data, such as the header row of a CSV file, so files with a
+// header can be ingested without removing it first. Streaming ingestion doesn't support it, so the managed client ingests such
+// data with queued ingestion.
 func IgnoreFirstRecord() FileOption {
 	return option{
 		run: func(p *properties.All) error {
@@ -229,8 +241,10 @@ func InferFormatFromFileName(fName string) DataFormat {
 
 // IngestionMapping provides runtime mapping of the data being imported to the columns in the table.
 // "mapping" will be JSON encoded, so it can be any type that can be JSON marshalled. If you pass a string
-// or []byte, it will be interpreted as already being JSON encoded.
+// or []byte, it will be interpreted as already being JSON encoded. A []ColumnMapping is checked before it is encoded.
 // The format parameter will automatically set the FileOption.Format option.
+// Streaming ingestion only supports mapping references, so the managed client ingests data with an inline mapping with queued
+// ingestion.
 func IngestionMapping(mapping interface{}, format DataFormat) FileOption {
 	return option{
 		run: func(p *properties.All) error {
@@ -244,6 +258,12 @@ func IngestionMapping(mapping interface{}, format DataFormat) FileOption {
 				).SetNoRetry()
 			}
 
+			if m, ok := mapping.([]ColumnMapping); ok {
+				if err := validateColumnMappings(m, kind); err != nil {
+					return err
+				}
+			}
+
 			var j string
 			switch v := mapping.(type) {
 			case string:
@@ -308,6 +328,63 @@ func DeleteSource() FileOption {
 	}
 }
 
+// SplitLargeSources splits a file larger than maxSize bytes, or a reader, into parts of at most maxSize bytes of data before
+// compression, each uploaded to its own blob and ingested with its own message, rather than ingesting it as one blob larger than
+// the service handles well. Zero uses DefaultSplitSize. The parts end on record boundaries, so only the CSV-like formats, TXT,
+// JSON and MultiJSON can be split, and compressed sources can't be. With IgnoreFirstRecord, the first record, such as a header,
+// is repeated at the start of each part. The Result of a source that was split holds the results of its parts, see
+// Result.Parts.
+func SplitLargeSources(maxSize int64) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			if maxSize < 0 {
+				return errors.ES(errors.OpFileIngest, errors.KClientArgs, "the split size must not be negative, got %d", maxSize).SetNoRetry()
+			}
+			if maxSize == 0 {
+				maxSize = DefaultSplitSize
+			}
+			p.Source.SplitSize = maxSize
+			return nil
+		},
+		clientScopes: QueuedClient | ManagedClient,
+		sourceScope:  FromFile | FromReader,
+		name:         "SplitLargeSources",
+	}
+}
+
+// DeleteSourceOnSuccess makes the service delete the source blob once it is ingested successfully. The blob is kept if the
+// ingestion fails. The service must be allowed to delete the blob, such as with a SAS that has the delete permission.
+func DeleteSourceOnSuccess() FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			p.Source.DeleteBlobOnSuccess = true
+			return nil
+		},
+		clientScopes: QueuedClient,
+		sourceScope:  FromBlob,
+		name:         "DeleteSourceOnSuccess",
+	}
+}
+
+// SignBlobURL adds a SAS signed with cred to the URL of the source blob, for blobs the service can't read otherwise. The SAS
+// allows reading the blob, and deleting it if DeleteSourceOnSuccess is also given, for validity.
+func SignBlobURL(cred *azblob.SharedKeyCredential, validity time.Duration) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			if cred == nil || validity <= 0 {
+				return errors.ES(errors.OpFileIngest, errors.KClientArgs, "SignBlobURL requires a credential and a positive validity").SetNoRetry()
+			}
+			p.Source.SignBlobURL = func(blobURL string) (string, error) {
+				return signBlobURL(cred, validity, blobURL, sas.BlobPermissions{Read: true, Delete: p.Source.DeleteBlobOnSuccess})
+			}
+			return nil
+		},
+		clientScopes: QueuedClient | StreamingClient,
+		sourceScope:  FromBlob,
+		name:         "SignBlobURL",
+	}
+}
+
 // IgnoreSizeLimit ignores the size limit for data ingestion.
 func IgnoreSizeLimit() FileOption {
 	return option{
@@ -322,11 +399,12 @@ func IgnoreSizeLimit() FileOption {
 }
 
 // Tags are tags to be associated with the ingested ata.
+// Tags can be given with IngestByTags and DropByTags too, and are added to theirs. A tag must not be empty, nor contain white
+// space, which separates the tags of an extent.
 func Tags(tags []string) FileOption {
 	return option{
 		run: func(p *properties.All) error {
-			p.Ingestion.Additional.Tags = tags
-			return nil
+			return addTags(p, "", tags)
 		},
 		sourceScope:  FromFile | FromReader | FromBlob,
 		clientScopes: QueuedClient | ManagedClient,
@@ -334,13 +412,42 @@ func Tags(tags []string) FileOption {
 	}
 }
 
+// IngestByTags tags the ingested data with "ingest-by:" tags with the values, which IfNotExists checks to skip data that was
+// already ingested.
+func IngestByTags(values ...string) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			return addTags(p, ingestByPrefix, values)
+		},
+		sourceScope:  FromFile | FromReader | FromBlob,
+		clientScopes: QueuedClient | ManagedClient,
+		name:         "IngestByTags",
+	}
+}
+
+// DropByTags tags the ingested data with "drop-by:" tags with the values, which lets the extents of the data be dropped
+// together, such as with management.DropExtentsByTags.
+func DropByTags(values ...string) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			return addTags(p, dropByPrefix, values)
+		},
+		sourceScope:  FromFile | FromReader | FromBlob,
+		clientScopes: QueuedClient | ManagedClient,
+		name:         "DropByTags",
+	}
+}
+
 // IfNotExists provides a string value that, if specified, prevents ingestion from succeeding if the table already
 // has data tagged with an ingest-by: tag with the same value. This ensures idempotent data ingestion.
 // For more information see: https://docs.microsoft.com/en-us/azure/kusto/management/extents-overview#ingest-by-extent-tags
 func IfNotExists(ingestByTag string) FileOption {
 	return option{
 		run: func(p *properties.All) error {
-			p.Ingestion.Additional.IngestIfNotExists = ingestByTag
+			if err := validateTag(ingestByTag); err != nil {
+				return err
+			}
+			p.Ingestion.Additional.IngestIfNotExists = append(p.Ingestion.Additional.IngestIfNotExists, ingestByTag)
 			return nil
 		},
 		sourceScope:  FromFile | FromReader | FromBlob,
@@ -349,14 +456,129 @@ func IfNotExists(ingestByTag string) FileOption {
 	}
 }
 
-// ReportResultToTable option requests that the ingestion status will be tracked in an Azure table.
+// IngestOnce ingests the data only if the table has no data tagged with the ingest-by: tag with the value, and tags the data
+// with it, like IfNotExists and IngestByTags together. Re-running a backfill job that ingests each of its parts with a value
+// of its own, such as the date of the part with SetCreationTime, then ingests the parts that failed only.
+func IngestOnce(value string) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			if err := addTags(p, ingestByPrefix, []string{value}); err != nil {
+				return err
+			}
+			p.Ingestion.Additional.IngestIfNotExists = append(p.Ingestion.Additional.IngestIfNotExists, value)
+			return nil
+		},
+		sourceScope:  FromFile | FromReader | FromBlob,
+		clientScopes: QueuedClient | ManagedClient,
+		name:         "IngestOnce",
+	}
+}
+
+const (
+	ingestByPrefix = "ingest-by:"
+	dropByPrefix   = "drop-by:"
+)
+
+// addTags adds the tags to the tags of the ingestion, with the prefix unless they already have it.
+func addTags(p *properties.All, prefix string, tags []string) error {
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(tag, prefix) {
+			tag = prefix + tag
+		}
+		p.Ingestion.Additional.Tags = append(p.Ingestion.Additional.Tags, tag)
+	}
+	return nil
+}
+
+// validateTag checks that the tag is neither empty nor contains white space.
+func validateTag(tag string) error {
+	if tag == "" {
+		return errors.ES(errors.OpUnknown, errors.KClientArgs, "tags must not be empty").SetNoRetry()
+	}
+	if strings.IndexFunc(tag, unicode.IsSpace) >= 0 {
+		return errors.ES(errors.OpUnknown, errors.KClientArgs, "tag %q must not contain white space", tag).SetNoRetry()
+	}
+	return nil
+}
+
+// IngestionReportLevel is the level of the ingestion statuses the service reports, set with ReportLevel.
+type IngestionReportLevel = properties.IngestionReportLevel
+
+//goland:noinspection GoUnusedConst - Part of the API
+const (
+	// ReportFailuresOnly reports the status of the failed ingestions only. It is the default.
+	ReportFailuresOnly IngestionReportLevel = properties.FailuresOnly
+	// ReportNone reports no status.
+	ReportNone IngestionReportLevel = properties.None
+	// ReportAll reports the status of the ingestions, successful or not.
+	ReportAll IngestionReportLevel = properties.FailureAndSuccess
+)
+
+// IngestionReportMethod is where the service reports the ingestion statuses to, set with ReportMethod.
+type IngestionReportMethod = properties.IngestionReportMethod
+
+//goland:noinspection GoUnusedConst - Part of the API
+const (
+	// ReportToQueue reports the statuses to the status queues of the cluster. It is the default.
+	ReportToQueue IngestionReportMethod = properties.ReportStatusToQueue
+	// ReportToTable reports the statuses to the status table of the cluster, which Result.Wait, Result.Poll and
+	// StatusReporter read.
+	ReportToTable IngestionReportMethod = properties.ReportStatusToTable
+	// ReportToQueueAndTable reports the statuses to both the status queues and the status table.
+	ReportToQueueAndTable IngestionReportMethod = properties.ReportStatusToQueueAndTable
+)
+
+// ReportLevel sets which ingestion statuses the service reports, to where ReportMethod sets. With ReportNone, the result of the
+// ingestion is Queued whatever the method. With ReportFailuresOnly and a method that reports to the table, the status of a
+// successful ingestion stays Pending in the table, so that Result.Wait only returns on a failure or once its context is done.
+func ReportLevel(level IngestionReportLevel) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			switch level {
+			case ReportFailuresOnly, ReportNone, ReportAll:
+			default:
+				return errors.ES(errors.OpUnknown, errors.KClientArgs, "unknown report level %d", level).SetNoRetry()
+			}
+			p.Ingestion.ReportLevel = level
+			return nil
+		},
+		sourceScope:  FromFile | FromReader | FromBlob,
+		clientScopes: QueuedClient | ManagedClient,
+		name:         "ReportLevel",
+	}
+}
+
+// ReportMethod sets where the service reports the ingestion statuses that ReportLevel sets to. The results of the ingestions
+// are tracked in the status table with ReportToTable or ReportToQueueAndTable, unless the level is ReportNone.
+func ReportMethod(method IngestionReportMethod) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			switch method {
+			case ReportToQueue, ReportToTable, ReportToQueueAndTable:
+			default:
+				return errors.ES(errors.OpUnknown, errors.KClientArgs, "unknown report method %d", method).SetNoRetry()
+			}
+			p.Ingestion.ReportMethod = method
+			return nil
+		},
+		sourceScope:  FromFile | FromReader | FromBlob,
+		clientScopes: QueuedClient | ManagedClient,
+		name:         "ReportMethod",
+	}
+}
+
+// ReportResultToTable option requests that the ingestion status will be tracked in an Azure table. It is the same as
+// ReportLevel(ReportAll) and ReportMethod(ReportToTable).
 // Note using Table status reporting is not recommended for high capacity ingestions, as it could slow down the ingestion.
 // In such cases, it's recommended to enable it temporarily for debugging failed ingestions.
 func ReportResultToTable() FileOption {
 	return option{
 		run: func(p *properties.All) error {
-			p.Ingestion.ReportLevel = properties.FailureAndSuccess
-			p.Ingestion.ReportMethod = properties.ReportStatusToTable
+			p.Ingestion.ReportLevel = ReportAll
+			p.Ingestion.ReportMethod = ReportToTable
 			return nil
 		},
 		sourceScope:  FromFile | FromReader | FromBlob,
@@ -365,11 +587,31 @@ func ReportResultToTable() FileOption {
 	}
 }
 
+// ReportResultToQueue option requests that the service reports the status of the ingestion, successful or not, to the
+// status queues of the cluster, instead of only reporting failures. The result of the ingestion is then Queued.
+func ReportResultToQueue() FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			p.Ingestion.ReportLevel = ReportAll
+			p.Ingestion.ReportMethod = ReportToQueue
+			return nil
+		},
+		sourceScope:  FromFile | FromReader | FromBlob,
+		clientScopes: QueuedClient | ManagedClient,
+		name:         "ReportResultToQueue",
+	}
+}
+
 // SetCreationTime option allows the user to override the data creation time the retention policies are considered against
 // If not set the data creation time is considered to be the time of ingestion
+// Backfill jobs that replay historical data set it to the time of the data. Data older than the lookback of the merge policy
+// of the table isn't merged, which CheckCreationTime checks before the ingestion.
 func SetCreationTime(t time.Time) FileOption {
 	return option{
 		run: func(p *properties.All) error {
+			if t.IsZero() {
+				return errors.ES(errors.OpUnknown, errors.KClientArgs, "the creation time must not be zero").SetNoRetry()
+			}
 			p.Ingestion.Additional.CreationTime = t
 			return nil
 		},
@@ -391,6 +633,14 @@ const (
 	SameNumberOfFields ValidationOption = 1
 	// IgnoreNonDoubleQuotedFields indicates that fields that do not have double quotes should be ignored.
 	IgnoreNonDoubleQuotedFields ValidationOption = 2
+
+	// DoNotValidate is the name the service gives to VOUnknown: the records are only checked to be well-formed in their format,
+	// such as lines of JSON that parse.
+	DoNotValidate = VOUnknown
+	// ValidateCsvInputConstantColumns is the name the service gives to SameNumberOfFields.
+	ValidateCsvInputConstantColumns = SameNumberOfFields
+	// ValidateCsvInputColumnLevelOnly is the name the service gives to IgnoreNonDoubleQuotedFields.
+	ValidateCsvInputColumnLevelOnly = IgnoreNonDoubleQuotedFields
 )
 
 // ValidationImplication is a setting used to indicate what to do when a Validation Policy is violated.
@@ -403,6 +653,12 @@ const (
 	FailIngestion ValidationImplication = 0
 	// IgnoreFailures indicates that failure of the ValidationPolicy will be ignored.
 	IgnoreFailures ValidationImplication = 1
+
+	// Fail is the name the service gives to FailIngestion.
+	Fail = FailIngestion
+	// BestEffort is the name the service gives to IgnoreFailures: the records that fail validation, such as malformed lines of
+	// JSON, are skipped, and the others are ingested.
+	BestEffort = IgnoreFailures
 )
 
 // ValPolicy sets a policy for validating data as it is sent for ingestion.
@@ -415,11 +671,21 @@ type ValPolicy struct {
 }
 
 // ValidationPolicy uses a ValPolicy to set our ingestion data validation policy. If not set, no validation policy
-// is used.
+// is used, and the default of the cluster applies, which fails the ingestion of data that has malformed records.
+// To skip malformed records, such as lines of JSON that don't parse, instead of failing the whole ingestion, use:
+//
+//	ValidationPolicy(ValPolicy{Options: DoNotValidate, Implications: BestEffort})
+//
 // For more information, see: https://docs.microsoft.com/en-us/azure/kusto/management/data-ingestion/
 func ValidationPolicy(policy ValPolicy) FileOption {
 	return option{
 		run: func(p *properties.All) error {
+			if policy.Options < VOUnknown || policy.Options > IgnoreNonDoubleQuotedFields {
+				return errors.ES(errors.OpFileIngest, errors.KClientArgs, "unknown validation option %d", policy.Options).SetNoRetry()
+			}
+			if policy.Implications < FailIngestion || policy.Implications > IgnoreFailures {
+				return errors.ES(errors.OpFileIngest, errors.KClientArgs, "unknown validation implication %d", policy.Implications).SetNoRetry()
+			}
 			b, err := json.Marshal(policy)
 			if err != nil {
 				return errors.ES(errors.OpUnknown, errors.KInternal, "bug: the ValPolicy provided would not JSON encode").SetNoRetry()
@@ -438,11 +704,13 @@ func ValidationPolicy(policy ValPolicy) FileOption {
 // FileFormat can be used to indicate what type of encoding is supported for the file. This is only needed if
 // the file extension is not present. A file like: "input.json.gz" or "input.json" does not need this option, while
 // "input" would.
-// If an ingestion mapping is specified, there is no need to specify the file format.
+// If an ingestion mapping is specified, there is no need to specify the file format. Setting it also skips the check of the
+// file extension against the kind of the mapping, for files whose extension doesn't match their content.
 func FileFormat(et DataFormat) FileOption {
 	return option{
 		run: func(p *properties.All) error {
 			p.Ingestion.Additional.Format = et
+			p.Source.FormatSet = true
 			return nil
 		},
 		sourceScope:  FromFile | FromReader | FromBlob,
@@ -451,6 +719,26 @@ func FileFormat(et DataFormat) FileOption {
 	}
 }
 
+// SourceID sets the ID of the source, instead of a random one. It is the ID of the ingestion message that queued clients post,
+// the key of its record in the status table, and Result.SourceID, so that the statuses of a job are correlated with its sources.
+// A deterministic ID, such as from DeterministicSourceID or ContentSourceID, gives the same ID to a source replayed by a job;
+// its status record is then reset. The service deduplicates replayed data by the tags of IfNotExists, such as a DedupTag,
+// not by source ID. The parts of a source split with SplitLargeSources get IDs derived from it.
+func SourceID(id uuid.UUID) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			if id == uuid.Nil {
+				return errors.ES(errors.OpUnknown, errors.KClientArgs, "the source ID must not be the nil UUID").SetNoRetry()
+			}
+			p.Source.ID = id
+			return nil
+		},
+		sourceScope:  FromFile | FromReader | FromBlob,
+		clientScopes: QueuedClient | StreamingClient | ManagedClient,
+		name:         "SourceID",
+	}
+}
+
 // ClientRequestId is an identifier for the ingestion, that can later be queried.
 func ClientRequestId(clientRequestId string) FileOption {
 	return option{
@@ -467,6 +755,7 @@ func ClientRequestId(clientRequestId string) FileOption {
 // CompressionType sets the compression type of the data.
 // Use this if the file name does not expose the compression type.
 // This sets DontCompress to true for compressed data.
+// CompressedSource also declares the size of the data of a compressed source.
 func CompressionType(compressionType ingestoptions.CompressionType) FileOption {
 	return option{
 		run: func(p *properties.All) error {
@@ -479,8 +768,37 @@ func CompressionType(compressionType ingestoptions.CompressionType) FileOption {
 	}
 }
 
+// CompressedSource declares that the source is already compressed with the compression, GZIP or ZIP, such as a reader of a
+// compressed stream or a file whose name doesn't tell, so that it is neither compressed again nor read as uncompressed data.
+// rawDataSize is the size of its data once decompressed, or an estimate of it, as with RawDataSize. If it is 0, the size of the
+// data is estimated from the size of the source, when it is known. The service batches the blobs by the size of their data, so
+// a blob of a compressed source with a wrong size skews the batching.
+func CompressedSource(compression ingestoptions.CompressionType, rawDataSize int64) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			if compression != ingestoptions.GZIP && compression != ingestoptions.ZIP {
+				return errors.ES(errors.OpUnknown, errors.KClientArgs, "CompressedSource takes GZIP or ZIP, got %s", compression).SetNoRetry()
+			}
+			if rawDataSize < 0 {
+				return errors.ES(errors.OpUnknown, errors.KClientArgs, "the raw data size must not be negative, got %d", rawDataSize).SetNoRetry()
+			}
+			p.Source.CompressionType = compression
+			p.Source.DontCompress = true
+			if rawDataSize > 0 {
+				p.Ingestion.RawDataSize = rawDataSize
+			}
+			return nil
+		},
+		clientScopes: QueuedClient | StreamingClient | ManagedClient,
+		sourceScope:  FromFile | FromReader,
+		name:         "CompressedSource",
+	}
+}
+
 // RawDataSize is the uncompressed data size. Should be used to comunicate the file size to the service for efficient ingestion.
 // Also used by managed client in the decision to use queued ingestion instead of streaming (if > 4mb)
+// It is most useful for binary formats, such as Parquet, ORC and Avro, whose files are much smaller than their data. Data in
+// these formats can be ingested without a mapping, in which case its columns are matched to the columns of the table by name.
 func RawDataSize(size int64) FileOption {
 	return option{
 		run: func(p *properties.All) error {
@@ -492,3 +810,68 @@ func RawDataSize(size int64) FileOption {
 		name:         "RawDataSize",
 	}
 }
+
+// Stage is a step of an ingestion, reported to the callback of OnStage.
+type Stage = properties.Stage
+
+//goland:noinspection GoUnusedConst - Part of the API
+const (
+	// StageCompressing is reported when the client starts compressing the data with gzip. The data is compressed while it is
+	// uploaded or streamed, so StageUploading or StageStreaming follows right away.
+	StageCompressing Stage = properties.SCompressing
+	// StageUploading is reported when the data starts being uploaded to a container, and again when the upload is retried.
+	StageUploading Stage = properties.SUploading
+	// StageQueued is reported when the ingestion is queued, after the data is uploaded.
+	StageQueued Stage = properties.SQueued
+	// StageStreaming is reported when the data starts being sent to the streaming endpoint.
+	StageStreaming Stage = properties.SStreaming
+)
+
+// OnProgress calls fn as the data is uploaded or streamed, with the bytes of the data sent so far, and its total size, or -1 if
+// it isn't known, such as for readers other than a *bytes.Reader or a *strings.Reader. The count starts over when an upload is
+// retried. fn may be called from another goroutine, and must return quickly.
+func OnProgress(fn func(sent int64, total int64)) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			p.Source.OnProgress = fn
+			return nil
+		},
+		clientScopes: QueuedClient | StreamingClient | ManagedClient,
+		sourceScope:  FromFile | FromReader,
+		name:         "OnProgress",
+	}
+}
+
+// OnStage calls fn as the ingestion goes through its stages, such as compressing, uploading and queueing the data, so the time
+// spent in each one can be measured. fn may be called from another goroutine, and must return quickly.
+func OnStage(fn func(stage Stage)) FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			p.Source.OnStage = fn
+			return nil
+		},
+		clientScopes: QueuedClient | StreamingClient | ManagedClient,
+		sourceScope:  FromFile | FromReader | FromBlob,
+		name:         "OnStage",
+	}
+}
+
+// Preview is what a dry run of an ingestion would send, returned by Result.Preview.
+type Preview = properties.Preview
+
+// DryRun makes the ingestion a dry run, to debug mappings and formats safely: the options are validated, and the data is read
+// and compressed as it would be, but nothing is uploaded or posted to the queues, and no table is created. Result.Preview
+// returns the blob that would be uploaded and the ingestion message that would be posted, and Result.Metrics the sizes of the
+// data. The ingestion message carries the authorization context and the SAS of the blob, as the one sent does. DryRun can't be
+// combined with SplitLargeSources.
+func DryRun() FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			p.Source.Preview = &properties.Preview{}
+			return nil
+		},
+		clientScopes: QueuedClient,
+		sourceScope:  FromFile | FromReader | FromBlob,
+		name:         "DryRun",
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"context"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"io"
+	"strings"
 	"testing"
 
+	"github.com/Azure/azure-kusto-go/azkustodata"
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -250,3 +255,230 @@ func TestFileFormatAndMapping(t *testing.T) {
 	}
 
 }
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc                string
+		options             []FileOption
+		expectedTags        []string
+		expectedIfNotExists []string
+		err                 string
+	}{
+		{
+			desc:         "Tags",
+			options:      []FileOption{Tags([]string{"blue", "green"})},
+			expectedTags: []string{"blue", "green"},
+		},
+		{
+			desc:         "Ingest-by and drop-by tags",
+			options:      []FileOption{Tags([]string{"blue"}), IngestByTags("2024-01-01"), DropByTags("drop-by:old", "part1")},
+			expectedTags: []string{"blue", "ingest-by:2024-01-01", "drop-by:old", "drop-by:part1"},
+		},
+		{
+			desc:                "If not exists",
+			options:             []FileOption{IfNotExists("2024-01-01")},
+			expectedIfNotExists: []string{"2024-01-01"},
+		},
+		{
+			desc:                "Ingest once",
+			options:             []FileOption{IngestOnce("2024-01-01")},
+			expectedTags:        []string{"ingest-by:2024-01-01"},
+			expectedIfNotExists: []string{"2024-01-01"},
+		},
+		{
+			desc:    "Empty tag",
+			options: []FileOption{DropByTags("")},
+			err:     "must not be empty",
+		},
+		{
+			desc:    "Tag with white space",
+			options: []FileOption{IngestOnce("2024 01 01")},
+			err:     "must not contain white space",
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			props := properties.All{}
+			var err error
+			for _, o := range test.options {
+				if err = o.Run(&props, QueuedClient, FromFile); err != nil {
+					break
+				}
+			}
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, properties.TagsList(test.expectedTags), props.Ingestion.Additional.Tags)
+			assert.Equal(t, properties.TagsList(test.expectedIfNotExists), props.Ingestion.Additional.IngestIfNotExists)
+		})
+	}
+}
+
+func TestValidationPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		policy   ValPolicy
+		expected string
+		err      string
+	}{
+		{
+			desc:     "Skip malformed records",
+			policy:   ValPolicy{Options: DoNotValidate, Implications: BestEffort},
+			expected: `{"ValidationOptions":0,"ValidationImplications":1}`,
+		},
+		{
+			desc:     "Fail on a different number of fields",
+			policy:   ValPolicy{Options: SameNumberOfFields, Implications: FailIngestion},
+			expected: `{"ValidationOptions":1,"ValidationImplications":0}`,
+		},
+		{
+			desc:   "Unknown option",
+			policy: ValPolicy{Options: 3},
+			err:    "unknown validation option 3",
+		},
+		{
+			desc:   "Unknown implication",
+			policy: ValPolicy{Implications: -1},
+			err:    "unknown validation implication -1",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			props := properties.All{}
+			err := ValidationPolicy(test.policy).Run(&props, QueuedClient, FromFile)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, props.Ingestion.Additional.ValidationPolicy)
+		})
+	}
+}
+
+func TestCompressedSource(t *testing.T) {
+	t.Parallel()
+
+	props := properties.All{}
+	require.NoError(t, CompressedSource(ingestoptions.GZIP, 1000).Run(&props, QueuedClient, FromReader))
+	assert.Equal(t, ingestoptions.GZIP, props.Source.CompressionType)
+	assert.True(t, props.Source.DontCompress)
+	assert.Equal(t, int64(1000), props.Ingestion.RawDataSize)
+
+	assert.ErrorContains(t, CompressedSource(ingestoptions.CTNone, 0).Run(&props, QueuedClient, FromReader), "GZIP or ZIP")
+	assert.ErrorContains(t, CompressedSource(ingestoptions.ZIP, -1).Run(&props, QueuedClient, FromReader), "must not be negative")
+	assert.Error(t, CompressedSource(ingestoptions.ZIP, 0).Run(&props, QueuedClient, FromBlob))
+
+	t.Run("Queued keeps the declared size", func(t *testing.T) {
+		t.Parallel()
+
+		ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable"})
+		require.NoError(t, err)
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				assert.True(t, props.Source.DontCompress)
+				return "https://account.blob.core.windows.net/container/blob.csv.zip", 10, nil
+			},
+			OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+				assert.Equal(t, int64(1000), props.Ingestion.RawDataSize)
+				return nil
+			},
+		}
+		_, err = ingestion.FromReader(t.Context(), strings.NewReader("PK"), CompressedSource(ingestoptions.ZIP, 1000))
+		require.NoError(t, err)
+	})
+
+	t.Run("Streaming rejects zip content", func(t *testing.T) {
+		t.Parallel()
+
+		streaming, err := newStreamingFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable"})
+		require.NoError(t, err)
+		_, err = streaming.FromReader(t.Context(), strings.NewReader("PK"), CompressedSource(ingestoptions.ZIP, 0))
+		assert.ErrorContains(t, err, "doesn't support zip content")
+	})
+
+	t.Run("Managed queues zip content and large data", func(t *testing.T) {
+		t.Parallel()
+
+		ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable"})
+		require.NoError(t, err)
+		queued := 0
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				queued++
+				return "https://account.blob.core.windows.net/container/blob.csv.gz", 0, nil
+			},
+		}
+		managed := newManagedFromClients(ingestion, &Streaming{
+			db:    "defaultDb",
+			table: "defaultTable",
+			streamConn: fakeStreamIngestor{
+				onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+					require.Fail(t, "Nothing should be streamed")
+					return nil
+				},
+			},
+		})
+
+		_, err = managed.FromReader(t.Context(), strings.NewReader("PK"), CompressedSource(ingestoptions.ZIP, 0))
+		require.NoError(t, err)
+		_, err = managed.FromReader(t.Context(), strings.NewReader("gz"), CompressedSource(ingestoptions.GZIP, 1024*1024*1024))
+		require.NoError(t, err)
+		assert.Equal(t, 2, queued)
+	})
+}
+
+func TestReportOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		options    []FileOption
+		wantLevel  IngestionReportLevel
+		wantMethod IngestionReportMethod
+		wantTable  bool
+	}{
+		{desc: "Default", wantLevel: ReportFailuresOnly, wantMethod: ReportToQueue},
+		{desc: "None", options: []FileOption{ReportLevel(ReportNone), ReportMethod(ReportToTable)}, wantLevel: ReportNone, wantMethod: ReportToTable},
+		{desc: "All to queue", options: []FileOption{ReportLevel(ReportAll)}, wantLevel: ReportAll, wantMethod: ReportToQueue},
+		{desc: "Failures to both", options: []FileOption{ReportMethod(ReportToQueueAndTable)}, wantLevel: ReportFailuresOnly, wantMethod: ReportToQueueAndTable, wantTable: true},
+		{desc: "ReportResultToTable", options: []FileOption{ReportResultToTable()}, wantLevel: ReportAll, wantMethod: ReportToTable, wantTable: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			props := properties.All{}
+			for _, o := range test.options {
+				require.NoError(t, o.Run(&props, QueuedClient, FromReader))
+			}
+			assert.Equal(t, test.wantLevel, props.Ingestion.ReportLevel)
+			assert.Equal(t, test.wantMethod, props.Ingestion.ReportMethod)
+
+			res := newResult()
+			res.putProps(props)
+			assert.Equal(t, test.wantTable, res.reportToTable)
+		})
+	}
+
+	props := properties.All{}
+	assert.ErrorContains(t, ReportLevel(IngestionReportLevel(7)).Run(&props, QueuedClient, FromReader), "unknown report level")
+	assert.ErrorContains(t, ReportMethod(properties.ReportStatusToAzureMonitoring).Run(&props, QueuedClient, FromReader), "unknown report method")
+	assert.Error(t, ReportLevel(ReportAll).Run(&props, StreamingClient, FromReader))
+}
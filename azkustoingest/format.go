@@ -0,0 +1,63 @@
+package azkustoingest
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+)
+
+// FormatMismatchError is returned when the format of the data doesn't match the kind of its ingestion mapping, such as a
+// "data.csv.gz" file ingested with a JSON mapping. Use errors.As to retrieve it.
+type FormatMismatchError struct {
+	errors.KustoError
+	// Source is the file or blob whose extension gave Format. It is empty if Format was set by an option.
+	Source string
+	// Format is the format of the data.
+	Format DataFormat
+	// MappingKind is the kind of the ingestion mapping, such as JSON for a MultiJSON mapping.
+	MappingKind DataFormat
+}
+
+func newFormatMismatchError(op errors.Op, source string, format DataFormat, mappingKind DataFormat) *FormatMismatchError {
+	e := &FormatMismatchError{Source: source, Format: format, MappingKind: mappingKind}
+	msg := "format and ingestion mapping type must match (hint: using ingestion mapping sets the format automatically)"
+	if source != "" {
+		msg = fmt.Sprintf("format and ingestion mapping type must match: the extension of %s is of format %s, and the mapping is of kind %s "+
+			"(hint: use FileFormat to set the format of a file whose extension doesn't match its content)", source, format, mappingKind)
+	}
+	e.KustoError = *errors.ES(op, errors.KClientArgs, "%s", msg).SetNoRetry()
+	return e
+}
+
+func (e *FormatMismatchError) Error() string {
+	return e.KustoError.Error()
+}
+
+func (e *FormatMismatchError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.KustoError.Unwrap()
+}
+
+// validateFormat checks that the format of the data, and the format inferred from the extension of source, if any, match the
+// kind of the ingestion mapping. The extension isn't checked if the format was set with FileFormat.
+func validateFormat(op errors.Op, props *properties.All, source string) error {
+	kind := props.Ingestion.Additional.IngestionMappingType
+	if kind == DFUnknown {
+		return nil
+	}
+
+	format := props.Ingestion.Additional.Format
+	if format != DFUnknown && format.MappingKind() != kind {
+		return newFormatMismatchError(op, "", format, kind)
+	}
+	if source == "" || props.Source.FormatSet {
+		return nil
+	}
+	if inferred := InferFormatFromFileName(source); inferred != DFUnknown && inferred.MappingKind() != kind {
+		return newFormatMismatchError(op, source, inferred, kind)
+	}
+	return nil
+}
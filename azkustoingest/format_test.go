@@ -0,0 +1,167 @@
+package azkustoingest
+
+import (
+	"bytes"
+	"context"
+	goErrors "errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		source  string
+		options []FileOption
+		// want is the mismatch expected, if any.
+		want *FormatMismatchError
+	}{
+		{desc: "No mapping", source: "data.csv.gz", options: []FileOption{FileFormat(JSON)}},
+		{desc: "Matching extension", source: "data.json", options: []FileOption{IngestionMappingRef("m", JSON)}},
+		{desc: "Same mapping kind", source: "data.multijson", options: []FileOption{IngestionMappingRef("m", JSON)}},
+		{desc: "Compressed extension", source: "data.parquet.gz", options: []FileOption{IngestionMappingRef("m", Parquet)}},
+		{desc: "No extension", source: "data", options: []FileOption{IngestionMappingRef("m", JSON)}},
+		{desc: "Blob", source: "https://account.blob.core.windows.net/c/data.json.gz?sig=x", options: []FileOption{IngestionMappingRef("m", JSON)}},
+		{desc: "FileFormat overrides the extension", source: "data.csv", options: []FileOption{FileFormat(JSON), IngestionMappingRef("m", JSON)}},
+		{
+			desc:    "Mismatching extension",
+			source:  "data.csv.gz",
+			options: []FileOption{IngestionMappingRef("m", JSON)},
+			want:    &FormatMismatchError{Source: "data.csv.gz", Format: CSV, MappingKind: JSON},
+		},
+		{
+			desc:    "Mismatching zip",
+			source:  "data.avro.zip",
+			options: []FileOption{IngestionMapping("[]", CSV)},
+			want:    &FormatMismatchError{Source: "data.avro.zip", Format: AVRO, MappingKind: CSV},
+		},
+		{
+			desc:    "Mismatching FileFormat",
+			source:  "data.json",
+			options: []FileOption{IngestionMappingRef("m", JSON), FileFormat(CSV)},
+			want:    &FormatMismatchError{Format: CSV, MappingKind: JSON},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			props := properties.All{}
+			for _, o := range test.options {
+				require.NoError(t, o.Run(&props, QueuedClient, FromFile))
+			}
+
+			err := validateFormat(errors.OpFileIngest, &props, test.source)
+			if test.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var mismatch *FormatMismatchError
+			require.True(t, goErrors.As(err, &mismatch))
+			assert.Equal(t, test.want.Source, mismatch.Source)
+			assert.Equal(t, test.want.Format, mismatch.Format)
+			assert.Equal(t, test.want.MappingKind, mismatch.MappingKind)
+			assert.Equal(t, errors.KClientArgs, mismatch.Kind)
+			assert.False(t, errors.Retry(&mismatch.KustoError))
+		})
+	}
+}
+
+func TestStreamingFormatChecks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	streaming := Streaming{
+		db:     "defaultDb",
+		table:  "defaultTable",
+		client: mockClient{endpoint: "https://test.kusto.windows.net"},
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				require.Fail(t, "Nothing should be streamed")
+				return nil
+			},
+		},
+	}
+
+	var mismatch *FormatMismatchError
+	_, err := streaming.FromFile(ctx, "data.csv.gz", IngestionMappingRef("m", JSON))
+	assert.True(t, goErrors.As(err, &mismatch))
+	_, err = streaming.FromReader(ctx, nil, IngestionMappingRef("m", JSON), FileFormat(CSV))
+	assert.True(t, goErrors.As(err, &mismatch))
+
+	zipPath := filepath.Join(t.TempDir(), "data.csv.zip")
+	require.NoError(t, os.WriteFile(zipPath, []byte("PK"), 0o600))
+	_, err = streaming.FromFile(ctx, zipPath)
+	assert.ErrorContains(t, err, "zip")
+}
+
+func TestBinaryFormats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := mockClient{
+		endpoint: "https://test.kusto.windows.net",
+		onMgmt: func(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+			if query.String() == ".get kusto identity token" {
+				return nil, nil
+			}
+			return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+		},
+	}
+	streamed := 0
+	streaming := &Streaming{
+		db:     "defaultDb",
+		table:  "defaultTable",
+		client: client,
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				streamed++
+				assert.Equal(t, Parquet, format)
+				b, err := io.ReadAll(payload)
+				require.NoError(t, err)
+				// Streaming ingestion expects gzip content, even for binary formats.
+				assert.Equal(t, []byte{0x1f, 0x8b}, b[:2])
+				return nil
+			},
+		},
+	}
+
+	_, err := streaming.FromReader(ctx, bytes.NewReader([]byte("PAR1")), FileFormat(Parquet))
+	require.NoError(t, err)
+	assert.Equal(t, 1, streamed)
+
+	_, err = streaming.FromReader(ctx, bytes.NewReader([]byte("data")), FileFormat(SStream))
+	assert.ErrorContains(t, err, "sstream")
+	assert.Equal(t, 1, streamed)
+
+	ingestion, err := newFromClient(client, &Ingestion{db: "defaultDb", table: "defaultTable"})
+	require.NoError(t, err)
+	queued := 0
+	ingestion.fs = resources.FsMock{
+		OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+			queued++
+			assert.Equal(t, SStream, props.Ingestion.Additional.Format)
+			return "https://some-blob.blob.core.windows.net/some-container/some-blob", 0, nil
+		},
+	}
+	managed := Managed{queued: ingestion, streaming: streaming}
+	_, err = managed.FromReader(ctx, bytes.NewReader([]byte("data")), FileFormat(SStream))
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+	assert.Equal(t, 1, streamed)
+}
@@ -5,21 +5,40 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata"
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Ingestor is the interface of the queued (Ingestion), streaming (Streaming) and managed (Managed) clients, so that code can
+// take any of them, and a FakeIngestor in its tests.
 type Ingestor interface {
 	io.Closer
+	// FromFile ingests a local file, or a blob if fPath is a blob URL.
 	FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error)
+	// FromReader ingests the content of the reader.
 	FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error)
 }
 
+var (
+	_ Ingestor = (*Ingestion)(nil)
+	_ Ingestor = (*Streaming)(nil)
+	_ Ingestor = (*Managed)(nil)
+	_ Ingestor = (*FakeIngestor)(nil)
+)
+
 // Ingestion provides data ingestion from external sources into Kusto.
 type Ingestion struct {
 	db    string
@@ -30,12 +49,43 @@ type Ingestion struct {
 
 	fs queued.Queued
 
-	bufferSize int
-	maxBuffers int
+	bufferSize        int
+	maxBuffers        int
+	uploadParallelism int
+
+	batchParallelism  int
+	stagingStorage    newStagingStorage
+	storageCredential azcore.TokenCredential
+
+	compressionThreshold int64
+
+	resourceSelection ResourceSelection
+	excludeAccount    func(account string) bool
+
+	logger      azkustodata.Logger
+	metricsHook func(database, table string, metrics IngestionMetrics)
+	// tracerProvider is the provider of WithTracerProvider, and tracer records the spans of the ingestions.
+	tracerProvider trace.TracerProvider
+	tracer         trace.Tracer
+	// flushWarning logs the warning about FlushImmediately once per client.
+	flushWarning sync.Once
+	// tableCreators are the creators of the tables of WithCreateTableIfNotExists, by table, and createdTables holds the tables
+	// they checked.
+	tableCreators map[string]tableCreator
+	createdTables sync.Map
+	// pending tracks the ingestions in progress, for Shutdown.
+	pending inflight
+	// spoolDir is the directory of WithSpool, and spoolMu serializes the replays of the spool.
+	spoolDir string
+	spoolMu  sync.Mutex
+	// spoolReaders is set by WithSpoolReaders.
+	spoolReaders bool
 
 	withoutEndpointCorrection    bool
 	customIngestConnectionString *azkustodata.ConnectionStringBuilder
 	httpClient                   *http.Client
+	interceptors                 []azkustodata.Interceptor
+	slow                         slowReporter
 	applicationForTracing        string
 	clientVersionForTracing      string
 }
@@ -53,15 +103,7 @@ func New(kcsb *azkustodata.ConnectionStringBuilder, options ...Option) (*Ingesti
 	i.applicationForTracing = clientDetails.ApplicationForTracing()
 	i.clientVersionForTracing = clientDetails.ClientVersionForTracing()
 
-	var client *azkustodata.Client
-	var err error
-
-	if i.httpClient != nil {
-		client, err = azkustodata.New(kcsb, azkustodata.WithHttpClient(i.httpClient))
-	} else {
-		client, err = azkustodata.New(kcsb)
-	}
-
+	client, err := azkustodata.New(i.withLogger(kcsb), i.clientOptions()...)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +112,8 @@ func New(kcsb *azkustodata.ConnectionStringBuilder, options ...Option) (*Ingesti
 }
 
 func newFromClient(client QueryClient, i *Ingestion) (*Ingestion, error) {
-	mgr, err := resources.New(client)
+	mgr, err := resources.New(client, resources.WithSelection(i.resourceSelection), resources.WithAccountFilter(i.excludeAccount),
+		resources.WithLogger(i.logger), resources.WithRetryHook(i.retryHook(errors.OpMgmt)))
 	if err != nil {
 		client.Close()
 		return nil, err
@@ -78,8 +121,29 @@ func newFromClient(client QueryClient, i *Ingestion) (*Ingestion, error) {
 
 	i.client = client
 	i.mgr = mgr
+	if i.tracerProvider != nil {
+		i.tracer = tracing.NewTracer(i.tracerProvider)
+	}
+
+	queuedOptions := []queued.Option{
+		queued.WithStaticBuffer(i.bufferSize, i.maxBuffers),
+		queued.WithCompressionThreshold(i.compressionThreshold),
+		queued.WithUploadParallelism(i.uploadParallelism),
+	}
+	if i.storageCredential != nil {
+		queuedOptions = append(queuedOptions, queued.WithStorageCredential(i.storageCredential))
+	}
+	if i.stagingStorage != nil {
+		staging, err := i.stagingStorage(client.HttpClient())
+		if err != nil {
+			mgr.Close()
+			client.Close()
+			return nil, err
+		}
+		queuedOptions = append(queuedOptions, queued.WithStagingStorage(staging))
+	}
 
-	fs, err := queued.New(i.db, i.table, mgr, client.HttpClient(), i.applicationForTracing, i.clientVersionForTracing, queued.WithStaticBuffer(i.bufferSize, i.maxBuffers))
+	fs, err := queued.New(i.db, i.table, mgr, client.HttpClient(), i.applicationForTracing, i.clientVersionForTracing, queuedOptions...)
 	if err != nil {
 		mgr.Close()
 		client.Close()
@@ -91,32 +155,53 @@ func newFromClient(client QueryClient, i *Ingestion) (*Ingestion, error) {
 	return i, nil
 }
 
+// log returns the logger of the client, given with WithLogger or by the connection string builder, or the default logger.
+func (i *Ingestion) log() azkustodata.Logger {
+	return logger.OrDefault(i.logger)
+}
+
+// prepForIngestion runs the options and prepares the properties of an ingestion. On error, it returns the properties prepared
+// so far, with the options run if they succeeded.
 func (i *Ingestion) prepForIngestion(ctx context.Context, options []FileOption, props properties.All, source SourceScope) (*Result, properties.All, error) {
 	result := newResult()
 
-	auth, err := i.mgr.AuthContext(ctx)
-	if err != nil {
-		return nil, properties.All{}, err
-	}
-
-	props.Ingestion.Additional.AuthContext = auth
-
 	for _, o := range options {
 		if err := o.Run(&props, QueuedClient, source); err != nil {
-			return nil, properties.All{}, err
+			return nil, props, err
 		}
 	}
 
+	if props.Source.Preview != nil && props.Source.SplitSize > 0 {
+		return nil, props, errors.ES(errors.OpFileIngest, errors.KClientArgs, "DryRun can't be combined with SplitLargeSources").SetNoRetry()
+	}
+
+	auth, err := i.mgr.AuthContext(ctx, props.Ingestion.DatabaseName)
+	if err != nil {
+		return nil, props, err
+	}
+
+	props.Ingestion.Additional.AuthContext = auth
+
 	if source == FromReader && props.Ingestion.Additional.Format == DFUnknown {
 		props.Ingestion.Additional.Format = CSV
 	}
 
-	if props.Ingestion.Additional.IngestionMappingType != DFUnknown && props.Ingestion.Additional.Format.MappingKind() != props.Ingestion.Additional.IngestionMappingType {
-		return nil, properties.All{}, errors.ES(
-			errors.OpUnknown,
-			errors.KClientArgs,
-			"format and ingestion mapping type must match (hint: using ingestion mapping sets the format automatically)",
-		).SetNoRetry()
+	if props.Ingestion.FlushImmediately {
+		i.flushWarning.Do(func() {
+			i.log().Warn("FlushImmediately bypasses the batching of queued ingestions, which creates many small extents and "+
+				"loads the cluster when used for frequent ingestions; prefer tuning the ingestion batching policy of the table",
+				"database", props.Ingestion.DatabaseName, "table", props.Ingestion.TableName)
+		})
+	}
+
+	if err := validateFormat(errors.OpUnknown, &props, props.Source.OriginalSource); err != nil {
+		return nil, props, err
+	}
+
+	if props.Source.Preview == nil {
+		if err := ensureTable(ctx, i.client, i.tableCreators, &i.createdTables, &props); err != nil {
+			return nil, props, err
+		}
 	}
 
 	if props.Ingestion.ReportLevel != properties.None {
@@ -126,13 +211,13 @@ func (i *Ingestion) prepForIngestion(ctx context.Context, options []FileOption,
 
 		switch props.Ingestion.ReportMethod {
 		case properties.ReportStatusToTable, properties.ReportStatusToQueueAndTable:
-			tableResources, err := i.mgr.GetTables()
+			tableResources, err := i.mgr.GetTables(ctx)
 			if err != nil {
-				return nil, properties.All{}, err
+				return nil, props, err
 			}
 
 			if len(tableResources) == 0 {
-				return nil, properties.All{}, fmt.Errorf("User requested reporting status to table, yet status table resource URI is not found")
+				return nil, props, fmt.Errorf("User requested reporting status to table, yet status table resource URI is not found")
 			}
 
 			props.Ingestion.TableEntryRef.TableConnectionString = tableResources[0].URL().String()
@@ -141,14 +226,35 @@ func (i *Ingestion) prepForIngestion(ctx context.Context, options []FileOption,
 		}
 	}
 
+	// The ingestion message carries the source ID, so that the statuses the service reports are correlated with it.
+	if props.Source.ID != uuid.Nil {
+		props.Ingestion.ID = props.Source.ID
+	}
+
+	if props.Source.Metrics == nil {
+		props.Source.Metrics = &properties.Metrics{}
+	}
 	result.putProps(props)
 	return result, props, nil
 }
 
 // FromFile allows uploading a data file for Kusto from either a local path or a blobstore URI path.
 // This method is thread-safe.
-func (i *Ingestion) FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error) {
-	return i.fromFile(ctx, fPath, options, i.newProp())
+func (i *Ingestion) FromFile(ctx context.Context, fPath string, options ...FileOption) (result *Result, err error) {
+	done, err := i.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := i.startSpan(ctx)
+	defer func() {
+		endSpan(span, result, err)
+		i.reportSlow(start, result, err)
+	}()
+
+	return i.reportMetrics(i.fromFile(ctx, fPath, options, i.newProp()))
 }
 
 // fromFile is an internal function to allow managed streaming to pass a properties object to the ingestion.
@@ -158,26 +264,85 @@ func (i *Ingestion) fromFile(ctx context.Context, fPath string, options []FileOp
 		return nil, err
 	}
 
-	var scope SourceScope
-	if local {
-		scope = FromFile
-		props.Source.OriginalSource = fPath
-	} else {
-		scope = FromBlob
+	if !local {
+		return i.fromBlob(ctx, fPath, 0, options, props)
 	}
 
-	result, props, err := i.prepForIngestion(ctx, options, props, scope)
+	props.Source.OriginalSource = fPath
+	result, props, err := i.prepForIngestion(ctx, options, props, FromFile)
 	if err != nil {
+		i.spoolFile(fPath, props, err)
 		return nil, err
 	}
 
 	result.record.IngestionSourcePath = fPath
 
-	blobURL := fPath
-	size := int64(0)
-	if local {
-		blobURL, size, err = i.fs.UploadLocalToBlob(ctx, fPath, props)
-		if err != nil {
+	if props.Source.SplitSize > 0 {
+		if stat, err := os.Stat(fPath); err == nil && stat.Size() > props.Source.SplitSize {
+			return i.splitFile(ctx, fPath, stat.Size(), result, props)
+		}
+	}
+
+	blobURL, size, err := i.fs.UploadLocalToBlob(ctx, fPath, props)
+	if err != nil {
+		i.spoolFile(fPath, props, err)
+		return nil, err
+	}
+
+	if err = result.putQueued(ctx, i); err != nil {
+		return nil, err
+	}
+
+	err = i.ingestBlob(ctx, blobURL, size, props)
+	if err != nil {
+		i.spoolFile(fPath, props, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FromBlob ingests a blob that is already in Azure storage, without uploading it again. size is the size of the data before
+// compression, which helps the service to plan the ingestion, or 0 if it isn't known. The URL must let the service read the
+// blob, such as with a SAS or a ";managed_identity=" suffix, unless SignBlobURL is given.
+// This method is thread-safe.
+func (i *Ingestion) FromBlob(ctx context.Context, blobURL string, size int64, options ...FileOption) (result *Result, err error) {
+	done, err := i.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := i.startSpan(ctx)
+	defer func() {
+		endSpan(span, result, err)
+		i.reportSlow(start, result, err)
+	}()
+
+	if u, err := url.Parse(blobURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "%q is not a blob URL (hint: use FromFile for local files)", blobURL).SetNoRetry()
+	}
+	if size < 0 {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the size of the blob must not be negative, got %d", size).SetNoRetry()
+	}
+	return i.reportMetrics(i.fromBlob(ctx, blobURL, size, options, i.newProp()))
+}
+
+func (i *Ingestion) fromBlob(ctx context.Context, blobURL string, size int64, options []FileOption, props properties.All) (*Result, error) {
+	result, props, err := i.prepForIngestion(ctx, options, props, FromBlob)
+	if err != nil {
+		i.spoolBlob(blobURL, size, props, err)
+		return nil, err
+	}
+	if err := validateFormat(errors.OpFileIngest, &props, blobURL); err != nil {
+		return nil, err
+	}
+
+	result.record.IngestionSourcePath = blobURL
+
+	if props.Source.SignBlobURL != nil {
+		if blobURL, err = props.Source.SignBlobURL(blobURL); err != nil {
 			return nil, err
 		}
 	}
@@ -186,8 +351,9 @@ func (i *Ingestion) fromFile(ctx context.Context, fPath string, options []FileOp
 		return nil, err
 	}
 
-	err = i.fs.IngestBlob(ctx, blobURL, size, props)
+	err = i.ingestBlob(ctx, blobURL, size, props)
 	if err != nil {
+		i.spoolBlob(blobURL, size, props, err)
 		return nil, err
 	}
 
@@ -196,18 +362,54 @@ func (i *Ingestion) fromFile(ctx context.Context, fPath string, options []FileOp
 
 // FromReader allows uploading a data file for Kusto from an io.Reader. The content is uploaded to Blobstore and
 // ingested after all data in the reader is processed. Content should not use compression as the content will be
-// compressed with gzip. This method is thread-safe.
-func (i *Ingestion) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
-	return i.fromReader(ctx, reader, options, i.newProp())
+// compressed with gzip. The reader is uploaded in blocks as it is read, so readers of any size, such as pipes from other
+// systems, use a bounded amount of memory; see WithStaticBuffer. This method is thread-safe.
+func (i *Ingestion) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (result *Result, err error) {
+	done, err := i.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := i.startSpan(ctx)
+	defer func() {
+		endSpan(span, result, err)
+		i.reportSlow(start, result, err)
+	}()
+
+	return i.reportMetrics(i.fromReader(ctx, reader, options, i.newProp()))
 }
 
 // fromReader is an internal function to allow managed streaming to pass a properties object to the ingestion.
-func (i *Ingestion) fromReader(ctx context.Context, reader io.Reader, options []FileOption, props properties.All) (*Result, error) {
-	result, props, err := i.prepForIngestion(ctx, options, props, FromReader)
+func (i *Ingestion) fromReader(ctx context.Context, reader io.Reader, options []FileOption, props properties.All) (result *Result, err error) {
+	reader, spool := i.spoolReader(reader)
+	split := false
+	defer func() {
+		if split {
+			// A replay of the whole source would ingest the parts that succeeded again.
+			spool(props, nil)
+			return
+		}
+		spool(props, err)
+	}()
+
+	result, props, err = i.prepForIngestion(ctx, options, props, FromReader)
 	if err != nil {
 		return nil, err
 	}
 
+	if props.Source.SplitSize > 0 {
+		size := int64(-1)
+		if sized, ok := reader.(interface{ Size() int64 }); ok {
+			size = sized.Size()
+		}
+		if size < 0 || size > props.Source.SplitSize {
+			split = true
+			return i.splitSource(ctx, reader, size, result, props)
+		}
+	}
+
 	blobURL, size, err := i.fs.UploadReaderToBlob(ctx, reader, props)
 	if err != nil {
 		return nil, err
@@ -217,7 +419,7 @@ func (i *Ingestion) fromReader(ctx context.Context, reader io.Reader, options []
 		return nil, err
 	}
 
-	err = i.fs.IngestBlob(ctx, blobURL, size, props)
+	err = i.ingestBlob(ctx, blobURL, size, props)
 	if err != nil {
 		return nil, err
 	}
@@ -225,6 +427,52 @@ func (i *Ingestion) fromReader(ctx context.Context, reader io.Reader, options []
 	return result, nil
 }
 
+// CanceledError is returned when the context of an ingestion is done before the ingestion completes: the upload of the data,
+// the post of the ingestion to the queues and streaming stop promptly, and a blob the upload may have committed is deleted.
+// Use errors.As to retrieve it; errors.Is reports whether it is of context.Canceled or context.DeadlineExceeded.
+type CanceledError = properties.CanceledError
+
+// SubmissionError is returned when the data was uploaded to a blob, but the ingestion of the blob couldn't be posted to any of
+// the queues of the cluster, after retrying. The blob can be ingested again with FromBlob, without uploading the data again,
+// such as by a job that re-drives the failures saved to a dead-letter store. Use errors.As to retrieve it.
+type SubmissionError struct {
+	errors.KustoError
+	// BlobURL is the URL of the blob, with the SAS the service reads it with, if any. The SAS of the containers of the cluster
+	// expires after a few hours.
+	BlobURL string
+	// Size is the size of the data before compression, or 0 if it isn't known.
+	Size int64
+}
+
+func newSubmissionError(blobURL string, size int64, err error) *SubmissionError {
+	e := &SubmissionError{BlobURL: blobURL, Size: size}
+	if ke, ok := errors.GetKustoError(err); ok {
+		e.KustoError = *ke
+	} else {
+		e.KustoError = *errors.E(errors.OpFileIngest, errors.KBlobstore, err)
+	}
+	return e
+}
+
+func (e *SubmissionError) Error() string {
+	return e.KustoError.Error()
+}
+
+func (e *SubmissionError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.KustoError.Unwrap()
+}
+
+// ingestBlob posts the ingestion of the blob to the queues of the cluster, and returns a *SubmissionError if it fails.
+func (i *Ingestion) ingestBlob(ctx context.Context, blobURL string, size int64, props properties.All) error {
+	if err := i.fs.IngestBlob(ctx, blobURL, size, props); err != nil {
+		return newSubmissionError(blobURL, size, err)
+	}
+	return nil
+}
+
 func (i *Ingestion) newProp() properties.All {
 	return properties.All{
 		Ingestion: properties.Ingestion{
@@ -234,7 +482,13 @@ func (i *Ingestion) newProp() properties.All {
 	}
 }
 
+// Close waits for the ingestions in progress, and releases the client. Use Shutdown to bound the wait.
 func (i *Ingestion) Close() error {
+	return i.Shutdown(context.Background())
+}
+
+// release closes the resources manager, the client and the uploader.
+func (i *Ingestion) release() error {
 	i.mgr.Close()
 	err := i.client.Close()
 	if err != nil {
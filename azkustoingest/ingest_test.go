@@ -1,11 +1,25 @@
 package azkustoingest
 
 import (
-	"github.com/Azure/azure-kusto-go/azkustodata"
+	"bytes"
+	"context"
+	"encoding/base64"
+	goErrors "errors"
+	"io"
+	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIngestion(t *testing.T) {
@@ -99,3 +113,168 @@ func TestIngestion(t *testing.T) {
 		})
 	}
 }
+
+func TestFromBlob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := mockClient{
+		endpoint: "https://test.kusto.windows.net",
+		onMgmt: func(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+			if query.String() == ".get ingestion resources" {
+				return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+			}
+			return nil, nil
+		},
+	}
+	ingestion, err := newFromClient(client, &Ingestion{db: "defaultDb", table: "defaultTable"})
+	require.NoError(t, err)
+
+	var ingested []string
+	var sizes []int64
+	var props []properties.All
+	ingestion.fs = resources.FsMock{
+		OnLocal: func(ctx context.Context, from string, p properties.All) (string, int64, error) {
+			require.Fail(t, "A blob shouldn't be uploaded")
+			return "", 0, nil
+		},
+		OnBlob: func(ctx context.Context, from string, fileSize int64, p properties.All) error {
+			ingested = append(ingested, from)
+			sizes = append(sizes, fileSize)
+			props = append(props, p)
+			return nil
+		},
+	}
+
+	blob := "https://account.blob.core.windows.net/container/data.json.gz"
+	result, err := ingestion.FromBlob(ctx, blob+"?sig=x", 1024, DeleteSourceOnSuccess())
+	require.NoError(t, err)
+	assert.Equal(t, Queued, result.record.Status)
+	assert.True(t, props[0].Source.DeleteBlobOnSuccess)
+
+	cred, err := azblob.NewSharedKeyCredential("account", base64.StdEncoding.EncodeToString([]byte("key")))
+	require.NoError(t, err)
+	_, err = ingestion.FromBlob(ctx, blob, 0, SignBlobURL(cred, time.Hour), Database("otherDb"))
+	require.NoError(t, err)
+	assert.Equal(t, "otherDb", props[1].Ingestion.DatabaseName)
+
+	require.Len(t, ingested, 2)
+	assert.Equal(t, blob+"?sig=x", ingested[0])
+	assert.Equal(t, []int64{1024, 0}, sizes)
+	signed, err := sas.ParseURL(ingested[1])
+	require.NoError(t, err)
+	assert.Equal(t, "r", signed.SAS.Permissions())
+	assert.Equal(t, "data.json.gz", signed.BlobName)
+
+	_, err = ingestion.FromBlob(ctx, "data.json", 0)
+	assert.Error(t, err)
+	_, err = ingestion.FromBlob(ctx, blob, -1)
+	assert.Error(t, err)
+	_, err = ingestion.FromBlob(ctx, blob, 0, IngestionMappingRef("m", CSV))
+	assert.Error(t, err)
+	_, err = ingestion.FromBlob(ctx, blob, 0, SignBlobURL(nil, time.Hour))
+	assert.Error(t, err)
+	assert.Len(t, ingested, 2)
+}
+
+func TestSubmissionError(t *testing.T) {
+	t.Parallel()
+
+	ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable"})
+	require.NoError(t, err)
+
+	const blobURL = "https://account.blob.core.windows.net/container/data.csv.gz?sig=x"
+	ingestion.fs = resources.FsMock{
+		OnReader: func(ctx context.Context, reader io.Reader, p properties.All) (string, int64, error) {
+			return blobURL, 42, nil
+		},
+		OnBlob: func(ctx context.Context, from string, fileSize int64, p properties.All) error {
+			return errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not upload file to any queue: unavailable")
+		},
+	}
+
+	res, err := ingestion.FromReader(context.Background(), strings.NewReader("a,b\n"))
+	assert.Nil(t, res)
+	var subErr *SubmissionError
+	require.True(t, goErrors.As(err, &subErr))
+	assert.Equal(t, blobURL, subErr.BlobURL)
+	assert.Equal(t, int64(42), subErr.Size)
+	assert.Equal(t, errors.KBlobstore, subErr.Kind)
+	assert.ErrorContains(t, err, "could not upload file to any queue")
+}
+
+func TestFlushImmediatelyWarning(t *testing.T) {
+	t.Parallel()
+
+	logs := &bytes.Buffer{}
+	ingestion, err := newFromClient(newMockClient(), getOptions([]Option{
+		WithDefaultDatabase("defaultDb"),
+		WithDefaultTable("defaultTable"),
+		WithLogger(slog.New(slog.NewTextHandler(logs, nil))),
+	}))
+	require.NoError(t, err)
+	ingestion.fs = resources.FsMock{
+		OnReader: func(ctx context.Context, reader io.Reader, p properties.All) (string, int64, error) {
+			return "https://account.blob.core.windows.net/container/data.csv.gz", 4, nil
+		},
+		OnBlob: func(ctx context.Context, from string, fileSize int64, p properties.All) error {
+			return nil
+		},
+	}
+
+	_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"))
+	require.NoError(t, err)
+	assert.Empty(t, logs.String())
+
+	// The warning is logged once per client.
+	for n := 0; n < 2; n++ {
+		_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"), FlushImmediately())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, strings.Count(logs.String(), "level=WARN"))
+	assert.Contains(t, logs.String(), "FlushImmediately bypasses the batching")
+	assert.Contains(t, logs.String(), "table=defaultTable")
+}
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", spoolDir: dir})
+	require.NoError(t, err)
+	fail := false
+	ingestion.fs = resources.FsMock{
+		OnReader: func(ctx context.Context, reader io.Reader, p properties.All) (string, int64, error) {
+			return "https://account.blob.core.windows.net/container/data.csv.gz", 4, nil
+		},
+		OnBlob: func(ctx context.Context, from string, fileSize int64, p properties.All) error {
+			if fail {
+				return errors.ES(errors.OpFileIngest, errors.KBlobstore, "no Kusto queue resources are defined")
+			}
+			if p.Source.Preview != nil {
+				p.Source.Preview.Message = "message"
+			}
+			return nil
+		},
+	}
+
+	res, err := ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"), DryRun())
+	require.NoError(t, err)
+	require.NotNil(t, res.Preview())
+	assert.Equal(t, "message", res.Preview().Message)
+
+	res, err = ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"))
+	require.NoError(t, err)
+	assert.Nil(t, res.Preview())
+
+	_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"), DryRun(), SplitLargeSources(1024))
+	e, ok := errors.GetKustoError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.KClientArgs, e.Kind)
+
+	// A failed dry run isn't persisted to the spool, as a replay would ingest it.
+	fail = true
+	_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"), DryRun())
+	require.Error(t, err)
+	assert.Empty(t, spoolFiles(t, dir))
+}
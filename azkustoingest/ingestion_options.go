@@ -2,6 +2,7 @@ package azkustoingest
 
 import (
 	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
 	"net"
 	"net/http"
 	"strings"
@@ -11,7 +12,9 @@ import (
 type Option func(s *Ingestion)
 
 // WithStaticBuffer configures the ingest client to upload data to Kusto using a set of one or more static memory buffers with a fixed size.
-// Only relevant for Queued and Managed ingestion.
+// Files and readers are uploaded in blocks of bufferSize bytes, and readers hold at most maxBuffers blocks in memory at once,
+// whatever their size. Zero values use 8MiB blocks and 4 buffers. bufferSize can be at most 4000MiB, and a blob holds up to 50,000 blocks, so
+// larger blocks allow larger readers. Only relevant for Queued and Managed ingestion.
 func WithStaticBuffer(bufferSize int, maxBuffers int) Option {
 	return func(s *Ingestion) {
 		s.bufferSize = bufferSize
@@ -19,6 +22,29 @@ func WithStaticBuffer(bufferSize int, maxBuffers int) Option {
 	}
 }
 
+// WithCompressionThreshold configures the queued and managed clients to upload data smaller than threshold bytes as is, rather
+// than compressing it with gzip, which costs more than it saves for small data. Larger data is compressed while it is uploaded,
+// and the sizes of its data before and after compression are recorded in the "rawSize" and "compressedSize" metadata of the
+// blob. Data whose size isn't known, such as most readers, is always compressed, and data that is already compressed or in a
+// binary format, such as Parquet, never is. Defaults to 0, which compresses all the data.
+func WithCompressionThreshold(threshold int64) Option {
+	return func(s *Ingestion) {
+		s.compressionThreshold = threshold
+	}
+}
+
+// WithUploadParallelism configures the queued and managed clients to upload n blocks of the data at once, which cuts the time
+// to upload large data over fast links. The size of the blocks is set with WithStaticBuffer, and readers hold n blocks in
+// memory, unless WithStaticBuffer sets another number of buffers. Each block is validated by the service with its CRC64 and
+// retried on its own if it fails. The MD5 of the data uploaded from a reader is recorded in the properties of the blob, and
+// the upload fails if the committed blob has another MD5.
+// n can be at most 65535. Defaults to 0, which uploads 50 blocks of a file at once, and 4 blocks of a reader.
+func WithUploadParallelism(n int) Option {
+	return func(s *Ingestion) {
+		s.uploadParallelism = n
+	}
+}
+
 // WithDefaultDatabase configures the ingest client to use the given database name as the default database for all ingest operations.
 func WithDefaultDatabase(db string) Option {
 	return func(s *Ingestion) {
@@ -59,6 +85,64 @@ func WithHttpClient(client *http.Client) Option {
 	}
 }
 
+// WithBatchParallelism configures the number of files IngestBatch uploads at the same time. The default is 8.
+func WithBatchParallelism(n int) Option {
+	return func(s *Ingestion) {
+		s.batchParallelism = n
+	}
+}
+
+// WithLogger sets the logger of the client, which receives its warnings, such as the use of options that hurt the cluster when
+// misused, and its internal events, such as the failures of the background refreshes of the ingestion resources, and of the
+// query client it is built on. *slog.Logger implements azkustodata.Logger. Defaults to the logger of the connection string
+// builder, set with its WithLogger method, or slog.Default().
+func WithLogger(logger azkustodata.Logger) Option {
+	return func(s *Ingestion) {
+		s.logger = logger
+	}
+}
+
+// WithMetricsHook sets a hook that the client calls with the database, the table and the metrics of each ingestion once it is
+// queued or streamed, such as to feed the planning of the capacity of the cluster without joining its logs. The metrics are
+// also returned by Result.Metrics. hook is called from the goroutine of the ingestion, and must return quickly.
+func WithMetricsHook(hook func(database, table string, metrics IngestionMetrics)) Option {
+	return func(s *Ingestion) {
+		s.metricsHook = hook
+	}
+}
+
+// ResourceSelection is the strategy that orders the temporary containers and the aggregation queues of the cluster that the
+// queued and managed clients upload the data to and post the ingestions to. A failed upload or post is retried with the
+// next resource in the order.
+type ResourceSelection = resources.Selection
+
+const (
+	// SelectRanked orders the resources by the success rate of their storage accounts in the last minute, alternating the
+	// accounts, as the ranked policy of the .NET SDK. It is the default.
+	SelectRanked ResourceSelection = resources.SelectRanked
+	// SelectRoundRobin cycles through the resources, alternating the accounts, so that the ingestions are spread evenly.
+	SelectRoundRobin ResourceSelection = resources.SelectRoundRobin
+	// SelectRandom orders the resources at random for each ingestion.
+	SelectRandom ResourceSelection = resources.SelectRandom
+)
+
+// WithResourceSelection sets the strategy that orders the temporary containers and the aggregation queues that the queued and
+// managed clients use. Defaults to SelectRanked.
+func WithResourceSelection(selection ResourceSelection) Option {
+	return func(s *Ingestion) {
+		s.resourceSelection = selection
+	}
+}
+
+// WithStorageAccountFilter sets a function that excludes the storage accounts of the cluster it returns true for from the
+// resources that the queued and managed clients use, such as accounts in another region. account is the host name of the
+// account, such as "account.blob.core.windows.net". Ingestions fail if all the accounts are excluded.
+func WithStorageAccountFilter(exclude func(account string) bool) Option {
+	return func(s *Ingestion) {
+		s.excludeAccount = exclude
+	}
+}
+
 func getOptions(options []Option) *Ingestion {
 	s := &Ingestion{}
 	for _, o := range options {
@@ -67,6 +151,36 @@ func getOptions(options []Option) *Ingestion {
 	return s
 }
 
+// withLogger returns kcsb, with the logger of WithLogger, if any, so the query client the ingest client is built on logs to it
+// too, and otherwise sets the logger of the ingest client to the one of kcsb.
+func (s *Ingestion) withLogger(kcsb *azkustodata.ConnectionStringBuilder) *azkustodata.ConnectionStringBuilder {
+	if s.logger == nil {
+		s.logger = kcsb.Logger
+		return kcsb
+	}
+	newKcsb := *kcsb
+	newKcsb.Logger = s.logger
+	return &newKcsb
+}
+
+// clientOptions returns the options of the query client the ingest client is built on.
+func (s *Ingestion) clientOptions() []azkustodata.Option {
+	var options []azkustodata.Option
+	if s.httpClient != nil {
+		options = append(options, azkustodata.WithHttpClient(s.httpClient))
+	}
+	if s.tracerProvider != nil {
+		options = append(options, azkustodata.WithTracerProvider(s.tracerProvider))
+	}
+	for _, interceptor := range s.interceptors {
+		options = append(options, azkustodata.WithInterceptor(interceptor))
+	}
+	if s.slow.callback != nil {
+		options = append(options, azkustodata.WithSlowOperationThreshold(s.slow.threshold, s.slow.callback))
+	}
+	return options
+}
+
 const domainPrefix = "://"
 const ingestPrefix = "ingest-"
 
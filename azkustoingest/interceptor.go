@@ -0,0 +1,37 @@
+package azkustoingest
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// WithInterceptor adds an interceptor to the HTTP calls of the client, both to the cluster and to the storage of its resources,
+// such as the uploads of the data to the temporary containers, whose SAS tokens are redacted from what the interceptor sees.
+// Its OnRetry hook is called before the client retries a streaming ingestion or a fetch of the ingestion resources that failed.
+// See azkustodata.Interceptor.
+func WithInterceptor(interceptor azkustodata.Interceptor) Option {
+	return func(s *Ingestion) {
+		s.interceptors = append(s.interceptors, interceptor)
+	}
+}
+
+// retryHook returns a function that calls the OnRetry hooks of the interceptors of the client for the retries of op, or nil if
+// none of them has one.
+func (s *Ingestion) retryHook(op errors.Op) func(ctx context.Context, retry int, err error) {
+	var hooks []func(ctx context.Context, op errors.Op, retry int, err error)
+	for _, i := range s.interceptors {
+		if i.OnRetry != nil {
+			hooks = append(hooks, i.OnRetry)
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, retry int, err error) {
+		for _, hook := range hooks {
+			hook(ctx, op, retry, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package properties
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// CanceledError is returned when the context of an ingestion is done before the ingestion completes. It is a KTimeout error,
+// that isn't retried, and errors.Is reports whether it is of context.Canceled or context.DeadlineExceeded.
+type CanceledError struct {
+	errors.KustoError
+	// Stage is the stage the ingestion was stopped at: SUploading while the data was uploaded, SQueued while the ingestion was
+	// posted to the queues, and SStreaming while the data was streamed.
+	Stage Stage
+}
+
+// NewCanceledError returns the error of an ingestion whose context is done, stopped at the stage.
+func NewCanceledError(ctx context.Context, op errors.Op, stage Stage) *CanceledError {
+	cause := context.Cause(ctx)
+	if cause == nil {
+		cause = context.Canceled
+	}
+	return &CanceledError{KustoError: *errors.E(op, errors.KTimeout, cause).SetNoRetry(), Stage: stage}
+}
+
+func (e *CanceledError) Error() string {
+	return "the ingestion was stopped while " + e.Stage.doing() + ": " + e.KustoError.Error()
+}
+
+func (e *CanceledError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return &e.KustoError
+}
+
+// doing describes the stage, for errors.
+func (s Stage) doing() string {
+	switch s {
+	case SUploading, SCompressing:
+		return "uploading the data"
+	case SQueued:
+		return "posting the ingestion to the queues"
+	case SStreaming:
+		return "streaming the data"
+	}
+	return "preparing the ingestion"
+}
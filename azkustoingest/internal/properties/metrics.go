@@ -0,0 +1,71 @@
+package properties
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics are the measurements of an ingestion, recorded in SourceOptions.Metrics as it goes through its stages.
+type Metrics struct {
+	// RawBytes is the size of the data read from the source, before the client compresses it, if it does. It is the size of
+	// the source for a source that is already compressed, and 0 for a blob that is ingested as is.
+	RawBytes int64
+	// CompressedBytes is the size of the data uploaded or streamed, after the client compresses it, if it does.
+	CompressedBytes int64
+	// UploadDuration is the time spent uploading the data to a blob, retries included, or streaming it.
+	UploadDuration time.Duration
+	// QueueLatency is the time spent posting the ingestion message to the queues of the service, retries included.
+	QueueLatency time.Duration
+}
+
+// Add returns the sum of the metrics, such as of the parts of a split source.
+func (m Metrics) Add(other Metrics) Metrics {
+	return Metrics{
+		RawBytes:        m.RawBytes + other.RawBytes,
+		CompressedBytes: m.CompressedBytes + other.CompressedBytes,
+		UploadDuration:  m.UploadDuration + other.UploadDuration,
+		QueueLatency:    m.QueueLatency + other.QueueLatency,
+	}
+}
+
+// RecordSizes records the sizes of the data before and after the client compresses it, if Metrics is set.
+func (p *All) RecordSizes(raw int64, compressed int64) {
+	if p.Source.Metrics != nil {
+		p.Source.Metrics.RawBytes = raw
+		p.Source.Metrics.CompressedBytes = compressed
+	}
+}
+
+// RecordUpload records the time spent uploading or streaming the data, if Metrics is set.
+func (p *All) RecordUpload(d time.Duration) {
+	if p.Source.Metrics != nil {
+		p.Source.Metrics.UploadDuration = d
+	}
+}
+
+// RecordQueue records the time spent posting the ingestion message, if Metrics is set.
+func (p *All) RecordQueue(d time.Duration) {
+	if p.Source.Metrics != nil {
+		p.Source.Metrics.QueueLatency = d
+	}
+}
+
+// CountingReader counts the bytes read from R. The count can be read while R is read, such as by a compressing goroutine that
+// is still reading when a streaming request fails.
+type CountingReader struct {
+	R io.Reader
+	n atomic.Int64
+}
+
+// Read implements io.Reader.
+func (c *CountingReader) Read(b []byte) (int, error) {
+	n, err := c.R.Read(b)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// N returns the number of bytes read so far.
+func (c *CountingReader) N() int64 {
+	return c.n.Load()
+}
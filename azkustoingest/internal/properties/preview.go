@@ -0,0 +1,19 @@
+package properties
+
+// Preview is what a dry run of an ingestion would send, recorded in SourceOptions.Preview in place of uploading the data and
+// posting the ingestion message.
+type Preview struct {
+	// BlobURL is the URL of the blob the data would be uploaded to, with the SAS of its container, if any, or the URL of the
+	// source blob.
+	BlobURL string
+	// Compressed reports whether the data would be compressed with gzip before it is uploaded.
+	Compressed bool
+	// BlobSize is the size of the blob that would be uploaded, after compression, or 0 for a source blob.
+	BlobSize int64
+	// Metadata is the metadata the blob would be uploaded with, such as the sizes of the data before and after compression.
+	Metadata map[string]string
+	// ContentMD5 is the MD5 of the blob that the upload would record in its properties, or nil if it wouldn't.
+	ContentMD5 []byte
+	// Message is the JSON of the ingestion message that would be posted to the queues of the cluster, encoded in base64.
+	Message string
+}
@@ -0,0 +1,77 @@
+package properties
+
+import "io"
+
+// Stage is a step of an ingestion, reported to the SourceOptions.OnStage callback.
+type Stage int8
+
+const (
+	// SUnknown is the zero value of Stage.
+	SUnknown Stage = iota
+	// SCompressing is reported when the client starts compressing the data with gzip. The data is compressed while it is
+	// uploaded or streamed, so SUploading or SStreaming follows right away.
+	SCompressing
+	// SUploading is reported when the data starts being uploaded to a container, and again when the upload is retried.
+	SUploading
+	// SQueued is reported when the ingestion message is posted to the queue of the service.
+	SQueued
+	// SStreaming is reported when the data starts being sent to the streaming endpoint.
+	SStreaming
+)
+
+// String implements fmt.Stringer.
+func (s Stage) String() string {
+	switch s {
+	case SCompressing:
+		return "Compressing"
+	case SUploading:
+		return "Uploading"
+	case SQueued:
+		return "Queued"
+	case SStreaming:
+		return "Streaming"
+	}
+	return "Unknown"
+}
+
+// ReportStage calls the OnStage callback, if set, with the stage.
+func (p *All) ReportStage(stage Stage) {
+	if p.Source.OnStage != nil {
+		p.Source.OnStage(stage)
+	}
+}
+
+// ReportProgress calls the OnProgress callback, if set, with the bytes of the source sent so far, and its total size, or -1 if
+// it isn't known.
+func (p *All) ReportProgress(sent int64, total int64) {
+	if p.Source.OnProgress != nil {
+		p.Source.OnProgress(sent, total)
+	}
+}
+
+// TrackProgress returns a reader of r that reports the bytes read from it to the OnProgress callback, or r if it isn't set.
+// total is the size of r, or -1 if it isn't known.
+func (p *All) TrackProgress(r io.Reader, total int64) io.Reader {
+	if p.Source.OnProgress == nil {
+		return r
+	}
+	return &progressReader{r: r, total: total, report: p.Source.OnProgress}
+}
+
+// progressReader reports the bytes read from r.
+type progressReader struct {
+	r      io.Reader
+	read   int64
+	total  int64
+	report func(sent int64, total int64)
+}
+
+// Read implements io.Reader.
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.report(p.read, p.total)
+	}
+	return n, err
+}
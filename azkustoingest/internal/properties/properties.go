@@ -3,6 +3,7 @@
 package properties
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -73,27 +74,28 @@ type dfDescriptor struct {
 	detectableExt  string
 	mappingKind    DataFormat
 	shouldCompress bool
+	streamable     bool
 }
 
 var dfDescriptions = []dfDescriptor{
-	{"", "", "", DFUnknown, true},
-	{"Avro", "avro", ".avro", AVRO, false},
-	{"ApacheAvro", "avro", "", AVRO, false},
-	{"Csv", "csv", ".csv", CSV, true},
-	{"Json", "json", ".json", JSON, true},
-	{"MultiJson", "multijson", "", JSON, true},
-	{"Orc", "orc", ".orc", ORC, false},
-	{"Parquet", "parquet", ".parquet", Parquet, false},
-	{"Psv", "psv", ".psv", CSV, true},
-	{"Raw", "raw", ".raw", CSV, true},
-	{"Scsv", "scsv", ".scsv", CSV, true},
-	{"Sohsv", "sohsv", ".sohsv", CSV, true},
-	{"SStream", "sstream", ".ss", DFUnknown, false},
-	{"Tsv", "tsv", ".tsv", CSV, true},
-	{"Tsve", "tsve", ".tsve", CSV, true},
-	{"Txt", "txt", ".txt", CSV, true},
-	{"W3cLogFile", "w3clogfile", ".w3clogfile", W3CLogFile, true},
-	{"SingleJson", "singlejson", "", JSON, true},
+	{"", "", "", DFUnknown, true, true},
+	{"Avro", "avro", ".avro", AVRO, false, true},
+	{"ApacheAvro", "apacheavro", "", AVRO, false, true},
+	{"Csv", "csv", ".csv", CSV, true, true},
+	{"Json", "json", ".json", JSON, true, true},
+	{"MultiJson", "multijson", "", JSON, true, true},
+	{"Orc", "orc", ".orc", ORC, false, true},
+	{"Parquet", "parquet", ".parquet", Parquet, false, true},
+	{"Psv", "psv", ".psv", CSV, true, true},
+	{"Raw", "raw", ".raw", CSV, true, true},
+	{"Scsv", "scsv", ".scsv", CSV, true, true},
+	{"Sohsv", "sohsv", ".sohsv", CSV, true, true},
+	{"SStream", "sstream", ".ss", DFUnknown, false, false},
+	{"Tsv", "tsv", ".tsv", CSV, true, true},
+	{"Tsve", "tsve", ".tsve", CSV, true, true},
+	{"Txt", "txt", ".txt", CSV, true, true},
+	{"W3cLogFile", "w3clogfile", ".w3clogfile", W3CLogFile, true, true},
+	{"SingleJson", "singlejson", "", JSON, true, true},
 }
 
 // IngestionReportLevel defines which ingestion statuses are reported by the DM.
@@ -177,6 +179,15 @@ func (d DataFormat) ShouldCompress() bool {
 	return true
 }
 
+// SupportsStreaming reports whether the format can be ingested with streaming ingestion.
+func (d DataFormat) SupportsStreaming() bool {
+	if int(d) < len(dfDescriptions) {
+		return dfDescriptions[d].streamable
+	}
+
+	return false
+}
+
 // DataFormatDiscovery looks at the file name and tries to discern what the file format is.
 func DataFormatDiscovery(fName string) DataFormat {
 	name := fName
@@ -217,6 +228,9 @@ type All struct {
 type ManagedStreaming struct {
 	// Backoff is the backoff strategy to use when retrying a transiently failed ingestion.
 	Backoff backoff.BackOff
+	// OnRetry, if set, is called before each retry of a transiently failed ingestion, with the number of the retry and the
+	// error of the attempt it retries.
+	OnRetry func(ctx context.Context, retry int, err error)
 }
 
 // Streaming provides options that are used when doing a streaming ingestion.
@@ -242,6 +256,33 @@ type SourceOptions struct {
 
 	// CompressionType is the type of compression used on the file.
 	CompressionType ingestoptions.CompressionType
+
+	// FormatSet indicates the format was set with the FileFormat option, so it isn't checked against the file extension.
+	FormatSet bool
+
+	// DeleteBlobOnSuccess indicates the service should delete the source blob once it is ingested successfully.
+	DeleteBlobOnSuccess bool
+
+	// SignBlobURL, if set, returns the URL of the source blob with a SAS the service can read it with.
+	SignBlobURL func(blobURL string) (string, error)
+
+	// OnProgress, if set, is called as the data is sent, with the bytes sent so far and the total, or -1 if it isn't known.
+	OnProgress func(sent int64, total int64)
+
+	// OnStage, if set, is called as the ingestion goes through its stages.
+	OnStage func(stage Stage)
+
+	// SplitSize, if set, is the size in bytes of the parts that a larger source is split into, each ingested as its own blob.
+	SplitSize int64
+
+	// Metrics, if set, records the measurements of the ingestion.
+	Metrics *Metrics
+
+	// StageURL indicates FromURL downloads the source and stages it, even when the service could read the URL itself.
+	StageURL bool
+
+	// Preview, if set, makes the ingestion a dry run, which records what it would send in it instead of sending it.
+	Preview *Preview
 }
 
 // Ingestion is a JSON serializable set of options that must be provided to the service.
@@ -307,9 +348,9 @@ type Additional struct {
 	IgnoreFirstRecord bool       `json:"ignoreFirstRecord"`
 	// Tags is a list of tags to associated with the ingested data.
 	Tags TagsList `json:"tags,omitempty"`
-	// IngestIfNotExists is a string value that, if specified, prevents ingestion from succeeding if the table already
-	// has data tagged with an ingest-by: tag with the same value. This ensures idempotent data ingestion.
-	IngestIfNotExists string `json:"ingestIfNotExists,omitempty"`
+	// IngestIfNotExists is a list of values that, if specified, prevents ingestion from succeeding if the table already
+	// has data tagged with an ingest-by: tag with one of the values. This ensures idempotent data ingestion.
+	IngestIfNotExists TagsList `json:"ingestIfNotExists,omitempty"`
 	// CreationTime is used to override the time considered for retantion policies, which by default is the time of ingestion.
 	CreationTime time.Time `json:"creationTime,omitempty"`
 }
@@ -357,6 +398,10 @@ func (a Additional) MarshalJSON() ([]byte, error) {
 	if _, ok := m["ingestionMappingType"]; ok {
 		m["ingestionMappingType"] = a.IngestionMappingType.CamelCase()
 	}
+	// omitempty doesn't apply to structs, so a creation time that isn't set must be removed here.
+	if a.CreationTime.IsZero() {
+		delete(m, "creationTime")
+	}
 
 	return json.Marshal(m)
 }
@@ -31,7 +31,7 @@ func TestIngestionJSONMarshal(t *testing.T) {
 			Format:               ApacheAVRO,
 			IgnoreFirstRecord:    true,
 			Tags:                 []string{"blue", "green"},
-			IngestIfNotExists:    "yellow",
+			IngestIfNotExists:    []string{"yellow"},
 			CreationTime:         time.Unix(0, 0).UTC(),
 		},
 		TableEntryRef: StatusTableDescription{
@@ -61,10 +61,10 @@ func TestIngestionJSONMarshal(t *testing.T) {
 			"ingestionMappingReference": "MapRef",
 			"ingestionMappingType":      "ApacheAvro",
 			"validationPolicy":          "{}",
-			"format":                    "avro",
+			"format":                    "apacheavro",
 			"ignoreFirstRecord":         true,
 			"tags":                      "[\"blue\",\"green\"]",
-			"ingestIfNotExists":         "yellow",
+			"ingestIfNotExists":         "[\"yellow\"]",
 			"creationTime":              "1970-01-01T00:00:00Z",
 		},
 		"IngestionStatusInTable": map[string]any{
@@ -83,3 +83,14 @@ func TestIngestionJSONMarshal(t *testing.T) {
 	err = json.Unmarshal(j, &actual)
 	assert.Equal(t, expected, actual)
 }
+
+func TestAdditionalJSONMarshalOmitsUnset(t *testing.T) {
+	j, err := json.Marshal(Additional{Format: CSV})
+	assert.NoError(t, err)
+
+	m := map[string]any{}
+	assert.NoError(t, json.Unmarshal(j, &m))
+	assert.NotContains(t, m, "creationTime")
+	assert.NotContains(t, m, "ingestIfNotExists")
+	assert.NotContains(t, m, "tags")
+}
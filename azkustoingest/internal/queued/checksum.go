@@ -0,0 +1,63 @@
+package queued
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"hash"
+	"io"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+)
+
+// checksumReader computes the MD5 of a reader while it is uploaded. Once it is read to the end, it records the MD5 in the
+// headers of the blob, which azblob sends when it commits the blob, after reading all of it.
+type checksumReader struct {
+	r       io.Reader
+	hash    hash.Hash
+	headers blob.HTTPHeaders
+}
+
+// withChecksums has the service validate each block of the upload with its CRC64, so a corrupted block fails the upload,
+// and records the MD5 of the whole blob in its properties. The service doesn't validate that MD5 against the committed
+// blob, verifyChecksum does it once the upload is done. It returns the reader to upload in place of reader.
+func withChecksums(reader io.Reader, options *azblob.UploadStreamOptions) io.Reader {
+	c := &checksumReader{r: reader, hash: md5.New()}
+	options.HTTPHeaders = &c.headers
+	options.TransactionalValidation = blob.TransferValidationTypeComputeCRC64()
+	return c
+}
+
+// Read implements io.Reader.
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	if err == io.EOF {
+		c.headers.BlobContentMD5 = c.hash.Sum(nil)
+	}
+	return n, err
+}
+
+// verifyChecksum compares the MD5 that withChecksums computed for an upload with the MD5 in the properties of the committed
+// blob. On a mismatch it deletes the blob, so it isn't ingested, and returns an error that fails the upload.
+func (i *Ingestion) verifyChecksum(ctx context.Context, client *azblob.Client, container, blob string, options *azblob.UploadStreamOptions) error {
+	if i.blobMD5 == nil || client == nil || options.HTTPHeaders == nil || options.HTTPHeaders.BlobContentMD5 == nil {
+		return nil
+	}
+	expected := options.HTTPHeaders.BlobContentMD5
+
+	actual, err := i.blobMD5(ctx, client, container, blob)
+	if err != nil {
+		return errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not read the properties of the uploaded blob: %s", err)
+	}
+	if bytes.Equal(actual, expected) {
+		return nil
+	}
+
+	if i.deleteBlob != nil {
+		_ = i.deleteBlob(ctx, client, container, blob)
+	}
+	return errors.ES(errors.OpFileIngest, errors.KBlobstore, "the MD5 of the uploaded blob is %x instead of %x", actual, expected)
+}
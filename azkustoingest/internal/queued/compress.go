@@ -0,0 +1,56 @@
+package queued
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/gzip"
+)
+
+const (
+	// rawSizeMetadata is the metadata of a compressed blob that holds the size of its data before compression, in bytes.
+	rawSizeMetadata = "rawSize"
+	// compressedSizeMetadata is the metadata of a compressed blob that holds the size of its data, in bytes.
+	compressedSizeMetadata = "compressedSize"
+)
+
+// compressedReader compresses a reader with gzip while it is uploaded. Once it is read to the end, it records the sizes of the
+// data before and after compression in the values of its metadata, which azblob sends when it commits the blob, after reading
+// all of it.
+type compressedReader struct {
+	gz         *gzip.Streamer
+	compressed int64
+
+	rawSize        string
+	compressedSize string
+}
+
+func newCompressedReader(reader io.Reader) *compressedReader {
+	gz := gzip.New()
+	gz.Reset(io.NopCloser(reader))
+	return &compressedReader{gz: gz}
+}
+
+// Read implements io.Reader.
+func (c *compressedReader) Read(p []byte) (int, error) {
+	n, err := c.gz.Read(p)
+	c.compressed += int64(n)
+	if err == io.EOF {
+		c.rawSize = strconv.FormatInt(c.gz.InputSize(), 10)
+		c.compressedSize = strconv.FormatInt(c.compressed, 10)
+	}
+	return n, err
+}
+
+// InputSize returns the size of the data before compression, once it is read to the end.
+func (c *compressedReader) InputSize() int64 {
+	return c.gz.InputSize()
+}
+
+// metadata returns the metadata of the blob the reader is uploaded to.
+func (c *compressedReader) metadata() map[string]*string {
+	return map[string]*string{
+		rawSizeMetadata:        &c.rawSize,
+		compressedSizeMetadata: &c.compressedSize,
+	}
+}
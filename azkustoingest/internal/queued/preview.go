@@ -0,0 +1,124 @@
+package queued
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+)
+
+// previewLocal records in the preview of a dry run the blob a local file would be uploaded to, without uploading it.
+func (i *Ingestion) previewLocal(ctx context.Context, from string, props properties.All) (string, int64, error) {
+	file, err := os.Open(from)
+	if err != nil {
+		return "", 0, errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "problem retrieving source file %q: %s", from, err).SetNoRetry()
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return "", 0, errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "could not Stat the file(%s): %s", from, err).SetNoRetry()
+	}
+
+	compression := EffectiveCompressionType(&props, from)
+	shouldCompress := i.shouldCompress(&props, compression, stat.Size())
+	if !shouldCompress {
+		// The file is uploaded as is, without a checksum of the whole blob.
+		blobURL, err := i.previewBlob(ctx, &props, from, compression, false)
+		if err != nil {
+			return "", 0, err
+		}
+		props.RecordSizes(stat.Size(), stat.Size())
+		props.Source.Preview.BlobSize = stat.Size()
+		return blobURL, rawSize(compression, stat.Size()), nil
+	}
+	return i.previewStream(ctx, file, from, compression, true, props)
+}
+
+// previewReader records in the preview of a dry run the blob the content of a reader would be uploaded to, without uploading
+// it. The reader is read to the end.
+func (i *Ingestion) previewReader(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+	total := readerSize(reader)
+	compression := EffectiveCompressionType(&props, props.Source.OriginalSource)
+	shouldCompress := i.shouldCompress(&props, compression, total)
+	blobURL, size, err := i.previewStream(ctx, reader, props.Source.OriginalSource, compression, shouldCompress, props)
+	if err != nil || shouldCompress {
+		return blobURL, size, err
+	}
+	if compression == ingestoptions.GZIP || compression == ingestoptions.ZIP {
+		return blobURL, rawSize(compression, total), nil
+	}
+	return blobURL, 0, nil
+}
+
+// previewStream reads the data as it would be streamed to the blob, compressed if shouldCompress is set, and records the blob in
+// the preview of the dry run. It returns the URL of the blob and the size of the data read.
+func (i *Ingestion) previewStream(ctx context.Context, reader io.Reader, fileName string, compression ingestoptions.CompressionType, shouldCompress bool, props properties.All) (string, int64, error) {
+	blobURL, err := i.previewBlob(ctx, &props, fileName, compression, shouldCompress)
+	if err != nil {
+		return "", 0, err
+	}
+
+	source := &properties.CountingReader{R: reader}
+	reader = source
+	options := i.streamOptions()
+	var compressed *compressedReader
+	if shouldCompress {
+		compressed = newCompressedReader(reader)
+		options.Metadata = compressed.metadata()
+		reader = compressed
+	}
+	uploaded := &properties.CountingReader{R: reader}
+	if _, err := io.Copy(io.Discard, withChecksums(uploaded, options)); err != nil {
+		return "", 0, errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "could not read the data: %s", err)
+	}
+
+	preview := props.Source.Preview
+	preview.BlobSize = uploaded.N()
+	preview.ContentMD5 = options.HTTPHeaders.BlobContentMD5
+	for k, v := range options.Metadata {
+		if preview.Metadata == nil {
+			preview.Metadata = map[string]string{}
+		}
+		preview.Metadata[k] = *v
+	}
+	props.RecordSizes(source.N(), uploaded.N())
+	return blobURL, source.N(), nil
+}
+
+// previewBlob records in the preview of the dry run the URL of the blob the data would be uploaded to: a new blob of the staging
+// storage, if any, or of the first container of the cluster the upload would try.
+func (i *Ingestion) previewBlob(ctx context.Context, props *properties.All, fileName string, compression ingestoptions.CompressionType, shouldCompress bool) (string, error) {
+	blobName := i.blobName(props, fileName, compression, shouldCompress)
+	props.Source.Preview.Compressed = shouldCompress
+
+	var blobURL string
+	if i.staging != nil {
+		authorized, _, err := i.authorizeStaged(fullUrl(i.staging.Client, i.staging.Container, blobName), 0)
+		if err != nil {
+			return "", err
+		}
+		blobURL = authorized
+	} else {
+		containers, err := i.mgr.GetRankedStorageContainers(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(containers) == 0 {
+			return "", errors.ES(
+				errors.OpFileIngest,
+				errors.KBlobstore,
+				"no IngestBlob Storage container resources are defined, there is no container to upload to",
+			).SetNoRetry()
+		}
+		client, containerName, err := i.upstreamContainer(containers[0])
+		if err != nil {
+			return "", err
+		}
+		blobURL = fullUrl(client, containerName, blobName)
+	}
+	props.Source.Preview.BlobURL = blobURL
+	return blobURL, nil
+}
@@ -4,8 +4,10 @@ package queued
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -17,12 +19,13 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/utils"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
-	"github.com/Azure/azure-kusto-go/azkustoingest/internal/gzip"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azqueue"
 	"github.com/google/uuid"
 )
@@ -38,6 +41,20 @@ const (
 	BlockSize             = 8 * _1MiB
 	Concurrency           = 50
 	StorageMaxRetryPolicy = 3
+
+	// StreamBuffers is the default number of blocks of a reader held in memory while they are uploaded, so a reader of any size
+	// is uploaded with at most StreamBuffers * BlockSize bytes of memory.
+	StreamBuffers = 4
+	// MaxBlockSize is the largest block that a block blob accepts.
+	MaxBlockSize = 4000 * _1MiB
+	// MaxUploadParallelism is the most blocks that WithUploadParallelism uploads at once.
+	MaxUploadParallelism = math.MaxUint16
+	// BlockMaxRetries is how many times the upload of a block is retried before the upload of the blob fails.
+	BlockMaxRetries = 3
+
+	defaultRetryInterval = 1 * time.Second
+	// cleanupTimeout bounds the deletion of the blob of a canceled upload, which outlives the context of the upload.
+	cleanupTimeout = 10 * time.Second
 )
 
 // Queued provides methods for taking data from various sources and ingesting it into Kusto using queued ingestion.
@@ -54,6 +71,12 @@ type uploadStream func(context.Context, io.Reader, *azblob.Client, string, strin
 // uploadBlob provides a type that mimics `azblob.UploadFile` to allow fakes for test
 type uploadBlob func(context.Context, *os.File, *azblob.Client, string, string, *azblob.UploadFileOptions) (azblob.UploadFileResponse, error)
 
+// deleteBlob provides a type that mimics `azblob.DeleteBlob` to allow fakes for tests.
+type deleteBlob func(ctx context.Context, client *azblob.Client, container, blob string) error
+
+// blobMD5 provides a type that reads the MD5 in the properties of a blob, to allow fakes for tests.
+type blobMD5 func(ctx context.Context, client *azblob.Client, container, blob string) ([]byte, error)
+
 // Ingestion provides methods for taking data from a filesystem of some type and ingesting it into Kusto.
 // This object is scoped for a single database and table.
 type Ingestion struct {
@@ -64,18 +87,64 @@ type Ingestion struct {
 
 	uploadStream uploadStream
 	uploadBlob   uploadBlob
+	deleteBlob   deleteBlob
+	blobMD5      blobMD5
 
 	bufferSize int
 	maxBuffers int
+	// parallelism is how many blocks are uploaded at once, or 0 for the defaults.
+	parallelism int
 
 	applicationForTracing   string
 	clientVersionForTracing string
+
+	staging *StagingStorage
+	// storageCredential authorizes the containers and queues of the cluster that have no SAS.
+	storageCredential azcore.TokenCredential
+
+	// compressionThreshold is the size under which data of a known size is uploaded without compressing it.
+	compressionThreshold int64
+
+	// retryInterval is how long to wait before the second attempt to upload or post to a queue, and doubles after each attempt.
+	retryInterval time.Duration
+}
+
+// StagingStorage is a container of the user that data is uploaded to, instead of the containers of the cluster.
+type StagingStorage struct {
+	Client    *azblob.Client
+	Container string
+	// Authorize returns the URL of a blob of the container with what the service needs to read it, such as a SAS.
+	Authorize func(blobURL string) (string, error)
 }
 
 // Option is an optional argument to New().
 type Option func(s *Ingestion)
 
-// WithStaticBuffer sets a static buffer with a buffer size and max amount of buffers for uploading blobs to kusto.
+// WithStagingStorage uploads the data to the staging storage of the user, instead of the containers of the cluster.
+func WithStagingStorage(staging *StagingStorage) Option {
+	return func(s *Ingestion) {
+		s.staging = staging
+	}
+}
+
+// WithStorageCredential authorizes the uploads to the containers and the messages to the queues of the cluster with the
+// credential, when their URIs have no SAS, as clusters where SAS are disabled return.
+func WithStorageCredential(cred azcore.TokenCredential) Option {
+	return func(s *Ingestion) {
+		s.storageCredential = cred
+	}
+}
+
+// WithCompressionThreshold uploads data smaller than threshold bytes without compressing it. Data whose size isn't known, such
+// as most readers, is always compressed.
+func WithCompressionThreshold(threshold int64) Option {
+	return func(s *Ingestion) {
+		s.compressionThreshold = threshold
+	}
+}
+
+// WithStaticBuffer sets the size of the blocks that readers are uploaded in, and how many of them are held in memory at once.
+// Zero values keep BlockSize and StreamBuffers.
 func WithStaticBuffer(bufferSize int, maxBuffers int) Option {
 	return func(s *Ingestion) {
 		s.bufferSize = bufferSize
@@ -83,6 +152,14 @@ func WithStaticBuffer(bufferSize int, maxBuffers int) Option {
 	}
 }
 
+// WithUploadParallelism uploads n blocks of the data at once. Readers then hold up to n blocks in memory, unless
+// WithStaticBuffer sets another number. Zero keeps Concurrency for files and StreamBuffers for readers.
+func WithUploadParallelism(n int) Option {
+	return func(s *Ingestion) {
+		s.parallelism = n
+	}
+}
+
 // New is the constructor for Ingestion.
 func New(db, table string, mgr *resources.Manager, http *http.Client, applicationForTracing string, clientVersionForTracing string, options ...Option) (*Ingestion, error) {
 	i := &Ingestion{
@@ -98,20 +175,92 @@ func New(db, table string, mgr *resources.Manager, http *http.Client, applicatio
 			options *azblob.UploadFileOptions) (azblob.UploadFileResponse, error) {
 			return client.UploadFile(ctx, container, blob, file, options)
 		},
+		deleteBlob: func(ctx context.Context, client *azblob.Client, container, blob string) error {
+			_, err := client.DeleteBlob(ctx, container, blob, nil)
+			return err
+		},
+		blobMD5: func(ctx context.Context, client *azblob.Client, container, blob string) ([]byte, error) {
+			props, err := client.ServiceClient().NewContainerClient(container).NewBlobClient(blob).GetProperties(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			return props.ContentMD5, nil
+		},
 		applicationForTracing:   applicationForTracing,
 		clientVersionForTracing: clientVersionForTracing,
+		retryInterval:           defaultRetryInterval,
 	}
 
 	for _, opt := range options {
 		opt(i)
 	}
 
+	if i.bufferSize < 0 || i.bufferSize > MaxBlockSize {
+		return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "buffer size must be between 0 and %d bytes, got %d", MaxBlockSize, i.bufferSize).SetNoRetry()
+	}
+	if i.compressionThreshold < 0 {
+		return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "compression threshold must not be negative, got %d", i.compressionThreshold).SetNoRetry()
+	}
+	if i.maxBuffers < 0 {
+		return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "max buffers must not be negative, got %d", i.maxBuffers).SetNoRetry()
+	}
+	if i.parallelism < 0 || i.parallelism > MaxUploadParallelism {
+		return nil, errors.ES(errors.OpUnknown, errors.KClientArgs, "upload parallelism must be between 0 and %d, got %d", MaxUploadParallelism, i.parallelism).SetNoRetry()
+	}
+	if i.bufferSize == 0 {
+		i.bufferSize = BlockSize
+	}
+	if i.maxBuffers == 0 {
+		i.maxBuffers = StreamBuffers
+		if i.parallelism > 0 {
+			i.maxBuffers = i.parallelism
+		}
+	}
+
 	return i, nil
 }
 
+// streamOptions stages the content of a reader in blocks of bufferSize, with at most maxBuffers of them in memory, so the
+// memory used doesn't grow with the size of the reader. A block blob holds up to 50,000 blocks, which is 390GiB with the
+// default block size.
+func (i *Ingestion) streamOptions() *azblob.UploadStreamOptions {
+	return &azblob.UploadStreamOptions{BlockSize: int64(i.bufferSize), Concurrency: i.maxBuffers}
+}
+
+// fileOptions uploads a file in blocks of bufferSize, parallelism of them at once, or Concurrency unless WithUploadParallelism
+// is set. The service validates each block with its CRC64.
+func (i *Ingestion) fileOptions() *azblob.UploadFileOptions {
+	blockSize := int64(i.bufferSize)
+	if blockSize == 0 {
+		blockSize = BlockSize
+	}
+	concurrency := uint16(Concurrency)
+	if i.parallelism > 0 {
+		concurrency = uint16(i.parallelism)
+	}
+	return &azblob.UploadFileOptions{
+		BlockSize:               blockSize,
+		Concurrency:             concurrency,
+		TransactionalValidation: blob.TransferValidationTypeComputeCRC64(),
+	}
+}
+
 // UploadLocalToBlob uploads a local file to blob storage and returns the blob URL and size.
 func (i *Ingestion) UploadLocalToBlob(ctx context.Context, from string, props properties.All) (string, int64, error) {
-	containers, err := i.mgr.GetRankedStorageContainers()
+	if props.Source.Preview != nil {
+		return i.previewLocal(ctx, from, props)
+	}
+	start := time.Now()
+	if i.staging != nil {
+		blobURL, size, err := i.localToBlob(ctx, from, i.staging.Client, i.staging.Container, &props)
+		if err != nil {
+			return "", 0, err
+		}
+		props.RecordUpload(time.Since(start))
+		return i.authorizeStaged(blobURL, size)
+	}
+
+	containers, err := i.mgr.GetRankedStorageContainers(ctx)
 	if err != nil {
 		return "", 0, err
 	}
@@ -124,7 +273,7 @@ func (i *Ingestion) UploadLocalToBlob(ctx context.Context, from string, props pr
 		).SetNoRetry()
 	}
 
-	queues, err := i.mgr.GetRankedStorageQueues()
+	queues, err := i.mgr.GetRankedStorageQueues(ctx)
 	if err != nil {
 		return "", 0, err
 	}
@@ -135,39 +284,51 @@ func (i *Ingestion) UploadLocalToBlob(ctx context.Context, from string, props pr
 		return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "no Kusto queue resources are defined, there is no queue to upload to").SetNoRetry()
 	}
 
-	// Go over all the containers and try to upload the file to each one. If we succeed, we are done.
-	for attempts, containerUri := range containers {
-		if attempts >= StorageMaxRetryPolicy {
-			return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "max retry policy reached").SetNoRetry()
+	// Go over the containers, with a backoff, and try to upload the file to each one. If we succeed, we are done.
+	var lastErr error
+	for attempts := 0; attempts < StorageMaxRetryPolicy; attempts++ {
+		containerUri := containers[attempts%len(containers)]
+		if err := i.waitToRetry(ctx, attempts); err != nil {
+			return "", 0, properties.NewCanceledError(ctx, errors.OpFileIngest, properties.SUploading)
 		}
 
 		client, containerName, err := i.upstreamContainer(containerUri)
 		if err != nil {
 			i.mgr.ReportStorageResourceResult(containerUri.Account(), false)
+			lastErr = err
 			continue
 		}
 
 		blobURL, size, err := i.localToBlob(ctx, from, client, containerName, &props)
 		if err == nil {
 			i.mgr.ReportStorageResourceResult(containerUri.Account(), true)
+			props.RecordUpload(time.Since(start))
 			return blobURL, size, nil
 		}
 
 		// check if the error is retryable
 		if errors.Retry(err) {
 			i.mgr.ReportStorageResourceResult(containerUri.Account(), false)
+			lastErr = err
 			continue
 		} else {
 			return "", 0, err
 		}
 	}
 
-	return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not upload file to any container")
+	return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not upload file to any container: %s", lastErr)
 }
 
 // UploadReaderToBlob uploads a file via an io.Reader and returns the blob URL and size.
 func (i *Ingestion) UploadReaderToBlob(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
-	containers, err := i.mgr.GetRankedStorageContainers()
+	if props.Source.Preview != nil {
+		return i.previewReader(ctx, reader, props)
+	}
+	if i.staging != nil {
+		return i.readerToStaging(ctx, reader, props)
+	}
+
+	containers, err := i.mgr.GetRankedStorageContainers(ctx)
 	if err != nil {
 		return "", 0, err
 	}
@@ -180,7 +341,7 @@ func (i *Ingestion) UploadReaderToBlob(ctx context.Context, reader io.Reader, pr
 		).SetNoRetry()
 	}
 
-	queues, err := i.mgr.GetRankedStorageQueues()
+	queues, err := i.mgr.GetRankedStorageQueues(ctx)
 	if err != nil {
 		return "", 0, err
 	}
@@ -191,41 +352,62 @@ func (i *Ingestion) UploadReaderToBlob(ctx context.Context, reader io.Reader, pr
 		return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "no Kusto queue resources are defined, there is no queue to upload to").SetNoRetry()
 	}
 
+	total := readerSize(reader)
 	compression := EffectiveCompressionType(&props, props.Source.OriginalSource)
-	shouldCompress := ShouldCompress(&props, compression)
-	blobName := GenBlobName(i.db, i.table, nower(), filepath.Base(uuid.New().String()), filepath.Base(props.Source.OriginalSource), compression, shouldCompress, props.Ingestion.Additional.Format.String())
+	shouldCompress := i.shouldCompress(&props, compression, total)
+	blobName := i.blobName(&props, props.Source.OriginalSource, compression, shouldCompress)
 	seeker, isSeekable := reader.(io.Seeker)
 
 	size := int64(0)
-
-	// Go over all the containers and try to upload the file to each one. If we succeed, we are done.
-	for attempts, containerUri := range containers {
-		currentReader := reader
-		if shouldCompress {
-			currentReader = gzip.Compress(currentReader)
-		}
-
-		if attempts >= StorageMaxRetryPolicy {
-			return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "max retry policy reached").SetNoRetry()
+	start := time.Now()
+
+	// Go over the containers, with a backoff, and try to upload the file to each one. If we succeed, we are done.
+	var lastErr error
+	for attempts := 0; attempts < StorageMaxRetryPolicy; attempts++ {
+		containerUri := containers[attempts%len(containers)]
+		if err := i.waitToRetry(ctx, attempts); err != nil {
+			return "", 0, properties.NewCanceledError(ctx, errors.OpFileIngest, properties.SUploading)
 		}
 
 		client, containerName, err := i.upstreamContainer(containerUri)
 		if err != nil {
 			i.mgr.ReportStorageResourceResult(containerUri.Account(), false)
+			lastErr = err
 			continue
 		}
 
+		source := &properties.CountingReader{R: props.TrackProgress(reader, total)}
+		var currentReader io.Reader = source
+		options := i.streamOptions()
+		var compressed *compressedReader
+		if shouldCompress {
+			props.ReportStage(properties.SCompressing)
+			compressed = newCompressedReader(currentReader)
+			options.Metadata = compressed.metadata()
+			currentReader = compressed
+		}
+		uploaded := &properties.CountingReader{R: currentReader}
+		currentReader = withChecksums(uploaded, options)
+		props.ReportStage(properties.SUploading)
+
 		_, err = i.uploadStream(
 			ctx,
 			currentReader,
 			client,
 			containerName,
 			blobName,
-			&azblob.UploadStreamOptions{BlockSize: int64(i.bufferSize), Concurrency: i.maxBuffers},
+			options,
 		)
+		if err == nil {
+			err = i.verifyChecksum(ctx, client, containerName, blobName, options)
+		}
 
 		if err != nil {
+			if ctx.Err() != nil {
+				return "", 0, i.canceledUpload(ctx, client, containerName, blobName)
+			}
 			i.mgr.ReportStorageResourceResult(containerUri.Account(), false)
+			lastErr = err
 			if isSeekable {
 				_, err = seeker.Seek(0, io.SeekStart)
 				if err != nil {
@@ -237,13 +419,80 @@ func (i *Ingestion) UploadReaderToBlob(ctx context.Context, reader io.Reader, pr
 		}
 
 		i.mgr.ReportStorageResourceResult(containerUri.Account(), true)
-		if gz, ok := currentReader.(*gzip.Streamer); ok {
-			size = gz.InputSize()
+		props.RecordSizes(source.N(), uploaded.N())
+		props.RecordUpload(time.Since(start))
+		if compressed != nil {
+			size = compressed.InputSize()
+		} else if compression == ingestoptions.GZIP || compression == ingestoptions.ZIP {
+			size = rawSize(compression, total)
 		}
 		return fullUrl(client, containerName, blobName), size, nil
 	}
 
-	return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "problem uploading to IngestBlob Storage")
+	return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "problem uploading to IngestBlob Storage: %s", lastErr)
+}
+
+// readerToStaging uploads the content of the reader to the staging storage of the user. Unlike the containers of the cluster,
+// there is no other container to retry the upload with.
+func (i *Ingestion) readerToStaging(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+	total := readerSize(reader)
+	compression := EffectiveCompressionType(&props, props.Source.OriginalSource)
+	shouldCompress := i.shouldCompress(&props, compression, total)
+	blobName := i.blobName(&props, props.Source.OriginalSource, compression, shouldCompress)
+
+	start := time.Now()
+	source := &properties.CountingReader{R: props.TrackProgress(reader, total)}
+	reader = source
+	options := i.streamOptions()
+	var compressed *compressedReader
+	if shouldCompress {
+		props.ReportStage(properties.SCompressing)
+		compressed = newCompressedReader(reader)
+		options.Metadata = compressed.metadata()
+		reader = compressed
+	}
+	uploaded := &properties.CountingReader{R: reader}
+	reader = withChecksums(uploaded, options)
+	props.ReportStage(properties.SUploading)
+	_, err := i.uploadStream(
+		ctx,
+		reader,
+		i.staging.Client,
+		i.staging.Container,
+		blobName,
+		options,
+	)
+	if err == nil {
+		err = i.verifyChecksum(ctx, i.staging.Client, i.staging.Container, blobName, options)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", 0, i.canceledUpload(ctx, i.staging.Client, i.staging.Container, blobName)
+		}
+		return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "problem uploading to the staging storage: %s", err)
+	}
+	props.RecordSizes(source.N(), uploaded.N())
+	props.RecordUpload(time.Since(start))
+
+	size := int64(0)
+	if compressed != nil {
+		size = compressed.InputSize()
+	} else if compression == ingestoptions.GZIP || compression == ingestoptions.ZIP {
+		size = rawSize(compression, total)
+	}
+	return i.authorizeStaged(fullUrl(i.staging.Client, i.staging.Container, blobName), size)
+}
+
+// authorizeStaged returns the URL of a blob uploaded to the staging storage of the user, with what the service needs to read it.
+func (i *Ingestion) authorizeStaged(blobURL string, size int64) (string, int64, error) {
+	if i.staging.Authorize == nil {
+		return blobURL, size, nil
+	}
+	authorized, err := i.staging.Authorize(blobURL)
+	if err != nil {
+		return "", 0, err
+	}
+	return authorized, size, nil
 }
 
 // IngestBlob ingests a file from Azure Blob Storage into Kusto.
@@ -254,11 +503,13 @@ func (i *Ingestion) IngestBlob(ctx context.Context, from string, fileSize int64,
 	// https://docs.microsoft.com/en-us/azure/data-explorer/ingest-data-overview#ingestion-methods
 
 	props.Ingestion.BlobPath = from
-	if fileSize != 0 {
+	// A size set with the RawDataSize option is kept, as the size of the file understates the size of the data of binary
+	// formats, such as Parquet.
+	if fileSize != 0 && props.Ingestion.RawDataSize == 0 {
 		props.Ingestion.RawDataSize = fileSize
 	}
 
-	props.Ingestion.RetainBlobOnSuccess = !props.Source.DeleteLocalSource
+	props.Ingestion.RetainBlobOnSuccess = !props.Source.DeleteLocalSource && !props.Source.DeleteBlobOnSuccess
 	props.Ingestion.ApplicationForTracing = i.applicationForTracing
 	props.Ingestion.ClientVersionForTracing = i.clientVersionForTracing
 
@@ -272,32 +523,84 @@ func (i *Ingestion) IngestBlob(ctx context.Context, from string, fileSize int64,
 		return errors.ES(errors.OpFileIngest, errors.KInternal, "could not marshal the ingestion blob info: %s", err).SetNoRetry()
 	}
 
-	queueResources, err := i.mgr.GetRankedStorageQueues()
+	queueResources, err := i.mgr.GetRankedStorageQueues(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Go over all the queues and try to upload the file to each one. If we succeed, we are done.
-	for attempts, queueUri := range queueResources {
-		if attempts >= StorageMaxRetryPolicy {
-			return errors.ES(errors.OpFileIngest, errors.KBlobstore, "max retry policy reached").SetNoRetry()
+	if len(queueResources) == 0 {
+		return errors.ES(errors.OpFileIngest, errors.KBlobstore, "no Kusto queue resources are defined, there is no queue to upload to").SetNoRetry()
+	}
+
+	if preview := props.Source.Preview; preview != nil {
+		message, err := base64.StdEncoding.DecodeString(j)
+		if err != nil {
+			return errors.ES(errors.OpFileIngest, errors.KInternal, "could not decode the ingestion blob info: %s", err).SetNoRetry()
+		}
+		preview.BlobURL = from
+		preview.Message = string(message)
+		return nil
+	}
+
+	// Go over the queues, with a backoff, and try to post the message to each one. If we succeed, we are done.
+	start := time.Now()
+	var lastErr error
+	for attempts := 0; attempts < StorageMaxRetryPolicy; attempts++ {
+		queueUri := queueResources[attempts%len(queueResources)]
+		if err := i.waitToRetry(ctx, attempts); err != nil {
+			return properties.NewCanceledError(ctx, errors.OpFileIngest, properties.SQueued)
 		}
+
 		queue, err := i.upstreamQueue(queueUri)
 		if err != nil {
 			i.mgr.ReportStorageResourceResult(queueUri.Account(), false)
+			lastErr = err
 			continue
 		}
 
 		if _, err := queue.EnqueueMessage(ctx, j, nil); err != nil {
+			if ctx.Err() != nil {
+				return properties.NewCanceledError(ctx, errors.OpFileIngest, properties.SQueued)
+			}
 			i.mgr.ReportStorageResourceResult(queueUri.Account(), false)
+			lastErr = err
 			continue
 		} else {
 			i.mgr.ReportStorageResourceResult(queueUri.Account(), true)
+			props.RecordQueue(time.Since(start))
+			props.ReportStage(properties.SQueued)
 			return props.ApplyDeleteLocalSourceOption()
 		}
 	}
 
-	return errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not upload file to any queue")
+	return errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not upload file to any queue: %s", lastErr)
+}
+
+// canceledUpload returns the error of an upload stopped by its context, after deleting the blob, in case the upload committed it
+// before it stopped. The blocks that were staged and not committed are deleted by the service after a week.
+func (i *Ingestion) canceledUpload(ctx context.Context, client *azblob.Client, container, blob string) error {
+	if i.deleteBlob != nil && client != nil {
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		_ = i.deleteBlob(cleanupCtx, client, container, blob)
+		cancel()
+	}
+	return properties.NewCanceledError(ctx, errors.OpFileIngest, properties.SUploading)
+}
+
+// waitToRetry waits before the attempt, twice as long as before the previous one. It returns the error of the context if it is
+// done first.
+func (i *Ingestion) waitToRetry(ctx context.Context, attempt int) error {
+	if attempt == 0 || i.retryInterval <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(i.retryInterval << (attempt - 1))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 func CompleteFormatFromFileName(props *properties.All, from string) error {
@@ -318,13 +621,21 @@ func CompleteFormatFromFileName(props *properties.All, from string) error {
 
 func (i *Ingestion) upstreamContainer(resourceUri *resources.URI) (*azblob.Client, string, error) {
 	storageUrl := resourceUri.URL()
-	serviceURL := fmt.Sprintf("%s://%s?%s", storageUrl.Scheme, storageUrl.Host, resourceUri.SAS().Encode())
-
-	client, err := azblob.NewClientWithNoCredential(serviceURL, &azblob.ClientOptions{
+	options := &azblob.ClientOptions{
 		ClientOptions: azcore.ClientOptions{
 			Transport: i.http,
+			Retry:     policy.RetryOptions{MaxRetries: BlockMaxRetries},
 		},
-	})
+	}
+
+	var client *azblob.Client
+	var err error
+	if i.useStorageCredential(resourceUri) {
+		client, err = azblob.NewClient(fmt.Sprintf("%s://%s/", storageUrl.Scheme, storageUrl.Host), i.storageCredential, options)
+	} else {
+		serviceURL := fmt.Sprintf("%s://%s?%s", storageUrl.Scheme, storageUrl.Host, resourceUri.SAS().Encode())
+		client, err = azblob.NewClientWithNoCredential(serviceURL, options)
+	}
 
 	if err != nil {
 		return nil, "", errors.E(errors.OpFileIngest, errors.KBlobstore, err)
@@ -335,13 +646,20 @@ func (i *Ingestion) upstreamContainer(resourceUri *resources.URI) (*azblob.Clien
 
 func (i *Ingestion) upstreamQueue(resourceUri *resources.URI) (*azqueue.QueueClient, error) {
 	queueUrl := resourceUri.URL()
-	serviceUrl := fmt.Sprintf("%s://%s?%s", queueUrl.Scheme, queueUrl.Host, resourceUri.SAS().Encode())
-
-	service, err := azqueue.NewServiceClientWithNoCredential(serviceUrl, &azqueue.ClientOptions{
+	options := &azqueue.ClientOptions{
 		ClientOptions: azcore.ClientOptions{
 			Transport: i.http,
 		},
-	})
+	}
+
+	var service *azqueue.ServiceClient
+	var err error
+	if i.useStorageCredential(resourceUri) {
+		service, err = azqueue.NewServiceClient(fmt.Sprintf("%s://%s/", queueUrl.Scheme, queueUrl.Host), i.storageCredential, options)
+	} else {
+		serviceUrl := fmt.Sprintf("%s://%s?%s", queueUrl.Scheme, queueUrl.Host, resourceUri.SAS().Encode())
+		service, err = azqueue.NewServiceClientWithNoCredential(serviceUrl, options)
+	}
 	if err != nil {
 		return nil, errors.E(errors.OpFileIngest, errors.KBlobstore, err)
 	}
@@ -349,15 +667,16 @@ func (i *Ingestion) upstreamQueue(resourceUri *resources.URI) (*azqueue.QueueCli
 	return service.NewQueueClient(resourceUri.ObjectName()), nil
 }
 
+// useStorageCredential reports whether the resource is authorized with the storage credential, rather than with its SAS.
+func (i *Ingestion) useStorageCredential(resourceUri *resources.URI) bool {
+	return i.storageCredential != nil && resourceUri.SAS().Get("sig") == ""
+}
+
 var nower = time.Now
 
 // localToBlob copies from a local to an Azure Blobstore blob. It returns the URL of the Blob, the local file info and an
 // error if there was one.
 func (i *Ingestion) localToBlob(ctx context.Context, from string, client *azblob.Client, container string, props *properties.All) (string, int64, error) {
-	compression := EffectiveCompressionType(props, from)
-	shouldCompress := ShouldCompress(props, compression)
-	blobName := GenBlobName(i.db, i.table, nower(), filepath.Base(uuid.New().String()), filepath.Base(from), compression, shouldCompress, props.Ingestion.Additional.Format.String())
-
 	file, err := os.Open(from)
 	if err != nil {
 		return "", 0, errors.ES(
@@ -377,44 +696,90 @@ func (i *Ingestion) localToBlob(ctx context.Context, from string, client *azblob
 		).SetNoRetry()
 	}
 
+	compression := EffectiveCompressionType(props, from)
+	shouldCompress := i.shouldCompress(props, compression, stat.Size())
+	blobName := i.blobName(props, from, compression, shouldCompress)
+
 	if shouldCompress {
-		gstream := gzip.New()
-		gstream.Reset(file)
+		props.ReportStage(properties.SCompressing)
+		props.ReportStage(properties.SUploading)
+		compressed := newCompressedReader(props.TrackProgress(file, stat.Size()))
+		options := i.streamOptions()
+		options.Metadata = compressed.metadata()
+		uploaded := &properties.CountingReader{R: compressed}
 
 		_, err = i.uploadStream(
 			ctx,
-			gstream,
+			withChecksums(uploaded, options),
 			client,
 			container,
 			blobName,
-			&azblob.UploadStreamOptions{BlockSize: int64(i.bufferSize), Concurrency: i.maxBuffers},
+			options,
 		)
+		if err == nil {
+			err = i.verifyChecksum(ctx, client, container, blobName, options)
+		}
 
 		if err != nil {
+			if ctx.Err() != nil {
+				return "", 0, i.canceledUpload(ctx, client, container, blobName)
+			}
 			return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "problem uploading to IngestBlob Storage: %s", err)
 		}
-		return fullUrl(client, container, blobName), gstream.InputSize(), nil
+		props.RecordSizes(compressed.InputSize(), uploaded.N())
+		return fullUrl(client, container, blobName), compressed.InputSize(), nil
 	}
 
 	// The high-level API UploadFileToBlockBlob function uploads blocks in parallel for optimal performance, and can handle large files as well.
 	// This function calls StageBlock/CommitBlockList for files larger 256 MBs, and calls Upload for any file smaller
+	options := i.fileOptions()
+	if props.Source.OnProgress != nil {
+		options.Progress = func(sent int64) {
+			props.ReportProgress(sent, stat.Size())
+		}
+	}
+	props.ReportStage(properties.SUploading)
 	_, err = i.uploadBlob(
 		ctx,
 		file,
 		client,
 		container,
 		blobName,
-		&azblob.UploadFileOptions{
-			BlockSize:   BlockSize,
-			Concurrency: Concurrency,
-		},
+		options,
 	)
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", 0, i.canceledUpload(ctx, client, container, blobName)
+		}
 		return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "problem uploading to IngestBlob Storage: %s", err)
 	}
 
-	return fullUrl(client, container, blobName), stat.Size(), nil
+	props.RecordSizes(stat.Size(), stat.Size())
+	return fullUrl(client, container, blobName), rawSize(compression, stat.Size()), nil
+}
+
+// rawSize returns the size of the data of a source of size bytes that is uploaded as is, or 0 if it isn't known: the size of
+// a compressed source, whose data is larger, is estimated. The service uses it to batch the blobs, so it is better left to the
+// RawDataSize option when the size of the data is known.
+func rawSize(compression ingestoptions.CompressionType, size int64) int64 {
+	if size < 0 {
+		return 0
+	}
+	return utils.EstimateRawDataSize(compression, size)
+}
+
+// blobName returns the name of a new blob for the data of props, read from fileName. It is named after the database and table
+// that the data is ingested into, which may be others than those of the client with the Database and Table options.
+func (i *Ingestion) blobName(props *properties.All, fileName string, compression ingestoptions.CompressionType, shouldCompress bool) string {
+	db, table := props.Ingestion.DatabaseName, props.Ingestion.TableName
+	if db == "" {
+		db = i.db
+	}
+	if table == "" {
+		table = i.table
+	}
+	return GenBlobName(db, table, nower(), filepath.Base(uuid.New().String()), filepath.Base(fileName), compression, shouldCompress, props.Ingestion.Additional.Format.String())
 }
 
 func GenBlobName(databaseName string, tableName string, time time.Time, guid string, fileName string, compressionFileExtension ingestoptions.CompressionType, shouldCompress bool, dataFormat string) string {
@@ -474,6 +839,12 @@ func ShouldCompress(props *properties.All, compressionFileExtension ingestoption
 	return props.Ingestion.Additional.Format.ShouldCompress()
 }
 
+// shouldCompress reports whether data of the size, or -1 if it isn't known, is compressed before it is uploaded: if it isn't
+// already compressed or in a binary format, such as Parquet, and isn't under the compression threshold.
+func (i *Ingestion) shouldCompress(props *properties.All, compression ingestoptions.CompressionType, size int64) bool {
+	return ShouldCompress(props, compression) && (size < 0 || size >= i.compressionThreshold)
+}
+
 // This allows mocking the stat func later on
 var statFunc = os.Stat
 
@@ -506,6 +877,14 @@ func IsLocalPath(s string) (bool, error) {
 	return true, nil
 }
 
+// readerSize returns the size of the reader if it is known without reading it, such as for a *bytes.Reader, or -1.
+func readerSize(reader io.Reader) int64 {
+	if r, ok := reader.(interface{ Size() int64 }); ok {
+		return r.Size()
+	}
+	return -1
+}
+
 func fullUrl(client *azblob.Client, container string, blob string) string {
 	parseURL, err := azblob.ParseURL(client.URL())
 	if err != nil {
@@ -4,18 +4,27 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/md5"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/utils"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 
 	"github.com/stretchr/testify/assert"
@@ -484,18 +493,108 @@ func TestUploadReaderToBlobRespectsExplicitCompressionTypeForBlobName(t *testing
 		),
 	}
 
-	_, _, err = i.UploadReaderToBlob(t.Context(), bytes.NewReader(compressed.Bytes()), properties.All{
+	props := properties.All{
 		Source: properties.SourceOptions{
 			CompressionType: ingestoptions.GZIP,
 		},
 		Ingestion: properties.Ingestion{
 			Additional: properties.Additional{Format: properties.CSV},
 		},
-	})
+	}
+	_, size, err := i.UploadReaderToBlob(t.Context(), bytes.NewReader(compressed.Bytes()), props)
 	require.NoError(t, err)
 
 	assert.Equal(t, compressed.Bytes(), fbs.out.Bytes(), "reader payload should not be recompressed when source is already gzip")
 	assert.True(t, strings.HasSuffix(fbs.blobName, ".gz"), "expected blob name to retain gzip extension, got %q", fbs.blobName)
+	// The size of the data of a compressed source is estimated from the size of the reader, if it is known.
+	assert.Equal(t, int64(compressed.Len())*utils.EstimatedCompressionFactor, size)
+
+	fbs.out.Reset()
+	_, size, err = i.UploadReaderToBlob(t.Context(), io.MultiReader(bytes.NewReader(compressed.Bytes())), props)
+	require.NoError(t, err)
+	assert.Zero(t, size)
+}
+
+func TestUploadReaderToStagingStorage(t *testing.T) {
+	t.Parallel()
+
+	client, err := azblob.NewClientWithNoCredential("https://mine.blob.core.windows.net/", nil)
+	require.NoError(t, err)
+
+	fbs := &fakeBlobstore{out: &bytes.Buffer{}}
+	i := &Ingestion{
+		db:           "database",
+		table:        "table",
+		uploadStream: fbs.uploadBlobStream,
+		// The containers of the cluster aren't used.
+		mgr: newFakeResourceManager(nil, nil, nil),
+		staging: &StagingStorage{
+			Client:    client,
+			Container: "staging",
+			Authorize: func(blobURL string) (string, error) {
+				return blobURL + "?sig=signed", nil
+			},
+		},
+	}
+
+	blobURL, size, err := i.UploadReaderToBlob(t.Context(), strings.NewReader("a,b\n"), properties.All{
+		Ingestion: properties.Ingestion{
+			Additional: properties.Additional{Format: properties.CSV},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(blobURL, "https://mine.blob.core.windows.net/staging/database_table_"), blobURL)
+	assert.True(t, strings.HasSuffix(blobURL, ".gz?sig=signed"), blobURL)
+	assert.Equal(t, int64(4), size)
+
+	r, err := gzip.NewReader(fbs.out)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n", string(data))
+}
+
+func TestUploadRetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	mgr := newFakeResourceManager(
+		[]string{"https://account.blob.core.windows.net/container"},
+		[]string{"https://account.queue.core.windows.net/queue"},
+		nil,
+	)
+
+	// With a single container, the upload is retried on it, after a backoff.
+	out := new(bytes.Buffer)
+	i := &Ingestion{
+		uploadStream:  newRetryingBlobstore(out, 2).uploadBlobStream,
+		mgr:           mgr,
+		retryInterval: 5 * time.Millisecond,
+	}
+	start := time.Now()
+	_, _, err := i.UploadReaderToBlob(t.Context(), strings.NewReader("data"), properties.All{
+		Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.Parquet}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "data", out.String())
+	assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+
+	// The last error is kept once the attempts are exhausted.
+	i.uploadStream = newRetryingBlobstore(new(bytes.Buffer), StorageMaxRetryPolicy).uploadBlobStream
+	_, _, err = i.UploadReaderToBlob(t.Context(), strings.NewReader("data"), properties.All{
+		Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.Parquet}},
+	})
+	assert.ErrorContains(t, err, "simulated upload failure")
+
+	// The backoff stops when the context is done.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	i.retryInterval = time.Hour
+	i.uploadStream = newRetryingBlobstore(new(bytes.Buffer), 1).uploadBlobStream
+	_, _, err = i.UploadReaderToBlob(ctx, strings.NewReader("data"), properties.All{
+		Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.Parquet}},
+	})
+	assert.ErrorIs(t, err, context.Canceled)
 }
 
 type retryingBlobstore struct {
@@ -553,15 +652,15 @@ func (f fakeResourceManager) ReportStorageResourceResult(_ string, _ bool) {
 
 }
 
-func (f fakeResourceManager) GetRankedStorageContainers() ([]*resources.URI, error) {
+func (f fakeResourceManager) GetRankedStorageContainers(context.Context) ([]*resources.URI, error) {
 	return f.storageContainers, nil
 }
 
-func (f fakeResourceManager) GetRankedStorageQueues() ([]*resources.URI, error) {
+func (f fakeResourceManager) GetRankedStorageQueues(context.Context) ([]*resources.URI, error) {
 	return f.storageQueues, nil
 }
 
-func (f fakeResourceManager) GetTables() ([]*resources.URI, error) {
+func (f fakeResourceManager) GetTables(context.Context) ([]*resources.URI, error) {
 	return f.tables, nil
 }
 
@@ -712,3 +811,563 @@ func TestReaderRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc        string
+		bufferSize  int
+		maxBuffers  int
+		want        *azblob.UploadStreamOptions
+		errContains string
+	}{
+		{
+			desc: "Defaults bound the memory of a reader",
+			want: &azblob.UploadStreamOptions{BlockSize: BlockSize, Concurrency: StreamBuffers},
+		},
+		{
+			desc:       "Static buffer",
+			bufferSize: 64 * _1MiB,
+			maxBuffers: 2,
+			want:       &azblob.UploadStreamOptions{BlockSize: 64 * _1MiB, Concurrency: 2},
+		},
+		{
+			desc:        "Block too large",
+			bufferSize:  MaxBlockSize + 1,
+			errContains: "buffer size must be between",
+		},
+		{
+			desc:        "Negative buffers",
+			maxBuffers:  -1,
+			errContains: "max buffers must not be negative",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			i, err := New("database", "table", nil, nil, "", "", WithStaticBuffer(test.bufferSize, test.maxBuffers))
+			if test.errContains != "" {
+				require.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, i.streamOptions())
+		})
+	}
+}
+
+func TestUploadParallelism(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("database", "table", nil, nil, "", "", WithUploadParallelism(16), WithStaticBuffer(32*_1MiB, 0))
+	require.NoError(t, err)
+	assert.Equal(t, &azblob.UploadStreamOptions{BlockSize: 32 * _1MiB, Concurrency: 16}, i.streamOptions())
+	file := i.fileOptions()
+	assert.Equal(t, int64(32*_1MiB), file.BlockSize)
+	assert.Equal(t, uint16(16), file.Concurrency)
+	assert.NotNil(t, file.TransactionalValidation)
+
+	i, err = New("database", "table", nil, nil, "", "", WithUploadParallelism(16), WithStaticBuffer(0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, 2, i.streamOptions().Concurrency)
+
+	i, err = New("database", "table", nil, nil, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(Concurrency), i.fileOptions().Concurrency)
+
+	_, err = New("database", "table", nil, nil, "", "", WithUploadParallelism(-1))
+	assert.ErrorContains(t, err, "upload parallelism must be between")
+	_, err = New("database", "table", nil, nil, "", "", WithUploadParallelism(MaxUploadParallelism+1))
+	assert.ErrorContains(t, err, "upload parallelism must be between")
+}
+
+func TestUploadChecksums(t *testing.T) {
+	t.Parallel()
+
+	newIngestion := func(t *testing.T, uploaded *[]byte, options **azblob.UploadStreamOptions, stored func([]byte) []byte, deleted *[]string) *Ingestion {
+		i, err := New("database", "table", nil, nil, "", "")
+		require.NoError(t, err)
+		i.mgr = newFakeResourceManager(
+			[]string{"https://account.blob.core.windows.net/container"},
+			[]string{"https://account.queue.core.windows.net/queue"},
+			nil,
+		)
+		i.uploadStream = func(_ context.Context, reader io.Reader, _ *azblob.Client, _ string, _ string, o *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error) {
+			*options = o
+			var err error
+			*uploaded, err = io.ReadAll(reader)
+			return azblob.UploadStreamResponse{}, err
+		}
+		// The service stores the MD5 sent with the commit of the blob.
+		i.blobMD5 = func(context.Context, *azblob.Client, string, string) ([]byte, error) {
+			return stored((*options).HTTPHeaders.BlobContentMD5), nil
+		}
+		i.deleteBlob = func(_ context.Context, _ *azblob.Client, container, blob string) error {
+			*deleted = append(*deleted, container+"/"+blob)
+			return nil
+		}
+		return i
+	}
+
+	for _, compress := range []bool{false, true} {
+		compress := compress
+		t.Run(fmt.Sprintf("compressed=%v", compress), func(t *testing.T) {
+			t.Parallel()
+
+			var uploaded []byte
+			var options *azblob.UploadStreamOptions
+			var deleted []string
+			i := newIngestion(t, &uploaded, &options, func(sum []byte) []byte { return sum }, &deleted)
+
+			props := properties.All{Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.CSV}}}
+			if !compress {
+				props.Source.DontCompress = true
+			}
+			_, _, err := i.UploadReaderToBlob(t.Context(), strings.NewReader("data"), props)
+			require.NoError(t, err)
+
+			assert.NotNil(t, options.TransactionalValidation)
+			require.NotNil(t, options.HTTPHeaders)
+			sum := md5.Sum(uploaded)
+			assert.Equal(t, sum[:], options.HTTPHeaders.BlobContentMD5)
+			assert.Empty(t, deleted)
+		})
+	}
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		var uploaded []byte
+		var options *azblob.UploadStreamOptions
+		var deleted []string
+		i := newIngestion(t, &uploaded, &options, func([]byte) []byte { return make([]byte, md5.Size) }, &deleted)
+		i.retryInterval = 0
+
+		props := properties.All{Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.CSV}}}
+		_, _, err := i.UploadReaderToBlob(t.Context(), strings.NewReader("data"), props)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "the MD5 of the uploaded blob is 00000000000000000000000000000000 instead of")
+		// Every attempt uploads a corrupted blob, which is deleted.
+		assert.Len(t, deleted, StorageMaxRetryPolicy)
+	})
+}
+
+type fakeTokenCredential struct{}
+
+func (fakeTokenCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "storage-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// roundTripFunc is an http.RoundTripper that answers with a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestStorageCredential(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc      string
+		queue     string
+		cred      azcore.TokenCredential
+		wantAuth  string
+		wantQuery string
+	}{
+		{
+			desc:     "Queue without a SAS is authorized with the credential",
+			queue:    "https://account.queue.core.windows.net/ready",
+			cred:     fakeTokenCredential{},
+			wantAuth: "Bearer storage-token",
+		},
+		{
+			desc:      "Queue with a SAS keeps using it",
+			queue:     "https://account.queue.core.windows.net/ready?sv=2022-11-02&sig=secret",
+			cred:      fakeTokenCredential{},
+			wantQuery: "secret",
+		},
+		{
+			desc:      "No credential",
+			queue:     "https://account.queue.core.windows.net/ready?sv=2022-11-02&sig=secret",
+			wantQuery: "secret",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var auth, sig string
+			var stages []properties.Stage
+			client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				auth = req.Header.Get("Authorization")
+				sig = req.URL.Query().Get("sig")
+				body := "<QueueMessagesList><QueueMessage><MessageId>id</MessageId></QueueMessage></QueueMessagesList>"
+				return &http.Response{
+					StatusCode: http.StatusCreated,
+					Header:     http.Header{"Content-Type": []string{"application/xml"}},
+					Body:       io.NopCloser(strings.NewReader(body)),
+					Request:    req,
+				}, nil
+			})}
+
+			i := &Ingestion{
+				db:                "database",
+				table:             "table",
+				http:              client,
+				mgr:               newFakeResourceManager(nil, []string{test.queue}, nil),
+				storageCredential: test.cred,
+			}
+			err := i.IngestBlob(t.Context(), "https://account.blob.core.windows.net/container/data.csv", 10, properties.All{
+				Ingestion: properties.Ingestion{
+					DatabaseName: "database",
+					TableName:    "table",
+					Additional:   properties.Additional{AuthContext: "auth"},
+				},
+				Source: properties.SourceOptions{
+					OnStage: func(stage properties.Stage) {
+						stages = append(stages, stage)
+					},
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []properties.Stage{properties.SQueued}, stages)
+
+			assert.Equal(t, test.wantAuth, auth)
+			assert.Equal(t, test.wantQuery, sig)
+		})
+	}
+}
+
+func TestUploadProgress(t *testing.T) {
+	t.Parallel()
+
+	mgr := newFakeResourceManager(
+		[]string{"https://account.blob.core.windows.net/container"},
+		[]string{"https://account.queue.core.windows.net/queue"},
+		nil,
+	)
+	i := &Ingestion{
+		uploadStream: newRetryingBlobstore(new(bytes.Buffer), 1).uploadBlobStream,
+		mgr:          mgr,
+	}
+
+	var stages []properties.Stage
+	var progress [][2]int64
+	var mu sync.Mutex
+	_, _, err := i.UploadReaderToBlob(t.Context(), strings.NewReader("data"), properties.All{
+		Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.CSV}},
+		Source: properties.SourceOptions{
+			OnStage: func(stage properties.Stage) {
+				mu.Lock()
+				defer mu.Unlock()
+				stages = append(stages, stage)
+			},
+			OnProgress: func(sent int64, total int64) {
+				mu.Lock()
+				defer mu.Unlock()
+				progress = append(progress, [2]int64{sent, total})
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The upload is retried once, and its progress starts over.
+	assert.Equal(t, []properties.Stage{
+		properties.SCompressing, properties.SUploading,
+		properties.SCompressing, properties.SUploading,
+	}, stages)
+	require.NotEmpty(t, progress)
+	assert.Equal(t, [2]int64{4, 4}, progress[len(progress)-1])
+	assert.Equal(t, int64(-1), readerSize(newNonSeekableReader(strings.NewReader("data"))))
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc         string
+		threshold    int64
+		reader       io.Reader
+		wantGzip     bool
+		wantMetadata map[string]string
+	}{
+		{
+			desc:         "Compressed above the threshold",
+			threshold:    4,
+			reader:       strings.NewReader("data"),
+			wantGzip:     true,
+			wantMetadata: map[string]string{rawSizeMetadata: "4"},
+		},
+		{
+			desc:      "Uploaded as is under the threshold",
+			threshold: 5,
+			reader:    strings.NewReader("data"),
+		},
+		{
+			desc:         "Compressed when the size is unknown",
+			threshold:    5,
+			reader:       newNonSeekableReader(strings.NewReader("data")),
+			wantGzip:     true,
+			wantMetadata: map[string]string{rawSizeMetadata: "4"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var uploaded []byte
+			var metadata map[string]*string
+			var blobName string
+			i, err := New("database", "table", nil, nil, "", "", WithCompressionThreshold(test.threshold))
+			require.NoError(t, err)
+			// The fake upload commits no blob whose properties could be read.
+			i.blobMD5 = nil
+			i.mgr = newFakeResourceManager(
+				[]string{"https://account.blob.core.windows.net/container"},
+				[]string{"https://account.queue.core.windows.net/queue"},
+				nil,
+			)
+			i.uploadStream = func(_ context.Context, reader io.Reader, _ *azblob.Client, _ string, blob string, o *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error) {
+				blobName = blob
+				uploaded, err = io.ReadAll(reader)
+				metadata = o.Metadata
+				return azblob.UploadStreamResponse{}, err
+			}
+
+			_, _, err = i.UploadReaderToBlob(t.Context(), test.reader, properties.All{
+				Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.CSV}},
+			})
+			require.NoError(t, err)
+
+			if !test.wantGzip {
+				assert.Equal(t, "data", string(uploaded))
+				assert.True(t, strings.HasSuffix(blobName, ".csv"), blobName)
+				assert.Nil(t, metadata)
+				return
+			}
+			assert.True(t, strings.HasSuffix(blobName, ".gz"), blobName)
+			r, err := gzip.NewReader(bytes.NewReader(uploaded))
+			require.NoError(t, err)
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "data", string(data))
+			assert.Equal(t, test.wantMetadata[rawSizeMetadata], *metadata[rawSizeMetadata])
+			assert.Equal(t, fmt.Sprint(len(uploaded)), *metadata[compressedSizeMetadata])
+		})
+	}
+
+	_, err := New("database", "table", nil, nil, "", "", WithCompressionThreshold(-1))
+	assert.ErrorContains(t, err, "compression threshold must not be negative")
+}
+
+func TestBlobNameTarget(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("database", "table", nil, nil, "", "")
+	require.NoError(t, err)
+
+	props := &properties.All{Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.CSV}}}
+	assert.True(t, strings.HasPrefix(i.blobName(props, "data.csv", ingestoptions.CTNone, true), "database_table_"))
+
+	props.Ingestion.DatabaseName = "otherDatabase"
+	props.Ingestion.TableName = "otherTable"
+	assert.True(t, strings.HasPrefix(i.blobName(props, "data.csv", ingestoptions.CTNone, true), "otherDatabase_otherTable_"))
+}
+
+func TestUploadMetrics(t *testing.T) {
+	t.Parallel()
+
+	fbs := &fakeBlobstore{out: &bytes.Buffer{}}
+	i := &Ingestion{
+		uploadStream: fbs.uploadBlobStream,
+		uploadBlob:   fbs.uploadBlobFile,
+		mgr: newFakeResourceManager(
+			[]string{"https://account.blob.core.windows.net/container"},
+			[]string{"https://account.queue.core.windows.net/queue"},
+			nil,
+		),
+	}
+
+	metrics := &properties.Metrics{}
+	_, _, err := i.UploadReaderToBlob(t.Context(), strings.NewReader("a,b\nc,d\n"), properties.All{
+		Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.CSV}},
+		Source:    properties.SourceOptions{Metrics: metrics},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(8), metrics.RawBytes)
+	assert.Equal(t, int64(fbs.out.Len()), metrics.CompressedBytes)
+	assert.Positive(t, metrics.UploadDuration)
+
+	path := filepath.Join(t.TempDir(), "data.csv.gz")
+	require.NoError(t, os.WriteFile(path, fbs.out.Bytes(), 0o600))
+	fbs.out.Reset()
+	metrics = &properties.Metrics{}
+	client, err := azblob.NewClientWithNoCredential("https://account.blob.core.windows.net/", nil)
+	require.NoError(t, err)
+	_, size, err := i.localToBlob(t.Context(), path, client, "container", &properties.All{
+		Ingestion: properties.Ingestion{Additional: properties.Additional{Format: properties.CSV}},
+		Source:    properties.SourceOptions{Metrics: metrics},
+	})
+	require.NoError(t, err)
+	// A compressed file is uploaded as is.
+	assert.Equal(t, int64(fbs.out.Len()), metrics.RawBytes)
+	assert.Equal(t, metrics.RawBytes, metrics.CompressedBytes)
+	assert.Equal(t, metrics.RawBytes*utils.EstimatedCompressionFactor, size)
+}
+
+func TestCanceled(t *testing.T) {
+	t.Parallel()
+
+	newIngestion := func(deleted *[]string) *Ingestion {
+		return &Ingestion{
+			http:  &http.Client{},
+			db:    "database",
+			table: "table",
+			uploadStream: func(ctx context.Context, reader io.Reader, client *azblob.Client, container, blob string,
+				options *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error) {
+				<-ctx.Done()
+				return azblob.UploadStreamResponse{}, ctx.Err()
+			},
+			deleteBlob: func(ctx context.Context, client *azblob.Client, container, blob string) error {
+				assert.NoError(t, ctx.Err(), "the blob is deleted with a live context")
+				*deleted = append(*deleted, container+"/"+blob)
+				return nil
+			},
+			mgr: newFakeResourceManager(
+				[]string{"https://account.blob.core.windows.net/container"},
+				[]string{"https://account.queue.core.windows.net/queue"},
+				nil,
+			),
+		}
+	}
+	props := properties.All{Ingestion: properties.Ingestion{
+		DatabaseName: "database",
+		TableName:    "table",
+		Additional:   properties.Additional{Format: properties.CSV, AuthContext: "authorization"},
+	}}
+
+	t.Run("Upload", func(t *testing.T) {
+		t.Parallel()
+
+		var deleted []string
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, _, err := newIngestion(&deleted).UploadReaderToBlob(ctx, strings.NewReader("a,b\n"), props)
+		assert.Less(t, time.Since(start), time.Second)
+
+		var canceled *properties.CanceledError
+		require.ErrorAs(t, err, &canceled)
+		assert.Equal(t, properties.SUploading, canceled.Stage)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, errors.Retry(err))
+		require.Len(t, deleted, 1)
+		assert.True(t, strings.HasPrefix(deleted[0], "container/database_table_"), deleted[0])
+	})
+
+	t.Run("Queue post", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		err := newIngestion(nil).IngestBlob(ctx, "https://account.blob.core.windows.net/container/blob", 0, props)
+
+		var canceled *properties.CanceledError
+		require.ErrorAs(t, err, &canceled)
+		assert.Equal(t, properties.SQueued, canceled.Stage)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	i := &Ingestion{
+		db:    "database",
+		table: "table",
+		uploadStream: func(context.Context, io.Reader, *azblob.Client, string, string, *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error) {
+			t.Error("a dry run uploaded a reader")
+			return azblob.UploadStreamResponse{}, nil
+		},
+		uploadBlob: func(context.Context, *os.File, *azblob.Client, string, string, *azblob.UploadFileOptions) (azblob.UploadFileResponse, error) {
+			t.Error("a dry run uploaded a file")
+			return azblob.UploadFileResponse{}, nil
+		},
+		mgr: newFakeResourceManager(
+			[]string{"https://account.blob.core.windows.net/container?sig=secret"},
+			[]string{"https://account.queue.core.windows.net/queue"},
+			nil,
+		),
+	}
+	newProps := func() properties.All {
+		return properties.All{
+			Ingestion: properties.Ingestion{
+				DatabaseName: "database",
+				TableName:    "table",
+				Additional:   properties.Additional{Format: properties.CSV, AuthContext: "authorization"},
+			},
+			Source: properties.SourceOptions{Preview: &properties.Preview{}, Metrics: &properties.Metrics{}},
+		}
+	}
+
+	t.Run("Reader", func(t *testing.T) {
+		t.Parallel()
+
+		props := newProps()
+		blobURL, size, err := i.UploadReaderToBlob(t.Context(), strings.NewReader("a,b\nc,d\n"), props)
+		require.NoError(t, err)
+		assert.Equal(t, int64(8), size)
+
+		preview := props.Source.Preview
+		assert.True(t, strings.HasPrefix(blobURL, "https://account.blob.core.windows.net/container/database_table_"), blobURL)
+		assert.True(t, strings.HasSuffix(blobURL, ".gz?sig=secret"), blobURL)
+		assert.Equal(t, blobURL, preview.BlobURL)
+		assert.True(t, preview.Compressed)
+		assert.Equal(t, props.Source.Metrics.CompressedBytes, preview.BlobSize)
+		assert.Equal(t, map[string]string{"rawSize": "8", "compressedSize": strconv.FormatInt(preview.BlobSize, 10)}, preview.Metadata)
+		assert.Len(t, preview.ContentMD5, md5.Size)
+
+		require.NoError(t, i.IngestBlob(t.Context(), blobURL, size, props))
+		var message map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(preview.Message), &message))
+		assert.Equal(t, blobURL, message["BlobPath"])
+		assert.Equal(t, "database", message["DatabaseName"])
+		assert.EqualValues(t, 8, message["RawDataSize"])
+		assert.Equal(t, "authorization", message["AdditionalProperties"].(map[string]interface{})["authorizationContext"])
+	})
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.csv")
+		require.NoError(t, os.WriteFile(path, []byte("a,b\n"), 0o600))
+		props := newProps()
+		props.Source.DeleteLocalSource = true
+		props.Source.DontCompress = true
+
+		blobURL, size, err := i.UploadLocalToBlob(t.Context(), path, props)
+		require.NoError(t, err)
+		require.NoError(t, i.IngestBlob(t.Context(), blobURL, size, props))
+
+		preview := props.Source.Preview
+		assert.True(t, strings.HasSuffix(blobURL, "_data.csv.csv?sig=secret"), blobURL)
+		assert.False(t, preview.Compressed)
+		assert.Equal(t, int64(4), preview.BlobSize)
+		assert.Nil(t, preview.Metadata)
+		assert.Nil(t, preview.ContentMD5)
+		assert.Contains(t, preview.Message, `"BlobPath":"`+blobURL+`"`)
+		assert.FileExists(t, path, "a dry run doesn't delete the source")
+	})
+}
@@ -70,6 +70,18 @@ func (r *RankedStorageAccountSet) getStorageAccount(accountName string) (*Ranked
 	return account, ok
 }
 
+// getAccounts returns the accounts, in no particular order.
+func (r *RankedStorageAccountSet) getAccounts() []RankedStorageAccount {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	accounts := make([]RankedStorageAccount, 0, len(r.accounts))
+	for _, account := range r.accounts {
+		accounts = append(accounts, *account)
+	}
+	return accounts
+}
+
 func (r *RankedStorageAccountSet) getRankedShuffledAccounts() []RankedStorageAccount {
 	r.lock.Lock()
 	defer r.lock.Unlock()
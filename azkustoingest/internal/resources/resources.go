@@ -18,6 +18,7 @@ import (
 
 	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
 	"github.com/cenkalti/backoff/v4"
 )
 
@@ -26,6 +27,13 @@ const (
 	defaultMultiplier      = 2
 	retryCount             = 4
 	fetchInterval          = 1 * time.Hour
+	// fetchRetryInterval is how long a failed fetch of the resources waits before it's retried.
+	fetchRetryInterval = 10 * time.Second
+	// refreshMargin is how long before they expire the resources and the authorization context are refreshed, so a SAS or a
+	// token is never used close to its expiry.
+	refreshMargin = 5 * time.Minute
+	// authContextTTL is how long the authorization context is cached for.
+	authContextTTL = 1 * time.Hour
 )
 
 // mgmter is a private interface that allows us to write hermetic tests against the azkustodata.Client.Mgmt() method.
@@ -108,39 +116,57 @@ type token struct {
 
 type ResourcesManager interface {
 	ReportStorageResourceResult(string, bool)
-	GetRankedStorageContainers() ([]*URI, error)
-	GetRankedStorageQueues() ([]*URI, error)
-	GetTables() ([]*URI, error)
+	GetRankedStorageContainers(ctx context.Context) ([]*URI, error)
+	GetRankedStorageQueues(ctx context.Context) ([]*URI, error)
+	GetTables(ctx context.Context) ([]*URI, error)
 	Close()
 }
 
 // Manager manages Kusto resources.
 type Manager struct {
-	client                   mgmter
-	done                     chan struct{}
-	resources                atomic.Value // Stores Ingestion
-	lastFetchTime            atomic.Value // Stores time.Time
-	kustoToken               token
-	authTokenCacheExpiration time.Time
-	authLock                 sync.Mutex
-	fetchLock                sync.Mutex
-	rankedStorageAccount     *RankedStorageAccountSet
+	client               mgmter
+	done                 chan struct{}
+	resources            atomic.Value            // Stores Ingestion
+	lastFetchTime        atomic.Value            // Stores time.Time
+	expiresAt            atomic.Value            // Stores time.Time, the earliest expiry of the SAS of the resources, if any
+	authContexts         map[string]*authContext // By database, guarded by authLock
+	authLock             sync.Mutex
+	fetchLock            sync.Mutex
+	rankedStorageAccount *RankedStorageAccountSet
+	selection            Selection
+	excludeAccount       func(account string) bool
+	containerSelector    selector
+	queueSelector        selector
+	logger               logger.Logger
+	retryHook            func(ctx context.Context, retry int, err error)
+	retryInterval        time.Duration
 }
 
 var _ ResourcesManager = (*Manager)(nil)
 
 // New is the constructor for Manager.
-func New(client mgmter) (*Manager, error) {
-	m := &Manager{client: client, done: make(chan struct{}), rankedStorageAccount: newDefaultRankedStorageAccountSet()}
+func New(client mgmter, options ...Option) (*Manager, error) {
+	m := &Manager{client: client, done: make(chan struct{}), rankedStorageAccount: newDefaultRankedStorageAccountSet(),
+		retryInterval: fetchRetryInterval}
+	for _, o := range options {
+		o(m)
+	}
+	if m.selection < SelectRanked || m.selection > SelectRandom {
+		return nil, kustoErrors.ES(kustoErrors.OpFileIngest, kustoErrors.KClientArgs, "unknown resource selection strategy %d", m.selection).SetNoRetry()
+	}
 	m.authLock = sync.Mutex{}
 	m.fetchLock = sync.Mutex{}
 
-	m.authTokenCacheExpiration = time.Now().UTC()
 	go m.renewResources()
 
 	return m, nil
 }
 
+// log returns the logger of the manager, given with WithLogger, or the default logger.
+func (m *Manager) log() logger.Logger {
+	return logger.OrDefault(m.logger)
+}
+
 // Close closes the manager. This stops any token refreshes.
 func (m *Manager) Close() {
 	for {
@@ -158,16 +184,17 @@ func (m *Manager) renewResources() {
 	tickDuration := 30 * time.Second
 
 	tick := time.NewTicker(tickDuration)
-	count := fetchInterval // Start with a fetch immediately.
 
 	for {
 		select {
 		case <-tick.C:
-			count += tickDuration
-			if count >= fetchInterval {
-				count = 0 * time.Second
-				m.fetchRetry(context.Background())
+			now := time.Now().UTC()
+			if m.refreshDue(now) {
+				if err := m.fetchRetry(context.Background()); err != nil {
+					m.log().Error("could not refresh the ingestion resources; ingestions fetch them again once they are stale", "error", err)
+				}
 			}
+			m.renewAuthContext(now)
 		case <-m.done:
 			tick.Stop()
 			return
@@ -175,20 +202,106 @@ func (m *Manager) renewResources() {
 	}
 }
 
-// AuthContext returns a string representing the authorization context. This auth token is a temporary token
-// that can be used to write a message via ingestion.  This is different than the ADAL token.
-func (m *Manager) AuthContext(ctx context.Context) (string, error) {
+// refreshDue reports whether the resources must be fetched again: hourly, or earlier if the SAS of one of them expires before.
+func (m *Manager) refreshDue(now time.Time) bool {
+	lastFetchTime, ok := m.lastFetchTime.Load().(time.Time)
+	if !ok {
+		return true
+	}
+	expiresAt, _ := m.expiresAt.Load().(time.Time)
+	return !now.Before(nextRefresh(lastFetchTime, expiresAt))
+}
+
+// nextRefresh returns when resources fetched at fetched, whose SAS expire at expiresAt, or never if it is zero, must be fetched
+// again.
+func nextRefresh(fetched time.Time, expiresAt time.Time) time.Time {
+	next := fetched.Add(fetchInterval)
+	if !expiresAt.IsZero() && expiresAt.Add(-refreshMargin).Before(next) {
+		next = expiresAt.Add(-refreshMargin)
+	}
+	return next
+}
+
+// authContext is the cached authorization context of a database. Each database has its own lock, so fetching the context of one
+// doesn't wait for the others.
+type authContext struct {
+	lock      sync.Mutex
+	token     token
+	expiresAt time.Time
+}
+
+// authContextOf returns the cache entry of the database, adding it if needed.
+func (m *Manager) authContextOf(db string) *authContext {
 	m.authLock.Lock()
 	defer m.authLock.Unlock()
-	if m.authTokenCacheExpiration.After(time.Now().UTC()) {
-		return m.kustoToken.AuthContext, nil
+	if m.authContexts == nil {
+		m.authContexts = map[string]*authContext{}
 	}
+	a, ok := m.authContexts[db]
+	if !ok {
+		a = &authContext{}
+		m.authContexts[db] = a
+	}
+	return a
+}
+
+// renewAuthContext fetches the authorization contexts of the databases again before they expire, if they were fetched before, so
+// ingestions don't wait for them. The contexts are renewed concurrently.
+func (m *Manager) renewAuthContext(now time.Time) {
+	m.authLock.Lock()
+	entries := make(map[string]*authContext, len(m.authContexts))
+	for db, a := range m.authContexts {
+		entries[db] = a
+	}
+	m.authLock.Unlock()
+
+	var wg sync.WaitGroup
+	for db, a := range entries {
+		wg.Add(1)
+		go func(db string, a *authContext) {
+			defer wg.Done()
+			a.lock.Lock()
+			defer a.lock.Unlock()
+			if a.token.AuthContext == "" || a.expiresAt.Add(-refreshMargin).After(now) {
+				return
+			}
+			// On failure, the cached context is kept until it expires, and AuthContext fetches it then.
+			if err := m.fetchAuthContext(context.Background(), db, a); err != nil {
+				m.log().Warn("could not renew the authorization context of a database; it is fetched again once it expires",
+					"database", db, "expiresAt", a.expiresAt, "error", err)
+			}
+		}(db, a)
+	}
+	wg.Wait()
+}
 
+// AuthContext returns a string representing the authorization context of the ingestions into the database. This auth token is a
+// temporary token that can be used to write a message via ingestion.  This is different than the ADAL token.
+// The contexts are cached per database, and an empty db uses the default database of the cluster.
+func (m *Manager) AuthContext(ctx context.Context, db string) (string, error) {
+	a := m.authContextOf(db)
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.expiresAt.After(time.Now().UTC()) {
+		return a.token.AuthContext, nil
+	}
+
+	if err := m.fetchAuthContext(ctx, db, a); err != nil {
+		return "", err
+	}
+	return a.token.AuthContext, nil
+}
+
+// fetchAuthContext fetches the authorization context of the database, and caches it in a. a.lock must be held.
+func (m *Manager) fetchAuthContext(ctx context.Context, db string, a *authContext) error {
+	if db == "" {
+		db = "NetDefaultDB"
+	}
 	var dataset v1.Dataset
 	retryCtx := backoff.WithContext(initBackoff(), ctx)
 	err := backoff.Retry(func() error {
 		var err error
-		dataset, err = m.client.Mgmt(ctx, "NetDefaultDB", kql.New(".get kusto identity token"))
+		dataset, err = m.client.Mgmt(ctx, db, kql.New(".get kusto identity token"))
 		if err == nil {
 			return nil
 		}
@@ -202,24 +315,24 @@ func (m *Manager) AuthContext(ctx context.Context) (string, error) {
 	}, retryCtx)
 
 	if err != nil {
-		return "", fmt.Errorf("problem getting authorization context from Kusto via Mgmt: %s", err)
+		return fmt.Errorf("problem getting authorization context from Kusto via Mgmt: %s", err)
 	}
 
 	tokens, err := query.ToStructs[token](dataset)
 	if err != nil {
-		return "", err
+		return err
 	}
 	if tokens == nil {
-		return "", fmt.Errorf("call for AuthContext returned no Rows")
+		return fmt.Errorf("call for AuthContext returned no Rows")
 	}
 
 	if len(tokens) != 1 {
-		return "", fmt.Errorf("call for AuthContext returned more than 1 Row")
+		return fmt.Errorf("call for AuthContext returned more than 1 Row")
 	}
 
-	m.kustoToken = tokens[0]
-	m.authTokenCacheExpiration = time.Now().UTC().Add(time.Hour)
-	return tokens[0].AuthContext, nil
+	a.token = tokens[0]
+	a.expiresAt = time.Now().UTC().Add(authContextTTL)
+	return nil
 }
 
 // ingestResc represents a kusto Mgmt() record about a resource
@@ -238,9 +351,29 @@ type Ingestion struct {
 	Containers []*URI
 	// Tables contains URIs for table resources.
 	Tables []*URI
+	// FailedQueues and SuccessfulQueues contain URIs for the queues the service reports ingestion statuses to.
+	FailedQueues     []*URI
+	SuccessfulQueues []*URI
 	//
 }
 
+// expiresAt returns the earliest expiry of the SAS of the resources, or zero if none of them has one.
+func (i *Ingestion) expiresAt() time.Time {
+	var earliest time.Time
+	for _, group := range [][]*URI{i.Containers, i.Queues, i.Tables, i.FailedQueues, i.SuccessfulQueues} {
+		for _, u := range group {
+			se, err := time.Parse(time.RFC3339, u.SAS().Get("se"))
+			if err != nil {
+				continue
+			}
+			if earliest.IsZero() || se.Before(earliest) {
+				earliest = se
+			}
+		}
+	}
+	return earliest
+}
+
 var errDoNotCare = errors.New("don't care about this")
 
 func (i *Ingestion) importRec(rec ingestResc, rankedStorageAccounts *RankedStorageAccountSet) error {
@@ -258,6 +391,10 @@ func (i *Ingestion) importRec(rec ingestResc, rankedStorageAccounts *RankedStora
 		rankedStorageAccounts.registerStorageAccount(u.Account())
 	case "IngestionsStatusTable":
 		i.Tables = append(i.Tables, u)
+	case "FailedIngestionsQueue":
+		i.FailedQueues = append(i.FailedQueues, u)
+	case "SuccessfulIngestionsQueue":
+		i.SuccessfulQueues = append(i.SuccessfulQueues, u)
 	default:
 		return errDoNotCare
 	}
@@ -272,31 +409,26 @@ func groupResourcesByStorageAccount(resources []*URI, rankedStorageAccount []Ran
 		storageAccounts[resource.Account()] = append(storageAccounts[resource.Account()], resource)
 	}
 
-	// Rank the resources by storage account.
-	var rankedResources []*URI
+	// Count the resources of the ranked storage accounts.
+	rankedResources := 0
 	for _, account := range rankedStorageAccount {
-		if resources, ok := storageAccounts[account.getAccountName()]; ok {
-			rankedResources = append(rankedResources, resources...)
-		}
+		rankedResources += len(storageAccounts[account.getAccountName()])
 	}
 
-	//Distribute the resources by round robin.
+	// Distribute the resources by round robin: the first resource of each account, in rank order, then the second one of each,
+	// and so on, so consecutive attempts use different accounts.
 	var distributedResources []*URI
-	for i := 0; i < len(rankedResources); i++ {
-		distributedResources = append(distributedResources, rankedResources[i%len(rankedResources)])
+	for i := 0; len(distributedResources) < rankedResources; i++ {
+		for _, account := range rankedStorageAccount {
+			if resources := storageAccounts[account.getAccountName()]; i < len(resources) {
+				distributedResources = append(distributedResources, resources[i])
+			}
+		}
 	}
 
 	return distributedResources
 }
 
-func (i *Ingestion) getRankedStorageContainers(rankedStorageAccounts []RankedStorageAccount) []*URI {
-	return groupResourcesByStorageAccount(i.Containers, rankedStorageAccounts)
-}
-
-func (i *Ingestion) getRankedStorageQueues(rankedStorageAccounts []RankedStorageAccount) []*URI {
-	return groupResourcesByStorageAccount(i.Queues, rankedStorageAccounts)
-}
-
 // fetch makes a azkustodata.Client.Mgmt() call to retrieve the resources used for Ingestion.
 func (m *Manager) fetch(ctx context.Context) error {
 	m.fetchLock.Lock()
@@ -341,7 +473,7 @@ func (m *Manager) fetch(ctx context.Context) error {
 	}
 
 	m.resources.Store(ingest)
-
+	m.expiresAt.Store(ingest.expiresAt())
 	m.lastFetchTime.Store(time.Now().UTC())
 
 	return nil
@@ -357,17 +489,33 @@ func (m *Manager) fetchRetry(ctx context.Context) error {
 		default:
 		}
 
-		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		err := m.fetch(ctx)
+		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err := m.fetch(fetchCtx)
 		cancel()
 		if err != nil {
 			attempts++
 			if attempts > retryCount {
 				return fmt.Errorf("failed to fetch ingestion resources: %w", err)
 			}
-			time.Sleep(10 * time.Second)
+			m.log().Warn("could not fetch the ingestion resources, retrying", "attempt", attempts, "error", err)
+			wait := time.NewTimer(m.retryInterval)
+			select {
+			case <-wait.C:
+			case <-ctx.Done():
+				wait.Stop()
+				return fmt.Errorf("failed to fetch ingestion resources: %w (the last attempt failed with: %v)", ctx.Err(), err)
+			case <-m.done:
+				wait.Stop()
+				return nil
+			}
+			if m.retryHook != nil {
+				m.retryHook(ctx, attempts, err)
+			}
 			continue
 		}
+		if attempts > 0 {
+			m.log().Info("fetched the ingestion resources after failures", "attempts", attempts+1)
+		}
 		return nil
 	}
 }
@@ -380,11 +528,14 @@ func initBackoff() backoff.BackOff {
 }
 
 // Resources returns information about the ingestion resources. This will used cached information instead
-// of fetching from source.
-func (m *Manager) getResources() (Ingestion, error) {
+// of fetching from source, unless the background refresh failed for long enough that the resources, or their SAS, are stale.
+// The fetch, and the waits between its retries, stop once ctx is done.
+func (m *Manager) getResources(ctx context.Context) (Ingestion, error) {
+	now := time.Now().UTC()
 	lastFetchTime, ok := m.lastFetchTime.Load().(time.Time)
-	if !ok || lastFetchTime.Add(2*fetchInterval).Before(time.Now().UTC()) {
-		err := m.fetchRetry(context.Background())
+	expiresAt, _ := m.expiresAt.Load().(time.Time)
+	if !ok || lastFetchTime.Add(2*fetchInterval).Before(now) || (!expiresAt.IsZero() && !now.Before(expiresAt)) {
+		err := m.fetchRetry(ctx)
 		if err != nil {
 			return Ingestion{}, err
 		}
@@ -402,28 +553,37 @@ func (m *Manager) ReportStorageResourceResult(accountName string, success bool)
 	m.rankedStorageAccount.addAccountResult(accountName, success)
 }
 
-// Get ranked containers
-func (m *Manager) GetRankedStorageContainers() ([]*URI, error) {
-	ingestionResources, err := m.getResources()
+// GetRankedStorageContainers returns the temporary containers, in the order of the selection strategy of the manager.
+func (m *Manager) GetRankedStorageContainers(ctx context.Context) ([]*URI, error) {
+	ingestionResources, err := m.getResources(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return ingestionResources.getRankedStorageContainers(m.rankedStorageAccount.getRankedShuffledAccounts()), nil
+	return m.order(ingestionResources.Containers, &m.containerSelector)
 }
 
-// get ranked queues
-func (m *Manager) GetRankedStorageQueues() ([]*URI, error) {
-	ingestionResources, err := m.getResources()
+// GetRankedStorageQueues returns the aggregation queues, in the order of the selection strategy of the manager.
+func (m *Manager) GetRankedStorageQueues(ctx context.Context) ([]*URI, error) {
+	ingestionResources, err := m.getResources(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return ingestionResources.getRankedStorageQueues(m.rankedStorageAccount.getRankedShuffledAccounts()), nil
+	return m.order(ingestionResources.Queues, &m.queueSelector)
 }
 
-func (m *Manager) GetTables() ([]*URI, error) {
-	ingestionResources, err := m.getResources()
+func (m *Manager) GetTables(ctx context.Context) ([]*URI, error) {
+	ingestionResources, err := m.getResources(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return ingestionResources.Tables, nil
 }
+
+// GetStatusQueues returns the queues the service reports failed and successful ingestions to.
+func (m *Manager) GetStatusQueues(ctx context.Context) (failed []*URI, successful []*URI, err error) {
+	ingestionResources, err := m.getResources(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ingestionResources.FailedQueues, ingestionResources.SuccessfulQueues, nil
+}
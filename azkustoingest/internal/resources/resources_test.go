@@ -1,12 +1,19 @@
 package resources
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log/slog"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-kusto-go/azkustodata"
 	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
@@ -140,7 +147,7 @@ func TestAuthContext(t *testing.T) {
 			t.Parallel()
 			manager := &Manager{client: test.fakeMgmt}
 
-			got, err := manager.AuthContext(context.Background())
+			got, err := manager.AuthContext(context.Background(), "")
 
 			if test.err {
 				assert.Error(t, err)
@@ -154,6 +161,131 @@ func TestAuthContext(t *testing.T) {
 	}
 }
 
+// authByDatabase returns an authorization context per database, and blocks the fetches of the database "slow" until release is
+// closed.
+type authByDatabase struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (a *authByDatabase) Mgmt(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+	a.mu.Lock()
+	a.calls[db]++
+	a.mu.Unlock()
+	if db == "slow" {
+		select {
+		case <-a.started:
+		default:
+			close(a.started)
+		}
+		<-a.release
+	}
+	return FakeAuthContext([]value.Values{{value.NewString("token-" + db)}}, false).Mgmt(ctx, db, query, options...)
+}
+
+func (a *authByDatabase) callsOf(db string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls[db]
+}
+
+func TestAuthContextPerDatabase(t *testing.T) {
+	t.Parallel()
+
+	fake := &authByDatabase{calls: map[string]int{}, started: make(chan struct{}), release: make(chan struct{})}
+	manager := &Manager{client: fake}
+
+	// A slow fetch for one database doesn't hold the others.
+	slow := make(chan string)
+	go func() {
+		got, _ := manager.AuthContext(context.Background(), "slow")
+		slow <- got
+	}()
+	<-fake.started
+
+	for _, db := range []string{"db1", "db2", "db1"} {
+		got, err := manager.AuthContext(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, "token-"+db, got)
+	}
+	got, err := manager.AuthContext(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "token-NetDefaultDB", got)
+
+	close(fake.release)
+	assert.Equal(t, "token-slow", <-slow)
+	for _, db := range []string{"db1", "db2", "slow", "NetDefaultDB"} {
+		assert.Equal(t, 1, fake.callsOf(db), db)
+	}
+
+	// Every cached context is renewed before it expires.
+	manager.renewAuthContext(time.Now().UTC().Add(authContextTTL))
+	for _, db := range []string{"db1", "db2", "slow", "NetDefaultDB"} {
+		assert.Equal(t, 2, fake.callsOf(db), db)
+	}
+}
+
+// failingMgmt fails every command.
+type failingMgmt struct{}
+
+func (failingMgmt) Mgmt(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+	return nil, errors.New("the cluster is unavailable")
+}
+
+func TestRenewAuthContextLogsFailures(t *testing.T) {
+	t.Parallel()
+
+	logs := &bytes.Buffer{}
+	manager := &Manager{client: failingMgmt{}}
+	WithLogger(slog.New(slog.NewTextHandler(logs, nil)))(manager)
+	expiresAt := time.Now().UTC()
+	manager.authContexts = map[string]*authContext{"db": {token: token{AuthContext: "token"}, expiresAt: expiresAt}}
+
+	manager.renewAuthContext(expiresAt)
+
+	assert.Contains(t, logs.String(), "could not renew the authorization context of a database")
+	assert.Contains(t, logs.String(), "database=db")
+	assert.Contains(t, logs.String(), "the cluster is unavailable")
+	// The cached context is kept until it expires.
+	assert.Equal(t, "token", manager.authContexts["db"].token.AuthContext)
+}
+
+func TestFetchRetryStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	var retries []int
+	manager := &Manager{client: failingMgmt{}, done: make(chan struct{}), retryInterval: time.Hour,
+		rankedStorageAccount: newDefaultRankedStorageAccountSet()}
+	WithRetryHook(func(ctx context.Context, retry int, err error) {
+		retries = append(retries, retry)
+	})(manager)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := manager.GetRankedStorageContainers(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "the cluster is unavailable")
+	assert.Less(t, time.Since(start), time.Minute)
+	assert.Empty(t, retries)
+
+	// The retry hook gets the context of the caller, rather than the context of the attempt that failed.
+	manager.retryInterval = 0
+	WithRetryHook(func(ctx context.Context, retry int, err error) {
+		assert.NoError(t, ctx.Err())
+		assert.Equal(t, "value", ctx.Value(retryHookKey{}))
+		retries = append(retries, retry)
+	})(manager)
+	_, err = manager.GetRankedStorageQueues(context.WithValue(context.Background(), retryHookKey{}, "value"))
+	require.Error(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4}, retries)
+}
+
+type retryHookKey struct{}
+
 func mustParse(s string) *URI {
 	u, err := Parse(s)
 	if err != nil {
@@ -218,18 +350,154 @@ func TestResources(t *testing.T) {
 
 			assert.NoError(t, err)
 
-			got, err := manager.getResources()
+			got, err := manager.getResources(context.Background())
 			assert.NoError(t, err)
 
 			assert.Equal(t, test.want, got)
 
-			containers, err := manager.GetRankedStorageContainers()
+			containers, err := manager.GetRankedStorageContainers(context.Background())
 			assert.NoError(t, err)
 			assert.Equal(t, test.want.Containers, containers)
 
-			queues, err := manager.GetRankedStorageQueues()
+			queues, err := manager.GetRankedStorageQueues(context.Background())
 			assert.NoError(t, err)
 			assert.Equal(t, test.want.Queues, queues)
 		})
 	}
 }
+
+func TestGroupResourcesByStorageAccount(t *testing.T) {
+	t.Parallel()
+
+	parse := func(u string) *URI {
+		uri, err := Parse(u)
+		assert.NoError(t, err)
+		return uri
+	}
+	a1 := parse("https://a.blob.core.windows.net/c1")
+	a2 := parse("https://a.blob.core.windows.net/c2")
+	b1 := parse("https://b.blob.core.windows.net/c1")
+	c1 := parse("https://c.blob.core.windows.net/c1")
+
+	now := func() int64 { return 0 }
+	ranked := []RankedStorageAccount{
+		*newRankedStorageAccount("b.blob.core.windows.net", 1, 1, now),
+		*newRankedStorageAccount("a.blob.core.windows.net", 1, 1, now),
+	}
+
+	// Consecutive resources are of different accounts, and the resources of unranked accounts are left out.
+	assert.Equal(t, []*URI{b1, a1, a2}, groupResourcesByStorageAccount([]*URI{a1, a2, b1, c1}, ranked))
+}
+
+func TestResourcesRefresh(t *testing.T) {
+	t.Parallel()
+
+	parse := func(u string) *URI {
+		uri, err := Parse(u)
+		assert.NoError(t, err)
+		return uri
+	}
+	ingest := Ingestion{
+		Containers: []*URI{parse("https://a.blob.core.windows.net/c1?se=2024-01-01T03%3A00%3A00Z&sig=x")},
+		Queues:     []*URI{parse("https://a.queue.core.windows.net/q1?se=2024-01-01T02%3A00%3A00Z&sig=x")},
+		Tables:     []*URI{parse("https://a.table.core.windows.net/t1?sig=x")},
+	}
+	expiresAt := ingest.expiresAt()
+	assert.Equal(t, time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC), expiresAt)
+	assert.True(t, (&Ingestion{}).expiresAt().IsZero())
+
+	fetched := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Without a SAS expiring before, resources are fetched hourly.
+	assert.Equal(t, fetched.Add(fetchInterval), nextRefresh(fetched, expiresAt))
+	assert.Equal(t, fetched.Add(fetchInterval), nextRefresh(fetched, time.Time{}))
+	// A SAS expiring within the hour is refreshed before it expires.
+	fetched = time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)
+	assert.Equal(t, expiresAt.Add(-refreshMargin), nextRefresh(fetched, expiresAt))
+}
+
+func TestSelection(t *testing.T) {
+	t.Parallel()
+
+	a1 := "https://a.blob.core.windows.net/c1"
+	a2 := "https://a.blob.core.windows.net/c2"
+	b1 := "https://b.blob.core.windows.net/c1"
+	newManager := func(t *testing.T, options ...Option) *Manager {
+		m := &Manager{
+			client: FakeResources([]value.Values{
+				{value.NewString("TempStorage"), value.NewString(a1)},
+				{value.NewString("TempStorage"), value.NewString(a2)},
+				{value.NewString("TempStorage"), value.NewString(b1)},
+				{value.NewString("SecuredReadyForAggregationQueue"), value.NewString("https://a.queue.core.windows.net/q1")},
+			}, false),
+			rankedStorageAccount: newDefaultRankedStorageAccountSet(),
+		}
+		for _, o := range options {
+			o(m)
+		}
+		require.NoError(t, m.fetch(context.Background()))
+		return m
+	}
+	containers := func(t *testing.T, m *Manager) []string {
+		uris, err := m.GetRankedStorageContainers(context.Background())
+		require.NoError(t, err)
+		var s []string
+		for _, u := range uris {
+			s = append(s, u.String())
+		}
+		return s
+	}
+
+	t.Run("Round robin", func(t *testing.T) {
+		t.Parallel()
+
+		m := newManager(t, WithSelection(SelectRoundRobin))
+		assert.Equal(t, []string{a1, b1, a2}, containers(t, m))
+		assert.Equal(t, []string{b1, a2, a1}, containers(t, m))
+		assert.Equal(t, []string{a2, a1, b1}, containers(t, m))
+		assert.Equal(t, []string{a1, b1, a2}, containers(t, m))
+		// The queues have their own cycle.
+		queues, err := m.GetRankedStorageQueues(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, queues, 1)
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		t.Parallel()
+
+		m := newManager(t, WithSelection(SelectRandom))
+		for i := 0; i < 10; i++ {
+			assert.ElementsMatch(t, []string{a1, a2, b1}, containers(t, m))
+		}
+	})
+
+	t.Run("Ranked prefers the healthy accounts", func(t *testing.T) {
+		t.Parallel()
+
+		m := newManager(t)
+		for i := 0; i < 10; i++ {
+			m.ReportStorageResourceResult("a.blob.core.windows.net", false)
+			m.ReportStorageResourceResult("b.blob.core.windows.net", true)
+		}
+		assert.Equal(t, []string{b1, a1, a2}, containers(t, m))
+	})
+
+	t.Run("Excluded accounts", func(t *testing.T) {
+		t.Parallel()
+
+		m := newManager(t, WithSelection(SelectRoundRobin), WithAccountFilter(func(account string) bool {
+			return account == "a.blob.core.windows.net"
+		}))
+		assert.Equal(t, []string{b1}, containers(t, m))
+
+		m = newManager(t, WithAccountFilter(func(string) bool { return true }))
+		_, err := m.GetRankedStorageContainers(context.Background())
+		assert.ErrorContains(t, err, "excludes all the")
+	})
+
+	t.Run("Unknown strategy", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := New(FakeResources(nil, false), WithSelection(Selection(7)))
+		assert.Error(t, err)
+	})
+}
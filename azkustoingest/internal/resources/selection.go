@@ -0,0 +1,104 @@
+package resources
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+
+	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
+)
+
+// Selection is the strategy that orders the temporary containers and the aggregation queues an ingestion tries.
+type Selection int
+
+const (
+	// SelectRanked orders the resources by the success rate of their storage accounts in the last minute, in tiers whose
+	// accounts are shuffled, and alternates the accounts, as the ranked policy of the .NET SDK. It is the default.
+	SelectRanked Selection = iota
+	// SelectRoundRobin orders the resources by storage account, alternating the accounts, and starts each ingestion at the
+	// resource after the first one of the previous ingestion, so that the ingestions are spread evenly.
+	SelectRoundRobin
+	// SelectRandom orders the resources at random for each ingestion.
+	SelectRandom
+)
+
+// Option is an optional argument to New.
+type Option func(m *Manager)
+
+// WithSelection sets the strategy that orders the resources of the ingestions.
+func WithSelection(selection Selection) Option {
+	return func(m *Manager) {
+		m.selection = selection
+	}
+}
+
+// WithAccountFilter sets a function that excludes the storage accounts it returns true for from the resources of the
+// ingestions. account is the host name of the account, such as "account.blob.core.windows.net".
+func WithAccountFilter(exclude func(account string) bool) Option {
+	return func(m *Manager) {
+		m.excludeAccount = exclude
+	}
+}
+
+// WithLogger sets the logger of the failures of the background refreshes of the resources and of the authorization contexts,
+// which aren't returned to any caller. Defaults to slog.Default().
+func WithLogger(l logger.Logger) Option {
+	return func(m *Manager) {
+		m.logger = l
+	}
+}
+
+// WithRetryHook sets a function that is called before each retry of a failed fetch of the resources, with the number of the
+// retry and the error of the fetch it retries.
+func WithRetryHook(hook func(ctx context.Context, retry int, err error)) Option {
+	return func(m *Manager) {
+		m.retryHook = hook
+	}
+}
+
+// selector orders the resources of a kind, containers or queues, with the strategy of the manager.
+type selector struct {
+	// next is the number of orderings made, for SelectRoundRobin.
+	next atomic.Uint64
+}
+
+// order returns the resources in the order of the strategy of the manager, without the resources of the excluded accounts.
+func (m *Manager) order(resources []*URI, s *selector) ([]*URI, error) {
+	var accounts []RankedStorageAccount
+	if m.selection == SelectRanked {
+		accounts = m.rankedStorageAccount.getRankedShuffledAccounts()
+	} else {
+		accounts = m.rankedStorageAccount.getAccounts()
+		sort.Slice(accounts, func(i, j int) bool { return accounts[i].getAccountName() < accounts[j].getAccountName() })
+	}
+
+	if m.excludeAccount != nil {
+		kept := accounts[:0]
+		for _, a := range accounts {
+			if !m.excludeAccount(a.getAccountName()) {
+				kept = append(kept, a)
+			}
+		}
+		if len(kept) == 0 && len(accounts) > 0 {
+			return nil, kustoErrors.ES(kustoErrors.OpFileIngest, kustoErrors.KBlobstore,
+				"the account filter excludes all the %d storage accounts of the cluster", len(accounts)).SetNoRetry()
+		}
+		accounts = kept
+	}
+
+	ordered := groupResourcesByStorageAccount(resources, accounts)
+	switch m.selection {
+	case SelectRoundRobin:
+		if n := len(ordered); n > 0 {
+			start := int((s.next.Add(1) - 1) % uint64(n))
+			ordered = append(append(make([]*URI, 0, n), ordered[start:]...), ordered[:start]...)
+		}
+	case SelectRandom:
+		rand.Shuffle(len(ordered), func(i, j int) {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	}
+	return ordered, nil
+}
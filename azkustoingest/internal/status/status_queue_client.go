@@ -0,0 +1,88 @@
+package status
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azqueue"
+)
+
+// QueueMessage is a message of a status queue.
+type QueueMessage struct {
+	ID         string
+	PopReceipt string
+	// Text is the content of the message, decoded from base64 if it was encoded.
+	Text []byte
+}
+
+// QueueReader reads the messages of a status queue.
+type QueueReader interface {
+	// Receive returns up to max messages of the queue, which are hidden from other readers until Delete is called or they are
+	// visible again.
+	Receive(ctx context.Context, max int32) ([]QueueMessage, error)
+	// Delete deletes a message returned by Receive.
+	Delete(ctx context.Context, msg QueueMessage) error
+}
+
+// QueueClient reads the messages of a status queue of the cluster.
+type QueueClient struct {
+	queueURI resources.URI
+	client   *azqueue.QueueClient
+}
+
+var _ QueueReader = (*QueueClient)(nil)
+
+// NewQueueClient creates a client of the status queue, authorized with the SAS of its URI.
+func NewQueueClient(client policy.Transporter, uri resources.URI) (*QueueClient, error) {
+	u := uri.URL()
+	service, err := azqueue.NewServiceClientWithNoCredential(fmt.Sprintf("%s://%s?%s", u.Scheme, u.Host, uri.SAS().Encode()), &azqueue.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Transport: client,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueClient{
+		queueURI: uri,
+		client:   service.NewQueueClient(uri.ObjectName()),
+	}, nil
+}
+
+// Receive implements QueueReader.Receive.
+func (c *QueueClient) Receive(ctx context.Context, max int32) ([]QueueMessage, error) {
+	resp, err := c.client.DequeueMessages(ctx, &azqueue.DequeueMessagesOptions{NumberOfMessages: to.Ptr(max)})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]QueueMessage, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		if m == nil || m.MessageID == nil || m.PopReceipt == nil {
+			continue
+		}
+		text := ""
+		if m.MessageText != nil {
+			text = *m.MessageText
+		}
+		// The service encodes the messages in base64.
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			decoded = []byte(text)
+		}
+		messages = append(messages, QueueMessage{ID: *m.MessageID, PopReceipt: *m.PopReceipt, Text: decoded})
+	}
+	return messages, nil
+}
+
+// Delete implements QueueReader.Delete.
+func (c *QueueClient) Delete(ctx context.Context, msg QueueMessage) error {
+	_, err := c.client.DeleteMessage(ctx, msg.ID, msg.PopReceipt, nil)
+	return err
+}
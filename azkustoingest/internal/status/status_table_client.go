@@ -3,11 +3,13 @@ package status
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/aztables"
 	"github.com/google/uuid"
+	"net/http"
 	"time"
 )
 
@@ -63,7 +65,7 @@ func (c *TableClient) Read(ctx context.Context, ingestionSourceID string) (map[s
 	return m, nil
 }
 
-// Write reads a table record containing ingestion status.
+// Write writes a table record containing ingestion status, replacing the record of the source if there is one.
 func (c *TableClient) Write(ctx context.Context, ingestionSourceID string, data map[string]interface{}) error {
 	ctx, cancel := context.WithTimeout(ctx, defaultTimeoutSeconds*time.Second)
 	defer cancel()
@@ -82,5 +84,11 @@ func (c *TableClient) Write(ctx context.Context, ingestionSourceID string, data
 		Format: &format,
 	})
 
+	// A source ID given by the caller, such as for a replayed job, may already have a record, which is reset.
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusConflict {
+		_, err = c.client.UpsertEntity(ctx, bytes, &aztables.UpsertEntityOptions{UpdateMode: aztables.UpdateModeReplace})
+	}
+
 	return err
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
 	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
@@ -27,9 +29,13 @@ const (
 	retryCount             = 2
 )
 
+// Managed ingests data with streaming ingestion, retrying transient errors, and falls back to queued ingestion when the data
+// is over the streaming size limit, when streaming is throttled, or when streaming ingestion is disabled for the table.
 type Managed struct {
 	queued    *Ingestion
 	streaming *Streaming
+	// pending tracks the ingestions in progress, for Shutdown.
+	pending inflight
 }
 
 // NewManaged is a constructor for Managed.
@@ -63,9 +69,17 @@ func newManagedFromClients(queued *Ingestion, streaming *Streaming) *Managed {
 	}
 }
 
+// streamingUnavailableCodes are parts of the codes of the errors that make streaming ingestion fail for a table, while queued
+// ingestion into it still works.
+var streamingUnavailableCodes = []string{
+	"StreamingIngestionPolicyNotEnabled",
+	"StreamingIngestionDisabled",
+	"TooLarge",
+}
+
 // Attempts to stream with retries, on success - return res,nil.
 // If failed permanently - return err,nil.
-// If failed transiently - return nil,nil.
+// If failed transiently, or streaming is unavailable for the table - return nil,nil.
 func (m *Managed) streamWithRetries(ctx context.Context, payloadProvider func() io.Reader, props properties.All, isBlobUri bool) (*Result, error) {
 	var result *Result
 
@@ -75,32 +89,41 @@ func (m *Managed) streamWithRetries(ctx context.Context, payloadProvider func()
 
 	actualBackoff := backoff.WithContext(backoff.WithMaxRetries(props.ManagedStreaming.Backoff, retryCount), ctx)
 
+	unavailable := false
 	var err error = nil
-	err = backoff.Retry(func() error {
+	err = backoff.RetryNotify(func() error {
 		if !hasCustomId {
 			props.Streaming.ClientRequestId = fmt.Sprintf("KGC.executeManagedStreamingIngest;%s;%d", managedUuid, i)
 		}
 		result, err = streamImpl(m.streaming.streamConn, ctx, payloadProvider(), props, isBlobUri)
 		i++
 		if err != nil {
-			if e, ok := err.(*errors.Error); ok {
-				if errors.Retry(e) {
-					return err
-				} else {
-					return backoff.Permanent(err)
-				}
-			} else {
+			if isStreamingUnavailable(err) {
+				// Retrying the same payload won't help, queued ingestion will take it instead.
+				unavailable = true
 				return backoff.Permanent(err)
 			}
+			if errors.Retry(err) {
+				return err
+			}
+			return backoff.Permanent(err)
 		}
 		return nil
-	}, actualBackoff)
+	}, actualBackoff, func(err error, _ time.Duration) {
+		if props.ManagedStreaming.OnRetry != nil {
+			props.ManagedStreaming.OnRetry(ctx, i, err)
+		}
+	})
 
 	if err == nil {
+		result.attempts = i
 		return result, nil
 	}
+	if ctx.Err() != nil {
+		return nil, properties.NewCanceledError(ctx, errors.OpIngestStream, properties.SStreaming)
+	}
 
-	if errors.Retry(err) {
+	if unavailable || errors.Retry(err) {
 		// Caller should fallback to queued
 		return nil, nil
 	}
@@ -108,12 +131,72 @@ func (m *Managed) streamWithRetries(ctx context.Context, payloadProvider func()
 	return nil, err
 }
 
-func (m *Managed) FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error) {
+// newStreamingBackoff returns the backoff between the retries of streaming ingestion.
+func newStreamingBackoff() *backoff.ExponentialBackOff {
+	exp := backoff.NewExponentialBackOff()
+	exp.InitialInterval = defaultInitialInterval
+	exp.Multiplier = defaultMultiplier
+	return exp
+}
+
+// isStreamingUnavailable reports whether the service rejected a streaming ingestion because of throttling, the size of the
+// payload, or because streaming ingestion is disabled for the table or the cluster.
+func isStreamingUnavailable(err error) bool {
+	httpErr, ok := err.(*errors.HttpError)
+	if !ok {
+		return false
+	}
+	if httpErr.IsThrottled() || httpErr.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+
+	rest, ok := httpErr.UnmarshalREST()["error"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, field := range []string{"code", "@type"} {
+		code, _ := rest[field].(string)
+		for _, c := range streamingUnavailableCodes {
+			if strings.Contains(code, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *Managed) FromFile(ctx context.Context, fPath string, options ...FileOption) (result *Result, err error) {
+	done, err := m.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := m.startSpan(ctx)
+	defer func() {
+		m.endSpan(span, result, err)
+		m.reportSlow(start, result, err)
+	}()
+
 	props := m.newProp()
 	file, err, local := prepFileAndProps(fPath, &props, options, ManagedClient)
 	if err != nil {
 		return nil, err
 	}
+	if err := ensureTable(ctx, m.queued.client, m.queued.tableCreators, &m.queued.createdTables, &props); err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+
+	if !canStream(&props) {
+		if file != nil {
+			file.Close()
+		}
+		return m.queued.reportMetrics(m.queued.fromFile(ctx, fPath, []FileOption{}, props))
+	}
 
 	if !local {
 		var size int64
@@ -135,15 +218,28 @@ func (m *Managed) FromFile(ctx context.Context, fPath string, options ...FileOpt
 		if !shouldUseQueuedIngestBySize(compressionTypeForEstimation, size) {
 			res, err := m.streamWithRetries(ctx, func() io.Reader { return generateBlobUriPayloadReader(fPath) }, props, true)
 			if err != nil || res != nil {
-				return res, err
+				return m.queued.reportMetrics(res, err)
 			}
 		}
 
-		return m.queued.fromFile(ctx, fPath, []FileOption{}, props)
+		return m.queued.reportMetrics(m.queued.fromFile(ctx, fPath, []FileOption{}, props))
+	}
+
+	// Streaming ingestion doesn't support zip files.
+	if queued.EffectiveCompressionType(&props, fPath) == ingestoptions.ZIP {
+		file.Close()
+		return m.queued.reportMetrics(m.queued.fromFile(ctx, fPath, []FileOption{}, props))
 	}
 
 	// No need to get local file size as we later use the compressed stream size
-	return m.managedStreamImpl(ctx, file, props)
+	return m.queued.reportMetrics(m.managedStreamImpl(ctx, file, props))
+}
+
+// canStream reports whether the data can be ingested with streaming ingestion, which supports neither the SStream format,
+// inline ingestion mappings nor skipping the first record. Other data is always ingested with queued ingestion.
+func canStream(props *properties.All) bool {
+	return props.Ingestion.Additional.Format.SupportsStreaming() && props.Ingestion.Additional.IngestionMapping == "" &&
+		!props.Ingestion.Additional.IgnoreFirstRecord
 }
 
 func shouldUseQueuedIngestBySize(compression ingestoptions.CompressionType, fileSize int64) bool {
@@ -155,7 +251,20 @@ func shouldUseQueuedIngestBySize(compression ingestoptions.CompressionType, file
 	return fileSize/utils.EstimatedCompressionFactor > maxStreamingSize
 }
 
-func (m *Managed) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+func (m *Managed) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (result *Result, err error) {
+	done, err := m.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := m.startSpan(ctx)
+	defer func() {
+		m.endSpan(span, result, err)
+		m.reportSlow(start, result, err)
+	}()
+
 	props := m.newProp()
 
 	for _, prop := range options {
@@ -164,17 +273,38 @@ func (m *Managed) FromReader(ctx context.Context, reader io.Reader, options ...F
 			return nil, err
 		}
 	}
+	if err := validateFormat(errors.OpFileIngest, &props, ""); err != nil {
+		return nil, err
+	}
+	if err := ensureTable(ctx, m.queued.client, m.queued.tableCreators, &m.queued.createdTables, &props); err != nil {
+		return nil, err
+	}
+	// Streaming ingestion doesn't support zip content, and data known to be over its limit is queued without reading it first.
+	if !canStream(&props) || props.Source.CompressionType == ingestoptions.ZIP ||
+		(props.Ingestion.RawDataSize > 0 && shouldUseQueuedIngestBySize(ingestoptions.CTNone, props.Ingestion.RawDataSize)) {
+		return m.queued.reportMetrics(m.queued.fromReader(ctx, reader, []FileOption{}, props))
+	}
 
-	return m.managedStreamImpl(ctx, io.NopCloser(reader), props)
+	return m.queued.reportMetrics(m.managedStreamImpl(ctx, io.NopCloser(reader), props))
 }
 
-func (m *Managed) managedStreamImpl(ctx context.Context, payload io.ReadCloser, props properties.All) (*Result, error) {
+func (m *Managed) managedStreamImpl(ctx context.Context, payload io.ReadCloser, props properties.All) (res *Result, err error) {
 	defer payload.Close()
-	compress := queued.ShouldCompress(&props, ingestoptions.CTUnknown)
-	var compressed io.Reader = payload
+	compress := streamCompress(&props, ingestoptions.CTUnknown)
+	raw := &properties.CountingReader{R: payload}
+	var compressed io.Reader = raw
 	if compress {
-		compressed = gzip.Compress(io.NopCloser(payload))
+		props.ReportStage(properties.SCompressing)
+		compressed = gzip.Compress(io.NopCloser(raw))
 		props.Source.DontCompress = true
+		props.Source.CompressionType = ingestoptions.GZIP
+
+		// The payload is compressed here, before it is streamed or uploaded, so the size of its data is counted here too.
+		defer func() {
+			if res != nil && res.metrics != nil {
+				res.metrics.RawBytes = raw.N()
+			}
+		}()
 	}
 
 	maxSize := maxStreamingSize
@@ -189,7 +319,7 @@ func (m *Managed) managedStreamImpl(ctx context.Context, payload io.ReadCloser,
 		return m.queued.fromReader(ctx, combinedBuf, []FileOption{}, props)
 	}
 
-	res, err := m.streamWithRetries(ctx, func() io.Reader { return bytes.NewReader(buf) }, props, false)
+	res, err = m.streamWithRetries(ctx, func() io.Reader { return bytes.NewReader(buf) }, props, false)
 	if err != nil || res != nil {
 		return res, err
 	}
@@ -200,21 +330,19 @@ func (m *Managed) managedStreamImpl(ctx context.Context, payload io.ReadCloser,
 }
 
 func (m *Managed) newProp() properties.All {
-	exp := backoff.NewExponentialBackOff()
-	exp.InitialInterval = defaultInitialInterval
-	exp.Multiplier = defaultMultiplier
-
 	return properties.All{
 		Ingestion: properties.Ingestion{
 			DatabaseName: m.streaming.db,
 			TableName:    m.streaming.table,
 		},
 		ManagedStreaming: properties.ManagedStreaming{
-			Backoff: exp,
+			Backoff: newStreamingBackoff(),
+			OnRetry: m.streaming.onRetry,
 		},
 	}
 }
 
+// Close waits for the ingestions in progress, and releases the client. Use Shutdown to bound the wait.
 func (m *Managed) Close() error {
-	return errors.CombineErrors(m.queued.Close(), m.streaming.Close())
+	return m.Shutdown(context.Background())
 }
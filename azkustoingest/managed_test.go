@@ -259,6 +259,93 @@ func TestManaged(t *testing.T) {
 			expectedCounter: 4,
 			expectedStatus:  Queued,
 		},
+		{
+			name:    "TestThrottled",
+			options: []FileOption{},
+			onStreamIngest: func(t *testing.T, ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string,
+				clientRequestId string, isBlobUri bool) error {
+				return errors.HTTP(errors.OpIngestStream, "429 Too Many Requests", 429, io.NopCloser(strings.NewReader(`{"error": {"code": "TooManyRequests", "@permanent": false}}`)), "streaming ingestion")
+			},
+			onMgmt: func(t *testing.T, ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+				// .get ingestion resources is always called in the ctor
+				if query.String() == ".get ingestion resources" {
+					return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+				}
+				if query.String() == ".get kusto identity token" {
+					return nil, nil
+				}
+
+				require.Fail(t, "Unexpected queued ingest call")
+				return nil, nil
+			},
+			onReader: func(t *testing.T, ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				counter++
+				all, err := io.ReadAll(reader)
+				assert.NoError(t, err)
+				assert.Equal(t, compressedBytes, all)
+				return "", 0, nil
+			},
+			expectedCounter: 2,
+			expectedStatus:  Queued,
+		},
+		{
+			name:    "TestStreamingDisabled",
+			options: []FileOption{},
+			onStreamIngest: func(t *testing.T, ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string,
+				clientRequestId string, isBlobUri bool) error {
+				return errors.HTTP(errors.OpIngestStream, "400 Bad Request", 400, io.NopCloser(strings.NewReader(`{"error": {"code": "BadRequest_StreamingIngestionPolicyNotEnabled", "@permanent": true}}`)), "streaming ingestion")
+			},
+			onMgmt: func(t *testing.T, ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+				// .get ingestion resources is always called in the ctor
+				if query.String() == ".get ingestion resources" {
+					return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+				}
+				if query.String() == ".get kusto identity token" {
+					return nil, nil
+				}
+
+				require.Fail(t, "Unexpected queued ingest call")
+				return nil, nil
+			},
+			onReader: func(t *testing.T, ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				counter++
+				all, err := io.ReadAll(reader)
+				assert.NoError(t, err)
+				assert.Equal(t, compressedBytes, all)
+				return "", 0, nil
+			},
+			expectedCounter: 2,
+			expectedStatus:  Queued,
+		},
+		{
+			name:    "TestPayloadTooLarge",
+			options: []FileOption{},
+			onStreamIngest: func(t *testing.T, ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string,
+				clientRequestId string, isBlobUri bool) error {
+				return errors.HTTP(errors.OpIngestStream, "413 Request Entity Too Large", 413, io.NopCloser(strings.NewReader(`{"error": {"code": "RequestEntityTooLarge", "@permanent": true}}`)), "streaming ingestion")
+			},
+			onMgmt: func(t *testing.T, ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+				// .get ingestion resources is always called in the ctor
+				if query.String() == ".get ingestion resources" {
+					return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+				}
+				if query.String() == ".get kusto identity token" {
+					return nil, nil
+				}
+
+				require.Fail(t, "Unexpected queued ingest call")
+				return nil, nil
+			},
+			onReader: func(t *testing.T, ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				counter++
+				all, err := io.ReadAll(reader)
+				assert.NoError(t, err)
+				assert.Equal(t, compressedBytes, all)
+				return "", 0, nil
+			},
+			expectedCounter: 2,
+			expectedStatus:  Queued,
+		},
 		{
 			name:      "TestBigFile",
 			options:   []FileOption{},
@@ -446,3 +533,46 @@ func initFile(t *testing.T, reader *bytes.Reader) ([]byte, []byte) {
 	require.NoError(t, err)
 	return data, compressedBytes
 }
+
+func TestManagedIgnoreFirstRecord(t *testing.T) {
+	t.Parallel()
+
+	client := mockClient{
+		endpoint: "https://test.kusto.windows.net",
+		onMgmt: func(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+			if query.String() == ".get kusto identity token" {
+				return nil, nil
+			}
+			return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+		},
+	}
+	ingestion, err := newFromClient(client, &Ingestion{db: "defaultDb", table: "defaultTable"})
+	require.NoError(t, err)
+
+	queued := 0
+	ingestion.fs = resources.FsMock{
+		OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+			queued++
+			assert.True(t, props.Ingestion.Additional.IgnoreFirstRecord)
+			return "https://some-blob.blob.core.windows.net/some-container/some-blob", 0, nil
+		},
+	}
+	managed := Managed{
+		queued: ingestion,
+		streaming: &Streaming{
+			db:     "defaultDb",
+			table:  "defaultTable",
+			client: client,
+			streamConn: fakeStreamIngestor{
+				onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+					require.Fail(t, "Streaming ingestion doesn't support skipping the first record")
+					return nil
+				},
+			},
+		},
+	}
+
+	_, err = managed.FromReader(context.Background(), strings.NewReader("Name,Count\na,1\n"), IgnoreFirstRecord())
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+}
@@ -0,0 +1,55 @@
+package azkustoingest
+
+import (
+	"strconv"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+)
+
+// ColumnMapping maps a field of the data to a column of the table, as an element of an inline ingestion mapping given with
+// IngestionMapping. See https://learn.microsoft.com/azure/data-explorer/kusto/management/mappings for the properties of each
+// mapping kind.
+type ColumnMapping struct {
+	Column string `json:"Column"`
+	// DataType is the type of the column, such as "string". It is only needed if the table doesn't have the column yet.
+	DataType string `json:"DataType,omitempty"`
+	// Properties locate the field in the data, such as "Ordinal" for CSV data, or "Path" for JSON, Parquet, ORC and Avro data.
+	Properties map[string]string `json:"Properties,omitempty"`
+}
+
+// CSVColumn maps the field at the zero based ordinal of CSV data to the column.
+func CSVColumn(column string, ordinal int) ColumnMapping {
+	return ColumnMapping{Column: column, Properties: map[string]string{"Ordinal": strconv.Itoa(ordinal)}}
+}
+
+// PathColumn maps the field at the path of JSON, Parquet, ORC or Avro data, such as "$.event.name", to the column.
+func PathColumn(column string, path string) ColumnMapping {
+	return ColumnMapping{Column: column, Properties: map[string]string{"Path": path}}
+}
+
+// ConstColumn sets the column to value, for every record of the data.
+func ConstColumn(column string, value string) ColumnMapping {
+	return ColumnMapping{Column: column, Properties: map[string]string{"ConstValue": value}}
+}
+
+// validateColumnMappings checks that the mappings set a column each, and locate its field as the mapping kind requires.
+func validateColumnMappings(mappings []ColumnMapping, kind DataFormat) error {
+	if len(mappings) == 0 {
+		return errors.ES(errors.OpUnknown, errors.KClientArgs, "an ingestion mapping must have at least one column").SetNoRetry()
+	}
+
+	located := "Path"
+	if kind == properties.CSV {
+		located = "Ordinal"
+	}
+	for i, m := range mappings {
+		if m.Column == "" {
+			return errors.ES(errors.OpUnknown, errors.KClientArgs, "column mapping[%d] has no column", i).SetNoRetry()
+		}
+		if m.Properties[located] == "" && m.Properties["ConstValue"] == "" && (kind != properties.AVRO || m.Properties["Field"] == "") {
+			return errors.ES(errors.OpUnknown, errors.KClientArgs, "column mapping of %s has no %s property, which a %s mapping requires", m.Column, located, kind).SetNoRetry()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package azkustoingest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnMappings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		mapping  []ColumnMapping
+		format   DataFormat
+		expected string
+		err      string
+	}{
+		{
+			desc:     "CSV",
+			mapping:  []ColumnMapping{CSVColumn("Name", 0), ConstColumn("Source", "app")},
+			format:   CSV,
+			expected: `[{"Column":"Name","Properties":{"Ordinal":"0"}},{"Column":"Source","Properties":{"ConstValue":"app"}}]`,
+		},
+		{
+			desc:     "JSON with a data type",
+			mapping:  []ColumnMapping{{Column: "Count", DataType: "long", Properties: map[string]string{"Path": "$.count"}}},
+			format:   MultiJSON,
+			expected: `[{"Column":"Count","DataType":"long","Properties":{"Path":"$.count"}}]`,
+		},
+		{
+			desc:     "Parquet",
+			mapping:  []ColumnMapping{PathColumn("Name", "$.name")},
+			format:   Parquet,
+			expected: `[{"Column":"Name","Properties":{"Path":"$.name"}}]`,
+		},
+		{
+			desc:    "Empty",
+			mapping: []ColumnMapping{},
+			format:  CSV,
+			err:     "at least one column",
+		},
+		{
+			desc:    "No column",
+			mapping: []ColumnMapping{PathColumn("", "$.name")},
+			format:  JSON,
+			err:     "has no column",
+		},
+		{
+			desc:    "Path in a CSV mapping",
+			mapping: []ColumnMapping{PathColumn("Name", "$.name")},
+			format:  CSV,
+			err:     "no Ordinal property",
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			props := properties.All{}
+			err := IngestionMapping(test.mapping, test.format).Run(&props, QueuedClient, FromFile)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expected, props.Ingestion.Additional.IngestionMapping)
+			assert.Equal(t, test.format, props.Ingestion.Additional.Format)
+		})
+	}
+}
+
+func TestManagedInlineMapping(t *testing.T) {
+	t.Parallel()
+
+	client := mockClient{
+		endpoint: "https://test.kusto.windows.net",
+		onMgmt: func(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+			if query.String() == ".get kusto identity token" {
+				return nil, nil
+			}
+			return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+		},
+	}
+	ingestion, err := newFromClient(client, &Ingestion{db: "defaultDb", table: "defaultTable"})
+	require.NoError(t, err)
+
+	queued := 0
+	ingestion.fs = resources.FsMock{
+		OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+			queued++
+			assert.JSONEq(t, `[{"Column":"Name","Properties":{"Path":"$.name"}}]`, props.Ingestion.Additional.IngestionMapping)
+			return "https://some-blob.blob.core.windows.net/some-container/some-blob", 0, nil
+		},
+	}
+	managed := Managed{
+		queued: ingestion,
+		streaming: &Streaming{
+			db:     "defaultDb",
+			table:  "defaultTable",
+			client: client,
+			streamConn: fakeStreamIngestor{
+				onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+					require.Fail(t, "Streaming ingestion doesn't support inline mappings")
+					return nil
+				},
+			},
+		},
+	}
+
+	_, err = managed.FromReader(context.Background(), bytes.NewReader([]byte(`{"name":"a"}`)), IngestionMapping([]ColumnMapping{PathColumn("Name", "$.name")}, JSON))
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+}
@@ -0,0 +1,27 @@
+package azkustoingest
+
+import "github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+
+// IngestionMetrics are the measurements of an ingestion: the size of its data before and after compression, the time spent
+// uploading or streaming it, and the time spent posting the ingestion message. They are returned by Result.Metrics and passed
+// to the hook of WithMetricsHook.
+type IngestionMetrics = properties.Metrics
+
+// reportMetrics calls the metrics hook of the client, if set, with the metrics of a successful ingestion, and returns the result
+// of the ingestion as is.
+func (i *Ingestion) reportMetrics(result *Result, err error) (*Result, error) {
+	return callMetricsHook(i.metricsHook, result, err)
+}
+
+// reportMetrics calls the metrics hook of the client, if set, with the metrics of a successful ingestion, and returns the result
+// of the ingestion as is.
+func (i *Streaming) reportMetrics(result *Result, err error) (*Result, error) {
+	return callMetricsHook(i.metricsHook, result, err)
+}
+
+func callMetricsHook(hook func(database, table string, metrics IngestionMetrics), result *Result, err error) (*Result, error) {
+	if hook != nil && err == nil && result != nil {
+		hook(result.record.Database, result.record.Table, result.Metrics())
+	}
+	return result, err
+}
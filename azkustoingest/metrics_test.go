@@ -0,0 +1,108 @@
+package azkustoingest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricsRecorder records the calls of a metrics hook.
+type metricsRecorder struct {
+	mu      sync.Mutex
+	targets []string
+	metrics []IngestionMetrics
+}
+
+func (m *metricsRecorder) hook(database, table string, metrics IngestionMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.targets = append(m.targets, database+"."+table)
+	m.metrics = append(m.metrics, metrics)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	// streamed holds the size of the payloads received by the fake streaming endpoint.
+	var streamed []int
+	newStreaming := func(rec *metricsRecorder) *Streaming {
+		return &Streaming{
+			db:          "defaultDb",
+			table:       "defaultTable",
+			metricsHook: rec.hook,
+			streamConn: fakeStreamIngestor{
+				onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+					b, err := io.ReadAll(payload)
+					streamed = append(streamed, len(b))
+					return err
+				},
+			},
+		}
+	}
+
+	t.Run("Streaming", func(t *testing.T) {
+		rec := &metricsRecorder{}
+		result, err := newStreaming(rec).FromReader(t.Context(), strings.NewReader("a,b\nc,d\n"), Table("Other"))
+		require.NoError(t, err)
+
+		m := result.Metrics()
+		assert.Equal(t, int64(8), m.RawBytes)
+		assert.Equal(t, int64(streamed[len(streamed)-1]), m.CompressedBytes)
+		assert.Positive(t, m.UploadDuration)
+		assert.Equal(t, []string{"defaultDb.Other"}, rec.targets)
+		assert.Equal(t, []IngestionMetrics{m}, rec.metrics)
+	})
+
+	t.Run("Queued", func(t *testing.T) {
+		rec := &metricsRecorder{}
+		ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", metricsHook: rec.hook})
+		require.NoError(t, err)
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				props.RecordSizes(8, 4)
+				props.RecordUpload(time.Second)
+				return "https://account.blob.core.windows.net/container/blob", 8, nil
+			},
+			OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+				props.RecordQueue(time.Millisecond)
+				return nil
+			},
+		}
+
+		result, err := ingestion.FromReader(t.Context(), strings.NewReader("a,b\nc,d\n"))
+		require.NoError(t, err)
+		want := IngestionMetrics{RawBytes: 8, CompressedBytes: 4, UploadDuration: time.Second, QueueLatency: time.Millisecond}
+		assert.Equal(t, want, result.Metrics())
+		assert.Equal(t, []IngestionMetrics{want}, rec.metrics)
+
+		// The metrics of a split source are the sums over its parts.
+		split := newSplitResult([]*Result{result, result})
+		assert.Equal(t, want.Add(want), split.Metrics())
+		assert.Equal(t, IngestionMetrics{}, newResult().Metrics())
+	})
+
+	t.Run("Managed counts the data it compresses", func(t *testing.T) {
+		rec := &metricsRecorder{}
+		ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", metricsHook: rec.hook})
+		require.NoError(t, err)
+		managed := newManagedFromClients(ingestion, newStreaming(rec))
+
+		data := bytes.Repeat([]byte("a,b\n"), 100)
+		result, err := managed.FromReader(t.Context(), bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), result.Metrics().RawBytes)
+		assert.Less(t, result.Metrics().CompressedBytes, int64(len(data)))
+		// The hook is called once, by the managed client.
+		assert.Equal(t, []IngestionMetrics{result.Metrics()}, rec.metrics)
+	})
+}
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/status"
+	"github.com/google/uuid"
 )
 
 // Result provides a way for users track the state of ingestion jobs.
@@ -16,6 +18,17 @@ type Result struct {
 	record        statusRecord
 	tableClient   status.TableClientReader
 	reportToTable bool
+
+	// parts holds the results of the parts of a source split with SplitLargeSources, or nil.
+	parts []*Result
+	// metrics is recorded as the ingestion goes, and complete once it is queued or streamed.
+	metrics *properties.Metrics
+	// attempts is the number of times streaming ingestion sent the data.
+	attempts int
+	// preview is what a dry run would send, or nil.
+	preview *properties.Preview
+	// clientRequestID is the client request ID of a streaming ingestion.
+	clientRequestID string
 }
 
 // newResult creates an initial ingestion status record.
@@ -26,14 +39,95 @@ func newResult() *Result {
 	return ret
 }
 
+// newSplitResult returns the result of a source split into parts, with their results.
+func newSplitResult(parts []*Result) *Result {
+	return &Result{record: parts[0].record, reportToTable: parts[0].reportToTable, parts: parts}
+}
+
+// Parts returns the results of the parts of a source that SplitLargeSources split, in order, or nil if the source wasn't split.
+// Each part is ingested as its own blob, with its own source ID. Wait and Poll of a split source cover all of its parts.
+func (r *Result) Parts() []*Result {
+	return r.parts
+}
+
+// Attempts returns the number of times streaming ingestion sent the data, retries of throttling and transient errors of the
+// service included, or 0 for queued ingestion.
+func (r *Result) Attempts() int {
+	return r.attempts
+}
+
+// Preview returns what the ingestion would send, for an ingestion with DryRun, or nil.
+func (r *Result) Preview() *Preview {
+	return r.preview
+}
+
+// Metrics returns the measurements of the ingestion, once it is queued or streamed. For a split source, they are the sums over
+// its parts.
+func (r *Result) Metrics() IngestionMetrics {
+	if len(r.parts) > 0 {
+		var m IngestionMetrics
+		for _, p := range r.parts {
+			m = m.Add(p.Metrics())
+		}
+		return m
+	}
+	if r.metrics == nil {
+		return IngestionMetrics{}
+	}
+	return *r.metrics
+}
+
+// SourceID returns the ID of the ingested source, which identifies the ingestion in the status table, such as with a
+// StatusReporter. For a split source, it is the ID of its first part.
+func (r *Result) SourceID() uuid.UUID {
+	return r.record.IngestionSourceID
+}
+
+// Status returns the last known status of the ingestion. After the channel returned by Wait is closed, it is the final status.
+// For a split source, it is the status of the first part that failed, or else of the first one that is pending, or else of the
+// last one.
+func (r *Result) Status() IngestionStatus {
+	if len(r.parts) > 0 {
+		return splitStatus(r.parts)
+	}
+	return newIngestionStatus(r.record)
+}
+
+// splitStatus returns the status of a source split into parts.
+func splitStatus(parts []*Result) IngestionStatus {
+	var pending *IngestionStatus
+	for _, p := range parts {
+		s := p.Status()
+		if s.Status.IsFinal() && !s.Status.IsSuccess() {
+			return s
+		}
+		if !s.Status.IsFinal() && pending == nil {
+			pending = &s
+		}
+	}
+	if pending != nil {
+		return *pending
+	}
+	return parts[len(parts)-1].Status()
+}
+
 // putProps sets the record to a failure state and adds the error to the record details.
 func (r *Result) putProps(props properties.All) {
-	r.reportToTable = props.Ingestion.ReportMethod == properties.ReportStatusToTable || props.Ingestion.ReportMethod == properties.ReportStatusToQueueAndTable
+	r.reportToTable = props.Ingestion.ReportLevel != properties.None &&
+		(props.Ingestion.ReportMethod == properties.ReportStatusToTable || props.Ingestion.ReportMethod == properties.ReportStatusToQueueAndTable)
 	r.record.FromProps(props)
+	r.metrics = props.Source.Metrics
+	r.preview = props.Source.Preview
+	r.clientRequestID = props.Streaming.ClientRequestId
 }
 
 // putQueued sets the initial success status depending on the status reporting state, returning the record on failure.
 func (r *Result) putQueued(ctx context.Context, i *Ingestion) error {
+	// A dry run writes no status record.
+	if r.preview != nil {
+		return nil
+	}
+
 	// If not checking status, just return queued
 	if !r.reportToTable {
 		r.record.Status = Queued
@@ -41,7 +135,7 @@ func (r *Result) putQueued(ctx context.Context, i *Ingestion) error {
 	}
 
 	// Get table URI
-	tableResources, err := i.mgr.GetTables()
+	tableResources, err := i.mgr.GetTables(ctx)
 	if err != nil {
 		r.record.Status = StatusRetrievalFailed
 		r.record.FailureStatus = Permanent
@@ -118,8 +212,9 @@ var (
 	DefaultWaitPollRetryBackoffJitter = 5 * time.Second
 )
 
-// Wait returns a channel that can be checked for ingestion results.
-// In order to check actual status please use the ReportResultToTable option when ingesting data.
+// Wait returns a channel that can be checked for ingestion results. The channel receives the failure of the ingestion, if any,
+// and is closed once its status is final, which Status then returns.
+// In order to check actual status please use the ReportResultToTable option, or ReportMethod(ReportToTable), when ingesting data.
 func (r *Result) Wait(ctx context.Context, options ...WaitOption) <-chan error {
 	cfg := waitConfig{
 		interval:           DefaultWaitPollInterval,
@@ -131,6 +226,10 @@ func (r *Result) Wait(ctx context.Context, options ...WaitOption) <-chan error {
 		o(&cfg)
 	}
 
+	if len(r.parts) > 0 {
+		return r.waitParts(ctx, options)
+	}
+
 	ch := make(chan error, 1)
 
 	if r.record.Status.IsFinal() {
@@ -141,14 +240,8 @@ func (r *Result) Wait(ctx context.Context, options ...WaitOption) <-chan error {
 		return ch
 	}
 
-	if !r.reportToTable {
-		ch <- errors.New("status reporting is not enabled")
-		close(ch)
-		return ch
-	}
-
-	if r.tableClient == nil {
-		ch <- errors.New("table client is not initialized")
+	if err := r.checkPollable(); err != nil {
+		ch <- err
 		close(ch)
 		return ch
 	}
@@ -165,6 +258,71 @@ func (r *Result) Wait(ctx context.Context, options ...WaitOption) <-chan error {
 	return ch
 }
 
+// waitParts waits for the parts of a split source, and sends the failure of the first part that failed, if any.
+func (r *Result) waitParts(ctx context.Context, options []WaitOption) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		defer close(ch)
+
+		errs := make([]error, len(r.parts))
+		var wg sync.WaitGroup
+		for idx, p := range r.parts {
+			wg.Add(1)
+			go func(idx int, p *Result) {
+				defer wg.Done()
+				errs[idx] = <-p.Wait(ctx, options...)
+			}(idx, p)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				ch <- err
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Poll reads the status of the ingestion from the status table once, and returns it. Unlike Wait, it doesn't block until the
+// ingestion ends: the status stays Pending until the service reports the outcome. Once the status is final, the table isn't read
+// anymore. Like Wait, it requires the ReportResultToTable option, and must not be called while a Wait is in progress.
+func (r *Result) Poll(ctx context.Context) (IngestionStatus, error) {
+	if len(r.parts) > 0 {
+		for _, p := range r.parts {
+			if _, err := p.Poll(ctx); err != nil {
+				return r.Status(), err
+			}
+		}
+		return r.Status(), nil
+	}
+	if r.record.Status.IsFinal() {
+		return r.Status(), nil
+	}
+	if err := r.checkPollable(); err != nil {
+		return r.Status(), err
+	}
+
+	smap, err := r.tableClient.Read(ctx, r.record.IngestionSourceID.String())
+	if err != nil {
+		return r.Status(), fmt.Errorf("failed reading from status table: %w", err)
+	}
+	r.record.FromMap(smap)
+	return r.Status(), nil
+}
+
+// checkPollable returns an error if the status of the ingestion can't be read from the status table.
+func (r *Result) checkPollable() error {
+	if !r.reportToTable {
+		return errors.New("status reporting is not enabled")
+	}
+	if r.tableClient == nil {
+		return errors.New("table client is not initialized")
+	}
+	return nil
+}
+
 func (r *Result) poll(ctx context.Context, cfg *waitConfig) {
 	initialInterval := cfg.interval
 	if cfg.immediateFirst {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type safeSlice[T any] struct {
@@ -182,3 +183,42 @@ func TestWait_WithRetryBackoffDelay(t *testing.T) {
 		}
 	})
 }
+
+func TestPoll(t *testing.T) {
+	t.Parallel()
+
+	reads := 0
+	res := &Result{
+		reportToTable: true,
+		tableClient: TableClientReaderFunc(func(ctx context.Context, ingestionSourceID string) (map[string]any, error) {
+			reads++
+			if reads == 1 {
+				return map[string]any{"Status": string(Pending)}, nil
+			}
+			return map[string]any{"Status": string(Failed), "ErrorCode": "BadRequest_EmptyBlob", "FailureStatus": string(Permanent)}, nil
+		}),
+		record: statusRecord{
+			Status: Pending,
+		},
+	}
+
+	st, err := res.Poll(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, Pending, st.Status)
+
+	st, err = res.Poll(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, Failed, st.Status)
+	assert.Equal(t, "BadRequest_EmptyBlob", st.ErrorCode)
+	assert.Error(t, st.Err())
+
+	// The status is final, so the table isn't read anymore.
+	st, err = res.Poll(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, Failed, st.Status)
+	assert.Equal(t, 2, reads)
+	assert.Equal(t, st, res.Status())
+
+	_, err = (&Result{record: statusRecord{Status: Pending}}).Poll(t.Context())
+	assert.ErrorContains(t, err, "status reporting is not enabled")
+}
@@ -0,0 +1,80 @@
+package azkustoingest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// inflight tracks the ingestions in progress on a client, so that Shutdown can wait for them before releasing the client.
+type inflight struct {
+	mu     sync.Mutex
+	n      int
+	closed bool
+	// idle is closed once no ingestion is in progress, after drain was called.
+	idle chan struct{}
+}
+
+// begin registers an ingestion, and returns the function to call once it is done. It returns a KClientArgs error once the
+// client is shut down.
+func (f *inflight) begin(op errors.Op) (func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, errors.ES(op, errors.KClientArgs, "the ingestion client is closed").SetNoRetry()
+	}
+	f.n++
+	return f.end, nil
+}
+
+func (f *inflight) end() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n--
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// drain rejects the new ingestions, and waits for those in progress until ctx is done.
+func (f *inflight) drain(ctx context.Context) error {
+	f.mu.Lock()
+	f.closed = true
+	if f.n == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return errors.ES(errors.OpUnknown, errors.KTimeout, "ingestions were still in progress when the client was shut down: %s", ctx.Err())
+	}
+}
+
+// Shutdown rejects new ingestions, waits for those in progress, such as uploads and queue posts, until ctx is done, and then
+// releases the client as Close does. The resources are released even if ctx is done first, with the ingestions still in
+// progress then failing, and Shutdown returns a KTimeout error.
+func (i *Ingestion) Shutdown(ctx context.Context) error {
+	return errors.CombineErrors(i.pending.drain(ctx), i.release())
+}
+
+// Shutdown rejects new ingestions, waits for those in progress until ctx is done, and then releases the client as Close does.
+// It returns a KTimeout error if ctx is done first.
+func (i *Streaming) Shutdown(ctx context.Context) error {
+	return errors.CombineErrors(i.pending.drain(ctx), i.release())
+}
+
+// Shutdown rejects new ingestions, waits for those in progress until ctx is done, and then shuts down its queued and streaming
+// clients. It returns a KTimeout error if ctx is done first.
+func (m *Managed) Shutdown(ctx context.Context) error {
+	return errors.CombineErrors(m.pending.drain(ctx), m.queued.Shutdown(ctx), m.streaming.Shutdown(ctx))
+}
@@ -0,0 +1,81 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Streaming waits for the ingestions in progress", func(t *testing.T) {
+		t.Parallel()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		streaming := &Streaming{
+			db:    "defaultDb",
+			table: "defaultTable",
+			streamConn: fakeStreamIngestor{
+				onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+					close(started)
+					<-release
+					return nil
+				},
+			},
+		}
+
+		ingested := make(chan error)
+		go func() {
+			_, err := streaming.FromReader(context.Background(), strings.NewReader("a,b"))
+			ingested <- err
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		err := streaming.Shutdown(ctx)
+		kerr, ok := errors.GetKustoError(err)
+		require.True(t, ok, "got %v", err)
+		assert.Equal(t, errors.KTimeout, kerr.Kind)
+
+		_, err = streaming.FromReader(t.Context(), strings.NewReader("a,b"))
+		assert.ErrorContains(t, err, "client is closed")
+
+		close(release)
+		require.NoError(t, <-ingested)
+		assert.NoError(t, streaming.Close())
+	})
+
+	t.Run("Batcher bounds the wait for its batches", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		client := &FakeIngestor{
+			OnFromReader: func(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+				<-release
+				return NewFakeResult(Succeeded), nil
+			},
+		}
+		b, err := NewBatcher[channelEvent](t.Context(), client, ChannelBatching{})
+		require.NoError(t, err)
+		require.NoError(t, b.Add(t.Context(), channelEvent{Name: "e"}))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		err = b.Shutdown(ctx)
+		kerr, ok := errors.GetKustoError(err)
+		require.True(t, ok, "got %v", err)
+		assert.Equal(t, errors.KTimeout, kerr.Kind)
+
+		close(release)
+		assert.NoError(t, b.Shutdown(t.Context()))
+	})
+}
@@ -0,0 +1,70 @@
+package azkustoingest
+
+import (
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/google/uuid"
+)
+
+// WithSlowOperationThreshold calls callback with the summary of each ingestion of the client that takes longer than threshold,
+// failed ones included, and of each call the client makes to the cluster, such as to fetch its resources; see
+// azkustodata.WithSlowOperationThreshold. The duration of a queued ingestion ends once it is queued, not once the service
+// ingested it. callback is called from the goroutine of the ingestion, and must return quickly.
+func WithSlowOperationThreshold(threshold time.Duration, callback func(op azkustodata.SlowOperation)) Option {
+	return func(s *Ingestion) {
+		s.slow = slowReporter{threshold: threshold, callback: callback}
+	}
+}
+
+// slowReporter calls the callback of WithSlowOperationThreshold with the ingestions that take longer than its threshold.
+type slowReporter struct {
+	threshold time.Duration
+	callback  func(op azkustodata.SlowOperation)
+}
+
+// reportSlow reports a queued ingestion of the client, which started at start, if it was slow.
+func (i *Ingestion) reportSlow(start time.Time, result *Result, err error) {
+	i.slow.report(start, errors.OpFileIngest, i.db, i.table, result, err)
+}
+
+// reportSlow reports a streaming ingestion of the client, which started at start, if it was slow.
+func (i *Streaming) reportSlow(start time.Time, result *Result, err error) {
+	i.slow.report(start, errors.OpIngestStream, i.db, i.table, result, err)
+}
+
+// reportSlow reports an ingestion of the client, which started at start, if it was slow, as a queued ingestion if it wasn't
+// streamed.
+func (m *Managed) reportSlow(start time.Time, result *Result, err error) {
+	op := errors.OpIngestStream
+	if result != nil && result.Attempts() == 0 {
+		op = errors.OpFileIngest
+	}
+	m.streaming.slow.report(start, op, m.streaming.db, m.streaming.table, result, err)
+}
+
+// report calls the callback if the ingestion, which started at start, took longer than the threshold. The database and the
+// table of the ingestion are those of its result, or db and table if it failed.
+func (s slowReporter) report(start time.Time, op errors.Op, db, table string, result *Result, err error) {
+	if s.callback == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed <= s.threshold {
+		return
+	}
+
+	slow := azkustodata.SlowOperation{Op: op, Database: db, Table: table, Duration: elapsed, Err: err}
+	if result != nil {
+		slow.Database, slow.Table = result.record.Database, result.record.Table
+		if op == errors.OpIngestStream {
+			slow.RequestID = result.clientRequestID
+		} else if id := result.SourceID(); id != uuid.Nil {
+			slow.RequestID = id.String()
+		}
+	} else if e, ok := errors.GetKustoError(err); ok {
+		slow.RequestID = e.ClientRequestID
+	}
+	s.callback(slow)
+}
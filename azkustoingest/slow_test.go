@@ -0,0 +1,95 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowOperationThreshold(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Queued", func(t *testing.T) {
+		t.Parallel()
+
+		var slow []azkustodata.SlowOperation
+		o := getOptions([]Option{WithDefaultDatabase("defaultDb"), WithDefaultTable("defaultTable"),
+			WithSlowOperationThreshold(0, func(op azkustodata.SlowOperation) { slow = append(slow, op) })})
+		ingestion, err := newFromClient(newMockClient(), o)
+		require.NoError(t, err)
+		fail := false
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				if fail {
+					return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "the upload failed")
+				}
+				return "https://account.blob.core.windows.net/container/blob", 8, nil
+			},
+		}
+
+		result, err := ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"), Table("Other"))
+		require.NoError(t, err)
+		fail = true
+		_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,b\n"))
+		require.Error(t, err)
+
+		require.Len(t, slow, 2)
+		assert.Equal(t, errors.OpFileIngest, slow[0].Op)
+		assert.Equal(t, "defaultDb", slow[0].Database)
+		assert.Equal(t, "Other", slow[0].Table)
+		assert.Equal(t, result.SourceID().String(), slow[0].RequestID)
+		assert.Empty(t, slow[0].TextHash)
+		assert.Positive(t, slow[0].Duration)
+		assert.NoError(t, slow[0].Err)
+
+		assert.Equal(t, "defaultTable", slow[1].Table)
+		assert.Equal(t, err, slow[1].Err)
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		t.Parallel()
+
+		var slow []azkustodata.SlowOperation
+		o := getOptions([]Option{WithDefaultDatabase("defaultDb"), WithDefaultTable("defaultTable"),
+			WithSlowOperationThreshold(0, func(op azkustodata.SlowOperation) { slow = append(slow, op) })})
+		streaming, err := newStreamingFromClient(newMockClient(), o)
+		require.NoError(t, err)
+		streaming.streamConn = fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				_, err := io.ReadAll(payload)
+				return err
+			},
+		}
+
+		_, err = streaming.FromReader(t.Context(), strings.NewReader("a,b\n"), ClientRequestId("streamed"))
+		require.NoError(t, err)
+		require.Len(t, slow, 1)
+		assert.Equal(t, errors.OpIngestStream, slow[0].Op)
+		assert.Equal(t, "defaultTable", slow[0].Table)
+		assert.Equal(t, "streamed", slow[0].RequestID)
+	})
+
+	t.Run("Fast ingestions aren't reported", func(t *testing.T) {
+		t.Parallel()
+
+		o := getOptions([]Option{WithDefaultDatabase("defaultDb"), WithDefaultTable("defaultTable"),
+			WithSlowOperationThreshold(1<<62, func(op azkustodata.SlowOperation) { t.Errorf("the ingestion was reported as slow: %+v", op) })})
+		streaming, err := newStreamingFromClient(newMockClient(), o)
+		require.NoError(t, err)
+		streaming.streamConn = fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				return nil
+			},
+		}
+		_, err = streaming.FromReader(t.Context(), strings.NewReader("a,b\n"))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,260 @@
+package azkustoingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
+	"github.com/google/uuid"
+)
+
+// DefaultSplitSize is the size of the parts that SplitLargeSources splits sources into, unless another size is given. The
+// service ingests blobs of up to about 1GiB of data best.
+const DefaultSplitSize = 1024 * 1024 * 1024
+
+// splitFile ingests the local file of size bytes as parts, with splitSource.
+func (i *Ingestion) splitFile(ctx context.Context, fPath string, size int64, result *Result, props properties.All) (*Result, error) {
+	file, err := os.Open(fPath)
+	if err != nil {
+		return nil, errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "problem retrieving source file %q: %s", fPath, err).SetNoRetry()
+	}
+	defer file.Close()
+
+	return i.splitSource(ctx, file, size, result, props)
+}
+
+// splitSource ingests the data of reader, of total bytes or -1 if it isn't known, as parts of at most props.Source.SplitSize
+// bytes, each uploaded to its own blob and queued with its own message. result is the result of the first part. If there is
+// more than one part, the returned Result holds the results of all of them. If a part fails, the parts before it stay queued.
+func (i *Ingestion) splitSource(ctx context.Context, reader io.Reader, total int64, result *Result, props properties.All) (*Result, error) {
+	if err := queued.CompleteFormatFromFileName(&props, props.Source.OriginalSource); err != nil {
+		return nil, err
+	}
+	splitter, err := newRecordSplitter(props.TrackProgress(reader, total), &props)
+	if err != nil {
+		return nil, err
+	}
+
+	// The progress is reported over the whole source, the size of each part is the size of its blob, and a local source is
+	// deleted once its last part is queued.
+	props.Source.OnProgress = nil
+	props.Ingestion.RawDataSize = 0
+	deleteSource := props.Source.DeleteLocalSource
+	props.Source.DeleteLocalSource = false
+
+	var parts []*Result
+	for {
+		part, err := splitter.next()
+		if err != nil {
+			return nil, err
+		}
+		if part == nil {
+			break
+		}
+
+		partProps := props
+		partResult := result
+		if len(parts) > 0 {
+			if partProps.Source.ID != uuid.Nil {
+				// The ID of a part is derived from the ID of the source, so that the parts of a source with a deterministic ID
+				// have deterministic IDs too.
+				partProps.Source.ID = uuid.NewSHA1(props.Source.ID, []byte(strconv.Itoa(len(parts))))
+				partProps.Ingestion.ID = partProps.Source.ID
+				if partProps.Ingestion.TableEntryRef.TableConnectionString != "" {
+					partProps.Ingestion.TableEntryRef.PartitionKey = partProps.Source.ID.String()
+				}
+			}
+			partProps.Source.Metrics = &properties.Metrics{}
+			partResult = newResult()
+			partResult.putProps(partProps)
+			partResult.record.IngestionSourcePath = result.record.IngestionSourcePath
+		}
+
+		blobURL, size, err := i.fs.UploadReaderToBlob(ctx, part, partProps)
+		if err != nil {
+			return nil, err
+		}
+		if splitter.done() {
+			partProps.Source.DeleteLocalSource = deleteSource
+		}
+
+		if err = partResult.putQueued(ctx, i); err != nil {
+			return nil, err
+		}
+		if err = i.ingestBlob(ctx, blobURL, size, partProps); err != nil {
+			return nil, err
+		}
+		parts = append(parts, partResult)
+	}
+
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return newSplitResult(parts), nil
+}
+
+// recordSplitter splits a source into parts that end on record boundaries.
+type recordSplitter struct {
+	// read returns the next record of the source, with its line break, or an error.
+	read    func() ([]byte, error)
+	maxSize int64
+	// header is the first record of the source, repeated at the start of each part, with IgnoreFirstRecord.
+	header []byte
+
+	// pending is the record that didn't fit in the previous part.
+	pending []byte
+	err     error
+	started bool
+}
+
+func newRecordSplitter(reader io.Reader, props *properties.All) (*recordSplitter, error) {
+	switch queued.EffectiveCompressionType(props, props.Source.OriginalSource) {
+	case ingestoptions.GZIP, ingestoptions.ZIP:
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "compressed sources cannot be split").SetNoRetry()
+	}
+
+	r := bufio.NewReader(reader)
+	s := &recordSplitter{maxSize: props.Source.SplitSize}
+	switch format := props.Ingestion.Additional.Format; format {
+	case CSV, PSV, SCSV, SOHSV, TSV:
+		s.read = func() ([]byte, error) {
+			return readQuotedRecord(r)
+		}
+	case TSVE, TXT:
+		s.read = func() ([]byte, error) {
+			line, err := r.ReadBytes('\n')
+			if err == io.EOF && len(line) > 0 {
+				return terminateRecord(line), nil
+			}
+			return line, err
+		}
+	case JSON, MultiJSON:
+		dec := json.NewDecoder(r)
+		s.read = func() ([]byte, error) {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+			return append(raw, '\n'), nil
+		}
+	default:
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "sources of format %s cannot be split on record boundaries", format).SetNoRetry()
+	}
+
+	if props.Ingestion.Additional.IgnoreFirstRecord {
+		header, err := s.read()
+		if err != nil && err != io.EOF {
+			return nil, errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "could not read the first record of the source: %s", err)
+		}
+		s.header = header
+	}
+	return s, nil
+}
+
+// readQuotedRecord reads a record of the CSV-like formats, whose quoted values can hold line breaks: a line, and the lines after
+// it while it has an odd number of quotes.
+func readQuotedRecord(r *bufio.Reader) ([]byte, error) {
+	var record []byte
+	quoted := false
+	for {
+		line, err := r.ReadBytes('\n')
+		record = append(record, line...)
+		if bytes.Count(line, []byte{'"'})%2 == 1 {
+			quoted = !quoted
+		}
+		if err == io.EOF && len(record) > 0 {
+			return terminateRecord(record), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !quoted {
+			return record, nil
+		}
+	}
+}
+
+// terminateRecord adds a line break to the last record of a source that doesn't end with one, so it can be followed by others.
+func terminateRecord(record []byte) []byte {
+	if record[len(record)-1] != '\n' {
+		record = append(record, '\n')
+	}
+	return record
+}
+
+// next returns the reader of the next part, or nil once the source is read to the end. Each part must be read to the end before
+// the next one. A source without records is a single empty part.
+func (s *recordSplitter) next() (io.Reader, error) {
+	if s.started {
+		if s.pending == nil && s.err == nil {
+			s.pending, s.err = s.read()
+		}
+		if s.err != nil && s.err != io.EOF {
+			return nil, errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "could not read the source: %s", s.err)
+		}
+		if s.pending == nil {
+			return nil, nil
+		}
+	}
+	s.started = true
+	return &splitPart{s: s, buf: s.header, size: int64(len(s.header))}, nil
+}
+
+// done reports whether the source was read to the end.
+func (s *recordSplitter) done() bool {
+	return s.pending == nil && s.err != nil
+}
+
+// splitPart reads the records of the source until the next one would make the part larger than the split size. A record larger
+// than the split size is a part of its own.
+type splitPart struct {
+	s       *recordSplitter
+	buf     []byte
+	size    int64
+	records int
+	full    bool
+}
+
+// Read implements io.Reader.
+func (p *splitPart) Read(b []byte) (int, error) {
+	s := p.s
+	for len(p.buf) == 0 {
+		if p.full {
+			return 0, io.EOF
+		}
+		record := s.pending
+		s.pending = nil
+		if record == nil {
+			if s.err == io.EOF {
+				p.full = true
+				continue
+			}
+			if s.err != nil {
+				return 0, s.err
+			}
+			if record, s.err = s.read(); s.err != nil {
+				continue
+			}
+		}
+		if p.records > 0 && p.size+int64(len(record)) > s.maxSize {
+			s.pending = record
+			p.full = true
+			continue
+		}
+		p.size += int64(len(record))
+		p.records++
+		p.buf = record
+	}
+
+	n := copy(b, p.buf)
+	p.buf = p.buf[n:]
+	return n, nil
+}
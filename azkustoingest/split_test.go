@@ -0,0 +1,171 @@
+package azkustoingest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// splitRecording holds the parts uploaded by an Ingestion of splitRecorder, in order, and the properties they were queued with.
+type splitRecording struct {
+	mu      sync.Mutex
+	parts   []string
+	ids     []uuid.UUID
+	deletes []bool
+}
+
+// splitRecorder returns an Ingestion whose uploads are kept in the returned recording.
+func splitRecorder(t *testing.T) (*Ingestion, *splitRecording) {
+	ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable"})
+	require.NoError(t, err)
+
+	rec := &splitRecording{}
+	ingestion.fs = resources.FsMock{
+		OnLocal: func(ctx context.Context, from string, props properties.All) (string, int64, error) {
+			return "", 0, fmt.Errorf("unexpected upload of the whole file")
+		},
+		OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return "", 0, err
+			}
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.parts = append(rec.parts, string(data))
+			return fmt.Sprintf("https://account.blob.core.windows.net/container/part%d", len(rec.parts)), int64(len(data)), nil
+		},
+		OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.ids = append(rec.ids, props.Source.ID)
+			rec.deletes = append(rec.deletes, props.Source.DeleteLocalSource)
+			return nil
+		},
+	}
+	return ingestion, rec
+}
+
+func TestSplitLargeSources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc        string
+		data        string
+		options     []FileOption
+		want        []string
+		errContains string
+	}{
+		{
+			desc:    "CSV on record boundaries, with quoted line breaks",
+			data:    "a,1\n\"b\nc\",2\nd,3\ne,4",
+			options: []FileOption{SplitLargeSources(10)},
+			want:    []string{"a,1\n", "\"b\nc\",2\n", "d,3\ne,4\n"},
+		},
+		{
+			desc:    "A record larger than the split size is a part of its own",
+			data:    "aaaaaaaaaaaa\nb\n",
+			options: []FileOption{SplitLargeSources(4)},
+			want:    []string{"aaaaaaaaaaaa\n", "b\n"},
+		},
+		{
+			desc:    "The header is repeated with IgnoreFirstRecord",
+			data:    "name,n\na,1\nb,2\n",
+			options: []FileOption{SplitLargeSources(12), IgnoreFirstRecord()},
+			want:    []string{"name,n\na,1\n", "name,n\nb,2\n"},
+		},
+		{
+			desc:    "MultiJSON on object boundaries",
+			data:    "{\"a\":\n1}\n{\"b\":2}{\"c\":3}",
+			options: []FileOption{SplitLargeSources(16), FileFormat(MultiJSON)},
+			want:    []string{"{\"a\":\n1}\n", "{\"b\":2}\n{\"c\":3}\n"},
+		},
+		{
+			desc:    "Small sources are a single part",
+			data:    "a,1\n",
+			options: []FileOption{SplitLargeSources(0)},
+			want:    []string{"a,1\n"},
+		},
+		{
+			desc:        "Binary formats can't be split",
+			data:        "PAR1",
+			options:     []FileOption{SplitLargeSources(10), FileFormat(Parquet)},
+			errContains: "cannot be split",
+		},
+		{
+			desc:        "Compressed sources can't be split",
+			data:        "gz",
+			options:     []FileOption{SplitLargeSources(10), CompressionType(ingestoptions.GZIP)},
+			errContains: "compressed sources cannot be split",
+		},
+		{
+			desc:        "Negative size",
+			options:     []FileOption{SplitLargeSources(-1)},
+			errContains: "must not be negative",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ingestion, rec := splitRecorder(t)
+			options := append([]FileOption{ReportResultToQueue()}, test.options...)
+			result, err := ingestion.FromReader(t.Context(), io.NopCloser(strings.NewReader(test.data)), options...)
+			if test.errContains != "" {
+				require.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, rec.parts)
+
+			if len(test.want) == 1 {
+				assert.Nil(t, result.Parts())
+				return
+			}
+			require.Len(t, result.Parts(), len(test.want))
+			seen := map[uuid.UUID]bool{}
+			for idx, part := range result.Parts() {
+				assert.Equal(t, rec.ids[idx], part.SourceID())
+				seen[part.SourceID()] = true
+			}
+			assert.Len(t, seen, len(test.want))
+			assert.Equal(t, result.Parts()[0].SourceID(), result.SourceID())
+			assert.Equal(t, Queued, result.Status().Status)
+		})
+	}
+}
+
+func TestSplitLargeFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	require.NoError(t, os.WriteFile(path, []byte("a,1\nb,2\nc,3\n"), 0o600))
+
+	ingestion, rec := splitRecorder(t)
+	var progress []int64
+	result, err := ingestion.FromFile(t.Context(), path, SplitLargeSources(8), DeleteSource(), OnProgress(func(sent int64, total int64) {
+		assert.Equal(t, int64(12), total)
+		progress = append(progress, sent)
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a,1\nb,2\n", "c,3\n"}, rec.parts)
+	assert.Len(t, result.Parts(), 2)
+	assert.Equal(t, int64(12), progress[len(progress)-1])
+
+	// The file is deleted once its last part is queued.
+	assert.Equal(t, []bool{false, true}, rec.deletes)
+	assert.NoError(t, <-result.Wait(t.Context()))
+}
@@ -0,0 +1,419 @@
+package azkustoingest
+
+import (
+	"context"
+	"encoding/gob"
+	goErrors "errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/google/uuid"
+)
+
+const (
+	// spoolEntryExt is the extension of the files of the spool that hold the ingestions.
+	spoolEntryExt = ".spool"
+	// spoolDataExt is the extension of the files of the spool that hold the content of the readers.
+	spoolDataExt = ".data"
+)
+
+// WithSpool configures the queued and managed clients to persist the ingestions that fail after retrying, such as during an
+// outage of the cluster, to the directory dir, which is created if it doesn't exist, so that ReplaySpool ingests them later.
+// A local file is persisted by its path, so it must be kept until it is replayed, and a blob by its URL. The content of a
+// reader that is an io.Seeker is read again and written to the spool if the ingestion fails. Other readers can't be read
+// again: if the data was uploaded and only the post to the queues failed, the uploaded blob is persisted by its URL, whose
+// SAS expires after a few hours, and otherwise the ingestion isn't persisted, unless WithSpoolReaders is given. The sources
+// split with SplitLargeSources aren't persisted. Ingestions rejected for their arguments, such as an invalid option, aren't
+// persisted either. The failed ingestion still returns its error.
+func WithSpool(dir string) Option {
+	return func(s *Ingestion) {
+		s.spoolDir = dir
+	}
+}
+
+// WithSpoolReaders configures the spool of WithSpool to also persist the ingestions of readers that aren't an io.Seeker and
+// fail before their data is uploaded. The content of each such reader is written to the spool while it is uploaded, and
+// removed once the ingestion succeeds, so every ingestion from a reader writes its whole data to the disk of the spool.
+func WithSpoolReaders() Option {
+	return func(s *Ingestion) {
+		s.spoolReaders = true
+	}
+}
+
+// spoolEntry is an ingestion persisted to the spool, with the properties it was prepared with.
+type spoolEntry struct {
+	// Path is the local file of the ingestion, if it was ingested with FromFile.
+	Path string
+	// Data indicates the content of the reader of the ingestion is in the data file of the entry.
+	Data bool
+	// BlobURL is the blob of the ingestion, if it was ingested with FromBlob.
+	BlobURL string
+	// Size is the size of the blob before compression, or 0 if it isn't known.
+	Size int64
+
+	Ingestion         properties.Ingestion
+	SourceID          uuid.UUID
+	CompressionType   ingestoptions.CompressionType
+	DontCompress      bool
+	DeleteLocalSource bool
+
+	// Err is the error the ingestion failed with.
+	Err string
+	// SpooledAt is when the ingestion was persisted.
+	SpooledAt time.Time
+}
+
+// newSpoolEntry returns the entry of an ingestion prepared with props.
+func newSpoolEntry(props properties.All, err error) spoolEntry {
+	ingestion := props.Ingestion
+	// The authorization context is renewed when the ingestion is replayed, rather than written to the disk.
+	ingestion.Additional.AuthContext = ""
+	return spoolEntry{
+		Ingestion:         ingestion,
+		SourceID:          props.Source.ID,
+		CompressionType:   props.Source.CompressionType,
+		DontCompress:      props.Source.DontCompress,
+		DeleteLocalSource: props.Source.DeleteLocalSource,
+		Err:               err.Error(),
+		SpooledAt:         time.Now().UTC(),
+	}
+}
+
+// props returns the properties of the ingestion of the entry.
+func (e spoolEntry) props() properties.All {
+	return properties.All{
+		Ingestion: e.Ingestion,
+		Source: properties.SourceOptions{
+			ID:                e.SourceID,
+			CompressionType:   e.CompressionType,
+			DontCompress:      e.DontCompress,
+			DeleteLocalSource: e.DeleteLocalSource,
+			OriginalSource:    e.Path,
+			Metrics:           &properties.Metrics{},
+		},
+	}
+}
+
+// spoolable reports whether an ingestion that failed with err is persisted to the spool: all the failures but the ones of the
+// arguments of the ingestion, which would fail again, and of dry runs, which a replay would ingest.
+func spoolable(props properties.All, err error) bool {
+	if props.Source.Preview != nil {
+		return false
+	}
+	if e, ok := errors.GetKustoError(err); ok {
+		return e.Kind != errors.KClientArgs
+	}
+	return true
+}
+
+// spool persists the entry to the spool with the name id, and logs the failure it was persisted for.
+func (i *Ingestion) spool(id uuid.UUID, entry spoolEntry) {
+	if err := i.writeSpoolEntry(id, entry); err != nil {
+		i.log().Error("could not persist a failed ingestion to the spool", "spool", i.spoolDir, "error", err, "ingestionError", entry.Err)
+		i.removeSpoolFiles(id)
+		return
+	}
+	i.log().Warn("a failed ingestion was persisted to the spool for replay", "spool", i.spoolDir, "entry", id, "error", entry.Err)
+}
+
+// writeSpoolEntry writes the entry to a temporary file that is renamed once complete, so that replays only see whole entries.
+func (i *Ingestion) writeSpoolEntry(id uuid.UUID, entry spoolEntry) error {
+	if err := os.MkdirAll(i.spoolDir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(i.spoolDir, id.String()+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(entry); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), i.spoolPath(id, spoolEntryExt))
+}
+
+// createSpoolData creates the data file of the entry id, which holds the content of a reader.
+func (i *Ingestion) createSpoolData(id uuid.UUID) (*os.File, error) {
+	if err := os.MkdirAll(i.spoolDir, 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(i.spoolPath(id, spoolDataExt), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+}
+
+func (i *Ingestion) spoolPath(id uuid.UUID, ext string) string {
+	return filepath.Join(i.spoolDir, id.String()+ext)
+}
+
+func (i *Ingestion) removeSpoolFiles(id uuid.UUID) {
+	os.Remove(i.spoolPath(id, spoolEntryExt))
+	os.Remove(i.spoolPath(id, spoolDataExt))
+}
+
+// spoolReader returns the reader to ingest the content of reader from, and a function that persists the ingestion to a new
+// entry of the spool if it fails with err. The content of an io.Seeker is read again from its offset when the ingestion fails.
+// Other readers are persisted by the URL of the blob they were uploaded to when only the post to the queues failed, and with
+// WithSpoolReaders, their content is written to the data file as it is read, and removed if the ingestion doesn't fail. It
+// returns reader as is, and a no-op, if the client has no spool.
+func (i *Ingestion) spoolReader(reader io.Reader) (io.Reader, func(props properties.All, err error)) {
+	noop := func(properties.All, error) {}
+	if i.spoolDir == "" {
+		return reader, noop
+	}
+	id := uuid.New()
+	failed := func(err error, ingestionErr error) {
+		i.log().Error("could not write the content of a reader to the spool", "spool", i.spoolDir, "error", err, "ingestionError", ingestionErr)
+		i.removeSpoolFiles(id)
+	}
+
+	if seeker, ok := reader.(io.Seeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return reader, noop
+		}
+		return reader, func(props properties.All, err error) {
+			if err == nil || !spoolable(props, err) {
+				return
+			}
+			data, createErr := i.createSpoolData(id)
+			if createErr != nil {
+				failed(createErr, err)
+				return
+			}
+			_, copyErr := seeker.Seek(start, io.SeekStart)
+			if copyErr == nil {
+				_, copyErr = io.Copy(data, reader)
+			}
+			if closeErr := data.Close(); copyErr == nil {
+				copyErr = closeErr
+			}
+			if copyErr != nil {
+				failed(copyErr, err)
+				return
+			}
+			entry := newSpoolEntry(props, err)
+			entry.Data = true
+			i.spool(id, entry)
+		}
+	}
+
+	if !i.spoolReaders {
+		return reader, func(props properties.All, err error) {
+			if err == nil || !spoolable(props, err) {
+				return
+			}
+			var submission *SubmissionError
+			if goErrors.As(err, &submission) {
+				i.spoolBlob(submission.BlobURL, submission.Size, props, err)
+				return
+			}
+			i.log().Warn("a failed ingestion from a reader that isn't an io.Seeker wasn't persisted to the spool (hint: use WithSpoolReaders)",
+				"spool", i.spoolDir, "error", err)
+		}
+	}
+
+	data, err := i.createSpoolData(id)
+	if err != nil {
+		failed(err, nil)
+		return reader, noop
+	}
+	return io.TeeReader(reader, data), func(props properties.All, err error) {
+		if err == nil || !spoolable(props, err) {
+			data.Close()
+			i.removeSpoolFiles(id)
+			return
+		}
+		// The upload may have stopped before reading all the content.
+		_, copyErr := io.Copy(data, reader)
+		if closeErr := data.Close(); copyErr == nil {
+			copyErr = closeErr
+		}
+		if copyErr != nil {
+			failed(copyErr, err)
+			return
+		}
+		entry := newSpoolEntry(props, err)
+		entry.Data = true
+		i.spool(id, entry)
+	}
+}
+
+// spoolFile persists the ingestion of a local file that failed with err, if the client has a spool.
+func (i *Ingestion) spoolFile(fPath string, props properties.All, err error) {
+	if i.spoolDir == "" || !spoolable(props, err) {
+		return
+	}
+	entry := newSpoolEntry(props, err)
+	if abs, absErr := filepath.Abs(fPath); absErr == nil {
+		fPath = abs
+	}
+	entry.Path = fPath
+	i.spool(uuid.New(), entry)
+}
+
+// spoolBlob persists the ingestion of a blob that failed with err, if the client has a spool.
+func (i *Ingestion) spoolBlob(blobURL string, size int64, props properties.All, err error) {
+	if i.spoolDir == "" || !spoolable(props, err) {
+		return
+	}
+	entry := newSpoolEntry(props, err)
+	entry.BlobURL, entry.Size = blobURL, size
+	i.spool(uuid.New(), entry)
+}
+
+// ReplaySpool ingests the ingestions persisted to the spool of WithSpool, oldest first, and removes each once it is queued. It
+// returns the number of ingestions replayed, and the errors of the ones that failed again, which are kept for a later replay.
+// It stops when ctx is done. Replays of the same client run one at a time, and the spool must not be replayed by more than one
+// client at once.
+func (i *Ingestion) ReplaySpool(ctx context.Context) (int, error) {
+	done, err := i.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	if i.spoolDir == "" {
+		return 0, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the client has no spool (hint: use WithSpool)").SetNoRetry()
+	}
+
+	i.spoolMu.Lock()
+	defer i.spoolMu.Unlock()
+
+	ids, err := i.spoolEntries()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	var errs []error
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			errs = append(errs, errors.ES(errors.OpFileIngest, errors.KTimeout, "the replay of the spool was stopped: %s", ctx.Err()))
+			break
+		}
+		if err := i.replaySpoolEntry(ctx, id); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		i.removeSpoolFiles(id)
+		replayed++
+	}
+	if len(errs) > 0 {
+		return replayed, errors.CombineErrors(errs...)
+	}
+	return replayed, nil
+}
+
+// spoolEntries returns the IDs of the entries of the spool, oldest first.
+func (i *Ingestion) spoolEntries() ([]uuid.UUID, error) {
+	files, err := os.ReadDir(i.spoolDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.E(errors.OpFileIngest, errors.KLocalFileSystem, err)
+	}
+
+	type spooled struct {
+		id      uuid.UUID
+		modTime time.Time
+	}
+	var entries []spooled
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, spoolEntryExt) {
+			continue
+		}
+		id, err := uuid.Parse(strings.TrimSuffix(name, spoolEntryExt))
+		if err != nil {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, spooled{id, info.ModTime()})
+	}
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].modTime.Before(entries[b].modTime) })
+
+	ids := make([]uuid.UUID, len(entries))
+	for n, e := range entries {
+		ids[n] = e.id
+	}
+	return ids, nil
+}
+
+// replaySpoolEntry uploads the source of the entry again, if it isn't a blob, and posts its ingestion to the queues of the
+// cluster.
+func (i *Ingestion) replaySpoolEntry(ctx context.Context, id uuid.UUID) error {
+	f, err := os.Open(i.spoolPath(id, spoolEntryExt))
+	if err != nil {
+		return errors.E(errors.OpFileIngest, errors.KLocalFileSystem, err)
+	}
+	var entry spoolEntry
+	err = gob.NewDecoder(f).Decode(&entry)
+	f.Close()
+	if err != nil {
+		return errors.ES(errors.OpFileIngest, errors.KLocalFileSystem, "could not read the spool entry %s: %s", id, err)
+	}
+
+	props := entry.props()
+	if props.Ingestion.Additional.AuthContext, err = i.mgr.AuthContext(ctx, props.Ingestion.DatabaseName); err != nil {
+		return err
+	}
+
+	blobURL, size := entry.BlobURL, entry.Size
+	switch {
+	case entry.Path != "":
+		if blobURL, size, err = i.fs.UploadLocalToBlob(ctx, entry.Path, props); err != nil {
+			return err
+		}
+	case entry.Data:
+		data, err := os.Open(i.spoolPath(id, spoolDataExt))
+		if err != nil {
+			return errors.E(errors.OpFileIngest, errors.KLocalFileSystem, err)
+		}
+		blobURL, size, err = i.fs.UploadReaderToBlob(ctx, data, props)
+		data.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The file is deleted once the entry is removed, so that a failure to delete it doesn't replay the entry again.
+	deleteFile := props.Source.DeleteLocalSource
+	props.Source.DeleteLocalSource = false
+	if err := i.ingestBlob(ctx, blobURL, size, props); err != nil {
+		return err
+	}
+	if deleteFile && entry.Path != "" {
+		defer func() {
+			if err := os.Remove(entry.Path); err != nil {
+				i.log().Warn("could not delete a local file replayed from the spool", "path", entry.Path, "error", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// ReplaySpool ingests the ingestions persisted to the spool of WithSpool with queued ingestion, as Ingestion.ReplaySpool does.
+func (m *Managed) ReplaySpool(ctx context.Context) (int, error) {
+	done, err := m.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	return m.queued.ReplaySpool(ctx)
+}
@@ -0,0 +1,229 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spoolRecorder is a storage that fails while down is set, and records the uploads and ingestions it accepts.
+type spoolRecorder struct {
+	mu   sync.Mutex
+	down bool
+	// queuesDown fails the ingestions of the blobs, but not the uploads.
+	queuesDown bool
+	uploaded   []string
+	ingested   []properties.All
+	blobs      []string
+}
+
+func (r *spoolRecorder) fs() resources.FsMock {
+	outage := func() error { return errors.ES(errors.OpFileIngest, errors.KBlobstore, "the storage is unavailable") }
+	return resources.FsMock{
+		OnLocal: func(ctx context.Context, from string, props properties.All) (string, int64, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.down {
+				return "", 0, outage()
+			}
+			b, err := os.ReadFile(from)
+			if err != nil {
+				return "", 0, err
+			}
+			r.uploaded = append(r.uploaded, string(b))
+			return "https://account.blob.core.windows.net/container/file", int64(len(b)), nil
+		},
+		OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+			// Read some of the content before failing, as an upload does.
+			buf := make([]byte, 2)
+			n, _ := io.ReadFull(reader, buf)
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.down {
+				return "", 0, outage()
+			}
+			rest, err := io.ReadAll(reader)
+			if err != nil {
+				return "", 0, err
+			}
+			r.uploaded = append(r.uploaded, string(buf[:n])+string(rest))
+			return "https://account.blob.core.windows.net/container/reader", int64(n + len(rest)), nil
+		},
+		OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.down || r.queuesDown {
+				return errors.ES(errors.OpFileIngest, errors.KBlobstore, "could not upload file to any queue")
+			}
+			r.blobs = append(r.blobs, from)
+			r.ingested = append(r.ingested, props)
+			return nil
+		},
+	}
+}
+
+func (r *spoolRecorder) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+func newSpoolingClient(t *testing.T, dir string) (*Ingestion, *spoolRecorder) {
+	ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", spoolDir: dir})
+	require.NoError(t, err)
+	rec := &spoolRecorder{down: true}
+	ingestion.fs = rec.fs()
+	return ingestion, rec
+}
+
+func spoolFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, filepath.Ext(e.Name()))
+	}
+	return names
+}
+
+func TestSpool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Readers", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "spool")
+		ingestion, rec := newSpoolingClient(t, dir)
+		WithSpoolReaders()(ingestion)
+
+		_, err := ingestion.FromReader(t.Context(), io.MultiReader(strings.NewReader("a,1\nb,2\n")), Table("Events"), Tags([]string{"t1"}))
+		require.Error(t, err)
+		_, err = ingestion.FromReader(t.Context(), strings.NewReader("c,3\n"))
+		require.Error(t, err)
+		assert.ElementsMatch(t, []string{".data", ".data", ".spool", ".spool"}, spoolFiles(t, dir))
+
+		// The replay fails again while the storage is down, and keeps the entries.
+		n, err := ingestion.ReplaySpool(t.Context())
+		assert.Error(t, err)
+		assert.Equal(t, 0, n)
+		assert.Len(t, spoolFiles(t, dir), 4)
+
+		rec.setDown(false)
+		n, err = ingestion.ReplaySpool(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Empty(t, spoolFiles(t, dir))
+		assert.ElementsMatch(t, []string{"a,1\nb,2\n", "c,3\n"}, rec.uploaded)
+
+		var events properties.All
+		for _, p := range rec.ingested {
+			if p.Ingestion.TableName == "Events" {
+				events = p
+			}
+		}
+		assert.Equal(t, "defaultDb", events.Ingestion.DatabaseName)
+		assert.Equal(t, properties.TagsList{"t1"}, events.Ingestion.Additional.Tags)
+		assert.Equal(t, CSV, events.Ingestion.Additional.Format)
+	})
+
+	t.Run("Readers that can't be read again", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		ingestion, rec := newSpoolingClient(t, dir)
+
+		// Without WithSpoolReaders, the content of the reader isn't written to the disk, so an upload that fails is lost.
+		_, err := ingestion.FromReader(t.Context(), io.MultiReader(strings.NewReader("a,1\n")))
+		require.Error(t, err)
+		assert.Empty(t, spoolFiles(t, dir))
+
+		// Once the data is uploaded, the blob is persisted by its URL.
+		rec.setDown(false)
+		rec.mu.Lock()
+		rec.queuesDown = true
+		rec.mu.Unlock()
+		_, err = ingestion.FromReader(t.Context(), io.MultiReader(strings.NewReader("b,2\n")))
+		require.Error(t, err)
+		assert.Equal(t, []string{".spool"}, spoolFiles(t, dir))
+
+		rec.mu.Lock()
+		rec.queuesDown = false
+		rec.mu.Unlock()
+		n, err := ingestion.ReplaySpool(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Empty(t, spoolFiles(t, dir))
+		assert.Equal(t, []string{"b,2\n"}, rec.uploaded)
+		assert.Equal(t, []string{"https://account.blob.core.windows.net/container/reader"}, rec.blobs)
+	})
+
+	t.Run("Files and blobs", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		ingestion, rec := newSpoolingClient(t, dir)
+		file := filepath.Join(t.TempDir(), "data.csv")
+		require.NoError(t, os.WriteFile(file, []byte("a,1\n"), 0o600))
+
+		_, err := ingestion.FromFile(t.Context(), file)
+		require.Error(t, err)
+		_, err = ingestion.FromBlob(t.Context(), "https://account.blob.core.windows.net/container/blob.csv?sig=secret", 10)
+		require.Error(t, err)
+		assert.ElementsMatch(t, []string{".spool", ".spool"}, spoolFiles(t, dir))
+
+		rec.setDown(false)
+		n, err := ingestion.ReplaySpool(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Empty(t, spoolFiles(t, dir))
+		assert.Equal(t, []string{"a,1\n"}, rec.uploaded)
+		assert.ElementsMatch(t, []string{"https://account.blob.core.windows.net/container/file",
+			"https://account.blob.core.windows.net/container/blob.csv?sig=secret"}, rec.blobs)
+		for _, p := range rec.ingested {
+			assert.NotEmpty(t, p.Ingestion.Additional.AuthContext)
+		}
+	})
+
+	t.Run("Successes and invalid arguments aren't persisted", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		ingestion, rec := newSpoolingClient(t, dir)
+		WithSpoolReaders()(ingestion)
+
+		_, err := ingestion.FromReader(t.Context(), io.MultiReader(strings.NewReader("a,1\n")), SourceID(uuid.Nil))
+		require.Error(t, err)
+		rec.setDown(false)
+		_, err = ingestion.FromReader(t.Context(), io.MultiReader(strings.NewReader("a,1\n")))
+		require.NoError(t, err)
+		assert.Empty(t, spoolFiles(t, dir))
+
+		n, err := ingestion.ReplaySpool(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+	})
+
+	t.Run("No spool", func(t *testing.T) {
+		t.Parallel()
+
+		ingestion, _ := newSpoolingClient(t, "")
+		_, err := ingestion.ReplaySpool(t.Context())
+		e, ok := errors.GetKustoError(err)
+		require.True(t, ok)
+		assert.Equal(t, errors.KClientArgs, e.Kind)
+	})
+}
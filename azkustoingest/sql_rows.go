@@ -0,0 +1,181 @@
+package azkustoingest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// SQLRowsBatching sets how FromSQLRows groups rows into batches.
+type SQLRowsBatching struct {
+	// MaxSize is the size in bytes of the encoded rows of a batch. Defaults to 64MiB.
+	MaxSize int
+	// OnBatch, if set, is called after each batch is submitted, with the number of rows of the batch and the result or error of
+	// its submission. A failed batch then doesn't stop FromSQLRows.
+	OnBatch func(rows int, result *Result, err error)
+}
+
+// FromSQLRows ingests the rows of a database/sql query, such as to migrate a table of a relational store into Kusto, with the
+// client. The rows are encoded as CSV, whose columns are matched to the columns of the table by position, or as MultiJSON,
+// whose columns are matched by name, if the options set FileFormat(JSON) or FileFormat(MultiJSON). NULL values are empty in
+// CSV and null in JSON; timestamps are formatted in UTC, with RFC 3339; decimals are kept as the text the driver returns; and
+// binary values, of BLOB, BINARY and BYTEA columns or that aren't valid UTF-8, are encoded in base64.
+// The rows are grouped into batches, per batching, and each batch is submitted with FromReader as soon as it is full.
+// FromSQLRows reads rows to the end and closes it. It returns the results of the submitted batches, and unless
+// batching.OnBatch is set, it stops at the first batch that fails to be submitted, and returns its error.
+func FromSQLRows(ctx context.Context, client Ingestor, rows *sql.Rows, batching SQLRowsBatching, options ...FileOption) ([]*Result, error) {
+	if rows == nil {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the rows must not be nil").SetNoRetry()
+	}
+	defer rows.Close()
+
+	// The batches are made of the rows as they are read, so they don't wait for a delay.
+	b, err := newRecordBatcher[*sql.Rows](client, ChannelBatching{MaxSize: batching.MaxSize, OnBatch: batching.OnBatch}, options, CSV,
+		func(format DataFormat) (recordEncoder[*sql.Rows], error) {
+			return newSQLRowEncoder(rows, format)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return b.results, err
+		}
+		// The row is the current row of rows, which the encoder scans.
+		if err := b.add(ctx, rows); err != nil {
+			return b.results, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return b.results, errors.ES(errors.OpFileIngest, errors.KOther, "could not read the rows: %s", err)
+	}
+	err = b.flush(ctx)
+	return b.results, err
+}
+
+// newSQLRowEncoder returns the encoder of the current row of rows into format, which is given rows itself as the record.
+func newSQLRowEncoder(rows *sql.Rows, format DataFormat) (recordEncoder[*sql.Rows], error) {
+	if format != CSV && format != JSON && format != MultiJSON {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "rows can only be ingested as CSV, JSON or MultiJSON, not %s", format).SetNoRetry()
+	}
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, errors.ES(errors.OpFileIngest, errors.KOther, "could not read the columns of the rows: %s", err)
+	}
+
+	binary := make([]bool, len(columns))
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		binary[i] = isBinarySQLType(c.DatabaseTypeName())
+		names[i] = c.Name()
+	}
+	raw := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+
+	if format == CSV {
+		record := make([]string, len(columns))
+		return func(buf *bytes.Buffer, rows *sql.Rows) error {
+			if err := rows.Scan(dest...); err != nil {
+				return errors.ES(errors.OpFileIngest, errors.KOther, "could not scan a row: %s", err)
+			}
+			for i, v := range raw {
+				if v == nil {
+					record[i] = ""
+					continue
+				}
+				s, err := csvValue(reflect.ValueOf(sqlValue(v, binary[i])))
+				if err != nil {
+					return errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not encode column %s of a row as CSV: %s", names[i], err).SetNoRetry()
+				}
+				record[i] = s
+			}
+			w := csv.NewWriter(buf)
+			if err := w.Write(record); err != nil {
+				return errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not encode a row as CSV: %s", err).SetNoRetry()
+			}
+			w.Flush()
+			return w.Error()
+		}, nil
+	}
+
+	return func(buf *bytes.Buffer, rows *sql.Rows) error {
+		if err := rows.Scan(dest...); err != nil {
+			return errors.ES(errors.OpFileIngest, errors.KOther, "could not scan a row: %s", err)
+		}
+		record := make(map[string]interface{}, len(columns))
+		for i, v := range raw {
+			v = sqlValue(v, binary[i])
+			if t, ok := v.(time.Time); ok {
+				v = t.UTC().Format(time.RFC3339Nano)
+			}
+			record[names[i]] = v
+		}
+		b, err := json.Marshal(record)
+		if err != nil {
+			return errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not encode a row as JSON: %s", err).SetNoRetry()
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+		return nil
+	}, nil
+}
+
+// isBinarySQLType reports whether a column of the database type holds binary data rather than text.
+func isBinarySQLType(databaseType string) bool {
+	t := strings.ToUpper(databaseType)
+	for _, binary := range []string{"BLOB", "BINARY", "BYTEA", "IMAGE"} {
+		if strings.Contains(t, binary) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlValue returns the value a driver scanned, as Kusto parses it: the bytes of binary columns, or that aren't valid UTF-8, are
+// encoded in base64, and the bytes of other columns, such as decimals, are text; NaN and infinite floats, which JSON can't
+// encode, are the strings Kusto parses them from.
+func sqlValue(v interface{}, binary bool) interface{} {
+	switch x := v.(type) {
+	case []byte:
+		if binary || !utf8.Valid(x) {
+			return base64.StdEncoding.EncodeToString(x)
+		}
+		return string(x)
+	case float32:
+		if s, ok := nonFiniteFloat(float64(x)); ok {
+			return s
+		}
+	case float64:
+		if s, ok := nonFiniteFloat(x); ok {
+			return s
+		}
+	}
+	return v
+}
+
+// nonFiniteFloat returns the string Kusto parses a NaN or infinite float from, and whether f is one.
+func nonFiniteFloat(f float64) (string, bool) {
+	switch {
+	case math.IsNaN(f):
+		return "NaN", true
+	case math.IsInf(f, 1):
+		return "Infinity", true
+	case math.IsInf(f, -1):
+		return "-Infinity", true
+	}
+	return "", false
+}
@@ -0,0 +1,171 @@
+package azkustoingest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQLDriver is a database/sql driver whose queries all return the rows of fakeSQLColumns and fakeSQLRows.
+type fakeSQLDriver struct{}
+
+var (
+	fakeSQLColumns = []string{"id", "name", "price", "created", "payload", "score"}
+	fakeSQLTypes   = []string{"BIGINT", "VARCHAR", "DECIMAL", "TIMESTAMP", "BLOB", "DOUBLE"}
+	fakeSQLRows    = [][]driver.Value{
+		{int64(1), "a,b", []byte("12.50"), time.Date(2024, 1, 2, 3, 4, 5, 600, time.FixedZone("", 3600)), []byte{0xff, 0x00}, 1.5},
+		{int64(2), nil, nil, nil, nil, math.Inf(1)},
+	}
+	registerFakeSQLDriver sync.Once
+)
+
+func (fakeSQLDriver) Open(string) (driver.Conn, error) {
+	return fakeSQLConn{}, nil
+}
+
+type fakeSQLConn struct{}
+
+func (fakeSQLConn) Prepare(string) (driver.Stmt, error) {
+	return fakeSQLStmt{}, nil
+}
+
+func (fakeSQLConn) Close() error {
+	return nil
+}
+
+func (fakeSQLConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+type fakeSQLStmt struct{}
+
+func (fakeSQLStmt) Close() error {
+	return nil
+}
+
+func (fakeSQLStmt) NumInput() int {
+	return 0
+}
+
+func (fakeSQLStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (fakeSQLStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeSQLResult{}, nil
+}
+
+type fakeSQLResult struct {
+	next int
+}
+
+func (r *fakeSQLResult) Columns() []string {
+	return fakeSQLColumns
+}
+
+func (r *fakeSQLResult) ColumnTypeDatabaseTypeName(i int) string {
+	return fakeSQLTypes[i]
+}
+
+func (r *fakeSQLResult) Close() error {
+	return nil
+}
+
+func (r *fakeSQLResult) Next(dest []driver.Value) error {
+	if r.next == len(fakeSQLRows) {
+		return io.EOF
+	}
+	copy(dest, fakeSQLRows[r.next])
+	r.next++
+	return nil
+}
+
+func queryFakeSQL(t *testing.T) *sql.Rows {
+	registerFakeSQLDriver.Do(func() {
+		sql.Register("fakesql", fakeSQLDriver{})
+	})
+	db, err := sql.Open("fakesql", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	rows, err := db.QueryContext(t.Context(), "SELECT * FROM products")
+	require.NoError(t, err)
+	return rows
+}
+
+func TestFromSQLRows(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CSV by default", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{}
+		results, err := FromSQLRows(t.Context(), rec, queryFakeSQL(t), SQLRowsBatching{})
+		require.NoError(t, err)
+
+		assert.Len(t, results, 1)
+		assert.Equal(t, []string{"1,\"a,b\",12.50,2024-01-02T02:04:05.0000006Z,/wA=,1.5\n2,,,,,Infinity\n"}, rec.batches)
+		assert.Equal(t, []DataFormat{CSV}, rec.formats)
+	})
+
+	t.Run("MultiJSON, batched by size", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{}
+		var batches []int
+		results, err := FromSQLRows(t.Context(), rec, queryFakeSQL(t), SQLRowsBatching{
+			MaxSize: 1,
+			OnBatch: func(rows int, result *Result, err error) {
+				assert.NoError(t, err)
+				batches = append(batches, rows)
+			},
+		}, FileFormat(MultiJSON))
+		require.NoError(t, err)
+
+		assert.Len(t, results, 2)
+		assert.Equal(t, []int{1, 1}, batches)
+		assert.Equal(t, []string{
+			`{"created":"2024-01-02T02:04:05.0000006Z","id":1,"name":"a,b","payload":"/wA=","price":"12.50","score":1.5}` + "\n",
+			`{"created":null,"id":2,"name":null,"payload":null,"price":null,"score":"Infinity"}` + "\n",
+		}, rec.batches)
+		assert.Equal(t, []DataFormat{MultiJSON, MultiJSON}, rec.formats)
+	})
+
+	t.Run("A failed batch stops the ingestion", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &batchRecorder{err: errors.ES(errors.OpFileIngest, errors.KBlobstore, "unavailable")}
+		results, err := FromSQLRows(t.Context(), rec, queryFakeSQL(t), SQLRowsBatching{MaxSize: 1})
+		require.ErrorContains(t, err, "unavailable")
+		assert.Empty(t, results)
+		assert.Len(t, rec.batches, 1)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := FromSQLRows(t.Context(), &batchRecorder{}, queryFakeSQL(t), SQLRowsBatching{}, FileFormat(Parquet))
+		require.ErrorContains(t, err, "can only be ingested as CSV, JSON or MultiJSON")
+
+		_, err = FromSQLRows(t.Context(), nil, queryFakeSQL(t), SQLRowsBatching{})
+		require.ErrorContains(t, err, "must not be nil")
+	})
+
+	t.Run("Context done", func(t *testing.T) {
+		t.Parallel()
+
+		rows := queryFakeSQL(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err := FromSQLRows(ctx, &batchRecorder{}, rows, SQLRowsBatching{})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,118 @@
+package azkustoingest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+// newStagingStorage builds the staging storage of the user, with the HTTP client of the ingestion.
+type newStagingStorage func(httpClient *http.Client) (*queued.StagingStorage, error)
+
+// WithStagingStorage configures the queued and managed clients to upload data to a container of the user, given by its URL,
+// instead of the temporary containers of the cluster, such as for compliance rules that forbid writing data to storage accounts
+// managed by Microsoft. The data is uploaded with the shared key credential, and the service reads it with a SAS valid for
+// sasValidity. The blobs aren't deleted once ingested, so the container should have a lifecycle management policy that does.
+func WithStagingStorage(containerURL string, cred *azblob.SharedKeyCredential, sasValidity time.Duration) Option {
+	return func(s *Ingestion) {
+		s.stagingStorage = func(httpClient *http.Client) (*queued.StagingStorage, error) {
+			if cred == nil || sasValidity <= 0 {
+				return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "WithStagingStorage requires a credential and a positive SAS validity").SetNoRetry()
+			}
+			serviceURL, container, err := parseContainerURL(containerURL)
+			if err != nil {
+				return nil, err
+			}
+			client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, blobClientOptions(httpClient))
+			if err != nil {
+				return nil, errors.E(errors.OpFileIngest, errors.KBlobstore, err)
+			}
+			return &queued.StagingStorage{
+				Client:    client,
+				Container: container,
+				Authorize: func(blobURL string) (string, error) {
+					return signBlobURL(cred, sasValidity, blobURL, sas.BlobPermissions{Read: true})
+				},
+			}, nil
+		}
+	}
+}
+
+// WithStagingStorageIdentity is like WithStagingStorage, but uploads data with a token credential, and the service reads it as the
+// managed identity, "system" or the object ID of a user assigned identity. The managed identity policy of the cluster must allow
+// the identity for native ingestion, and the identity must be allowed to read the blobs of the container.
+func WithStagingStorageIdentity(containerURL string, cred azcore.TokenCredential, managedIdentity string) Option {
+	return func(s *Ingestion) {
+		s.stagingStorage = func(httpClient *http.Client) (*queued.StagingStorage, error) {
+			if cred == nil || managedIdentity == "" {
+				return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "WithStagingStorageIdentity requires a credential and a managed identity").SetNoRetry()
+			}
+			serviceURL, container, err := parseContainerURL(containerURL)
+			if err != nil {
+				return nil, err
+			}
+			client, err := azblob.NewClient(serviceURL, cred, blobClientOptions(httpClient))
+			if err != nil {
+				return nil, errors.E(errors.OpFileIngest, errors.KBlobstore, err)
+			}
+			return &queued.StagingStorage{
+				Client:    client,
+				Container: container,
+				Authorize: func(blobURL string) (string, error) {
+					return blobURL + ";managed_identity=" + managedIdentity, nil
+				},
+			}, nil
+		}
+	}
+}
+
+// WithStorageCredential configures the queued and managed clients to authorize with the credential the uploads to the temporary
+// containers of the cluster and the messages to its queues, when the cluster returns them without a SAS, as it does where the
+// use of SAS is disabled by policy. The identity of the credential must be allowed to write the blobs of the containers and the
+// messages of the queues. Resources that have a SAS keep using it.
+func WithStorageCredential(cred azcore.TokenCredential) Option {
+	return func(s *Ingestion) {
+		s.storageCredential = cred
+	}
+}
+
+// parseContainerURL splits the URL of a container into the URL of its storage account and its name.
+func parseContainerURL(containerURL string) (string, string, error) {
+	parts, err := azblob.ParseURL(containerURL)
+	if err != nil || parts.Host == "" || parts.ContainerName == "" || parts.BlobName != "" {
+		return "", "", errors.ES(errors.OpFileIngest, errors.KClientArgs, "%q is not the URL of a storage container", containerURL).SetNoRetry()
+	}
+	return parts.Scheme + "://" + parts.Host, parts.ContainerName, nil
+}
+
+func blobClientOptions(httpClient *http.Client) *azblob.ClientOptions {
+	return &azblob.ClientOptions{ClientOptions: azcore.ClientOptions{
+		Transport: httpClient,
+		Retry:     policy.RetryOptions{MaxRetries: queued.BlockMaxRetries},
+	}}
+}
+
+// signBlobURL adds a SAS signed with cred, with the permissions, valid for validity, to the URL of the blob.
+func signBlobURL(cred *azblob.SharedKeyCredential, validity time.Duration, blobURL string, perms sas.BlobPermissions) (string, error) {
+	parts, err := sas.ParseURL(blobURL)
+	if err != nil {
+		return "", errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not parse the blob URL: %s", err).SetNoRetry()
+	}
+	parts.SAS, err = sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		ExpiryTime:    time.Now().UTC().Add(validity),
+		Permissions:   perms.String(),
+		ContainerName: parts.ContainerName,
+		BlobName:      parts.BlobName,
+	}.SignWithSharedKey(cred)
+	if err != nil {
+		return "", errors.ES(errors.OpFileIngest, errors.KClientArgs, "could not sign the blob URL: %s", err).SetNoRetry()
+	}
+	return parts.String(), nil
+}
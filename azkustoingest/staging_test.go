@@ -0,0 +1,61 @@
+package azkustoingest
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagingStorage(t *testing.T) {
+	t.Parallel()
+
+	cred, err := azblob.NewSharedKeyCredential("mine", base64.StdEncoding.EncodeToString([]byte("key")))
+	require.NoError(t, err)
+
+	i := getOptions([]Option{WithStagingStorage("https://mine.blob.core.windows.net/staging", cred, time.Hour)})
+	staging, err := i.stagingStorage(http.DefaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, "staging", staging.Container)
+	assert.Equal(t, "https://mine.blob.core.windows.net", strings.TrimSuffix(staging.Client.URL(), "/"))
+
+	signed, err := staging.Authorize("https://mine.blob.core.windows.net/staging/data.csv.gz")
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/staging/data.csv.gz", u.Path)
+	assert.Equal(t, "r", u.Query().Get("sp"))
+	assert.NotEmpty(t, u.Query().Get("sig"))
+
+	i = getOptions([]Option{WithStagingStorageIdentity("https://mine.blob.core.windows.net/staging", fakeTokenCredential{}, "system")})
+	staging, err = i.stagingStorage(http.DefaultClient)
+	require.NoError(t, err)
+	authorized, err := staging.Authorize("https://mine.blob.core.windows.net/staging/data.csv.gz")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(authorized, "data.csv.gz;managed_identity=system"), authorized)
+
+	for _, containerURL := range []string{"https://mine.blob.core.windows.net", "https://mine.blob.core.windows.net/staging/blob", "not a url"} {
+		i = getOptions([]Option{WithStagingStorage(containerURL, cred, time.Hour)})
+		_, err = i.stagingStorage(http.DefaultClient)
+		assert.ErrorContains(t, err, "is not the URL of a storage container", containerURL)
+	}
+
+	i = getOptions([]Option{WithStagingStorage("https://mine.blob.core.windows.net/staging", nil, time.Hour)})
+	_, err = i.stagingStorage(http.DefaultClient)
+	assert.ErrorContains(t, err, "requires a credential")
+}
+
+type fakeTokenCredential struct{}
+
+func (fakeTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
@@ -0,0 +1,131 @@
+package azkustoingest
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/status"
+	"github.com/google/uuid"
+)
+
+// IngestionStatus is the status of the ingestion of a source, as reported by the service to the status table.
+type IngestionStatus struct {
+	SourceID  uuid.UUID
+	Status    StatusCode
+	Database  string
+	Table     string
+	UpdatedOn time.Time
+	// SourcePath is the path or URL of the source, without its SAS.
+	SourcePath string
+	// OperationID and ActivityID identify the ingestion in the service, such as in `.show ingestion failures`.
+	OperationID uuid.UUID
+	ActivityID  uuid.UUID
+	// ErrorCode, FailureStatus and Details describe the failure of a failed ingestion, such as "BadRequest_EmptyBlob".
+	ErrorCode                  string
+	FailureStatus              FailureStatusCode
+	Details                    string
+	OriginatesFromUpdatePolicy bool
+}
+
+func newIngestionStatus(r statusRecord) IngestionStatus {
+	path := r.IngestionSourcePath
+	if path == undefinedString {
+		path = ""
+	}
+	return IngestionStatus{
+		SourceID:                   r.IngestionSourceID,
+		Status:                     r.Status,
+		Database:                   r.Database,
+		Table:                      r.Table,
+		UpdatedOn:                  r.UpdatedOn,
+		SourcePath:                 path,
+		OperationID:                r.OperationID,
+		ActivityID:                 r.ActivityID,
+		ErrorCode:                  r.ErrorCode,
+		FailureStatus:              r.FailureStatus,
+		Details:                    r.Details,
+		OriginatesFromUpdatePolicy: r.OriginatesFromUpdatePolicy,
+	}
+}
+
+// Err returns nil if the ingestion succeeded or is still pending, or an error describing its failure otherwise. The error can
+// be inspected with GetIngestionStatus, GetErrorCode and IsRetryable.
+func (s IngestionStatus) Err() error {
+	if !s.Status.IsFinal() || s.Status.IsSuccess() {
+		return nil
+	}
+	r := newStatusRecord()
+	r.Status = s.Status
+	r.IngestionSourceID = s.SourceID
+	r.Database = s.Database
+	r.Table = s.Table
+	r.UpdatedOn = s.UpdatedOn
+	if s.SourcePath != "" {
+		r.IngestionSourcePath = s.SourcePath
+	}
+	r.OperationID = s.OperationID
+	r.ActivityID = s.ActivityID
+	r.ErrorCode = s.ErrorCode
+	r.FailureStatus = s.FailureStatus
+	r.Details = s.Details
+	r.OriginatesFromUpdatePolicy = s.OriginatesFromUpdatePolicy
+	return r
+}
+
+// StatusReporter reads the statuses of queued ingestions from the status table of the cluster, by the IDs of their sources,
+// as returned by Result.SourceID. Only the ingestions made with ReportResultToTable, or a ReportMethod
+// that reports to the table, are reported to it.
+type StatusReporter struct {
+	table status.TableClientReader
+}
+
+// StatusReporter returns a StatusReporter for the ingestions made with the client.
+func (i *Ingestion) StatusReporter() (*StatusReporter, error) {
+	tables, err := i.mgr.GetTables(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, errors.ES(errors.OpFileIngest, errors.KInternal, "the ingestion resources of the cluster have no status table")
+	}
+
+	client, err := status.NewTableClient(i.client.HttpClient(), *tables[0])
+	if err != nil {
+		return nil, errors.E(errors.OpFileIngest, errors.KInternal, err)
+	}
+	return &StatusReporter{table: client}, nil
+}
+
+// Status returns the status of the ingestion of the source with the given ID. The status is Pending until the service reports
+// the outcome of the ingestion.
+func (s *StatusReporter) Status(ctx context.Context, sourceID uuid.UUID) (IngestionStatus, error) {
+	if sourceID == uuid.Nil {
+		return IngestionStatus{}, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the source ID must be set (hint: use ReportResultToTable when ingesting)").SetNoRetry()
+	}
+
+	m, err := s.table.Read(ctx, sourceID.String())
+	if err != nil {
+		return IngestionStatus{}, errors.ES(errors.OpFileIngest, errors.KHTTPError, "could not read the ingestion status of source %s: %s", sourceID, err)
+	}
+
+	r := newStatusRecord()
+	r.FromMap(m)
+	if r.IngestionSourceID == uuid.Nil {
+		r.IngestionSourceID = sourceID
+	}
+	return newIngestionStatus(r), nil
+}
+
+// Statuses returns the statuses of the ingestions of the sources with the given IDs, in order.
+func (s *StatusReporter) Statuses(ctx context.Context, sourceIDs ...uuid.UUID) ([]IngestionStatus, error) {
+	statuses := make([]IngestionStatus, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		st, err := s.Status(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
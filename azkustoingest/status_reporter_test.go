@@ -0,0 +1,71 @@
+package azkustoingest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusReporter(t *testing.T) {
+	t.Parallel()
+
+	pending := uuid.New()
+	succeeded := uuid.New()
+	failed := uuid.New()
+	operation := uuid.New()
+
+	reporter := &StatusReporter{
+		table: TableClientReaderFunc(func(ctx context.Context, ingestionSourceID string) (map[string]interface{}, error) {
+			switch ingestionSourceID {
+			case pending.String():
+				return map[string]interface{}{"Status": string(Pending), "IngestionSourceId": pending.String()}, nil
+			case succeeded.String():
+				return map[string]interface{}{"Status": string(Succeeded), "Database": "db", "Table": "table"}, nil
+			case failed.String():
+				return map[string]interface{}{
+					"Status":        string(Failed),
+					"ErrorCode":     "BadRequest_EmptyBlob",
+					"FailureStatus": string(Permanent),
+					"Details":       "the blob is empty",
+					"OperationId":   operation.String(),
+				}, nil
+			}
+			return nil, fmt.Errorf("entity %s not found", ingestionSourceID)
+		}),
+	}
+
+	statuses, err := reporter.Statuses(t.Context(), pending, succeeded, failed)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, pending, statuses[0].SourceID)
+	assert.Equal(t, Pending, statuses[0].Status)
+	assert.NoError(t, statuses[0].Err())
+
+	assert.Equal(t, succeeded, statuses[1].SourceID)
+	assert.Equal(t, Succeeded, statuses[1].Status)
+	assert.Equal(t, "db", statuses[1].Database)
+	assert.Equal(t, "table", statuses[1].Table)
+	assert.NoError(t, statuses[1].Err())
+
+	assert.Equal(t, Failed, statuses[2].Status)
+	assert.Equal(t, "BadRequest_EmptyBlob", statuses[2].ErrorCode)
+	assert.Equal(t, Permanent, statuses[2].FailureStatus)
+	assert.Equal(t, "the blob is empty", statuses[2].Details)
+	assert.Equal(t, operation, statuses[2].OperationID)
+	err = statuses[2].Err()
+	require.Error(t, err)
+	code, _ := GetErrorCode(err)
+	assert.Equal(t, "BadRequest_EmptyBlob", code)
+	assert.False(t, IsRetryable(err))
+
+	_, err = reporter.Status(t.Context(), uuid.New())
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = reporter.Status(t.Context(), uuid.Nil)
+	assert.Error(t, err)
+}
@@ -6,14 +6,20 @@ import (
 	"encoding/json"
 	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
 	"io"
+	"net/url"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata"
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/logger"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/gzip"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type streamIngestor interface {
@@ -21,12 +27,36 @@ type streamIngestor interface {
 	StreamIngest(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error
 }
 
+// loggingStreamIngestor streams with the logger of the client in the context of the calls, which the connection logs to.
+type loggingStreamIngestor struct {
+	streamIngestor
+	logger azkustodata.Logger
+}
+
+func (l loggingStreamIngestor) StreamIngest(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+	return l.streamIngestor.StreamIngest(logger.NewContext(ctx, l.logger), db, table, payload, format, mappingName, clientRequestId, isBlobUri)
+}
+
 // Streaming provides data ingestion from external sources into Kusto.
 type Streaming struct {
 	db         string
 	table      string
 	client     QueryClient
 	streamConn streamIngestor
+
+	metricsHook func(database, table string, metrics IngestionMetrics)
+	// onRetry calls the OnRetry hooks of the interceptors of the client, or is nil.
+	onRetry func(ctx context.Context, retry int, err error)
+	// slow reports the slow ingestions of the client.
+	slow slowReporter
+	// tracer records the spans of the ingestions, if WithTracerProvider is set.
+	tracer trace.Tracer
+	// tableCreators are the creators of the tables of WithCreateTableIfNotExists, by table, and createdTables holds the tables
+	// they checked.
+	tableCreators map[string]tableCreator
+	createdTables sync.Map
+	// pending tracks the ingestions in progress, for Shutdown.
+	pending inflight
 }
 
 type blobUri struct {
@@ -45,15 +75,7 @@ func NewStreaming(kcsb *azkustodata.ConnectionStringBuilder, options ...Option)
 		kcsb = &newKcsb
 	}
 
-	var client *azkustodata.Client
-	var err error
-
-	if o.httpClient != nil {
-		client, err = azkustodata.New(kcsb, azkustodata.WithHttpClient(o.httpClient))
-	} else {
-		client, err = azkustodata.New(kcsb)
-	}
-
+	client, err := azkustodata.New(o.withLogger(kcsb), o.clientOptions()...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,10 +91,17 @@ func newStreamingFromClient(client QueryClient, o *Ingestion) (*Streaming, error
 	}
 
 	i := &Streaming{
-		db:         o.db,
-		table:      o.table,
-		client:     client,
-		streamConn: streamConn,
+		db:            o.db,
+		table:         o.table,
+		client:        client,
+		streamConn:    loggingStreamIngestor{streamIngestor: streamConn, logger: o.logger},
+		metricsHook:   o.metricsHook,
+		onRetry:       o.retryHook(errors.OpIngestStream),
+		slow:          o.slow,
+		tableCreators: o.tableCreators,
+	}
+	if o.tracerProvider != nil {
+		i.tracer = tracing.NewTracer(o.tracerProvider)
 	}
 
 	return i, nil
@@ -80,20 +109,93 @@ func newStreamingFromClient(client QueryClient, o *Ingestion) (*Streaming, error
 
 // FromFile allows uploading a data file for Kusto from either a local path or a blobstore URI path.
 // This method is thread-safe.
-func (i *Streaming) FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error) {
+func (i *Streaming) FromFile(ctx context.Context, fPath string, options ...FileOption) (result *Result, err error) {
+	done, err := i.pending.begin(errors.OpIngestStream)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := i.startSpan(ctx)
+	defer func() {
+		endSpan(span, result, err)
+		i.reportSlow(start, result, err)
+	}()
+
 	props := i.newProp()
 	file, err, local := prepFileAndProps(fPath, &props, options, StreamingClient)
 
 	if err != nil {
 		return nil, err
 	}
+	if err := ensureTable(ctx, i.client, i.tableCreators, &i.createdTables, &props); err != nil {
+		return nil, err
+	}
 
 	if !local {
-		return streamImpl(i.streamConn, ctx, generateBlobUriPayloadReader(fPath), props, true)
+		return i.reportMetrics(streamBlob(i.streamConn, ctx, fPath, props))
 	}
 
 	defer file.Close()
-	return streamImpl(i.streamConn, ctx, file, props, false)
+	return i.reportMetrics(streamWithRetries(i.streamConn, ctx, file, replayFile(file), props, false))
+}
+
+// FromBlob streams a blob that is already in Azure storage to Kusto: the service reads the blob itself, with the latency of
+// streaming ingestion, so small blobs are ingested without downloading them first. The blob is subject to the size limit of
+// streaming ingestion. Its format is inferred from its name, unless FileFormat is given. The URL must let the service read the
+// blob, such as with a SAS or a ";managed_identity=" suffix, unless SignBlobURL is given.
+// This method is thread-safe.
+func (i *Streaming) FromBlob(ctx context.Context, blobURL string, options ...FileOption) (result *Result, err error) {
+	done, err := i.pending.begin(errors.OpIngestStream)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := i.startSpan(ctx)
+	defer func() {
+		endSpan(span, result, err)
+		i.reportSlow(start, result, err)
+	}()
+
+	if u, err := url.Parse(blobURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, errors.ES(errors.OpIngestStream, errors.KClientArgs, "%q is not a blob URL (hint: use FromFile for local files)", blobURL).SetNoRetry()
+	}
+
+	props := i.newProp()
+	for _, option := range options {
+		if err := option.Run(&props, StreamingClient, FromBlob); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateFormat(errors.OpIngestStream, &props, blobURL); err != nil {
+		return nil, err
+	}
+	if err := ensureTable(ctx, i.client, i.tableCreators, &i.createdTables, &props); err != nil {
+		return nil, err
+	}
+	return i.reportMetrics(streamBlob(i.streamConn, ctx, blobURL, props))
+}
+
+// streamBlob has the service stream the blob, which it reads with the sourceKind=uri variant of streaming ingestion.
+func streamBlob(c streamIngestor, ctx context.Context, blobURL string, props properties.All) (*Result, error) {
+	if err := queued.CompleteFormatFromFileName(&props, blobURL); err != nil {
+		return nil, err
+	}
+	if err := validateStreamingFormat(&props); err != nil {
+		return nil, err
+	}
+
+	if props.Source.SignBlobURL != nil {
+		var err error
+		if blobURL, err = props.Source.SignBlobURL(blobURL); err != nil {
+			return nil, err
+		}
+	}
+	replay := func() (io.Reader, error) { return generateBlobUriPayloadReader(blobURL), nil }
+	return streamWithRetries(c, ctx, generateBlobUriPayloadReader(blobURL), replay, props, true)
 }
 
 // Returns the opened file, err, boolean indicator if its a local file
@@ -106,23 +208,40 @@ func prepFileAndProps(fPath string, props *properties.All, options []FileOption,
 		}
 	}
 
+	op := errors.OpFileIngest
+	if client == StreamingClient {
+		op = errors.OpIngestStream
+	}
+	if err := validateFormat(op, props, fPath); err != nil {
+		return nil, err, true
+	}
+
 	local, err := queued.IsLocalPath(fPath)
 	if err != nil {
 		return nil, err, local
 	}
 
 	if !local {
-		return nil, nil, false
+		// The format of a blob is inferred from its name as well, so managed ingestion knows whether it can be streamed.
+		return nil, queued.CompleteFormatFromFileName(props, fPath), false
 	}
 
 	props.Source.OriginalSource = fPath
 	compression := queued.EffectiveCompressionType(props, fPath)
+	if compression == ingestoptions.ZIP && client == StreamingClient {
+		return nil, errors.ES(op, errors.KClientArgs, "streaming ingestion doesn't support zip files (hint: use queued or managed ingestion)").SetNoRetry(), true
+	}
 	err = queued.CompleteFormatFromFileName(props, fPath)
 	if err != nil {
 		return nil, err, true
 	}
+	if client == StreamingClient {
+		if err := validateStreamingFormat(props); err != nil {
+			return nil, err, true
+		}
+	}
 
-	props.Source.DontCompress = !queued.ShouldCompress(props, compression)
+	props.Source.DontCompress = !streamCompress(props, compression)
 
 	file, err := os.Open(fPath)
 	if err != nil {
@@ -131,10 +250,24 @@ func prepFileAndProps(fPath string, props *properties.All, options []FileOption,
 	return file, nil, true
 }
 
-// FromReader allows uploading a data file for Kusto from an io.Reader. The content is uploaded to Blobstore and
-// ingested after all data in the reader is processed. Content should not use compression as the content will be
-// compressed with gzip. This method is thread-safe.
-func (i *Streaming) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+// FromReader streams the content of an io.Reader to Kusto. Uncompressed content is compressed with gzip while it is sent.
+// Content that is already compressed, as set with CompressionType, is sent as is, and rejected before sending it if it is
+// known to be over the 4MB limit of streaming ingestion, such as content read from a file or a bytes.Reader.
+// This method is thread-safe.
+func (i *Streaming) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (result *Result, err error) {
+	done, err := i.pending.begin(errors.OpIngestStream)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := i.startSpan(ctx)
+	defer func() {
+		endSpan(span, result, err)
+		i.reportSlow(start, result, err)
+	}()
+
 	props := i.newProp()
 
 	for _, prop := range options {
@@ -143,31 +276,95 @@ func (i *Streaming) FromReader(ctx context.Context, reader io.Reader, options ..
 			return nil, err
 		}
 	}
+	if err := validateFormat(errors.OpIngestStream, &props, ""); err != nil {
+		return nil, err
+	}
+	if err := validateStreamingFormat(&props); err != nil {
+		return nil, err
+	}
+	if props.Source.CompressionType == ingestoptions.ZIP {
+		return nil, errors.ES(errors.OpIngestStream, errors.KClientArgs, "streaming ingestion doesn't support zip content (hint: use queued or managed ingestion)").SetNoRetry()
+	}
+	if err := ensureTable(ctx, i.client, i.tableCreators, &i.createdTables, &props); err != nil {
+		return nil, err
+	}
 
-	return streamImpl(i.streamConn, ctx, reader, props, false)
+	return i.reportMetrics(streamWithRetries(i.streamConn, ctx, reader, replayReader(reader), props, false))
+}
+
+// streamCompress reports whether the payload must be compressed with gzip before it is streamed, as streaming ingestion expects
+// gzip content. Unlike queued ingestion, this includes the binary formats, such as Parquet, unless the payload already is.
+func streamCompress(props *properties.All, compression ingestoptions.CompressionType) bool {
+	if props.Source.DontCompress {
+		return false
+	}
+	if props.Source.CompressionType != ingestoptions.CTUnknown {
+		compression = props.Source.CompressionType
+	}
+	return compression == ingestoptions.CTUnknown || compression == ingestoptions.CTNone
+}
+
+// validateStreamingFormat returns an error if the format of the data can't be ingested with streaming ingestion.
+func validateStreamingFormat(props *properties.All) error {
+	if format := props.Ingestion.Additional.Format; !format.SupportsStreaming() {
+		return errors.ES(errors.OpIngestStream, errors.KClientArgs, "streaming ingestion doesn't support the %s format (hint: use queued or managed ingestion)", format).SetNoRetry()
+	}
+	return nil
 }
 
 func streamImpl(c streamIngestor, ctx context.Context, payload io.Reader, props properties.All, isBlobUri bool) (*Result, error) {
-	compress := queued.ShouldCompress(&props, ingestoptions.CTUnknown)
-	if compress && !isBlobUri {
-		payload = gzip.Compress(payload)
+	if props.Source.Metrics == nil {
+		props.Source.Metrics = &properties.Metrics{}
+	}
+	compress := streamCompress(&props, ingestoptions.CTUnknown)
+	var source, streamed *properties.CountingReader
+	if !isBlobUri {
+		size, ok := payloadSize(payload)
+		// The payload is sent as is, so a payload over the limit can be rejected before uploading any of it.
+		if !compress && ok && size > maxStreamingSize {
+			return nil, errors.ES(errors.OpIngestStream, errors.KClientArgs,
+				"the payload is %d bytes, over the %d bytes limit of streaming ingestion (hint: use queued or managed ingestion)", size, maxStreamingSize).SetNoRetry()
+		}
+		if !ok {
+			size = -1
+		}
+		source = &properties.CountingReader{R: props.TrackProgress(payload, size)}
+		payload = source
+		if compress {
+			props.ReportStage(properties.SCompressing)
+			payload = gzip.Compress(payload)
+		}
+		streamed = &properties.CountingReader{R: payload}
+		payload = streamed
 	}
 
 	if props.Ingestion.Additional.Format == DFUnknown {
 		props.Ingestion.Additional.Format = CSV
 	}
 
+	props.ReportStage(properties.SStreaming)
+	start := time.Now()
 	err := c.StreamIngest(ctx, props.Ingestion.DatabaseName, props.Ingestion.TableName, payload, props.Ingestion.Additional.Format,
 		props.Ingestion.Additional.IngestionMappingRef,
 		props.Streaming.ClientRequestId,
 		isBlobUri)
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, properties.NewCanceledError(ctx, errors.OpIngestStream, properties.SStreaming)
+		}
+		if httpErr, ok := err.(*errors.HttpError); ok {
+			return nil, httpErr
+		}
 		if e, ok := errors.GetKustoError(err); ok {
 			return nil, e
 		}
 		return nil, errors.E(errors.OpIngestStream, errors.KClientArgs, err)
 	}
+	props.RecordUpload(time.Since(start))
+	if source != nil {
+		props.RecordSizes(source.N(), streamed.N())
+	}
 
 	err = props.ApplyDeleteLocalSourceOption()
 	if err != nil {
@@ -181,6 +378,25 @@ func streamImpl(c streamIngestor, ctx context.Context, payload io.Reader, props
 	return result, nil
 }
 
+// payloadSize returns the number of bytes left to read from the payload, if it can be known without reading it.
+func payloadSize(payload io.Reader) (int64, bool) {
+	switch p := payload.(type) {
+	case interface{ Len() int }:
+		return int64(p.Len()), true
+	case *os.File:
+		info, err := p.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		offset, err := p.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		return info.Size() - offset, true
+	}
+	return 0, false
+}
+
 func (i *Streaming) newProp() properties.All {
 	return properties.All{
 		Ingestion: properties.Ingestion{
@@ -190,10 +406,19 @@ func (i *Streaming) newProp() properties.All {
 		Streaming: properties.Streaming{
 			ClientRequestId: "KGC.executeStreaming;" + uuid.New().String(),
 		},
+		ManagedStreaming: properties.ManagedStreaming{
+			Backoff: newStreamingBackoff(),
+			OnRetry: i.onRetry,
+		},
 	}
 }
 
+// Close waits for the ingestions in progress, and releases the client. Use Shutdown to bound the wait.
 func (i *Streaming) Close() error {
+	return i.Shutdown(context.Background())
+}
+
+func (i *Streaming) release() error {
 	return i.streamConn.Close()
 }
 
@@ -0,0 +1,136 @@
+package azkustoingest
+
+import (
+	"context"
+	goErrors "errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// StreamingError is returned when streaming ingestion fails, after retrying the throttling and transient errors of the
+// service. Use errors.As to retrieve it; the error the service returned, such as an *errors.HttpError, is its wrapped error.
+type StreamingError struct {
+	errors.KustoError
+	// Attempts is the number of times the data was sent, retries included.
+	Attempts int
+	// Permanent reports that the error is neither throttling nor a transient failure of the service, such as when the data
+	// doesn't match the ingestion mapping, so it wasn't retried.
+	Permanent bool
+
+	err error
+}
+
+func newStreamingError(err error, attempts int) *StreamingError {
+	e := &StreamingError{Attempts: attempts, Permanent: !isTransientStreamingError(err), err: err}
+	if ke, ok := errors.GetKustoError(err); ok {
+		e.KustoError = *ke
+	} else {
+		e.KustoError = *errors.E(errors.OpIngestStream, errors.KHTTPError, err)
+	}
+	return e
+}
+
+func (e *StreamingError) Error() string {
+	return e.KustoError.Error()
+}
+
+func (e *StreamingError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.err
+}
+
+// isTransientStreamingError reports whether the service throttled the streaming ingestion, or failed it for a transient reason
+// of the engine, so that sending the same data again may succeed.
+func isTransientStreamingError(err error) bool {
+	var httpErr *errors.HttpError
+	if !goErrors.As(err, &httpErr) {
+		return false
+	}
+	if httpErr.IsThrottled() {
+		return true
+	}
+	return httpErr.StatusCode >= http.StatusInternalServerError && errors.Retry(err)
+}
+
+// replayFile returns the payloads of the retries of a local file, which is read again from its start.
+func replayFile(file io.ReadSeeker) func() (io.Reader, error) {
+	return func() (io.Reader, error) {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, errors.E(errors.OpIngestStream, errors.KLocalFileSystem, err)
+		}
+		return file, nil
+	}
+}
+
+// replayReader returns the payloads of the retries of a reader, which is read again from its current offset, or nil if the
+// reader isn't an io.Seeker, as its content can't then be sent twice.
+func replayReader(reader io.Reader) func() (io.Reader, error) {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() (io.Reader, error) {
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return nil, errors.E(errors.OpIngestStream, errors.KIO, err)
+		}
+		return reader, nil
+	}
+}
+
+// streamWithRetries streams the payload, and retries with an exponential backoff while the service throttles it or fails it for
+// a transient reason, the next attempt would start before the deadline of ctx, and replay returns the payload again; a nil
+// replay disables the retries. The errors of the service are returned as a *StreamingError.
+func streamWithRetries(c streamIngestor, ctx context.Context, payload io.Reader, replay func() (io.Reader, error), props properties.All, isBlobUri bool) (*Result, error) {
+	off := backoff.WithMaxRetries(props.ManagedStreaming.Backoff, retryCount)
+	off.Reset()
+
+	for attempts := 1; ; attempts++ {
+		result, err := streamImpl(c, ctx, payload, props, isBlobUri)
+		if err == nil {
+			result.attempts = attempts
+			return result, nil
+		}
+		var canceled *properties.CanceledError
+		if goErrors.As(err, &canceled) {
+			return nil, err
+		}
+		if ke, ok := errors.GetKustoError(err); ok && ke.Kind == errors.KClientArgs {
+			// The payload was rejected before sending it.
+			return nil, err
+		}
+		streamErr := newStreamingError(err, attempts)
+		if streamErr.Permanent || replay == nil {
+			return nil, streamErr
+		}
+
+		wait := off.NextBackOff()
+		if wait == backoff.Stop {
+			return nil, streamErr
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, streamErr
+		}
+		select {
+		case <-ctx.Done():
+			return nil, properties.NewCanceledError(ctx, errors.OpIngestStream, properties.SStreaming)
+		case <-time.After(wait):
+		}
+		if payload, err = replay(); err != nil {
+			return nil, err
+		}
+		if props.ManagedStreaming.OnRetry != nil {
+			props.ManagedStreaming.OnRetry(ctx, attempts, streamErr)
+		}
+	}
+}
@@ -3,16 +3,23 @@ package azkustoingest
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata"
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/gzip"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -182,7 +189,12 @@ func TestStreaming(t *testing.T) {
 				clientRequestId string, isBlobUri bool) error {
 				return errors.E(errors.OpIngestStream, errors.KHTTPError, fmt.Errorf("error"))
 			},
-			expectedError: errors.E(errors.OpIngestStream, errors.KHTTPError, fmt.Errorf("error")),
+			expectedError: &StreamingError{
+				KustoError: *errors.E(errors.OpIngestStream, errors.KHTTPError, fmt.Errorf("error")),
+				Attempts:   1,
+				Permanent:  true,
+				err:        errors.E(errors.OpIngestStream, errors.KHTTPError, fmt.Errorf("error")),
+			},
 		},
 	}
 
@@ -223,3 +235,288 @@ func TestStreaming(t *testing.T) {
 	}
 
 }
+
+func TestStreamingSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	called := 0
+	streaming := Streaming{
+		db:     "defaultDb",
+		table:  "defaultTable",
+		client: mockClient{endpoint: "https://test.kusto.windows.net"},
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				called++
+				_, err := io.Copy(io.Discard, payload)
+				return err
+			},
+		},
+	}
+
+	big := make([]byte, maxStreamingSize+1)
+
+	_, err := streaming.FromReader(ctx, bytes.NewReader(big), CompressionType(ingestoptions.GZIP))
+	assert.Error(t, err)
+	assert.False(t, errors.Retry(err))
+
+	file, err := os.CreateTemp(t.TempDir(), "*.csv.gz")
+	require.NoError(t, err)
+	_, err = file.Write(big)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	_, err = streaming.FromFile(ctx, file.Name())
+	assert.Error(t, err)
+	assert.Equal(t, 0, called)
+
+	// Uncompressed content is compressed while it is sent, so its size is unknown up-front.
+	_, err = streaming.FromReader(ctx, bytes.NewReader(big))
+	assert.NoError(t, err)
+	_, err = streaming.FromReader(ctx, bytes.NewReader(big[:mb]), CompressionType(ingestoptions.GZIP))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, called)
+}
+
+func TestStreamingProgress(t *testing.T) {
+	t.Parallel()
+
+	streaming := Streaming{
+		db:     "defaultDb",
+		table:  "defaultTable",
+		client: mockClient{endpoint: "https://test.kusto.windows.net"},
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				_, err := io.Copy(io.Discard, payload)
+				return err
+			},
+		},
+	}
+
+	var mu sync.Mutex
+	var stages []Stage
+	var sent, total int64
+	_, err := streaming.FromReader(t.Context(), strings.NewReader("a,b\nc,d\n"),
+		OnStage(func(stage Stage) {
+			mu.Lock()
+			defer mu.Unlock()
+			stages = append(stages, stage)
+		}),
+		OnProgress(func(s int64, t int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			sent, total = s, t
+		}),
+	)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []Stage{StageCompressing, StageStreaming}, stages)
+	assert.Equal(t, int64(8), sent)
+	assert.Equal(t, int64(8), total)
+}
+
+func TestStreamingFromBlob(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var sourceURIs []string
+	var formats []azkustodata.DataFormatForStreaming
+	streaming := Streaming{
+		db:     "defaultDb",
+		table:  "defaultTable",
+		client: mockClient{endpoint: "https://test.kusto.windows.net"},
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				assert.True(t, isBlobUri)
+				var uri blobUri
+				require.NoError(t, json.NewDecoder(payload).Decode(&uri))
+				mu.Lock()
+				defer mu.Unlock()
+				sourceURIs = append(sourceURIs, uri.SourceUri)
+				formats = append(formats, format)
+				return nil
+			},
+		},
+	}
+
+	const blobURL = "https://account.blob.core.windows.net/container/events.json.gz"
+	res, err := streaming.FromBlob(t.Context(), blobURL+"?sig=sas")
+	require.NoError(t, err)
+	assert.Equal(t, Succeeded, res.Status().Status)
+
+	// The format of a blob given to FromFile is inferred from its name as well.
+	_, err = streaming.FromFile(t.Context(), blobURL+"?sig=sas")
+	require.NoError(t, err)
+
+	cred, err := azblob.NewSharedKeyCredential("account", base64.StdEncoding.EncodeToString([]byte("key")))
+	require.NoError(t, err)
+	_, err = streaming.FromBlob(t.Context(), blobURL, SignBlobURL(cred, time.Hour))
+	require.NoError(t, err)
+
+	require.Len(t, sourceURIs, 3)
+	assert.Equal(t, blobURL+"?sig=sas", sourceURIs[0])
+	assert.Equal(t, blobURL+"?sig=sas", sourceURIs[1])
+	assert.True(t, strings.HasPrefix(sourceURIs[2], blobURL+"?"), sourceURIs[2])
+	assert.Contains(t, sourceURIs[2], "sig=")
+	assert.Equal(t, []azkustodata.DataFormatForStreaming{JSON, JSON, JSON}, formats)
+
+	_, err = streaming.FromBlob(t.Context(), "events.json")
+	assert.ErrorContains(t, err, "is not a blob URL")
+	_, err = streaming.FromBlob(t.Context(), "https://account.blob.core.windows.net/container/events.ss")
+	assert.ErrorContains(t, err, "doesn't support the sstream format")
+}
+
+func TestStreamingRetries(t *testing.T) {
+	t.Parallel()
+
+	throttled := func() error {
+		return errors.HTTP(errors.OpIngestStream, "429 Too Many Requests", 429,
+			io.NopCloser(strings.NewReader(`{"error": {"code": "TooManyRequests", "@permanent": false}}`)), "streaming ingestion")
+	}
+	mismatch := func() error {
+		return errors.HTTP(errors.OpIngestStream, "400 Bad Request", 400,
+			io.NopCloser(strings.NewReader(`{"error": {"code": "BadRequest_MappingMismatch", "@permanent": true}}`)), "streaming ingestion")
+	}
+	quick := func() FileOption {
+		off := backoff.NewExponentialBackOff()
+		off.InitialInterval = time.Millisecond
+		return backOff(off)
+	}
+
+	// newStreaming returns a client whose endpoint returns the errors in order, then succeeds, and records the payloads.
+	newStreaming := func(errs ...error) (*Streaming, *[]string) {
+		var mu sync.Mutex
+		payloads := &[]string{}
+		return &Streaming{
+			db:     "defaultDb",
+			table:  "defaultTable",
+			client: mockClient{endpoint: "https://test.kusto.windows.net"},
+			streamConn: fakeStreamIngestor{
+				onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+					b, err := io.ReadAll(payload)
+					if err != nil {
+						return err
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					*payloads = append(*payloads, string(b))
+					if len(*payloads) <= len(errs) {
+						return errs[len(*payloads)-1]
+					}
+					return nil
+				},
+			},
+		}, payloads
+	}
+
+	t.Run("Throttling is retried", func(t *testing.T) {
+		t.Parallel()
+
+		streaming, payloads := newStreaming(throttled(), throttled())
+		result, err := streaming.FromReader(t.Context(), strings.NewReader("a,b\n"), quick(), DontCompress())
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Attempts())
+		assert.Equal(t, []string{"a,b\n", "a,b\n", "a,b\n"}, *payloads)
+	})
+
+	t.Run("Retries are reported to the interceptors", func(t *testing.T) {
+		t.Parallel()
+
+		var retries []int
+		o := getOptions([]Option{WithInterceptor(azkustodata.Interceptor{
+			OnRetry: func(ctx context.Context, op errors.Op, retry int, err error) {
+				assert.Equal(t, errors.OpIngestStream, op)
+				var httpErr *errors.HttpError
+				require.ErrorAs(t, err, &httpErr)
+				assert.True(t, httpErr.IsThrottled())
+				retries = append(retries, retry)
+			},
+		}), WithInterceptor(azkustodata.Interceptor{})})
+		streaming, _ := newStreaming(throttled(), throttled())
+		streaming.onRetry = o.retryHook(errors.OpIngestStream)
+		_, err := streaming.FromReader(t.Context(), strings.NewReader("a,b\n"), quick(), DontCompress())
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, retries)
+	})
+
+	t.Run("Retries are limited", func(t *testing.T) {
+		t.Parallel()
+
+		streaming, payloads := newStreaming(throttled(), throttled(), throttled())
+		_, err := streaming.FromBlob(t.Context(), "https://account.blob.core.windows.net/container/blob.csv", quick())
+		var streamErr *StreamingError
+		require.ErrorAs(t, err, &streamErr)
+		assert.Equal(t, retryCount+1, streamErr.Attempts)
+		assert.False(t, streamErr.Permanent)
+		assert.Len(t, *payloads, retryCount+1)
+
+		var httpErr *errors.HttpError
+		require.ErrorAs(t, err, &httpErr)
+		assert.True(t, httpErr.IsThrottled())
+	})
+
+	t.Run("A permanent error fails at once", func(t *testing.T) {
+		t.Parallel()
+
+		streaming, payloads := newStreaming(mismatch())
+		_, err := streaming.FromReader(t.Context(), strings.NewReader("a,b\n"), quick())
+		var streamErr *StreamingError
+		require.ErrorAs(t, err, &streamErr)
+		assert.Equal(t, 1, streamErr.Attempts)
+		assert.True(t, streamErr.Permanent)
+		assert.Equal(t, errors.KHTTPError, streamErr.Kind)
+		assert.Len(t, *payloads, 1)
+	})
+
+	t.Run("A reader that can't be read again isn't retried", func(t *testing.T) {
+		t.Parallel()
+
+		streaming, payloads := newStreaming(throttled())
+		_, err := streaming.FromReader(t.Context(), io.MultiReader(strings.NewReader("a,b\n")), quick())
+		var streamErr *StreamingError
+		require.ErrorAs(t, err, &streamErr)
+		assert.False(t, streamErr.Permanent)
+		assert.Len(t, *payloads, 1)
+	})
+
+	t.Run("The deadline stops the retries", func(t *testing.T) {
+		t.Parallel()
+
+		streaming, payloads := newStreaming(throttled())
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+		// The default backoff waits a second before the first retry, past the deadline.
+		_, err := streaming.FromReader(ctx, strings.NewReader("a,b\n"))
+		var streamErr *StreamingError
+		require.ErrorAs(t, err, &streamErr)
+		assert.Equal(t, 1, streamErr.Attempts)
+		assert.Len(t, *payloads, 1)
+	})
+}
+
+func TestStreamingCanceled(t *testing.T) {
+	t.Parallel()
+
+	streaming := &Streaming{
+		db:     "defaultDb",
+		table:  "defaultTable",
+		client: mockClient{endpoint: "https://test.kusto.windows.net"},
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				<-ctx.Done()
+				return errors.E(errors.OpIngestStream, errors.KHTTPError, ctx.Err())
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err := streaming.FromReader(ctx, strings.NewReader("a,b\n"))
+
+	var canceled *CanceledError
+	require.ErrorAs(t, err, &canceled)
+	assert.Equal(t, StageStreaming, canceled.Stage)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "streaming the data")
+}
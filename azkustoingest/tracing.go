@@ -0,0 +1,69 @@
+package azkustoingest
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracerProvider records an OpenTelemetry span for each ingestion of the client, with the tracers of tp, such as the
+// TracerProvider of the OpenTelemetry SDK, and sends the trace context with the calls to the service in W3C Trace Context
+// headers. The spans carry the cluster, the database, the table, the source ID, the sizes of the data and the error codes of
+// failed ingestions, and the calls the client makes to the cluster to fetch its resources are recorded as their own spans.
+// Without it, no span is recorded.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Ingestion) {
+		s.tracerProvider = tp
+	}
+}
+
+// startSpan starts the span of a queued ingestion of the client.
+func (i *Ingestion) startSpan(ctx context.Context) (context.Context, trace.Span) {
+	return startSpan(ctx, i.tracer, errors.OpFileIngest, i.client, i.db, i.table)
+}
+
+// startSpan starts the span of a streaming ingestion of the client.
+func (i *Streaming) startSpan(ctx context.Context) (context.Context, trace.Span) {
+	return startSpan(ctx, i.tracer, errors.OpIngestStream, i.client, i.db, i.table)
+}
+
+// startSpan starts the span of an ingestion of the client, as a streaming ingestion until endSpan finds it was queued.
+func (m *Managed) startSpan(ctx context.Context) (context.Context, trace.Span) {
+	return m.streaming.startSpan(ctx)
+}
+
+// startSpan starts the span of an ingestion to the cluster of client, or returns a span that records nothing if tracer is nil.
+func startSpan(ctx context.Context, tracer trace.Tracer, op errors.Op, client QueryClient, db, table string) (context.Context, trace.Span) {
+	if tracer == nil {
+		return tracing.Start(ctx, nil, op, "", db)
+	}
+	return tracing.Start(ctx, tracer, op, client.Endpoint(), db, tracing.AttrTable.String(table))
+}
+
+// endSpan ends the span of a managed ingestion, whose operation is queued ingestion if it wasn't streamed.
+func (m *Managed) endSpan(span trace.Span, result *Result, err error) {
+	if result != nil && result.Attempts() == 0 {
+		tracing.SetOperation(span, errors.OpFileIngest)
+	}
+	endSpan(span, result, err)
+}
+
+// endSpan records the database, the table, the source ID and the sizes of the data of an ingestion in its span, and ends it.
+func endSpan(span trace.Span, result *Result, err error) {
+	if result != nil && span.IsRecording() {
+		metrics := result.Metrics()
+		span.SetAttributes(
+			tracing.AttrDatabase.String(result.record.Database),
+			tracing.AttrTable.String(result.record.Table),
+			tracing.AttrRawBytes.Int64(metrics.RawBytes),
+			tracing.AttrSentBytes.Int64(metrics.CompressedBytes),
+		)
+		if id := result.SourceID(); id != uuid.Nil {
+			span.SetAttributes(tracing.AttrSourceID.String(id.String()))
+		}
+	}
+	tracing.End(span, err)
+}
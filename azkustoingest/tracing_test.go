@@ -0,0 +1,122 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/tracing"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	newTracer := func() (*tracetest.SpanRecorder, trace.TracerProvider) {
+		recorder := tracetest.NewSpanRecorder()
+		return recorder, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	}
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		return attrs
+	}
+
+	t.Run("Queued", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, tp := newTracer()
+		ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", tracerProvider: tp})
+		require.NoError(t, err)
+		fail := false
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				if fail {
+					return "", 0, errors.ES(errors.OpFileIngest, errors.KBlobstore, "the upload failed")
+				}
+				props.RecordSizes(8, 4)
+				props.RecordUpload(time.Second)
+				return "https://account.blob.core.windows.net/container/blob", 8, nil
+			},
+			OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+				return nil
+			},
+		}
+
+		result, err := ingestion.FromReader(t.Context(), strings.NewReader("a,b\nc,d\n"), Table("Other"))
+		require.NoError(t, err)
+		fail = true
+		_, err = ingestion.FromReader(t.Context(), strings.NewReader("a,b\nc,d\n"))
+		require.Error(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		attrs := attributes(spans[0])
+		assert.Equal(t, "kusto.queued_ingest", spans[0].Name())
+		assert.Equal(t, "defaultDb", attrs[tracing.AttrDatabase].AsString())
+		assert.Equal(t, "Other", attrs[tracing.AttrTable].AsString())
+		assert.Equal(t, result.SourceID().String(), attrs[tracing.AttrSourceID].AsString())
+		assert.Equal(t, int64(8), attrs[tracing.AttrRawBytes].AsInt64())
+		assert.Equal(t, int64(4), attrs[tracing.AttrSentBytes].AsInt64())
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+		attrs = attributes(spans[1])
+		assert.Equal(t, "KBlobstore", attrs[tracing.AttrErrorKind].AsString())
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+	})
+
+	t.Run("Streaming and managed", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, tp := newTracer()
+		var traceparents []string
+		streaming, err := newStreamingFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", tracerProvider: tp})
+		require.NoError(t, err)
+		streaming.streamConn = fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				traceparents = append(traceparents, trace.SpanContextFromContext(ctx).TraceID().String())
+				_, err := io.ReadAll(payload)
+				return err
+			},
+		}
+		ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable", tracerProvider: tp})
+		require.NoError(t, err)
+		ingestion.fs = resources.FsMock{
+			OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+				return "https://account.blob.core.windows.net/container/blob", 8, nil
+			},
+		}
+		managed := newManagedFromClients(ingestion, streaming)
+
+		_, err = streaming.FromReader(t.Context(), strings.NewReader("a,b\n"))
+		require.NoError(t, err)
+		_, err = managed.FromReader(t.Context(), strings.NewReader("a,b\n"))
+		require.NoError(t, err)
+		// Data known to be over the limit of streaming ingestion is queued.
+		_, err = managed.FromReader(t.Context(), strings.NewReader("a,b\n"), RawDataSize(1<<40))
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 3)
+		assert.Equal(t, "kusto.streaming_ingest", spans[0].Name())
+		assert.Equal(t, "kusto.streaming_ingest", spans[1].Name())
+		assert.Equal(t, "kusto.queued_ingest", spans[2].Name())
+		assert.Equal(t, "queued_ingest", attributes(spans[2])[tracing.AttrOperation].AsString())
+		// The streaming calls carry the trace context of their spans.
+		assert.Equal(t, []string{spans[0].SpanContext().TraceID().String(), spans[1].SpanContext().TraceID().String()}, traceparents)
+	})
+}
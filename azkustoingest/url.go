@@ -0,0 +1,111 @@
+package azkustoingest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/utils"
+)
+
+// StageURL makes FromURL download the source and upload it to the staging storage of the cluster, even when the service could
+// read the URL itself, such as for an Amazon S3 bucket that only the client can reach.
+func StageURL() FileOption {
+	return option{
+		run: func(p *properties.All) error {
+			p.Source.StageURL = true
+			return nil
+		},
+		clientScopes: QueuedClient,
+		sourceScope:  FromBlob | FromReader,
+		name:         "StageURL",
+	}
+}
+
+// FromURL ingests a source from a URL, such as an Amazon S3 presigned URL or a public https URL, for pipelines that move data
+// from other clouds. Azure storage and Amazon S3 URLs are passed to the service on the ingestion message, as with FromBlob, so
+// that the service reads the data itself; an S3 URL must then be presigned, or end with an ";AwsCredentials=" suffix. Other
+// URLs, and all of them with StageURL, are downloaded by the client and uploaded to the staging storage of the cluster, as with
+// FromReader. size is the size of the data before compression, or 0 if it isn't known. The format and compression are inferred
+// from the path of the URL, unless given with FileFormat and CompressionType.
+// This method is thread-safe.
+func (i *Ingestion) FromURL(ctx context.Context, sourceURL string, size int64, options ...FileOption) (result *Result, err error) {
+	done, err := i.pending.begin(errors.OpFileIngest)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	ctx, span := i.startSpan(ctx)
+	defer func() {
+		endSpan(span, result, err)
+		i.reportSlow(start, result, err)
+	}()
+
+	u, err := url.Parse(sourceURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs,
+			"%q is not an http or https URL (hint: S3 objects are ingested by their https URL)", properties.RemoveQueryParamsFromUrl(sourceURL)).SetNoRetry()
+	}
+	if size < 0 {
+		return nil, errors.ES(errors.OpFileIngest, errors.KClientArgs, "the size of the source must not be negative, got %d", size).SetNoRetry()
+	}
+
+	// The options are run ahead to know whether to stage the source, and run again by the ingestion.
+	scratch := i.newProp()
+	for _, o := range options {
+		if err := o.Run(&scratch, QueuedClient, FromBlob|FromReader); err != nil {
+			return nil, err
+		}
+	}
+
+	if isServiceReadableURL(u) && !scratch.Source.StageURL {
+		return i.reportMetrics(i.fromBlob(ctx, sourceURL, size, options, i.newProp()))
+	}
+	return i.reportMetrics(i.fromPulledURL(ctx, u, size, options))
+}
+
+// fromPulledURL downloads the source at the URL, and ingests it as a reader.
+func (i *Ingestion) fromPulledURL(ctx context.Context, u *url.URL, size int64, options []FileOption) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.E(errors.OpFileIngest, errors.KClientArgs, err).SetNoRetry()
+	}
+	resp, err := i.client.HttpClient().Do(req)
+	if err != nil {
+		return nil, errors.E(errors.OpFileIngest, errors.KHTTPError, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.HTTP(errors.OpFileIngest, resp.Status, resp.StatusCode, resp.Body,
+			"could not download "+properties.RemoveQueryParamsFromUrl(u.String()))
+	}
+
+	props := i.newProp()
+	props.Ingestion.Additional.Format = InferFormatFromFileName(u.Path)
+	if compression := utils.CompressionDiscovery(u.Path); compression != ingestoptions.CTNone {
+		props.Source.CompressionType = compression
+	}
+	if size > 0 {
+		props.Ingestion.RawDataSize = size
+	}
+	return i.fromReader(ctx, resp.Body, options, props)
+}
+
+// isServiceReadableURL reports whether the service reads the source at the URL itself: Azure blob and data lake storage, and
+// Amazon S3.
+func isServiceReadableURL(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, azure := range []string{".blob.core.", ".dfs.core."} {
+		if strings.Contains(host, azure) {
+			return true
+		}
+	}
+	return strings.HasSuffix(host, ".amazonaws.com") && strings.Contains(host, "s3")
+}
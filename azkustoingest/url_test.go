@@ -0,0 +1,105 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromURL(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/exports/data.json.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("gzipped"))
+	}))
+	t.Cleanup(server.Close)
+
+	s3 := "https://bucket.s3.us-east-1.amazonaws.com/exports/data.csv?X-Amz-Signature=secret"
+
+	tests := []struct {
+		desc        string
+		url         string
+		options     []FileOption
+		wantBlob    string
+		wantStaged  string
+		wantFormat  DataFormat
+		wantCompr   ingestoptions.CompressionType
+		errContains string
+	}{
+		{desc: "S3 is passed through", url: s3, wantBlob: s3},
+		{desc: "Azure storage is passed through", url: "https://account.blob.core.windows.net/c/data.csv", wantBlob: "https://account.blob.core.windows.net/c/data.csv"},
+		{desc: "Other URLs are staged", url: server.URL + "/exports/data.json.gz", wantStaged: "gzipped", wantFormat: JSON, wantCompr: ingestoptions.GZIP},
+		{desc: "The format can be given", url: server.URL + "/exports/data.json.gz", options: []FileOption{FileFormat(MultiJSON)}, wantStaged: "gzipped", wantFormat: MultiJSON, wantCompr: ingestoptions.GZIP},
+		{desc: "Download failure", url: server.URL + "/missing.csv", errContains: "404"},
+		{desc: "Not an URL", url: "s3://bucket/data.csv", errContains: "not an http or https URL"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var blob, staged string
+			var stagedProps properties.All
+			ingestion, err := newFromClient(newMockClient(), &Ingestion{db: "defaultDb", table: "defaultTable"})
+			require.NoError(t, err)
+			ingestion.fs = resources.FsMock{
+				OnReader: func(ctx context.Context, reader io.Reader, props properties.All) (string, int64, error) {
+					b, err := io.ReadAll(reader)
+					staged, stagedProps = string(b), props
+					return "https://account.blob.core.windows.net/staging/blob", int64(len(b)), err
+				},
+				OnBlob: func(ctx context.Context, from string, fileSize int64, props properties.All) error {
+					if staged == "" {
+						blob = from
+					}
+					return nil
+				},
+			}
+
+			_, err = ingestion.FromURL(t.Context(), test.url, 0, test.options...)
+			if test.errContains != "" {
+				assert.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantBlob, blob)
+			assert.Equal(t, test.wantStaged, staged)
+			if test.wantStaged != "" {
+				assert.Equal(t, test.wantFormat, stagedProps.Ingestion.Additional.Format)
+				assert.Equal(t, test.wantCompr, stagedProps.Source.CompressionType)
+			}
+		})
+	}
+
+	t.Run("StageURL stages a readable URL", func(t *testing.T) {
+		t.Parallel()
+
+		assert.True(t, isServiceReadableURL(mustParseURL(t, s3)))
+		assert.False(t, isServiceReadableURL(mustParseURL(t, server.URL)))
+
+		props := properties.All{}
+		require.NoError(t, StageURL().Run(&props, QueuedClient, FromBlob))
+		assert.True(t, props.Source.StageURL)
+		assert.Error(t, StageURL().Run(&props, StreamingClient, FromBlob))
+	})
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	u, err := url.Parse(s)
+	require.NoError(t, err)
+	return u
+}